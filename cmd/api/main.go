@@ -45,6 +45,34 @@ func main() {
 	server.RegisterFiberRoutes()
 	server.BalanceFiberRoutes()
 	server.TransactionFiberRoutes()
+	server.ApprovalFiberRoutes()
+	server.AuditFiberRoutes()
+	server.WalletAdminFiberRoutes()
+	server.LimitFiberRoutes()
+	server.KYCFiberRoutes()
+	server.FraudFiberRoutes()
+	server.ScreeningFiberRoutes()
+	server.FXFiberRoutes()
+	server.FeeFiberRoutes()
+	server.WithdrawalFiberRoutes()
+	server.DisbursementFiberRoutes()
+	server.ScheduleFiberRoutes()
+	server.NotificationFiberRoutes()
+	server.PaymentRequestFiberRoutes()
+	server.SplitBillFiberRoutes()
+	server.QRISFiberRoutes()
+	server.MerchantFiberRoutes()
+	server.SettlementFiberRoutes()
+	server.DisputeFiberRoutes()
+	server.BillPaymentFiberRoutes()
+	server.PromotionFiberRoutes()
+	server.LoyaltyFiberRoutes()
+	server.ReferralFiberRoutes()
+
+	// Background jobs berhenti saat aplikasi selesai shutdown
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	server.StartBackgroundJobs(jobsCtx)
 
 	// Create a done channel to signal when the shutdown is complete
 	done := make(chan bool, 1)
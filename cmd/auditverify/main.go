@@ -0,0 +1,31 @@
+package main
+
+import (
+	"ewallet-engine/internal/audit"
+	"ewallet-engine/internal/database"
+	"log"
+	"os"
+
+	_ "github.com/joho/godotenv/autoload"
+)
+
+// auditverify menelusuri ulang hash chain audit_logs dan keluar dengan kode 1
+// jika ditemukan entri yang diubah atau dihapus.
+func main() {
+	db := database.New()
+	defer db.Close()
+
+	auditService := audit.NewAuditService(audit.NewAuditRepository(db.GetDB()))
+
+	result, err := auditService.Verify()
+	if err != nil {
+		log.Fatalf("Gagal memverifikasi audit log: %v", err)
+	}
+
+	if !result.Valid {
+		log.Printf("❌ Audit log rusak pada entri %d: %s (%d entri diperiksa)", result.BrokenAt, result.Reason, result.Checked)
+		os.Exit(1)
+	}
+
+	log.Printf("✅ Audit log valid, %d entri diperiksa", result.Checked)
+}
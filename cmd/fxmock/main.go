@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"ewallet-engine/internal/fx"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+)
+
+// fxmock adalah layanan kurs tiruan untuk pengembangan lokal. Ia menyajikan
+// tabel kurs dari FX_RATES_FILE di GET /rates dengan fluktuasi acak sebesar
+// FXMOCK_JITTER_BPS, dan bisa dipakai oleh server API dengan
+// FX_RATE_SOURCE=http FX_RATES_URL=http://localhost:8090.
+func main() {
+	ratesFile := os.Getenv("FX_RATES_FILE")
+	if ratesFile == "" {
+		ratesFile = "config/fx_rates.json"
+	}
+	raw, err := os.ReadFile(ratesFile)
+	if err != nil {
+		log.Fatalf("Gagal membaca file kurs %s: %v", ratesFile, err)
+	}
+
+	var table fx.RateTable
+	if err := json.Unmarshal(raw, &table); err != nil {
+		log.Fatalf("Format file kurs tidak valid: %v", err)
+	}
+
+	jitterBps, _ := strconv.Atoi(os.Getenv("FXMOCK_JITTER_BPS"))
+
+	port := os.Getenv("FXMOCK_PORT")
+	if port == "" {
+		port = "8090"
+	}
+
+	http.HandleFunc("/rates", func(w http.ResponseWriter, r *http.Request) {
+		response := fx.RateTable{Base: table.Base, Rates: make(map[string]float64, len(table.Rates))}
+		for currency, rate := range table.Rates {
+			if jitterBps > 0 {
+				rate *= 1 + float64(rand.Intn(2*jitterBps+1)-jitterBps)/10000
+			}
+			response.Rates[currency] = rate
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	})
+
+	log.Printf("Mock layanan kurs berjalan di :%s", port)
+	log.Fatal(http.ListenAndServe(":"+port, nil))
+}
@@ -0,0 +1,137 @@
+package approvals
+
+import (
+	"errors"
+	"ewallet-engine/internal/audit"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ApprovalHandler struct {
+	service      ApprovalService
+	auditService audit.AuditService
+}
+
+func NewApprovalHandler(service ApprovalService, auditService audit.AuditService) *ApprovalHandler {
+	return &ApprovalHandler{service: service, auditService: auditService}
+}
+
+func (h *ApprovalHandler) SubmitHandler(c *fiber.Ctx) error {
+	makerID := c.Locals("user_id").(uint)
+
+	var request SubmitRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+	// Disbursement hanya diajukan lewat endpoint eksekusi batch supaya amount
+	// di payload selalu sama dengan total batch.
+	if request.OperationType == OperationDisbursement {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Disbursement diajukan melalui endpoint eksekusi batch"})
+	}
+
+	approval, err := h.service.Submit(makerID, request)
+	if err != nil {
+		if errors.Is(err, ErrDuplicateTarget) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"message": err.Error()})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionApprovalSubmitted,
+		TargetType: "approval_request",
+		TargetID:   fmt.Sprint(approval.ID),
+		After:      approvalSnapshot(approval),
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Permintaan persetujuan berhasil dibuat",
+		"data":    approval,
+	})
+}
+
+func (h *ApprovalHandler) ListHandler(c *fiber.Ctx) error {
+	status := ApprovalStatus(c.Query("status"))
+
+	approvals, err := h.service.ListRequests(status)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": approvals})
+}
+
+func (h *ApprovalHandler) GetHandler(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	approval, histories, err := h.service.GetRequest(uint(id))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"data":    approval,
+		"history": histories,
+	})
+}
+
+func (h *ApprovalHandler) ApproveHandler(c *fiber.Ctx) error {
+	return h.decide(c, h.service.Approve, audit.ActionApprovalApproved, "Permintaan disetujui dan dieksekusi")
+}
+
+func (h *ApprovalHandler) RejectHandler(c *fiber.Ctx) error {
+	return h.decide(c, h.service.Reject, audit.ActionApprovalRejected, "Permintaan ditolak")
+}
+
+func (h *ApprovalHandler) decide(c *fiber.Ctx, decision func(id uint, checkerID uint, note string) (*ApprovalRequest, error), action string, successMessage string) error {
+	checkerID := c.Locals("user_id").(uint)
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	var request DecisionRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+	}
+
+	approval, err := decision(uint(id), checkerID, request.Note)
+	if approval != nil {
+		_ = h.auditService.Record(audit.Entry{
+			Meta:       audit.FromContext(c),
+			Action:     action,
+			TargetType: "approval_request",
+			TargetID:   fmt.Sprint(approval.ID),
+			Before:     audit.Snapshot{"status": StatusPending},
+			After:      approvalSnapshot(approval),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+			"data":    approval,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": successMessage,
+		"data":    approval,
+	})
+}
+
+func approvalSnapshot(approval *ApprovalRequest) audit.Snapshot {
+	return audit.Snapshot{
+		"operation_type":  approval.OperationType,
+		"status":          approval.Status,
+		"payload":         approval.Payload,
+		"execution_error": approval.ExecutionError,
+	}
+}
@@ -0,0 +1,157 @@
+package approvals
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+type OperationType string
+
+const (
+	OperationManualCredit OperationType = "MANUAL_CREDIT"
+	OperationManualDebit  OperationType = "MANUAL_DEBIT"
+	OperationReversal     OperationType = "REVERSAL"
+	OperationPayout       OperationType = "PAYOUT"
+	// OperationDisbursement mengeksekusi batch disbursement; payload berisi
+	// batch_id, reference (batch_id ditambah nomor pengajuan) dan amount
+	// (total batch).
+	OperationDisbursement OperationType = "DISBURSEMENT"
+)
+
+type ApprovalStatus string
+
+const (
+	StatusPending  ApprovalStatus = "PENDING"
+	StatusApproved ApprovalStatus = "APPROVED"
+	// StatusExecuting diklaim sebelum executor dipanggil supaya operasi yang
+	// sama tidak dijalankan dua kali dan yang terputus bisa dipulihkan.
+	StatusExecuting ApprovalStatus = "EXECUTING"
+	StatusRejected  ApprovalStatus = "REJECTED"
+	StatusExpired   ApprovalStatus = "EXPIRED"
+	StatusExecuted  ApprovalStatus = "EXECUTED"
+	StatusFailed    ApprovalStatus = "FAILED"
+)
+
+// Payload menyimpan parameter operasi yang akan dieksekusi setelah disetujui.
+type Payload map[string]interface{}
+
+func (p Payload) Value() (driver.Value, error) {
+	return json.Marshal(p)
+}
+
+func (p *Payload) Scan(value interface{}) error {
+	if value == nil {
+		*p = make(Payload)
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("failed to unmarshal JSON")
+	}
+	return json.Unmarshal(bytes, p)
+}
+
+func (p Payload) String(key string) (string, error) {
+	value, ok := p[key].(string)
+	if !ok || value == "" {
+		return "", fmt.Errorf("payload %s wajib diisi", key)
+	}
+	return value, nil
+}
+
+func (p Payload) Float(key string) (float64, error) {
+	switch value := p[key].(type) {
+	case float64:
+		return value, nil
+	case int:
+		return float64(value), nil
+	case uint:
+		return float64(value), nil
+	case string:
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err == nil {
+			return parsed, nil
+		}
+	}
+	return 0, fmt.Errorf("payload %s harus berupa angka", key)
+}
+
+func (p Payload) Uint(key string) (uint, error) {
+	value, err := p.Float(key)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("payload %s harus berupa angka positif", key)
+	}
+	return uint(value), nil
+}
+
+// ApprovalRequest adalah operasi maker yang menunggu keputusan checker.
+// TargetKey berisi operation_type:reference; unique index-nya mencegah target
+// yang sama diajukan lagi, apa pun status permintaan sebelumnya.
+type ApprovalRequest struct {
+	ID             uint           `gorm:"primaryKey" json:"id"`
+	OperationType  OperationType  `gorm:"type:varchar(30);not null;index" json:"operation_type"`
+	Status         ApprovalStatus `gorm:"type:enum('PENDING','APPROVED','EXECUTING','REJECTED','EXPIRED','EXECUTED','FAILED');default:'PENDING';index" json:"status"`
+	Payload        Payload        `gorm:"type:json" json:"payload"`
+	Reason         string         `gorm:"type:varchar(255);not null" json:"reason"`
+	MakerID        uint           `gorm:"not null" json:"maker_id"`
+	CheckerID      *uint          `json:"checker_id,omitempty"`
+	CheckerNote    string         `gorm:"type:varchar(255)" json:"checker_note,omitempty"`
+	ExecutionError string         `gorm:"type:text" json:"execution_error,omitempty"`
+	TargetKey      string         `gorm:"type:varchar(150);uniqueIndex;not null" json:"-"`
+	ExpiresAt      time.Time      `gorm:"not null;index" json:"expires_at"`
+	DecidedAt      *time.Time     `json:"decided_at,omitempty"`
+	ExecutedAt     *time.Time     `json:"executed_at,omitempty"`
+	CreatedAt      time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// Reference adalah reference idempoten untuk mutasi yang dibuat executor
+// permintaan ini.
+func (a ApprovalRequest) Reference() string {
+	return fmt.Sprintf("APR-%d", a.ID)
+}
+
+// Approver mengembalikan checker yang menyetujui permintaan, atau maker bila
+// permintaan disetujui otomatis karena di bawah batas.
+func (a ApprovalRequest) Approver() uint {
+	if a.CheckerID != nil {
+		return *a.CheckerID
+	}
+	return a.MakerID
+}
+
+// ApprovalHistory adalah jejak audit setiap perubahan status ApprovalRequest.
+type ApprovalHistory struct {
+	ID                uint           `gorm:"primaryKey" json:"id"`
+	ApprovalRequestID uint           `gorm:"not null;index" json:"approval_request_id"`
+	ActorID           uint           `gorm:"not null" json:"actor_id"`
+	Action            string         `gorm:"type:varchar(30);not null" json:"action"`
+	FromStatus        ApprovalStatus `gorm:"type:varchar(20)" json:"from_status"`
+	ToStatus          ApprovalStatus `gorm:"type:varchar(20);not null" json:"to_status"`
+	Note              string         `gorm:"type:text" json:"note,omitempty"`
+	CreatedAt         time.Time      `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type SubmitRequest struct {
+	OperationType OperationType `json:"operation_type"`
+	Payload       Payload       `json:"payload"`
+	Reason        string        `json:"reason"`
+}
+
+type DecisionRequest struct {
+	Note string `json:"note"`
+}
+
+// Executor menjalankan operasi saldo yang sebenarnya setelah permintaan
+// disetujui. Eksekusi yang terputus dijalankan ulang oleh RecoverStuck, jadi
+// executor harus idempoten terhadap approval.Reference().
+type Executor func(approval ApprovalRequest) error
+
+// Closer dipanggil saat permintaan berakhir tanpa operasinya berhasil
+// dijalankan (REJECTED, EXPIRED atau FAILED), misalnya untuk melepas objek
+// yang ditahan selama menunggu persetujuan.
+type Closer func(approval ApprovalRequest)
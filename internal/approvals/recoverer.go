@@ -0,0 +1,37 @@
+package approvals
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// StartRecoverer secara berkala menandai permintaan PENDING yang kedaluwarsa
+// dan menjalankan ulang permintaan yang terputus saat dieksekusi sampai ctx
+// dibatalkan.
+func StartRecoverer(ctx context.Context, service ApprovalService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if expired, err := service.ExpireStale(); err != nil {
+				log.Printf("ERROR: Gagal menandai permintaan persetujuan kedaluwarsa: %v", err)
+			} else if expired > 0 {
+				log.Printf("SUCCESS: %d permintaan persetujuan kedaluwarsa", expired)
+			}
+
+			recovered, err := service.RecoverStuck()
+			if err != nil {
+				log.Printf("ERROR: Gagal memulihkan permintaan persetujuan: %v", err)
+				continue
+			}
+			if recovered > 0 {
+				log.Printf("SUCCESS: %d permintaan persetujuan dipulihkan", recovered)
+			}
+		}
+	}
+}
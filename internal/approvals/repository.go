@@ -0,0 +1,103 @@
+package approvals
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ApprovalRepository interface {
+	CreateRequest(request *ApprovalRequest) error
+	FindByID(id uint) (*ApprovalRequest, error)
+	ListRequests(status ApprovalStatus) ([]ApprovalRequest, error)
+	FindExpiredPending(now time.Time) ([]ApprovalRequest, error)
+	// FindStuck mengembalikan permintaan APPROVED atau EXECUTING yang tidak
+	// berubah sejak before.
+	FindStuck(before time.Time) ([]ApprovalRequest, error)
+	TransitionStatus(id uint, from ApprovalStatus, updates map[string]interface{}) (bool, error)
+	// ReclaimExecuting mengklaim ulang permintaan EXECUTING yang tidak berubah
+	// sejak before, sehingga hanya satu proses yang memulihkannya.
+	ReclaimExecuting(id uint, before time.Time) (bool, error)
+	CreateHistory(history *ApprovalHistory) error
+	FindHistory(requestID uint) ([]ApprovalHistory, error)
+}
+
+type approvalRepository struct {
+	DB *gorm.DB
+}
+
+func NewApprovalRepository(db *gorm.DB) ApprovalRepository {
+	return &approvalRepository{DB: db}
+}
+
+func (r *approvalRepository) CreateRequest(request *ApprovalRequest) error {
+	err := r.DB.Create(request).Error
+	if err != nil && (errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "Duplicate entry")) {
+		return ErrDuplicateTarget
+	}
+	return err
+}
+
+func (r *approvalRepository) FindByID(id uint) (*ApprovalRequest, error) {
+	var request ApprovalRequest
+	err := r.DB.First(&request, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (r *approvalRepository) ListRequests(status ApprovalStatus) ([]ApprovalRequest, error) {
+	var requests []ApprovalRequest
+	query := r.DB.Order("created_at DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&requests).Error
+	return requests, err
+}
+
+func (r *approvalRepository) FindExpiredPending(now time.Time) ([]ApprovalRequest, error) {
+	var requests []ApprovalRequest
+	err := r.DB.Where("status = ? AND expires_at <= ?", StatusPending, now).Find(&requests).Error
+	return requests, err
+}
+
+func (r *approvalRepository) FindStuck(before time.Time) ([]ApprovalRequest, error) {
+	var requests []ApprovalRequest
+	err := r.DB.Where("status IN ? AND updated_at <= ?", []ApprovalStatus{StatusApproved, StatusExecuting}, before).
+		Order("id ASC").Find(&requests).Error
+	return requests, err
+}
+
+// TransitionStatus hanya mengubah baris yang statusnya masih from, sehingga dua
+// approver yang memutuskan bersamaan tidak bisa sama-sama berhasil.
+func (r *approvalRepository) TransitionStatus(id uint, from ApprovalStatus, updates map[string]interface{}) (bool, error) {
+	result := r.DB.Model(&ApprovalRequest{}).Where("id = ? AND status = ?", id, from).Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *approvalRepository) ReclaimExecuting(id uint, before time.Time) (bool, error) {
+	result := r.DB.Model(&ApprovalRequest{}).
+		Where("id = ? AND status = ? AND updated_at <= ?", id, StatusExecuting, before).
+		Update("updated_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *approvalRepository) CreateHistory(history *ApprovalHistory) error {
+	return r.DB.Create(history).Error
+}
+
+func (r *approvalRepository) FindHistory(requestID uint) ([]ApprovalHistory, error) {
+	var histories []ApprovalHistory
+	err := r.DB.Where("approval_request_id = ?", requestID).Order("created_at ASC, id ASC").Find(&histories).Error
+	return histories, err
+}
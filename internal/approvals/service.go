@@ -0,0 +1,406 @@
+package approvals
+
+import (
+	"errors"
+	"ewallet-engine/internal/balance"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrDuplicateTarget = errors.New("operasi untuk target yang sama sudah pernah diajukan")
+
+const (
+	defaultApprovalTTL      = 24 * time.Hour
+	defaultPayoutLimit      = 10000000
+	defaultStuckAfter       = 15 * time.Minute
+	systemActorID      uint = 0
+)
+
+type ApprovalService interface {
+	RegisterExecutor(operation OperationType, executor Executor)
+	RegisterCloser(operation OperationType, closer Closer)
+	Submit(makerID uint, request SubmitRequest) (*ApprovalRequest, error)
+	Approve(id uint, checkerID uint, note string) (*ApprovalRequest, error)
+	Reject(id uint, checkerID uint, note string) (*ApprovalRequest, error)
+	GetRequest(id uint) (*ApprovalRequest, []ApprovalHistory, error)
+	ListRequests(status ApprovalStatus) ([]ApprovalRequest, error)
+	ExpireStale() (int, error)
+	RecoverStuck() (int, error)
+}
+
+type approvalService struct {
+	repo      ApprovalRepository
+	executors map[OperationType]Executor
+	closers   map[OperationType]Closer
+	ttl       time.Duration
+	// stuckAfter adalah lama permintaan APPROVED atau EXECUTING tidak berubah
+	// sebelum dianggap terputus dan dijalankan ulang.
+	stuckAfter time.Duration
+	// payoutLimits adalah batas auto-approve per mata uang. Mata uang tanpa
+	// batas selalu menunggu checker.
+	payoutLimits map[string]float64
+}
+
+func NewApprovalService(repo ApprovalRepository) ApprovalService {
+	ttl := defaultApprovalTTL
+	if hours, err := strconv.Atoi(os.Getenv("APPROVAL_TTL_HOURS")); err == nil && hours > 0 {
+		ttl = time.Duration(hours) * time.Hour
+	}
+	stuckAfter := defaultStuckAfter
+	if minutes, err := strconv.Atoi(os.Getenv("APPROVAL_STUCK_MINUTES")); err == nil && minutes > 0 {
+		stuckAfter = time.Duration(minutes) * time.Minute
+	}
+
+	// APPROVAL_PAYOUT_LIMIT berlaku untuk DefaultCurrency; mata uang lain
+	// memakai APPROVAL_PAYOUT_LIMIT_<KODE>, misalnya APPROVAL_PAYOUT_LIMIT_USD.
+	payoutLimits := map[string]float64{balance.DefaultCurrency: defaultPayoutLimit}
+	if limit, err := strconv.ParseFloat(os.Getenv("APPROVAL_PAYOUT_LIMIT"), 64); err == nil && limit >= 0 {
+		payoutLimits[balance.DefaultCurrency] = limit
+	}
+	for _, env := range os.Environ() {
+		key, value, _ := strings.Cut(env, "=")
+		code, ok := strings.CutPrefix(key, "APPROVAL_PAYOUT_LIMIT_")
+		if !ok {
+			continue
+		}
+		currency, err := balance.NormalizeCurrency(code)
+		if err != nil {
+			continue
+		}
+		if limit, err := strconv.ParseFloat(value, 64); err == nil && limit >= 0 {
+			payoutLimits[currency] = limit
+		}
+	}
+
+	return &approvalService{
+		repo:         repo,
+		executors:    make(map[OperationType]Executor),
+		closers:      make(map[OperationType]Closer),
+		ttl:          ttl,
+		stuckAfter:   stuckAfter,
+		payoutLimits: payoutLimits,
+	}
+}
+
+func (s *approvalService) RegisterExecutor(operation OperationType, executor Executor) {
+	s.executors[operation] = executor
+}
+
+func (s *approvalService) RegisterCloser(operation OperationType, closer Closer) {
+	s.closers[operation] = closer
+}
+
+func (s *approvalService) Submit(makerID uint, request SubmitRequest) (*ApprovalRequest, error) {
+	if _, ok := s.executors[request.OperationType]; !ok {
+		return nil, errors.New("jenis operasi tidak didukung")
+	}
+	if request.Reason == "" {
+		return nil, errors.New("alasan wajib diisi")
+	}
+	if request.Payload == nil {
+		request.Payload = make(Payload)
+	}
+
+	if request.OperationType != OperationReversal {
+		amount, err := request.Payload.Float("amount")
+		if err != nil {
+			return nil, err
+		}
+		if amount <= 0 {
+			return nil, errors.New("jumlah transaksi tidak valid")
+		}
+	}
+
+	target, err := request.Payload.String("reference")
+	if err != nil {
+		return nil, err
+	}
+
+	approval := ApprovalRequest{
+		OperationType: request.OperationType,
+		Status:        StatusPending,
+		Payload:       request.Payload,
+		Reason:        request.Reason,
+		MakerID:       makerID,
+		TargetKey:     fmt.Sprintf("%s:%s", request.OperationType, target),
+		ExpiresAt:     time.Now().Add(s.ttl),
+	}
+
+	if err := s.repo.CreateRequest(&approval); err != nil {
+		return nil, err
+	}
+	s.recordHistory(approval.ID, makerID, "SUBMITTED", "", StatusPending, request.Reason)
+
+	// Payout dan disbursement di bawah limit mata uangnya tidak butuh checker,
+	// langsung dieksekusi.
+	if request.OperationType == OperationPayout || request.OperationType == OperationDisbursement {
+		if s.withinPayoutLimit(request.Payload) {
+			ok, err := s.repo.TransitionStatus(approval.ID, StatusPending, map[string]interface{}{"status": StatusApproved})
+			if err != nil || !ok {
+				return nil, errors.New("gagal memproses payout")
+			}
+			s.recordHistory(approval.ID, systemActorID, "AUTO_APPROVED", StatusPending, StatusApproved, "payout di bawah limit persetujuan")
+			return s.execute(approval.ID, systemActorID)
+		}
+	}
+
+	return &approval, nil
+}
+
+func (s *approvalService) Approve(id uint, checkerID uint, note string) (*ApprovalRequest, error) {
+	approval, err := s.loadPendingForDecision(id, checkerID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	ok, err := s.repo.TransitionStatus(approval.ID, StatusPending, map[string]interface{}{
+		"status":       StatusApproved,
+		"checker_id":   checkerID,
+		"checker_note": note,
+		"decided_at":   now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("permintaan sudah diputuskan oleh approver lain")
+	}
+	s.recordHistory(approval.ID, checkerID, "APPROVED", StatusPending, StatusApproved, note)
+
+	return s.execute(approval.ID, checkerID)
+}
+
+func (s *approvalService) Reject(id uint, checkerID uint, note string) (*ApprovalRequest, error) {
+	approval, err := s.loadPendingForDecision(id, checkerID)
+	if err != nil {
+		return nil, err
+	}
+	if note == "" {
+		return nil, errors.New("catatan penolakan wajib diisi")
+	}
+
+	now := time.Now()
+	ok, err := s.repo.TransitionStatus(approval.ID, StatusPending, map[string]interface{}{
+		"status":       StatusRejected,
+		"checker_id":   checkerID,
+		"checker_note": note,
+		"decided_at":   now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("permintaan sudah diputuskan oleh approver lain")
+	}
+	s.recordHistory(approval.ID, checkerID, "REJECTED", StatusPending, StatusRejected, note)
+
+	rejected, err := s.repo.FindByID(approval.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.close(*rejected)
+	return rejected, nil
+}
+
+func (s *approvalService) GetRequest(id uint) (*ApprovalRequest, []ApprovalHistory, error) {
+	approval, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, nil, errors.New("permintaan persetujuan tidak ditemukan")
+	}
+
+	histories, err := s.repo.FindHistory(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return approval, histories, nil
+}
+
+func (s *approvalService) ListRequests(status ApprovalStatus) ([]ApprovalRequest, error) {
+	if _, err := s.ExpireStale(); err != nil {
+		log.Printf("ERROR: Gagal menandai permintaan persetujuan kedaluwarsa: %v", err)
+	}
+	return s.repo.ListRequests(status)
+}
+
+// ExpireStale menandai semua permintaan PENDING yang melewati ExpiresAt sebagai EXPIRED.
+func (s *approvalService) ExpireStale() (int, error) {
+	expired, err := s.repo.FindExpiredPending(time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, approval := range expired {
+		ok, err := s.repo.TransitionStatus(approval.ID, StatusPending, map[string]interface{}{"status": StatusExpired})
+		if err != nil {
+			return count, err
+		}
+		if ok {
+			s.recordHistory(approval.ID, systemActorID, "EXPIRED", StatusPending, StatusExpired, "")
+			approval.Status = StatusExpired
+			s.close(approval)
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (s *approvalService) loadPendingForDecision(id uint, checkerID uint) (*ApprovalRequest, error) {
+	approval, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, errors.New("permintaan persetujuan tidak ditemukan")
+	}
+
+	if approval.Status != StatusPending {
+		return nil, fmt.Errorf("permintaan sudah berstatus %s", approval.Status)
+	}
+
+	if approval.MakerID == checkerID {
+		return nil, errors.New("pembuat permintaan tidak boleh menyetujui permintaannya sendiri")
+	}
+
+	if time.Now().After(approval.ExpiresAt) {
+		ok, err := s.repo.TransitionStatus(approval.ID, StatusPending, map[string]interface{}{"status": StatusExpired})
+		if err == nil && ok {
+			s.recordHistory(approval.ID, systemActorID, "EXPIRED", StatusPending, StatusExpired, "")
+			approval.Status = StatusExpired
+			s.close(*approval)
+		}
+		return nil, errors.New("permintaan persetujuan sudah kedaluwarsa")
+	}
+
+	return approval, nil
+}
+
+// execute mengklaim permintaan APPROVED menjadi EXECUTING sebelum executor
+// dipanggil, sehingga approve dan pemulihan tidak menjalankannya bersamaan.
+func (s *approvalService) execute(id uint, actorID uint) (*ApprovalRequest, error) {
+	ok, err := s.repo.TransitionStatus(id, StatusApproved, map[string]interface{}{"status": StatusExecuting})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("permintaan sudah dieksekusi")
+	}
+	s.recordHistory(id, actorID, "EXECUTING", StatusApproved, StatusExecuting, "")
+	return s.run(id, actorID)
+}
+
+// run menjalankan executor untuk permintaan EXECUTING lalu mencatat hasilnya.
+func (s *approvalService) run(id uint, actorID uint) (*ApprovalRequest, error) {
+	approval, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	executor := s.executors[approval.OperationType]
+	execErr := executor(*approval)
+
+	now := time.Now()
+	updates := map[string]interface{}{"status": StatusExecuted, "executed_at": &now}
+	if execErr != nil {
+		log.Printf("ERROR: Eksekusi approval %d gagal: %v", approval.ID, execErr)
+		updates = map[string]interface{}{"status": StatusFailed, "execution_error": execErr.Error()}
+	}
+	ok, err := s.repo.TransitionStatus(approval.ID, StatusExecuting, updates)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("permintaan sudah diselesaikan proses lain")
+	}
+
+	if execErr != nil {
+		approval.Status = StatusFailed
+		approval.ExecutionError = execErr.Error()
+		s.recordHistory(approval.ID, actorID, "EXECUTION_FAILED", StatusExecuting, StatusFailed, execErr.Error())
+		s.close(*approval)
+		return approval, fmt.Errorf("eksekusi operasi gagal: %w", execErr)
+	}
+	approval.Status = StatusExecuted
+	approval.ExecutedAt = &now
+	s.recordHistory(approval.ID, actorID, "EXECUTED", StatusExecuting, StatusExecuted, "")
+	return approval, nil
+}
+
+// RecoverStuck menjalankan ulang permintaan yang terputus antara disetujui dan
+// selesai dieksekusi, misalnya karena server berhenti. Executor idempoten
+// terhadap approval.Reference(), jadi operasi yang sempat berjalan tidak
+// dibukukan dua kali.
+func (s *approvalService) RecoverStuck() (int, error) {
+	before := time.Now().Add(-s.stuckAfter)
+	stuck, err := s.repo.FindStuck(before)
+	if err != nil {
+		return 0, err
+	}
+
+	recovered := 0
+	for _, approval := range stuck {
+		if approval.Status == StatusExecuting {
+			ok, err := s.repo.ReclaimExecuting(approval.ID, before)
+			if err != nil {
+				log.Printf("ERROR: Gagal mengklaim ulang approval %d: %v", approval.ID, err)
+				continue
+			}
+			if !ok {
+				continue
+			}
+			_, err = s.run(approval.ID, systemActorID)
+			recovered++
+			if err != nil {
+				log.Printf("ERROR: Pemulihan approval %d gagal: %v", approval.ID, err)
+			}
+			continue
+		}
+
+		_, err := s.execute(approval.ID, systemActorID)
+		recovered++
+		if err != nil {
+			log.Printf("ERROR: Pemulihan approval %d gagal: %v", approval.ID, err)
+		}
+	}
+	return recovered, nil
+}
+
+// withinPayoutLimit memeriksa amount payload terhadap batas auto-approve mata
+// uangnya. Payload tanpa currency berarti DefaultCurrency.
+func (s *approvalService) withinPayoutLimit(payload Payload) bool {
+	amount, err := payload.Float("amount")
+	if err != nil {
+		return false
+	}
+	code, _ := payload.String("currency")
+	currency, err := balance.NormalizeCurrency(code)
+	if err != nil {
+		return false
+	}
+	limit, ok := s.payoutLimits[currency]
+	return ok && amount <= limit
+}
+
+func (s *approvalService) close(approval ApprovalRequest) {
+	if closer, ok := s.closers[approval.OperationType]; ok {
+		closer(approval)
+	}
+}
+
+func (s *approvalService) recordHistory(requestID uint, actorID uint, action string, from ApprovalStatus, to ApprovalStatus, note string) {
+	history := ApprovalHistory{
+		ApprovalRequestID: requestID,
+		ActorID:           actorID,
+		Action:            action,
+		FromStatus:        from,
+		ToStatus:          to,
+		Note:              note,
+	}
+	if err := s.repo.CreateHistory(&history); err != nil {
+		log.Printf("ERROR: Gagal menyimpan histori approval %d: %v", requestID, err)
+	}
+}
@@ -0,0 +1,324 @@
+package approvals
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type fakeApprovalRepository struct {
+	ApprovalRepository
+	requests  map[uint]*ApprovalRequest
+	histories []ApprovalHistory
+}
+
+func newFakeApprovalRepository() *fakeApprovalRepository {
+	return &fakeApprovalRepository{requests: make(map[uint]*ApprovalRequest)}
+}
+
+func (r *fakeApprovalRepository) CreateRequest(request *ApprovalRequest) error {
+	for _, existing := range r.requests {
+		if existing.TargetKey == request.TargetKey {
+			return ErrDuplicateTarget
+		}
+	}
+	request.ID = uint(len(r.requests) + 1)
+	request.UpdatedAt = time.Now()
+	stored := *request
+	r.requests[request.ID] = &stored
+	return nil
+}
+
+func (r *fakeApprovalRepository) FindByID(id uint) (*ApprovalRequest, error) {
+	request, ok := r.requests[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *request
+	return &copied, nil
+}
+
+func (r *fakeApprovalRepository) FindExpiredPending(now time.Time) ([]ApprovalRequest, error) {
+	var expired []ApprovalRequest
+	for _, request := range r.requests {
+		if request.Status == StatusPending && !request.ExpiresAt.After(now) {
+			expired = append(expired, *request)
+		}
+	}
+	return expired, nil
+}
+
+func (r *fakeApprovalRepository) FindStuck(before time.Time) ([]ApprovalRequest, error) {
+	var stuck []ApprovalRequest
+	for id := uint(1); id <= uint(len(r.requests)); id++ {
+		request := r.requests[id]
+		if (request.Status == StatusApproved || request.Status == StatusExecuting) && !request.UpdatedAt.After(before) {
+			stuck = append(stuck, *request)
+		}
+	}
+	return stuck, nil
+}
+
+func (r *fakeApprovalRepository) ReclaimExecuting(id uint, before time.Time) (bool, error) {
+	request, ok := r.requests[id]
+	if !ok || request.Status != StatusExecuting || request.UpdatedAt.After(before) {
+		return false, nil
+	}
+	request.UpdatedAt = time.Now()
+	return true, nil
+}
+
+func (r *fakeApprovalRepository) TransitionStatus(id uint, from ApprovalStatus, updates map[string]interface{}) (bool, error) {
+	request, ok := r.requests[id]
+	if !ok || request.Status != from {
+		return false, nil
+	}
+	request.Status = updates["status"].(ApprovalStatus)
+	if checkerID, ok := updates["checker_id"].(uint); ok {
+		request.CheckerID = &checkerID
+	}
+	request.UpdatedAt = time.Now()
+	return true, nil
+}
+
+func (r *fakeApprovalRepository) CreateHistory(history *ApprovalHistory) error {
+	r.histories = append(r.histories, *history)
+	return nil
+}
+
+func newTestService(repo *fakeApprovalRepository) (*approvalService, *[]ApprovalRequest) {
+	executed := &[]ApprovalRequest{}
+	service := &approvalService{
+		repo:         repo,
+		executors:    make(map[OperationType]Executor),
+		ttl:          time.Hour,
+		stuckAfter:   time.Minute,
+		closers:      make(map[OperationType]Closer),
+		payoutLimits: map[string]float64{"IDR": 1000000},
+	}
+	executor := func(approval ApprovalRequest) error {
+		*executed = append(*executed, approval)
+		return nil
+	}
+	service.RegisterExecutor(OperationManualCredit, executor)
+	service.RegisterExecutor(OperationPayout, executor)
+	return service, executed
+}
+
+func creditRequest(reference string) SubmitRequest {
+	return SubmitRequest{
+		OperationType: OperationManualCredit,
+		Payload:       Payload{"user_id": 7, "amount": 50000, "reference": reference},
+		Reason:        "koreksi saldo",
+	}
+}
+
+func TestApproveRejectsMakerAsChecker(t *testing.T) {
+	service, executed := newTestService(newFakeApprovalRepository())
+
+	approval, err := service.Submit(3, creditRequest("ADJ-1"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.Approve(approval.ID, 3, ""); err == nil {
+		t.Fatalf("expected maker to be rejected as checker")
+	}
+	if len(*executed) != 0 {
+		t.Fatalf("expected nothing executed; got %d", len(*executed))
+	}
+
+	approved, err := service.Approve(approval.ID, 4, "ok")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if approved.Status != StatusExecuted || len(*executed) != 1 {
+		t.Fatalf("expected EXECUTED once; got %s, %d", approved.Status, len(*executed))
+	}
+}
+
+func TestApproveTwiceExecutesOnce(t *testing.T) {
+	service, executed := newTestService(newFakeApprovalRepository())
+
+	approval, _ := service.Submit(3, creditRequest("ADJ-1"))
+	if _, err := service.Approve(approval.ID, 4, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.Approve(approval.ID, 5, ""); err == nil {
+		t.Fatalf("expected second approval to fail")
+	}
+	if len(*executed) != 1 {
+		t.Fatalf("expected operation executed once; got %d", len(*executed))
+	}
+}
+
+func TestApproveExpiredRequest(t *testing.T) {
+	repo := newFakeApprovalRepository()
+	service, executed := newTestService(repo)
+
+	approval, _ := service.Submit(3, creditRequest("ADJ-1"))
+	repo.requests[approval.ID].ExpiresAt = time.Now().Add(-time.Minute)
+
+	if _, err := service.Approve(approval.ID, 4, ""); err == nil {
+		t.Fatalf("expected expired request to be rejected")
+	}
+	if repo.requests[approval.ID].Status != StatusExpired || len(*executed) != 0 {
+		t.Fatalf("expected EXPIRED without execution; got %s, %d", repo.requests[approval.ID].Status, len(*executed))
+	}
+}
+
+func TestSubmitPayoutAutoExecutesUpToLimit(t *testing.T) {
+	service, executed := newTestService(newFakeApprovalRepository())
+
+	below, err := service.Submit(3, SubmitRequest{OperationType: OperationPayout, Payload: Payload{"user_id": 7, "amount": 1000000, "reference": "PO-1"}, Reason: "payout"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if below.Status != StatusExecuted || len(*executed) != 1 {
+		t.Fatalf("expected payout at the limit to auto-execute; got %s", below.Status)
+	}
+
+	above, err := service.Submit(3, SubmitRequest{OperationType: OperationPayout, Payload: Payload{"user_id": 7, "amount": 1000001, "reference": "PO-2"}, Reason: "payout"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if above.Status != StatusPending || len(*executed) != 1 {
+		t.Fatalf("expected payout above the limit to wait for a checker; got %s", above.Status)
+	}
+}
+
+func TestSubmitRejectsDuplicateTarget(t *testing.T) {
+	repo := newFakeApprovalRepository()
+	service, _ := newTestService(repo)
+
+	first, err := service.Submit(3, creditRequest("ADJ-1"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.Submit(5, creditRequest("ADJ-1")); !errors.Is(err, ErrDuplicateTarget) {
+		t.Fatalf("expected ErrDuplicateTarget; got %v", err)
+	}
+	if _, err := service.Submit(3, creditRequest("ADJ-2")); err != nil {
+		t.Fatalf("expected a different target to be accepted; got %v", err)
+	}
+
+	if _, err := service.Reject(first.ID, 4, "tidak sesuai"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.Submit(3, creditRequest("ADJ-1")); !errors.Is(err, ErrDuplicateTarget) {
+		t.Fatalf("expected a rejected target to stay taken; got %v", err)
+	}
+
+	executed, _ := service.Submit(3, creditRequest("ADJ-3"))
+	if _, err := service.Approve(executed.ID, 4, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.Submit(3, creditRequest("ADJ-3")); !errors.Is(err, ErrDuplicateTarget) {
+		t.Fatalf("expected an executed target to stay taken; got %v", err)
+	}
+}
+
+func TestSubmitPayoutUsesCurrencyLimit(t *testing.T) {
+	service, executed := newTestService(newFakeApprovalRepository())
+
+	usd, err := service.Submit(3, SubmitRequest{OperationType: OperationPayout, Payload: Payload{"user_id": 7, "amount": 500, "currency": "USD", "reference": "PO-USD"}, Reason: "payout"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if usd.Status != StatusPending || len(*executed) != 0 {
+		t.Fatalf("expected USD payout without a USD limit to wait for a checker; got %s", usd.Status)
+	}
+
+	service.payoutLimits["USD"] = 1000
+	usd, err = service.Submit(3, SubmitRequest{OperationType: OperationPayout, Payload: Payload{"user_id": 7, "amount": 500, "currency": "usd", "reference": "PO-USD-2"}, Reason: "payout"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if usd.Status != StatusExecuted || len(*executed) != 1 {
+		t.Fatalf("expected USD payout within the USD limit to auto-execute; got %s", usd.Status)
+	}
+}
+
+func TestCloserRunsWhenRequestEndsWithoutExecution(t *testing.T) {
+	repo := newFakeApprovalRepository()
+	service, _ := newTestService(repo)
+	var closed []ApprovalStatus
+	service.RegisterCloser(OperationManualCredit, func(approval ApprovalRequest) {
+		closed = append(closed, approval.Status)
+	})
+
+	rejected, _ := service.Submit(3, creditRequest("ADJ-1"))
+	if _, err := service.Reject(rejected.ID, 4, "tidak sesuai"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expired, _ := service.Submit(3, creditRequest("ADJ-2"))
+	repo.requests[expired.ID].ExpiresAt = time.Now().Add(-time.Minute)
+	if _, err := service.ExpireStale(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	service.RegisterExecutor(OperationManualCredit, func(approval ApprovalRequest) error {
+		return errors.New("saldo tidak mencukupi")
+	})
+	failed, _ := service.Submit(3, creditRequest("ADJ-3"))
+	if _, err := service.Approve(failed.ID, 4, ""); err == nil {
+		t.Fatalf("expected execution error")
+	}
+
+	want := []ApprovalStatus{StatusRejected, StatusExpired, StatusFailed}
+	if len(closed) != len(want) {
+		t.Fatalf("expected closer for %v; got %v", want, closed)
+	}
+	for i := range want {
+		if closed[i] != want[i] {
+			t.Errorf("closer %d status = %s, want %s", i, closed[i], want[i])
+		}
+	}
+}
+
+func TestRecoverStuckRunsInterruptedExecutionsOnce(t *testing.T) {
+	repo := newFakeApprovalRepository()
+	service, executed := newTestService(repo)
+
+	approved, _ := service.Submit(3, creditRequest("ADJ-1"))
+	executing, _ := service.Submit(3, creditRequest("ADJ-2"))
+	fresh, _ := service.Submit(3, creditRequest("ADJ-3"))
+
+	// Server berhenti setelah status disimpan tetapi sebelum hasil eksekusi tercatat.
+	stale := time.Now().Add(-time.Hour)
+	checkerID := uint(4)
+	repo.requests[approved.ID].Status = StatusApproved
+	repo.requests[approved.ID].CheckerID = &checkerID
+	repo.requests[approved.ID].UpdatedAt = stale
+	repo.requests[executing.ID].Status = StatusExecuting
+	repo.requests[executing.ID].CheckerID = &checkerID
+	repo.requests[executing.ID].UpdatedAt = stale
+	repo.requests[fresh.ID].Status = StatusExecuting
+
+	recovered, err := service.RecoverStuck()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if recovered != 2 || len(*executed) != 2 {
+		t.Fatalf("expected both stale requests recovered; got %d recovered, %d executed", recovered, len(*executed))
+	}
+	for _, id := range []uint{approved.ID, executing.ID} {
+		if repo.requests[id].Status != StatusExecuted {
+			t.Errorf("request %d status = %s, want EXECUTED", id, repo.requests[id].Status)
+		}
+	}
+	if repo.requests[fresh.ID].Status != StatusExecuting {
+		t.Errorf("request still executing elsewhere must be left alone; got %s", repo.requests[fresh.ID].Status)
+	}
+	for _, approval := range *executed {
+		if approval.Approver() != checkerID || approval.Reference() == "" {
+			t.Errorf("executor must receive the checker and approval reference; got %d, %q", approval.Approver(), approval.Reference())
+		}
+	}
+
+	if recovered, _ := service.RecoverStuck(); recovered != 0 || len(*executed) != 2 {
+		t.Fatalf("expected nothing left to recover; got %d recovered, %d executed", recovered, len(*executed))
+	}
+}
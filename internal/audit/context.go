@@ -0,0 +1,23 @@
+package audit
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// FromContext mengambil aktor, IP, user agent dan request ID dari request Fiber.
+func FromContext(c *fiber.Ctx) Meta {
+	meta := Meta{
+		IP:        c.IP(),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+	}
+
+	if userID, ok := c.Locals("user_id").(uint); ok {
+		meta.ActorID = &userID
+	}
+
+	if requestID, ok := c.Locals("requestid").(string); ok {
+		meta.RequestID = requestID
+	}
+
+	return meta
+}
@@ -0,0 +1,68 @@
+package audit
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AuditHandler struct {
+	service AuditService
+}
+
+func NewAuditHandler(service AuditService) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+func (h *AuditHandler) QueryHandler(c *fiber.Ctx) error {
+	filter := Filter{
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+		RequestID:  c.Query("request_id"),
+		Page:       c.QueryInt("page", 1),
+		Limit:      c.QueryInt("limit", defaultQueryLimit),
+	}
+
+	if actor := c.Query("actor_id"); actor != "" {
+		actorID, err := strconv.ParseUint(actor, 10, 64)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "actor_id tidak valid"})
+		}
+		id := uint(actorID)
+		filter.ActorID = &id
+	}
+
+	for param, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": param + " harus berformat RFC3339"})
+		}
+		*target = &parsed
+	}
+
+	logs, total, err := h.service.Query(filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"data":  logs,
+		"total": total,
+		"page":  filter.Page,
+	})
+}
+
+func (h *AuditHandler) VerifyHandler(c *fiber.Ctx) error {
+	result, err := h.service.Verify()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": result})
+}
@@ -0,0 +1,207 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	ActionLogin                    = "LOGIN"
+	ActionLoginFailed              = "LOGIN_FAILED"
+	ActionLogout                   = "LOGOUT"
+	ActionTokenRefresh             = "TOKEN_REFRESH"
+	ActionTokenRefreshFailed       = "TOKEN_REFRESH_FAILED"
+	ActionBalanceAdjusted          = "BALANCE_ADJUSTED"
+	ActionTransactionStatusChanged = "TRANSACTION_STATUS_CHANGED"
+	ActionApprovalSubmitted        = "APPROVAL_SUBMITTED"
+	ActionApprovalApproved         = "APPROVAL_APPROVED"
+	ActionApprovalRejected         = "APPROVAL_REJECTED"
+	ActionKYCSubmitted             = "KYC_SUBMITTED"
+	ActionKYCApproved              = "KYC_APPROVED"
+	ActionKYCRejected              = "KYC_REJECTED"
+	ActionWalletOpened             = "WALLET_OPENED"
+	ActionFXExecuted               = "FX_EXECUTED"
+	ActionFeeScheduleCreated       = "FEE_SCHEDULE_CREATED"
+	ActionFeeScheduleUpdated       = "FEE_SCHEDULE_UPDATED"
+	ActionFeeScheduleEnded         = "FEE_SCHEDULE_ENDED"
+	ActionBeneficiaryAdded         = "BENEFICIARY_ADDED"
+	ActionBeneficiaryRemoved       = "BENEFICIARY_REMOVED"
+	ActionWithdrawalRequested      = "WITHDRAWAL_REQUESTED"
+	ActionWithdrawalReconciled     = "WITHDRAWAL_RECONCILED"
+	ActionDisbursementCreated      = "DISBURSEMENT_CREATED"
+	ActionDisbursementExecuted     = "DISBURSEMENT_EXECUTED"
+	ActionDisbursementCancelled    = "DISBURSEMENT_CANCELLED"
+	ActionScheduleCreated          = "SCHEDULE_CREATED"
+	ActionScheduleStatusChanged    = "SCHEDULE_STATUS_CHANGED"
+	ActionPINChanged               = "PIN_CHANGED"
+	ActionPaymentRequestCreated    = "PAYMENT_REQUEST_CREATED"
+	ActionPaymentRequestAccepted   = "PAYMENT_REQUEST_ACCEPTED"
+	ActionPaymentRequestDeclined   = "PAYMENT_REQUEST_DECLINED"
+	ActionPaymentRequestCancelled  = "PAYMENT_REQUEST_CANCELLED"
+	ActionSplitGroupCreated        = "SPLIT_GROUP_CREATED"
+	ActionSplitMemberAdded         = "SPLIT_MEMBER_ADDED"
+	ActionSplitExpenseAdded        = "SPLIT_EXPENSE_ADDED"
+	ActionSplitSettled             = "SPLIT_SETTLED"
+	ActionQRISGenerated            = "QRIS_GENERATED"
+	ActionMerchantCreated          = "MERCHANT_CREATED"
+	ActionMerchantStatusChanged    = "MERCHANT_STATUS_CHANGED"
+	ActionMerchantAPIKeyIssued     = "MERCHANT_API_KEY_ISSUED"
+	ActionMerchantAPIKeyRevoked    = "MERCHANT_API_KEY_REVOKED"
+	ActionPaymentIntentCreated     = "PAYMENT_INTENT_CREATED"
+	ActionPaymentIntentCancelled   = "PAYMENT_INTENT_CANCELLED"
+	ActionPaymentIntentConfirmed   = "PAYMENT_INTENT_CONFIRMED"
+	ActionPaymentIntentRefunded    = "PAYMENT_INTENT_REFUNDED"
+	ActionSettlementAccountSet     = "SETTLEMENT_ACCOUNT_SET"
+	ActionSettlementRun            = "SETTLEMENT_RUN"
+	ActionSettlementRetried        = "SETTLEMENT_RETRIED"
+	ActionSettlementReconciled     = "SETTLEMENT_RECONCILED"
+	ActionDisputeOpened            = "DISPUTE_OPENED"
+	ActionDisputeEvidenceAdded     = "DISPUTE_EVIDENCE_ADDED"
+	ActionDisputeResponded         = "DISPUTE_RESPONDED"
+	ActionDisputeWithdrawn         = "DISPUTE_WITHDRAWN"
+	ActionDisputeDecided           = "DISPUTE_DECIDED"
+	ActionBillPaymentRequested     = "BILL_PAYMENT_REQUESTED"
+	ActionBillPaymentReconciled    = "BILL_PAYMENT_RECONCILED"
+	ActionCampaignCreated          = "CAMPAIGN_CREATED"
+	ActionCampaignStatusChanged    = "CAMPAIGN_STATUS_CHANGED"
+	ActionVouchersCreated          = "VOUCHERS_CREATED"
+	ActionEarnRuleCreated          = "EARN_RULE_CREATED"
+	ActionEarnRuleEnded            = "EARN_RULE_ENDED"
+	ActionRewardCreated            = "REWARD_CREATED"
+	ActionRewardStatusChanged      = "REWARD_STATUS_CHANGED"
+	ActionPointsRedeemed           = "POINTS_REDEEMED"
+	ActionReferralApproved         = "REFERRAL_APPROVED"
+	ActionReferralRejected         = "REFERRAL_REJECTED"
+	ActionScreeningHitCleared      = "SCREENING_HIT_CLEARED"
+	ActionScreeningHitConfirmed    = "SCREENING_HIT_CONFIRMED"
+	ActionFraudCaseCleared         = "FRAUD_CASE_CLEARED"
+	ActionFraudCaseConfirmed       = "FRAUD_CASE_CONFIRMED"
+	ActionFraudRulesReloaded       = "FRAUD_RULES_RELOADED"
+	ActionLimitRuleSaved           = "LIMIT_RULE_SAVED"
+)
+
+// Snapshot adalah keadaan objek sebelum/sesudah suatu event.
+type Snapshot map[string]interface{}
+
+func (s Snapshot) Value() (driver.Value, error) {
+	if s == nil {
+		return nil, nil
+	}
+	return json.Marshal(s)
+}
+
+func (s *Snapshot) Scan(value interface{}) error {
+	if value == nil {
+		*s = nil
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("failed to unmarshal JSON")
+	}
+	return json.Unmarshal(bytes, s)
+}
+
+// AuditLog bersifat append-only; repository tidak menyediakan update maupun delete.
+type AuditLog struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	ActorID    *uint     `gorm:"index" json:"actor_id,omitempty"`
+	Action     string    `gorm:"type:varchar(50);not null;index" json:"action"`
+	TargetType string    `gorm:"type:varchar(50);index:idx_audit_target" json:"target_type"`
+	TargetID   string    `gorm:"type:varchar(100);index:idx_audit_target" json:"target_id"`
+	Before     Snapshot  `gorm:"type:json" json:"before,omitempty"`
+	After      Snapshot  `gorm:"type:json" json:"after,omitempty"`
+	IP         string    `gorm:"type:varchar(45)" json:"ip"`
+	UserAgent  string    `gorm:"type:varchar(255)" json:"user_agent"`
+	RequestID  string    `gorm:"type:varchar(64);index" json:"request_id"`
+	PrevHash   string    `gorm:"type:char(64);not null" json:"prev_hash"`
+	Hash       string    `gorm:"type:char(64);not null;uniqueIndex" json:"hash"`
+	CreatedAt  time.Time `gorm:"not null;index" json:"created_at"`
+}
+
+// Meta berisi informasi request yang menyertai setiap entri audit.
+type Meta struct {
+	ActorID   *uint
+	IP        string
+	UserAgent string
+	RequestID string
+}
+
+type Entry struct {
+	Meta
+	Action     string
+	TargetType string
+	TargetID   string
+	Before     Snapshot
+	After      Snapshot
+}
+
+type Filter struct {
+	ActorID    *uint
+	Action     string
+	TargetType string
+	TargetID   string
+	RequestID  string
+	From       *time.Time
+	To         *time.Time
+	Page       int
+	Limit      int
+}
+
+type VerifyResult struct {
+	Checked  int    `json:"checked"`
+	Valid    bool   `json:"valid"`
+	BrokenAt uint   `json:"broken_at,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// ComputeHash menghitung hash entri dari isinya dan hash entri sebelumnya.
+func ComputeHash(log *AuditLog) string {
+	actor := ""
+	if log.ActorID != nil {
+		actor = fmt.Sprint(*log.ActorID)
+	}
+
+	payload := fmt.Sprintf("v1|%s|%s|%s|%s|%s|%s|%s|%s|%s|%s|%d",
+		log.PrevHash,
+		actor,
+		log.Action,
+		log.TargetType,
+		log.TargetID,
+		canonicalSnapshot(log.Before),
+		canonicalSnapshot(log.After),
+		log.IP,
+		log.UserAgent,
+		log.RequestID,
+		log.CreatedAt.Unix(),
+	)
+
+	sum := sha256.Sum256([]byte(payload))
+	return hex.EncodeToString(sum[:])
+}
+
+// canonicalSnapshot menormalkan snapshot lewat round-trip JSON supaya hash yang
+// dihitung saat menulis sama dengan hash yang dihitung ulang dari database.
+func canonicalSnapshot(snapshot Snapshot) string {
+	if snapshot == nil {
+		return ""
+	}
+
+	raw, err := json.Marshal(snapshot)
+	if err != nil {
+		return ""
+	}
+
+	var normalized map[string]interface{}
+	if err := json.Unmarshal(raw, &normalized); err != nil {
+		return ""
+	}
+
+	raw, _ = json.Marshal(normalized)
+	return string(raw)
+}
@@ -0,0 +1,55 @@
+package audit
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestComputeHashSurvivesJSONRoundTrip(t *testing.T) {
+	actorID := uint(7)
+	entry := AuditLog{
+		ActorID:    &actorID,
+		Action:     ActionBalanceAdjusted,
+		TargetType: "wallet",
+		TargetID:   "7",
+		Before:     Snapshot{"balance": 10000},
+		After:      Snapshot{"balance": uint(25000), "reference": "TOPUP-1"},
+		IP:         "127.0.0.1",
+		CreatedAt:  time.Unix(1700000000, 0),
+	}
+	hash := ComputeHash(&entry)
+
+	// Simulasikan nilai yang dibaca kembali dari kolom JSON.
+	raw, _ := json.Marshal(entry.After)
+	var after Snapshot
+	if err := after.Scan(raw); err != nil {
+		t.Fatalf("scan snapshot: %v", err)
+	}
+	entry.After = after
+
+	if got := ComputeHash(&entry); got != hash {
+		t.Errorf("expected hash %s after round trip; got %s", hash, got)
+	}
+}
+
+func TestComputeHashDetectsTampering(t *testing.T) {
+	entry := AuditLog{
+		Action:    ActionLogin,
+		TargetID:  "1",
+		PrevHash:  "abc",
+		CreatedAt: time.Unix(1700000000, 0),
+	}
+	hash := ComputeHash(&entry)
+
+	entry.TargetID = "2"
+	if ComputeHash(&entry) == hash {
+		t.Error("expected hash to change when target_id changes")
+	}
+
+	entry.TargetID = "1"
+	entry.PrevHash = "abd"
+	if ComputeHash(&entry) == hash {
+		t.Error("expected hash to change when prev_hash changes")
+	}
+}
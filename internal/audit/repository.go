@@ -0,0 +1,78 @@
+package audit
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AuditRepository interface {
+	Append(log *AuditLog, hash func(log *AuditLog) string) error
+	Query(filter Filter) ([]AuditLog, int64, error)
+	FindBatchAfter(lastID uint, limit int) ([]AuditLog, error)
+}
+
+type auditRepository struct {
+	DB *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{DB: db}
+}
+
+// Append mengunci entri terakhir agar prev_hash tidak dipakai dua kali oleh
+// penulis yang berjalan bersamaan.
+func (r *auditRepository) Append(log *AuditLog, hash func(log *AuditLog) string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var last AuditLog
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Order("id DESC").Limit(1).Find(&last).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		log.PrevHash = last.Hash
+		log.Hash = hash(log)
+		return tx.Create(log).Error
+	})
+}
+
+func (r *auditRepository) Query(filter Filter) ([]AuditLog, int64, error) {
+	query := r.DB.Model(&AuditLog{})
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at <= ?", *filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var logs []AuditLog
+	err := query.Order("id DESC").Offset((filter.Page - 1) * filter.Limit).Limit(filter.Limit).Find(&logs).Error
+	return logs, total, err
+}
+
+func (r *auditRepository) FindBatchAfter(lastID uint, limit int) ([]AuditLog, error) {
+	var logs []AuditLog
+	err := r.DB.Where("id > ?", lastID).Order("id ASC").Limit(limit).Find(&logs).Error
+	return logs, err
+}
@@ -0,0 +1,112 @@
+package audit
+
+import (
+	"errors"
+	"log"
+	"time"
+)
+
+const (
+	defaultQueryLimit = 50
+	maxQueryLimit     = 500
+	verifyBatchSize   = 1000
+)
+
+type AuditService interface {
+	Record(entry Entry) error
+	Query(filter Filter) ([]AuditLog, int64, error)
+	Verify() (*VerifyResult, error)
+}
+
+type auditService struct {
+	repo AuditRepository
+}
+
+func NewAuditService(repo AuditRepository) AuditService {
+	return &auditService{repo: repo}
+}
+
+func (s *auditService) Record(entry Entry) error {
+	if entry.Action == "" {
+		return errors.New("action audit wajib diisi")
+	}
+
+	auditLog := AuditLog{
+		ActorID:    entry.ActorID,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		Before:     entry.Before,
+		After:      entry.After,
+		IP:         entry.IP,
+		UserAgent:  truncate(entry.UserAgent, 255),
+		RequestID:  entry.RequestID,
+		CreatedAt:  time.Now().Truncate(time.Second),
+	}
+
+	if err := s.repo.Append(&auditLog, ComputeHash); err != nil {
+		log.Printf("ERROR: Gagal menyimpan audit log %s: %v", entry.Action, err)
+		return err
+	}
+	return nil
+}
+
+func (s *auditService) Query(filter Filter) ([]AuditLog, int64, error) {
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultQueryLimit
+	}
+	if filter.Limit > maxQueryLimit {
+		filter.Limit = maxQueryLimit
+	}
+	return s.repo.Query(filter)
+}
+
+// Verify menelusuri seluruh rantai dari entri pertama dan berhenti pada entri
+// pertama yang hash-nya tidak cocok.
+func (s *auditService) Verify() (*VerifyResult, error) {
+	result := &VerifyResult{Valid: true}
+	prevHash := ""
+	var lastID uint
+
+	for {
+		batch, err := s.repo.FindBatchAfter(lastID, verifyBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			return result, nil
+		}
+
+		for i := range batch {
+			entry := &batch[i]
+			result.Checked++
+
+			if entry.PrevHash != prevHash {
+				result.Valid = false
+				result.BrokenAt = entry.ID
+				result.Reason = "prev_hash tidak sesuai dengan hash entri sebelumnya"
+				return result, nil
+			}
+
+			if ComputeHash(entry) != entry.Hash {
+				result.Valid = false
+				result.BrokenAt = entry.ID
+				result.Reason = "isi entri tidak sesuai dengan hash yang tersimpan"
+				return result, nil
+			}
+
+			prevHash = entry.Hash
+			lastID = entry.ID
+		}
+	}
+}
+
+func truncate(value string, length int) string {
+	if len(value) <= length {
+		return value
+	}
+	return value[:length]
+}
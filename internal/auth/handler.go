@@ -1,15 +1,20 @@
 package auth
 
 import (
+	"errors"
+	"ewallet-engine/internal/audit"
+	"fmt"
+
 	"github.com/gofiber/fiber/v2"
 )
 
 type AuthHandler struct {
-	authService AuthService
+	authService  AuthService
+	auditService audit.AuditService
 }
 
-func NewAuthHandler(service AuthService) *AuthHandler {
-	return &AuthHandler{authService: service}
+func NewAuthHandler(service AuthService, auditService audit.AuditService) *AuthHandler {
+	return &AuthHandler{authService: service, auditService: auditService}
 }
 
 func (h *AuthHandler) Register(c *fiber.Ctx) error {
@@ -29,7 +34,11 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 		})
 	}
 
-	createdUser, err := h.authService.RegisterUser(*user)
+	createdUser, err := h.authService.RegisterUser(*user, Signup{
+		ReferralCode: request.ReferralCode,
+		DeviceID:     c.Get("X-Device-ID"),
+		IP:           c.IP(),
+	})
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"message": err.Error(),
@@ -40,6 +49,7 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 		"message": "User registered successfully",
 		"data": fiber.Map{
 			"username":     createdUser.Username,
+			"full_name":    createdUser.FullName,
 			"email":        createdUser.Email,
 			"phone_number": createdUser.PhoneNumber,
 			"address":      createdUser.Address,
@@ -60,11 +70,27 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 
 	user, token, refreshToken, err := h.authService.LoginUser(request)
 	if err != nil {
+		_ = h.auditService.Record(audit.Entry{
+			Meta:       audit.FromContext(c),
+			Action:     audit.ActionLoginFailed,
+			TargetType: "user",
+			TargetID:   request.Username,
+			After:      audit.Snapshot{"reason": err.Error()},
+		})
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"message": err.Error(),
 		})
 	}
 
+	meta := audit.FromContext(c)
+	meta.ActorID = &user.ID
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       meta,
+		Action:     audit.ActionLogin,
+		TargetType: "user",
+		TargetID:   fmt.Sprint(user.ID),
+	})
+
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Login successful",
 		"data": fiber.Map{
@@ -90,6 +116,13 @@ func (h *AuthHandler) Logout(c *fiber.Ctx) error {
 		})
 	}
 
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionLogout,
+		TargetType: "user",
+		TargetID:   fmt.Sprint(userID),
+	})
+
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Logout berhasil",
 	})
@@ -109,11 +142,23 @@ func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
 
 	token, newRefreshToken, err := h.authService.RefreshAccessToken(request.RefreshToken)
 	if err != nil {
+		_ = h.auditService.Record(audit.Entry{
+			Meta:       audit.FromContext(c),
+			Action:     audit.ActionTokenRefreshFailed,
+			TargetType: "session",
+			After:      audit.Snapshot{"reason": err.Error()},
+		})
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"message": err.Error(),
 		})
 	}
 
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionTokenRefresh,
+		TargetType: "session",
+	})
+
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Token refreshed successfully",
 		"data": fiber.Map{
@@ -121,4 +166,32 @@ func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
 			"refresh_token": newRefreshToken,
 		},
 	})
-}
\ No newline at end of file
+}
+func (h *AuthHandler) SetPIN(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	var request struct {
+		CurrentPIN string `json:"current_pin"`
+		PIN        string `json:"pin"`
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	if err := h.authService.SetPIN(userID, request.CurrentPIN, request.PIN); err != nil {
+		if errors.Is(err, ErrPINLocked) {
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"message": err.Error()})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionPINChanged,
+		TargetType: "user",
+		TargetID:   fmt.Sprint(userID),
+	})
+
+	return c.JSON(fiber.Map{"message": "PIN transaksi berhasil disimpan"})
+}
@@ -44,6 +44,63 @@ func JWTMiddleware() fiber.Handler {
 		userID := uint(claims["user_id"].(float64)) 
 		c.Locals("user_id", userID)
 
+		role, _ := claims["role"].(string)
+		if role == "" {
+			role = RoleUser
+		}
+		c.Locals("role", role)
+
+		return c.Next()
+	}
+}
+
+// RequireRole hanya meneruskan request jika role pada token termasuk salah satu roles.
+// Harus dipasang setelah JWTMiddleware.
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, _ := c.Locals("role").(string)
+		for _, allowed := range roles {
+			if role == allowed {
+				return c.Next()
+			}
+		}
+
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": "Akses ditolak untuk role ini",
+		})
+	}
+}
+
+// ActiveAccountMiddleware menolak request dari akun yang diblokir, misalnya
+// karena hit screening yang belum direview. Harus dipasang setelah JWTMiddleware.
+func ActiveAccountMiddleware(repo UserRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, ok := c.Locals("user_id").(uint)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"message": "Unauthorized access",
+			})
+		}
+
+		user, err := repo.FindByID(userID)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"message": "User tidak ditemukan",
+			})
+		}
+
+		if user.Status == StatusBlocked {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"message": "Akun diblokir, silakan hubungi customer service",
+			})
+		}
+
+		if user.Status != StatusActive {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"message": "Akun sedang dalam proses verifikasi, silakan login ulang",
+			})
+		}
+
 		return c.Next()
 	}
-}
\ No newline at end of file
+}
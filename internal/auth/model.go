@@ -5,26 +5,68 @@ import (
 	"time"
 )
 
+const (
+	RoleUser     = "USER"
+	RoleOperator = "OPERATOR"
+	RoleApprover = "APPROVER"
+	RoleAdmin    = "ADMIN"
+	// RoleMerchant dipakai user sistem pemilik wallet settlement merchant; user
+	// ini tidak bisa login dan tidak bisa menjadi penerima transfer biasa.
+	RoleMerchant = "MERCHANT"
+)
+
+const (
+	StatusActive  = "ACTIVE"
+	StatusBlocked = "BLOCKED"
+	// StatusPendingScreening dipakai akun baru sampai screening nama berhasil
+	// dijalankan; akun ini belum bisa bertransaksi.
+	StatusPendingScreening = "PENDING_SCREENING"
+)
+
+const (
+	KYCTierUnverified = "UNVERIFIED"
+	KYCTierVerified   = "VERIFIED"
+)
+
 type User struct {
 	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id"`
 	Username    string    `gorm:"type:varchar(255);unique;not null" json:"username"`
+	FullName    string    `gorm:"type:varchar(255)" json:"full_name"`
 	Password    string    `gorm:"type:varchar(255);not null" json:"-"`
 	Email       string    `gorm:"type:varchar(255);unique;not null" json:"email"`
 	PhoneNumber string    `gorm:"type:varchar(12);unique;not null" json:"phone_number"`
 	Address     string    `gorm:"type:text;not null" json:"address"`
 	DOB         time.Time `gorm:"type:date;not null" json:"dob"`
-	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	Role        string    `gorm:"type:varchar(20);not null;default:'USER'" json:"role"`
+	KYCTier     string    `gorm:"column:kyc_tier;type:varchar(20);not null;default:'UNVERIFIED'" json:"kyc_tier"`
+	Status      string    `gorm:"type:varchar(20);not null;default:'ACTIVE'" json:"status"`
+	// PINHash adalah bcrypt dari PIN transaksi 6 digit; kosong jika belum dibuat.
+	PINHash           string     `gorm:"column:pin_hash;type:varchar(255)" json:"-"`
+	PINFailedAttempts int        `gorm:"column:pin_failed_attempts;not null;default:0" json:"-"`
+	PINLockedUntil    *time.Time `gorm:"column:pin_locked_until" json:"-"`
+	CreatedAt         time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
 }
 
 
 type RegisterRequest struct {
 	Username    string `json:"username" validate:"required"`
+	FullName    string `json:"full_name"`
 	Password    string `json:"password" validate:"required,min=6"`
 	Email       string `json:"email" validate:"required,email"`
 	PhoneNumber string `json:"phone_number" validate:"required"`
 	Address     string `json:"address" validate:"required"`
 	DOB         string `json:"dob" validate:"required"` 
+	// ReferralCode adalah kode referral user yang mengajak; boleh kosong.
+	ReferralCode string `json:"referral_code"`
+}
+
+// Signup adalah konteks registrasi yang tidak disimpan di User: kode
+// referral yang dipakai serta device dan IP pendaftar.
+type Signup struct {
+	ReferralCode string
+	DeviceID     string
+	IP           string
 }
 
 func (r *RegisterRequest) ConvertToUser() (*User, error) {
@@ -35,6 +77,7 @@ func (r *RegisterRequest) ConvertToUser() (*User, error) {
 
 	return &User{
 		Username:    r.Username,
+		FullName:    r.FullName,
 		Password:    r.Password,
 		Email:       r.Email,
 		PhoneNumber: r.PhoneNumber,
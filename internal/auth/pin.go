@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	pinLength      = 6
+	maxPINAttempts = 5
+	pinLockout     = 30 * time.Minute
+)
+
+var (
+	ErrPINNotSet  = errors.New("PIN transaksi belum dibuat")
+	ErrInvalidPIN = errors.New("PIN salah")
+	ErrPINLocked  = errors.New("PIN terkunci karena terlalu banyak percobaan, coba lagi nanti")
+)
+
+// PINVerifier dipakai service lain untuk meminta konfirmasi PIN sebelum dana berpindah.
+type PINVerifier interface {
+	VerifyPIN(userID uint, pin string) error
+}
+
+func validPINFormat(pin string) bool {
+	if len(pin) != pinLength {
+		return false
+	}
+	for _, r := range pin {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// SetPIN membuat atau mengganti PIN transaksi. Penggantian PIN wajib
+// menyertakan PIN lama yang benar.
+func (s *authService) SetPIN(userID uint, currentPIN string, newPIN string) error {
+	if !validPINFormat(newPIN) {
+		return fmt.Errorf("PIN harus %d digit angka", pinLength)
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return errors.New("user tidak ditemukan")
+	}
+	if user.PINHash != "" {
+		if err := s.VerifyPIN(userID, currentPIN); err != nil {
+			return err
+		}
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(newPIN), bcrypt.DefaultCost)
+	if err != nil {
+		return errors.New("gagal mengenkripsi PIN")
+	}
+
+	return s.userRepo.UpdatePIN(userID, map[string]interface{}{
+		"pin_hash":            string(hashed),
+		"pin_failed_attempts": 0,
+		"pin_locked_until":    nil,
+	})
+}
+
+// VerifyPIN memeriksa PIN transaksi. Setelah maxPINAttempts kali salah
+// berturut-turut PIN dikunci selama pinLockout.
+func (s *authService) VerifyPIN(userID uint, pin string) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return errors.New("user tidak ditemukan")
+	}
+	if user.PINHash == "" {
+		return ErrPINNotSet
+	}
+	if user.PINLockedUntil != nil && time.Now().Before(*user.PINLockedUntil) {
+		return ErrPINLocked
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(user.PINHash), []byte(pin)) != nil {
+		updates := map[string]interface{}{"pin_failed_attempts": user.PINFailedAttempts + 1}
+		if user.PINFailedAttempts+1 >= maxPINAttempts {
+			lockedUntil := time.Now().Add(pinLockout)
+			updates["pin_failed_attempts"] = 0
+			updates["pin_locked_until"] = &lockedUntil
+			log.Printf("ALERT: PIN user_id %d dikunci setelah %d kali salah", userID, maxPINAttempts)
+		}
+		if err := s.userRepo.UpdatePIN(userID, updates); err != nil {
+			log.Printf("ERROR: Gagal mencatat percobaan PIN user_id %d: %v", userID, err)
+		}
+		return ErrInvalidPIN
+	}
+
+	if user.PINFailedAttempts > 0 || user.PINLockedUntil != nil {
+		if err := s.userRepo.UpdatePIN(userID, map[string]interface{}{"pin_failed_attempts": 0, "pin_locked_until": nil}); err != nil {
+			log.Printf("ERROR: Gagal mereset percobaan PIN user_id %d: %v", userID, err)
+		}
+	}
+	return nil
+}
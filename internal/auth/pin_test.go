@@ -0,0 +1,20 @@
+package auth
+
+import "testing"
+
+func TestValidPINFormat(t *testing.T) {
+	cases := map[string]bool{
+		"123456":  true,
+		"000000":  true,
+		"12345":   false,
+		"1234567": false,
+		"12a456":  false,
+		"":        false,
+		"١٢٣٤٥٦":  false,
+	}
+	for pin, want := range cases {
+		if got := validPINFormat(pin); got != want {
+			t.Errorf("validPINFormat(%q) = %v, want %v", pin, got, want)
+		}
+	}
+}
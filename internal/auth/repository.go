@@ -15,6 +15,7 @@ type UserRepository interface {
 	CreateUser(user *User) error
 	FindByEmail(email string) (*User, error)
 	FindByUsername(username string) (*User, error)
+	FindByID(id uint) (*User, error)
 	SaveUserSession(session *UserSession) error
 	SaveTokenToCache(userID uint, token, refreshToken string, expiration time.Duration) error
 	DeleteTokenFromCache(userID uint, refreshToken string) error
@@ -22,6 +23,8 @@ type UserRepository interface {
 	DeleteUserSession(userID uint) error
 	FindUserIDByRefreshToken(refreshToken string) (uint, error)
 	GetRedis() *redis.Client
+	UpdatePIN(userID uint, updates map[string]interface{}) error
+	TransitionStatus(userID uint, from string, to string) (bool, error)
 }
 
 type userRepository struct {
@@ -95,6 +98,29 @@ func (r *userRepository) FindByUsername(username string) (*User, error) {
 	return &user, nil
 }
 
+func (r *userRepository) FindByID(id uint) (*User, error) {
+	var user User
+	err := r.DB.First(&user, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) UpdatePIN(userID uint, updates map[string]interface{}) error {
+	return r.DB.Model(&User{}).Where("id = ?", userID).Updates(updates).Error
+}
+
+// TransitionStatus mengubah status hanya jika status saat ini masih from,
+// sehingga blokir dari screener tidak tertimpa.
+func (r *userRepository) TransitionStatus(userID uint, from string, to string) (bool, error) {
+	result := r.DB.Model(&User{}).Where("id = ? AND status = ?", userID, from).Update("status", to)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
 func (r *userRepository) SaveUserSession(session *UserSession) error {
 	return r.DB.Create(session).Error
 }
@@ -3,6 +3,7 @@ package auth
 import (
 	"context"
 	"errors"
+	"log"
 	"os"
 	"time"
 
@@ -11,31 +12,56 @@ import (
 )
 
 type AuthService interface {
-	RegisterUser(user User) (*User, error)
+	RegisterUser(user User, signup Signup) (*User, error)
 	LoginUser(request LoginRequest) (*User, string, string, error)
 	LogoutUser(userID uint) error
 	RefreshAccessToken(refreshToken string) (string, string, error)
+	SetPIN(userID uint, currentPIN string, newPIN string) error
+	VerifyPIN(userID uint, pin string) error
+}
+
+// NameScreener memeriksa nama user baru terhadap daftar sanksi dan PEP.
+type NameScreener interface {
+	ScreenUser(userID uint, name string) error
+}
+
+// ReferralRegistrar memvalidasi kode referral sebelum user dibuat dan
+// mencatat user baru ke program referral setelahnya.
+type ReferralRegistrar interface {
+	CheckCode(code string) error
+	Register(user User, signup Signup) error
 }
 
 type authService struct {
-	userRepo UserRepository
+	userRepo  UserRepository
+	screener  NameScreener
+	referrals ReferralRegistrar
 }
 
-func NewAuthService(repo UserRepository) AuthService {
-	return &authService{userRepo: repo}
+func NewAuthService(repo UserRepository, screener NameScreener, referrals ReferralRegistrar) AuthService {
+	return &authService{userRepo: repo, screener: screener, referrals: referrals}
 }
 
-func (s *authService) RegisterUser(user User) (*User, error) {
+func (s *authService) RegisterUser(user User, signup Signup) (*User, error) {
 	existingUser, _ := s.userRepo.FindByEmail(user.Email)
 	if existingUser != nil {
 		return nil, errors.New("email sudah terdaftar")
 	}
 
+	if signup.ReferralCode != "" {
+		if err := s.referrals.CheckCode(signup.ReferralCode); err != nil {
+			return nil, err
+		}
+	}
+
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, errors.New("gagal mengenkripsi password")
 	}
 	user.Password = string(hashedPassword)
+	user.Role = RoleUser
+	user.KYCTier = KYCTierUnverified
+	user.Status = StatusPendingScreening
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
 
@@ -43,6 +69,18 @@ func (s *authService) RegisterUser(user User) (*User, error) {
 		return nil, err
 	}
 
+	// Hasil screening tidak diberitahukan ke user. Jika screening gagal, akun
+	// tetap PENDING_SCREENING dan screening diulang saat login.
+	if err := s.screenUser(&user); err != nil {
+		log.Printf("ERROR: Gagal screening user_id %d, akun belum diaktifkan: %v", user.ID, err)
+	}
+
+	// Kode referral milik user baru tetap dibuat walaupun tanpa referrer;
+	// kegagalan di sini tidak membatalkan registrasi.
+	if err := s.referrals.Register(user, signup); err != nil {
+		log.Printf("ERROR: Gagal mencatat referral user_id %d: %v", user.ID, err)
+	}
+
 	return &user, nil
 }
 
@@ -57,6 +95,17 @@ func (s *authService) LoginUser(request LoginRequest) (*User, string, string, er
 		return nil, "", "", errors.New("username atau password salah")
 	}
 
+	if user.Status == StatusPendingScreening {
+		if err := s.screenUser(user); err != nil {
+			log.Printf("ERROR: Gagal screening ulang user_id %d: %v", user.ID, err)
+			return nil, "", "", errors.New("akun sedang dalam proses verifikasi, silakan coba lagi")
+		}
+	}
+
+	if user.Status == StatusBlocked {
+		return nil, "", "", errors.New("akun diblokir, silakan hubungi customer service")
+	}
+
 	token, refreshToken, err := generateJWT(user)
 	if err != nil {
 		return nil, "", "", errors.New("gagal membuat token")
@@ -84,12 +133,42 @@ func (s *authService) LoginUser(request LoginRequest) (*User, string, string, er
 }
 
 
+// screenUser menjalankan screening nama dan mengaktifkan akun yang masih
+// PENDING_SCREENING. Akun yang cocok sudah diblokir oleh screener sehingga
+// transisinya tidak berlaku dan status BLOCKED dibaca ulang.
+func (s *authService) screenUser(user *User) error {
+	name := user.FullName
+	if name == "" {
+		name = user.Username
+	}
+	if err := s.screener.ScreenUser(user.ID, name); err != nil {
+		return err
+	}
+
+	activated, err := s.userRepo.TransitionStatus(user.ID, StatusPendingScreening, StatusActive)
+	if err != nil {
+		return err
+	}
+	if activated {
+		user.Status = StatusActive
+		return nil
+	}
+
+	current, err := s.userRepo.FindByID(user.ID)
+	if err != nil {
+		return err
+	}
+	user.Status = current.Status
+	return nil
+}
+
 func generateJWT(user *User) (string, string, error) {
 	var secretKey = []byte(os.Getenv("JWT_SECRET_KEY"))
 
 	claims := jwt.MapClaims{
 		"user_id":  user.ID,
 		"username": user.Username,
+		"role":     user.Role,
 		"exp":      time.Now().Add(time.Hour * 1).Unix(),
 	}
 
@@ -132,7 +211,12 @@ func (s *authService) RefreshAccessToken(refreshToken string) (string, string, e
 		return "", "", errors.New("refresh token tidak valid atau sudah kedaluwarsa")
 	}
 
-	token, newRefreshToken, err := generateJWT(&User{ID: userID})
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return "", "", errors.New("user tidak ditemukan")
+	}
+
+	token, newRefreshToken, err := generateJWT(user)
 	if err != nil {
 		return "", "", errors.New("gagal membuat token baru")
 	}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+)
+
+type fakeUserRepository struct {
+	UserRepository
+	users map[uint]*User
+}
+
+func (r *fakeUserRepository) FindByEmail(email string) (*User, error) {
+	return nil, errors.New("record not found")
+}
+
+func (r *fakeUserRepository) CreateUser(user *User) error {
+	user.ID = uint(len(r.users) + 1)
+	stored := *user
+	r.users[user.ID] = &stored
+	return nil
+}
+
+func (r *fakeUserRepository) FindByID(id uint) (*User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *fakeUserRepository) TransitionStatus(userID uint, from string, to string) (bool, error) {
+	user, ok := r.users[userID]
+	if !ok || user.Status != from {
+		return false, nil
+	}
+	user.Status = to
+	return true, nil
+}
+
+// fakeScreener memblokir user saat hit, seperti screening service.
+type fakeScreener struct {
+	repo *fakeUserRepository
+	hit  bool
+	err  error
+}
+
+func (s *fakeScreener) ScreenUser(userID uint, name string) error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.hit {
+		s.repo.users[userID].Status = StatusBlocked
+	}
+	return nil
+}
+
+type fakeReferrals struct{}
+
+func (fakeReferrals) CheckCode(code string) error             { return nil }
+func (fakeReferrals) Register(user User, signup Signup) error { return nil }
+
+func registerTestUser(t *testing.T, screener *fakeScreener) *User {
+	t.Helper()
+	service := NewAuthService(screener.repo, screener, fakeReferrals{})
+	user, err := service.RegisterUser(User{Username: "budi", FullName: "Budi Santoso", Email: "budi@example.com", Password: "rahasia"}, Signup{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return user
+}
+
+func TestRegisterUserActivatesAfterCleanScreening(t *testing.T) {
+	repo := &fakeUserRepository{users: make(map[uint]*User)}
+
+	user := registerTestUser(t, &fakeScreener{repo: repo})
+	if user.Status != StatusActive || repo.users[user.ID].Status != StatusActive {
+		t.Fatalf("expected ACTIVE; got %s", repo.users[user.ID].Status)
+	}
+}
+
+func TestRegisterUserKeepsScreeningBlock(t *testing.T) {
+	repo := &fakeUserRepository{users: make(map[uint]*User)}
+
+	user := registerTestUser(t, &fakeScreener{repo: repo, hit: true})
+	if user.Status != StatusBlocked || repo.users[user.ID].Status != StatusBlocked {
+		t.Fatalf("expected BLOCKED after a screening hit; got %s", repo.users[user.ID].Status)
+	}
+}
+
+func TestRegisterUserFailsClosedWhenScreeningErrors(t *testing.T) {
+	repo := &fakeUserRepository{users: make(map[uint]*User)}
+
+	user := registerTestUser(t, &fakeScreener{repo: repo, err: errors.New("watchlist tidak tersedia")})
+	if user.Status != StatusPendingScreening || repo.users[user.ID].Status != StatusPendingScreening {
+		t.Fatalf("expected PENDING_SCREENING when screening fails; got %s", repo.users[user.ID].Status)
+	}
+}
@@ -0,0 +1,196 @@
+package balance
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInsufficientBalance dikembalikan saat saldo tersedia tidak cukup untuk debit atau hold.
+var ErrInsufficientBalance = errors.New("saldo tidak mencukupi untuk transaksi ini")
+
+// ErrReferenceUsed dikembalikan jika mutasi dengan reference yang sama sudah
+// dibukukan pada wallet.
+var ErrReferenceUsed = errors.New("reference sudah digunakan")
+
+// balanceTolerance menyerap selisih pembulatan float saat membandingkan saldo.
+const balanceTolerance = 0.005
+
+// ComputeEntryHash menghitung hash WalletTransaction dari isinya, termasuk
+// mata uangnya, dan hash entri sebelumnya pada wallet yang sama.
+func ComputeEntryHash(entry *WalletTransaction) string {
+	payload := fmt.Sprintf("v2|%s|%d|%s|%s|%s|%s|%s|%d",
+		entry.PrevHash,
+		entry.WalletID,
+		entry.Currency,
+		entry.WalletTransactionType,
+		strconv.FormatFloat(entry.Amount, 'f', -1, 64),
+		entry.Reference,
+		strconv.FormatFloat(entry.BalanceAfter, 'f', -1, 64),
+		entry.CreatedAt.Unix(),
+	)
+
+	sum := sha256.Sum256([]byte(payload))
+	return hex.EncodeToString(sum[:])
+}
+
+// computeLegacyEntryHash adalah format hash v1 tanpa mata uang, hanya dipakai
+// untuk memverifikasi entri yang dibuat sebelum format v2.
+func computeLegacyEntryHash(entry *WalletTransaction) string {
+	payload := fmt.Sprintf("v1|%s|%d|%s|%s|%s|%s|%d",
+		entry.PrevHash,
+		entry.WalletID,
+		entry.WalletTransactionType,
+		strconv.FormatFloat(entry.Amount, 'f', -1, 64),
+		entry.Reference,
+		strconv.FormatFloat(entry.BalanceAfter, 'f', -1, 64),
+		entry.CreatedAt.Unix(),
+	)
+
+	sum := sha256.Sum256([]byte(payload))
+	return hex.EncodeToString(sum[:])
+}
+
+// ApplyWalletEntry mengunci wallet, mengubah saldonya dan mencatat
+// WalletTransaction yang tersambung ke hash chain, semuanya dalam satu transaksi
+// database. Semua perubahan saldo wallet harus lewat fungsi ini.
+func ApplyWalletEntry(db *gorm.DB, walletID uint, txType string, amount float64, reference string) (*WalletTransaction, error) {
+	var entry *WalletTransaction
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var wallet Wallet
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&wallet, walletID).Error
+		if err != nil {
+			return err
+		}
+
+		entry, err = applyLockedEntry(tx, &wallet, txType, amount, reference)
+		return err
+	})
+
+	return entry, err
+}
+
+// applyLockedEntry mengubah saldo wallet yang sudah dikunci dan mencatat entrinya.
+// Debit hanya boleh memakai saldo yang tidak sedang ditahan hold.
+func applyLockedEntry(tx *gorm.DB, wallet *Wallet, txType string, amount float64, reference string) (*WalletTransaction, error) {
+	if txType == "CREDIT" {
+		wallet.Balance += amount
+	} else if txType == "DEBIT" {
+		if wallet.Available() < amount {
+			return nil, ErrInsufficientBalance
+		}
+		wallet.Balance -= amount
+	} else {
+		return nil, errors.New("jenis transaksi tidak valid")
+	}
+
+	if err := tx.Save(wallet).Error; err != nil {
+		return nil, err
+	}
+
+	return appendChainedEntry(tx, wallet, txType, amount, reference)
+}
+
+// appendChainedEntry harus dipanggil di dalam transaksi yang sudah mengunci wallet.
+func appendChainedEntry(tx *gorm.DB, wallet *Wallet, txType string, amount float64, reference string) (*WalletTransaction, error) {
+	var last WalletTransaction
+	err := tx.Where("wallet_id = ?", wallet.ID).Order("id DESC").Limit(1).Find(&last).Error
+	if err != nil {
+		return nil, err
+	}
+
+	entry := WalletTransaction{
+		WalletID:              wallet.ID,
+		Amount:                amount,
+		Currency:              wallet.Currency,
+		WalletTransactionType: txType,
+		Reference:             reference,
+		BalanceAfter:          wallet.Balance,
+		PrevHash:              last.Hash,
+		CreatedAt:             time.Now().Truncate(time.Second),
+	}
+	entry.Hash = ComputeEntryHash(&entry)
+
+	if err := tx.Create(&entry).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// VerifyChain menelusuri entri wallet secara berurutan, memeriksa sambungan
+// prev_hash, menghitung ulang hash tiap entri dan menjumlah ulang saldo.
+// Entri lama yang dibuat sebelum hash chain ada (hash kosong) hanya dipakai
+// untuk menghitung saldo. Hash v1 tanpa mata uang hanya diterima sebelum entri
+// v2 pertama, dan mata uang setiap entri harus sama dengan mata uang wallet.
+func VerifyChain(wallet Wallet, entries []WalletTransaction) ChainReport {
+	report := ChainReport{
+		WalletID:      wallet.ID,
+		Entries:       len(entries),
+		Valid:         true,
+		ActualBalance: wallet.Balance,
+	}
+
+	prevHash := ""
+	chained := false
+	currencyHashed := false
+	running := 0.0
+
+	for i := range entries {
+		entry := &entries[i]
+
+		if entry.WalletTransactionType == "CREDIT" {
+			running += entry.Amount
+		} else {
+			running -= entry.Amount
+		}
+
+		if entry.Currency != wallet.Currency {
+			return report.broken(entry.ID, "mata uang entri tidak sesuai dengan mata uang wallet")
+		}
+
+		if entry.Hash == "" {
+			if chained {
+				return report.broken(entry.ID, "entri tanpa hash ditemukan setelah hash chain dimulai")
+			}
+			continue
+		}
+		chained = true
+
+		if entry.PrevHash != prevHash {
+			return report.broken(entry.ID, "prev_hash tidak sesuai dengan hash entri sebelumnya")
+		}
+		if ComputeEntryHash(entry) == entry.Hash {
+			currencyHashed = true
+		} else if currencyHashed || computeLegacyEntryHash(entry) != entry.Hash {
+			return report.broken(entry.ID, "isi entri tidak sesuai dengan hash yang tersimpan")
+		}
+		if math.Abs(running-entry.BalanceAfter) > balanceTolerance {
+			return report.broken(entry.ID, "balance_after tidak sesuai dengan jumlah mutasi")
+		}
+
+		prevHash = entry.Hash
+	}
+
+	report.ExpectedBalance = running
+	if math.Abs(running-wallet.Balance) > balanceTolerance {
+		report.Valid = false
+		report.Reason = "saldo wallet tidak sesuai dengan jumlah mutasi"
+	}
+
+	return report
+}
+
+func (r ChainReport) broken(entryID uint, reason string) ChainReport {
+	r.Valid = false
+	r.BrokenAt = entryID
+	r.Reason = reason
+	return r
+}
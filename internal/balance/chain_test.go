@@ -0,0 +1,118 @@
+package balance
+
+import (
+	"testing"
+	"time"
+)
+
+func buildChain(walletID uint, movements []WalletTransaction) []WalletTransaction {
+	prevHash := ""
+	running := 0.0
+	for i := range movements {
+		entry := &movements[i]
+		entry.ID = uint(i + 1)
+		entry.WalletID = walletID
+		if entry.WalletTransactionType == "CREDIT" {
+			running += entry.Amount
+		} else {
+			running -= entry.Amount
+		}
+		entry.BalanceAfter = running
+		entry.PrevHash = prevHash
+		entry.CreatedAt = time.Unix(1700000000+int64(i), 0)
+		entry.Hash = ComputeEntryHash(entry)
+		prevHash = entry.Hash
+	}
+	return movements
+}
+
+func TestVerifyChainValid(t *testing.T) {
+	entries := buildChain(1, []WalletTransaction{
+		{WalletTransactionType: "CREDIT", Amount: 50000, Reference: "TOPUP-1"},
+		{WalletTransactionType: "DEBIT", Amount: 12500.5, Reference: "BUY-1"},
+	})
+
+	report := VerifyChain(Wallet{ID: 1, Balance: 37499.5}, entries)
+	if !report.Valid {
+		t.Fatalf("expected valid chain; got %+v", report)
+	}
+}
+
+func TestVerifyChainDetectsEditedEntry(t *testing.T) {
+	entries := buildChain(1, []WalletTransaction{
+		{WalletTransactionType: "CREDIT", Amount: 50000, Reference: "TOPUP-1"},
+		{WalletTransactionType: "DEBIT", Amount: 10000, Reference: "BUY-1"},
+	})
+	entries[1].Amount = 1000
+
+	report := VerifyChain(Wallet{ID: 1, Balance: 40000}, entries)
+	if report.Valid || report.BrokenAt != 2 {
+		t.Errorf("expected chain broken at entry 2; got %+v", report)
+	}
+}
+
+func TestVerifyChainDetectsBalanceMismatch(t *testing.T) {
+	entries := buildChain(1, []WalletTransaction{
+		{WalletTransactionType: "CREDIT", Amount: 50000, Reference: "TOPUP-1"},
+	})
+
+	report := VerifyChain(Wallet{ID: 1, Balance: 90000}, entries)
+	if report.Valid {
+		t.Errorf("expected balance mismatch to be reported; got %+v", report)
+	}
+	if report.ExpectedBalance != 50000 {
+		t.Errorf("expected recomputed balance 50000; got %v", report.ExpectedBalance)
+	}
+}
+
+func TestVerifyChainDetectsEditedCurrency(t *testing.T) {
+	entries := buildChain(1, []WalletTransaction{
+		{Currency: "USD", WalletTransactionType: "CREDIT", Amount: 500, Reference: "TOPUP-1"},
+		{Currency: "USD", WalletTransactionType: "DEBIT", Amount: 100, Reference: "BUY-1"},
+	})
+
+	if report := VerifyChain(Wallet{ID: 1, Currency: "USD", Balance: 400}, entries); !report.Valid {
+		t.Fatalf("expected valid chain; got %+v", report)
+	}
+
+	// Entri dipindah ke wallet lain dengan mata uang yang juga diubah.
+	entries[1].Currency = "IDR"
+	report := VerifyChain(Wallet{ID: 1, Currency: "IDR", Balance: 400}, entries)
+	if report.Valid || report.BrokenAt != 1 {
+		t.Errorf("expected chain broken at entry 1; got %+v", report)
+	}
+	entries[0].Currency = "IDR"
+	report = VerifyChain(Wallet{ID: 1, Currency: "IDR", Balance: 400}, entries)
+	if report.Valid || report.BrokenAt != 1 {
+		t.Errorf("expected edited currency to break the hash at entry 1; got %+v", report)
+	}
+}
+
+func TestVerifyChainAcceptsLegacyHashesBeforeFirstV2Entry(t *testing.T) {
+	entries := buildChain(1, []WalletTransaction{
+		{Currency: "IDR", WalletTransactionType: "CREDIT", Amount: 50000, Reference: "TOPUP-1"},
+		{Currency: "IDR", WalletTransactionType: "DEBIT", Amount: 10000, Reference: "BUY-1"},
+		{Currency: "IDR", WalletTransactionType: "CREDIT", Amount: 5000, Reference: "TOPUP-2"},
+	})
+	rehash := func(entry *WalletTransaction, prevHash string) {
+		entry.PrevHash = prevHash
+		entry.Hash = computeLegacyEntryHash(entry)
+	}
+
+	// Entri pertama dibuat dengan format v1 sebelum format v2 berlaku.
+	rehash(&entries[0], "")
+	entries[1].PrevHash = entries[0].Hash
+	entries[1].Hash = ComputeEntryHash(&entries[1])
+	entries[2].PrevHash = entries[1].Hash
+	entries[2].Hash = ComputeEntryHash(&entries[2])
+	if report := VerifyChain(Wallet{ID: 1, Currency: "IDR", Balance: 45000}, entries); !report.Valid {
+		t.Fatalf("expected legacy prefix to be accepted; got %+v", report)
+	}
+
+	// Hash v1 setelah entri v2 berarti mata uang entri tidak ikut dilindungi.
+	rehash(&entries[2], entries[1].Hash)
+	report := VerifyChain(Wallet{ID: 1, Currency: "IDR", Balance: 45000}, entries)
+	if report.Valid || report.BrokenAt != 3 {
+		t.Errorf("expected v1 hash after a v2 entry to break the chain at entry 3; got %+v", report)
+	}
+}
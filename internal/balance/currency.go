@@ -0,0 +1,72 @@
+package balance
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// DefaultCurrency adalah mata uang wallet utama. Limit transaksi dan batas
+// saldo tier KYC dinyatakan dalam mata uang ini.
+const DefaultCurrency = "IDR"
+
+// currencyMinorUnits memetakan kode ISO 4217 yang didukung ke jumlah digit
+// desimal satuan terkecilnya.
+var currencyMinorUnits = map[string]int{
+	"IDR": 0,
+	"JPY": 0,
+	"USD": 2,
+	"SGD": 2,
+	"MYR": 2,
+	"EUR": 2,
+	"AUD": 2,
+}
+
+var ErrUnsupportedCurrency = errors.New("mata uang tidak didukung")
+
+// NormalizeCurrency mengembalikan kode ISO 4217 dalam huruf besar. Kode kosong
+// berarti DefaultCurrency.
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return DefaultCurrency, nil
+	}
+	if _, ok := currencyMinorUnits[code]; !ok {
+		return "", ErrUnsupportedCurrency
+	}
+	return code, nil
+}
+
+// MinorUnits mengembalikan jumlah digit desimal mata uang, misalnya 0 untuk IDR
+// dan 2 untuk USD.
+func MinorUnits(currency string) int {
+	return currencyMinorUnits[currency]
+}
+
+// ToMinor mengubah amount ke satuan terkecil mata uang (sen untuk USD).
+func ToMinor(amount float64, currency string) int64 {
+	return int64(math.Round(amount * math.Pow10(MinorUnits(currency))))
+}
+
+// FromMinor adalah kebalikan ToMinor.
+func FromMinor(minor int64, currency string) float64 {
+	return float64(minor) / math.Pow10(MinorUnits(currency))
+}
+
+// RoundAmount membulatkan amount ke satuan terkecil mata uang.
+func RoundAmount(amount float64, currency string) float64 {
+	return FromMinor(ToMinor(amount, currency), currency)
+}
+
+// ValidateAmount memastikan amount positif dan tidak punya digit desimal lebih
+// dari yang dimiliki mata uangnya, misalnya IDR 1000.5 ditolak.
+func ValidateAmount(amount float64, currency string) error {
+	if amount <= 0 {
+		return errors.New("jumlah transaksi tidak valid")
+	}
+	if math.Abs(RoundAmount(amount, currency)-amount) > 1e-9 {
+		return fmt.Errorf("jumlah %s maksimal %d angka desimal", currency, MinorUnits(currency))
+	}
+	return nil
+}
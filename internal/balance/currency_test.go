@@ -0,0 +1,49 @@
+package balance
+
+import "testing"
+
+func TestNormalizeCurrency(t *testing.T) {
+	if got, err := NormalizeCurrency(""); err != nil || got != DefaultCurrency {
+		t.Errorf("expected empty currency to default to %s; got %q, %v", DefaultCurrency, got, err)
+	}
+	if got, err := NormalizeCurrency(" usd "); err != nil || got != "USD" {
+		t.Errorf("expected usd to normalize to USD; got %q, %v", got, err)
+	}
+	if _, err := NormalizeCurrency("XXX"); err != ErrUnsupportedCurrency {
+		t.Errorf("expected ErrUnsupportedCurrency; got %v", err)
+	}
+}
+
+func TestValidateAmountRespectsMinorUnits(t *testing.T) {
+	cases := []struct {
+		amount   float64
+		currency string
+		valid    bool
+	}{
+		{15000, "IDR", true},
+		{15000.5, "IDR", false},
+		{12.34, "USD", true},
+		{12.345, "USD", false},
+		{0, "USD", false},
+		{-1, "IDR", false},
+	}
+
+	for _, tc := range cases {
+		err := ValidateAmount(tc.amount, tc.currency)
+		if (err == nil) != tc.valid {
+			t.Errorf("ValidateAmount(%v, %s): expected valid=%v; got %v", tc.amount, tc.currency, tc.valid, err)
+		}
+	}
+}
+
+func TestMinorUnitConversion(t *testing.T) {
+	if got := ToMinor(19.99, "USD"); got != 1999 {
+		t.Errorf("expected 1999 cents; got %d", got)
+	}
+	if got := ToMinor(15000, "IDR"); got != 15000 {
+		t.Errorf("expected 15000; got %d", got)
+	}
+	if got := FromMinor(1999, "USD"); got != 19.99 {
+		t.Errorf("expected 19.99; got %v", got)
+	}
+}
@@ -0,0 +1,60 @@
+package balance
+
+import (
+	"gorm.io/gorm"
+)
+
+// PlatformRevenueUserID adalah pemilik wallet pendapatan platform, satu per
+// mata uang. ID 0 tidak pernah dipakai oleh user sungguhan.
+const PlatformRevenueUserID uint = 0
+
+// FeeReferencePrefix membedakan baris biaya dari mutasi utamanya di hash chain.
+const FeeReferencePrefix = "FEE-"
+
+// FeeCalculator menghitung biaya sebuah operasi. txType mengikuti jenis
+// transaksi (misalnya TOPUP atau PURCHASE) dan channel boleh kosong.
+type FeeCalculator interface {
+	CalculateFee(userID uint, txType string, channel string, currency string, amount float64) (float64, error)
+}
+
+// PlatformWallet mengembalikan wallet pendapatan platform untuk currency dan
+// membuatnya jika belum ada.
+func PlatformWallet(db *gorm.DB, currency string) (*Wallet, error) {
+	var wallet Wallet
+	err := db.Where("user_id = ? AND currency = ?", PlatformRevenueUserID, currency).
+		FirstOrCreate(&wallet, Wallet{UserID: PlatformRevenueUserID, Currency: currency}).Error
+	if err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+// PostFee mendebit fee dari wallet sebagai baris tersendiri dan mengkreditnya
+// ke wallet pendapatan platform. Panggil di dalam transaksi database yang sama
+// dengan mutasi utamanya supaya keduanya berhasil atau gagal bersama.
+func PostFee(tx *gorm.DB, wallet *Wallet, fee float64, reference string) error {
+	return moveFee(tx, wallet, fee, reference, "DEBIT", "CREDIT")
+}
+
+// ReverseFee mengembalikan fee yang sudah diposting dengan PostFee.
+func ReverseFee(tx *gorm.DB, wallet *Wallet, fee float64, reference string) error {
+	return moveFee(tx, wallet, fee, reference, "CREDIT", "DEBIT")
+}
+
+func moveFee(tx *gorm.DB, wallet *Wallet, fee float64, reference string, userSide string, platformSide string) error {
+	if fee <= 0 {
+		return nil
+	}
+
+	platform, err := PlatformWallet(tx, wallet.Currency)
+	if err != nil {
+		return err
+	}
+
+	feeReference := FeeReferencePrefix + reference
+	if _, err := ApplyWalletEntry(tx, wallet.ID, userSide, fee, feeReference); err != nil {
+		return err
+	}
+	_, err = ApplyWalletEntry(tx, platform.ID, platformSide, fee, feeReference)
+	return err
+}
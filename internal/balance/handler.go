@@ -1,24 +1,39 @@
 package balance
 
 import (
+	"errors"
+	"ewallet-engine/internal/audit"
+	"ewallet-engine/internal/fraud"
+	"fmt"
+
 	"github.com/gofiber/fiber/v2"
 )
 
 type BalanceHandler struct {
-	service BalanceService
+	service      BalanceService
+	auditService audit.AuditService
+	fraudService fraud.FraudService
 }
 
-func NewBalanceHandler(service BalanceService) *BalanceHandler {
-	return &BalanceHandler{service: service}
+func NewBalanceHandler(service BalanceService, auditService audit.AuditService, fraudService fraud.FraudService) *BalanceHandler {
+	return &BalanceHandler{service: service, auditService: auditService, fraudService: fraudService}
 }
 
 func (h *BalanceHandler) GetBalanceHandler(c *fiber.Ctx) error {
 	userID := c.Locals("user_id").(uint) 
-	balance, err := h.service.GetUserBalance(userID)
+	summary, err := h.service.GetBalanceSummary(userID, c.Query("currency"))
 	if err != nil {
+		if errors.Is(err, ErrUnsupportedCurrency) || errors.Is(err, ErrWalletNotFound) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
 	}
-	return c.JSON(fiber.Map{"balance": balance})
+	return c.JSON(fiber.Map{
+		"currency":          summary.Currency,
+		"balance":           summary.Balance,
+		"available_balance": summary.AvailableBalance,
+		"held_balance":      summary.HeldBalance,
+	})
 }
 
 func (h *BalanceHandler) TopUpBalanceHandler(c *fiber.Ctx) error {
@@ -28,6 +43,8 @@ func (h *BalanceHandler) TopUpBalanceHandler(c *fiber.Ctx) error {
 		Amount               float64 `json:"amount"`
 		WalletTransactionType string  `json:"wallet_transaction_type"`
 		Reference            string  `json:"reference"`
+		Currency             string  `json:"currency"`
+		Channel              string  `json:"channel"`
 	}
 
 	if err := c.BodyParser(&request); err != nil {
@@ -38,10 +55,136 @@ func (h *BalanceHandler) TopUpBalanceHandler(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "wallet_transaction_type harus CREDIT atau DEBIT"})
 	}
 
-	err := h.service.ProcessBalanceTransaction(userID, request.Amount, request.WalletTransactionType, request.Reference)
+	operation := fraud.OperationTopUp
+	if request.WalletTransactionType == "DEBIT" {
+		operation = fraud.OperationPurchase
+	}
+
+	// REVIEW menyimpan mutasi sebagai PENDING; saldo baru berubah setelah
+	// operator menyatakan case-nya aman.
+	evaluation, err := h.fraudService.Screen(fraud.Event{
+		UserID:    userID,
+		Operation: operation,
+		Amount:    request.Amount,
+		Reference: request.Reference,
+		DeviceID:  c.Get("X-Device-ID"),
+		IP:        c.IP(),
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+	if evaluation.Decision == fraud.DecisionReview {
+		adjustment, err := h.service.HoldForReview(PendingAdjustment{
+			UserID:                userID,
+			Currency:              request.Currency,
+			Channel:               request.Channel,
+			Amount:                request.Amount,
+			WalletTransactionType: request.WalletTransactionType,
+			Reference:             request.Reference,
+			FraudCaseID:           evaluation.CaseID,
+		})
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+		}
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+			"message": "Transaksi menunggu peninjauan",
+			"data":    adjustment,
+		})
+	}
+	if evaluation.Decision != fraud.DecisionAllow {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": "Transaksi ditahan oleh sistem deteksi fraud",
+			"case_id": evaluation.CaseID,
+		})
+	}
+
+	before, err := h.service.GetBalanceSummary(userID, request.Currency)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	fee, err := h.service.ProcessWithFee(userID, before.Currency, request.Channel, request.Amount, request.WalletTransactionType, request.Reference)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
 	}
 
-	return c.JSON(fiber.Map{"message": "Transaksi berhasil"})
+	after, _ := h.service.GetBalanceSummary(userID, before.Currency)
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionBalanceAdjusted,
+		TargetType: "wallet",
+		TargetID:   fmt.Sprint(userID),
+		Before:     audit.Snapshot{"balance": before.Balance, "currency": before.Currency},
+		After: audit.Snapshot{
+			"balance":                 after.Balance,
+			"currency":                after.Currency,
+			"fee":                     fee,
+			"amount":                  request.Amount,
+			"wallet_transaction_type": request.WalletTransactionType,
+			"reference":               request.Reference,
+		},
+	})
+
+	return c.JSON(fiber.Map{
+		"message": "Transaksi berhasil",
+		"fee":     fee,
+	})
+}
+
+func (h *BalanceHandler) ListWalletsHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	balances, err := h.service.ListBalances(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": balances})
+}
+
+func (h *BalanceHandler) OpenWalletHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	var request struct {
+		Currency string `json:"currency"`
+	}
+
+	if err := c.BodyParser(&request); err != nil || request.Currency == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "currency wajib diisi"})
+	}
+
+	wallet, err := h.service.OpenWallet(userID, request.Currency)
+	if err != nil {
+		if errors.Is(err, ErrWalletExists) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"message": err.Error()})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionWalletOpened,
+		TargetType: "wallet",
+		TargetID:   fmt.Sprint(wallet.ID),
+		After:      audit.Snapshot{"user_id": userID, "currency": wallet.Currency},
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Wallet berhasil dibuka",
+		"data":    wallet,
+	})
+}
+
+func (h *BalanceHandler) VerifyWalletHandler(c *fiber.Ctx) error {
+	walletID, err := c.ParamsInt("id")
+	if err != nil || walletID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID wallet tidak valid"})
+	}
+
+	report, err := h.service.VerifyWallet(uint(walletID))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": report})
 }
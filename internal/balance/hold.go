@@ -0,0 +1,206 @@
+package balance
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrHoldNotFound juga dikembalikan untuk hold milik wallet user lain supaya
+// reference yang bisa ditebak tidak dapat dipakai melepas atau meng-capture
+// hold orang lain.
+var (
+	ErrHoldNotFound      = errors.New("hold tidak ditemukan")
+	ErrHoldNotActive     = errors.New("hold sudah tidak aktif")
+	ErrHoldReferenceUsed = errors.New("reference hold sudah digunakan")
+)
+
+// PlaceHold mencadangkan amount dari saldo tersedia wallet untuk reference.
+// Saldo tidak berubah dan tidak ada entri hash chain sampai hold di-capture.
+func PlaceHold(db *gorm.DB, walletID uint, amount float64, reference string, expiresAt time.Time) (*Hold, error) {
+	var hold *Hold
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var wallet Wallet
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&wallet, walletID).Error
+		if err != nil {
+			return err
+		}
+
+		var existing Hold
+		result := tx.Where("reference = ?", reference).Limit(1).Find(&existing)
+		if result.Error != nil {
+			return result.Error
+		}
+		var found *Hold
+		if result.RowsAffected == 1 {
+			found = &existing
+		}
+
+		hold, err = reserveHold(&wallet, found, amount, reference, expiresAt)
+		if err != nil {
+			return err
+		}
+		if err := tx.Save(&wallet).Error; err != nil {
+			return err
+		}
+		return tx.Create(hold).Error
+	})
+
+	return hold, err
+}
+
+// reserveHold memindahkan amount dari saldo tersedia wallet ke saldo ditahan.
+// existing adalah hold lain dengan reference yang sama, atau nil.
+func reserveHold(wallet *Wallet, existing *Hold, amount float64, reference string, expiresAt time.Time) (*Hold, error) {
+	if amount <= 0 {
+		return nil, errors.New("jumlah hold tidak valid")
+	}
+	if existing != nil {
+		return nil, ErrHoldReferenceUsed
+	}
+	if wallet.Available() < amount {
+		return nil, ErrInsufficientBalance
+	}
+
+	wallet.HeldBalance += amount
+	return &Hold{
+		WalletID:  wallet.ID,
+		Reference: reference,
+		Amount:    amount,
+		Status:    HoldActive,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// CaptureHold mendebit amount dari hold aktif milik reference pada wallet
+// ownerUserID, ditambah fee sebagai baris biaya tersendiri. Capture parsial
+// diperbolehkan; sisa hold langsung dikembalikan ke saldo tersedia.
+func CaptureHold(db *gorm.DB, ownerUserID uint, reference string, amount float64, fee float64) (*WalletTransaction, error) {
+	var entry *WalletTransaction
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		wallet, hold, err := lockHold(tx, ownerUserID, reference)
+		if err != nil {
+			return err
+		}
+
+		if err := captureHeld(wallet, hold, amount); err != nil {
+			return err
+		}
+		if err := tx.Save(wallet).Error; err != nil {
+			return err
+		}
+		entry, err = appendChainedEntry(tx, wallet, "DEBIT", amount, reference)
+		if err != nil {
+			return err
+		}
+		if err := PostFee(tx, wallet, fee, reference); err != nil {
+			return err
+		}
+
+		return tx.Model(hold).Updates(map[string]interface{}{
+			"status":          hold.Status,
+			"captured_amount": hold.CapturedAmount,
+			"resolved_at":     hold.ResolvedAt,
+		}).Error
+	})
+
+	return entry, err
+}
+
+// ReleaseHold mengembalikan seluruh hold aktif milik reference pada wallet
+// ownerUserID ke saldo tersedia dan menandainya dengan status (RELEASED atau
+// EXPIRED).
+func ReleaseHold(db *gorm.DB, ownerUserID uint, reference string, status HoldStatus) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		wallet, hold, err := lockHold(tx, ownerUserID, reference)
+		if err != nil {
+			return err
+		}
+
+		if err := releaseHeld(wallet, hold, status); err != nil {
+			return err
+		}
+		if err := tx.Save(wallet).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(hold).Updates(map[string]interface{}{
+			"status":      hold.Status,
+			"resolved_at": hold.ResolvedAt,
+		}).Error
+	})
+}
+
+// captureHeld melepas seluruh hold aktif dari saldo ditahan lalu mendebit
+// amount dari saldo wallet.
+func captureHeld(wallet *Wallet, hold *Hold, amount float64) error {
+	if hold.Status != HoldActive {
+		return ErrHoldNotActive
+	}
+	if amount <= 0 || amount > hold.Amount {
+		return errors.New("jumlah capture harus lebih dari 0 dan tidak melebihi jumlah hold")
+	}
+
+	if wallet.Available()+hold.Amount < amount {
+		return ErrInsufficientBalance
+	}
+	wallet.HeldBalance -= hold.Amount
+	wallet.Balance -= amount
+
+	now := time.Now()
+	hold.Status = HoldCaptured
+	hold.CapturedAmount = amount
+	hold.ResolvedAt = &now
+	return nil
+}
+
+// releaseHeld mengembalikan seluruh hold aktif ke saldo tersedia wallet.
+func releaseHeld(wallet *Wallet, hold *Hold, status HoldStatus) error {
+	if hold.Status != HoldActive {
+		return ErrHoldNotActive
+	}
+
+	wallet.HeldBalance -= hold.Amount
+	if wallet.HeldBalance < 0 {
+		wallet.HeldBalance = 0
+	}
+
+	now := time.Now()
+	hold.Status = status
+	hold.ResolvedAt = &now
+	return nil
+}
+
+// lockHold mengunci wallet lalu hold-nya, selalu dengan urutan yang sama
+// seperti PlaceHold supaya tidak terjadi deadlock. Hold pada wallet yang bukan
+// milik ownerUserID dianggap tidak ada.
+func lockHold(tx *gorm.DB, ownerUserID uint, reference string) (*Wallet, *Hold, error) {
+	var hold Hold
+	err := tx.Where("reference = ?", reference).First(&hold).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrHoldNotFound
+		}
+		return nil, nil, err
+	}
+
+	var wallet Wallet
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&wallet, hold.WalletID).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	if wallet.UserID != ownerUserID {
+		return nil, nil, ErrHoldNotFound
+	}
+
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&hold, hold.ID).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &wallet, &hold, nil
+}
@@ -0,0 +1,127 @@
+package balance
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReserveHold(t *testing.T) {
+	cases := []struct {
+		name          string
+		heldBalance   float64
+		existing      *Hold
+		amount        float64
+		wantErr       error
+		wantHeld      float64
+		wantAvailable float64
+	}{
+		{"within available", 20000, nil, 30000, nil, 50000, 50000},
+		{"exactly available", 20000, nil, 80000, nil, 100000, 0},
+		{"above available", 20000, nil, 80001, ErrInsufficientBalance, 20000, 80000},
+		{"duplicate reference", 20000, &Hold{Reference: "PAY-1", Status: HoldReleased}, 10000, ErrHoldReferenceUsed, 20000, 80000},
+	}
+
+	for _, tc := range cases {
+		wallet := Wallet{ID: 1, Balance: 100000, HeldBalance: tc.heldBalance}
+		hold, err := reserveHold(&wallet, tc.existing, tc.amount, "PAY-1", time.Now().Add(time.Hour))
+		if !errors.Is(err, tc.wantErr) {
+			t.Errorf("%s: expected error %v; got %v", tc.name, tc.wantErr, err)
+		}
+		if err == nil && (hold.Status != HoldActive || hold.Amount != tc.amount || hold.WalletID != wallet.ID) {
+			t.Errorf("%s: expected an ACTIVE hold of %.2f; got %+v", tc.name, tc.amount, hold)
+		}
+		if wallet.Balance != 100000 || wallet.HeldBalance != tc.wantHeld || wallet.Available() != tc.wantAvailable {
+			t.Errorf("%s: balance/held/available = %.2f/%.2f/%.2f, want 100000.00/%.2f/%.2f",
+				tc.name, wallet.Balance, wallet.HeldBalance, wallet.Available(), tc.wantHeld, tc.wantAvailable)
+		}
+	}
+
+	wallet := Wallet{ID: 1, Balance: 100000}
+	if _, err := reserveHold(&wallet, nil, 0, "PAY-1", time.Now()); err == nil || wallet.HeldBalance != 0 {
+		t.Errorf("expected a zero hold to be rejected without holding; got %v, held %.2f", err, wallet.HeldBalance)
+	}
+}
+
+func TestCaptureHeld(t *testing.T) {
+	cases := []struct {
+		name          string
+		status        HoldStatus
+		amount        float64
+		wantErr       bool
+		wantBalance   float64
+		wantHeld      float64
+		wantAvailable float64
+	}{
+		{"full capture", HoldActive, 30000, false, 70000, 10000, 60000},
+		{"partial capture releases the rest", HoldActive, 25000, false, 75000, 10000, 65000},
+		{"more than the hold amount", HoldActive, 30001, true, 100000, 40000, 60000},
+		{"zero amount", HoldActive, 0, true, 100000, 40000, 60000},
+		{"already captured", HoldCaptured, 30000, true, 100000, 40000, 60000},
+		{"already released", HoldReleased, 30000, true, 100000, 40000, 60000},
+		{"expired", HoldExpired, 30000, true, 100000, 40000, 60000},
+	}
+
+	for _, tc := range cases {
+		// Hold lain sebesar 10000 pada wallet yang sama tidak boleh ikut terlepas.
+		wallet := Wallet{ID: 1, Balance: 100000, HeldBalance: 40000}
+		hold := Hold{WalletID: 1, Reference: "PAY-1", Amount: 30000, Status: tc.status}
+
+		err := captureHeld(&wallet, &hold, tc.amount)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: unexpected error result: %v", tc.name, err)
+		}
+		if tc.status != HoldActive && !errors.Is(err, ErrHoldNotActive) {
+			t.Errorf("%s: expected ErrHoldNotActive; got %v", tc.name, err)
+		}
+		if err == nil && (hold.Status != HoldCaptured || hold.CapturedAmount != tc.amount || hold.ResolvedAt == nil) {
+			t.Errorf("%s: expected hold CAPTURED for %.2f; got %+v", tc.name, tc.amount, hold)
+		}
+		if err != nil && hold.Status != tc.status {
+			t.Errorf("%s: rejected capture must keep status %s; got %s", tc.name, tc.status, hold.Status)
+		}
+		if wallet.Balance != tc.wantBalance || wallet.HeldBalance != tc.wantHeld {
+			t.Errorf("%s: balance/held = %.2f/%.2f, want %.2f/%.2f", tc.name, wallet.Balance, wallet.HeldBalance, tc.wantBalance, tc.wantHeld)
+		}
+		if wallet.Available() != tc.wantAvailable {
+			t.Errorf("%s: available = %.2f, want %.2f", tc.name, wallet.Available(), tc.wantAvailable)
+		}
+		if wallet.Available() != wallet.Balance-wallet.HeldBalance {
+			t.Errorf("%s: available %.2f must equal balance minus held", tc.name, wallet.Available())
+		}
+	}
+}
+
+func TestReleaseHeld(t *testing.T) {
+	cases := []struct {
+		name          string
+		status        HoldStatus
+		releaseAs     HoldStatus
+		wantErr       error
+		wantHeld      float64
+		wantAvailable float64
+	}{
+		{"released", HoldActive, HoldReleased, nil, 10000, 90000},
+		{"expired", HoldActive, HoldExpired, nil, 10000, 90000},
+		{"already captured", HoldCaptured, HoldReleased, ErrHoldNotActive, 40000, 60000},
+		{"already released", HoldReleased, HoldReleased, ErrHoldNotActive, 40000, 60000},
+		{"already expired", HoldExpired, HoldExpired, ErrHoldNotActive, 40000, 60000},
+	}
+
+	for _, tc := range cases {
+		wallet := Wallet{ID: 1, Balance: 100000, HeldBalance: 40000}
+		hold := Hold{WalletID: 1, Reference: "PAY-1", Amount: 30000, Status: tc.status}
+
+		err := releaseHeld(&wallet, &hold, tc.releaseAs)
+		if !errors.Is(err, tc.wantErr) {
+			t.Errorf("%s: expected error %v; got %v", tc.name, tc.wantErr, err)
+		}
+		if err == nil && (hold.Status != tc.releaseAs || hold.ResolvedAt == nil) {
+			t.Errorf("%s: expected hold %s; got %+v", tc.name, tc.releaseAs, hold)
+		}
+		if wallet.Balance != 100000 || wallet.HeldBalance != tc.wantHeld || wallet.Available() != tc.wantAvailable {
+			t.Errorf("%s: balance/held/available = %.2f/%.2f/%.2f, want 100000.00/%.2f/%.2f",
+				tc.name, wallet.Balance, wallet.HeldBalance, wallet.Available(), tc.wantHeld, tc.wantAvailable)
+		}
+	}
+}
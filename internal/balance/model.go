@@ -5,19 +5,104 @@ import (
 )
 
 type Wallet struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	UserID    uint      `gorm:"uniqueIndex" json:"user_id"`
-	Balance   float64   `gorm:"not null;default:0" json:"balance"`
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID     uint `gorm:"primaryKey" json:"id"`
+	UserID uint `gorm:"uniqueIndex:idx_wallets_user_currency" json:"user_id"`
+	// Currency adalah kode ISO 4217; setiap user punya paling banyak satu wallet per mata uang.
+	Currency string  `gorm:"type:char(3);not null;default:'IDR';uniqueIndex:idx_wallets_user_currency" json:"currency"`
+	Balance  float64 `gorm:"not null;default:0" json:"balance"`
+	// HeldBalance adalah bagian Balance yang sedang dicadangkan oleh hold aktif.
+	HeldBalance float64   `gorm:"not null;default:0" json:"held_balance"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 }
 
 type WalletTransaction struct {
-	ID                   uint      `gorm:"primaryKey" json:"id"`
-	WalletID             uint      `gorm:"not null" json:"wallet_id"`
-	Amount               float64   `gorm:"not null" json:"amount"`
+	ID       uint    `gorm:"primaryKey" json:"id"`
+	WalletID uint    `gorm:"not null" json:"wallet_id"`
+	Amount   float64 `gorm:"not null" json:"amount"`
+	// Currency selalu sama dengan mata uang wallet-nya.
+	Currency              string    `gorm:"type:char(3);not null;default:'IDR'" json:"currency"`
 	WalletTransactionType string    `gorm:"column:wallet_transaction_type;type:enum('CREDIT','DEBIT');not null" json:"wallet_transaction_type"`
-	Reference            string    `gorm:"type:varchar(100);not null" json:"reference"`
-	CreatedAt            time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt            time.Time `gorm:"autoUpdateTime" json:"updated_at"`
-}
\ No newline at end of file
+	Reference             string    `gorm:"type:varchar(100);not null" json:"reference"`
+	BalanceAfter          float64   `gorm:"not null;default:0" json:"balance_after"`
+	PrevHash              string    `gorm:"type:char(64);not null;default:''" json:"prev_hash"`
+	Hash                  string    `gorm:"type:char(64);not null;default:'';index" json:"hash"`
+	CreatedAt             time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt             time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// ChainReport adalah hasil verifikasi hash chain dan saldo sebuah wallet.
+type ChainReport struct {
+	WalletID        uint    `json:"wallet_id"`
+	Entries         int     `json:"entries"`
+	Valid           bool    `json:"valid"`
+	BrokenAt        uint    `json:"broken_at,omitempty"`
+	Reason          string  `json:"reason,omitempty"`
+	ExpectedBalance float64 `json:"expected_balance"`
+	ActualBalance   float64 `json:"actual_balance"`
+}
+
+// Available mengembalikan saldo yang masih bisa dipakai, yaitu saldo dikurangi hold.
+func (w Wallet) Available() float64 {
+	return w.Balance - w.HeldBalance
+}
+
+type HoldStatus string
+
+const (
+	HoldActive   HoldStatus = "ACTIVE"
+	HoldCaptured HoldStatus = "CAPTURED"
+	HoldReleased HoldStatus = "RELEASED"
+	HoldExpired  HoldStatus = "EXPIRED"
+)
+
+// Hold mencadangkan sebagian saldo wallet untuk transaksi yang masih PENDING.
+// Saldo baru benar-benar didebit saat hold di-capture.
+type Hold struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	WalletID       uint       `gorm:"not null;index" json:"wallet_id"`
+	Reference      string     `gorm:"type:varchar(255);uniqueIndex;not null" json:"reference"`
+	Amount         float64    `gorm:"not null" json:"amount"`
+	CapturedAmount float64    `gorm:"not null;default:0" json:"captured_amount"`
+	Status         HoldStatus `gorm:"type:enum('ACTIVE','CAPTURED','RELEASED','EXPIRED');default:'ACTIVE';index" json:"status"`
+	ExpiresAt      time.Time  `gorm:"index" json:"expires_at"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// BalanceSummary adalah saldo wallet beserta pembagian tersedia dan ditahan.
+type BalanceSummary struct {
+	Currency         string  `json:"currency"`
+	Balance          float64 `json:"balance"`
+	AvailableBalance float64 `json:"available_balance"`
+	HeldBalance      float64 `json:"held_balance"`
+}
+
+type AdjustmentStatus string
+
+const (
+	AdjustmentPending   AdjustmentStatus = "PENDING"
+	AdjustmentCompleted AdjustmentStatus = "COMPLETED"
+	AdjustmentRejected  AdjustmentStatus = "REJECTED"
+	AdjustmentFailed    AdjustmentStatus = "FAILED"
+)
+
+// PendingAdjustment adalah mutasi langsung (top up) yang ditahan karena
+// keputusan fraud REVIEW. Saldo baru berubah setelah case-nya dinyatakan aman.
+type PendingAdjustment struct {
+	ID                    uint             `gorm:"primaryKey" json:"id"`
+	UserID                uint             `gorm:"not null;index" json:"user_id"`
+	Currency              string           `gorm:"type:char(3);not null;default:'IDR'" json:"currency"`
+	Channel               string           `gorm:"type:varchar(50)" json:"channel,omitempty"`
+	Amount                float64          `gorm:"not null" json:"amount"`
+	WalletTransactionType string           `gorm:"column:wallet_transaction_type;type:enum('CREDIT','DEBIT');not null" json:"wallet_transaction_type"`
+	Reference             string           `gorm:"type:varchar(100);uniqueIndex;not null" json:"reference"`
+	FraudCaseID           uint             `gorm:"not null;index" json:"fraud_case_id"`
+	Status                AdjustmentStatus `gorm:"type:enum('PENDING','COMPLETED','REJECTED','FAILED');default:'PENDING';index" json:"status"`
+	Fee                   float64          `gorm:"not null;default:0" json:"fee"`
+	FailureReason         string           `gorm:"type:varchar(255)" json:"failure_reason,omitempty"`
+	ResolvedAt            *time.Time       `json:"resolved_at,omitempty"`
+	CreatedAt             time.Time        `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt             time.Time        `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -2,14 +2,32 @@ package balance
 
 import (
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type BalanceRepository interface {
-	GetBalance(userID uint) (float64, error)
-	AdjustBalance(userID uint, amount float64, txType string, reference string) error
-	RecordTransaction(walletID uint, txType string, amount float64, reference string) error
+	GetWallet(userID uint, currency string) (*Wallet, error)
+	ListWallets(userID uint) ([]Wallet, error)
+	OpenWallet(userID uint, currency string) (*Wallet, error)
+	AdjustBalance(userID uint, currency string, amount float64, txType string, reference string, fee float64) error
+	// ReferenceBooked melaporkan apakah reference sudah dibukukan dengan jenis
+	// mutasi txType pada wallet currency milik user.
+	ReferenceBooked(userID uint, currency string, txType string, reference string) (bool, error)
+	FindWalletByID(walletID uint) (*Wallet, error)
+	FindWalletIDsAfter(lastID uint, limit int) ([]uint, error)
+	FindWalletEntries(walletID uint) ([]WalletTransaction, error)
+	FindExpiredHolds(now time.Time, limit int) ([]Hold, error)
+	// ReleaseHold melepas hold atas nama pemilik wallet-nya.
+	ReleaseHold(hold Hold, status HoldStatus) error
+
+	CreatePendingAdjustment(adjustment *PendingAdjustment) error
+	FindPendingAdjustmentByCase(caseID uint) (*PendingAdjustment, error)
+	// ResolvePendingAdjustment mengubah mutasi yang masih PENDING dan
+	// melaporkan apakah barisnya memang masih PENDING.
+	ResolvePendingAdjustment(id uint, updates map[string]interface{}) (bool, error)
 }
 
 type balanceRepository struct {
@@ -20,51 +38,122 @@ func NewBalanceRepository(db *gorm.DB) BalanceRepository {
 	return &balanceRepository{DB: db}
 }
 
-func (r *balanceRepository) GetBalance(userID uint) (float64, error) {
-	var wallet Wallet
-	err := r.DB.Where("user_id = ?", userID).First(&wallet).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return 0, nil
-		}
-		return 0, err
+// GetWallet mengembalikan wallet kosong (belum tersimpan) jika user belum punya
+// wallet DefaultCurrency, dan ErrWalletNotFound untuk mata uang lain.
+func (r *balanceRepository) GetWallet(userID uint, currency string) (*Wallet, error) {
+	wallet, err := FindUserWallet(r.DB, userID, currency, false)
+	if errors.Is(err, ErrWalletNotFound) && currency == DefaultCurrency {
+		return &Wallet{UserID: userID, Currency: currency}, nil
 	}
-	return wallet.Balance, nil
+	return wallet, err
 }
 
-func (r *balanceRepository) AdjustBalance(userID uint, amount float64, txType string, reference string) error {
-	var wallet Wallet
+func (r *balanceRepository) ListWallets(userID uint) ([]Wallet, error) {
+	var wallets []Wallet
+	err := r.DB.Where("user_id = ?", userID).Order("id ASC").Find(&wallets).Error
+	return wallets, err
+}
+
+func (r *balanceRepository) OpenWallet(userID uint, currency string) (*Wallet, error) {
+	return OpenWallet(r.DB, userID, currency)
+}
 
-	err := r.DB.Where("user_id = ?", userID).FirstOrCreate(&wallet, Wallet{UserID: userID}).Error
+// AdjustBalance menerapkan mutasi dan, jika fee lebih dari 0, baris biayanya
+// dalam satu transaksi database. Reference yang sudah dibukukan dengan jenis
+// mutasi yang sama pada wallet ditolak.
+func (r *balanceRepository) AdjustBalance(userID uint, currency string, amount float64, txType string, reference string, fee float64) error {
+	wallet, err := FindUserWallet(r.DB, userID, currency, true)
 	if err != nil {
 		return err
 	}
 
-	if txType == "CREDIT" {
-		wallet.Balance += amount
-	} else if txType == "DEBIT" {
-		if wallet.Balance < amount {
-			return errors.New("saldo tidak mencukupi untuk transaksi ini")
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if reference != "" {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&Wallet{}, wallet.ID).Error; err != nil {
+				return err
+			}
+			var booked int64
+			err := tx.Model(&WalletTransaction{}).
+				Where("wallet_id = ? AND reference = ? AND wallet_transaction_type = ?", wallet.ID, reference, txType).
+				Count(&booked).Error
+			if err != nil {
+				return err
+			}
+			if booked > 0 {
+				return ErrReferenceUsed
+			}
 		}
-		wallet.Balance -= amount
-	} else {
-		return errors.New("jenis transaksi tidak valid")
+		if _, err := ApplyWalletEntry(tx, wallet.ID, txType, amount, reference); err != nil {
+			return err
+		}
+		return PostFee(tx, wallet, fee, reference)
+	})
+}
+
+func (r *balanceRepository) ReferenceBooked(userID uint, currency string, txType string, reference string) (bool, error) {
+	wallet, err := FindUserWallet(r.DB, userID, currency, false)
+	if errors.Is(err, ErrWalletNotFound) {
+		return false, nil
 	}
+	if err != nil {
+		return false, err
+	}
+
+	var booked int64
+	err = r.DB.Model(&WalletTransaction{}).
+		Where("wallet_id = ? AND reference = ? AND wallet_transaction_type = ?", wallet.ID, reference, txType).
+		Count(&booked).Error
+	return booked > 0, err
+}
 
-	err = r.DB.Save(&wallet).Error
+func (r *balanceRepository) FindWalletByID(walletID uint) (*Wallet, error) {
+	var wallet Wallet
+	err := r.DB.First(&wallet, walletID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+func (r *balanceRepository) FindWalletIDsAfter(lastID uint, limit int) ([]uint, error) {
+	var ids []uint
+	err := r.DB.Model(&Wallet{}).Where("id > ?", lastID).Order("id ASC").Limit(limit).Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *balanceRepository) FindWalletEntries(walletID uint) ([]WalletTransaction, error) {
+	var entries []WalletTransaction
+	err := r.DB.Where("wallet_id = ?", walletID).Order("id ASC").Find(&entries).Error
+	return entries, err
+}
+
+func (r *balanceRepository) FindExpiredHolds(now time.Time, limit int) ([]Hold, error) {
+	var holds []Hold
+	err := r.DB.Where("status = ? AND expires_at <= ?", HoldActive, now).Order("id ASC").Limit(limit).Find(&holds).Error
+	return holds, err
+}
+
+func (r *balanceRepository) ReleaseHold(hold Hold, status HoldStatus) error {
+	wallet, err := r.FindWalletByID(hold.WalletID)
 	if err != nil {
 		return err
 	}
+	return ReleaseHold(r.DB, wallet.UserID, hold.Reference, status)
+}
 
-	return r.RecordTransaction(wallet.ID, txType, amount, reference)
+func (r *balanceRepository) CreatePendingAdjustment(adjustment *PendingAdjustment) error {
+	return r.DB.Create(adjustment).Error
 }
 
-func (r *balanceRepository) RecordTransaction(walletID uint, txType string, amount float64, reference string) error {
-	tx := WalletTransaction{
-		WalletID:             walletID,
-		WalletTransactionType: txType, 
-		Amount:               amount,
-		Reference:            reference,
+func (r *balanceRepository) FindPendingAdjustmentByCase(caseID uint) (*PendingAdjustment, error) {
+	var adjustment PendingAdjustment
+	if err := r.DB.Where("fraud_case_id = ?", caseID).First(&adjustment).Error; err != nil {
+		return nil, err
 	}
-	return r.DB.Create(&tx).Error
+	return &adjustment, nil
+}
+
+func (r *balanceRepository) ResolvePendingAdjustment(id uint, updates map[string]interface{}) (bool, error) {
+	result := r.DB.Model(&PendingAdjustment{}).Where("id = ? AND status = ?", id, AdjustmentPending).Updates(updates)
+	return result.RowsAffected == 1, result.Error
 }
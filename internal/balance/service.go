@@ -1,28 +1,334 @@
 package balance
 
-import "errors"
+import (
+	"errors"
+	"ewallet-engine/internal/limits"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const verifyBatchSize = 500
 
 type BalanceService interface {
-	GetUserBalance(userID uint) (float64, error)
-	ProcessBalanceTransaction(userID uint, amount float64, txType string, reference string) error
+	GetBalanceSummary(userID uint, currency string) (*BalanceSummary, error)
+	ListBalances(userID uint) ([]BalanceSummary, error)
+	OpenWallet(userID uint, currency string) (*Wallet, error)
+	ProcessBalanceTransaction(userID uint, currency string, amount float64, txType string, reference string) error
+	ProcessWithFee(userID uint, currency string, channel string, amount float64, txType string, reference string) (float64, error)
+	VerifyWallet(walletID uint) (*ChainReport, error)
+	VerifyAllWallets() ([]ChainReport, error)
+	ExpireHolds() ([]Hold, error)
+
+	HoldForReview(adjustment PendingAdjustment) (*PendingAdjustment, error)
+	ResolveReview(caseID uint, approved bool) (*PendingAdjustment, error)
+}
+
+// ErrAdjustmentNotFound dikembalikan ResolveReview bila case fraud tidak
+// menahan mutasi langsung apa pun.
+var ErrAdjustmentNotFound = errors.New("mutasi yang ditahan tidak ditemukan")
+
+// CreditGuard memvalidasi kredit sebelum saldo wallet bertambah, misalnya
+// batas saldo per tier KYC.
+type CreditGuard interface {
+	CheckCredit(userID uint, amount float64) error
 }
 
 type balanceService struct {
-	repo BalanceRepository
+	repo        BalanceRepository
+	limiter     limits.LimitService
+	creditGuard CreditGuard
+	fees        FeeCalculator
+}
+
+func NewBalanceService(repo BalanceRepository, limiter limits.LimitService, creditGuard CreditGuard, fees FeeCalculator) BalanceService {
+	return &balanceService{repo: repo, limiter: limiter, creditGuard: creditGuard, fees: fees}
+}
+
+func (s *balanceService) GetBalanceSummary(userID uint, currency string) (*BalanceSummary, error) {
+	currency, err := NormalizeCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	wallet, err := s.repo.GetWallet(userID, currency)
+	if err != nil {
+		return nil, err
+	}
+	summary := summarize(*wallet)
+	return &summary, nil
+}
+
+// ListBalances mengembalikan saldo setiap wallet user, selalu termasuk wallet
+// DefaultCurrency walaupun belum pernah dipakai.
+func (s *balanceService) ListBalances(userID uint) ([]BalanceSummary, error) {
+	wallets, err := s.repo.ListWallets(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]BalanceSummary, 0, len(wallets)+1)
+	hasDefault := false
+	for _, wallet := range wallets {
+		if wallet.Currency == DefaultCurrency {
+			hasDefault = true
+		}
+		summaries = append(summaries, summarize(wallet))
+	}
+	if !hasDefault {
+		summaries = append([]BalanceSummary{{Currency: DefaultCurrency}}, summaries...)
+	}
+	return summaries, nil
 }
 
-func NewBalanceService(repo BalanceRepository) BalanceService {
-	return &balanceService{repo: repo}
+func (s *balanceService) OpenWallet(userID uint, currency string) (*Wallet, error) {
+	currency, err := NormalizeCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.OpenWallet(userID, currency)
 }
 
-func (s *balanceService) GetUserBalance(userID uint) (float64, error) {
-	return s.repo.GetBalance(userID)
+func summarize(wallet Wallet) BalanceSummary {
+	return BalanceSummary{
+		Currency:         wallet.Currency,
+		Balance:          wallet.Balance,
+		AvailableBalance: wallet.Available(),
+		HeldBalance:      wallet.HeldBalance,
+	}
+}
+
+// ProcessBalanceTransaction menyesuaikan saldo wallet currency tanpa biaya,
+// misalnya untuk penyesuaian manual oleh operator. Limit dan batas tier KYC
+// dinyatakan dalam DefaultCurrency sehingga hanya diperiksa untuk wallet
+// DefaultCurrency.
+func (s *balanceService) ProcessBalanceTransaction(userID uint, currency string, amount float64, txType string, reference string) error {
+	currency, err := NormalizeCurrency(currency)
+	if err != nil {
+		return err
+	}
+	if err := ValidateAmount(amount, currency); err != nil {
+		return err
+	}
+	return s.process(userID, currency, amount, txType, reference, 0)
 }
 
-func (s *balanceService) ProcessBalanceTransaction(userID uint, amount float64, txType string, reference string) error {
-	if amount <= 0 {
-		return errors.New("jumlah transaksi tidak valid")
+// ProcessWithFee sama seperti ProcessBalanceTransaction tetapi juga memotong
+// biaya sesuai jadwal fee untuk channel, lalu mengembalikan biaya yang dipotong.
+func (s *balanceService) ProcessWithFee(userID uint, currency string, channel string, amount float64, txType string, reference string) (float64, error) {
+	currency, err := NormalizeCurrency(currency)
+	if err != nil {
+		return 0, err
+	}
+	if err := ValidateAmount(amount, currency); err != nil {
+		return 0, err
+	}
+
+	fee, err := s.fees.CalculateFee(userID, feeTransactionType(txType), channel, currency, amount)
+	if err != nil {
+		return 0, err
+	}
+	if txType == "CREDIT" && fee >= amount {
+		return 0, errors.New("jumlah top up harus lebih besar dari biaya")
+	}
+
+	return fee, s.process(userID, currency, amount, txType, reference, fee)
+}
+
+// feeTransactionType memetakan mutasi langsung ke jenis transaksi jadwal fee.
+func feeTransactionType(txType string) string {
+	if txType == "CREDIT" {
+		return "TOPUP"
+	}
+	return "PURCHASE"
+}
+
+func (s *balanceService) process(userID uint, currency string, amount float64, txType string, reference string, fee float64) error {
+	operation := limits.OperationDebit
+	if txType == "CREDIT" {
+		operation = limits.OperationTopUp
+	}
+	reserved := false
+
+	if reference != "" {
+		booked, err := s.repo.ReferenceBooked(userID, currency, txType, reference)
+		if err != nil {
+			return err
+		}
+		if booked {
+			return ErrReferenceUsed
+		}
 	}
 
-	return s.repo.AdjustBalance(userID, amount, txType, reference)
+	if currency == DefaultCurrency {
+		if txType == "CREDIT" {
+			if err := s.creditGuard.CheckCredit(userID, amount); err != nil {
+				return err
+			}
+		}
+
+		// Reservasi yang sudah ada tanpa mutasi berarti percobaan sebelumnya
+		// terputus sebelum saldo dibukukan. Limit-nya sudah terpakai, jadi
+		// mutasi dilanjutkan tanpa reservasi baru dan AdjustBalance tetap
+		// menolak reference yang sudah dibukukan.
+		created, err := s.limiter.Reserve(userID, operation, amount, reference)
+		if err != nil {
+			return err
+		}
+		reserved = created
+	}
+
+	if err := s.repo.AdjustBalance(userID, currency, amount, txType, reference, fee); err != nil {
+		if reserved {
+			if releaseErr := s.limiter.Release(userID, operation, reference); releaseErr != nil {
+				log.Printf("ERROR: Gagal mengembalikan limit user_id %d: %v", userID, releaseErr)
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *balanceService) VerifyWallet(walletID uint) (*ChainReport, error) {
+	wallet, err := s.repo.FindWalletByID(walletID)
+	if err != nil {
+		return nil, errors.New("wallet tidak ditemukan")
+	}
+
+	entries, err := s.repo.FindWalletEntries(walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := VerifyChain(*wallet, entries)
+	return &report, nil
+}
+
+// VerifyAllWallets memverifikasi setiap wallet dan hanya mengembalikan laporan
+// untuk wallet yang chain-nya rusak.
+func (s *balanceService) VerifyAllWallets() ([]ChainReport, error) {
+	var broken []ChainReport
+	var lastID uint
+
+	for {
+		ids, err := s.repo.FindWalletIDsAfter(lastID, verifyBatchSize)
+		if err != nil {
+			return broken, err
+		}
+		if len(ids) == 0 {
+			return broken, nil
+		}
+
+		for _, id := range ids {
+			report, err := s.VerifyWallet(id)
+			if err != nil {
+				log.Printf("ERROR: Gagal memverifikasi wallet %d: %v", id, err)
+				continue
+			}
+			if !report.Valid {
+				broken = append(broken, *report)
+			}
+		}
+		lastID = ids[len(ids)-1]
+	}
+}
+
+// ExpireHolds melepas hold aktif yang sudah lewat expires_at dan mengembalikan
+// daftar hold yang berhasil dilepas.
+func (s *balanceService) ExpireHolds() ([]Hold, error) {
+	var expired []Hold
+
+	for {
+		holds, err := s.repo.FindExpiredHolds(time.Now(), verifyBatchSize)
+		if err != nil {
+			return expired, err
+		}
+
+		released := 0
+		for _, hold := range holds {
+			err := s.repo.ReleaseHold(hold, HoldExpired)
+			if err != nil {
+				if !errors.Is(err, ErrHoldNotActive) {
+					log.Printf("ERROR: Gagal melepas hold %s yang kedaluwarsa: %v", hold.Reference, err)
+				}
+				continue
+			}
+			hold.Status = HoldExpired
+			expired = append(expired, hold)
+			released++
+		}
+
+		if len(holds) < verifyBatchSize || released == 0 {
+			return expired, nil
+		}
+	}
+}
+
+// HoldForReview menyimpan mutasi langsung yang mendapat keputusan fraud REVIEW
+// sebagai PENDING. Saldo, limit dan fee baru diproses oleh ResolveReview.
+func (s *balanceService) HoldForReview(adjustment PendingAdjustment) (*PendingAdjustment, error) {
+	currency, err := NormalizeCurrency(adjustment.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateAmount(adjustment.Amount, currency); err != nil {
+		return nil, err
+	}
+	if adjustment.Reference == "" || adjustment.FraudCaseID == 0 {
+		return nil, errors.New("reference dan case fraud wajib diisi")
+	}
+
+	adjustment.Currency = currency
+	adjustment.Status = AdjustmentPending
+	if err := s.repo.CreatePendingAdjustment(&adjustment); err != nil {
+		return nil, err
+	}
+	return &adjustment, nil
+}
+
+// ResolveReview menyelesaikan mutasi yang ditahan case fraud caseID. Bila
+// approved, mutasi dibukukan dengan aturan yang sama seperti top up langsung;
+// kegagalan saat itu, misalnya batas tier KYC, menandai mutasi FAILED. Bila
+// tidak, mutasi ditolak tanpa menyentuh saldo.
+func (s *balanceService) ResolveReview(caseID uint, approved bool) (*PendingAdjustment, error) {
+	adjustment, err := s.repo.FindPendingAdjustmentByCase(caseID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAdjustmentNotFound
+		}
+		return nil, err
+	}
+	if adjustment.Status != AdjustmentPending {
+		return adjustment, nil
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"resolved_at": &now}
+	var processErr error
+	if !approved {
+		updates["status"] = AdjustmentRejected
+	} else {
+		fee, err := s.ProcessWithFee(adjustment.UserID, adjustment.Currency, adjustment.Channel, adjustment.Amount, adjustment.WalletTransactionType, adjustment.Reference)
+		if err != nil {
+			processErr = err
+			updates["status"] = AdjustmentFailed
+			updates["failure_reason"] = err.Error()
+		} else {
+			updates["status"] = AdjustmentCompleted
+			updates["fee"] = fee
+			adjustment.Fee = fee
+		}
+	}
+
+	ok, err := s.repo.ResolvePendingAdjustment(adjustment.ID, updates)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		log.Printf("ALERT: Mutasi %s sudah diselesaikan proses lain", adjustment.Reference)
+	}
+	adjustment.Status = updates["status"].(AdjustmentStatus)
+	adjustment.ResolvedAt = &now
+	return adjustment, processErr
 }
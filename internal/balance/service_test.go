@@ -0,0 +1,171 @@
+package balance
+
+import (
+	"errors"
+	"ewallet-engine/internal/limits"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+type fakeBalanceRepository struct {
+	BalanceRepository
+	adjustments map[uint]*PendingAdjustment
+	balances    map[uint]float64
+	booked      map[string]bool
+}
+
+func newFakeBalanceRepository() *fakeBalanceRepository {
+	return &fakeBalanceRepository{adjustments: make(map[uint]*PendingAdjustment), balances: make(map[uint]float64), booked: make(map[string]bool)}
+}
+
+func (r *fakeBalanceRepository) AdjustBalance(userID uint, currency string, amount float64, txType string, reference string, fee float64) error {
+	if r.booked[txType+reference] {
+		return ErrReferenceUsed
+	}
+	r.booked[txType+reference] = true
+	if txType == "CREDIT" {
+		r.balances[userID] += amount - fee
+	} else {
+		r.balances[userID] -= amount + fee
+	}
+	return nil
+}
+
+func (r *fakeBalanceRepository) ReferenceBooked(userID uint, currency string, txType string, reference string) (bool, error) {
+	return r.booked[txType+reference], nil
+}
+
+func (r *fakeBalanceRepository) CreatePendingAdjustment(adjustment *PendingAdjustment) error {
+	adjustment.ID = uint(len(r.adjustments) + 1)
+	stored := *adjustment
+	r.adjustments[adjustment.ID] = &stored
+	return nil
+}
+
+func (r *fakeBalanceRepository) FindPendingAdjustmentByCase(caseID uint) (*PendingAdjustment, error) {
+	for _, adjustment := range r.adjustments {
+		if adjustment.FraudCaseID == caseID {
+			copied := *adjustment
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeBalanceRepository) ResolvePendingAdjustment(id uint, updates map[string]interface{}) (bool, error) {
+	adjustment := r.adjustments[id]
+	if adjustment.Status != AdjustmentPending {
+		return false, nil
+	}
+	adjustment.Status = updates["status"].(AdjustmentStatus)
+	return true, nil
+}
+
+type fakeLimiter struct {
+	limits.LimitService
+	reserved map[string]bool
+}
+
+func (l fakeLimiter) Reserve(userID uint, operation limits.Operation, amount float64, reference string) (bool, error) {
+	if l.reserved == nil {
+		return true, nil
+	}
+	if l.reserved[reference] {
+		return false, nil
+	}
+	l.reserved[reference] = true
+	return true, nil
+}
+
+func (l fakeLimiter) Release(userID uint, operation limits.Operation, reference string) error {
+	delete(l.reserved, reference)
+	return nil
+}
+
+type fakeCreditGuard struct {
+	maxCredit float64
+}
+
+func (g fakeCreditGuard) CheckCredit(userID uint, amount float64) error {
+	if amount > g.maxCredit {
+		return errors.New("saldo melebihi batas maksimum")
+	}
+	return nil
+}
+
+type flatFee float64
+
+func (f flatFee) CalculateFee(userID uint, txType string, channel string, currency string, amount float64) (float64, error) {
+	return float64(f), nil
+}
+
+func TestResolveReviewBooksOnlyClearedTopUps(t *testing.T) {
+	cases := []struct {
+		name        string
+		amount      float64
+		approved    bool
+		wantStatus  AdjustmentStatus
+		wantBalance float64
+		wantErr     bool
+	}{
+		{"cleared", 50000, true, AdjustmentCompleted, 48500, false},
+		{"confirmed fraud", 50000, false, AdjustmentRejected, 0, false},
+		{"cleared above KYC cap", 150000, true, AdjustmentFailed, 0, true},
+	}
+	for _, tc := range cases {
+		repo := newFakeBalanceRepository()
+		service := NewBalanceService(repo, fakeLimiter{}, fakeCreditGuard{maxCredit: 100000}, flatFee(1500))
+
+		held, err := service.HoldForReview(PendingAdjustment{UserID: 7, Amount: tc.amount, WalletTransactionType: "CREDIT", Reference: "TOP-1", FraudCaseID: 3})
+		if err != nil {
+			t.Fatalf("%s: unexpected error holding top up: %v", tc.name, err)
+		}
+		if held.Status != AdjustmentPending || repo.balances[7] != 0 {
+			t.Fatalf("%s: held top up must stay PENDING without moving the balance", tc.name)
+		}
+
+		resolved, err := service.ResolveReview(3, tc.approved)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: unexpected error result: %v", tc.name, err)
+		}
+		if resolved.Status != tc.wantStatus || repo.adjustments[held.ID].Status != tc.wantStatus {
+			t.Errorf("%s: status = %s, want %s", tc.name, repo.adjustments[held.ID].Status, tc.wantStatus)
+		}
+		if repo.balances[7] != tc.wantBalance {
+			t.Errorf("%s: balance = %.2f, want %.2f", tc.name, repo.balances[7], tc.wantBalance)
+		}
+
+		// Keputusan kedua untuk case yang sama tidak membukukan ulang.
+		if _, err := service.ResolveReview(3, true); err != nil || repo.balances[7] != tc.wantBalance {
+			t.Errorf("%s: second resolution must be a no-op; balance %.2f, err %v", tc.name, repo.balances[7], err)
+		}
+	}
+
+	service := NewBalanceService(newFakeBalanceRepository(), fakeLimiter{}, fakeCreditGuard{}, flatFee(0))
+	if _, err := service.ResolveReview(99, true); !errors.Is(err, ErrAdjustmentNotFound) {
+		t.Errorf("expected ErrAdjustmentNotFound for a case without a held top up; got %v", err)
+	}
+}
+
+func TestProcessBooksReferenceOnce(t *testing.T) {
+	repo := newFakeBalanceRepository()
+	limiter := fakeLimiter{reserved: make(map[string]bool)}
+	service := NewBalanceService(repo, limiter, fakeCreditGuard{maxCredit: 1000000}, flatFee(0))
+
+	// Percobaan sebelumnya terputus setelah limit direservasi tetapi sebelum
+	// saldo dibukukan; pengulangan tetap harus membukukan mutasinya.
+	limiter.reserved["APR-1"] = true
+	if err := service.ProcessBalanceTransaction(7, "IDR", 50000, "CREDIT", "APR-1"); err != nil {
+		t.Fatalf("expected interrupted credit to be booked on retry: %v", err)
+	}
+	if err := service.ProcessBalanceTransaction(7, "IDR", 50000, "CREDIT", "APR-1"); !errors.Is(err, ErrReferenceUsed) {
+		t.Fatalf("expected ErrReferenceUsed for a booked reference; got %v", err)
+	}
+	if repo.balances[7] != 50000 {
+		t.Fatalf("expected the credit booked once; balance %.2f", repo.balances[7])
+	}
+	if !limiter.reserved["APR-1"] {
+		t.Fatal("rejected retry must not release the original reservation")
+	}
+}
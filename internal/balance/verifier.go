@@ -0,0 +1,59 @@
+package balance
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// StartChainVerifier menjalankan VerifyAllWallets secara berkala sampai ctx
+// dibatalkan dan melaporkan setiap wallet yang chain-nya rusak ke log.
+func StartChainVerifier(ctx context.Context, service BalanceService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			broken, err := service.VerifyAllWallets()
+			if err != nil {
+				log.Printf("ERROR: Verifikasi hash chain wallet gagal: %v", err)
+			}
+			for _, report := range broken {
+				log.Printf("ALERT: Hash chain wallet %d rusak pada entri %d: %s (saldo tercatat %.2f, hasil hitung %.2f)",
+					report.WalletID, report.BrokenAt, report.Reason, report.ActualBalance, report.ExpectedBalance)
+			}
+			if err == nil && len(broken) == 0 {
+				log.Println("SUCCESS: Semua hash chain wallet valid")
+			}
+		}
+	}
+}
+
+// StartHoldExpirer melepas hold yang kedaluwarsa secara berkala sampai ctx
+// dibatalkan. onExpired dipanggil untuk setiap hold yang dilepas supaya
+// pemilik hold (misalnya transaksi PENDING) bisa ikut ditutup.
+func StartHoldExpirer(ctx context.Context, service BalanceService, interval time.Duration, onExpired func(hold Hold)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := service.ExpireHolds()
+			if err != nil {
+				log.Printf("ERROR: Gagal melepas hold yang kedaluwarsa: %v", err)
+			}
+			for _, hold := range expired {
+				log.Printf("SUCCESS: Hold %s sebesar %.2f kedaluwarsa dan dilepas", hold.Reference, hold.Amount)
+				if onExpired != nil {
+					onExpired(hold)
+				}
+			}
+		}
+	}
+}
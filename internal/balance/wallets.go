@@ -0,0 +1,52 @@
+package balance
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrWalletNotFound = errors.New("wallet untuk mata uang ini belum dibuka")
+	ErrWalletExists   = errors.New("wallet untuk mata uang ini sudah ada")
+)
+
+// FindUserWallet mencari wallet user untuk currency. Wallet DefaultCurrency
+// dibuat otomatis jika create bernilai true; wallet mata uang lain harus dibuka
+// lewat OpenWallet.
+func FindUserWallet(db *gorm.DB, userID uint, currency string, create bool) (*Wallet, error) {
+	var wallet Wallet
+
+	query := db.Where("user_id = ? AND currency = ?", userID, currency)
+	var err error
+	if create && currency == DefaultCurrency {
+		err = query.FirstOrCreate(&wallet, Wallet{UserID: userID, Currency: currency}).Error
+	} else {
+		err = query.First(&wallet).Error
+	}
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWalletNotFound
+		}
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+// OpenWallet membuat wallet kosong untuk currency yang belum dimiliki user.
+func OpenWallet(db *gorm.DB, userID uint, currency string) (*Wallet, error) {
+	_, err := FindUserWallet(db, userID, currency, false)
+	if err == nil {
+		return nil, ErrWalletExists
+	}
+	if !errors.Is(err, ErrWalletNotFound) {
+		return nil, err
+	}
+
+	wallet := Wallet{UserID: userID, Currency: currency}
+	if err := db.Create(&wallet).Error; err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
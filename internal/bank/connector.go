@@ -0,0 +1,86 @@
+package bank
+
+import (
+	"context"
+	"errors"
+	"sort"
+)
+
+type TransferStatus string
+
+const (
+	TransferSuccess TransferStatus = "SUCCESS"
+	TransferFailed  TransferStatus = "FAILED"
+	TransferPending TransferStatus = "PENDING"
+)
+
+var (
+	ErrUnsupportedBank  = errors.New("bank tidak didukung")
+	ErrAccountNotFound  = errors.New("rekening tujuan tidak ditemukan")
+	ErrTransferNotFound = errors.New("transfer tidak ditemukan di bank")
+	ErrConnectorTimeout = errors.New("bank tidak merespons tepat waktu")
+)
+
+// Bank adalah bank tujuan yang didukung beserta kode yang dipakai connector.
+type Bank struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+var supportedBanks = map[string]string{
+	"BCA":     "Bank Central Asia",
+	"BNI":     "Bank Negara Indonesia",
+	"BRI":     "Bank Rakyat Indonesia",
+	"MANDIRI": "Bank Mandiri",
+	"BSI":     "Bank Syariah Indonesia",
+	"CIMB":    "CIMB Niaga",
+	"PERMATA": "Bank Permata",
+}
+
+// SupportedBanks mengembalikan daftar bank tujuan yang diurutkan berdasarkan kode.
+func SupportedBanks() []Bank {
+	banks := make([]Bank, 0, len(supportedBanks))
+	for code, name := range supportedBanks {
+		banks = append(banks, Bank{Code: code, Name: name})
+	}
+	sort.Slice(banks, func(i, j int) bool { return banks[i].Code < banks[j].Code })
+	return banks
+}
+
+func IsSupported(code string) bool {
+	_, ok := supportedBanks[code]
+	return ok
+}
+
+type InquiryResult struct {
+	BankCode      string `json:"bank_code"`
+	AccountNumber string `json:"account_number"`
+	AccountName   string `json:"account_name"`
+}
+
+type TransferRequest struct {
+	Reference     string
+	BankCode      string
+	AccountNumber string
+	AccountName   string
+	Amount        float64
+	Currency      string
+	Remark        string
+}
+
+type TransferResult struct {
+	Status        TransferStatus `json:"status"`
+	ExternalID    string         `json:"external_id,omitempty"`
+	FailureReason string         `json:"failure_reason,omitempty"`
+}
+
+// BankConnector adalah jalur keluar dana ke rekening bank. Transfer yang
+// mengembalikan error (termasuk timeout) berarti hasilnya tidak diketahui dan
+// harus dipastikan lewat Status memakai reference yang sama; reference juga
+// menjadi kunci idempotensi sehingga Transfer ulang tidak mengirim dana dua kali.
+type BankConnector interface {
+	Name() string
+	Inquiry(ctx context.Context, bankCode string, accountNumber string) (*InquiryResult, error)
+	Transfer(ctx context.Context, request TransferRequest) (*TransferResult, error)
+	Status(ctx context.Context, reference string) (*TransferResult, error)
+}
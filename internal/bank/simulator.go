@@ -0,0 +1,167 @@
+package bank
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Akhiran nomor rekening yang memicu skenario tertentu di Simulator.
+const (
+	simulatorNotFoundSuffix    = "0000"
+	simulatorFailSuffix        = "9991"
+	simulatorTimeoutSuffix     = "9992"
+	simulatorPendingSuffix     = "9993"
+	simulatorLostSuffix        = "9994"
+	simulatorPendingSettleTime = 30 * time.Second
+)
+
+var simulatorFirstNames = []string{"Andi", "Budi", "Citra", "Dewi", "Eko", "Fitri", "Gilang", "Hana", "Indra", "Joko"}
+var simulatorLastNames = []string{"Pratama", "Santoso", "Wijaya", "Lestari", "Saputra", "Kurniawan", "Hidayat", "Rahmawati"}
+
+type simulatedTransfer struct {
+	result    TransferResult
+	settlesAt time.Time
+}
+
+// Simulator adalah BankConnector lokal untuk pengembangan dan pengujian.
+// Hasilnya ditentukan oleh akhiran nomor rekening:
+//
+//	0000  inquiry gagal, rekening tidak ditemukan
+//	9991  transfer ditolak bank
+//	9992  timeout, tetapi bank sebenarnya memproses transfer (Status -> SUCCESS)
+//	9993  transfer PENDING dan berhasil setelah 30 detik
+//	9994  timeout dan transfer tidak pernah sampai ke bank (Status -> tidak ditemukan)
+//
+// Rekening lain selalu berhasil. Data transfer hanya disimpan di memori.
+type Simulator struct {
+	latency time.Duration
+
+	mu        sync.Mutex
+	transfers map[string]*simulatedTransfer
+	sequence  int
+}
+
+func NewSimulator(latency time.Duration) *Simulator {
+	return &Simulator{latency: latency, transfers: make(map[string]*simulatedTransfer)}
+}
+
+func (s *Simulator) Name() string {
+	return "simulator"
+}
+
+func (s *Simulator) Inquiry(ctx context.Context, bankCode string, accountNumber string) (*InquiryResult, error) {
+	if !IsSupported(bankCode) {
+		return nil, ErrUnsupportedBank
+	}
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(accountNumber, simulatorNotFoundSuffix) {
+		return nil, ErrAccountNotFound
+	}
+
+	return &InquiryResult{
+		BankCode:      bankCode,
+		AccountNumber: accountNumber,
+		AccountName:   simulatedAccountName(bankCode, accountNumber),
+	}, nil
+}
+
+func (s *Simulator) Transfer(ctx context.Context, request TransferRequest) (*TransferResult, error) {
+	if !IsSupported(request.BankCode) {
+		return nil, ErrUnsupportedBank
+	}
+
+	s.mu.Lock()
+	if existing, ok := s.transfers[request.Reference]; ok {
+		result := s.current(existing)
+		s.mu.Unlock()
+		return &result, nil
+	}
+	s.mu.Unlock()
+
+	if strings.HasSuffix(request.AccountNumber, simulatorLostSuffix) {
+		return nil, ErrConnectorTimeout
+	}
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sequence++
+	transfer := &simulatedTransfer{result: TransferResult{
+		Status:     TransferSuccess,
+		ExternalID: fmt.Sprintf("SIM-%s-%06d", request.BankCode, s.sequence),
+	}}
+
+	switch {
+	case strings.HasSuffix(request.AccountNumber, simulatorFailSuffix):
+		transfer.result.Status = TransferFailed
+		transfer.result.FailureReason = "rekening tujuan sudah ditutup"
+	case strings.HasSuffix(request.AccountNumber, simulatorPendingSuffix):
+		transfer.result.Status = TransferPending
+		transfer.settlesAt = time.Now().Add(simulatorPendingSettleTime)
+	}
+	s.transfers[request.Reference] = transfer
+
+	if strings.HasSuffix(request.AccountNumber, simulatorTimeoutSuffix) {
+		return nil, ErrConnectorTimeout
+	}
+
+	result := transfer.result
+	return &result, nil
+}
+
+func (s *Simulator) Status(ctx context.Context, reference string) (*TransferResult, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	transfer, ok := s.transfers[reference]
+	if !ok {
+		return nil, ErrTransferNotFound
+	}
+	result := s.current(transfer)
+	return &result, nil
+}
+
+// current harus dipanggil dengan s.mu terkunci.
+func (s *Simulator) current(transfer *simulatedTransfer) TransferResult {
+	if transfer.result.Status == TransferPending && !transfer.settlesAt.IsZero() && time.Now().After(transfer.settlesAt) {
+		transfer.result.Status = TransferSuccess
+	}
+	return transfer.result
+}
+
+func (s *Simulator) wait(ctx context.Context) error {
+	if s.latency <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(s.latency)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ErrConnectorTimeout
+	case <-timer.C:
+		return nil
+	}
+}
+
+func simulatedAccountName(bankCode string, accountNumber string) string {
+	h := fnv.New32a()
+	h.Write([]byte(bankCode + accountNumber))
+	sum := h.Sum32()
+	return strings.ToUpper(simulatorFirstNames[sum%uint32(len(simulatorFirstNames))] + " " +
+		simulatorLastNames[(sum/7)%uint32(len(simulatorLastNames))])
+}
@@ -0,0 +1,82 @@
+package bank
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSimulatorTransferScenarios(t *testing.T) {
+	sim := NewSimulator(0)
+	ctx := context.Background()
+
+	cases := []struct {
+		account    string
+		wantErr    error
+		wantStatus TransferStatus
+		statusErr  error
+	}{
+		{account: "1234567890", wantStatus: TransferSuccess},
+		{account: "1234569991", wantStatus: TransferFailed},
+		{account: "1234569993", wantStatus: TransferPending},
+		{account: "1234569992", wantErr: ErrConnectorTimeout, wantStatus: TransferSuccess},
+		{account: "1234569994", wantErr: ErrConnectorTimeout, statusErr: ErrTransferNotFound},
+	}
+
+	for _, tc := range cases {
+		reference := "WD-" + tc.account
+		result, err := sim.Transfer(ctx, TransferRequest{Reference: reference, BankCode: "BCA", AccountNumber: tc.account, Amount: 10000})
+		if tc.wantErr != nil {
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("%s: err = %v, want %v", tc.account, err, tc.wantErr)
+			}
+		} else if err != nil || result.Status != tc.wantStatus {
+			t.Fatalf("%s: result = %+v, err = %v", tc.account, result, err)
+		}
+
+		status, err := sim.Status(ctx, reference)
+		if tc.statusErr != nil {
+			if !errors.Is(err, tc.statusErr) {
+				t.Fatalf("%s: status err = %v, want %v", tc.account, err, tc.statusErr)
+			}
+			continue
+		}
+		if err != nil || status.Status != tc.wantStatus {
+			t.Fatalf("%s: status = %+v, err = %v", tc.account, status, err)
+		}
+	}
+}
+
+func TestSimulatorTransferIsIdempotent(t *testing.T) {
+	sim := NewSimulator(0)
+	request := TransferRequest{Reference: "WD-1", BankCode: "BNI", AccountNumber: "5550001111", Amount: 50000}
+
+	first, err := sim.Transfer(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := sim.Transfer(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.ExternalID != second.ExternalID {
+		t.Fatalf("transfer ganda menghasilkan external id berbeda: %s vs %s", first.ExternalID, second.ExternalID)
+	}
+}
+
+func TestSimulatorInquiry(t *testing.T) {
+	sim := NewSimulator(0)
+
+	if _, err := sim.Inquiry(context.Background(), "XYZ", "123"); !errors.Is(err, ErrUnsupportedBank) {
+		t.Fatalf("err = %v, want ErrUnsupportedBank", err)
+	}
+	if _, err := sim.Inquiry(context.Background(), "BCA", "1230000"); !errors.Is(err, ErrAccountNotFound) {
+		t.Fatalf("err = %v, want ErrAccountNotFound", err)
+	}
+
+	a, _ := sim.Inquiry(context.Background(), "BCA", "1234567890")
+	b, _ := sim.Inquiry(context.Background(), "BCA", "1234567890")
+	if a.AccountName == "" || a.AccountName != b.AccountName {
+		t.Fatalf("nama rekening harus deterministik: %q vs %q", a.AccountName, b.AccountName)
+	}
+}
@@ -0,0 +1,155 @@
+package billers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type PaymentStatus string
+
+const (
+	PaymentSuccess PaymentStatus = "SUCCESS"
+	PaymentFailed  PaymentStatus = "FAILED"
+	PaymentPending PaymentStatus = "PENDING"
+)
+
+var (
+	ErrUnknownBiller       = errors.New("biller tidak didukung")
+	ErrInvalidCustomer     = errors.New("nomor pelanggan tidak valid")
+	ErrInvalidDenomination = errors.New("nominal tidak tersedia untuk biller ini")
+	ErrCustomerNotFound    = errors.New("nomor pelanggan tidak ditemukan")
+	ErrBillAlreadyPaid     = errors.New("tagihan sudah lunas atau belum terbit")
+	ErrPaymentNotFound     = errors.New("pembayaran tidak ditemukan di biller")
+	ErrProviderTimeout     = errors.New("biller tidak merespons tepat waktu")
+)
+
+type Category string
+
+const (
+	CategoryElectricity Category = "ELECTRICITY"
+	CategoryMobile      Category = "MOBILE"
+	CategoryInsurance   Category = "INSURANCE"
+)
+
+// BillType menentukan asal nominal: POSTPAID dari inquiry ke biller,
+// PREPAID dari denominasi yang dipilih user.
+type BillType string
+
+const (
+	BillPostpaid BillType = "POSTPAID"
+	BillPrepaid  BillType = "PREPAID"
+)
+
+// Biller adalah produk PPOB yang didukung beserta aturan nomor pelanggannya.
+type Biller struct {
+	Code          string    `json:"code"`
+	Name          string    `json:"name"`
+	Category      Category  `json:"category"`
+	Type          BillType  `json:"type"`
+	AdminFee      float64   `json:"admin_fee"`
+	Denominations []float64 `json:"denominations,omitempty"`
+	NumberPrefix  string    `json:"-"`
+	MinLength     int       `json:"-"`
+	MaxLength     int       `json:"-"`
+}
+
+var catalog = []Biller{
+	{Code: "PLN_POSTPAID", Name: "PLN Pascabayar", Category: CategoryElectricity, Type: BillPostpaid, AdminFee: 2500, MinLength: 11, MaxLength: 12},
+	{Code: "PLN_PREPAID", Name: "Token Listrik PLN", Category: CategoryElectricity, Type: BillPrepaid, AdminFee: 2500,
+		Denominations: []float64{20000, 50000, 100000, 200000, 500000, 1000000}, MinLength: 11, MaxLength: 12},
+	{Code: "PULSA_TELKOMSEL", Name: "Pulsa Telkomsel", Category: CategoryMobile, Type: BillPrepaid,
+		Denominations: []float64{5000, 10000, 20000, 25000, 50000, 100000}, NumberPrefix: "08", MinLength: 10, MaxLength: 13},
+	{Code: "PULSA_INDOSAT", Name: "Pulsa Indosat Ooredoo", Category: CategoryMobile, Type: BillPrepaid,
+		Denominations: []float64{5000, 10000, 20000, 25000, 50000, 100000}, NumberPrefix: "08", MinLength: 10, MaxLength: 13},
+	{Code: "PULSA_XL", Name: "Pulsa XL Axiata", Category: CategoryMobile, Type: BillPrepaid,
+		Denominations: []float64{5000, 10000, 25000, 50000, 100000}, NumberPrefix: "08", MinLength: 10, MaxLength: 13},
+	{Code: "BPJS_KESEHATAN", Name: "BPJS Kesehatan", Category: CategoryInsurance, Type: BillPostpaid, AdminFee: 2500,
+		NumberPrefix: "88888", MinLength: 16, MaxLength: 16},
+}
+
+// Catalog mengembalikan daftar biller, seluruhnya atau hanya satu kategori.
+func Catalog(category Category) []Biller {
+	billers := make([]Biller, 0, len(catalog))
+	for _, biller := range catalog {
+		if category == "" || biller.Category == category {
+			billers = append(billers, biller)
+		}
+	}
+	return billers
+}
+
+func Find(code string) (Biller, bool) {
+	for _, biller := range catalog {
+		if biller.Code == code {
+			return biller, true
+		}
+	}
+	return Biller{}, false
+}
+
+// ValidateCustomerNumber memeriksa format nomor pelanggan sebelum inquiry
+// supaya kesalahan ketik tidak sampai ke aggregator.
+func (b Biller) ValidateCustomerNumber(number string) error {
+	if len(number) < b.MinLength || len(number) > b.MaxLength || !strings.HasPrefix(number, b.NumberPrefix) {
+		return ErrInvalidCustomer
+	}
+	for _, r := range number {
+		if r < '0' || r > '9' {
+			return ErrInvalidCustomer
+		}
+	}
+	return nil
+}
+
+// ValidateDenomination memastikan nominal PREPAID termasuk daftar denominasi.
+func (b Biller) ValidateDenomination(amount float64) error {
+	for _, denomination := range b.Denominations {
+		if amount == denomination {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %.0f", ErrInvalidDenomination, amount)
+}
+
+type InquiryRequest struct {
+	BillerCode     string
+	CustomerNumber string
+	// Amount hanya diisi untuk biller PREPAID.
+	Amount float64
+}
+
+type InquiryResult struct {
+	BillerCode     string  `json:"biller_code"`
+	CustomerNumber string  `json:"customer_number"`
+	CustomerName   string  `json:"customer_name"`
+	Period         string  `json:"period,omitempty"`
+	Amount         float64 `json:"amount"`
+	AdminFee       float64 `json:"admin_fee"`
+}
+
+type PaymentRequest struct {
+	Reference      string
+	BillerCode     string
+	CustomerNumber string
+	Amount         float64
+}
+
+type PaymentResult struct {
+	Status        PaymentStatus `json:"status"`
+	ExternalID    string        `json:"external_id,omitempty"`
+	SerialNumber  string        `json:"serial_number,omitempty"`
+	FailureReason string        `json:"failure_reason,omitempty"`
+}
+
+// BillerProvider adalah aggregator PPOB. Pay yang mengembalikan error
+// (termasuk timeout) berarti hasilnya tidak diketahui dan harus dipastikan
+// lewat Status memakai reference yang sama; reference juga menjadi kunci
+// idempotensi sehingga Pay ulang tidak membayar tagihan dua kali.
+type BillerProvider interface {
+	Name() string
+	Inquiry(ctx context.Context, request InquiryRequest) (*InquiryResult, error)
+	Pay(ctx context.Context, request PaymentRequest) (*PaymentResult, error)
+	Status(ctx context.Context, reference string) (*PaymentResult, error)
+}
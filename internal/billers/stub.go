@@ -0,0 +1,222 @@
+package billers
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Akhiran nomor pelanggan yang memicu skenario tertentu di Stub.
+const (
+	stubNotFoundSuffix    = "0000"
+	stubPaidSuffix        = "1111"
+	stubFailSuffix        = "9991"
+	stubTimeoutSuffix     = "9992"
+	stubPendingSuffix     = "9993"
+	stubLostSuffix        = "9994"
+	stubPendingSettleTime = 30 * time.Second
+)
+
+var stubFirstNames = []string{"Agus", "Bambang", "Sri", "Dian", "Rina", "Hendra", "Wahyu", "Yanti", "Rudi", "Tuti"}
+var stubLastNames = []string{"Susanto", "Halim", "Nugroho", "Permata", "Siregar", "Wibowo", "Hasibuan", "Utami"}
+
+type stubPayment struct {
+	result    PaymentResult
+	settlesAt time.Time
+}
+
+// Stub adalah BillerProvider lokal untuk pengembangan dan pengujian.
+// Hasilnya ditentukan oleh akhiran nomor pelanggan:
+//
+//	0000  inquiry gagal, nomor pelanggan tidak ditemukan
+//	1111  inquiry POSTPAID gagal, tagihan sudah lunas
+//	9991  pembayaran ditolak biller
+//	9992  timeout, tetapi biller sebenarnya memproses pembayaran (Status -> SUCCESS)
+//	9993  pembayaran PENDING dan berhasil setelah 30 detik
+//	9994  timeout dan pembayaran tidak pernah sampai ke biller (Status -> tidak ditemukan)
+//
+// Nomor lain selalu berhasil. Tagihan POSTPAID dihitung deterministik dari
+// nomor pelanggan dan data pembayaran hanya disimpan di memori.
+type Stub struct {
+	latency time.Duration
+
+	mu       sync.Mutex
+	payments map[string]*stubPayment
+	sequence int
+}
+
+func NewStub(latency time.Duration) *Stub {
+	return &Stub{latency: latency, payments: make(map[string]*stubPayment)}
+}
+
+func (s *Stub) Name() string {
+	return "stub"
+}
+
+func (s *Stub) Inquiry(ctx context.Context, request InquiryRequest) (*InquiryResult, error) {
+	biller, ok := Find(request.BillerCode)
+	if !ok {
+		return nil, ErrUnknownBiller
+	}
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(request.CustomerNumber, stubNotFoundSuffix) {
+		return nil, ErrCustomerNotFound
+	}
+
+	result := &InquiryResult{
+		BillerCode:     biller.Code,
+		CustomerNumber: request.CustomerNumber,
+		CustomerName:   stubCustomerName(biller.Code, request.CustomerNumber),
+		AdminFee:       biller.AdminFee,
+	}
+	if biller.Type == BillPrepaid {
+		if err := biller.ValidateDenomination(request.Amount); err != nil {
+			return nil, err
+		}
+		result.Amount = request.Amount
+		return result, nil
+	}
+
+	if strings.HasSuffix(request.CustomerNumber, stubPaidSuffix) {
+		return nil, ErrBillAlreadyPaid
+	}
+	result.Amount = stubBillAmount(biller.Code, request.CustomerNumber)
+	result.Period = time.Now().Format("2006-01")
+	return result, nil
+}
+
+func (s *Stub) Pay(ctx context.Context, request PaymentRequest) (*PaymentResult, error) {
+	biller, ok := Find(request.BillerCode)
+	if !ok {
+		return nil, ErrUnknownBiller
+	}
+
+	s.mu.Lock()
+	if existing, ok := s.payments[request.Reference]; ok {
+		result := s.current(existing)
+		s.mu.Unlock()
+		return &result, nil
+	}
+	s.mu.Unlock()
+
+	if strings.HasSuffix(request.CustomerNumber, stubLostSuffix) {
+		return nil, ErrProviderTimeout
+	}
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sequence++
+	payment := &stubPayment{result: PaymentResult{
+		Status:       PaymentSuccess,
+		ExternalID:   fmt.Sprintf("STUB-%s-%06d", biller.Code, s.sequence),
+		SerialNumber: stubSerialNumber(biller, request.Reference, s.sequence),
+	}}
+
+	switch {
+	case strings.HasSuffix(request.CustomerNumber, stubFailSuffix):
+		payment.result = PaymentResult{Status: PaymentFailed, ExternalID: payment.result.ExternalID, FailureReason: "pelanggan diblokir oleh biller"}
+	case biller.Type == BillPostpaid && request.Amount != stubBillAmount(biller.Code, request.CustomerNumber):
+		payment.result = PaymentResult{Status: PaymentFailed, ExternalID: payment.result.ExternalID, FailureReason: "jumlah tagihan tidak sesuai"}
+	case strings.HasSuffix(request.CustomerNumber, stubPendingSuffix):
+		payment.result.Status = PaymentPending
+		payment.settlesAt = time.Now().Add(stubPendingSettleTime)
+	}
+	s.payments[request.Reference] = payment
+
+	if strings.HasSuffix(request.CustomerNumber, stubTimeoutSuffix) {
+		return nil, ErrProviderTimeout
+	}
+
+	result := s.current(payment)
+	return &result, nil
+}
+
+func (s *Stub) Status(ctx context.Context, reference string) (*PaymentResult, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	payment, ok := s.payments[reference]
+	if !ok {
+		return nil, ErrPaymentNotFound
+	}
+	result := s.current(payment)
+	return &result, nil
+}
+
+// current harus dipanggil dengan s.mu terkunci. Serial number baru terlihat
+// setelah pembayaran SUCCESS.
+func (s *Stub) current(payment *stubPayment) PaymentResult {
+	if payment.result.Status == PaymentPending && !payment.settlesAt.IsZero() && time.Now().After(payment.settlesAt) {
+		payment.result.Status = PaymentSuccess
+	}
+	result := payment.result
+	if result.Status != PaymentSuccess {
+		result.SerialNumber = ""
+	}
+	return result
+}
+
+func (s *Stub) wait(ctx context.Context) error {
+	if s.latency <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(s.latency)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ErrProviderTimeout
+	case <-timer.C:
+		return nil
+	}
+}
+
+func stubHash(parts ...string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(strings.Join(parts, "|")))
+	return h.Sum32()
+}
+
+func stubCustomerName(billerCode string, customerNumber string) string {
+	sum := stubHash(billerCode, customerNumber)
+	return strings.ToUpper(stubFirstNames[sum%uint32(len(stubFirstNames))] + " " +
+		stubLastNames[(sum/7)%uint32(len(stubLastNames))])
+}
+
+// stubBillAmount menghasilkan tagihan bulanan yang stabil per pelanggan:
+// BPJS sebesar iuran per anggota keluarga, PLN dalam kelipatan 100 rupiah.
+func stubBillAmount(billerCode string, customerNumber string) float64 {
+	sum := stubHash(billerCode, customerNumber)
+	if billerCode == "BPJS_KESEHATAN" {
+		return float64(sum%5+1) * 42000
+	}
+	return 50000 + float64(sum%7000)*100
+}
+
+// stubSerialNumber meniru token listrik 20 digit untuk PLN prabayar dan
+// nomor seri voucher untuk produk lain.
+func stubSerialNumber(biller Biller, reference string, sequence int) string {
+	if biller.Code != "PLN_PREPAID" {
+		return fmt.Sprintf("SN%010d", sequence)
+	}
+	token := fmt.Sprintf("%010d%010d", stubHash(reference), stubHash(reference, "token"))
+	groups := make([]string, 0, 5)
+	for i := 0; i < len(token); i += 4 {
+		groups = append(groups, token[i:i+4])
+	}
+	return strings.Join(groups, "-")
+}
@@ -0,0 +1,127 @@
+package billers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestStubPaymentScenarios(t *testing.T) {
+	stub := NewStub(0)
+	ctx := context.Background()
+
+	cases := []struct {
+		number     string
+		wantErr    error
+		wantStatus PaymentStatus
+		statusErr  error
+	}{
+		{number: "081234567890", wantStatus: PaymentSuccess},
+		{number: "081234569991", wantStatus: PaymentFailed},
+		{number: "081234569993", wantStatus: PaymentPending},
+		{number: "081234569992", wantErr: ErrProviderTimeout, wantStatus: PaymentSuccess},
+		{number: "081234569994", wantErr: ErrProviderTimeout, statusErr: ErrPaymentNotFound},
+	}
+
+	for _, tc := range cases {
+		reference := "BILL-" + tc.number
+		result, err := stub.Pay(ctx, PaymentRequest{Reference: reference, BillerCode: "PULSA_TELKOMSEL", CustomerNumber: tc.number, Amount: 10000})
+		if tc.wantErr != nil {
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("%s: err = %v, want %v", tc.number, err, tc.wantErr)
+			}
+		} else if err != nil || result.Status != tc.wantStatus {
+			t.Fatalf("%s: result = %+v, err = %v", tc.number, result, err)
+		}
+
+		status, err := stub.Status(ctx, reference)
+		if tc.statusErr != nil {
+			if !errors.Is(err, tc.statusErr) {
+				t.Fatalf("%s: status err = %v, want %v", tc.number, err, tc.statusErr)
+			}
+			continue
+		}
+		if err != nil || status.Status != tc.wantStatus {
+			t.Fatalf("%s: status = %+v, err = %v", tc.number, status, err)
+		}
+		if (status.SerialNumber != "") != (status.Status == PaymentSuccess) {
+			t.Fatalf("%s: serial number %q pada status %s", tc.number, status.SerialNumber, status.Status)
+		}
+	}
+}
+
+func TestStubPayIsIdempotent(t *testing.T) {
+	stub := NewStub(0)
+	request := PaymentRequest{Reference: "BILL-1", BillerCode: "PLN_PREPAID", CustomerNumber: "123456789012", Amount: 50000}
+
+	first, err := stub.Pay(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := stub.Pay(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.ExternalID != second.ExternalID || first.SerialNumber != second.SerialNumber {
+		t.Fatalf("pembayaran ganda menghasilkan hasil berbeda: %+v vs %+v", first, second)
+	}
+	if len(strings.ReplaceAll(first.SerialNumber, "-", "")) != 20 {
+		t.Fatalf("token listrik harus 20 digit: %q", first.SerialNumber)
+	}
+}
+
+func TestStubPostpaidInquiryAndPay(t *testing.T) {
+	stub := NewStub(0)
+	ctx := context.Background()
+
+	if _, err := stub.Inquiry(ctx, InquiryRequest{BillerCode: "PLN_POSTPAID", CustomerNumber: "123450000"}); !errors.Is(err, ErrCustomerNotFound) {
+		t.Fatalf("err = %v, want ErrCustomerNotFound", err)
+	}
+	if _, err := stub.Inquiry(ctx, InquiryRequest{BillerCode: "PLN_POSTPAID", CustomerNumber: "123451111"}); !errors.Is(err, ErrBillAlreadyPaid) {
+		t.Fatalf("err = %v, want ErrBillAlreadyPaid", err)
+	}
+
+	bill, err := stub.Inquiry(ctx, InquiryRequest{BillerCode: "PLN_POSTPAID", CustomerNumber: "512345678901"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := stub.Inquiry(ctx, InquiryRequest{BillerCode: "PLN_POSTPAID", CustomerNumber: "512345678901"})
+	if bill.Amount <= 0 || bill.Amount != again.Amount || bill.CustomerName != again.CustomerName {
+		t.Fatalf("inquiry harus deterministik: %+v vs %+v", bill, again)
+	}
+
+	mismatch, err := stub.Pay(ctx, PaymentRequest{Reference: "BILL-A", BillerCode: "PLN_POSTPAID", CustomerNumber: "512345678901", Amount: bill.Amount + 100})
+	if err != nil || mismatch.Status != PaymentFailed {
+		t.Fatalf("pembayaran dengan jumlah berbeda harus gagal: %+v, err = %v", mismatch, err)
+	}
+	paid, err := stub.Pay(ctx, PaymentRequest{Reference: "BILL-B", BillerCode: "PLN_POSTPAID", CustomerNumber: "512345678901", Amount: bill.Amount})
+	if err != nil || paid.Status != PaymentSuccess {
+		t.Fatalf("result = %+v, err = %v", paid, err)
+	}
+}
+
+func TestBillerValidation(t *testing.T) {
+	pulsa, _ := Find("PULSA_XL")
+	for number, valid := range map[string]bool{
+		"081234567890":   true,
+		"0812345":        false,
+		"0812345678901x": false,
+		"621234567890":   false,
+	} {
+		if err := pulsa.ValidateCustomerNumber(number); (err == nil) != valid {
+			t.Errorf("ValidateCustomerNumber(%q) = %v, want valid %v", number, err, valid)
+		}
+	}
+
+	if err := pulsa.ValidateDenomination(25000); err != nil {
+		t.Errorf("25000 harus tersedia: %v", err)
+	}
+	if err := pulsa.ValidateDenomination(20000); !errors.Is(err, ErrInvalidDenomination) {
+		t.Errorf("err = %v, want ErrInvalidDenomination", err)
+	}
+
+	if len(Catalog(CategoryMobile)) != 3 || len(Catalog("")) != len(catalog) {
+		t.Errorf("Catalog tidak memfilter kategori dengan benar")
+	}
+}
@@ -0,0 +1,179 @@
+package billpayments
+
+import (
+	"errors"
+	"ewallet-engine/internal/audit"
+	"ewallet-engine/internal/auth"
+	"ewallet-engine/internal/billers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type BillPaymentHandler struct {
+	service      BillPaymentService
+	auditService audit.AuditService
+}
+
+func NewBillPaymentHandler(service BillPaymentService, auditService audit.AuditService) *BillPaymentHandler {
+	return &BillPaymentHandler{service: service, auditService: auditService}
+}
+
+func (h *BillPaymentHandler) ListBillersHandler(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"data": h.service.ListBillers(billers.Category(c.Query("category")))})
+}
+
+func (h *BillPaymentHandler) InquiryHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	var request struct {
+		BillerCode     string  `json:"biller_code"`
+		CustomerNumber string  `json:"customer_number"`
+		Amount         float64 `json:"amount"`
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	inquiry, err := h.service.Inquiry(userID, request.BillerCode, request.CustomerNumber, request.Amount)
+	if err != nil {
+		return h.billerError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": inquiry})
+}
+
+func (h *BillPaymentHandler) PayHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	var request struct {
+		InquiryID uint   `json:"inquiry_id"`
+		Reference string `json:"reference"`
+		PIN       string `json:"pin"`
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	payment, err := h.service.Pay(userID, request.InquiryID, request.Reference, request.PIN)
+	if err != nil {
+		return h.paymentError(c, err)
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionBillPaymentRequested,
+		TargetType: "bill_payment",
+		TargetID:   payment.Reference,
+		After:      paymentSnapshot(payment),
+	})
+
+	status := fiber.StatusAccepted
+	if payment.IsFinal() {
+		status = fiber.StatusOK
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"message": "Pembayaran tagihan diproses",
+		"data":    payment,
+	})
+}
+
+func (h *BillPaymentHandler) GetPaymentHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	payment, err := h.service.GetPayment(userID, c.Params("reference"))
+	if err != nil {
+		return h.paymentError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": payment})
+}
+
+func (h *BillPaymentHandler) ListMyPaymentsHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	payments, err := h.service.ListPayments(userID, PaymentStatus(c.Query("status")))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": payments})
+}
+
+func (h *BillPaymentHandler) ListPaymentsHandler(c *fiber.Ctx) error {
+	payments, err := h.service.ListPayments(0, PaymentStatus(c.Query("status")))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": payments})
+}
+
+func (h *BillPaymentHandler) ReconcileHandler(c *fiber.Ctx) error {
+	reference := c.Params("reference")
+
+	before, err := h.service.GetPayment(0, reference)
+	if err != nil {
+		return h.paymentError(c, err)
+	}
+
+	after, err := h.service.Reconcile(reference)
+	if err != nil {
+		return h.paymentError(c, err)
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionBillPaymentReconciled,
+		TargetType: "bill_payment",
+		TargetID:   reference,
+		Before:     paymentSnapshot(before),
+		After:      paymentSnapshot(after),
+	})
+
+	return c.JSON(fiber.Map{
+		"message": "Rekonsiliasi pembayaran tagihan selesai",
+		"data":    after,
+	})
+}
+
+func (h *BillPaymentHandler) billerError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, billers.ErrCustomerNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, billers.ErrBillAlreadyPaid):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, billers.ErrProviderTimeout):
+		return c.Status(fiber.StatusGatewayTimeout).JSON(fiber.Map{"message": err.Error()})
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+}
+
+func (h *BillPaymentHandler) paymentError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, ErrPaymentNotFound), errors.Is(err, ErrInquiryNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, ErrInquiryUsed), errors.Is(err, ErrInquiryExpired):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, auth.ErrPINLocked):
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, auth.ErrInvalidPIN), errors.Is(err, auth.ErrPINNotSet):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"message": err.Error()})
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+}
+
+func paymentSnapshot(payment *BillPayment) audit.Snapshot {
+	return audit.Snapshot{
+		"status":          payment.Status,
+		"biller_code":     payment.BillerCode,
+		"customer_number": payment.CustomerNumber,
+		"amount":          payment.Amount,
+		"admin_fee":       payment.AdminFee,
+		"fee":             payment.Fee,
+		"external_id":     payment.ExternalID,
+	}
+}
@@ -0,0 +1,87 @@
+package billpayments
+
+import (
+	"ewallet-engine/internal/billers"
+	"time"
+)
+
+// BillInquiry menyimpan hasil inquiry supaya pembayaran memakai nominal dan
+// nama pelanggan yang sudah dikonfirmasi user, bukan input ulang dari client.
+type BillInquiry struct {
+	ID             uint             `gorm:"primaryKey" json:"id"`
+	UserID         uint             `gorm:"not null;index" json:"user_id"`
+	BillerCode     string           `gorm:"type:varchar(30);not null" json:"biller_code"`
+	Category       billers.Category `gorm:"type:varchar(20);not null" json:"category"`
+	CustomerNumber string           `gorm:"type:varchar(30);not null" json:"customer_number"`
+	CustomerName   string           `gorm:"type:varchar(150);not null" json:"customer_name"`
+	Period         string           `gorm:"type:varchar(20)" json:"period,omitempty"`
+	Amount         float64          `gorm:"not null" json:"amount"`
+	AdminFee       float64          `gorm:"not null;default:0" json:"admin_fee"`
+	Fee            float64          `gorm:"not null;default:0" json:"fee"`
+	Total          float64          `gorm:"not null" json:"total"`
+	Currency       string           `gorm:"type:char(3);not null;default:'IDR'" json:"currency"`
+	ExpiresAt      time.Time        `gorm:"not null" json:"expires_at"`
+	UsedAt         *time.Time       `json:"used_at,omitempty"`
+	CreatedAt      time.Time        `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type PaymentStatus string
+
+const (
+	// StatusPending: dana sudah di-hold, belum dikirim ke biller.
+	StatusPending PaymentStatus = "PENDING"
+	// StatusProcessing: biller menerima pembayaran tetapi belum final.
+	StatusProcessing PaymentStatus = "PROCESSING"
+	// StatusUnknown: provider error atau timeout; hasil dipastikan oleh polling status.
+	StatusUnknown PaymentStatus = "UNKNOWN"
+	StatusSuccess PaymentStatus = "SUCCESS"
+	StatusFailed  PaymentStatus = "FAILED"
+)
+
+// BillPayment adalah pembayaran tagihan atau pembelian produk PPOB. Detail
+// tagihan disalin dari BillInquiry. SerialNumber berisi token listrik atau
+// nomor seri voucher dari biller setelah SUCCESS.
+type BillPayment struct {
+	ID             uint             `gorm:"primaryKey" json:"id"`
+	Reference      string           `gorm:"type:varchar(100);uniqueIndex;not null" json:"reference"`
+	UserID         uint             `gorm:"not null;index" json:"user_id"`
+	InquiryID      uint             `gorm:"not null" json:"inquiry_id"`
+	BillerCode     string           `gorm:"type:varchar(30);not null" json:"biller_code"`
+	Category       billers.Category `gorm:"type:varchar(20);not null" json:"category"`
+	CustomerNumber string           `gorm:"type:varchar(30);not null" json:"customer_number"`
+	CustomerName   string           `gorm:"type:varchar(150);not null" json:"customer_name"`
+	Period         string           `gorm:"type:varchar(20)" json:"period,omitempty"`
+	Amount         float64          `gorm:"not null" json:"amount"`
+	AdminFee       float64          `gorm:"not null;default:0" json:"admin_fee"`
+	Fee            float64          `gorm:"not null;default:0" json:"fee"`
+	Currency       string           `gorm:"type:char(3);not null;default:'IDR'" json:"currency"`
+	Status         PaymentStatus    `gorm:"type:enum('PENDING','PROCESSING','UNKNOWN','SUCCESS','FAILED');default:'PENDING';index" json:"status"`
+	Provider       string           `gorm:"type:varchar(30);not null" json:"provider"`
+	ExternalID     string           `gorm:"type:varchar(100)" json:"external_id,omitempty"`
+	SerialNumber   string           `gorm:"type:varchar(100)" json:"serial_number,omitempty"`
+	FailureReason  string           `gorm:"type:varchar(255)" json:"failure_reason,omitempty"`
+	CheckAttempts  int              `gorm:"not null;default:0" json:"check_attempts"`
+	LastCheckedAt  *time.Time       `json:"last_checked_at,omitempty"`
+	CompletedAt    *time.Time       `json:"completed_at,omitempty"`
+	CreatedAt      time.Time        `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time        `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// IsFinal melaporkan apakah pembayaran sudah selesai dan hold-nya sudah diputuskan.
+func (p BillPayment) IsFinal() bool {
+	return p.Status == StatusSuccess || p.Status == StatusFailed
+}
+
+// HoldReferencePrefix membedakan hold pembayaran tagihan dari hold fitur lain
+// karena reference pembayaran dipilih user.
+const HoldReferencePrefix = "BILL-"
+
+// HoldReference adalah reference hold saldo untuk pembayaran ini.
+func (p BillPayment) HoldReference() string {
+	return HoldReferencePrefix + p.Reference
+}
+
+// Charged adalah jumlah yang didebit dari wallet di luar fee platform.
+func (p BillPayment) Charged() float64 {
+	return p.Amount + p.AdminFee
+}
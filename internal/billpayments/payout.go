@@ -0,0 +1,114 @@
+package billpayments
+
+import (
+	"ewallet-engine/internal/notifications"
+	"ewallet-engine/internal/payouts"
+	"fmt"
+	"log"
+	"time"
+)
+
+var payoutStates = map[PaymentStatus]payouts.State{
+	StatusPending:    payouts.StatePending,
+	StatusProcessing: payouts.StateProcessing,
+	StatusUnknown:    payouts.StateUnknown,
+	StatusSuccess:    payouts.StateSucceeded,
+	StatusFailed:     payouts.StateFailed,
+}
+
+// paymentPayout menjalankan BillPayment lewat payouts.Machine.
+type paymentPayout struct {
+	service *billPaymentService
+	payment *BillPayment
+}
+
+func (p *paymentPayout) Reference() string {
+	return p.payment.Reference
+}
+
+func (p *paymentPayout) State() payouts.State {
+	return payoutStates[p.payment.Status]
+}
+
+func (p *paymentPayout) Transition(to payouts.State, outcome payouts.Outcome) (bool, error) {
+	next := *p.payment
+	now := time.Now()
+	switch to {
+	case payouts.StateProcessing:
+		next.Status = StatusProcessing
+		if outcome.ExternalID != "" {
+			next.ExternalID = outcome.ExternalID
+		}
+	case payouts.StateUnknown:
+		next.Status = StatusUnknown
+		next.FailureReason = outcome.FailureReason
+	case payouts.StateSucceeded:
+		next.Status = StatusSuccess
+		next.ExternalID = outcome.ExternalID
+		next.SerialNumber = outcome.SerialNumber
+		next.FailureReason = ""
+		next.CompletedAt = &now
+	case payouts.StateFailed:
+		next.Status = StatusFailed
+		next.FailureReason = outcome.FailureReason
+		next.CompletedAt = &now
+	}
+
+	ok, err := p.service.repo.TransitionStatus(p.payment.ID, p.payment.Status, map[string]interface{}{
+		"status":         next.Status,
+		"external_id":    next.ExternalID,
+		"serial_number":  next.SerialNumber,
+		"failure_reason": next.FailureReason,
+		"completed_at":   next.CompletedAt,
+	})
+	if err == nil && ok {
+		*p.payment = next
+	}
+	return ok, err
+}
+
+func (p *paymentPayout) CaptureHold() error {
+	payment := p.payment
+	return p.service.repo.CaptureHold(payment.UserID, payment.HoldReference(), payment.Charged(), payment.Fee)
+}
+
+func (p *paymentPayout) ReleaseHold() error {
+	return p.service.repo.ReleaseHold(p.payment.UserID, p.payment.HoldReference())
+}
+
+func (p *paymentPayout) Succeeded() {
+	payment := p.payment
+	body := fmt.Sprintf("Pembayaran %s untuk %s berhasil.", payment.BillerCode, payment.CustomerNumber)
+	if payment.SerialNumber != "" {
+		body += " No. seri/token: " + payment.SerialNumber
+	}
+	p.service.notifier.Notify(payment.UserID, NotificationPaid, "Pembayaran tagihan berhasil", body,
+		notifications.Data{"reference": payment.Reference, "serial_number": payment.SerialNumber})
+	log.Printf("SUCCESS: Pembayaran tagihan %s sebesar %.2f ke %s %s selesai", payment.Reference, payment.Charged(), payment.BillerCode, payment.CustomerNumber)
+}
+
+// Failed mengembalikan limit dan memberi tahu user hanya bila dana sempat
+// di-hold; kegagalan PlaceHold sudah dilaporkan langsung ke pemanggil.
+func (p *paymentPayout) Failed(held bool) {
+	payment := p.payment
+	p.service.releaseLimit(payment.UserID, payment.Reference)
+	if !held {
+		return
+	}
+	p.service.notifier.Notify(payment.UserID, NotificationFailed, "Pembayaran tagihan gagal",
+		fmt.Sprintf("Pembayaran %s untuk %s gagal dan dana dikembalikan ke saldo Anda.", payment.BillerCode, payment.CustomerNumber),
+		notifications.Data{"reference": payment.Reference})
+}
+
+func (p *paymentPayout) MarkChecked(at time.Time) (int, error) {
+	if err := p.service.repo.MarkChecked(p.payment.ID, at); err != nil {
+		return 0, err
+	}
+	p.payment.CheckAttempts++
+	p.payment.LastCheckedAt = &at
+	return p.payment.CheckAttempts, nil
+}
+
+func (p *paymentPayout) SentAt() time.Time {
+	return p.payment.CreatedAt
+}
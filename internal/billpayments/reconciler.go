@@ -0,0 +1,30 @@
+package billpayments
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// StartReconciler secara berkala memastikan hasil pembayaran tagihan
+// PROCESSING/UNKNOWN ke biller sampai ctx dibatalkan.
+func StartReconciler(ctx context.Context, service BillPaymentService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			resolved, err := service.ReconcilePending()
+			if err != nil {
+				log.Printf("ERROR: Gagal cek status pembayaran tagihan: %v", err)
+				continue
+			}
+			if resolved > 0 {
+				log.Printf("SUCCESS: %d pembayaran tagihan selesai direkonsiliasi", resolved)
+			}
+		}
+	}
+}
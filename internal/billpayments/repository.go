@@ -0,0 +1,126 @@
+package billpayments
+
+import (
+	"ewallet-engine/internal/balance"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type BillPaymentRepository interface {
+	CreateInquiry(inquiry *BillInquiry) error
+	FindInquiry(userID uint, id uint) (*BillInquiry, error)
+	MarkInquiryUsed(id uint, at time.Time) (bool, error)
+
+	CreatePayment(payment *BillPayment) error
+	FindByReference(reference string) (*BillPayment, error)
+	ListPayments(userID uint, status PaymentStatus, limit int) ([]BillPayment, error)
+	FindUnresolved(checkedBefore time.Time, limit int) ([]BillPayment, error)
+	TransitionStatus(id uint, from PaymentStatus, updates map[string]interface{}) (bool, error)
+	MarkChecked(id uint, at time.Time) error
+
+	PlaceHold(userID uint, currency string, amount float64, reference string, expiresAt time.Time) error
+	CaptureHold(userID uint, reference string, amount float64, fee float64) error
+	ReleaseHold(userID uint, reference string) error
+}
+
+type billPaymentRepository struct {
+	DB *gorm.DB
+}
+
+func NewBillPaymentRepository(db *gorm.DB) BillPaymentRepository {
+	return &billPaymentRepository{DB: db}
+}
+
+func (r *billPaymentRepository) CreateInquiry(inquiry *BillInquiry) error {
+	return r.DB.Create(inquiry).Error
+}
+
+func (r *billPaymentRepository) FindInquiry(userID uint, id uint) (*BillInquiry, error) {
+	var inquiry BillInquiry
+	err := r.DB.Where("id = ? AND user_id = ?", id, userID).First(&inquiry).Error
+	if err != nil {
+		return nil, err
+	}
+	return &inquiry, nil
+}
+
+// MarkInquiryUsed menandai inquiry terpakai hanya bila belum pernah dipakai,
+// sehingga satu inquiry tidak bisa menghasilkan dua pembayaran.
+func (r *billPaymentRepository) MarkInquiryUsed(id uint, at time.Time) (bool, error) {
+	result := r.DB.Model(&BillInquiry{}).Where("id = ? AND used_at IS NULL", id).Update("used_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *billPaymentRepository) CreatePayment(payment *BillPayment) error {
+	return r.DB.Create(payment).Error
+}
+
+func (r *billPaymentRepository) FindByReference(reference string) (*BillPayment, error) {
+	var payment BillPayment
+	err := r.DB.Where("reference = ?", reference).First(&payment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (r *billPaymentRepository) ListPayments(userID uint, status PaymentStatus, limit int) ([]BillPayment, error) {
+	var payments []BillPayment
+	query := r.DB.Order("id DESC").Limit(limit)
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&payments).Error
+	return payments, err
+}
+
+// FindUnresolved mengembalikan pembayaran PROCESSING/UNKNOWN yang belum dicek
+// sejak checkedBefore, termasuk yang belum pernah dicek.
+func (r *billPaymentRepository) FindUnresolved(checkedBefore time.Time, limit int) ([]BillPayment, error) {
+	var payments []BillPayment
+	err := r.DB.
+		Where("status IN ?", []PaymentStatus{StatusProcessing, StatusUnknown}).
+		Where("last_checked_at IS NULL OR last_checked_at < ?", checkedBefore).
+		Order("id ASC").Limit(limit).Find(&payments).Error
+	return payments, err
+}
+
+func (r *billPaymentRepository) TransitionStatus(id uint, from PaymentStatus, updates map[string]interface{}) (bool, error) {
+	result := r.DB.Model(&BillPayment{}).Where("id = ? AND status = ?", id, from).Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *billPaymentRepository) MarkChecked(id uint, at time.Time) error {
+	return r.DB.Model(&BillPayment{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"last_checked_at": at,
+		"check_attempts":  gorm.Expr("check_attempts + 1"),
+	}).Error
+}
+
+func (r *billPaymentRepository) PlaceHold(userID uint, currency string, amount float64, reference string, expiresAt time.Time) error {
+	wallet, err := balance.FindUserWallet(r.DB, userID, currency, false)
+	if err != nil {
+		return err
+	}
+	_, err = balance.PlaceHold(r.DB, wallet.ID, amount, reference, expiresAt)
+	return err
+}
+
+func (r *billPaymentRepository) CaptureHold(userID uint, reference string, amount float64, fee float64) error {
+	_, err := balance.CaptureHold(r.DB, userID, reference, amount, fee)
+	return err
+}
+
+func (r *billPaymentRepository) ReleaseHold(userID uint, reference string) error {
+	return balance.ReleaseHold(r.DB, userID, reference, balance.HoldReleased)
+}
@@ -0,0 +1,324 @@
+package billpayments
+
+import (
+	"context"
+	"errors"
+	"ewallet-engine/internal/auth"
+	"ewallet-engine/internal/balance"
+	"ewallet-engine/internal/billers"
+	"ewallet-engine/internal/limits"
+	"ewallet-engine/internal/notifications"
+	"ewallet-engine/internal/payouts"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FeeTransactionType adalah transaction_type jadwal biaya untuk pembayaran
+// tagihan; channel-nya adalah kode biller.
+const FeeTransactionType = "BILL_PAYMENT"
+
+const (
+	NotificationPaid   = "BILL_PAYMENT_PAID"
+	NotificationFailed = "BILL_PAYMENT_FAILED"
+)
+
+var (
+	ErrInquiryNotFound = errors.New("inquiry tagihan tidak ditemukan")
+	ErrInquiryExpired  = errors.New("inquiry tagihan sudah kedaluwarsa, silakan cek ulang tagihan")
+	ErrInquiryUsed     = errors.New("inquiry tagihan sudah dipakai untuk pembayaran lain")
+	ErrPaymentNotFound = errors.New("pembayaran tagihan tidak ditemukan")
+)
+
+type BillPaymentService interface {
+	ListBillers(category billers.Category) []billers.Biller
+	Inquiry(userID uint, billerCode string, customerNumber string, amount float64) (*BillInquiry, error)
+	Pay(userID uint, inquiryID uint, reference string, pin string) (*BillPayment, error)
+	GetPayment(userID uint, reference string) (*BillPayment, error)
+	ListPayments(userID uint, status PaymentStatus) ([]BillPayment, error)
+	Reconcile(reference string) (*BillPayment, error)
+	ReconcilePending() (int, error)
+}
+
+type billPaymentService struct {
+	repo       BillPaymentRepository
+	provider   billers.BillerProvider
+	pins       auth.PINVerifier
+	limiter    limits.LimitService
+	fees       balance.FeeCalculator
+	notifier   notifications.Notifier
+	machine    payouts.Machine
+	inquiryTTL time.Duration
+	holdTTL    time.Duration
+	recheck    time.Duration
+}
+
+func NewBillPaymentService(repo BillPaymentRepository, provider billers.BillerProvider, pins auth.PINVerifier, limiter limits.LimitService, fees balance.FeeCalculator, notifier notifications.Notifier) BillPaymentService {
+	s := &billPaymentService{
+		repo:     repo,
+		provider: provider,
+		pins:     pins,
+		limiter:  limiter,
+		fees:     fees,
+		notifier: notifier,
+		machine: payouts.Machine{
+			Label:          "Pembayaran tagihan",
+			Timeout:        15 * time.Second,
+			NotFoundGrace:  10 * time.Minute,
+			MaxChecks:      30,
+			NotFound:       billers.ErrPaymentNotFound,
+			NotFoundReason: "pembayaran tidak diterima biller",
+		},
+		inquiryTTL: 15 * time.Minute,
+		holdTTL:    7 * 24 * time.Hour,
+		recheck:    time.Minute,
+	}
+	if v, err := strconv.Atoi(os.Getenv("BILLER_TIMEOUT_SECONDS")); err == nil && v > 0 {
+		s.machine.Timeout = time.Duration(v) * time.Second
+	}
+	if v, err := strconv.Atoi(os.Getenv("BILL_INQUIRY_TTL_MINUTES")); err == nil && v > 0 {
+		s.inquiryTTL = time.Duration(v) * time.Minute
+	}
+	if v, err := strconv.Atoi(os.Getenv("BILL_PAYMENT_HOLD_TTL_DAYS")); err == nil && v > 0 {
+		s.holdTTL = time.Duration(v) * 24 * time.Hour
+	}
+	if v, err := strconv.Atoi(os.Getenv("BILL_PAYMENT_NOT_FOUND_GRACE_MINUTES")); err == nil && v > 0 {
+		s.machine.NotFoundGrace = time.Duration(v) * time.Minute
+	}
+	if v, err := strconv.Atoi(os.Getenv("BILL_PAYMENT_RECHECK_SECONDS")); err == nil && v > 0 {
+		s.recheck = time.Duration(v) * time.Second
+	}
+	if v, err := strconv.Atoi(os.Getenv("BILL_PAYMENT_MAX_STATUS_CHECKS")); err == nil && v > 0 {
+		s.machine.MaxChecks = v
+	}
+	return s
+}
+
+func (s *billPaymentService) ListBillers(category billers.Category) []billers.Biller {
+	return billers.Catalog(billers.Category(strings.ToUpper(string(category))))
+}
+
+// Inquiry menanyakan tagihan ke biller dan menyimpan hasilnya beserta fee
+// platform. amount hanya dipakai untuk biller PREPAID sebagai denominasi.
+func (s *billPaymentService) Inquiry(userID uint, billerCode string, customerNumber string, amount float64) (*BillInquiry, error) {
+	biller, ok := billers.Find(strings.ToUpper(strings.TrimSpace(billerCode)))
+	if !ok {
+		return nil, billers.ErrUnknownBiller
+	}
+	customerNumber = strings.TrimSpace(customerNumber)
+	if err := biller.ValidateCustomerNumber(customerNumber); err != nil {
+		return nil, err
+	}
+	if biller.Type == billers.BillPrepaid {
+		if err := biller.ValidateDenomination(amount); err != nil {
+			return nil, err
+		}
+	} else {
+		amount = 0
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.machine.Timeout)
+	defer cancel()
+	result, err := s.provider.Inquiry(ctx, billers.InquiryRequest{BillerCode: biller.Code, CustomerNumber: customerNumber, Amount: amount})
+	if err != nil {
+		return nil, err
+	}
+
+	currency := balance.DefaultCurrency
+	fee, err := s.fees.CalculateFee(userID, FeeTransactionType, biller.Code, currency, result.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	inquiry := &BillInquiry{
+		UserID:         userID,
+		BillerCode:     biller.Code,
+		Category:       biller.Category,
+		CustomerNumber: result.CustomerNumber,
+		CustomerName:   result.CustomerName,
+		Period:         result.Period,
+		Amount:         result.Amount,
+		AdminFee:       result.AdminFee,
+		Fee:            fee,
+		Total:          result.Amount + result.AdminFee + fee,
+		Currency:       currency,
+		ExpiresAt:      time.Now().Add(s.inquiryTTL),
+	}
+	if err := s.repo.CreateInquiry(inquiry); err != nil {
+		return nil, err
+	}
+	return inquiry, nil
+}
+
+// Pay membayar tagihan dari inquiry setelah PIN diverifikasi. Total di-hold
+// lebih dulu; hold hanya di-capture saat biller mengonfirmasi SUCCESS dan
+// hanya dilepas saat biller mengonfirmasi FAILED, hasil lain menunggu polling
+// status. Reference yang sama mengembalikan pembayaran yang sudah ada.
+func (s *billPaymentService) Pay(userID uint, inquiryID uint, reference string, pin string) (*BillPayment, error) {
+	if reference == "" {
+		return nil, errors.New("reference wajib diisi")
+	}
+
+	if existing, err := s.repo.FindByReference(reference); err == nil {
+		if existing.UserID != userID {
+			return nil, errors.New("reference sudah digunakan")
+		}
+		return existing, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	inquiry, err := s.repo.FindInquiry(userID, inquiryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInquiryNotFound
+		}
+		return nil, err
+	}
+	if inquiry.UsedAt != nil {
+		return nil, ErrInquiryUsed
+	}
+	if time.Now().After(inquiry.ExpiresAt) {
+		return nil, ErrInquiryExpired
+	}
+
+	if err := s.pins.VerifyPIN(userID, pin); err != nil {
+		return nil, err
+	}
+	// Limit dipakai sebelum inquiry ditandai terpakai dan dikembalikan oleh
+	// paymentPayout.Failed bila pembayaran gagal.
+	created, err := s.limiter.Reserve(userID, limits.OperationDebit, inquiry.Amount+inquiry.AdminFee, HoldReferencePrefix+reference)
+	if err != nil {
+		return nil, err
+	}
+	// Reservasi yang sudah ada milik pembayaran lain untuk inquiry yang sama.
+	if !created {
+		return nil, ErrInquiryUsed
+	}
+
+	used, err := s.repo.MarkInquiryUsed(inquiry.ID, time.Now())
+	if err != nil || !used {
+		s.releaseLimit(userID, reference)
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrInquiryUsed
+	}
+
+	payment := &BillPayment{
+		Reference:      reference,
+		UserID:         userID,
+		InquiryID:      inquiry.ID,
+		BillerCode:     inquiry.BillerCode,
+		Category:       inquiry.Category,
+		CustomerNumber: inquiry.CustomerNumber,
+		CustomerName:   inquiry.CustomerName,
+		Period:         inquiry.Period,
+		Amount:         inquiry.Amount,
+		AdminFee:       inquiry.AdminFee,
+		Fee:            inquiry.Fee,
+		Currency:       inquiry.Currency,
+		Status:         StatusPending,
+		Provider:       s.provider.Name(),
+	}
+	if err := s.repo.CreatePayment(payment); err != nil {
+		s.releaseLimit(userID, reference)
+		return nil, err
+	}
+
+	payout := &paymentPayout{service: s, payment: payment}
+	if err := s.repo.PlaceHold(userID, payment.Currency, payment.Charged()+payment.Fee, payment.HoldReference(), time.Now().Add(s.holdTTL)); err != nil {
+		s.machine.Fail(payout, err.Error())
+		if errors.Is(err, balance.ErrInsufficientBalance) {
+			return nil, errors.New("saldo tidak mencukupi")
+		}
+		return nil, err
+	}
+
+	err = s.machine.Send(payout, func(ctx context.Context) (*payouts.Outcome, error) {
+		result, err := s.provider.Pay(ctx, billers.PaymentRequest{
+			Reference:      reference,
+			BillerCode:     payment.BillerCode,
+			CustomerNumber: payment.CustomerNumber,
+			Amount:         payment.Amount,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return payouts.PaymentOutcome(result), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+func (s *billPaymentService) releaseLimit(userID uint, reference string) {
+	if err := s.limiter.Release(userID, limits.OperationDebit, HoldReferencePrefix+reference); err != nil {
+		log.Printf("ERROR: Gagal mengembalikan limit pembayaran %s: %v", reference, err)
+	}
+}
+
+func (s *billPaymentService) GetPayment(userID uint, reference string) (*BillPayment, error) {
+	payment, err := s.repo.FindByReference(reference)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPaymentNotFound
+		}
+		return nil, err
+	}
+	if userID != 0 && payment.UserID != userID {
+		return nil, ErrPaymentNotFound
+	}
+	return payment, nil
+}
+
+func (s *billPaymentService) ListPayments(userID uint, status PaymentStatus) ([]BillPayment, error) {
+	return s.repo.ListPayments(userID, PaymentStatus(strings.ToUpper(string(status))), 100)
+}
+
+// Reconcile menanyakan status pembayaran ke biller untuk pembayaran yang
+// belum final. Pembayaran yang sudah final dikembalikan apa adanya.
+func (s *billPaymentService) Reconcile(reference string) (*BillPayment, error) {
+	payment, err := s.GetPayment(0, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.machine.Reconcile(&paymentPayout{service: s, payment: payment}, func(ctx context.Context) (*payouts.Outcome, error) {
+		result, err := s.provider.Status(ctx, reference)
+		if err != nil {
+			return nil, err
+		}
+		return payouts.PaymentOutcome(result), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// ReconcilePending memproses pembayaran PROCESSING/UNKNOWN yang sudah lewat
+// interval cek ulang dan mengembalikan jumlah yang menjadi final.
+func (s *billPaymentService) ReconcilePending() (int, error) {
+	pending, err := s.repo.FindUnresolved(time.Now().Add(-s.recheck), 100)
+	if err != nil {
+		return 0, err
+	}
+
+	references := make([]string, 0, len(pending))
+	for _, item := range pending {
+		references = append(references, item.Reference)
+	}
+	return s.machine.ReconcileAll(references, func(reference string) (bool, error) {
+		payment, err := s.Reconcile(reference)
+		if err != nil {
+			return false, err
+		}
+		return payment.IsFinal(), nil
+	}), nil
+}
@@ -0,0 +1,323 @@
+package billpayments
+
+import (
+	"context"
+	"ewallet-engine/internal/balance"
+	"ewallet-engine/internal/billers"
+	"ewallet-engine/internal/limits"
+	"ewallet-engine/internal/notifications"
+	"ewallet-engine/internal/payouts"
+	"fmt"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// fakeBillPaymentRepository menyimpan satu wallet milik user pembayar; hold
+// mengurangi saldo tersedia dan capture mendebit tagihan ditambah fee.
+type fakeBillPaymentRepository struct {
+	BillPaymentRepository
+	payments map[string]*BillPayment
+	wallet   balance.Wallet
+	holds    map[string]*balance.Hold
+}
+
+func newFakeBillPaymentRepository(walletBalance float64) *fakeBillPaymentRepository {
+	return &fakeBillPaymentRepository{
+		payments: make(map[string]*BillPayment),
+		wallet:   balance.Wallet{ID: 1, UserID: 7, Currency: "IDR", Balance: walletBalance},
+		holds:    make(map[string]*balance.Hold),
+	}
+}
+
+func (r *fakeBillPaymentRepository) FindInquiry(userID uint, id uint) (*BillInquiry, error) {
+	return &BillInquiry{
+		ID:             id,
+		UserID:         userID,
+		BillerCode:     "PLN_PREPAID",
+		Category:       billers.CategoryElectricity,
+		CustomerNumber: "12345678901",
+		CustomerName:   "BUDI SANTOSO",
+		Amount:         100000,
+		AdminFee:       2500,
+		Fee:            1000,
+		Currency:       "IDR",
+		ExpiresAt:      time.Now().Add(time.Minute),
+	}, nil
+}
+
+func (r *fakeBillPaymentRepository) MarkInquiryUsed(id uint, at time.Time) (bool, error) {
+	return true, nil
+}
+
+func (r *fakeBillPaymentRepository) CreatePayment(payment *BillPayment) error {
+	payment.ID = uint(len(r.payments) + 1)
+	payment.CreatedAt = time.Now()
+	stored := *payment
+	r.payments[payment.Reference] = &stored
+	return nil
+}
+
+func (r *fakeBillPaymentRepository) FindByReference(reference string) (*BillPayment, error) {
+	payment, ok := r.payments[reference]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *payment
+	return &copied, nil
+}
+
+func (r *fakeBillPaymentRepository) TransitionStatus(id uint, from PaymentStatus, updates map[string]interface{}) (bool, error) {
+	for _, payment := range r.payments {
+		if payment.ID != id {
+			continue
+		}
+		if payment.Status != from {
+			return false, nil
+		}
+		payment.Status = updates["status"].(PaymentStatus)
+		return true, nil
+	}
+	return false, nil
+}
+
+func (r *fakeBillPaymentRepository) MarkChecked(id uint, at time.Time) error {
+	return nil
+}
+
+func (r *fakeBillPaymentRepository) PlaceHold(userID uint, currency string, amount float64, reference string, expiresAt time.Time) error {
+	if _, ok := r.holds[reference]; ok {
+		return balance.ErrHoldReferenceUsed
+	}
+	if r.wallet.Available() < amount {
+		return balance.ErrInsufficientBalance
+	}
+	r.wallet.HeldBalance += amount
+	r.holds[reference] = &balance.Hold{Reference: reference, Amount: amount, Status: balance.HoldActive}
+	return nil
+}
+
+func (r *fakeBillPaymentRepository) CaptureHold(userID uint, reference string, amount float64, fee float64) error {
+	hold, ok := r.holds[reference]
+	if !ok || hold.Status != balance.HoldActive {
+		return balance.ErrHoldNotFound
+	}
+	r.wallet.HeldBalance -= hold.Amount
+	r.wallet.Balance -= amount + fee
+	hold.Status = balance.HoldCaptured
+	return nil
+}
+
+func (r *fakeBillPaymentRepository) ReleaseHold(userID uint, reference string) error {
+	hold, ok := r.holds[reference]
+	if !ok || hold.Status != balance.HoldActive {
+		return balance.ErrHoldNotFound
+	}
+	r.wallet.HeldBalance -= hold.Amount
+	hold.Status = balance.HoldReleased
+	return nil
+}
+
+func (r *fakeBillPaymentRepository) holdStatus(reference string) balance.HoldStatus {
+	if hold, ok := r.holds[reference]; ok {
+		return hold.Status
+	}
+	return ""
+}
+
+type fakeProvider struct {
+	billers.BillerProvider
+	pay       *billers.PaymentResult
+	payErr    error
+	status    *billers.PaymentResult
+	statusErr error
+}
+
+func (p *fakeProvider) Name() string { return "fake" }
+
+func (p *fakeProvider) Pay(ctx context.Context, request billers.PaymentRequest) (*billers.PaymentResult, error) {
+	return p.pay, p.payErr
+}
+
+func (p *fakeProvider) Status(ctx context.Context, reference string) (*billers.PaymentResult, error) {
+	return p.status, p.statusErr
+}
+
+type acceptPIN struct{}
+
+func (acceptPIN) VerifyPIN(userID uint, pin string) error { return nil }
+
+// fakeLimiter mencatat pemakaian limit harian per reference.
+type fakeLimiter struct {
+	limits.LimitService
+	daily float64
+	used  map[string]float64
+}
+
+func (l *fakeLimiter) Reserve(userID uint, operation limits.Operation, amount float64, reference string) (bool, error) {
+	if _, ok := l.used[reference]; ok {
+		return false, nil
+	}
+	if l.usage()+amount > l.daily {
+		return false, fmt.Errorf("jumlah melebihi sisa limit harian %.2f", l.daily-l.usage())
+	}
+	l.used[reference] = amount
+	return true, nil
+}
+
+func (l *fakeLimiter) Release(userID uint, operation limits.Operation, reference string) error {
+	delete(l.used, reference)
+	return nil
+}
+
+func (l *fakeLimiter) usage() float64 {
+	total := 0.0
+	for _, amount := range l.used {
+		total += amount
+	}
+	return total
+}
+
+type fakeNotifier struct {
+	sent []string
+}
+
+func (n *fakeNotifier) Notify(userID uint, notificationType string, title string, body string, data notifications.Data) {
+	n.sent = append(n.sent, notificationType)
+}
+
+type flatFee float64
+
+func (f flatFee) CalculateFee(userID uint, txType string, channel string, currency string, amount float64) (float64, error) {
+	return float64(f), nil
+}
+
+var testMachine = payouts.Machine{
+	Label:          "Pembayaran tagihan",
+	Timeout:        time.Second,
+	NotFoundGrace:  10 * time.Minute,
+	MaxChecks:      30,
+	NotFound:       billers.ErrPaymentNotFound,
+	NotFoundReason: "pembayaran tidak diterima biller",
+}
+
+// Inquiry fake bernilai tagihan 100000, biaya admin 2500 dan fee 1000 sehingga
+// hold sebesar 103500 dan limit terpakai 102500.
+func TestPaySettlesBalanceLimitAndNotifies(t *testing.T) {
+	cases := []struct {
+		name         string
+		result       *billers.PaymentResult
+		wantStatus   PaymentStatus
+		wantBalance  float64
+		wantUsage    float64
+		wantNotified string
+	}{
+		{"biller success", &billers.PaymentResult{Status: billers.PaymentSuccess, ExternalID: "AGG-1", SerialNumber: "1234-5678"}, StatusSuccess, 96500, 102500, NotificationPaid},
+		{"biller failure", &billers.PaymentResult{Status: billers.PaymentFailed, FailureReason: "nomor pelanggan diblokir"}, StatusFailed, 200000, 0, NotificationFailed},
+	}
+
+	for _, tc := range cases {
+		repo := newFakeBillPaymentRepository(200000)
+		limiter := &fakeLimiter{daily: 150000, used: make(map[string]float64)}
+		notifier := &fakeNotifier{}
+		service := &billPaymentService{repo: repo, provider: &fakeProvider{pay: tc.result}, pins: acceptPIN{}, limiter: limiter, fees: flatFee(1000), notifier: notifier, machine: testMachine, inquiryTTL: time.Minute, holdTTL: time.Hour, recheck: time.Minute}
+
+		payment, err := service.Pay(7, 1, "BILL-1", "123456")
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if payment.Status != tc.wantStatus {
+			t.Errorf("%s: status = %s, want %s", tc.name, payment.Status, tc.wantStatus)
+		}
+		if repo.wallet.Balance != tc.wantBalance || repo.wallet.HeldBalance != 0 {
+			t.Errorf("%s: balance/held = %.2f/%.2f, want %.2f/0.00", tc.name, repo.wallet.Balance, repo.wallet.HeldBalance, tc.wantBalance)
+		}
+		if limiter.usage() != tc.wantUsage {
+			t.Errorf("%s: daily usage = %.2f, want %.2f", tc.name, limiter.usage(), tc.wantUsage)
+		}
+		if len(notifier.sent) != 1 || notifier.sent[0] != tc.wantNotified {
+			t.Errorf("%s: notifications = %v, want [%s]", tc.name, notifier.sent, tc.wantNotified)
+		}
+	}
+}
+
+func TestPayRejectsAboveDailyLimit(t *testing.T) {
+	repo := newFakeBillPaymentRepository(500000)
+	limiter := &fakeLimiter{daily: 100000, used: make(map[string]float64)}
+	notifier := &fakeNotifier{}
+	service := &billPaymentService{repo: repo, provider: &fakeProvider{pay: &billers.PaymentResult{Status: billers.PaymentSuccess}}, pins: acceptPIN{}, limiter: limiter, fees: flatFee(1000), notifier: notifier, machine: testMachine, inquiryTTL: time.Minute, holdTTL: time.Hour, recheck: time.Minute}
+
+	if _, err := service.Pay(7, 1, "BILL-1", "123456"); err == nil {
+		t.Fatalf("expected the daily limit to reject the payment")
+	}
+	if len(repo.payments) != 0 || len(repo.holds) != 0 {
+		t.Fatalf("expected no payment or hold above the limit; got %d payments, %d holds", len(repo.payments), len(repo.holds))
+	}
+	if repo.wallet.Balance != 500000 || limiter.usage() != 0 || len(notifier.sent) != 0 {
+		t.Fatalf("expected balance and limit untouched; balance %.2f, usage %.2f, notifications %v", repo.wallet.Balance, limiter.usage(), notifier.sent)
+	}
+}
+
+func TestPayInsufficientBalanceFailsWithoutHold(t *testing.T) {
+	repo := newFakeBillPaymentRepository(103000)
+	limiter := &fakeLimiter{daily: 1000000, used: make(map[string]float64)}
+	notifier := &fakeNotifier{}
+	service := &billPaymentService{repo: repo, provider: &fakeProvider{}, pins: acceptPIN{}, limiter: limiter, fees: flatFee(1000), notifier: notifier, machine: testMachine, inquiryTTL: time.Minute, holdTTL: time.Hour, recheck: time.Minute}
+
+	if _, err := service.Pay(7, 1, "BILL-1", "123456"); err == nil || err.Error() != "saldo tidak mencukupi" {
+		t.Fatalf("expected insufficient balance error; got %v", err)
+	}
+	if repo.payments["BILL-1"].Status != StatusFailed || limiter.usage() != 0 {
+		t.Fatalf("expected FAILED with the limit returned; got %s, usage %.2f", repo.payments["BILL-1"].Status, limiter.usage())
+	}
+	if repo.wallet.Balance != 103000 || repo.wallet.HeldBalance != 0 || len(notifier.sent) != 0 {
+		t.Fatalf("expected balance untouched and no notification; got %.2f/%.2f, %v", repo.wallet.Balance, repo.wallet.HeldBalance, notifier.sent)
+	}
+}
+
+func TestPayUnknownKeepsFundsHeldUntilNotFoundGrace(t *testing.T) {
+	repo := newFakeBillPaymentRepository(200000)
+	limiter := &fakeLimiter{daily: 1000000, used: make(map[string]float64)}
+	provider := &fakeProvider{payErr: context.DeadlineExceeded, statusErr: billers.ErrPaymentNotFound}
+	service := &billPaymentService{repo: repo, provider: provider, pins: acceptPIN{}, limiter: limiter, fees: flatFee(1000), notifier: &fakeNotifier{}, machine: testMachine, inquiryTTL: time.Minute, holdTTL: time.Hour, recheck: time.Minute}
+
+	payment, err := service.Pay(7, 1, "BILL-1", "123456")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if payment.Status != StatusUnknown || repo.wallet.Available() != 96500 || repo.wallet.Balance != 200000 {
+		t.Fatalf("expected UNKNOWN with 103500 held after a timeout; got %s, available %.2f", payment.Status, repo.wallet.Available())
+	}
+
+	if payment, _ = service.Reconcile("BILL-1"); payment.Status != StatusUnknown || repo.wallet.HeldBalance != 103500 {
+		t.Fatalf("expected UNKNOWN with funds held inside the grace period; got %s, held %.2f", payment.Status, repo.wallet.HeldBalance)
+	}
+
+	repo.payments["BILL-1"].CreatedAt = time.Now().Add(-time.Hour)
+	if payment, _ = service.Reconcile("BILL-1"); payment.Status != StatusFailed {
+		t.Fatalf("expected FAILED after the not-found grace period; got %s", payment.Status)
+	}
+	if repo.wallet.Balance != 200000 || repo.wallet.HeldBalance != 0 || limiter.usage() != 0 {
+		t.Fatalf("expected funds and limit returned; got %.2f/%.2f, usage %.2f", repo.wallet.Balance, repo.wallet.HeldBalance, limiter.usage())
+	}
+}
+
+func TestPayHoldFailureDoesNotReleaseExistingHold(t *testing.T) {
+	repo := newFakeBillPaymentRepository(200000)
+	// Hold lain dengan reference yang sama sudah ada sebelum pembayaran ini.
+	repo.wallet.HeldBalance = 30000
+	repo.holds["BILL-BILL-1"] = &balance.Hold{Reference: "BILL-BILL-1", Amount: 30000, Status: balance.HoldActive}
+	notifier := &fakeNotifier{}
+	service := &billPaymentService{repo: repo, provider: &fakeProvider{}, pins: acceptPIN{}, limiter: &fakeLimiter{daily: 1000000, used: make(map[string]float64)}, fees: flatFee(1000), notifier: notifier, machine: testMachine, inquiryTTL: time.Minute, holdTTL: time.Hour, recheck: time.Minute}
+
+	if _, err := service.Pay(7, 1, "BILL-1", "123456"); err == nil {
+		t.Fatalf("expected the hold to be rejected")
+	}
+	if repo.payments["BILL-1"].Status != StatusFailed || len(notifier.sent) != 0 {
+		t.Fatalf("expected FAILED without notification; got %s %v", repo.payments["BILL-1"].Status, notifier.sent)
+	}
+	if repo.holdStatus("BILL-BILL-1") != balance.HoldActive || repo.wallet.HeldBalance != 30000 || repo.wallet.Balance != 200000 {
+		t.Fatalf("expected a hold this payment never placed to stay active; got %s, held %.2f", repo.holdStatus("BILL-BILL-1"), repo.wallet.HeldBalance)
+	}
+}
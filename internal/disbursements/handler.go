@@ -0,0 +1,221 @@
+package disbursements
+
+import (
+	"errors"
+	"ewallet-engine/internal/audit"
+	"io"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type DisbursementHandler struct {
+	service      DisbursementService
+	auditService audit.AuditService
+}
+
+func NewDisbursementHandler(service DisbursementService, auditService audit.AuditService) *DisbursementHandler {
+	return &DisbursementHandler{service: service, auditService: auditService}
+}
+
+type batchUpload struct {
+	SourceWalletID uint
+	Description    string
+	FileName       string
+	Rows           []Row
+}
+
+// readUpload menerima multipart dengan field file (CSV/JSON) atau body JSON
+// berisi rows langsung.
+func readUpload(c *fiber.Ctx) (*batchUpload, error) {
+	if header, err := c.FormFile("file"); err == nil {
+		sourceWalletID, err := strconv.ParseUint(c.FormValue("source_wallet_id"), 10, 64)
+		if err != nil {
+			return nil, errors.New("source_wallet_id tidak valid")
+		}
+
+		file, err := header.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return nil, err
+		}
+		rows, err := ParseFile(header.Filename, data)
+		if err != nil {
+			return nil, err
+		}
+
+		return &batchUpload{
+			SourceWalletID: uint(sourceWalletID),
+			Description:    c.FormValue("description"),
+			FileName:       header.Filename,
+			Rows:           rows,
+		}, nil
+	}
+
+	var request struct {
+		SourceWalletID uint   `json:"source_wallet_id"`
+		Description    string `json:"description"`
+		Rows           []Row  `json:"rows"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return nil, errors.New("Invalid request body")
+	}
+	for i := range request.Rows {
+		request.Rows[i].RowNumber = i + 1
+		request.Rows[i].Error = ""
+	}
+
+	return &batchUpload{SourceWalletID: request.SourceWalletID, Description: request.Description, Rows: request.Rows}, nil
+}
+
+// CreateBatchHandler memvalidasi file. Dengan ?dry_run=true hanya laporan
+// validasi yang dikembalikan; tanpa itu batch disimpan jika semua baris valid.
+func (h *DisbursementHandler) CreateBatchHandler(c *fiber.Ctx) error {
+	actorID := c.Locals("user_id").(uint)
+
+	upload, err := readUpload(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	if c.QueryBool("dry_run", false) {
+		report, err := h.service.Validate(upload.SourceWalletID, upload.Rows)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+		}
+		return c.JSON(fiber.Map{"data": report})
+	}
+
+	batch, report, err := h.service.CreateBatch(actorID, upload.SourceWalletID, upload.Description, upload.FileName, upload.Rows)
+	if err != nil {
+		if errors.Is(err, ErrBatchInvalid) {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"message": err.Error(), "data": report})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionDisbursementCreated,
+		TargetType: "disbursement_batch",
+		TargetID:   batch.BatchID,
+		After:      batchSnapshot(batch),
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Batch disbursement berhasil dibuat",
+		"data":    batch,
+	})
+}
+
+func (h *DisbursementHandler) ExecuteBatchHandler(c *fiber.Ctx) error {
+	actorID := c.Locals("user_id").(uint)
+
+	batch, err := h.service.Execute(actorID, c.Params("batch_id"))
+	if err != nil {
+		return h.batchError(c, err)
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionDisbursementExecuted,
+		TargetType: "disbursement_batch",
+		TargetID:   batch.BatchID,
+		After:      batchSnapshot(batch),
+	})
+
+	message := "Batch disbursement sedang diproses"
+	if batch.Status == BatchAwaitingApproval {
+		message = "Batch disbursement menunggu persetujuan approver"
+	}
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": message,
+		"data":    batch,
+	})
+}
+
+func (h *DisbursementHandler) CancelBatchHandler(c *fiber.Ctx) error {
+	batch, err := h.service.Cancel(c.Params("batch_id"))
+	if err != nil {
+		return h.batchError(c, err)
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionDisbursementCancelled,
+		TargetType: "disbursement_batch",
+		TargetID:   batch.BatchID,
+		After:      batchSnapshot(batch),
+	})
+
+	return c.JSON(fiber.Map{
+		"message": "Batch disbursement dibatalkan",
+		"data":    batch,
+	})
+}
+
+func (h *DisbursementHandler) GetBatchHandler(c *fiber.Ctx) error {
+	batch, progress, err := h.service.GetBatch(c.Params("batch_id"))
+	if err != nil {
+		return h.batchError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": fiber.Map{"batch": batch, "progress": progress}})
+}
+
+func (h *DisbursementHandler) ListBatchesHandler(c *fiber.Ctx) error {
+	batches, err := h.service.ListBatches(BatchStatus(c.Query("status")))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": batches})
+}
+
+func (h *DisbursementHandler) ListItemsHandler(c *fiber.Ctx) error {
+	items, err := h.service.ListItems(c.Params("batch_id"), ItemStatus(c.Query("status")))
+	if err != nil {
+		return h.batchError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": items})
+}
+
+func (h *DisbursementHandler) ResultFileHandler(c *fiber.Ctx) error {
+	batchID := c.Params("batch_id")
+
+	data, err := h.service.ResultCSV(batchID)
+	if err != nil {
+		return h.batchError(c, err)
+	}
+
+	c.Set(fiber.HeaderContentType, "text/csv")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+batchID+`-result.csv"`)
+	return c.Send(data)
+}
+
+func (h *DisbursementHandler) batchError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, ErrBatchNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, ErrBatchNotPending):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"message": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+}
+
+func batchSnapshot(batch *Batch) audit.Snapshot {
+	return audit.Snapshot{
+		"status":           batch.Status,
+		"source_wallet_id": batch.SourceWalletID,
+		"currency":         batch.Currency,
+		"total_rows":       batch.TotalRows,
+		"total_amount":     batch.TotalAmount,
+	}
+}
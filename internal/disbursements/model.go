@@ -0,0 +1,106 @@
+package disbursements
+
+import "time"
+
+type BatchStatus string
+
+const (
+	// BatchValidated: semua baris valid dan tersimpan, menunggu eksekusi.
+	BatchValidated BatchStatus = "VALIDATED"
+	// BatchAwaitingApproval: eksekusi sudah diajukan ke approvals dan
+	// menunggu checker karena total batch melebihi batas auto-approve.
+	BatchAwaitingApproval    BatchStatus = "AWAITING_APPROVAL"
+	BatchProcessing          BatchStatus = "PROCESSING"
+	BatchCompleted           BatchStatus = "COMPLETED"
+	BatchCompletedWithErrors BatchStatus = "COMPLETED_WITH_ERRORS"
+	BatchCancelled           BatchStatus = "CANCELLED"
+)
+
+type ItemStatus string
+
+const (
+	ItemPending ItemStatus = "PENDING"
+	ItemSuccess ItemStatus = "SUCCESS"
+	ItemFailed  ItemStatus = "FAILED"
+)
+
+// Batch adalah satu unggahan pembayaran massal yang didanai dari satu wallet sumber.
+type Batch struct {
+	ID              uint        `gorm:"primaryKey" json:"id"`
+	BatchID         string      `gorm:"type:varchar(40);uniqueIndex;not null" json:"batch_id"`
+	SourceWalletID  uint        `gorm:"not null" json:"source_wallet_id"`
+	Currency        string      `gorm:"type:char(3);not null" json:"currency"`
+	Description     string      `gorm:"type:varchar(255)" json:"description,omitempty"`
+	FileName        string      `gorm:"type:varchar(255)" json:"file_name,omitempty"`
+	Status          BatchStatus `gorm:"type:enum('VALIDATED','AWAITING_APPROVAL','PROCESSING','COMPLETED','COMPLETED_WITH_ERRORS','CANCELLED');default:'VALIDATED';index" json:"status"`
+	TotalRows       int         `gorm:"not null" json:"total_rows"`
+	TotalAmount     float64     `gorm:"not null" json:"total_amount"`
+	SucceededRows   int         `gorm:"not null;default:0" json:"succeeded_rows"`
+	SucceededAmount float64     `gorm:"not null;default:0" json:"succeeded_amount"`
+	FailedRows      int         `gorm:"not null;default:0" json:"failed_rows"`
+	CreatedBy       uint        `gorm:"not null" json:"created_by"`
+	// ApprovalAttempts bertambah setiap batch diajukan ke approvals sehingga
+	// pengajuan ulang setelah ditolak memakai referensi approval yang baru.
+	ApprovalAttempts int        `gorm:"not null;default:0" json:"approval_attempts"`
+	ExecutedBy       *uint      `json:"executed_by,omitempty"`
+	StartedAt        *time.Time `json:"started_at,omitempty"`
+	CompletedAt      *time.Time `json:"completed_at,omitempty"`
+	CreatedAt        time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// Item adalah satu baris batch. Reference unik secara global sehingga baris
+// yang sama tidak akan pernah dibayar dua kali, termasuk lintas batch.
+type Item struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	BatchID         uint       `gorm:"not null;index" json:"-"`
+	RowNumber       int        `gorm:"column:row_no;not null" json:"row_number"`
+	RecipientUserID uint       `gorm:"not null" json:"recipient_user_id"`
+	PhoneNumber     string     `gorm:"type:varchar(20)" json:"phone_number,omitempty"`
+	Amount          float64    `gorm:"not null" json:"amount"`
+	Reference       string     `gorm:"type:varchar(100);uniqueIndex;not null" json:"reference"`
+	Note            string     `gorm:"type:varchar(255)" json:"note,omitempty"`
+	Status          ItemStatus `gorm:"type:enum('PENDING','SUCCESS','FAILED');default:'PENDING';index" json:"status"`
+	Error           string     `gorm:"type:varchar(255)" json:"error,omitempty"`
+	ProcessedAt     *time.Time `json:"processed_at,omitempty"`
+	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// Row adalah satu baris file unggahan sebelum disimpan. Error berisi alasan
+// baris ditolak saat validasi.
+type Row struct {
+	RowNumber   int     `json:"row_number"`
+	UserID      uint    `json:"user_id,omitempty"`
+	PhoneNumber string  `json:"phone_number,omitempty"`
+	Amount      float64 `json:"amount"`
+	Reference   string  `json:"reference,omitempty"`
+	Note        string  `json:"note,omitempty"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// Report adalah hasil validasi (dry-run) sebuah file.
+type Report struct {
+	SourceWalletID   uint    `json:"source_wallet_id"`
+	Currency         string  `json:"currency"`
+	TotalRows        int     `json:"total_rows"`
+	ValidRows        int     `json:"valid_rows"`
+	InvalidRows      int     `json:"invalid_rows"`
+	TotalAmount      float64 `json:"total_amount"`
+	AvailableBalance float64 `json:"available_balance"`
+	Sufficient       bool    `json:"sufficient"`
+	Rows             []Row   `json:"rows"`
+}
+
+// Valid melaporkan apakah batch boleh disimpan.
+func (r Report) Valid() bool {
+	return r.TotalRows > 0 && r.InvalidRows == 0 && r.Sufficient
+}
+
+// Progress merangkum status item sebuah batch.
+type Progress struct {
+	Pending   int     `json:"pending"`
+	Succeeded int     `json:"succeeded"`
+	Failed    int     `json:"failed"`
+	Percent   float64 `json:"percent"`
+}
@@ -0,0 +1,116 @@
+package disbursements
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var ErrUnsupportedFormat = errors.New("format file tidak didukung, gunakan CSV atau JSON")
+
+// ParseFile membaca baris penerima dari file CSV atau JSON. Format ditentukan
+// dari ekstensi nama file, lalu dari karakter pertama isi file. Kolom CSV
+// yang dikenali: user_id, phone_number, amount, reference, note. Kesalahan per
+// baris dicatat di Row.Error; error hanya dikembalikan jika file tidak terbaca.
+func ParseFile(name string, data []byte) ([]Row, error) {
+	lower := strings.ToLower(name)
+	trimmed := bytes.TrimSpace(data)
+
+	switch {
+	case strings.HasSuffix(lower, ".json"):
+		return parseJSON(trimmed)
+	case strings.HasSuffix(lower, ".csv"):
+		return parseCSV(trimmed)
+	case len(trimmed) > 0 && trimmed[0] == '[':
+		return parseJSON(trimmed)
+	case len(trimmed) > 0:
+		return parseCSV(trimmed)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+func parseJSON(data []byte) ([]Row, error) {
+	var rows []Row
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, fmt.Errorf("file JSON tidak valid: %v", err)
+	}
+	for i := range rows {
+		rows[i].RowNumber = i + 1
+		rows[i].PhoneNumber = strings.TrimSpace(rows[i].PhoneNumber)
+		rows[i].Reference = strings.TrimSpace(rows[i].Reference)
+		rows[i].Error = ""
+	}
+	return rows, nil
+}
+
+func parseCSV(data []byte) ([]Row, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("header CSV tidak terbaca: %v", err)
+	}
+
+	index := make(map[string]int)
+	for i, column := range header {
+		index[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))] = i
+	}
+	if _, ok := index["amount"]; !ok {
+		return nil, errors.New("kolom amount wajib ada di header CSV")
+	}
+	_, hasUser := index["user_id"]
+	_, hasPhone := index["phone_number"]
+	if !hasUser && !hasPhone {
+		return nil, errors.New("kolom user_id atau phone_number wajib ada di header CSV")
+	}
+
+	var rows []Row
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("baris %d tidak terbaca: %v", line, err)
+		}
+
+		field := func(column string) string {
+			i, ok := index[column]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		row := Row{
+			RowNumber:   line,
+			PhoneNumber: field("phone_number"),
+			Reference:   field("reference"),
+			Note:        field("note"),
+		}
+
+		if raw := field("user_id"); raw != "" {
+			id, err := strconv.ParseUint(raw, 10, 64)
+			if err != nil || id == 0 {
+				row.Error = "user_id tidak valid"
+			}
+			row.UserID = uint(id)
+		}
+
+		amount, err := strconv.ParseFloat(field("amount"), 64)
+		if err != nil && row.Error == "" {
+			row.Error = "amount tidak valid"
+		}
+		row.Amount = amount
+
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -0,0 +1,57 @@
+package disbursements
+
+import "testing"
+
+func TestParseFileCSV(t *testing.T) {
+	data := []byte("\ufeffUser_ID,phone_number,amount,reference,note\n" +
+		"12,,150000,REF-1,insentif\n" +
+		",081234567890,25000,,\n" +
+		"abc,,1000,,\n" +
+		"13,,seribu,,\n")
+
+	rows, err := ParseFile("payout.csv", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("len(rows) = %d, want 4", len(rows))
+	}
+
+	if rows[0].UserID != 12 || rows[0].Amount != 150000 || rows[0].Reference != "REF-1" || rows[0].Note != "insentif" || rows[0].Error != "" {
+		t.Fatalf("row 1 = %+v", rows[0])
+	}
+	if rows[1].PhoneNumber != "081234567890" || rows[1].RowNumber != 2 || rows[1].Error != "" {
+		t.Fatalf("row 2 = %+v", rows[1])
+	}
+	if rows[2].Error != "user_id tidak valid" {
+		t.Fatalf("row 3 error = %q", rows[2].Error)
+	}
+	if rows[3].Error != "amount tidak valid" {
+		t.Fatalf("row 4 error = %q", rows[3].Error)
+	}
+}
+
+func TestParseFileCSVRequiresColumns(t *testing.T) {
+	if _, err := ParseFile("payout.csv", []byte("name,amount\nBudi,1000\n")); err == nil {
+		t.Fatal("header tanpa user_id/phone_number harus ditolak")
+	}
+	if _, err := ParseFile("payout.csv", []byte("user_id,nominal\n1,1000\n")); err == nil {
+		t.Fatal("header tanpa amount harus ditolak")
+	}
+}
+
+func TestParseFileJSON(t *testing.T) {
+	data := []byte(`[{"user_id": 7, "amount": 5000}, {"phone_number": " 0812 ", "amount": 2500, "reference": "X-2"}]`)
+
+	rows, err := ParseFile("upload", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].UserID != 7 || rows[1].PhoneNumber != "0812" || rows[1].RowNumber != 2 {
+		t.Fatalf("rows = %+v", rows)
+	}
+
+	if _, err := ParseFile("upload.json", []byte(`{"rows": []}`)); err == nil {
+		t.Fatal("JSON bukan array harus ditolak")
+	}
+}
@@ -0,0 +1,193 @@
+package disbursements
+
+import (
+	"errors"
+	"ewallet-engine/internal/auth"
+	"ewallet-engine/internal/balance"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrItemAlreadyProcessed dikembalikan saat baris sudah diproses worker lain.
+var ErrItemAlreadyProcessed = errors.New("baris sudah diproses")
+
+type itemCount struct {
+	Status ItemStatus
+	Rows   int
+	Amount float64
+}
+
+type DisbursementRepository interface {
+	FindWallet(id uint) (*balance.Wallet, error)
+	FindUsersByIDs(ids []uint) ([]auth.User, error)
+	FindUsersByPhones(phones []string) ([]auth.User, error)
+	FindWalletOwners(userIDs []uint, currency string) ([]uint, error)
+	ExistingReferences(references []string) ([]string, error)
+
+	CreateBatch(batch *Batch, items []Item) error
+	FindBatch(batchID string) (*Batch, error)
+	ListBatches(status BatchStatus, limit int) ([]Batch, error)
+	TransitionBatch(id uint, from BatchStatus, updates map[string]interface{}) (bool, error)
+
+	ListItems(batchID uint, status ItemStatus) ([]Item, error)
+	CountItems(batchID uint) ([]itemCount, error)
+	PayItem(item *Item, sourceWalletID uint, currency string) error
+	FailItem(id uint, reason string) (bool, error)
+}
+
+type disbursementRepository struct {
+	DB *gorm.DB
+}
+
+func NewDisbursementRepository(db *gorm.DB) DisbursementRepository {
+	return &disbursementRepository{DB: db}
+}
+
+func (r *disbursementRepository) FindWallet(id uint) (*balance.Wallet, error) {
+	var wallet balance.Wallet
+	if err := r.DB.First(&wallet, id).Error; err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+func (r *disbursementRepository) FindUsersByIDs(ids []uint) ([]auth.User, error) {
+	var users []auth.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	err := r.DB.Where("id IN ?", ids).Find(&users).Error
+	return users, err
+}
+
+func (r *disbursementRepository) FindUsersByPhones(phones []string) ([]auth.User, error) {
+	var users []auth.User
+	if len(phones) == 0 {
+		return users, nil
+	}
+	err := r.DB.Where("phone_number IN ?", phones).Find(&users).Error
+	return users, err
+}
+
+func (r *disbursementRepository) FindWalletOwners(userIDs []uint, currency string) ([]uint, error) {
+	var owners []uint
+	if len(userIDs) == 0 {
+		return owners, nil
+	}
+	err := r.DB.Model(&balance.Wallet{}).Where("user_id IN ? AND currency = ?", userIDs, currency).Pluck("user_id", &owners).Error
+	return owners, err
+}
+
+func (r *disbursementRepository) ExistingReferences(references []string) ([]string, error) {
+	var existing []string
+	if len(references) == 0 {
+		return existing, nil
+	}
+	err := r.DB.Model(&Item{}).Where("reference IN ?", references).Pluck("reference", &existing).Error
+	return existing, err
+}
+
+func (r *disbursementRepository) CreateBatch(batch *Batch, items []Item) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(batch).Error; err != nil {
+			return err
+		}
+		for i := range items {
+			items[i].BatchID = batch.ID
+		}
+		return tx.CreateInBatches(items, 500).Error
+	})
+}
+
+func (r *disbursementRepository) FindBatch(batchID string) (*Batch, error) {
+	var batch Batch
+	if err := r.DB.Where("batch_id = ?", batchID).First(&batch).Error; err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+func (r *disbursementRepository) ListBatches(status BatchStatus, limit int) ([]Batch, error) {
+	var batches []Batch
+	query := r.DB.Order("id DESC").Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&batches).Error
+	return batches, err
+}
+
+func (r *disbursementRepository) TransitionBatch(id uint, from BatchStatus, updates map[string]interface{}) (bool, error) {
+	result := r.DB.Model(&Batch{}).Where("id = ? AND status = ?", id, from).Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *disbursementRepository) ListItems(batchID uint, status ItemStatus) ([]Item, error) {
+	var items []Item
+	query := r.DB.Where("batch_id = ?", batchID).Order("row_no ASC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&items).Error
+	return items, err
+}
+
+func (r *disbursementRepository) CountItems(batchID uint) ([]itemCount, error) {
+	var counts []itemCount
+	err := r.DB.Model(&Item{}).
+		Select("status, COUNT(*) AS `rows`, COALESCE(SUM(amount), 0) AS amount").
+		Where("batch_id = ?", batchID).Group("status").Scan(&counts).Error
+	return counts, err
+}
+
+// PayItem menandai baris SUCCESS dan memindahkan dana dalam satu transaksi DB.
+// Klaim PENDING -> SUCCESS menjamin setiap baris hanya dibayar sekali walaupun
+// batch dilanjutkan oleh lebih dari satu proses.
+func (r *disbursementRepository) PayItem(item *Item, sourceWalletID uint, currency string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&Item{}).
+			Where("id = ? AND status = ?", item.ID, ItemPending).
+			Updates(map[string]interface{}{"status": ItemSuccess, "processed_at": &now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return ErrItemAlreadyProcessed
+		}
+
+		target, err := balance.FindUserWallet(tx, item.RecipientUserID, currency, true)
+		if err != nil {
+			return err
+		}
+
+		var locked []balance.Wallet
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", []uint{sourceWalletID, target.ID}).Order("id ASC").Find(&locked).Error
+		if err != nil {
+			return err
+		}
+
+		if _, err := balance.ApplyWalletEntry(tx, sourceWalletID, "DEBIT", item.Amount, item.Reference); err != nil {
+			return err
+		}
+		_, err = balance.ApplyWalletEntry(tx, target.ID, "CREDIT", item.Amount, item.Reference)
+		return err
+	})
+}
+
+func (r *disbursementRepository) FailItem(id uint, reason string) (bool, error) {
+	now := time.Now()
+	result := r.DB.Model(&Item{}).
+		Where("id = ? AND status = ?", id, ItemPending).
+		Updates(map[string]interface{}{"status": ItemFailed, "error": reason, "processed_at": &now})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -0,0 +1,685 @@
+package disbursements
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"ewallet-engine/internal/approvals"
+	"ewallet-engine/internal/auth"
+	"ewallet-engine/internal/balance"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrBatchNotFound    = errors.New("batch disbursement tidak ditemukan")
+	ErrBatchInvalid     = errors.New("file berisi baris yang tidak valid atau saldo sumber tidak mencukupi")
+	ErrBatchNotPending  = errors.New("batch tidak dalam status yang dapat diproses")
+	ErrSourceNotFunding = errors.New("wallet sumber bukan wallet pendanaan disbursement")
+)
+
+type DisbursementService interface {
+	Validate(sourceWalletID uint, rows []Row) (*Report, error)
+	CreateBatch(actorID uint, sourceWalletID uint, description string, fileName string, rows []Row) (*Batch, *Report, error)
+	Execute(actorID uint, batchID string) (*Batch, error)
+	ExecuteApproved(actorID uint, batchID string) (*Batch, error)
+	Reopen(batchID string) (*Batch, error)
+	Cancel(batchID string) (*Batch, error)
+	ResumeProcessing() int
+	GetBatch(batchID string) (*Batch, *Progress, error)
+	ListBatches(status BatchStatus) ([]Batch, error)
+	ListItems(batchID string, status ItemStatus) ([]Item, error)
+	ResultCSV(batchID string) ([]byte, error)
+}
+
+// ApprovalSubmitter adalah bagian ApprovalService yang dipakai untuk
+// mengajukan eksekusi batch.
+type ApprovalSubmitter interface {
+	Submit(makerID uint, request approvals.SubmitRequest) (*approvals.ApprovalRequest, error)
+}
+
+type disbursementService struct {
+	repo        DisbursementRepository
+	approvals   ApprovalSubmitter
+	creditGuard balance.CreditGuard
+	workers     int
+	maxRows     int
+	// fundingWallets adalah wallet selain wallet platform yang boleh menjadi
+	// sumber batch (DISBURSEMENT_FUNDING_WALLET_IDS).
+	fundingWallets map[uint]bool
+
+	mu      sync.Mutex
+	running map[uint]bool
+}
+
+func NewDisbursementService(repo DisbursementRepository, approvalService ApprovalSubmitter, creditGuard balance.CreditGuard) DisbursementService {
+	s := &disbursementService{repo: repo, approvals: approvalService, creditGuard: creditGuard, workers: 5, maxRows: 5000, fundingWallets: make(map[uint]bool), running: make(map[uint]bool)}
+	if v, err := strconv.Atoi(os.Getenv("DISBURSEMENT_WORKERS")); err == nil && v > 0 {
+		s.workers = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("DISBURSEMENT_MAX_ROWS")); err == nil && v > 0 {
+		s.maxRows = v
+	}
+	for _, id := range strings.Split(os.Getenv("DISBURSEMENT_FUNDING_WALLET_IDS"), ",") {
+		if v, err := strconv.ParseUint(strings.TrimSpace(id), 10, 64); err == nil && v > 0 {
+			s.fundingWallets[uint(v)] = true
+		}
+	}
+	return s
+}
+
+// Validate memeriksa setiap baris tanpa menyimpan apa pun (dry-run).
+func (s *disbursementService) Validate(sourceWalletID uint, rows []Row) (*Report, error) {
+	if len(rows) == 0 {
+		return nil, errors.New("file tidak berisi baris penerima")
+	}
+	if len(rows) > s.maxRows {
+		return nil, fmt.Errorf("jumlah baris melebihi batas %d", s.maxRows)
+	}
+
+	source, err := s.repo.FindWallet(sourceWalletID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("wallet sumber tidak ditemukan")
+		}
+		return nil, err
+	}
+	if source.UserID != balance.PlatformRevenueUserID && !s.fundingWallets[source.ID] {
+		return nil, ErrSourceNotFunding
+	}
+
+	recipients, err := s.resolveRecipients(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	var walletOwners map[uint]bool
+	if source.Currency != balance.DefaultCurrency {
+		walletOwners, err = s.walletOwners(rows, recipients, source.Currency)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	references := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if row.Reference != "" {
+			references = append(references, row.Reference)
+		}
+	}
+	used, err := s.repo.ExistingReferences(references)
+	if err != nil {
+		return nil, err
+	}
+	usedReferences := make(map[string]bool, len(used))
+	for _, reference := range used {
+		usedReferences[reference] = true
+	}
+
+	report := &Report{
+		SourceWalletID:   source.ID,
+		Currency:         source.Currency,
+		TotalRows:        len(rows),
+		AvailableBalance: source.Available(),
+		Rows:             make([]Row, len(rows)),
+	}
+	seen := make(map[string]int)
+
+	for i, row := range rows {
+		if row.Error == "" {
+			row.Error = s.validateRow(&row, source, recipients, walletOwners, usedReferences, seen)
+		}
+		if row.Error == "" {
+			report.ValidRows++
+			report.TotalAmount += row.Amount
+		} else {
+			report.InvalidRows++
+		}
+		report.Rows[i] = row
+	}
+
+	report.TotalAmount = balance.RoundAmount(report.TotalAmount, source.Currency)
+	report.Sufficient = report.TotalAmount <= report.AvailableBalance
+	return report, nil
+}
+
+func (s *disbursementService) validateRow(row *Row, source *balance.Wallet, recipients recipientIndex, walletOwners map[uint]bool, usedReferences map[string]bool, seen map[string]int) string {
+	user, problem := recipients.lookup(*row)
+	if problem != "" {
+		return problem
+	}
+	row.UserID = user.ID
+	row.PhoneNumber = user.PhoneNumber
+
+	if user.Status != auth.StatusActive {
+		return "akun penerima tidak aktif"
+	}
+	if user.ID == source.UserID {
+		return "penerima tidak boleh pemilik wallet sumber"
+	}
+	if walletOwners != nil && !walletOwners[user.ID] {
+		return fmt.Sprintf("penerima tidak memiliki wallet %s", source.Currency)
+	}
+	if err := balance.ValidateAmount(row.Amount, source.Currency); err != nil {
+		return err.Error()
+	}
+
+	if row.Reference != "" {
+		if len(row.Reference) > 80 {
+			return "reference maksimal 80 karakter"
+		}
+		if first, ok := seen[row.Reference]; ok {
+			return fmt.Sprintf("reference duplikat dengan baris %d", first)
+		}
+		seen[row.Reference] = row.RowNumber
+		if usedReferences[row.Reference] {
+			return "reference sudah pernah digunakan"
+		}
+	}
+	return ""
+}
+
+// CreateBatch menyimpan batch hanya jika seluruh baris valid dan saldo
+// tersedia di wallet sumber mencukupi. Report selalu dikembalikan bila validasi
+// sempat berjalan.
+func (s *disbursementService) CreateBatch(actorID uint, sourceWalletID uint, description string, fileName string, rows []Row) (*Batch, *Report, error) {
+	report, err := s.Validate(sourceWalletID, rows)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !report.Valid() {
+		return nil, report, ErrBatchInvalid
+	}
+
+	batchID, err := newBatchID()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	batch := &Batch{
+		BatchID:        batchID,
+		SourceWalletID: report.SourceWalletID,
+		Currency:       report.Currency,
+		Description:    strings.TrimSpace(description),
+		FileName:       fileName,
+		Status:         BatchValidated,
+		TotalRows:      report.TotalRows,
+		TotalAmount:    report.TotalAmount,
+		CreatedBy:      actorID,
+	}
+
+	items := make([]Item, 0, len(report.Rows))
+	for _, row := range report.Rows {
+		reference := row.Reference
+		if reference == "" {
+			reference = fmt.Sprintf("%s-%05d", batchID, row.RowNumber)
+		}
+		items = append(items, Item{
+			RowNumber:       row.RowNumber,
+			RecipientUserID: row.UserID,
+			PhoneNumber:     row.PhoneNumber,
+			Amount:          row.Amount,
+			Reference:       reference,
+			Note:            row.Note,
+			Status:          ItemPending,
+		})
+	}
+
+	if err := s.repo.CreateBatch(batch, items); err != nil {
+		return nil, report, err
+	}
+	return batch, report, nil
+}
+
+// Execute mengajukan batch VALIDATED ke approvals. Batch dengan total di bawah
+// batas auto-approve mata uangnya langsung disetujui dan mulai diproses,
+// selebihnya menunggu checker. Batch yang masih PROCESSING (misalnya setelah restart)
+// dilanjutkan dari baris yang belum diproses.
+func (s *disbursementService) Execute(actorID uint, batchID string) (*Batch, error) {
+	batch, err := s.findBatch(batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch batch.Status {
+	case BatchValidated:
+		return s.submitForApproval(actorID, batch)
+	case BatchProcessing:
+		s.start(*batch)
+		return batch, nil
+	default:
+		return nil, ErrBatchNotPending
+	}
+}
+
+func (s *disbursementService) submitForApproval(actorID uint, batch *Batch) (*Batch, error) {
+	attempt := batch.ApprovalAttempts + 1
+	ok, err := s.repo.TransitionBatch(batch.ID, BatchValidated, map[string]interface{}{
+		"status":            BatchAwaitingApproval,
+		"approval_attempts": attempt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrBatchNotPending
+	}
+
+	approval, err := s.approvals.Submit(actorID, approvals.SubmitRequest{
+		OperationType: approvals.OperationDisbursement,
+		Payload: approvals.Payload{
+			"batch_id":         batch.BatchID,
+			"reference":        fmt.Sprintf("%s-%d", batch.BatchID, attempt),
+			"amount":           batch.TotalAmount,
+			"currency":         batch.Currency,
+			"source_wallet_id": batch.SourceWalletID,
+		},
+		Reason: fmt.Sprintf("Eksekusi disbursement %s (%d baris)", batch.BatchID, batch.TotalRows),
+	})
+	if approval == nil && err != nil {
+		if _, revertErr := s.repo.TransitionBatch(batch.ID, BatchAwaitingApproval, map[string]interface{}{"status": BatchValidated}); revertErr != nil {
+			log.Printf("ERROR: Gagal mengembalikan status batch %s: %v", batch.BatchID, revertErr)
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.findBatch(batch.BatchID)
+}
+
+// ExecuteApproved memulai pembayaran batch yang sudah disetujui di background.
+// Dipanggil oleh executor approvals.OperationDisbursement dengan checker
+// sebagai actorID. Batch yang sudah mulai diproses tidak diulang, jadi
+// eksekusi ulang approval yang sama cukup melanjutkan baris yang tersisa.
+func (s *disbursementService) ExecuteApproved(actorID uint, batchID string) (*Batch, error) {
+	batch, err := s.findBatch(batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch batch.Status {
+	case BatchProcessing:
+		s.start(*batch)
+		return batch, nil
+	case BatchCompleted, BatchCompletedWithErrors:
+		return batch, nil
+	}
+
+	now := time.Now()
+	ok, err := s.repo.TransitionBatch(batch.ID, BatchAwaitingApproval, map[string]interface{}{
+		"status":      BatchProcessing,
+		"executed_by": actorID,
+		"started_at":  &now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrBatchNotPending
+	}
+	batch.Status = BatchProcessing
+	batch.ExecutedBy = &actorID
+	batch.StartedAt = &now
+
+	s.start(*batch)
+	return batch, nil
+}
+
+// Reopen mengembalikan batch AWAITING_APPROVAL ke VALIDATED. Dipanggil saat
+// approval-nya ditolak, kedaluwarsa atau gagal dieksekusi sehingga batch bisa
+// diajukan ulang tanpa diunggah ulang.
+func (s *disbursementService) Reopen(batchID string) (*Batch, error) {
+	batch, err := s.findBatch(batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := s.repo.TransitionBatch(batch.ID, BatchAwaitingApproval, map[string]interface{}{"status": BatchValidated})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrBatchNotPending
+	}
+	batch.Status = BatchValidated
+	return batch, nil
+}
+
+// Cancel membatalkan batch yang belum diproses. Approval yang masih menunggu
+// untuk batch ini akan gagal dieksekusi karena status batch sudah berubah.
+func (s *disbursementService) Cancel(batchID string) (*Batch, error) {
+	batch, err := s.findBatch(batchID)
+	if err != nil {
+		return nil, err
+	}
+	if batch.Status != BatchValidated && batch.Status != BatchAwaitingApproval {
+		return nil, ErrBatchNotPending
+	}
+
+	ok, err := s.repo.TransitionBatch(batch.ID, batch.Status, map[string]interface{}{"status": BatchCancelled})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrBatchNotPending
+	}
+	batch.Status = BatchCancelled
+	return batch, nil
+}
+
+// ResumeProcessing melanjutkan semua batch PROCESSING, dipanggil saat server start.
+func (s *disbursementService) ResumeProcessing() int {
+	batches, err := s.repo.ListBatches(BatchProcessing, 100)
+	if err != nil {
+		log.Printf("ERROR: Gagal memuat batch disbursement yang sedang diproses: %v", err)
+		return 0
+	}
+	for _, batch := range batches {
+		s.start(batch)
+	}
+	return len(batches)
+}
+
+func (s *disbursementService) GetBatch(batchID string) (*Batch, *Progress, error) {
+	batch, err := s.findBatch(batchID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	counts, err := s.repo.CountItems(batch.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	progress := &Progress{}
+	total := 0
+	for _, count := range counts {
+		total += count.Rows
+		switch count.Status {
+		case ItemPending:
+			progress.Pending = count.Rows
+		case ItemSuccess:
+			progress.Succeeded = count.Rows
+		case ItemFailed:
+			progress.Failed = count.Rows
+		}
+	}
+	if total > 0 {
+		progress.Percent = math.Round(float64(progress.Succeeded+progress.Failed)/float64(total)*10000) / 100
+	}
+	return batch, progress, nil
+}
+
+func (s *disbursementService) ListBatches(status BatchStatus) ([]Batch, error) {
+	return s.repo.ListBatches(BatchStatus(strings.ToUpper(string(status))), 100)
+}
+
+func (s *disbursementService) ListItems(batchID string, status ItemStatus) ([]Item, error) {
+	batch, err := s.findBatch(batchID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListItems(batch.ID, ItemStatus(strings.ToUpper(string(status))))
+}
+
+// ResultCSV menghasilkan file hasil per baris untuk diunduh tim operasional.
+func (s *disbursementService) ResultCSV(batchID string) ([]byte, error) {
+	batch, err := s.findBatch(batchID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.repo.ListItems(batch.ID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	_ = writer.Write([]string{"row_number", "user_id", "phone_number", "amount", "currency", "reference", "status", "error", "processed_at"})
+	for _, item := range items {
+		processedAt := ""
+		if item.ProcessedAt != nil {
+			processedAt = item.ProcessedAt.Format(time.RFC3339)
+		}
+		_ = writer.Write([]string{
+			strconv.Itoa(item.RowNumber),
+			strconv.FormatUint(uint64(item.RecipientUserID), 10),
+			item.PhoneNumber,
+			strconv.FormatFloat(item.Amount, 'f', balance.MinorUnits(batch.Currency), 64),
+			batch.Currency,
+			item.Reference,
+			string(item.Status),
+			item.Error,
+			processedAt,
+		})
+	}
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+// start menjalankan batch di goroutine terpisah kecuali batch tersebut sudah
+// berjalan di proses ini.
+func (s *disbursementService) start(batch Batch) {
+	s.mu.Lock()
+	if s.running[batch.ID] {
+		s.mu.Unlock()
+		return
+	}
+	s.running[batch.ID] = true
+	s.mu.Unlock()
+
+	go func() {
+		defer func() {
+			s.mu.Lock()
+			delete(s.running, batch.ID)
+			s.mu.Unlock()
+		}()
+		s.run(batch)
+	}()
+}
+
+func (s *disbursementService) run(batch Batch) {
+	items, err := s.repo.ListItems(batch.ID, ItemPending)
+	if err != nil {
+		log.Printf("ERROR: Gagal memuat baris batch %s: %v", batch.BatchID, err)
+		return
+	}
+
+	queue := make(chan Item)
+	var wg sync.WaitGroup
+	for i := 0; i < s.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range queue {
+				s.pay(batch, item)
+			}
+		}()
+	}
+	for _, item := range items {
+		queue <- item
+	}
+	close(queue)
+	wg.Wait()
+
+	s.finish(batch)
+}
+
+func (s *disbursementService) pay(batch Batch, item Item) {
+	var err error
+	// Batas saldo tier KYC dinyatakan dalam DefaultCurrency.
+	if batch.Currency == balance.DefaultCurrency {
+		err = s.creditGuard.CheckCredit(item.RecipientUserID, item.Amount)
+	}
+	if err == nil {
+		err = s.repo.PayItem(&item, batch.SourceWalletID, batch.Currency)
+	}
+	if err == nil || errors.Is(err, ErrItemAlreadyProcessed) {
+		return
+	}
+
+	reason := err.Error()
+	if errors.Is(err, balance.ErrInsufficientBalance) {
+		reason = "saldo wallet sumber tidak mencukupi"
+	}
+	if _, failErr := s.repo.FailItem(item.ID, reason); failErr != nil {
+		log.Printf("ERROR: Gagal menandai baris %d batch %s gagal: %v", item.RowNumber, batch.BatchID, failErr)
+	}
+}
+
+func (s *disbursementService) finish(batch Batch) {
+	counts, err := s.repo.CountItems(batch.ID)
+	if err != nil {
+		log.Printf("ERROR: Gagal menghitung hasil batch %s: %v", batch.BatchID, err)
+		return
+	}
+
+	var succeeded, failed, pending int
+	var amount float64
+	for _, count := range counts {
+		switch count.Status {
+		case ItemSuccess:
+			succeeded, amount = count.Rows, count.Amount
+		case ItemFailed:
+			failed = count.Rows
+		case ItemPending:
+			pending = count.Rows
+		}
+	}
+	if pending > 0 {
+		log.Printf("ERROR: Batch %s masih memiliki %d baris PENDING", batch.BatchID, pending)
+		return
+	}
+
+	status := BatchCompleted
+	if failed > 0 {
+		status = BatchCompletedWithErrors
+	}
+	now := time.Now()
+	_, err = s.repo.TransitionBatch(batch.ID, BatchProcessing, map[string]interface{}{
+		"status":           status,
+		"succeeded_rows":   succeeded,
+		"succeeded_amount": amount,
+		"failed_rows":      failed,
+		"completed_at":     &now,
+	})
+	if err != nil {
+		log.Printf("ERROR: Gagal menyelesaikan batch %s: %v", batch.BatchID, err)
+		return
+	}
+	log.Printf("SUCCESS: Batch %s selesai: %d berhasil (%.2f %s), %d gagal", batch.BatchID, succeeded, amount, batch.Currency, failed)
+}
+
+func (s *disbursementService) findBatch(batchID string) (*Batch, error) {
+	batch, err := s.repo.FindBatch(batchID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBatchNotFound
+		}
+		return nil, err
+	}
+	return batch, nil
+}
+
+func (s *disbursementService) resolveRecipients(rows []Row) (recipientIndex, error) {
+	var ids []uint
+	var phones []string
+	for _, row := range rows {
+		if row.UserID != 0 {
+			ids = append(ids, row.UserID)
+		}
+		if row.PhoneNumber != "" {
+			phones = append(phones, row.PhoneNumber)
+		}
+	}
+
+	index := recipientIndex{byID: make(map[uint]auth.User), byPhone: make(map[string]auth.User)}
+
+	users, err := s.repo.FindUsersByIDs(ids)
+	if err != nil {
+		return index, err
+	}
+	for _, user := range users {
+		index.byID[user.ID] = user
+	}
+
+	users, err = s.repo.FindUsersByPhones(phones)
+	if err != nil {
+		return index, err
+	}
+	for _, user := range users {
+		index.byPhone[user.PhoneNumber] = user
+	}
+	return index, nil
+}
+
+func (s *disbursementService) walletOwners(rows []Row, recipients recipientIndex, currency string) (map[uint]bool, error) {
+	var ids []uint
+	for _, row := range rows {
+		if user, problem := recipients.lookup(row); problem == "" {
+			ids = append(ids, user.ID)
+		}
+	}
+
+	owners, err := s.repo.FindWalletOwners(ids, currency)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[uint]bool, len(owners))
+	for _, id := range owners {
+		result[id] = true
+	}
+	return result, nil
+}
+
+type recipientIndex struct {
+	byID    map[uint]auth.User
+	byPhone map[string]auth.User
+}
+
+// lookup mencari penerima berdasarkan user_id atau phone_number. Jika
+// keduanya diisi, keduanya harus menunjuk user yang sama.
+func (idx recipientIndex) lookup(row Row) (auth.User, string) {
+	if row.UserID == 0 && row.PhoneNumber == "" {
+		return auth.User{}, "user_id atau phone_number wajib diisi"
+	}
+
+	var byID, byPhone auth.User
+	var okID, okPhone bool
+	if row.UserID != 0 {
+		if byID, okID = idx.byID[row.UserID]; !okID {
+			return auth.User{}, "user_id tidak ditemukan"
+		}
+	}
+	if row.PhoneNumber != "" {
+		if byPhone, okPhone = idx.byPhone[row.PhoneNumber]; !okPhone {
+			return auth.User{}, "phone_number tidak terdaftar"
+		}
+	}
+	if okID && okPhone && byID.ID != byPhone.ID {
+		return auth.User{}, "user_id dan phone_number merujuk user berbeda"
+	}
+	if okID {
+		return byID, ""
+	}
+	return byPhone, ""
+}
+
+func newBatchID() (string, error) {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "DSB-" + strings.ToUpper(hex.EncodeToString(buf)), nil
+}
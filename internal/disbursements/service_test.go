@@ -0,0 +1,310 @@
+package disbursements
+
+import (
+	"errors"
+	"ewallet-engine/internal/approvals"
+	"ewallet-engine/internal/auth"
+	"ewallet-engine/internal/balance"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+type fakeDisbursementRepository struct {
+	DisbursementRepository
+	wallets map[uint]*balance.Wallet
+	batches map[string]*Batch
+	items   []*Item
+}
+
+func (r *fakeDisbursementRepository) FindWallet(id uint) (*balance.Wallet, error) {
+	wallet, ok := r.wallets[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return wallet, nil
+}
+
+func (r *fakeDisbursementRepository) FindUsersByIDs(ids []uint) ([]auth.User, error) {
+	var users []auth.User
+	for _, id := range ids {
+		if r.walletOf(id) != nil {
+			users = append(users, auth.User{ID: id, Status: auth.StatusActive})
+		}
+	}
+	return users, nil
+}
+
+func (r *fakeDisbursementRepository) FindUsersByPhones(phones []string) ([]auth.User, error) {
+	return nil, nil
+}
+
+func (r *fakeDisbursementRepository) ExistingReferences(references []string) ([]string, error) {
+	return nil, nil
+}
+
+func (r *fakeDisbursementRepository) FindBatch(batchID string) (*Batch, error) {
+	batch, ok := r.batches[batchID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *batch
+	return &copied, nil
+}
+
+func (r *fakeDisbursementRepository) TransitionBatch(id uint, from BatchStatus, updates map[string]interface{}) (bool, error) {
+	for _, batch := range r.batches {
+		if batch.ID != id {
+			continue
+		}
+		if batch.Status != from {
+			return false, nil
+		}
+		batch.Status = updates["status"].(BatchStatus)
+		if attempts, ok := updates["approval_attempts"].(int); ok {
+			batch.ApprovalAttempts = attempts
+		}
+		if executedBy, ok := updates["executed_by"].(uint); ok {
+			batch.ExecutedBy = &executedBy
+		}
+		if rows, ok := updates["succeeded_rows"].(int); ok {
+			batch.SucceededRows = rows
+			batch.SucceededAmount = updates["succeeded_amount"].(float64)
+			batch.FailedRows = updates["failed_rows"].(int)
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+func (r *fakeDisbursementRepository) ListItems(batchID uint, status ItemStatus) ([]Item, error) {
+	var items []Item
+	for _, item := range r.items {
+		if item.BatchID == batchID && item.Status == status {
+			items = append(items, *item)
+		}
+	}
+	return items, nil
+}
+
+func (r *fakeDisbursementRepository) CountItems(batchID uint) ([]itemCount, error) {
+	counts := make(map[ItemStatus]*itemCount)
+	var result []itemCount
+	for _, item := range r.items {
+		if item.BatchID != batchID {
+			continue
+		}
+		if counts[item.Status] == nil {
+			counts[item.Status] = &itemCount{Status: item.Status}
+		}
+		counts[item.Status].Rows++
+		counts[item.Status].Amount += item.Amount
+	}
+	for _, count := range counts {
+		result = append(result, *count)
+	}
+	return result, nil
+}
+
+func (r *fakeDisbursementRepository) walletOf(userID uint) *balance.Wallet {
+	for _, wallet := range r.wallets {
+		if wallet.UserID == userID {
+			return wallet
+		}
+	}
+	return nil
+}
+
+func (r *fakeDisbursementRepository) PayItem(item *Item, sourceWalletID uint, currency string) error {
+	stored := r.items[item.ID-1]
+	if stored.Status != ItemPending {
+		return ErrItemAlreadyProcessed
+	}
+	source := r.wallets[sourceWalletID]
+	if source.Available() < item.Amount {
+		return balance.ErrInsufficientBalance
+	}
+	source.Balance -= item.Amount
+	r.walletOf(item.RecipientUserID).Balance += item.Amount
+	stored.Status = ItemSuccess
+	return nil
+}
+
+func (r *fakeDisbursementRepository) FailItem(id uint, reason string) (bool, error) {
+	stored := r.items[id-1]
+	if stored.Status != ItemPending {
+		return false, nil
+	}
+	stored.Status = ItemFailed
+	stored.Error = reason
+	return true, nil
+}
+
+// walletCap menolak kredit yang membuat saldo penerima melewati batas tier KYC.
+type walletCap struct {
+	repo *fakeDisbursementRepository
+	max  float64
+}
+
+func (c walletCap) CheckCredit(userID uint, amount float64) error {
+	if c.repo.walletOf(userID).Balance+amount > c.max {
+		return errors.New("saldo melebihi batas maksimum tier KYC")
+	}
+	return nil
+}
+
+// pendingApprovals mensimulasikan batch di atas APPROVAL_PAYOUT_LIMIT: setiap
+// pengajuan menunggu checker.
+type pendingApprovals struct {
+	submitted []approvals.SubmitRequest
+	err       error
+}
+
+func (a *pendingApprovals) Submit(makerID uint, request approvals.SubmitRequest) (*approvals.ApprovalRequest, error) {
+	if a.err != nil {
+		return nil, a.err
+	}
+	a.submitted = append(a.submitted, request)
+	return &approvals.ApprovalRequest{ID: uint(len(a.submitted)), OperationType: request.OperationType, Status: approvals.StatusPending, MakerID: makerID}, nil
+}
+
+// Wallet 2 adalah wallet pendanaan berisi 100.000; user 7, 8 dan 9 penerima.
+func newFakeDisbursementRepository(status BatchStatus, amounts ...float64) *fakeDisbursementRepository {
+	repo := &fakeDisbursementRepository{
+		wallets: map[uint]*balance.Wallet{
+			1: {ID: 1, UserID: balance.PlatformRevenueUserID, Currency: "IDR"},
+			2: {ID: 2, UserID: 50, Currency: "IDR", Balance: 100000},
+			3: {ID: 3, UserID: 7, Currency: "IDR"},
+			4: {ID: 4, UserID: 8, Currency: "IDR", Balance: 90000},
+			5: {ID: 5, UserID: 9, Currency: "IDR"},
+		},
+		batches: make(map[string]*Batch),
+	}
+	total := 0.0
+	for i, amount := range amounts {
+		repo.items = append(repo.items, &Item{ID: uint(i + 1), BatchID: 1, RowNumber: i + 1, RecipientUserID: uint(7 + i), Amount: amount, Status: ItemPending})
+		total += amount
+	}
+	repo.batches["DSB-1"] = &Batch{ID: 1, BatchID: "DSB-1", SourceWalletID: 2, Currency: "IDR", Status: status, TotalRows: len(amounts), TotalAmount: total}
+	return repo
+}
+
+func TestValidateChecksSourceWalletAndBalance(t *testing.T) {
+	repo := newFakeDisbursementRepository(BatchValidated)
+	service := &disbursementService{repo: repo, maxRows: 10, fundingWallets: map[uint]bool{2: true}}
+
+	if _, err := service.Validate(3, []Row{{RowNumber: 1, UserID: 9, Amount: 10000}}); !errors.Is(err, ErrSourceNotFunding) {
+		t.Fatalf("expected ErrSourceNotFunding for a user wallet; got %v", err)
+	}
+
+	cases := []struct {
+		name      string
+		amounts   []float64
+		wantTotal float64
+		wantValid bool
+	}{
+		{"within source balance", []float64{60000, 40000}, 100000, true},
+		{"above source balance", []float64{60000, 40001}, 100001, false},
+	}
+	for _, tc := range cases {
+		rows := make([]Row, len(tc.amounts))
+		for i, amount := range tc.amounts {
+			rows[i] = Row{RowNumber: i + 1, UserID: uint(7 + i), Amount: amount}
+		}
+		report, err := service.Validate(2, rows)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if report.TotalAmount != tc.wantTotal || report.AvailableBalance != 100000 || report.Valid() != tc.wantValid {
+			t.Errorf("%s: total/available/valid = %.2f/%.2f/%v, want %.2f/100000.00/%v",
+				tc.name, report.TotalAmount, report.AvailableBalance, report.Valid(), tc.wantTotal, tc.wantValid)
+		}
+	}
+	if repo.wallets[2].Balance != 100000 {
+		t.Errorf("dry-run must not move the source balance; got %.2f", repo.wallets[2].Balance)
+	}
+}
+
+func TestExecuteApprovedPaysRowsWithinCaps(t *testing.T) {
+	// Baris kedua melewati batas KYC penerima, baris ketiga melebihi sisa saldo sumber.
+	repo := newFakeDisbursementRepository(BatchAwaitingApproval, 40000, 20000, 70000)
+	service := &disbursementService{repo: repo, creditGuard: walletCap{repo: repo, max: 100000}, workers: 1, running: map[uint]bool{1: true}}
+
+	batch, err := service.ExecuteApproved(4, "DSB-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if batch.Status != BatchProcessing || *repo.batches["DSB-1"].ExecutedBy != 4 {
+		t.Fatalf("expected PROCESSING executed by the checker; got %s, %v", batch.Status, repo.batches["DSB-1"].ExecutedBy)
+	}
+	service.run(*batch)
+
+	stored := repo.batches["DSB-1"]
+	if stored.Status != BatchCompletedWithErrors || stored.SucceededRows != 1 || stored.SucceededAmount != 40000 || stored.FailedRows != 2 {
+		t.Fatalf("expected 1 row paid and 2 failed; got %s %d/%.2f/%d", stored.Status, stored.SucceededRows, stored.SucceededAmount, stored.FailedRows)
+	}
+	if repo.wallets[2].Balance != 60000 || repo.wallets[3].Balance != 40000 || repo.wallets[4].Balance != 90000 || repo.wallets[5].Balance != 0 {
+		t.Errorf("unexpected balances source/7/8/9 = %.2f/%.2f/%.2f/%.2f",
+			repo.wallets[2].Balance, repo.wallets[3].Balance, repo.wallets[4].Balance, repo.wallets[5].Balance)
+	}
+	if repo.items[2].Error != "saldo wallet sumber tidak mencukupi" {
+		t.Errorf("expected row 3 to fail on the source balance; got %q", repo.items[2].Error)
+	}
+
+	// Approval yang dijalankan ulang setelah batch selesai tidak membayar lagi.
+	if _, err := service.ExecuteApproved(5, "DSB-1"); err != nil || repo.wallets[2].Balance != 60000 {
+		t.Fatalf("expected re-execution of a completed batch to be a no-op; got %v, source %.2f", err, repo.wallets[2].Balance)
+	}
+}
+
+func TestExecuteUsesNewApprovalReferencePerAttempt(t *testing.T) {
+	approvalService := &pendingApprovals{}
+	repo := newFakeDisbursementRepository(BatchValidated, 25000)
+	service := &disbursementService{repo: repo, approvals: approvalService}
+
+	batch, err := service.Execute(3, "DSB-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if batch.Status != BatchAwaitingApproval {
+		t.Fatalf("expected AWAITING_APPROVAL; got %s", batch.Status)
+	}
+	if _, err := service.Execute(3, "DSB-1"); !errors.Is(err, ErrBatchNotPending) {
+		t.Fatalf("expected a batch awaiting approval not to be submitted twice; got %v", err)
+	}
+
+	// Approval pertama ditolak, batch diajukan ulang.
+	if _, err := service.Reopen("DSB-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.Execute(3, "DSB-1"); err != nil {
+		t.Fatalf("expected a reopened batch to be resubmitted; got %v", err)
+	}
+
+	want := []string{"DSB-1-1", "DSB-1-2"}
+	if len(approvalService.submitted) != len(want) {
+		t.Fatalf("expected %d submissions; got %d", len(want), len(approvalService.submitted))
+	}
+	for i, submitted := range approvalService.submitted {
+		reference, _ := submitted.Payload.String("reference")
+		amount, _ := submitted.Payload.Float("amount")
+		if reference != want[i] || amount != 25000 {
+			t.Errorf("submission %d = %s/%.2f, want %s/25000.00", i, reference, amount, want[i])
+		}
+	}
+	if repo.batches["DSB-1"].Status != BatchAwaitingApproval || repo.wallets[2].Balance != 100000 {
+		t.Errorf("expected batch awaiting approval with the source untouched; got %s, %.2f", repo.batches["DSB-1"].Status, repo.wallets[2].Balance)
+	}
+}
+
+func TestExecuteRevertsBatchWhenSubmitFails(t *testing.T) {
+	repo := newFakeDisbursementRepository(BatchValidated, 25000)
+	service := &disbursementService{repo: repo, approvals: &pendingApprovals{err: errors.New("jenis operasi tidak didukung")}}
+
+	if _, err := service.Execute(3, "DSB-1"); err == nil {
+		t.Fatalf("expected submit error")
+	}
+	if repo.batches["DSB-1"].Status != BatchValidated {
+		t.Fatalf("expected batch back to VALIDATED; got %s", repo.batches["DSB-1"].Status)
+	}
+}
@@ -0,0 +1,30 @@
+package disputes
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// StartEscalator secara berkala memindahkan dispute yang tenggat tanggapan
+// merchant-nya lewat ke review operator sampai ctx dibatalkan.
+func StartEscalator(ctx context.Context, service DisputeService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			escalated, err := service.EscalateOverdue(time.Now())
+			if err != nil {
+				log.Printf("ERROR: Gagal mengeskalasi dispute: %v", err)
+				continue
+			}
+			if escalated > 0 {
+				log.Printf("SUCCESS: %d dispute dieskalasi ke review operator", escalated)
+			}
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package disputes
+
+import (
+	"errors"
+	"ewallet-engine/internal/audit"
+	"ewallet-engine/internal/kyc"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type DisputeHandler struct {
+	service      DisputeService
+	auditService audit.AuditService
+}
+
+func NewDisputeHandler(service DisputeService, auditService audit.AuditService) *DisputeHandler {
+	return &DisputeHandler{service: service, auditService: auditService}
+}
+
+// scopeOf menentukan pihak pemanggil route customer/merchant: API key
+// merchant mengisi Locals merchant_id, selain itu pemanggil adalah customer.
+// Route admin memakai operatorScope.
+func scopeOf(c *fiber.Ctx) Scope {
+	if merchantID, ok := c.Locals("merchant_id").(uint); ok {
+		return Scope{Party: PartyMerchant, ID: merchantID}
+	}
+	userID, _ := c.Locals("user_id").(uint)
+	return Scope{Party: PartyCustomer, ID: userID}
+}
+
+func operatorScope(c *fiber.Ctx) Scope {
+	userID, _ := c.Locals("user_id").(uint)
+	return Scope{Party: PartyOperator, ID: userID}
+}
+
+func (h *DisputeHandler) OpenHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	var request OpenRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	dispute, err := h.service.Open(userID, request)
+	if err != nil {
+		return h.disputeError(c, err)
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionDisputeOpened,
+		TargetType: "dispute",
+		TargetID:   fmt.Sprint(dispute.ID),
+		After:      disputeSnapshot(dispute),
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Dispute berhasil diajukan",
+		"data":    dispute,
+	})
+}
+
+func (h *DisputeHandler) ListHandler(c *fiber.Ctx) error {
+	return h.list(c, scopeOf(c))
+}
+
+func (h *DisputeHandler) AdminListHandler(c *fiber.Ctx) error {
+	return h.list(c, operatorScope(c))
+}
+
+func (h *DisputeHandler) list(c *fiber.Ctx, scope Scope) error {
+	disputes, err := h.service.List(scope, Status(c.Query("status")))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": disputes})
+}
+
+func (h *DisputeHandler) GetHandler(c *fiber.Ctx) error {
+	return h.get(c, scopeOf(c))
+}
+
+func (h *DisputeHandler) AdminGetHandler(c *fiber.Ctx) error {
+	return h.get(c, operatorScope(c))
+}
+
+func (h *DisputeHandler) get(c *fiber.Ctx, scope Scope) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	detail, err := h.service.Get(scope, uint(id))
+	if err != nil {
+		return h.disputeError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": detail})
+}
+
+// AddEvidenceHandler menerima multipart dengan field note dan file opsional.
+func (h *DisputeHandler) AddEvidenceHandler(c *fiber.Ctx) error {
+	return h.addEvidence(c, scopeOf(c))
+}
+
+func (h *DisputeHandler) AdminAddEvidenceHandler(c *fiber.Ctx) error {
+	return h.addEvidence(c, operatorScope(c))
+}
+
+func (h *DisputeHandler) addEvidence(c *fiber.Ctx, scope Scope) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	var document *kyc.Document
+	if header, err := c.FormFile("file"); err == nil {
+		file, err := header.Open()
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Gagal membaca berkas bukti"})
+		}
+		defer file.Close()
+		document = &kyc.Document{Filename: header.Filename, Size: header.Size, Content: file}
+	}
+
+	evidence, err := h.service.AddEvidence(scope, uint(id), c.FormValue("note"), document)
+	if err != nil {
+		return h.disputeError(c, err)
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionDisputeEvidenceAdded,
+		TargetType: "dispute",
+		TargetID:   fmt.Sprint(id),
+		After:      audit.Snapshot{"evidence_id": evidence.ID, "party": scope.Party, "file_name": evidence.FileName},
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Bukti berhasil ditambahkan",
+		"data":    evidence,
+	})
+}
+
+func (h *DisputeHandler) EvidenceFileHandler(c *fiber.Ctx) error {
+	return h.evidenceFile(c, scopeOf(c))
+}
+
+func (h *DisputeHandler) AdminEvidenceFileHandler(c *fiber.Ctx) error {
+	return h.evidenceFile(c, operatorScope(c))
+}
+
+func (h *DisputeHandler) evidenceFile(c *fiber.Ctx, scope Scope) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+	evidenceID, err := c.ParamsInt("evidence_id")
+	if err != nil || evidenceID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	evidence, file, err := h.service.OpenEvidence(scope, uint(id), uint(evidenceID))
+	if err != nil {
+		return h.disputeError(c, err)
+	}
+
+	c.Set(fiber.HeaderContentType, evidence.ContentType)
+	return c.SendStream(file)
+}
+
+func (h *DisputeHandler) RespondHandler(c *fiber.Ctx) error {
+	merchantID := c.Locals("merchant_id").(uint)
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	var request RespondRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	dispute, err := h.service.Respond(merchantID, uint(id), request)
+	if err != nil {
+		return h.disputeError(c, err)
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionDisputeResponded,
+		TargetType: "dispute",
+		TargetID:   fmt.Sprint(dispute.ID),
+		Before:     audit.Snapshot{"status": StatusOpen},
+		After:      disputeSnapshot(dispute),
+	})
+
+	message := "Dispute diteruskan ke review operator"
+	if request.Accept {
+		message = "Dispute diterima, dana dikembalikan ke customer"
+	}
+	return c.JSON(fiber.Map{"message": message, "data": dispute})
+}
+
+func (h *DisputeHandler) WithdrawHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	var request struct {
+		Note string `json:"note"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+	}
+
+	dispute, err := h.service.Withdraw(userID, uint(id), request.Note)
+	if err != nil {
+		return h.disputeError(c, err)
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionDisputeWithdrawn,
+		TargetType: "dispute",
+		TargetID:   fmt.Sprint(dispute.ID),
+		After:      disputeSnapshot(dispute),
+	})
+
+	return c.JSON(fiber.Map{"message": "Dispute ditarik", "data": dispute})
+}
+
+func (h *DisputeHandler) DecideHandler(c *fiber.Ctx) error {
+	operatorID := c.Locals("user_id").(uint)
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	var request DecideRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	dispute, err := h.service.Decide(operatorID, uint(id), request)
+	if err != nil {
+		return h.disputeError(c, err)
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionDisputeDecided,
+		TargetType: "dispute",
+		TargetID:   fmt.Sprint(dispute.ID),
+		After:      disputeSnapshot(dispute),
+	})
+
+	return c.JSON(fiber.Map{"message": "Dispute diputuskan", "data": dispute})
+}
+
+func (h *DisputeHandler) disputeError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, ErrDisputeNotFound), errors.Is(err, ErrEvidenceMissing):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, ErrDisputeExists), errors.Is(err, ErrDisputeClosed), errors.Is(err, ErrDisputeState), errors.Is(err, ErrDeadlinePassed):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"message": err.Error()})
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+}
+
+func disputeSnapshot(dispute *Dispute) audit.Snapshot {
+	return audit.Snapshot{
+		"transaction_reference": dispute.TransactionReference,
+		"merchant_id":           dispute.MerchantID,
+		"amount":                dispute.Amount,
+		"held_amount":           dispute.HeldAmount,
+		"status":                dispute.Status,
+		"refund_reference":      dispute.RefundReference,
+	}
+}
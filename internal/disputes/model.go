@@ -0,0 +1,186 @@
+package disputes
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+type Status string
+
+const (
+	// StatusOpen: menunggu tanggapan merchant sampai MerchantDeadline.
+	StatusOpen Status = "OPEN"
+	// StatusUnderReview: merchant menolak atau tenggat lewat; menunggu keputusan operator.
+	StatusUnderReview Status = "UNDER_REVIEW"
+	// StatusCustomerWon: dana dikembalikan ke customer dari wallet merchant.
+	StatusCustomerWon Status = "CUSTOMER_WON"
+	// StatusMerchantWon: hold dilepas dan dana tetap milik merchant.
+	StatusMerchantWon Status = "MERCHANT_WON"
+	StatusWithdrawn   Status = "WITHDRAWN"
+)
+
+var activeStatuses = []Status{StatusOpen, StatusUnderReview}
+
+// IsFinal melaporkan apakah dispute sudah diputuskan atau ditarik.
+func (s Status) IsFinal() bool {
+	return s != StatusOpen && s != StatusUnderReview
+}
+
+type Reason string
+
+const (
+	ReasonNotReceived    Reason = "NOT_RECEIVED"
+	ReasonNotAsDescribed Reason = "NOT_AS_DESCRIBED"
+	ReasonDuplicate      Reason = "DUPLICATE"
+	ReasonUnauthorized   Reason = "UNAUTHORIZED"
+	ReasonOther          Reason = "OTHER"
+)
+
+var knownReasons = map[Reason]bool{
+	ReasonNotReceived:    true,
+	ReasonNotAsDescribed: true,
+	ReasonDuplicate:      true,
+	ReasonUnauthorized:   true,
+	ReasonOther:          true,
+}
+
+// Party adalah pihak yang melakukan aksi pada dispute.
+type Party string
+
+const (
+	PartyCustomer Party = "CUSTOMER"
+	PartyMerchant Party = "MERCHANT"
+	PartyOperator Party = "OPERATOR"
+	PartySystem   Party = "SYSTEM"
+)
+
+// Dispute adalah keberatan customer atas PURCHASE ke merchant. Selama dispute
+// aktif, HeldAmount ditahan dari wallet settlement merchant dengan
+// HoldReference; HeldAmount bisa lebih kecil dari Amount bila saldo merchant
+// tidak mencukupi saat dispute dibuka.
+type Dispute struct {
+	ID                   uint       `gorm:"primaryKey" json:"id"`
+	TransactionReference string     `gorm:"type:varchar(255);not null;index" json:"transaction_reference"`
+	CustomerUserID       uint       `gorm:"not null;index" json:"customer_user_id"`
+	MerchantID           uint       `gorm:"not null;index" json:"merchant_id"`
+	MerchantUserID       uint       `gorm:"not null" json:"-"`
+	Amount               float64    `gorm:"not null" json:"amount"`
+	Currency             string     `gorm:"type:char(3);not null;default:'IDR'" json:"currency"`
+	Reason               Reason     `gorm:"type:varchar(30);not null" json:"reason"`
+	Description          string     `gorm:"type:varchar(1000)" json:"description,omitempty"`
+	Status               Status     `gorm:"type:enum('OPEN','UNDER_REVIEW','CUSTOMER_WON','MERCHANT_WON','WITHDRAWN');default:'OPEN';index" json:"status"`
+	HeldAmount           float64    `gorm:"not null;default:0" json:"held_amount"`
+	HoldReference        string     `gorm:"type:varchar(100)" json:"hold_reference,omitempty"`
+	MerchantDeadline     time.Time  `gorm:"not null;index" json:"merchant_deadline"`
+	MerchantRespondedAt  *time.Time `json:"merchant_responded_at,omitempty"`
+	DecidedBy            *uint      `json:"decided_by,omitempty"`
+	DecidedAt            *time.Time `json:"decided_at,omitempty"`
+	DecisionNote         string     `gorm:"type:varchar(500)" json:"decision_note,omitempty"`
+	RefundReference      string     `gorm:"type:varchar(255)" json:"refund_reference,omitempty"`
+	CreatedAt            time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt            time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// DisputeEvidence adalah catatan dan/atau berkas pendukung dari salah satu pihak.
+type DisputeEvidence struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	DisputeID   uint      `gorm:"not null;index" json:"dispute_id"`
+	Party       Party     `gorm:"type:varchar(20);not null" json:"party"`
+	SubmittedBy uint      `gorm:"not null" json:"submitted_by"`
+	Note        string    `gorm:"type:varchar(1000)" json:"note,omitempty"`
+	FileKey     string    `gorm:"type:varchar(255)" json:"-"`
+	FileName    string    `gorm:"type:varchar(255)" json:"file_name,omitempty"`
+	ContentType string    `gorm:"type:varchar(100)" json:"content_type,omitempty"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// EventData adalah detail tambahan satu kejadian di timeline.
+type EventData map[string]interface{}
+
+func (d EventData) Value() (driver.Value, error) {
+	return json.Marshal(d)
+}
+
+func (d *EventData) Scan(value interface{}) error {
+	if value == nil {
+		*d = make(EventData)
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("failed to unmarshal JSON")
+	}
+	return json.Unmarshal(bytes, d)
+}
+
+// DisputeEvent adalah satu baris timeline dispute. Timeline hanya ditambah,
+// tidak pernah diubah.
+type DisputeEvent struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	DisputeID uint      `gorm:"not null;index" json:"dispute_id"`
+	EventType string    `gorm:"type:varchar(40);not null" json:"event_type"`
+	Actor     Party     `gorm:"type:varchar(20);not null" json:"actor"`
+	ActorID   uint      `gorm:"not null;default:0" json:"actor_id,omitempty"`
+	Note      string    `gorm:"type:varchar(1000)" json:"note,omitempty"`
+	Data      EventData `gorm:"type:json" json:"data,omitempty"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+const (
+	EventOpened            = "OPENED"
+	EventHoldPlaced        = "HOLD_PLACED"
+	EventHoldShortfall     = "HOLD_SHORTFALL"
+	EventEvidenceAdded     = "EVIDENCE_ADDED"
+	EventMerchantAccepted  = "MERCHANT_ACCEPTED"
+	EventMerchantContested = "MERCHANT_CONTESTED"
+	EventDeadlinePassed    = "DEADLINE_PASSED"
+	EventDecided           = "DECIDED"
+	EventRefunded          = "REFUNDED"
+	EventHoldReleased      = "HOLD_RELEASED"
+	EventWithdrawn         = "WITHDRAWN"
+)
+
+// Detail adalah dispute beserta bukti dan timeline-nya.
+type Detail struct {
+	Dispute
+	Evidence []DisputeEvidence `json:"evidence"`
+	Timeline []DisputeEvent    `json:"timeline"`
+}
+
+// Scope menentukan dispute yang boleh diakses pemanggil: customer dan
+// merchant hanya melihat dispute miliknya, operator melihat semuanya.
+type Scope struct {
+	Party Party
+	ID    uint
+}
+
+func (s Scope) allows(dispute *Dispute) bool {
+	switch s.Party {
+	case PartyCustomer:
+		return dispute.CustomerUserID == s.ID
+	case PartyMerchant:
+		return dispute.MerchantID == s.ID
+	case PartyOperator:
+		return true
+	}
+	return false
+}
+
+type OpenRequest struct {
+	TransactionReference string  `json:"transaction_reference"`
+	Reason               Reason  `json:"reason"`
+	Description          string  `json:"description"`
+	Amount               float64 `json:"amount"`
+}
+
+type RespondRequest struct {
+	Accept bool   `json:"accept"`
+	Note   string `json:"note"`
+}
+
+type DecideRequest struct {
+	Outcome Party  `json:"outcome"`
+	Note    string `json:"note"`
+}
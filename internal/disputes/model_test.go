@@ -0,0 +1,62 @@
+package disputes
+
+import "testing"
+
+func TestDisputeAmount(t *testing.T) {
+	cases := []struct {
+		requested float64
+		remaining float64
+		want      float64
+		wantErr   bool
+	}{
+		// 0 berarti seluruh sisa yang belum di-refund.
+		{0, 150000, 150000, false},
+		{50000, 150000, 50000, false},
+		{150000, 150000, 150000, false},
+		{150001, 150000, 0, true},
+		{-1, 150000, 0, true},
+		{0, 0, 0, true},
+	}
+	for _, tc := range cases {
+		got, err := disputeAmount(tc.requested, tc.remaining, "IDR")
+		if (err != nil) != tc.wantErr {
+			t.Fatalf("disputeAmount(%v, %v) error = %v, wantErr %v", tc.requested, tc.remaining, err, tc.wantErr)
+		}
+		if got != tc.want {
+			t.Errorf("disputeAmount(%v, %v) = %v, want %v", tc.requested, tc.remaining, got, tc.want)
+		}
+	}
+}
+
+func TestScopeAllows(t *testing.T) {
+	dispute := &Dispute{CustomerUserID: 7, MerchantID: 3}
+	cases := []struct {
+		scope Scope
+		want  bool
+	}{
+		{Scope{Party: PartyCustomer, ID: 7}, true},
+		{Scope{Party: PartyCustomer, ID: 3}, false},
+		{Scope{Party: PartyMerchant, ID: 3}, true},
+		{Scope{Party: PartyMerchant, ID: 7}, false},
+		{Scope{Party: PartyOperator}, true},
+		{Scope{Party: PartySystem}, false},
+	}
+	for _, tc := range cases {
+		if got := tc.scope.allows(dispute); got != tc.want {
+			t.Errorf("%+v.allows() = %v, want %v", tc.scope, got, tc.want)
+		}
+	}
+}
+
+func TestStatusIsFinal(t *testing.T) {
+	for _, status := range []Status{StatusOpen, StatusUnderReview} {
+		if status.IsFinal() {
+			t.Errorf("%s.IsFinal() = true, want false", status)
+		}
+	}
+	for _, status := range []Status{StatusCustomerWon, StatusMerchantWon, StatusWithdrawn} {
+		if !status.IsFinal() {
+			t.Errorf("%s.IsFinal() = false, want true", status)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package disputes
+
+import (
+	"errors"
+	"ewallet-engine/internal/balance"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type DisputeRepository interface {
+	CreateDispute(dispute *Dispute) error
+	FindDispute(id uint) (*Dispute, error)
+	FindActiveByTransaction(reference string) (*Dispute, error)
+	ListDisputes(customerUserID uint, merchantID uint, status Status, limit int) ([]Dispute, error)
+	FindOverdue(now time.Time, limit int) ([]Dispute, error)
+	TransitionStatus(id uint, from []Status, updates map[string]interface{}) (bool, error)
+	UpdateDispute(id uint, updates map[string]interface{}) error
+
+	AddEvidence(evidence *DisputeEvidence) error
+	FindEvidence(disputeID uint, id uint) (*DisputeEvidence, error)
+	ListEvidence(disputeID uint) ([]DisputeEvidence, error)
+	AddEvent(event *DisputeEvent) error
+	ListEvents(disputeID uint) ([]DisputeEvent, error)
+
+	// HoldAvailable menahan amount dari wallet userID, atau sebesar saldo
+	// tersedia bila lebih kecil, dan mengembalikan jumlah yang benar-benar ditahan.
+	HoldAvailable(userID uint, currency string, amount float64, reference string, expiresAt time.Time) (float64, error)
+	ReleaseHold(userID uint, reference string) error
+}
+
+type disputeRepository struct {
+	DB *gorm.DB
+}
+
+func NewDisputeRepository(db *gorm.DB) DisputeRepository {
+	return &disputeRepository{DB: db}
+}
+
+func (r *disputeRepository) CreateDispute(dispute *Dispute) error {
+	return r.DB.Create(dispute).Error
+}
+
+func (r *disputeRepository) FindDispute(id uint) (*Dispute, error) {
+	var dispute Dispute
+	if err := r.DB.First(&dispute, id).Error; err != nil {
+		return nil, err
+	}
+	return &dispute, nil
+}
+
+func (r *disputeRepository) FindActiveByTransaction(reference string) (*Dispute, error) {
+	var dispute Dispute
+	err := r.DB.Where("transaction_reference = ? AND status IN ?", reference, activeStatuses).First(&dispute).Error
+	if err != nil {
+		return nil, err
+	}
+	return &dispute, nil
+}
+
+func (r *disputeRepository) ListDisputes(customerUserID uint, merchantID uint, status Status, limit int) ([]Dispute, error) {
+	var disputes []Dispute
+	query := r.DB.Model(&Dispute{})
+	if customerUserID != 0 {
+		query = query.Where("customer_user_id = ?", customerUserID)
+	}
+	if merchantID != 0 {
+		query = query.Where("merchant_id = ?", merchantID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("id DESC").Limit(limit).Find(&disputes).Error
+	return disputes, err
+}
+
+func (r *disputeRepository) FindOverdue(now time.Time, limit int) ([]Dispute, error) {
+	var disputes []Dispute
+	err := r.DB.Where("status = ? AND merchant_deadline < ?", StatusOpen, now).
+		Order("merchant_deadline ASC").Limit(limit).Find(&disputes).Error
+	return disputes, err
+}
+
+func (r *disputeRepository) TransitionStatus(id uint, from []Status, updates map[string]interface{}) (bool, error) {
+	result := r.DB.Model(&Dispute{}).Where("id = ? AND status IN ?", id, from).Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *disputeRepository) UpdateDispute(id uint, updates map[string]interface{}) error {
+	return r.DB.Model(&Dispute{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *disputeRepository) AddEvidence(evidence *DisputeEvidence) error {
+	return r.DB.Create(evidence).Error
+}
+
+func (r *disputeRepository) FindEvidence(disputeID uint, id uint) (*DisputeEvidence, error) {
+	var evidence DisputeEvidence
+	if err := r.DB.Where("id = ? AND dispute_id = ?", id, disputeID).First(&evidence).Error; err != nil {
+		return nil, err
+	}
+	return &evidence, nil
+}
+
+func (r *disputeRepository) ListEvidence(disputeID uint) ([]DisputeEvidence, error) {
+	var evidence []DisputeEvidence
+	err := r.DB.Where("dispute_id = ?", disputeID).Order("id ASC").Find(&evidence).Error
+	return evidence, err
+}
+
+func (r *disputeRepository) AddEvent(event *DisputeEvent) error {
+	return r.DB.Create(event).Error
+}
+
+func (r *disputeRepository) ListEvents(disputeID uint) ([]DisputeEvent, error) {
+	var events []DisputeEvent
+	err := r.DB.Where("dispute_id = ?", disputeID).Order("id ASC").Find(&events).Error
+	return events, err
+}
+
+func (r *disputeRepository) HoldAvailable(userID uint, currency string, amount float64, reference string, expiresAt time.Time) (float64, error) {
+	wallet, err := balance.FindUserWallet(r.DB, userID, currency, false)
+	if err != nil {
+		return 0, err
+	}
+
+	held := amount
+	if available := balance.RoundAmount(wallet.Available(), currency); available < held {
+		held = available
+	}
+	if held <= 0 {
+		return 0, nil
+	}
+
+	if _, err := balance.PlaceHold(r.DB, wallet.ID, held, reference, expiresAt); err != nil {
+		if errors.Is(err, balance.ErrInsufficientBalance) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return held, nil
+}
+
+func (r *disputeRepository) ReleaseHold(userID uint, reference string) error {
+	return balance.ReleaseHold(r.DB, userID, reference, balance.HoldReleased)
+}
@@ -0,0 +1,544 @@
+package disputes
+
+import (
+	"bufio"
+	"errors"
+	"ewallet-engine/internal/balance"
+	"ewallet-engine/internal/kyc"
+	"ewallet-engine/internal/merchants"
+	"ewallet-engine/internal/notifications"
+	"ewallet-engine/internal/transactions"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	maxEvidenceSize       = 5 * 1024 * 1024
+	maxEvidencePerDispute = 20
+	// disputeHoldTTL cukup panjang supaya hold tidak kedaluwarsa sebelum keputusan.
+	disputeHoldTTL = 180 * 24 * time.Hour
+)
+
+const (
+	NotificationOpened  = "DISPUTE_OPENED"
+	NotificationDecided = "DISPUTE_DECIDED"
+)
+
+var (
+	ErrDisputeNotFound = errors.New("dispute tidak ditemukan")
+	ErrDisputeExists   = errors.New("transaksi ini sudah memiliki dispute yang aktif")
+	ErrDisputeClosed   = errors.New("dispute sudah ditutup")
+	ErrDisputeState    = errors.New("status dispute tidak mengizinkan aksi ini")
+	ErrDeadlinePassed  = errors.New("tenggat tanggapan merchant sudah lewat")
+	ErrEvidenceMissing = errors.New("bukti tidak ditemukan")
+)
+
+var allowedEvidenceTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"application/pdf": ".pdf",
+}
+
+type DisputeService interface {
+	Open(customerID uint, request OpenRequest) (*Dispute, error)
+	List(scope Scope, status Status) ([]Dispute, error)
+	Get(scope Scope, id uint) (*Detail, error)
+	AddEvidence(scope Scope, id uint, note string, document *kyc.Document) (*DisputeEvidence, error)
+	OpenEvidence(scope Scope, id uint, evidenceID uint) (*DisputeEvidence, io.ReadCloser, error)
+	Respond(merchantID uint, id uint, request RespondRequest) (*Dispute, error)
+	Withdraw(customerID uint, id uint, note string) (*Dispute, error)
+	Decide(operatorID uint, id uint, request DecideRequest) (*Dispute, error)
+	EscalateOverdue(now time.Time) (int, error)
+}
+
+type disputeService struct {
+	repo         DisputeRepository
+	transactions transactions.TransactionService
+	merchants    merchants.MerchantService
+	store        kyc.BlobStore
+	notifier     notifications.Notifier
+	window       time.Duration
+	responseTime time.Duration
+}
+
+func NewDisputeService(repo DisputeRepository, transactionService transactions.TransactionService, merchantService merchants.MerchantService, store kyc.BlobStore, notifier notifications.Notifier) DisputeService {
+	s := &disputeService{
+		repo:         repo,
+		transactions: transactionService,
+		merchants:    merchantService,
+		store:        store,
+		notifier:     notifier,
+		window:       120 * 24 * time.Hour,
+		responseTime: 7 * 24 * time.Hour,
+	}
+	if days, err := strconv.Atoi(os.Getenv("DISPUTE_WINDOW_DAYS")); err == nil && days > 0 {
+		s.window = time.Duration(days) * 24 * time.Hour
+	}
+	if days, err := strconv.Atoi(os.Getenv("DISPUTE_RESPONSE_DAYS")); err == nil && days > 0 {
+		s.responseTime = time.Duration(days) * 24 * time.Hour
+	}
+	return s
+}
+
+// Open membuat dispute atas PURCHASE ke merchant milik customer dan menahan
+// dana sengketa dari wallet settlement merchant sampai ada keputusan.
+func (s *disputeService) Open(customerID uint, request OpenRequest) (*Dispute, error) {
+	if !knownReasons[request.Reason] {
+		return nil, errors.New("reason harus salah satu dari NOT_RECEIVED, NOT_AS_DESCRIBED, DUPLICATE, UNAUTHORIZED, OTHER")
+	}
+	description := strings.TrimSpace(request.Description)
+	if len(description) > 1000 {
+		return nil, errors.New("deskripsi maksimal 1000 karakter")
+	}
+
+	transaction, err := s.transactions.GetTransactionByReference(strings.TrimSpace(request.TransactionReference))
+	if err != nil || transaction.UserID != customerID {
+		return nil, errors.New("transaksi tidak ditemukan")
+	}
+	if transaction.TransactionType != transactions.TransactionPurchase || transaction.CounterpartyUserID == 0 {
+		return nil, errors.New("dispute hanya dapat diajukan untuk pembayaran ke merchant")
+	}
+	if transaction.TransactionStatus != transactions.StatusSuccess && transaction.TransactionStatus != transactions.StatusPartiallyRefunded {
+		return nil, errors.New("transaksi belum berhasil atau sudah dikembalikan seluruhnya")
+	}
+	if time.Since(transaction.CreatedAt) > s.window {
+		return nil, fmt.Errorf("dispute hanya dapat diajukan dalam %d hari setelah transaksi", int(s.window.Hours()/24))
+	}
+
+	amount, err := disputeAmount(request.Amount, transaction.Charged()-transaction.RefundedAmount, transaction.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.FindActiveByTransaction(transaction.Reference); err == nil {
+		return nil, ErrDisputeExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	merchant, err := s.merchants.GetMerchantBySettlementUser(transaction.CounterpartyUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	dispute := &Dispute{
+		TransactionReference: transaction.Reference,
+		CustomerUserID:       customerID,
+		MerchantID:           merchant.ID,
+		MerchantUserID:       merchant.SettlementUserID,
+		Amount:               amount,
+		Currency:             transaction.Currency,
+		Reason:               request.Reason,
+		Description:          description,
+		Status:               StatusOpen,
+		MerchantDeadline:     time.Now().Add(s.responseTime),
+	}
+	if err := s.repo.CreateDispute(dispute); err != nil {
+		return nil, err
+	}
+	s.record(dispute.ID, EventOpened, PartyCustomer, customerID, description, EventData{"reason": dispute.Reason, "amount": amount})
+
+	dispute.HoldReference = fmt.Sprintf("DSP-%d", dispute.ID)
+	held, err := s.repo.HoldAvailable(dispute.MerchantUserID, dispute.Currency, amount, dispute.HoldReference, time.Now().Add(disputeHoldTTL))
+	if err != nil {
+		log.Printf("ERROR: Gagal menahan dana dispute %d: %v", dispute.ID, err)
+	}
+	dispute.HeldAmount = held
+	if err := s.repo.UpdateDispute(dispute.ID, map[string]interface{}{"held_amount": held, "hold_reference": dispute.HoldReference}); err != nil {
+		log.Printf("ERROR: Gagal menyimpan hold dispute %d: %v", dispute.ID, err)
+	}
+	if held < amount {
+		log.Printf("ALERT: Dispute %d hanya dapat menahan %.2f dari %.2f %s", dispute.ID, held, amount, dispute.Currency)
+		s.record(dispute.ID, EventHoldShortfall, PartySystem, 0, "saldo merchant tidak mencukupi untuk menahan seluruh dana", EventData{"held_amount": held, "amount": amount})
+	} else {
+		s.record(dispute.ID, EventHoldPlaced, PartySystem, 0, "", EventData{"held_amount": held, "hold_reference": dispute.HoldReference})
+	}
+
+	s.notifier.Notify(merchant.OwnerUserID, NotificationOpened, "Dispute baru",
+		fmt.Sprintf("Customer mengajukan dispute atas transaksi %s. Tanggapi sebelum %s.", transaction.Reference, dispute.MerchantDeadline.Format("02 Jan 2006 15:04")),
+		notifications.Data{"dispute_id": dispute.ID, "merchant_id": merchant.ID})
+	return dispute, nil
+}
+
+func (s *disputeService) List(scope Scope, status Status) ([]Dispute, error) {
+	status = Status(strings.ToUpper(string(status)))
+	switch scope.Party {
+	case PartyCustomer:
+		return s.repo.ListDisputes(scope.ID, 0, status, 100)
+	case PartyMerchant:
+		return s.repo.ListDisputes(0, scope.ID, status, 100)
+	default:
+		return s.repo.ListDisputes(0, 0, status, 100)
+	}
+}
+
+func (s *disputeService) Get(scope Scope, id uint) (*Detail, error) {
+	dispute, err := s.find(scope, id)
+	if err != nil {
+		return nil, err
+	}
+	evidence, err := s.repo.ListEvidence(dispute.ID)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.repo.ListEvents(dispute.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &Detail{Dispute: *dispute, Evidence: evidence, Timeline: events}, nil
+}
+
+// AddEvidence menambahkan catatan dan/atau berkas bukti selama dispute aktif.
+func (s *disputeService) AddEvidence(scope Scope, id uint, note string, document *kyc.Document) (*DisputeEvidence, error) {
+	dispute, err := s.find(scope, id)
+	if err != nil {
+		return nil, err
+	}
+	if dispute.Status.IsFinal() {
+		return nil, ErrDisputeClosed
+	}
+	note = strings.TrimSpace(note)
+	if note == "" && document == nil {
+		return nil, errors.New("catatan atau berkas bukti wajib diisi")
+	}
+	if len(note) > 1000 {
+		return nil, errors.New("catatan maksimal 1000 karakter")
+	}
+
+	existing, err := s.repo.ListEvidence(dispute.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= maxEvidencePerDispute {
+		return nil, fmt.Errorf("maksimal %d bukti per dispute", maxEvidencePerDispute)
+	}
+
+	evidence := &DisputeEvidence{
+		DisputeID:   dispute.ID,
+		Party:       scope.Party,
+		SubmittedBy: scope.ID,
+		Note:        note,
+	}
+	if document != nil {
+		key, contentType, err := s.storeEvidence(dispute.ID, *document)
+		if err != nil {
+			return nil, err
+		}
+		evidence.FileKey = key
+		evidence.FileName = document.Filename
+		evidence.ContentType = contentType
+	}
+
+	if err := s.repo.AddEvidence(evidence); err != nil {
+		if evidence.FileKey != "" {
+			_ = s.store.Delete(evidence.FileKey)
+		}
+		return nil, err
+	}
+	s.record(dispute.ID, EventEvidenceAdded, scope.Party, scope.ID, note, EventData{"evidence_id": evidence.ID, "file_name": evidence.FileName})
+	return evidence, nil
+}
+
+func (s *disputeService) OpenEvidence(scope Scope, id uint, evidenceID uint) (*DisputeEvidence, io.ReadCloser, error) {
+	dispute, err := s.find(scope, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	evidence, err := s.repo.FindEvidence(dispute.ID, evidenceID)
+	if err != nil || evidence.FileKey == "" {
+		return nil, nil, ErrEvidenceMissing
+	}
+	file, err := s.store.Get(evidence.FileKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return evidence, file, nil
+}
+
+// Respond adalah tanggapan merchant sebelum tenggat. Merchant yang menerima
+// dispute langsung mengembalikan dana ke customer; yang menolak membawa
+// dispute ke review operator.
+func (s *disputeService) Respond(merchantID uint, id uint, request RespondRequest) (*Dispute, error) {
+	dispute, err := s.find(Scope{Party: PartyMerchant, ID: merchantID}, id)
+	if err != nil {
+		return nil, err
+	}
+	if dispute.Status != StatusOpen {
+		return nil, ErrDisputeState
+	}
+	if time.Now().After(dispute.MerchantDeadline) {
+		return nil, ErrDeadlinePassed
+	}
+	note := strings.TrimSpace(request.Note)
+
+	if request.Accept {
+		s.record(dispute.ID, EventMerchantAccepted, PartyMerchant, merchantID, note, nil)
+		return s.resolveForCustomer(dispute, PartyMerchant, merchantID, note)
+	}
+
+	if note == "" {
+		return nil, errors.New("alasan penolakan wajib diisi")
+	}
+	now := time.Now()
+	ok, err := s.repo.TransitionStatus(dispute.ID, []Status{StatusOpen}, map[string]interface{}{
+		"status":                StatusUnderReview,
+		"merchant_responded_at": &now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrDisputeState
+	}
+	dispute.Status = StatusUnderReview
+	dispute.MerchantRespondedAt = &now
+	s.record(dispute.ID, EventMerchantContested, PartyMerchant, merchantID, note, nil)
+	return dispute, nil
+}
+
+// Withdraw menutup dispute atas permintaan customer dan melepas hold.
+func (s *disputeService) Withdraw(customerID uint, id uint, note string) (*Dispute, error) {
+	dispute, err := s.find(Scope{Party: PartyCustomer, ID: customerID}, id)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := s.repo.TransitionStatus(dispute.ID, activeStatuses, map[string]interface{}{"status": StatusWithdrawn})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrDisputeClosed
+	}
+	dispute.Status = StatusWithdrawn
+	s.record(dispute.ID, EventWithdrawn, PartyCustomer, customerID, strings.TrimSpace(note), nil)
+	s.releaseHold(dispute)
+	return dispute, nil
+}
+
+// Decide adalah keputusan operator atas dispute yang sedang direview atau
+// yang tenggat tanggapan merchant-nya sudah lewat.
+func (s *disputeService) Decide(operatorID uint, id uint, request DecideRequest) (*Dispute, error) {
+	dispute, err := s.find(Scope{Party: PartyOperator}, id)
+	if err != nil {
+		return nil, err
+	}
+	if dispute.Status.IsFinal() {
+		return nil, ErrDisputeClosed
+	}
+	if dispute.Status == StatusOpen && time.Now().Before(dispute.MerchantDeadline) {
+		return nil, errors.New("dispute masih menunggu tanggapan merchant")
+	}
+	note := strings.TrimSpace(request.Note)
+	if note == "" {
+		return nil, errors.New("catatan keputusan wajib diisi")
+	}
+
+	switch request.Outcome {
+	case PartyCustomer:
+		return s.resolveForCustomer(dispute, PartyOperator, operatorID, note)
+	case PartyMerchant:
+		now := time.Now()
+		ok, err := s.repo.TransitionStatus(dispute.ID, activeStatuses, map[string]interface{}{
+			"status":        StatusMerchantWon,
+			"decided_by":    operatorID,
+			"decided_at":    &now,
+			"decision_note": note,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrDisputeClosed
+		}
+		dispute.Status = StatusMerchantWon
+		dispute.DecidedBy = &operatorID
+		dispute.DecidedAt = &now
+		dispute.DecisionNote = note
+		s.record(dispute.ID, EventDecided, PartyOperator, operatorID, note, EventData{"outcome": StatusMerchantWon})
+		s.releaseHold(dispute)
+		s.notifyDecision(dispute)
+		return dispute, nil
+	}
+	return nil, errors.New("outcome harus CUSTOMER atau MERCHANT")
+}
+
+// EscalateOverdue memindahkan dispute OPEN yang tenggatnya lewat ke review
+// operator dan mengembalikan jumlahnya.
+func (s *disputeService) EscalateOverdue(now time.Time) (int, error) {
+	overdue, err := s.repo.FindOverdue(now, 100)
+	if err != nil {
+		return 0, err
+	}
+
+	escalated := 0
+	for _, dispute := range overdue {
+		ok, err := s.repo.TransitionStatus(dispute.ID, []Status{StatusOpen}, map[string]interface{}{"status": StatusUnderReview})
+		if err != nil {
+			log.Printf("ERROR: Gagal mengeskalasi dispute %d: %v", dispute.ID, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		s.record(dispute.ID, EventDeadlinePassed, PartySystem, 0, "merchant tidak menanggapi sebelum tenggat", nil)
+		escalated++
+	}
+	return escalated, nil
+}
+
+// resolveForCustomer melepas hold lalu me-refund dana sengketa dari wallet
+// merchant ke customer. Reference refund tetap per dispute sehingga
+// pemanggilan ulang setelah kegagalan tidak me-refund dua kali. Refund
+// dibatasi sisa yang belum dikembalikan, misalnya bila merchant sudah
+// me-refund sebagian di luar dispute.
+func (s *disputeService) resolveForCustomer(dispute *Dispute, actor Party, actorID uint, note string) (*Dispute, error) {
+	s.releaseHold(dispute)
+
+	refundReference := fmt.Sprintf("DSP-%d-CB", dispute.ID)
+	transaction, err := s.transactions.GetTransactionByReference(dispute.TransactionReference)
+	if err != nil {
+		return nil, err
+	}
+	amount := dispute.Amount
+	if remaining := balance.RoundAmount(transaction.Charged()-transaction.RefundedAmount, dispute.Currency); remaining < amount {
+		amount = remaining
+	}
+
+	if existing, err := s.transactions.GetTransactionByReference(refundReference); err == nil {
+		amount = existing.Amount
+	}
+	if amount > 0 {
+		refund, err := s.transactions.RefundMerchantPayment(dispute.MerchantUserID, dispute.TransactionReference, amount, refundReference,
+			fmt.Sprintf("Pengembalian dana dispute #%d", dispute.ID))
+		if err != nil {
+			log.Printf("ALERT: Refund dispute %d gagal: %v", dispute.ID, err)
+			return nil, err
+		}
+		s.record(dispute.ID, EventRefunded, PartySystem, 0, "", EventData{"refund_reference": refund.Reference, "amount": refund.Amount})
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":           StatusCustomerWon,
+		"decided_at":       &now,
+		"decision_note":    note,
+		"refund_reference": refundReference,
+	}
+	if actor == PartyOperator {
+		updates["decided_by"] = actorID
+		dispute.DecidedBy = &actorID
+	}
+	ok, err := s.repo.TransitionStatus(dispute.ID, activeStatuses, updates)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrDisputeClosed
+	}
+	dispute.Status = StatusCustomerWon
+	dispute.DecidedAt = &now
+	dispute.DecisionNote = note
+	dispute.RefundReference = refundReference
+	s.record(dispute.ID, EventDecided, actor, actorID, note, EventData{"outcome": StatusCustomerWon, "refunded_amount": amount})
+	s.notifyDecision(dispute)
+	return dispute, nil
+}
+
+func (s *disputeService) releaseHold(dispute *Dispute) {
+	if dispute.HeldAmount <= 0 || dispute.HoldReference == "" {
+		return
+	}
+	if err := s.repo.ReleaseHold(dispute.MerchantUserID, dispute.HoldReference); err != nil {
+		if !errors.Is(err, balance.ErrHoldNotFound) && !errors.Is(err, balance.ErrHoldNotActive) {
+			log.Printf("ALERT: Hold dispute %s gagal dilepas: %v", dispute.HoldReference, err)
+		}
+		return
+	}
+	s.record(dispute.ID, EventHoldReleased, PartySystem, 0, "", EventData{"held_amount": dispute.HeldAmount})
+}
+
+func (s *disputeService) notifyDecision(dispute *Dispute) {
+	title := "Dispute diputuskan"
+	body := fmt.Sprintf("Dispute #%d atas transaksi %s dimenangkan merchant.", dispute.ID, dispute.TransactionReference)
+	if dispute.Status == StatusCustomerWon {
+		body = fmt.Sprintf("Dispute #%d atas transaksi %s disetujui, dana dikembalikan ke saldo Anda.", dispute.ID, dispute.TransactionReference)
+	}
+	data := notifications.Data{"dispute_id": dispute.ID, "status": dispute.Status}
+	s.notifier.Notify(dispute.CustomerUserID, NotificationDecided, title, body, data)
+
+	if merchant, err := s.merchants.GetMerchant(dispute.MerchantID); err == nil {
+		s.notifier.Notify(merchant.OwnerUserID, NotificationDecided, title, body, data)
+	}
+}
+
+func (s *disputeService) find(scope Scope, id uint) (*Dispute, error) {
+	dispute, err := s.repo.FindDispute(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDisputeNotFound
+		}
+		return nil, err
+	}
+	if !scope.allows(dispute) {
+		return nil, ErrDisputeNotFound
+	}
+	return dispute, nil
+}
+
+func (s *disputeService) record(disputeID uint, eventType string, actor Party, actorID uint, note string, data EventData) {
+	event := &DisputeEvent{DisputeID: disputeID, EventType: eventType, Actor: actor, ActorID: actorID, Note: note, Data: data}
+	if err := s.repo.AddEvent(event); err != nil {
+		log.Printf("ERROR: Gagal mencatat timeline dispute %d (%s): %v", disputeID, eventType, err)
+	}
+}
+
+func (s *disputeService) storeEvidence(disputeID uint, document kyc.Document) (string, string, error) {
+	if document.Content == nil || document.Size == 0 {
+		return "", "", errors.New("berkas bukti kosong")
+	}
+	if document.Size > maxEvidenceSize {
+		return "", "", errors.New("ukuran berkas bukti maksimal 5MB")
+	}
+
+	reader := bufio.NewReader(document.Content)
+	head, _ := reader.Peek(512)
+	contentType := http.DetectContentType(head)
+	extension, ok := allowedEvidenceTypes[contentType]
+	if !ok {
+		return "", "", errors.New("berkas bukti harus berupa JPG, PNG atau PDF")
+	}
+
+	key := fmt.Sprintf("%d/%d%s", disputeID, time.Now().UnixNano(), extension)
+	if err := s.store.Put(key, io.LimitReader(reader, maxEvidenceSize)); err != nil {
+		return "", "", fmt.Errorf("gagal menyimpan berkas bukti: %w", err)
+	}
+	return key, contentType, nil
+}
+
+// disputeAmount mengembalikan jumlah yang disengketakan: seluruh sisa yang
+// belum di-refund bila requested 0, atau requested bila tidak melebihinya.
+func disputeAmount(requested float64, remaining float64, currency string) (float64, error) {
+	remaining = balance.RoundAmount(remaining, currency)
+	if remaining <= 0 {
+		return 0, errors.New("transaksi sudah dikembalikan seluruhnya")
+	}
+	if requested == 0 {
+		return remaining, nil
+	}
+	if err := balance.ValidateAmount(requested, currency); err != nil {
+		return 0, err
+	}
+	if requested > remaining {
+		return 0, fmt.Errorf("jumlah dispute melebihi sisa transaksi %s %s", strconv.FormatFloat(remaining, 'f', balance.MinorUnits(currency), 64), currency)
+	}
+	return requested, nil
+}
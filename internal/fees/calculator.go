@@ -0,0 +1,74 @@
+package fees
+
+import (
+	"ewallet-engine/internal/balance"
+	"math"
+	"time"
+)
+
+// Calculate menghitung biaya schedule untuk amount lalu membulatkannya ke
+// satuan terkecil mata uang jadwal.
+func Calculate(schedule FeeSchedule, amount float64) float64 {
+	var fee float64
+
+	switch schedule.Method {
+	case MethodFlat:
+		fee = schedule.Flat
+	case MethodPercentage:
+		fee = schedule.Flat + amount*schedule.Percentage/100
+	case MethodTiered:
+		for _, tier := range schedule.Tiers {
+			if tier.UpTo == 0 || amount <= tier.UpTo {
+				fee = tier.Flat + amount*tier.Percentage/100
+				break
+			}
+		}
+	}
+
+	if fee < schedule.MinFee {
+		fee = schedule.MinFee
+	}
+	if schedule.MaxFee > 0 && fee > schedule.MaxFee {
+		fee = schedule.MaxFee
+	}
+
+	return balance.RoundAmount(math.Max(fee, 0), schedule.Currency)
+}
+
+// Select memilih jadwal yang berlaku untuk channel dan tier pada waktu now.
+// Jadwal dengan channel atau tier berbeda diabaikan; di antara yang cocok,
+// yang paling spesifik menang, lalu yang EffectiveFrom-nya paling baru.
+func Select(schedules []FeeSchedule, channel string, tier string, now time.Time) (*FeeSchedule, bool) {
+	var best *FeeSchedule
+	bestScore := -1
+
+	for i := range schedules {
+		candidate := &schedules[i]
+		if !candidate.ActiveAt(now) {
+			continue
+		}
+		if candidate.Channel != "" && candidate.Channel != channel {
+			continue
+		}
+		if candidate.UserTier != "" && candidate.UserTier != tier {
+			continue
+		}
+
+		score := 0
+		if candidate.Channel != "" {
+			score += 2
+		}
+		if candidate.UserTier != "" {
+			score++
+		}
+
+		if score > bestScore ||
+			(score == bestScore && candidate.EffectiveFrom.After(best.EffectiveFrom)) ||
+			(score == bestScore && candidate.EffectiveFrom.Equal(best.EffectiveFrom) && candidate.ID > best.ID) {
+			best = candidate
+			bestScore = score
+		}
+	}
+
+	return best, best != nil
+}
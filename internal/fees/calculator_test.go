@@ -0,0 +1,94 @@
+package fees
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalculate(t *testing.T) {
+	cases := []struct {
+		name     string
+		schedule FeeSchedule
+		amount   float64
+		expected float64
+	}{
+		{"flat", FeeSchedule{Method: MethodFlat, Flat: 2500, Currency: "IDR"}, 100000, 2500},
+		{"percentage rounded to rupiah", FeeSchedule{Method: MethodPercentage, Percentage: 0.7, Currency: "IDR"}, 123456, 864},
+		{"percentage capped", FeeSchedule{Method: MethodPercentage, Percentage: 1, MaxFee: 5000, Currency: "IDR"}, 1000000, 5000},
+		{"percentage with minimum", FeeSchedule{Method: MethodPercentage, Percentage: 1, MinFee: 1000, Currency: "IDR"}, 50000, 1000},
+		{"percentage in cents", FeeSchedule{Method: MethodPercentage, Percentage: 2.9, Flat: 0.3, Currency: "USD"}, 10, 0.59},
+		{"tiered lower bracket", tieredSchedule(), 50000, 1000},
+		{"tiered upper bracket", tieredSchedule(), 2000000, 2000 + 10000},
+	}
+
+	for _, tc := range cases {
+		if got := Calculate(tc.schedule, tc.amount); got != tc.expected {
+			t.Errorf("%s: expected fee %v; got %v", tc.name, tc.expected, got)
+		}
+	}
+}
+
+func tieredSchedule() FeeSchedule {
+	return FeeSchedule{
+		Method:   MethodTiered,
+		Currency: "IDR",
+		Tiers: Tiers{
+			{UpTo: 100000, Flat: 1000},
+			{UpTo: 0, Flat: 2000, Percentage: 0.5},
+		},
+	}
+}
+
+func TestSelectPrefersSpecificAndLatestSchedule(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-48 * time.Hour)
+	recent := now.Add(-time.Hour)
+	ended := now.Add(-30 * time.Minute)
+	future := now.Add(time.Hour)
+
+	schedules := []FeeSchedule{
+		{ID: 1, Name: "generic-old", EffectiveFrom: past},
+		{ID: 2, Name: "generic-new", EffectiveFrom: recent},
+		{ID: 3, Name: "va-bca", Channel: "VA_BCA", EffectiveFrom: past},
+		{ID: 4, Name: "va-bca-verified", Channel: "VA_BCA", UserTier: "VERIFIED", EffectiveFrom: past, EffectiveTo: &ended},
+		{ID: 5, Name: "future", Channel: "VA_BCA", UserTier: "VERIFIED", EffectiveFrom: future},
+		{ID: 6, Name: "other-channel", Channel: "QRIS", EffectiveFrom: past},
+	}
+
+	cases := []struct {
+		channel, tier string
+		expected      uint
+	}{
+		{"", "UNVERIFIED", 2},
+		{"VA_BCA", "VERIFIED", 3},
+		{"OVO", "VERIFIED", 2},
+		{"QRIS", "", 6},
+	}
+
+	for _, tc := range cases {
+		selected, ok := Select(schedules, tc.channel, tc.tier, now)
+		if !ok || selected.ID != tc.expected {
+			t.Errorf("Select(%q, %q): expected schedule %d; got %+v", tc.channel, tc.tier, tc.expected, selected)
+		}
+	}
+
+	if _, ok := Select(nil, "", "", now); ok {
+		t.Error("expected no schedule to be selected from an empty list")
+	}
+}
+
+func TestValidateScheduleRejectsOpenEndedMiddleTier(t *testing.T) {
+	schedule := FeeSchedule{
+		Name:            "bad tiers",
+		TransactionType: "purchase",
+		Method:          MethodTiered,
+		Tiers:           Tiers{{UpTo: 0, Flat: 1000}, {UpTo: 500000, Flat: 2000}},
+	}
+
+	if err := validateSchedule(&schedule); err == nil {
+		t.Fatal("expected schedule with open-ended middle tier to be rejected")
+	}
+	if schedule.TransactionType != "PURCHASE" || schedule.Currency != "IDR" {
+		t.Errorf("expected schedule to be normalised; got %q %q", schedule.TransactionType, schedule.Currency)
+	}
+}
@@ -0,0 +1,173 @@
+package fees
+
+import (
+	"errors"
+	"ewallet-engine/internal/audit"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type FeeHandler struct {
+	service      FeeService
+	auditService audit.AuditService
+}
+
+func NewFeeHandler(service FeeService, auditService audit.AuditService) *FeeHandler {
+	return &FeeHandler{service: service, auditService: auditService}
+}
+
+func (h *FeeHandler) QuoteHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	var request struct {
+		TransactionType string  `json:"transaction_type"`
+		Channel         string  `json:"channel"`
+		Currency        string  `json:"currency"`
+		Amount          float64 `json:"amount"`
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	quote, err := h.service.QuoteFee(userID, request.TransactionType, request.Channel, request.Currency, request.Amount)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": quote})
+}
+
+func (h *FeeHandler) ListSchedulesHandler(c *fiber.Ctx) error {
+	schedules, err := h.service.ListSchedules(c.Query("transaction_type"), c.QueryBool("active", false))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": schedules})
+}
+
+func (h *FeeHandler) CreateScheduleHandler(c *fiber.Ctx) error {
+	actorID := c.Locals("user_id").(uint)
+
+	var request FeeSchedule
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	schedule, err := h.service.CreateSchedule(actorID, request)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionFeeScheduleCreated,
+		TargetType: "fee_schedule",
+		TargetID:   fmt.Sprint(schedule.ID),
+		After:      scheduleSnapshot(schedule),
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Jadwal fee berhasil dibuat",
+		"data":    schedule,
+	})
+}
+
+func (h *FeeHandler) UpdateScheduleHandler(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	var request FeeSchedule
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	before, after, err := h.service.UpdateSchedule(uint(id), request)
+	if err != nil {
+		return h.scheduleError(c, err)
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionFeeScheduleUpdated,
+		TargetType: "fee_schedule",
+		TargetID:   fmt.Sprint(after.ID),
+		Before:     scheduleSnapshot(before),
+		After:      scheduleSnapshot(after),
+	})
+
+	return c.JSON(fiber.Map{
+		"message": "Jadwal fee berhasil diperbarui",
+		"data":    after,
+	})
+}
+
+func (h *FeeHandler) EndScheduleHandler(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	var request struct {
+		EffectiveTo *time.Time `json:"effective_to"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+	}
+
+	at := time.Now()
+	if request.EffectiveTo != nil {
+		at = *request.EffectiveTo
+	}
+
+	before, after, err := h.service.EndSchedule(uint(id), at)
+	if err != nil {
+		return h.scheduleError(c, err)
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionFeeScheduleEnded,
+		TargetType: "fee_schedule",
+		TargetID:   fmt.Sprint(after.ID),
+		Before:     scheduleSnapshot(before),
+		After:      scheduleSnapshot(after),
+	})
+
+	return c.JSON(fiber.Map{
+		"message": "Jadwal fee berhasil diakhiri",
+		"data":    after,
+	})
+}
+
+func (h *FeeHandler) scheduleError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, ErrScheduleNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+	}
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+}
+
+func scheduleSnapshot(schedule *FeeSchedule) audit.Snapshot {
+	return audit.Snapshot{
+		"name":             schedule.Name,
+		"transaction_type": schedule.TransactionType,
+		"channel":          schedule.Channel,
+		"user_tier":        schedule.UserTier,
+		"currency":         schedule.Currency,
+		"method":           schedule.Method,
+		"flat":             schedule.Flat,
+		"percentage":       schedule.Percentage,
+		"tiers":            schedule.Tiers,
+		"min_fee":          schedule.MinFee,
+		"max_fee":          schedule.MaxFee,
+		"effective_from":   schedule.EffectiveFrom,
+		"effective_to":     schedule.EffectiveTo,
+	}
+}
@@ -0,0 +1,94 @@
+package fees
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+type Method string
+
+const (
+	MethodFlat       Method = "FLAT"
+	MethodPercentage Method = "PERCENTAGE"
+	MethodTiered     Method = "TIERED"
+)
+
+// Tier adalah satu rentang pada jadwal TIERED. Rentang berlaku untuk amount
+// sampai dengan UpTo; UpTo 0 berarti tanpa batas atas dan harus menjadi tier
+// terakhir.
+type Tier struct {
+	UpTo       float64 `json:"up_to"`
+	Flat       float64 `json:"flat"`
+	Percentage float64 `json:"percentage"`
+}
+
+type Tiers []Tier
+
+func (t Tiers) Value() (driver.Value, error) {
+	return json.Marshal(t)
+}
+
+func (t *Tiers) Scan(value interface{}) error {
+	if value == nil {
+		*t = nil
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("failed to unmarshal JSON")
+	}
+	return json.Unmarshal(bytes, t)
+}
+
+// FeeSchedule menentukan biaya untuk satu jenis transaksi. Channel dan
+// UserTier kosong berarti berlaku untuk semua channel/tier; jadwal yang lebih
+// spesifik menang, lalu jadwal dengan EffectiveFrom paling baru.
+//
+// Percentage dinyatakan dalam persen (1.5 berarti 1,5%). MinFee dan MaxFee
+// membatasi hasil akhir; MaxFee 0 berarti tanpa batas atas.
+type FeeSchedule struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	Name            string     `gorm:"type:varchar(100);not null" json:"name"`
+	TransactionType string     `gorm:"type:varchar(30);not null;index:idx_fee_schedule_scope" json:"transaction_type"`
+	Channel         string     `gorm:"type:varchar(50);not null;default:'';index:idx_fee_schedule_scope" json:"channel"`
+	UserTier        string     `gorm:"type:varchar(30);not null;default:''" json:"user_tier"`
+	Currency        string     `gorm:"type:char(3);not null;default:'IDR'" json:"currency"`
+	Method          Method     `gorm:"type:enum('FLAT','PERCENTAGE','TIERED');not null" json:"method"`
+	Flat            float64    `gorm:"not null;default:0" json:"flat"`
+	Percentage      float64    `gorm:"not null;default:0" json:"percentage"`
+	Tiers           Tiers      `gorm:"type:json" json:"tiers,omitempty"`
+	MinFee          float64    `gorm:"not null;default:0" json:"min_fee"`
+	MaxFee          float64    `gorm:"not null;default:0" json:"max_fee"`
+	EffectiveFrom   time.Time  `gorm:"not null;index" json:"effective_from"`
+	EffectiveTo     *time.Time `json:"effective_to,omitempty"`
+	CreatedBy       uint       `gorm:"not null" json:"created_by"`
+	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// ActiveAt melaporkan apakah jadwal berlaku pada waktu t.
+func (s FeeSchedule) ActiveAt(t time.Time) bool {
+	if t.Before(s.EffectiveFrom) {
+		return false
+	}
+	return s.EffectiveTo == nil || t.Before(*s.EffectiveTo)
+}
+
+// Quote adalah rincian biaya sebelum transaksi dieksekusi. Total adalah jumlah
+// yang keluar dari wallet (amount + fee), kecuali TOPUP di mana Total adalah
+// dana bersih yang masuk (amount - fee).
+type Quote struct {
+	TransactionType string  `json:"transaction_type"`
+	Channel         string  `json:"channel,omitempty"`
+	Currency        string  `json:"currency"`
+	Amount          float64 `json:"amount"`
+	Fee             float64 `json:"fee"`
+	Total           float64 `json:"total"`
+	ScheduleID      uint    `json:"schedule_id,omitempty"`
+	ScheduleName    string  `json:"schedule_name,omitempty"`
+}
+
+// TierResolver mengembalikan tier KYC user untuk memilih jadwal fee.
+type TierResolver func(userID uint) string
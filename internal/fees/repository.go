@@ -0,0 +1,63 @@
+package fees
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type FeeRepository interface {
+	FindActiveSchedules(txType string, currency string, at time.Time) ([]FeeSchedule, error)
+	ListSchedules(txType string, activeAt *time.Time) ([]FeeSchedule, error)
+	FindScheduleByID(id uint) (*FeeSchedule, error)
+	CreateSchedule(schedule *FeeSchedule) error
+	SaveSchedule(schedule *FeeSchedule) error
+}
+
+type feeRepository struct {
+	DB *gorm.DB
+}
+
+func NewFeeRepository(db *gorm.DB) FeeRepository {
+	return &feeRepository{DB: db}
+}
+
+func (r *feeRepository) FindActiveSchedules(txType string, currency string, at time.Time) ([]FeeSchedule, error) {
+	var schedules []FeeSchedule
+	err := r.DB.
+		Where("transaction_type = ? AND currency = ? AND effective_from <= ?", txType, currency, at).
+		Where("effective_to IS NULL OR effective_to > ?", at).
+		Find(&schedules).Error
+	return schedules, err
+}
+
+func (r *feeRepository) ListSchedules(txType string, activeAt *time.Time) ([]FeeSchedule, error) {
+	var schedules []FeeSchedule
+	query := r.DB.Order("transaction_type ASC, effective_from DESC")
+	if txType != "" {
+		query = query.Where("transaction_type = ?", txType)
+	}
+	if activeAt != nil {
+		query = query.Where("effective_from <= ?", *activeAt).
+			Where("effective_to IS NULL OR effective_to > ?", *activeAt)
+	}
+	err := query.Find(&schedules).Error
+	return schedules, err
+}
+
+func (r *feeRepository) FindScheduleByID(id uint) (*FeeSchedule, error) {
+	var schedule FeeSchedule
+	err := r.DB.First(&schedule, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (r *feeRepository) CreateSchedule(schedule *FeeSchedule) error {
+	return r.DB.Create(schedule).Error
+}
+
+func (r *feeRepository) SaveSchedule(schedule *FeeSchedule) error {
+	return r.DB.Save(schedule).Error
+}
@@ -0,0 +1,216 @@
+package fees
+
+import (
+	"errors"
+	"ewallet-engine/internal/balance"
+	"strings"
+	"time"
+)
+
+var ErrScheduleNotFound = errors.New("jadwal fee tidak ditemukan")
+
+type FeeService interface {
+	CalculateFee(userID uint, txType string, channel string, currency string, amount float64) (float64, error)
+	QuoteFee(userID uint, txType string, channel string, currency string, amount float64) (*Quote, error)
+	ListSchedules(txType string, activeOnly bool) ([]FeeSchedule, error)
+	CreateSchedule(actorID uint, schedule FeeSchedule) (*FeeSchedule, error)
+	UpdateSchedule(id uint, changes FeeSchedule) (*FeeSchedule, *FeeSchedule, error)
+	EndSchedule(id uint, at time.Time) (*FeeSchedule, *FeeSchedule, error)
+}
+
+type feeService struct {
+	repo         FeeRepository
+	tierResolver TierResolver
+}
+
+func NewFeeService(repo FeeRepository, tierResolver TierResolver) FeeService {
+	if tierResolver == nil {
+		tierResolver = func(userID uint) string { return "" }
+	}
+	return &feeService{repo: repo, tierResolver: tierResolver}
+}
+
+// CalculateFee mengembalikan 0 jika tidak ada jadwal yang berlaku.
+func (s *feeService) CalculateFee(userID uint, txType string, channel string, currency string, amount float64) (float64, error) {
+	quote, err := s.QuoteFee(userID, txType, channel, currency, amount)
+	if err != nil {
+		return 0, err
+	}
+	return quote.Fee, nil
+}
+
+func (s *feeService) QuoteFee(userID uint, txType string, channel string, currency string, amount float64) (*Quote, error) {
+	txType = strings.ToUpper(strings.TrimSpace(txType))
+	if txType == "" {
+		return nil, errors.New("transaction_type wajib diisi")
+	}
+	currency, err := balance.NormalizeCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+	if err := balance.ValidateAmount(amount, currency); err != nil {
+		return nil, err
+	}
+
+	quote := Quote{
+		TransactionType: txType,
+		Channel:         channel,
+		Currency:        currency,
+		Amount:          amount,
+	}
+
+	now := time.Now()
+	schedules, err := s.repo.FindActiveSchedules(txType, currency, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if schedule, ok := Select(schedules, channel, s.tierResolver(userID), now); ok {
+		quote.Fee = Calculate(*schedule, amount)
+		quote.ScheduleID = schedule.ID
+		quote.ScheduleName = schedule.Name
+	}
+
+	if txType == "TOPUP" {
+		quote.Total = balance.RoundAmount(amount-quote.Fee, currency)
+	} else {
+		quote.Total = balance.RoundAmount(amount+quote.Fee, currency)
+	}
+	return &quote, nil
+}
+
+func (s *feeService) ListSchedules(txType string, activeOnly bool) ([]FeeSchedule, error) {
+	var activeAt *time.Time
+	if activeOnly {
+		now := time.Now()
+		activeAt = &now
+	}
+	return s.repo.ListSchedules(strings.ToUpper(txType), activeAt)
+}
+
+func (s *feeService) CreateSchedule(actorID uint, schedule FeeSchedule) (*FeeSchedule, error) {
+	schedule.ID = 0
+	schedule.CreatedBy = actorID
+	if schedule.EffectiveFrom.IsZero() {
+		schedule.EffectiveFrom = time.Now()
+	}
+
+	if err := validateSchedule(&schedule); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateSchedule(&schedule); err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// UpdateSchedule hanya mengizinkan perubahan jadwal yang belum berlaku. Tarif
+// yang sudah berlaku diganti dengan membuat jadwal baru dengan EffectiveFrom
+// lebih baru supaya riwayat biaya tetap bisa ditelusuri.
+func (s *feeService) UpdateSchedule(id uint, changes FeeSchedule) (*FeeSchedule, *FeeSchedule, error) {
+	existing, err := s.repo.FindScheduleByID(id)
+	if err != nil {
+		return nil, nil, ErrScheduleNotFound
+	}
+	if !time.Now().Before(existing.EffectiveFrom) {
+		return nil, nil, errors.New("jadwal yang sudah berlaku tidak dapat diubah, buat jadwal baru dengan effective_from berikutnya")
+	}
+
+	before := *existing
+	changes.ID = existing.ID
+	changes.CreatedBy = existing.CreatedBy
+	changes.CreatedAt = existing.CreatedAt
+	if changes.EffectiveFrom.IsZero() {
+		changes.EffectiveFrom = existing.EffectiveFrom
+	}
+
+	if err := validateSchedule(&changes); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.repo.SaveSchedule(&changes); err != nil {
+		return nil, nil, err
+	}
+	return &before, &changes, nil
+}
+
+// EndSchedule mengisi EffectiveTo sehingga jadwal berhenti berlaku pada at.
+func (s *feeService) EndSchedule(id uint, at time.Time) (*FeeSchedule, *FeeSchedule, error) {
+	existing, err := s.repo.FindScheduleByID(id)
+	if err != nil {
+		return nil, nil, ErrScheduleNotFound
+	}
+	if existing.EffectiveTo != nil && !existing.EffectiveTo.After(time.Now()) {
+		return nil, nil, errors.New("jadwal sudah tidak berlaku")
+	}
+
+	before := *existing
+	if at.Before(existing.EffectiveFrom) {
+		at = existing.EffectiveFrom
+	}
+	existing.EffectiveTo = &at
+
+	if err := s.repo.SaveSchedule(existing); err != nil {
+		return nil, nil, err
+	}
+	return &before, existing, nil
+}
+
+func validateSchedule(schedule *FeeSchedule) error {
+	schedule.Name = strings.TrimSpace(schedule.Name)
+	schedule.TransactionType = strings.ToUpper(strings.TrimSpace(schedule.TransactionType))
+	schedule.Channel = strings.ToUpper(strings.TrimSpace(schedule.Channel))
+	schedule.UserTier = strings.ToUpper(strings.TrimSpace(schedule.UserTier))
+
+	if schedule.Name == "" {
+		return errors.New("nama jadwal wajib diisi")
+	}
+	if schedule.TransactionType == "" {
+		return errors.New("transaction_type wajib diisi")
+	}
+
+	currency, err := balance.NormalizeCurrency(schedule.Currency)
+	if err != nil {
+		return err
+	}
+	schedule.Currency = currency
+
+	if schedule.Flat < 0 || schedule.Percentage < 0 || schedule.MinFee < 0 || schedule.MaxFee < 0 {
+		return errors.New("nilai fee tidak boleh negatif")
+	}
+	if schedule.Percentage > 100 {
+		return errors.New("persentase fee maksimal 100")
+	}
+	if schedule.MaxFee > 0 && schedule.MaxFee < schedule.MinFee {
+		return errors.New("max_fee tidak boleh lebih kecil dari min_fee")
+	}
+	if schedule.EffectiveTo != nil && !schedule.EffectiveTo.After(schedule.EffectiveFrom) {
+		return errors.New("effective_to harus setelah effective_from")
+	}
+
+	switch schedule.Method {
+	case MethodFlat, MethodPercentage:
+		schedule.Tiers = nil
+	case MethodTiered:
+		if len(schedule.Tiers) == 0 {
+			return errors.New("jadwal TIERED wajib memiliki tiers")
+		}
+		for i, tier := range schedule.Tiers {
+			if tier.Flat < 0 || tier.Percentage < 0 || tier.Percentage > 100 || tier.UpTo < 0 {
+				return errors.New("nilai tier tidak valid")
+			}
+			last := i == len(schedule.Tiers)-1
+			if (tier.UpTo == 0) != last {
+				return errors.New("hanya dan wajib tier terakhir yang tanpa batas atas (up_to 0)")
+			}
+			if i > 0 && tier.UpTo != 0 && tier.UpTo <= schedule.Tiers[i-1].UpTo {
+				return errors.New("up_to setiap tier harus naik")
+			}
+		}
+	default:
+		return errors.New("method fee harus FLAT, PERCENTAGE atau TIERED")
+	}
+
+	return nil
+}
@@ -0,0 +1,191 @@
+package fraud
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// reloadCheckInterval membatasi seberapa sering mtime file rule diperiksa.
+const reloadCheckInterval = 10 * time.Second
+
+type RuleConfig struct {
+	Name    string             `json:"name"`
+	Type    string             `json:"type"`
+	Enabled bool               `json:"enabled"`
+	Action  Decision           `json:"action"`
+	Score   int                `json:"score"`
+	Params  map[string]float64 `json:"params"`
+}
+
+type Config struct {
+	ReviewScore int          `json:"review_score"`
+	BlockScore  int          `json:"block_score"`
+	Rules       []RuleConfig `json:"rules"`
+}
+
+type configuredRule struct {
+	rule   Rule
+	action Decision
+	score  int
+}
+
+// Engine memuat rule dari file JSON dan memuat ulang otomatis ketika file
+// berubah, sehingga rule bisa diubah tanpa redeploy.
+type Engine struct {
+	path string
+
+	mu          sync.RWMutex
+	config      Config
+	rules       []configuredRule
+	modTime     time.Time
+	lastChecked time.Time
+}
+
+var defaultConfig = Config{
+	ReviewScore: 50,
+	BlockScore:  90,
+	Rules: []RuleConfig{
+		{Name: "velocity_debit", Type: "velocity", Enabled: true, Action: DecisionReview, Score: 40, Params: map[string]float64{"max_count": 5, "window_minutes": 10}},
+		{Name: "amount_anomaly", Type: "amount_anomaly", Enabled: true, Action: DecisionReview, Score: 30, Params: map[string]float64{"multiplier": 5, "min_history": 5, "lookback_days": 30}},
+		{Name: "new_device_large_amount", Type: "new_device_large_amount", Enabled: true, Action: DecisionReview, Score: 40, Params: map[string]float64{"threshold": 5000000}},
+		{Name: "topup_then_transfer", Type: "topup_then_transfer", Enabled: true, Action: DecisionReview, Score: 30, Params: map[string]float64{"window_minutes": 30, "ratio": 0.8}},
+	},
+}
+
+func NewEngine(path string) *Engine {
+	engine := &Engine{path: path}
+	if err := engine.Reload(); err != nil {
+		log.Printf("ERROR: Gagal memuat rule fraud dari %s, memakai rule bawaan: %v", path, err)
+		engine.apply(defaultConfig, time.Time{})
+	}
+	return engine
+}
+
+func (e *Engine) Reload() error {
+	info, err := os.Stat(e.path)
+	if err != nil {
+		return err
+	}
+
+	raw, err := os.ReadFile(e.path)
+	if err != nil {
+		return err
+	}
+
+	var config Config
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return fmt.Errorf("format rule fraud tidak valid: %w", err)
+	}
+
+	return e.apply(config, info.ModTime())
+}
+
+func (e *Engine) Config() Config {
+	e.reloadIfChanged()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.config
+}
+
+// Evaluate menjalankan semua rule aktif. Keputusan akhir adalah aksi terberat
+// dari rule yang terpicu, dinaikkan lagi bila total skor melewati ambang.
+func (e *Engine) Evaluate(event Event, history History) Evaluation {
+	e.reloadIfChanged()
+
+	e.mu.RLock()
+	rules := e.rules
+	config := e.config
+	e.mu.RUnlock()
+
+	evaluation := Evaluation{Decision: DecisionAllow}
+	for _, configured := range rules {
+		triggered, reason, err := configured.rule.Evaluate(event, history)
+		if err != nil {
+			log.Printf("ERROR: Rule fraud %s gagal dievaluasi: %v", configured.rule.Name(), err)
+			continue
+		}
+
+		result := RuleResult{Rule: configured.rule.Name(), Triggered: triggered, Decision: DecisionAllow}
+		if triggered {
+			result.Decision = configured.action
+			result.Score = configured.score
+			result.Reason = reason
+			evaluation.Score += configured.score
+			evaluation.Decision = evaluation.Decision.Stricter(configured.action)
+		}
+		evaluation.Results = append(evaluation.Results, result)
+	}
+
+	if config.BlockScore > 0 && evaluation.Score >= config.BlockScore {
+		evaluation.Decision = evaluation.Decision.Stricter(DecisionBlock)
+	} else if config.ReviewScore > 0 && evaluation.Score >= config.ReviewScore {
+		evaluation.Decision = evaluation.Decision.Stricter(DecisionReview)
+	}
+
+	return evaluation
+}
+
+func (e *Engine) apply(config Config, modTime time.Time) error {
+	rules := make([]configuredRule, 0, len(config.Rules))
+	for _, ruleConfig := range config.Rules {
+		if !ruleConfig.Enabled {
+			continue
+		}
+
+		factory, ok := ruleFactories[ruleConfig.Type]
+		if !ok {
+			return fmt.Errorf("tipe rule fraud %q tidak dikenal", ruleConfig.Type)
+		}
+		if _, ok := decisionSeverity[ruleConfig.Action]; !ok {
+			return fmt.Errorf("aksi rule fraud %q tidak valid", ruleConfig.Action)
+		}
+
+		name := ruleConfig.Name
+		if name == "" {
+			name = ruleConfig.Type
+		}
+		rules = append(rules, configuredRule{
+			rule:   factory(name, ruleConfig.Params),
+			action: ruleConfig.Action,
+			score:  ruleConfig.Score,
+		})
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.config = config
+	e.rules = rules
+	e.modTime = modTime
+	e.lastChecked = time.Now()
+	return nil
+}
+
+func (e *Engine) reloadIfChanged() {
+	e.mu.RLock()
+	due := time.Since(e.lastChecked) >= reloadCheckInterval
+	modTime := e.modTime
+	e.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	e.mu.Lock()
+	e.lastChecked = time.Now()
+	e.mu.Unlock()
+
+	info, err := os.Stat(e.path)
+	if err != nil || !info.ModTime().After(modTime) {
+		return
+	}
+
+	if err := e.Reload(); err != nil {
+		log.Printf("ERROR: Gagal memuat ulang rule fraud, rule lama tetap dipakai: %v", err)
+		return
+	}
+	log.Printf("SUCCESS: Rule fraud dimuat ulang dari %s", e.path)
+}
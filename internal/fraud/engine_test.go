@@ -0,0 +1,88 @@
+package fraud
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type fakeHistory struct {
+	debitCount  int64
+	average     float64
+	historySize int64
+	toppedUp    float64
+	knownDevice bool
+}
+
+func (f fakeHistory) CountEvents(uint, []string, time.Time) (int64, error) { return f.debitCount, nil }
+
+func (f fakeHistory) AmountStats(uint, []string, time.Time) (int64, float64, error) {
+	return f.historySize, f.average, nil
+}
+
+func (f fakeHistory) SumAmount(uint, []string, time.Time) (float64, error) { return f.toppedUp, nil }
+
+func (f fakeHistory) IsKnownDevice(uint, string) (bool, error) { return f.knownDevice, nil }
+
+func writeRules(t *testing.T, path string, body string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatalf("write rules: %v", err)
+	}
+}
+
+func TestEvaluateTakesStrictestTriggeredAction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	writeRules(t, path, `{
+		"review_score": 100,
+		"block_score": 200,
+		"rules": [
+			{"name": "velocity", "type": "velocity", "enabled": true, "action": "REVIEW", "score": 10, "params": {"max_count": 3}},
+			{"name": "burst", "type": "velocity", "enabled": true, "action": "BLOCK", "score": 10, "params": {"max_count": 5}},
+			{"name": "device", "type": "new_device_large_amount", "enabled": false, "action": "BLOCK", "score": 10}
+		]
+	}`)
+	engine := NewEngine(path)
+
+	event := Event{UserID: 1, Operation: OperationPurchase, Amount: 100, DeviceID: "abc"}
+
+	if got := engine.Evaluate(event, fakeHistory{debitCount: 1, knownDevice: true}); got.Decision != DecisionAllow {
+		t.Errorf("expected ALLOW for quiet user; got %s", got.Decision)
+	}
+	if got := engine.Evaluate(event, fakeHistory{debitCount: 3, knownDevice: true}); got.Decision != DecisionReview {
+		t.Errorf("expected REVIEW after 4 debits; got %s", got.Decision)
+	}
+	if got := engine.Evaluate(event, fakeHistory{debitCount: 5, knownDevice: true}); got.Decision != DecisionBlock || got.Score != 20 {
+		t.Errorf("expected BLOCK with score 20 after 6 debits; got %s/%d", got.Decision, got.Score)
+	}
+}
+
+func TestEvaluateEscalatesOnScoreThreshold(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	writeRules(t, path, `{
+		"review_score": 50,
+		"block_score": 70,
+		"rules": [
+			{"type": "amount_anomaly", "enabled": true, "action": "REVIEW", "score": 40, "params": {"multiplier": 3, "min_history": 2}},
+			{"type": "topup_then_transfer", "enabled": true, "action": "REVIEW", "score": 40}
+		]
+	}`)
+	engine := NewEngine(path)
+
+	event := Event{UserID: 1, Operation: OperationPurchase, Amount: 1000}
+	got := engine.Evaluate(event, fakeHistory{historySize: 10, average: 100, toppedUp: 1000})
+	if got.Decision != DecisionBlock {
+		t.Errorf("expected score 80 to escalate to BLOCK; got %s (%d)", got.Decision, got.Score)
+	}
+}
+
+func TestInvalidRulesFallBackToDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	writeRules(t, path, `{"rules": [{"type": "unknown", "enabled": true, "action": "REVIEW"}]}`)
+
+	engine := NewEngine(path)
+	if len(engine.Config().Rules) != len(defaultConfig.Rules) {
+		t.Errorf("expected built-in rules when file is invalid; got %+v", engine.Config())
+	}
+}
@@ -0,0 +1,116 @@
+package fraud
+
+import (
+	"ewallet-engine/internal/audit"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type FraudHandler struct {
+	service      FraudService
+	auditService audit.AuditService
+}
+
+func NewFraudHandler(service FraudService, auditService audit.AuditService) *FraudHandler {
+	return &FraudHandler{service: service, auditService: auditService}
+}
+
+func (h *FraudHandler) ListCasesHandler(c *fiber.Ctx) error {
+	status := CaseStatus(c.Query("status", string(CaseOpen)))
+
+	cases, err := h.service.ListCases(status)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": cases})
+}
+
+func (h *FraudHandler) GetCaseHandler(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	fraudCase, err := h.service.GetCase(uint(id))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": fraudCase})
+}
+
+func (h *FraudHandler) ClearCaseHandler(c *fiber.Ctx) error {
+	return h.review(c, h.service.ClearCase, audit.ActionFraudCaseCleared, "Case dinyatakan aman")
+}
+
+func (h *FraudHandler) ConfirmCaseHandler(c *fiber.Ctx) error {
+	return h.review(c, h.service.ConfirmCase, audit.ActionFraudCaseConfirmed, "Case dikonfirmasi sebagai fraud")
+}
+
+func (h *FraudHandler) RulesHandler(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"data": h.service.Rules()})
+}
+
+func (h *FraudHandler) ReloadRulesHandler(c *fiber.Ctx) error {
+	before := h.service.Rules()
+	if err := h.service.ReloadRules(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionFraudRulesReloaded,
+		TargetType: "fraud_rules",
+		Before:     audit.Snapshot{"rules": before},
+		After:      audit.Snapshot{"rules": h.service.Rules()},
+	})
+
+	return c.JSON(fiber.Map{
+		"message": "Rule fraud berhasil dimuat ulang",
+		"data":    h.service.Rules(),
+	})
+}
+
+func (h *FraudHandler) review(c *fiber.Ctx, decision func(id uint, reviewerID uint, note string) (*FraudCase, error), action string, successMessage string) error {
+	reviewerID := c.Locals("user_id").(uint)
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	var request struct {
+		Note string `json:"note"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+	}
+
+	fraudCase, err := decision(uint(id), reviewerID, request.Note)
+	// Keputusan tetap tersimpan walaupun tindak lanjutnya gagal, jadi tetap dicatat.
+	if fraudCase != nil && fraudCase.Status != CaseOpen {
+		_ = h.auditService.Record(audit.Entry{
+			Meta:       audit.FromContext(c),
+			Action:     action,
+			TargetType: "fraud_case",
+			TargetID:   fmt.Sprint(fraudCase.ID),
+			Before:     audit.Snapshot{"status": CaseOpen},
+			After:      audit.Snapshot{"status": fraudCase.Status, "reference": fraudCase.Reference, "note": fraudCase.ReviewNote},
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+			"data":    fraudCase,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": successMessage,
+		"data":    fraudCase,
+	})
+}
@@ -0,0 +1,129 @@
+package fraud
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+type Decision string
+
+const (
+	DecisionAllow  Decision = "ALLOW"
+	DecisionReview Decision = "REVIEW"
+	DecisionBlock  Decision = "BLOCK"
+)
+
+var decisionSeverity = map[Decision]int{
+	DecisionAllow:  0,
+	DecisionReview: 1,
+	DecisionBlock:  2,
+}
+
+// Stricter mengembalikan keputusan yang lebih berat di antara d dan other.
+func (d Decision) Stricter(other Decision) Decision {
+	if decisionSeverity[other] > decisionSeverity[d] {
+		return other
+	}
+	return d
+}
+
+const (
+	OperationPurchase = "PURCHASE"
+	OperationTopUp    = "TOPUP"
+	OperationTransfer = "TRANSFER"
+)
+
+// debitOperations adalah operasi yang mengeluarkan dana dari wallet.
+var debitOperations = []string{OperationPurchase, OperationTransfer}
+
+type CaseStatus string
+
+const (
+	CaseOpen      CaseStatus = "OPEN"
+	CaseBlocked   CaseStatus = "BLOCKED"
+	CaseCleared   CaseStatus = "CLEARED"
+	CaseConfirmed CaseStatus = "CONFIRMED"
+)
+
+// Event adalah transaksi yang akan dinilai sebelum dana berpindah.
+type Event struct {
+	UserID    uint
+	Operation string
+	Amount    float64
+	Reference string
+	DeviceID  string
+	IP        string
+}
+
+type RuleResult struct {
+	Rule      string   `json:"rule"`
+	Triggered bool     `json:"triggered"`
+	Decision  Decision `json:"decision"`
+	Score     int      `json:"score"`
+	Reason    string   `json:"reason,omitempty"`
+}
+
+type Evaluation struct {
+	Decision Decision     `json:"decision"`
+	Score    int          `json:"score"`
+	Results  []RuleResult `json:"results"`
+	CaseID   uint         `json:"case_id,omitempty"`
+}
+
+type RuleResults []RuleResult
+
+func (r RuleResults) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+func (r *RuleResults) Scan(value interface{}) error {
+	if value == nil {
+		*r = nil
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("failed to unmarshal JSON")
+	}
+	return json.Unmarshal(bytes, r)
+}
+
+// FraudEvent menyimpan setiap event yang dinilai; tabel ini menjadi sumber
+// riwayat untuk rule velocity, anomali nominal dan device baru.
+type FraudEvent struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;index:idx_fraud_event_user" json:"user_id"`
+	Operation string    `gorm:"type:varchar(20);not null;index:idx_fraud_event_user" json:"operation"`
+	Amount    float64   `gorm:"not null" json:"amount"`
+	Reference string    `gorm:"type:varchar(255)" json:"reference"`
+	DeviceID  string    `gorm:"type:varchar(100);index" json:"device_id"`
+	IP        string    `gorm:"type:varchar(45)" json:"ip"`
+	Decision  Decision  `gorm:"type:varchar(10);not null" json:"decision"`
+	Score     int       `gorm:"not null" json:"score"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index:idx_fraud_event_user" json:"created_at"`
+}
+
+// FraudCase adalah antrean review untuk event REVIEW dan catatan untuk event BLOCK.
+type FraudCase struct {
+	ID         uint        `gorm:"primaryKey" json:"id"`
+	EventID    uint        `gorm:"not null" json:"event_id"`
+	UserID     uint        `gorm:"not null;index" json:"user_id"`
+	Operation  string      `gorm:"type:varchar(20);not null" json:"operation"`
+	Amount     float64     `gorm:"not null" json:"amount"`
+	Reference  string      `gorm:"type:varchar(255);index" json:"reference"`
+	Decision   Decision    `gorm:"type:varchar(10);not null" json:"decision"`
+	Score      int         `gorm:"not null" json:"score"`
+	Results    RuleResults `gorm:"type:json" json:"results"`
+	Status     CaseStatus  `gorm:"type:enum('OPEN','BLOCKED','CLEARED','CONFIRMED');default:'OPEN';index" json:"status"`
+	ReviewerID *uint       `json:"reviewer_id,omitempty"`
+	ReviewNote string      `gorm:"type:varchar(255)" json:"review_note,omitempty"`
+	ReviewedAt *time.Time  `json:"reviewed_at,omitempty"`
+	CreatedAt  time.Time   `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time   `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// CaseResolver dipanggil setelah operator memutuskan sebuah case, misalnya
+// untuk menggagalkan transaksi PENDING yang terbukti fraud.
+type CaseResolver func(fraudCase FraudCase) error
@@ -0,0 +1,103 @@
+package fraud
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type FraudRepository interface {
+	History
+	CreateEvent(event *FraudEvent) error
+	CreateCase(fraudCase *FraudCase) error
+	FindCaseByID(id uint) (*FraudCase, error)
+	ListCases(status CaseStatus) ([]FraudCase, error)
+	HasOpenCase(reference string) (bool, error)
+	TransitionCase(id uint, from CaseStatus, updates map[string]interface{}) (bool, error)
+}
+
+type fraudRepository struct {
+	DB *gorm.DB
+}
+
+func NewFraudRepository(db *gorm.DB) FraudRepository {
+	return &fraudRepository{DB: db}
+}
+
+func (r *fraudRepository) CountEvents(userID uint, operations []string, since time.Time) (int64, error) {
+	var count int64
+	err := r.DB.Model(&FraudEvent{}).
+		Where("user_id = ? AND operation IN ? AND created_at >= ?", userID, operations, since).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *fraudRepository) AmountStats(userID uint, operations []string, since time.Time) (int64, float64, error) {
+	var stats struct {
+		Count   int64
+		Average float64
+	}
+	err := r.DB.Model(&FraudEvent{}).
+		Select("COUNT(*) AS count, COALESCE(AVG(amount), 0) AS average").
+		Where("user_id = ? AND operation IN ? AND decision = ? AND created_at >= ?", userID, operations, DecisionAllow, since).
+		Scan(&stats).Error
+	return stats.Count, stats.Average, err
+}
+
+func (r *fraudRepository) SumAmount(userID uint, operations []string, since time.Time) (float64, error) {
+	var total float64
+	err := r.DB.Model(&FraudEvent{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("user_id = ? AND operation IN ? AND decision = ? AND created_at >= ?", userID, operations, DecisionAllow, since).
+		Scan(&total).Error
+	return total, err
+}
+
+func (r *fraudRepository) IsKnownDevice(userID uint, deviceID string) (bool, error) {
+	var count int64
+	err := r.DB.Model(&FraudEvent{}).
+		Where("user_id = ? AND device_id = ? AND decision = ?", userID, deviceID, DecisionAllow).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *fraudRepository) CreateEvent(event *FraudEvent) error {
+	return r.DB.Create(event).Error
+}
+
+func (r *fraudRepository) CreateCase(fraudCase *FraudCase) error {
+	return r.DB.Create(fraudCase).Error
+}
+
+func (r *fraudRepository) FindCaseByID(id uint) (*FraudCase, error) {
+	var fraudCase FraudCase
+	err := r.DB.First(&fraudCase, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &fraudCase, nil
+}
+
+func (r *fraudRepository) ListCases(status CaseStatus) ([]FraudCase, error) {
+	var cases []FraudCase
+	query := r.DB.Order("created_at ASC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&cases).Error
+	return cases, err
+}
+
+func (r *fraudRepository) HasOpenCase(reference string) (bool, error) {
+	var count int64
+	err := r.DB.Model(&FraudCase{}).Where("reference = ? AND status = ?", reference, CaseOpen).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *fraudRepository) TransitionCase(id uint, from CaseStatus, updates map[string]interface{}) (bool, error) {
+	result := r.DB.Model(&FraudCase{}).Where("id = ? AND status = ?", id, from).Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
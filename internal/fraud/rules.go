@@ -0,0 +1,176 @@
+package fraud
+
+import (
+	"fmt"
+	"time"
+)
+
+// History adalah riwayat event user yang dibutuhkan rule.
+type History interface {
+	CountEvents(userID uint, operations []string, since time.Time) (int64, error)
+	AmountStats(userID uint, operations []string, since time.Time) (int64, float64, error)
+	SumAmount(userID uint, operations []string, since time.Time) (float64, error)
+	IsKnownDevice(userID uint, deviceID string) (bool, error)
+}
+
+// Rule menilai satu event. Rule baru cukup mengimplementasikan interface ini
+// dan didaftarkan di ruleFactories.
+type Rule interface {
+	Name() string
+	Evaluate(event Event, history History) (bool, string, error)
+}
+
+type ruleFactory func(name string, params map[string]float64) Rule
+
+var ruleFactories = map[string]ruleFactory{
+	"velocity":                newVelocityRule,
+	"amount_anomaly":          newAmountAnomalyRule,
+	"new_device_large_amount": newNewDeviceLargeAmountRule,
+	"topup_then_transfer":     newTopUpThenTransferRule,
+}
+
+func param(params map[string]float64, key string, fallback float64) float64 {
+	if value, ok := params[key]; ok && value > 0 {
+		return value
+	}
+	return fallback
+}
+
+func isDebit(operation string) bool {
+	for _, candidate := range debitOperations {
+		if candidate == operation {
+			return true
+		}
+	}
+	return false
+}
+
+// velocityRule: lebih dari MaxCount debit dalam Window.
+type velocityRule struct {
+	name     string
+	maxCount int64
+	window   time.Duration
+}
+
+func newVelocityRule(name string, params map[string]float64) Rule {
+	return &velocityRule{
+		name:     name,
+		maxCount: int64(param(params, "max_count", 5)),
+		window:   time.Duration(param(params, "window_minutes", 10)) * time.Minute,
+	}
+}
+
+func (r *velocityRule) Name() string { return r.name }
+
+func (r *velocityRule) Evaluate(event Event, history History) (bool, string, error) {
+	if !isDebit(event.Operation) {
+		return false, "", nil
+	}
+
+	count, err := history.CountEvents(event.UserID, debitOperations, time.Now().Add(-r.window))
+	if err != nil {
+		return false, "", err
+	}
+	if count+1 > r.maxCount {
+		return true, fmt.Sprintf("%d debit dalam %s terakhir", count+1, r.window), nil
+	}
+	return false, "", nil
+}
+
+// amountAnomalyRule: nominal jauh di atas rata-rata riwayat user.
+type amountAnomalyRule struct {
+	name       string
+	multiplier float64
+	minHistory int64
+	lookback   time.Duration
+}
+
+func newAmountAnomalyRule(name string, params map[string]float64) Rule {
+	return &amountAnomalyRule{
+		name:       name,
+		multiplier: param(params, "multiplier", 5),
+		minHistory: int64(param(params, "min_history", 5)),
+		lookback:   time.Duration(param(params, "lookback_days", 30)) * 24 * time.Hour,
+	}
+}
+
+func (r *amountAnomalyRule) Name() string { return r.name }
+
+func (r *amountAnomalyRule) Evaluate(event Event, history History) (bool, string, error) {
+	count, average, err := history.AmountStats(event.UserID, []string{event.Operation}, time.Now().Add(-r.lookback))
+	if err != nil {
+		return false, "", err
+	}
+	if count < r.minHistory || average <= 0 {
+		return false, "", nil
+	}
+	if event.Amount > average*r.multiplier {
+		return true, fmt.Sprintf("nominal %.2f lebih dari %.1fx rata-rata %.2f", event.Amount, r.multiplier, average), nil
+	}
+	return false, "", nil
+}
+
+// newDeviceLargeAmountRule: device yang belum pernah dipakai user dengan nominal besar.
+type newDeviceLargeAmountRule struct {
+	name      string
+	threshold float64
+}
+
+func newNewDeviceLargeAmountRule(name string, params map[string]float64) Rule {
+	return &newDeviceLargeAmountRule{
+		name:      name,
+		threshold: param(params, "threshold", 5000000),
+	}
+}
+
+func (r *newDeviceLargeAmountRule) Name() string { return r.name }
+
+func (r *newDeviceLargeAmountRule) Evaluate(event Event, history History) (bool, string, error) {
+	if event.Amount < r.threshold {
+		return false, "", nil
+	}
+	if event.DeviceID == "" {
+		return true, "device tidak dikenali untuk nominal besar", nil
+	}
+
+	known, err := history.IsKnownDevice(event.UserID, event.DeviceID)
+	if err != nil {
+		return false, "", err
+	}
+	if !known {
+		return true, fmt.Sprintf("device baru %s untuk nominal %.2f", event.DeviceID, event.Amount), nil
+	}
+	return false, "", nil
+}
+
+// topUpThenTransferRule: sebagian besar dana top up langsung dikeluarkan lagi.
+type topUpThenTransferRule struct {
+	name   string
+	window time.Duration
+	ratio  float64
+}
+
+func newTopUpThenTransferRule(name string, params map[string]float64) Rule {
+	return &topUpThenTransferRule{
+		name:   name,
+		window: time.Duration(param(params, "window_minutes", 30)) * time.Minute,
+		ratio:  param(params, "ratio", 0.8),
+	}
+}
+
+func (r *topUpThenTransferRule) Name() string { return r.name }
+
+func (r *topUpThenTransferRule) Evaluate(event Event, history History) (bool, string, error) {
+	if !isDebit(event.Operation) {
+		return false, "", nil
+	}
+
+	toppedUp, err := history.SumAmount(event.UserID, []string{OperationTopUp}, time.Now().Add(-r.window))
+	if err != nil {
+		return false, "", err
+	}
+	if toppedUp > 0 && event.Amount >= toppedUp*r.ratio {
+		return true, fmt.Sprintf("%.2f dikeluarkan setelah top up %.2f dalam %s", event.Amount, toppedUp, r.window), nil
+	}
+	return false, "", nil
+}
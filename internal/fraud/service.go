@@ -0,0 +1,156 @@
+package fraud
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+type FraudService interface {
+	Screen(event Event) (*Evaluation, error)
+	IsHeld(reference string) (bool, error)
+	ListCases(status CaseStatus) ([]FraudCase, error)
+	GetCase(id uint) (*FraudCase, error)
+	ClearCase(id uint, reviewerID uint, note string) (*FraudCase, error)
+	ConfirmCase(id uint, reviewerID uint, note string) (*FraudCase, error)
+	SetResolvers(onCleared CaseResolver, onConfirmed CaseResolver)
+	Rules() Config
+	ReloadRules() error
+}
+
+type fraudService struct {
+	repo        FraudRepository
+	engine      *Engine
+	onCleared   CaseResolver
+	onConfirmed CaseResolver
+}
+
+func NewFraudService(repo FraudRepository, engine *Engine) FraudService {
+	return &fraudService{repo: repo, engine: engine}
+}
+
+func (s *fraudService) SetResolvers(onCleared CaseResolver, onConfirmed CaseResolver) {
+	s.onCleared = onCleared
+	s.onConfirmed = onConfirmed
+}
+
+// Screen menilai event, mencatatnya sebagai riwayat, dan membuka case bila
+// keputusannya REVIEW atau BLOCK.
+func (s *fraudService) Screen(event Event) (*Evaluation, error) {
+	evaluation := s.engine.Evaluate(event, s.repo)
+
+	record := FraudEvent{
+		UserID:    event.UserID,
+		Operation: event.Operation,
+		Amount:    event.Amount,
+		Reference: event.Reference,
+		DeviceID:  event.DeviceID,
+		IP:        event.IP,
+		Decision:  evaluation.Decision,
+		Score:     evaluation.Score,
+	}
+	if err := s.repo.CreateEvent(&record); err != nil {
+		return nil, err
+	}
+
+	if evaluation.Decision == DecisionAllow {
+		return &evaluation, nil
+	}
+
+	status := CaseOpen
+	if evaluation.Decision == DecisionBlock {
+		status = CaseBlocked
+	}
+
+	fraudCase := FraudCase{
+		EventID:   record.ID,
+		UserID:    event.UserID,
+		Operation: event.Operation,
+		Amount:    event.Amount,
+		Reference: event.Reference,
+		Decision:  evaluation.Decision,
+		Score:     evaluation.Score,
+		Results:   evaluation.Results,
+		Status:    status,
+	}
+	if err := s.repo.CreateCase(&fraudCase); err != nil {
+		return nil, err
+	}
+	evaluation.CaseID = fraudCase.ID
+
+	log.Printf("ALERT: Event %s user_id %d ditandai %s (skor %d, case %d)", event.Operation, event.UserID, evaluation.Decision, evaluation.Score, fraudCase.ID)
+	return &evaluation, nil
+}
+
+func (s *fraudService) IsHeld(reference string) (bool, error) {
+	return s.repo.HasOpenCase(reference)
+}
+
+func (s *fraudService) ListCases(status CaseStatus) ([]FraudCase, error) {
+	return s.repo.ListCases(status)
+}
+
+func (s *fraudService) GetCase(id uint) (*FraudCase, error) {
+	fraudCase, err := s.repo.FindCaseByID(id)
+	if err != nil {
+		return nil, errors.New("case fraud tidak ditemukan")
+	}
+	return fraudCase, nil
+}
+
+func (s *fraudService) ClearCase(id uint, reviewerID uint, note string) (*FraudCase, error) {
+	return s.resolve(id, reviewerID, note, CaseCleared, s.onCleared)
+}
+
+func (s *fraudService) ConfirmCase(id uint, reviewerID uint, note string) (*FraudCase, error) {
+	if note == "" {
+		return nil, errors.New("catatan konfirmasi fraud wajib diisi")
+	}
+	return s.resolve(id, reviewerID, note, CaseConfirmed, s.onConfirmed)
+}
+
+func (s *fraudService) Rules() Config {
+	return s.engine.Config()
+}
+
+func (s *fraudService) ReloadRules() error {
+	return s.engine.Reload()
+}
+
+func (s *fraudService) resolve(id uint, reviewerID uint, note string, status CaseStatus, resolver CaseResolver) (*FraudCase, error) {
+	fraudCase, err := s.GetCase(id)
+	if err != nil {
+		return nil, err
+	}
+	if fraudCase.Status != CaseOpen {
+		return nil, fmt.Errorf("case sudah berstatus %s", fraudCase.Status)
+	}
+
+	ok, err := s.repo.TransitionCase(id, CaseOpen, map[string]interface{}{
+		"status":      status,
+		"reviewer_id": reviewerID,
+		"review_note": note,
+		"reviewed_at": time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("case sudah direview oleh operator lain")
+	}
+
+	fraudCase, err = s.repo.FindCaseByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if resolver != nil {
+		if err := resolver(*fraudCase); err != nil {
+			log.Printf("ERROR: Tindak lanjut case fraud %d gagal: %v", id, err)
+			return fraudCase, fmt.Errorf("case tersimpan tetapi tindak lanjut gagal: %w", err)
+		}
+	}
+
+	return fraudCase, nil
+}
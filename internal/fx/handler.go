@@ -0,0 +1,128 @@
+package fx
+
+import (
+	"errors"
+	"ewallet-engine/internal/audit"
+	"ewallet-engine/internal/balance"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type FXHandler struct {
+	service      FXService
+	auditService audit.AuditService
+}
+
+func NewFXHandler(service FXService, auditService audit.AuditService) *FXHandler {
+	return &FXHandler{service: service, auditService: auditService}
+}
+
+func (h *FXHandler) QuoteHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	var request struct {
+		FromCurrency string  `json:"from_currency"`
+		ToCurrency   string  `json:"to_currency"`
+		Amount       float64 `json:"amount"`
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	quote, err := h.service.CreateQuote(userID, request.FromCurrency, request.ToCurrency, request.Amount)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Quote berhasil dibuat",
+		"data":    quote,
+	})
+}
+
+func (h *FXHandler) ExecuteHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	var request struct {
+		QuoteID   string `json:"quote_id"`
+		Reference string `json:"reference"`
+	}
+
+	if err := c.BodyParser(&request); err != nil || request.QuoteID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "quote_id wajib diisi"})
+	}
+
+	quote, err := h.service.ExecuteQuote(userID, request.QuoteID, request.Reference)
+	if err != nil {
+		status := fiber.StatusBadRequest
+		switch {
+		case errors.Is(err, ErrQuoteNotFound):
+			status = fiber.StatusNotFound
+		case errors.Is(err, ErrQuoteExpired), errors.Is(err, ErrQuoteNotExecutable):
+			status = fiber.StatusConflict
+		case errors.Is(err, balance.ErrInsufficientBalance), errors.Is(err, balance.ErrWalletNotFound):
+			status = fiber.StatusUnprocessableEntity
+		}
+		return c.Status(status).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionFXExecuted,
+		TargetType: "fx_quote",
+		TargetID:   quote.QuoteID,
+		After: audit.Snapshot{
+			"from_currency": quote.FromCurrency,
+			"to_currency":   quote.ToCurrency,
+			"from_amount":   quote.FromAmount,
+			"to_amount":     quote.ToAmount,
+			"rate":          quote.Rate,
+			"reference":     quote.Reference,
+		},
+	})
+
+	return c.JSON(fiber.Map{
+		"message": "Konversi berhasil",
+		"data":    quote,
+	})
+}
+
+func (h *FXHandler) LedgerHandler(c *fiber.Ctx) error {
+	filter := LedgerFilter{
+		Currency: c.Query("currency"),
+		Limit:    c.QueryInt("limit", defaultLedgerLimit),
+	}
+
+	if user := c.Query("user_id"); user != "" {
+		userID, err := strconv.ParseUint(user, 10, 64)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "user_id tidak valid"})
+		}
+		filter.UserID = uint(userID)
+	}
+
+	for param, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": param + " harus berformat RFC3339"})
+		}
+		*target = &parsed
+	}
+
+	entries, summary, err := h.service.Ledger(filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"data":    entries,
+		"summary": summary,
+	})
+}
@@ -0,0 +1,71 @@
+package fx
+
+import "time"
+
+type QuoteStatus string
+
+const (
+	QuoteOpen     QuoteStatus = "OPEN"
+	QuoteExecuted QuoteStatus = "EXECUTED"
+)
+
+// FXQuote mengunci kurs konversi untuk user sampai ExpiresAt. Rate adalah kurs
+// yang diberikan ke user, yaitu MidRate dikurangi spread.
+type FXQuote struct {
+	ID           uint    `gorm:"primaryKey" json:"-"`
+	QuoteID      string  `gorm:"type:varchar(40);uniqueIndex;not null" json:"quote_id"`
+	UserID       uint    `gorm:"not null;index" json:"user_id"`
+	FromCurrency string  `gorm:"type:char(3);not null" json:"from_currency"`
+	ToCurrency   string  `gorm:"type:char(3);not null" json:"to_currency"`
+	FromAmount   float64 `gorm:"not null" json:"from_amount"`
+	ToAmount     float64 `gorm:"not null" json:"to_amount"`
+	MidRate      float64 `gorm:"not null" json:"mid_rate"`
+	Rate         float64 `gorm:"not null" json:"rate"`
+	SpreadBps    int     `gorm:"not null" json:"spread_bps"`
+	// BaseRate adalah kurs tengah ToCurrency ke DefaultCurrency saat quote dibuat,
+	// dipakai untuk menyatakan gain/loss di ledger.
+	BaseRate   float64     `gorm:"not null;default:1" json:"-"`
+	Provider   string      `gorm:"type:varchar(20);not null" json:"provider"`
+	Status     QuoteStatus `gorm:"type:enum('OPEN','EXECUTED');default:'OPEN'" json:"status"`
+	Reference  string      `gorm:"type:varchar(255);index" json:"reference,omitempty"`
+	ExpiresAt  time.Time   `gorm:"not null" json:"expires_at"`
+	ExecutedAt *time.Time  `json:"executed_at,omitempty"`
+	CreatedAt  time.Time   `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time   `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// FXLedgerEntry mencatat satu konversi yang dieksekusi beserta selisih terhadap
+// kurs tengah. GainLoss positif berarti keuntungan platform (spread dan
+// pembulatan), dinyatakan dalam ToCurrency dan dalam DefaultCurrency.
+type FXLedgerEntry struct {
+	ID                   uint      `gorm:"primaryKey" json:"id"`
+	QuoteID              string    `gorm:"type:varchar(40);uniqueIndex;not null" json:"quote_id"`
+	UserID               uint      `gorm:"not null;index" json:"user_id"`
+	Reference            string    `gorm:"type:varchar(255);not null" json:"reference"`
+	FromCurrency         string    `gorm:"type:char(3);not null" json:"from_currency"`
+	ToCurrency           string    `gorm:"type:char(3);not null" json:"to_currency"`
+	FromAmount           float64   `gorm:"not null" json:"from_amount"`
+	ToAmount             float64   `gorm:"not null" json:"to_amount"`
+	MidToAmount          float64   `gorm:"not null" json:"mid_to_amount"`
+	MidRate              float64   `gorm:"not null" json:"mid_rate"`
+	Rate                 float64   `gorm:"not null" json:"rate"`
+	GainLoss             float64   `gorm:"not null" json:"gain_loss"`
+	GainLossBase         float64   `gorm:"not null" json:"gain_loss_base"`
+	GainLossBaseCurrency string    `gorm:"type:char(3);not null" json:"gain_loss_base_currency"`
+	CreatedAt            time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// LedgerFilter membatasi hasil query ledger FX untuk admin.
+type LedgerFilter struct {
+	UserID   uint
+	Currency string
+	From     *time.Time
+	To       *time.Time
+	Limit    int
+}
+
+// LedgerSummary menjumlah gain/loss FX dalam DefaultCurrency.
+type LedgerSummary struct {
+	Entries      int64   `json:"entries"`
+	GainLossBase float64 `json:"gain_loss_base"`
+}
@@ -0,0 +1,221 @@
+package fx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// reloadCheckInterval membatasi seberapa sering mtime file kurs diperiksa.
+const reloadCheckInterval = 10 * time.Second
+
+var ErrRateUnavailable = errors.New("kurs untuk pasangan mata uang ini tidak tersedia")
+
+// RateProvider mengembalikan kurs tengah (mid rate), yaitu jumlah unit quote
+// untuk 1 unit base.
+type RateProvider interface {
+	Name() string
+	Rate(base string, quote string) (float64, error)
+}
+
+// RateTable adalah format file kurs statis sekaligus respons mock HTTP:
+// Rates berisi nilai 1 unit mata uang dalam mata uang Base.
+type RateTable struct {
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// Cross menghitung kurs base->quote lewat mata uang acuan tabel.
+func (t RateTable) Cross(base string, quote string) (float64, error) {
+	if base == quote {
+		return 1, nil
+	}
+
+	baseValue, ok := t.valueOf(base)
+	if !ok {
+		return 0, ErrRateUnavailable
+	}
+	quoteValue, ok := t.valueOf(quote)
+	if !ok {
+		return 0, ErrRateUnavailable
+	}
+	return baseValue / quoteValue, nil
+}
+
+func (t RateTable) valueOf(currency string) (float64, bool) {
+	if currency == t.Base {
+		return 1, true
+	}
+	value, ok := t.Rates[currency]
+	return value, ok && value > 0
+}
+
+// StaticFileProvider membaca tabel kurs dari file JSON dan memuat ulang
+// otomatis ketika file berubah.
+type StaticFileProvider struct {
+	path string
+
+	mu          sync.RWMutex
+	table       RateTable
+	modTime     time.Time
+	lastChecked time.Time
+}
+
+// NewStaticFileProvider tetap mengembalikan provider walaupun file gagal dimuat;
+// semua kurs tidak tersedia sampai file diperbaiki dan dimuat ulang otomatis.
+func NewStaticFileProvider(path string) *StaticFileProvider {
+	provider := &StaticFileProvider{path: path}
+	if err := provider.Reload(); err != nil {
+		log.Printf("ERROR: Gagal memuat kurs dari %s: %v", path, err)
+		provider.lastChecked = time.Now()
+	}
+	return provider
+}
+
+func (p *StaticFileProvider) Name() string {
+	return "file"
+}
+
+func (p *StaticFileProvider) Reload() error {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return err
+	}
+
+	table, err := readRateTable(p.path)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.table = table
+	p.modTime = info.ModTime()
+	p.lastChecked = time.Now()
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *StaticFileProvider) Rate(base string, quote string) (float64, error) {
+	p.reloadIfChanged()
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.table.Cross(base, quote)
+}
+
+func (p *StaticFileProvider) reloadIfChanged() {
+	p.mu.RLock()
+	due := time.Since(p.lastChecked) >= reloadCheckInterval
+	modTime := p.modTime
+	p.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	info, err := os.Stat(p.path)
+	if err != nil || !info.ModTime().After(modTime) {
+		p.mu.Lock()
+		p.lastChecked = time.Now()
+		p.mu.Unlock()
+		return
+	}
+
+	_ = p.Reload()
+}
+
+func readRateTable(path string) (RateTable, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return RateTable{}, err
+	}
+
+	var table RateTable
+	if err := json.Unmarshal(raw, &table); err != nil {
+		return RateTable{}, fmt.Errorf("format file kurs tidak valid: %w", err)
+	}
+	if table.Base == "" || len(table.Rates) == 0 {
+		return RateTable{}, errors.New("file kurs harus berisi base dan rates")
+	}
+	table.Base = strings.ToUpper(table.Base)
+	return table, nil
+}
+
+// HTTPRateProvider mengambil tabel kurs dari layanan HTTP yang mengembalikan
+// RateTable di GET {baseURL}/rates, misalnya cmd/fxmock. Tabel di-cache
+// selama cacheTTL supaya quote tidak selalu memanggil layanan kurs.
+type HTTPRateProvider struct {
+	baseURL  string
+	client   *http.Client
+	cacheTTL time.Duration
+
+	mu        sync.Mutex
+	table     RateTable
+	fetchedAt time.Time
+}
+
+func NewHTTPRateProvider(baseURL string, cacheTTL time.Duration) *HTTPRateProvider {
+	return &HTTPRateProvider{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		client:   &http.Client{Timeout: 5 * time.Second},
+		cacheTTL: cacheTTL,
+	}
+}
+
+func (p *HTTPRateProvider) Name() string {
+	return "http"
+}
+
+func (p *HTTPRateProvider) Rate(base string, quote string) (float64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.fetchedAt.IsZero() || time.Since(p.fetchedAt) >= p.cacheTTL {
+		table, err := p.fetch()
+		if err != nil {
+			// Kurs lama yang masih ada lebih baik daripada menolak semua quote,
+			// tapi hanya sampai dua kali umur cache.
+			if p.fetchedAt.IsZero() || time.Since(p.fetchedAt) >= 2*p.cacheTTL {
+				return 0, fmt.Errorf("gagal mengambil kurs: %w", err)
+			}
+		} else {
+			p.table = table
+			p.fetchedAt = time.Now()
+		}
+	}
+
+	return p.table.Cross(base, quote)
+}
+
+func (p *HTTPRateProvider) fetch() (RateTable, error) {
+	endpoint, err := url.JoinPath(p.baseURL, "rates")
+	if err != nil {
+		return RateTable{}, err
+	}
+
+	resp, err := p.client.Get(endpoint)
+	if err != nil {
+		return RateTable{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return RateTable{}, fmt.Errorf("layanan kurs mengembalikan status %d", resp.StatusCode)
+	}
+
+	var table RateTable
+	if err := json.NewDecoder(resp.Body).Decode(&table); err != nil {
+		return RateTable{}, err
+	}
+	if table.Base == "" || len(table.Rates) == 0 {
+		return RateTable{}, errors.New("respons layanan kurs tidak lengkap")
+	}
+	table.Base = strings.ToUpper(table.Base)
+	return table, nil
+}
@@ -0,0 +1,84 @@
+package fx
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var sampleTable = RateTable{
+	Base:  "IDR",
+	Rates: map[string]float64{"USD": 16000, "SGD": 12000},
+}
+
+func TestRateTableCross(t *testing.T) {
+	cases := []struct {
+		base, quote string
+		expected    float64
+	}{
+		{"USD", "IDR", 16000},
+		{"IDR", "USD", 1.0 / 16000},
+		{"USD", "SGD", 16000.0 / 12000},
+		{"USD", "USD", 1},
+	}
+
+	for _, tc := range cases {
+		got, err := sampleTable.Cross(tc.base, tc.quote)
+		if err != nil {
+			t.Fatalf("Cross(%s, %s): %v", tc.base, tc.quote, err)
+		}
+		if math.Abs(got-tc.expected) > 1e-12 {
+			t.Errorf("Cross(%s, %s) = %v; expected %v", tc.base, tc.quote, got, tc.expected)
+		}
+	}
+
+	if _, err := sampleTable.Cross("USD", "EUR"); err != ErrRateUnavailable {
+		t.Errorf("expected ErrRateUnavailable for unknown currency; got %v", err)
+	}
+}
+
+func TestPriceQuoteAppliesSpreadAndRoundsDown(t *testing.T) {
+	// 100 USD -> IDR pada kurs tengah 16000 dengan spread 1%.
+	rate, toAmount := PriceQuote(16000, 100, 100, "IDR")
+	if rate != 15840 || toAmount != 1584000 {
+		t.Errorf("expected rate 15840 and 1584000 IDR; got %v and %v", rate, toAmount)
+	}
+
+	// 100000 IDR -> USD: 100000 / 16000 * 0.99 = 6.1875, dibulatkan ke bawah ke sen.
+	_, toAmount = PriceQuote(1.0/16000, 100, 100000, "USD")
+	if toAmount != 6.18 {
+		t.Errorf("expected 6.18 USD; got %v", toAmount)
+	}
+}
+
+func TestHTTPRateProviderCachesTable(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path != "/rates" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(sampleTable)
+	}))
+	defer server.Close()
+
+	provider := NewHTTPRateProvider(server.URL, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		rate, err := provider.Rate("USD", "IDR")
+		if err != nil {
+			t.Fatalf("Rate: %v", err)
+		}
+		if rate != 16000 {
+			t.Errorf("expected 16000; got %v", rate)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("expected rate table to be fetched once; got %d calls", calls)
+	}
+}
@@ -0,0 +1,116 @@
+package fx
+
+import (
+	"ewallet-engine/internal/balance"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FXRepository interface {
+	CreateQuote(quote *FXQuote) error
+	FindQuote(quoteID string) (*FXQuote, error)
+	ExecuteQuote(quote *FXQuote, reference string, entry *FXLedgerEntry) error
+	QueryLedger(filter LedgerFilter) ([]FXLedgerEntry, error)
+	SummarizeLedger(filter LedgerFilter) (*LedgerSummary, error)
+}
+
+type fxRepository struct {
+	DB *gorm.DB
+}
+
+func NewFXRepository(db *gorm.DB) FXRepository {
+	return &fxRepository{DB: db}
+}
+
+func (r *fxRepository) CreateQuote(quote *FXQuote) error {
+	return r.DB.Create(quote).Error
+}
+
+func (r *fxRepository) FindQuote(quoteID string) (*FXQuote, error) {
+	var quote FXQuote
+	err := r.DB.Where("quote_id = ?", quoteID).First(&quote).Error
+	if err != nil {
+		return nil, err
+	}
+	return &quote, nil
+}
+
+// ExecuteQuote menandai quote EXECUTED, mendebit wallet asal, mengkredit wallet
+// tujuan dan mencatat ledger FX dalam satu transaksi database. Kedua wallet
+// dikunci dengan urutan id supaya dua konversi berlawanan arah tidak deadlock.
+func (r *fxRepository) ExecuteQuote(quote *FXQuote, reference string, entry *FXLedgerEntry) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&FXQuote{}).
+			Where("id = ? AND status = ? AND expires_at > ?", quote.ID, QuoteOpen, now).
+			Updates(map[string]interface{}{
+				"status":      QuoteExecuted,
+				"reference":   reference,
+				"executed_at": &now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return ErrQuoteNotExecutable
+		}
+
+		source, err := balance.FindUserWallet(tx, quote.UserID, quote.FromCurrency, false)
+		if err != nil {
+			return err
+		}
+		target, err := balance.FindUserWallet(tx, quote.UserID, quote.ToCurrency, true)
+		if err != nil {
+			return err
+		}
+
+		var locked []balance.Wallet
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", []uint{source.ID, target.ID}).Order("id ASC").Find(&locked).Error
+		if err != nil {
+			return err
+		}
+
+		if _, err := balance.ApplyWalletEntry(tx, source.ID, "DEBIT", quote.FromAmount, reference); err != nil {
+			return err
+		}
+		if _, err := balance.ApplyWalletEntry(tx, target.ID, "CREDIT", quote.ToAmount, reference); err != nil {
+			return err
+		}
+
+		return tx.Create(entry).Error
+	})
+}
+
+func (r *fxRepository) ledgerQuery(filter LedgerFilter) *gorm.DB {
+	query := r.DB.Model(&FXLedgerEntry{})
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Currency != "" {
+		query = query.Where("(from_currency = ? OR to_currency = ?)", filter.Currency, filter.Currency)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at <= ?", *filter.To)
+	}
+	return query
+}
+
+func (r *fxRepository) QueryLedger(filter LedgerFilter) ([]FXLedgerEntry, error) {
+	var entries []FXLedgerEntry
+	err := r.ledgerQuery(filter).Order("id DESC").Limit(filter.Limit).Find(&entries).Error
+	return entries, err
+}
+
+func (r *fxRepository) SummarizeLedger(filter LedgerFilter) (*LedgerSummary, error) {
+	var summary LedgerSummary
+	err := r.ledgerQuery(filter).
+		Select("COUNT(*) AS entries, COALESCE(SUM(gain_loss_base), 0) AS gain_loss_base").
+		Scan(&summary).Error
+	return &summary, err
+}
@@ -0,0 +1,207 @@
+package fx
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"ewallet-engine/internal/balance"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultSpreadBps   = 100
+	defaultQuoteTTL    = 60 * time.Second
+	defaultLedgerLimit = 100
+)
+
+var (
+	ErrQuoteNotFound      = errors.New("quote tidak ditemukan")
+	ErrQuoteExpired       = errors.New("quote sudah kedaluwarsa, silakan minta quote baru")
+	ErrQuoteNotExecutable = errors.New("quote sudah kedaluwarsa atau sudah dieksekusi")
+)
+
+type FXService interface {
+	CreateQuote(userID uint, fromCurrency string, toCurrency string, amount float64) (*FXQuote, error)
+	ExecuteQuote(userID uint, quoteID string, reference string) (*FXQuote, error)
+	Ledger(filter LedgerFilter) ([]FXLedgerEntry, *LedgerSummary, error)
+}
+
+type fxService struct {
+	repo        FXRepository
+	provider    RateProvider
+	creditGuard balance.CreditGuard
+	spreadBps   int
+	quoteTTL    time.Duration
+}
+
+func NewFXService(repo FXRepository, provider RateProvider, creditGuard balance.CreditGuard) FXService {
+	spreadBps := defaultSpreadBps
+	if bps, err := strconv.Atoi(os.Getenv("FX_SPREAD_BPS")); err == nil && bps >= 0 && bps < 10000 {
+		spreadBps = bps
+	}
+
+	quoteTTL := defaultQuoteTTL
+	if seconds, err := strconv.Atoi(os.Getenv("FX_QUOTE_TTL_SECONDS")); err == nil && seconds > 0 {
+		quoteTTL = time.Duration(seconds) * time.Second
+	}
+
+	return &fxService{
+		repo:        repo,
+		provider:    provider,
+		creditGuard: creditGuard,
+		spreadBps:   spreadBps,
+		quoteTTL:    quoteTTL,
+	}
+}
+
+// PriceQuote menghitung kurs user dan jumlah yang diterima untuk amount.
+// Jumlah tujuan dibulatkan ke bawah ke satuan terkecil mata uang tujuan
+// sehingga pembulatan tidak pernah merugikan platform.
+func PriceQuote(midRate float64, spreadBps int, amount float64, toCurrency string) (rate float64, toAmount float64) {
+	rate = midRate * (1 - float64(spreadBps)/10000)
+	scale := math.Pow10(balance.MinorUnits(toCurrency))
+	toAmount = math.Floor(amount*rate*scale+1e-9) / scale
+	return rate, toAmount
+}
+
+func (s *fxService) CreateQuote(userID uint, fromCurrency string, toCurrency string, amount float64) (*FXQuote, error) {
+	from, err := balance.NormalizeCurrency(fromCurrency)
+	if err != nil {
+		return nil, err
+	}
+	to, err := balance.NormalizeCurrency(toCurrency)
+	if err != nil {
+		return nil, err
+	}
+	if from == to {
+		return nil, errors.New("mata uang asal dan tujuan tidak boleh sama")
+	}
+	if err := balance.ValidateAmount(amount, from); err != nil {
+		return nil, err
+	}
+
+	midRate, err := s.provider.Rate(from, to)
+	if err != nil {
+		return nil, err
+	}
+	baseRate, err := s.provider.Rate(to, balance.DefaultCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	rate, toAmount := PriceQuote(midRate, s.spreadBps, amount, to)
+	if toAmount <= 0 {
+		return nil, errors.New("jumlah terlalu kecil untuk dikonversi")
+	}
+
+	quoteID, err := newQuoteID()
+	if err != nil {
+		return nil, err
+	}
+
+	quote := FXQuote{
+		QuoteID:      quoteID,
+		UserID:       userID,
+		FromCurrency: from,
+		ToCurrency:   to,
+		FromAmount:   amount,
+		ToAmount:     toAmount,
+		MidRate:      midRate,
+		Rate:         rate,
+		SpreadBps:    s.spreadBps,
+		BaseRate:     baseRate,
+		Provider:     s.provider.Name(),
+		Status:       QuoteOpen,
+		ExpiresAt:    time.Now().Add(s.quoteTTL),
+	}
+
+	if err := s.repo.CreateQuote(&quote); err != nil {
+		return nil, err
+	}
+	return &quote, nil
+}
+
+func (s *fxService) ExecuteQuote(userID uint, quoteID string, reference string) (*FXQuote, error) {
+	quote, err := s.repo.FindQuote(quoteID)
+	if err != nil || quote.UserID != userID {
+		return nil, ErrQuoteNotFound
+	}
+	if quote.Status != QuoteOpen {
+		return nil, ErrQuoteNotExecutable
+	}
+	if !time.Now().Before(quote.ExpiresAt) {
+		return nil, ErrQuoteExpired
+	}
+
+	if quote.ToCurrency == balance.DefaultCurrency {
+		if err := s.creditGuard.CheckCredit(userID, quote.ToAmount); err != nil {
+			return nil, err
+		}
+	}
+
+	reference = strings.TrimSpace(reference)
+	if reference == "" {
+		reference = quote.QuoteID
+	}
+
+	midToAmount := quote.FromAmount * quote.MidRate
+	gainLoss := midToAmount - quote.ToAmount
+	entry := FXLedgerEntry{
+		QuoteID:              quote.QuoteID,
+		UserID:               userID,
+		Reference:            reference,
+		FromCurrency:         quote.FromCurrency,
+		ToCurrency:           quote.ToCurrency,
+		FromAmount:           quote.FromAmount,
+		ToAmount:             quote.ToAmount,
+		MidToAmount:          midToAmount,
+		MidRate:              quote.MidRate,
+		Rate:                 quote.Rate,
+		GainLoss:             gainLoss,
+		GainLossBase:         gainLoss * quote.BaseRate,
+		GainLossBaseCurrency: balance.DefaultCurrency,
+	}
+
+	if err := s.repo.ExecuteQuote(quote, reference, &entry); err != nil {
+		if !errors.Is(err, ErrQuoteNotExecutable) && !errors.Is(err, balance.ErrInsufficientBalance) && !errors.Is(err, balance.ErrWalletNotFound) {
+			log.Printf("ERROR: Gagal mengeksekusi quote FX %s: %v", quote.QuoteID, err)
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	quote.Status = QuoteExecuted
+	quote.Reference = reference
+	quote.ExecutedAt = &now
+	return quote, nil
+}
+
+func (s *fxService) Ledger(filter LedgerFilter) ([]FXLedgerEntry, *LedgerSummary, error) {
+	if filter.Limit <= 0 || filter.Limit > defaultLedgerLimit {
+		filter.Limit = defaultLedgerLimit
+	}
+
+	entries, err := s.repo.QueryLedger(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	summary, err := s.repo.SummarizeLedger(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entries, summary, nil
+}
+
+func newQuoteID() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "FXQ-" + strings.ToUpper(hex.EncodeToString(buf)), nil
+}
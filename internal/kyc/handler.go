@@ -0,0 +1,157 @@
+package kyc
+
+import (
+	"ewallet-engine/internal/audit"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type KYCHandler struct {
+	service      KYCService
+	auditService audit.AuditService
+}
+
+func NewKYCHandler(service KYCService, auditService audit.AuditService) *KYCHandler {
+	return &KYCHandler{service: service, auditService: auditService}
+}
+
+func (h *KYCHandler) SubmitHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	var request SubmitRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	idDocument, closeID, err := openFormDocument(c, DocumentIDCard)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+	defer closeID()
+
+	selfie, closeSelfie, err := openFormDocument(c, DocumentSelfie)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+	defer closeSelfie()
+
+	submission, err := h.service.Submit(userID, request, idDocument, selfie)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionKYCSubmitted,
+		TargetType: "kyc_submission",
+		TargetID:   fmt.Sprint(submission.ID),
+		After:      audit.Snapshot{"status": submission.Status, "requested_tier": submission.RequestedTier},
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Pengajuan KYC berhasil dikirim",
+		"data":    submission,
+	})
+}
+
+func (h *KYCHandler) StatusHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	tier, submission, err := h.service.GetStatus(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": fiber.Map{
+			"tier":            tier,
+			"policy":          PolicyFor(tier),
+			"last_submission": submission,
+		},
+	})
+}
+
+func (h *KYCHandler) ListHandler(c *fiber.Ctx) error {
+	status := SubmissionStatus(c.Query("status", string(StatusPending)))
+
+	submissions, err := h.service.ListSubmissions(status)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": submissions})
+}
+
+func (h *KYCHandler) DocumentHandler(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	document, err := h.service.OpenDocument(uint(id), c.Params("kind"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.SendStream(document)
+}
+
+func (h *KYCHandler) ApproveHandler(c *fiber.Ctx) error {
+	return h.review(c, h.service.Approve, audit.ActionKYCApproved, "Pengajuan KYC disetujui")
+}
+
+func (h *KYCHandler) RejectHandler(c *fiber.Ctx) error {
+	return h.review(c, h.service.Reject, audit.ActionKYCRejected, "Pengajuan KYC ditolak")
+}
+
+func (h *KYCHandler) review(c *fiber.Ctx, decision func(id uint, reviewerID uint, note string) (*KYCSubmission, error), action string, successMessage string) error {
+	reviewerID := c.Locals("user_id").(uint)
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	var request struct {
+		Note string `json:"note"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+	}
+
+	submission, err := decision(uint(id), reviewerID, request.Note)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     action,
+		TargetType: "kyc_submission",
+		TargetID:   fmt.Sprint(submission.ID),
+		Before:     audit.Snapshot{"status": StatusPending},
+		After:      audit.Snapshot{"status": submission.Status, "user_id": submission.UserID, "note": submission.ReviewNote},
+	})
+
+	return c.JSON(fiber.Map{
+		"message": successMessage,
+		"data":    submission,
+	})
+}
+
+func openFormDocument(c *fiber.Ctx, field string) (Document, func(), error) {
+	header, err := c.FormFile(field)
+	if err != nil {
+		return Document{}, func() {}, fmt.Errorf("dokumen %s wajib diunggah", field)
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return Document{}, func() {}, fmt.Errorf("gagal membaca dokumen %s", field)
+	}
+
+	return Document{Filename: header.Filename, Size: header.Size, Content: file}, func() { file.Close() }, nil
+}
@@ -0,0 +1,69 @@
+package kyc
+
+import (
+	"ewallet-engine/internal/auth"
+	"io"
+	"time"
+)
+
+type SubmissionStatus string
+
+const (
+	StatusPending  SubmissionStatus = "PENDING"
+	StatusApproved SubmissionStatus = "APPROVED"
+	StatusRejected SubmissionStatus = "REJECTED"
+)
+
+const (
+	DocumentIDCard = "id_document"
+	DocumentSelfie = "selfie"
+)
+
+type KYCSubmission struct {
+	ID            uint             `gorm:"primaryKey" json:"id"`
+	UserID        uint             `gorm:"not null;index" json:"user_id"`
+	RequestedTier string           `gorm:"type:varchar(20);not null" json:"requested_tier"`
+	Status        SubmissionStatus `gorm:"type:enum('PENDING','APPROVED','REJECTED');default:'PENDING';index" json:"status"`
+	FullName      string           `gorm:"type:varchar(255);not null" json:"full_name"`
+	IDNumber      string           `gorm:"type:varchar(32);not null" json:"id_number"`
+	IDDocumentKey string           `gorm:"type:varchar(255);not null" json:"-"`
+	SelfieKey     string           `gorm:"type:varchar(255);not null" json:"-"`
+	ReviewerID    *uint            `json:"reviewer_id,omitempty"`
+	ReviewNote    string           `gorm:"type:varchar(255)" json:"review_note,omitempty"`
+	ReviewedAt    *time.Time       `json:"reviewed_at,omitempty"`
+	CreatedAt     time.Time        `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time        `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+type SubmitRequest struct {
+	FullName string `json:"full_name" form:"full_name"`
+	IDNumber string `json:"id_number" form:"id_number"`
+}
+
+// Document adalah berkas unggahan yang akan disimpan ke BlobStore.
+type Document struct {
+	Filename string
+	Size     int64
+	Content  io.Reader
+}
+
+// TierPolicy membatasi saldo maksimum dan volume dana masuk per bulan.
+type TierPolicy struct {
+	Tier          string  `json:"tier"`
+	MaxBalance    float64 `json:"max_balance"`
+	MonthlyVolume float64 `json:"monthly_volume"`
+}
+
+// tierPolicies mengikuti batas uang elektronik Bank Indonesia untuk akun
+// unregistered dan registered.
+var tierPolicies = map[string]TierPolicy{
+	auth.KYCTierUnverified: {Tier: auth.KYCTierUnverified, MaxBalance: 2000000, MonthlyVolume: 20000000},
+	auth.KYCTierVerified:   {Tier: auth.KYCTierVerified, MaxBalance: 20000000, MonthlyVolume: 40000000},
+}
+
+func PolicyFor(tier string) TierPolicy {
+	if policy, ok := tierPolicies[tier]; ok {
+		return policy
+	}
+	return tierPolicies[auth.KYCTierUnverified]
+}
@@ -0,0 +1,112 @@
+package kyc
+
+import (
+	"errors"
+	"ewallet-engine/internal/auth"
+	"ewallet-engine/internal/balance"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type KYCRepository interface {
+	CreateSubmission(submission *KYCSubmission) error
+	FindByID(id uint) (*KYCSubmission, error)
+	FindLatestByUser(userID uint) (*KYCSubmission, error)
+	HasPendingSubmission(userID uint) (bool, error)
+	ListSubmissions(status SubmissionStatus) ([]KYCSubmission, error)
+	TransitionStatus(id uint, from SubmissionStatus, updates map[string]interface{}) (bool, error)
+	FindUserTier(userID uint) (string, error)
+	UpdateUserTier(userID uint, tier string) error
+	GetWalletBalance(userID uint) (float64, error)
+	SumCredits(userID uint, from time.Time, to time.Time) (float64, error)
+}
+
+type kycRepository struct {
+	DB *gorm.DB
+}
+
+func NewKYCRepository(db *gorm.DB) KYCRepository {
+	return &kycRepository{DB: db}
+}
+
+func (r *kycRepository) CreateSubmission(submission *KYCSubmission) error {
+	return r.DB.Create(submission).Error
+}
+
+func (r *kycRepository) FindByID(id uint) (*KYCSubmission, error) {
+	var submission KYCSubmission
+	err := r.DB.First(&submission, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &submission, nil
+}
+
+func (r *kycRepository) FindLatestByUser(userID uint) (*KYCSubmission, error) {
+	var submission KYCSubmission
+	err := r.DB.Where("user_id = ?", userID).Order("id DESC").First(&submission).Error
+	if err != nil {
+		return nil, err
+	}
+	return &submission, nil
+}
+
+func (r *kycRepository) HasPendingSubmission(userID uint) (bool, error) {
+	var count int64
+	err := r.DB.Model(&KYCSubmission{}).Where("user_id = ? AND status = ?", userID, StatusPending).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *kycRepository) ListSubmissions(status SubmissionStatus) ([]KYCSubmission, error) {
+	var submissions []KYCSubmission
+	query := r.DB.Order("created_at ASC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&submissions).Error
+	return submissions, err
+}
+
+func (r *kycRepository) TransitionStatus(id uint, from SubmissionStatus, updates map[string]interface{}) (bool, error) {
+	result := r.DB.Model(&KYCSubmission{}).Where("id = ? AND status = ?", id, from).Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *kycRepository) FindUserTier(userID uint) (string, error) {
+	var user auth.User
+	err := r.DB.Select("id", "kyc_tier").First(&user, userID).Error
+	if err != nil {
+		return "", err
+	}
+	return user.KYCTier, nil
+}
+
+func (r *kycRepository) UpdateUserTier(userID uint, tier string) error {
+	return r.DB.Model(&auth.User{}).Where("id = ?", userID).Update("kyc_tier", tier).Error
+}
+
+func (r *kycRepository) GetWalletBalance(userID uint) (float64, error) {
+	wallet, err := balance.FindUserWallet(r.DB, userID, balance.DefaultCurrency, false)
+	if errors.Is(err, balance.ErrWalletNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return wallet.Balance, nil
+}
+
+func (r *kycRepository) SumCredits(userID uint, from time.Time, to time.Time) (float64, error) {
+	var total float64
+	err := r.DB.Model(&balance.WalletTransaction{}).
+		Joins("JOIN wallets ON wallets.id = wallet_transactions.wallet_id").
+		Where("wallets.user_id = ? AND wallets.currency = ? AND wallet_transactions.wallet_transaction_type = ?", userID, balance.DefaultCurrency, "CREDIT").
+		Where("wallet_transactions.created_at >= ? AND wallet_transactions.created_at < ?", from, to).
+		Select("COALESCE(SUM(wallet_transactions.amount), 0)").
+		Scan(&total).Error
+	return total, err
+}
@@ -0,0 +1,238 @@
+package kyc
+
+import (
+	"bufio"
+	"errors"
+	"ewallet-engine/internal/auth"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const maxDocumentSize = 5 * 1024 * 1024
+
+var (
+	idNumberPattern      = regexp.MustCompile(`^[0-9]{16}$`)
+	allowedDocumentTypes = map[string]string{
+		"image/jpeg":      ".jpg",
+		"image/png":       ".png",
+		"application/pdf": ".pdf",
+	}
+)
+
+type KYCService interface {
+	Submit(userID uint, request SubmitRequest, idDocument Document, selfie Document) (*KYCSubmission, error)
+	GetStatus(userID uint) (string, *KYCSubmission, error)
+	ListSubmissions(status SubmissionStatus) ([]KYCSubmission, error)
+	GetSubmission(id uint) (*KYCSubmission, error)
+	OpenDocument(id uint, kind string) (io.ReadCloser, error)
+	Approve(id uint, reviewerID uint, note string) (*KYCSubmission, error)
+	Reject(id uint, reviewerID uint, note string) (*KYCSubmission, error)
+	TierOf(userID uint) string
+	CheckCredit(userID uint, amount float64) error
+}
+
+type kycService struct {
+	repo  KYCRepository
+	store BlobStore
+}
+
+func NewKYCService(repo KYCRepository, store BlobStore) KYCService {
+	return &kycService{repo: repo, store: store}
+}
+
+func (s *kycService) Submit(userID uint, request SubmitRequest, idDocument Document, selfie Document) (*KYCSubmission, error) {
+	request.FullName = strings.TrimSpace(request.FullName)
+	if request.FullName == "" {
+		return nil, errors.New("nama lengkap wajib diisi")
+	}
+	if !idNumberPattern.MatchString(request.IDNumber) {
+		return nil, errors.New("NIK harus 16 digit angka")
+	}
+
+	if s.TierOf(userID) == auth.KYCTierVerified {
+		return nil, errors.New("akun sudah terverifikasi")
+	}
+
+	pending, err := s.repo.HasPendingSubmission(userID)
+	if err != nil {
+		return nil, err
+	}
+	if pending {
+		return nil, errors.New("masih ada pengajuan KYC yang sedang direview")
+	}
+
+	idKey, err := s.storeDocument(userID, DocumentIDCard, idDocument)
+	if err != nil {
+		return nil, err
+	}
+	selfieKey, err := s.storeDocument(userID, DocumentSelfie, selfie)
+	if err != nil {
+		_ = s.store.Delete(idKey)
+		return nil, err
+	}
+
+	submission := KYCSubmission{
+		UserID:        userID,
+		RequestedTier: auth.KYCTierVerified,
+		Status:        StatusPending,
+		FullName:      request.FullName,
+		IDNumber:      request.IDNumber,
+		IDDocumentKey: idKey,
+		SelfieKey:     selfieKey,
+	}
+
+	if err := s.repo.CreateSubmission(&submission); err != nil {
+		_ = s.store.Delete(idKey)
+		_ = s.store.Delete(selfieKey)
+		return nil, err
+	}
+
+	return &submission, nil
+}
+
+func (s *kycService) GetStatus(userID uint) (string, *KYCSubmission, error) {
+	submission, err := s.repo.FindLatestByUser(userID)
+	if err != nil {
+		submission = nil
+	}
+	return s.TierOf(userID), submission, nil
+}
+
+func (s *kycService) ListSubmissions(status SubmissionStatus) ([]KYCSubmission, error) {
+	return s.repo.ListSubmissions(status)
+}
+
+func (s *kycService) GetSubmission(id uint) (*KYCSubmission, error) {
+	submission, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, errors.New("pengajuan KYC tidak ditemukan")
+	}
+	return submission, nil
+}
+
+func (s *kycService) OpenDocument(id uint, kind string) (io.ReadCloser, error) {
+	submission, err := s.GetSubmission(id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case DocumentIDCard:
+		return s.store.Get(submission.IDDocumentKey)
+	case DocumentSelfie:
+		return s.store.Get(submission.SelfieKey)
+	}
+	return nil, errors.New("jenis dokumen tidak valid")
+}
+
+func (s *kycService) Approve(id uint, reviewerID uint, note string) (*KYCSubmission, error) {
+	submission, err := s.decide(id, reviewerID, StatusApproved, note)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateUserTier(submission.UserID, submission.RequestedTier); err != nil {
+		return nil, fmt.Errorf("gagal memperbarui tier user: %w", err)
+	}
+
+	log.Printf("SUCCESS: KYC user_id %d disetujui, tier %s", submission.UserID, submission.RequestedTier)
+	return submission, nil
+}
+
+func (s *kycService) Reject(id uint, reviewerID uint, note string) (*KYCSubmission, error) {
+	if note == "" {
+		return nil, errors.New("alasan penolakan wajib diisi")
+	}
+	return s.decide(id, reviewerID, StatusRejected, note)
+}
+
+// TierOf dipakai juga sebagai limits.TierResolver.
+func (s *kycService) TierOf(userID uint) string {
+	tier, err := s.repo.FindUserTier(userID)
+	if err != nil || tier == "" {
+		return auth.KYCTierUnverified
+	}
+	return tier
+}
+
+// CheckCredit menolak kredit yang membuat saldo melewati batas tier atau
+// membuat dana masuk bulan ini melewati volume bulanan tier.
+func (s *kycService) CheckCredit(userID uint, amount float64) error {
+	policy := PolicyFor(s.TierOf(userID))
+
+	current, err := s.repo.GetWalletBalance(userID)
+	if err != nil {
+		return err
+	}
+	if current+amount > policy.MaxBalance {
+		return fmt.Errorf("saldo melebihi batas maksimum %.2f untuk akun %s", policy.MaxBalance, policy.Tier)
+	}
+
+	now := time.Now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	credited, err := s.repo.SumCredits(userID, monthStart, monthStart.AddDate(0, 1, 0))
+	if err != nil {
+		return err
+	}
+	if credited+amount > policy.MonthlyVolume {
+		return fmt.Errorf("dana masuk bulan ini melebihi batas %.2f untuk akun %s", policy.MonthlyVolume, policy.Tier)
+	}
+
+	return nil
+}
+
+func (s *kycService) decide(id uint, reviewerID uint, status SubmissionStatus, note string) (*KYCSubmission, error) {
+	submission, err := s.GetSubmission(id)
+	if err != nil {
+		return nil, err
+	}
+	if submission.Status != StatusPending {
+		return nil, fmt.Errorf("pengajuan sudah berstatus %s", submission.Status)
+	}
+	if submission.UserID == reviewerID {
+		return nil, errors.New("reviewer tidak boleh mereview pengajuannya sendiri")
+	}
+
+	now := time.Now()
+	ok, err := s.repo.TransitionStatus(id, StatusPending, map[string]interface{}{
+		"status":      status,
+		"reviewer_id": reviewerID,
+		"review_note": note,
+		"reviewed_at": now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("pengajuan sudah direview oleh operator lain")
+	}
+
+	return s.repo.FindByID(id)
+}
+
+func (s *kycService) storeDocument(userID uint, kind string, document Document) (string, error) {
+	if document.Content == nil || document.Size == 0 {
+		return "", fmt.Errorf("dokumen %s wajib diunggah", kind)
+	}
+	if document.Size > maxDocumentSize {
+		return "", fmt.Errorf("ukuran dokumen %s maksimal 5MB", kind)
+	}
+
+	reader := bufio.NewReader(document.Content)
+	head, _ := reader.Peek(512)
+	extension, ok := allowedDocumentTypes[http.DetectContentType(head)]
+	if !ok {
+		return "", fmt.Errorf("dokumen %s harus berupa JPG, PNG atau PDF", kind)
+	}
+
+	key := fmt.Sprintf("%d/%d-%s%s", userID, time.Now().UnixNano(), kind, extension)
+	if err := s.store.Put(key, io.LimitReader(reader, maxDocumentSize)); err != nil {
+		return "", fmt.Errorf("gagal menyimpan dokumen %s: %w", kind, err)
+	}
+	return key, nil
+}
@@ -0,0 +1,76 @@
+package kyc
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BlobStore menyimpan dokumen KYC. Implementasi lain (S3, GCS) cukup memenuhi
+// interface ini.
+type BlobStore interface {
+	Put(key string, content io.Reader) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+type localBlobStore struct {
+	baseDir string
+}
+
+func NewLocalBlobStore(baseDir string) BlobStore {
+	return &localBlobStore{baseDir: baseDir}
+}
+
+func (s *localBlobStore) Put(key string, content io.Reader) error {
+	path, err := s.resolve(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(file, content); err != nil {
+		file.Close()
+		os.Remove(path)
+		return err
+	}
+	return file.Close()
+}
+
+func (s *localBlobStore) Get(key string) (io.ReadCloser, error) {
+	path, err := s.resolve(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+func (s *localBlobStore) Delete(key string) error {
+	path, err := s.resolve(key)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// resolve menolak key yang keluar dari baseDir, misalnya "../../etc/passwd".
+func (s *localBlobStore) resolve(key string) (string, error) {
+	cleaned := filepath.Clean("/" + key)
+	path := filepath.Join(s.baseDir, cleaned)
+
+	base := filepath.Clean(s.baseDir) + string(os.PathSeparator)
+	if !strings.HasPrefix(path, base) {
+		return "", errors.New("key dokumen tidak valid")
+	}
+	return path, nil
+}
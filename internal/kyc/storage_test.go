@@ -0,0 +1,48 @@
+package kyc
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalBlobStoreRoundTrip(t *testing.T) {
+	store := NewLocalBlobStore(t.TempDir())
+
+	if err := store.Put("7/ktp.png", strings.NewReader("content")); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+
+	reader, err := store.Get("7/ktp.png")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	defer reader.Close()
+
+	body, _ := io.ReadAll(reader)
+	if string(body) != "content" {
+		t.Errorf("expected stored content; got %q", body)
+	}
+
+	if err := store.Put("7/ktp.png", strings.NewReader("other")); err == nil {
+		t.Error("expected existing document not to be overwritten")
+	}
+}
+
+func TestLocalBlobStoreStaysInsideBaseDir(t *testing.T) {
+	root := t.TempDir()
+	store := NewLocalBlobStore(filepath.Join(root, "kyc"))
+
+	if err := store.Put("../../escape.txt", strings.NewReader("x")); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "escape.txt")); err == nil {
+		t.Error("expected key to be confined to the base directory")
+	}
+	if _, err := os.Stat(filepath.Join(root, "kyc", "escape.txt")); err != nil {
+		t.Errorf("expected document inside base directory; got %v", err)
+	}
+}
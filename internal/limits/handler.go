@@ -0,0 +1,81 @@
+package limits
+
+import (
+	"ewallet-engine/internal/audit"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type LimitHandler struct {
+	service      LimitService
+	auditService audit.AuditService
+}
+
+func NewLimitHandler(service LimitService, auditService audit.AuditService) *LimitHandler {
+	return &LimitHandler{service: service, auditService: auditService}
+}
+
+func (h *LimitHandler) GetLimitsHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	statuses, err := h.service.GetLimits(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": statuses})
+}
+
+func (h *LimitHandler) ListRulesHandler(c *fiber.Ctx) error {
+	rules, err := h.service.ListRules()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": rules})
+}
+
+func (h *LimitHandler) SaveRuleHandler(c *fiber.Ctx) error {
+	var request LimitRule
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+	request.ID = 0
+
+	previous, rule, err := h.service.SaveRule(request)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	entry := audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionLimitRuleSaved,
+		TargetType: "limit_rule",
+		TargetID:   fmt.Sprint(rule.ID),
+		After:      ruleSnapshot(*rule),
+	}
+	if previous != nil {
+		entry.Before = ruleSnapshot(*previous)
+	}
+	_ = h.auditService.Record(entry)
+
+	return c.JSON(fiber.Map{
+		"message": "Rule limit berhasil disimpan",
+		"data":    rule,
+	})
+}
+
+func ruleSnapshot(rule LimitRule) audit.Snapshot {
+	snapshot := audit.Snapshot{
+		"tier":            rule.Tier,
+		"operation":       rule.Operation,
+		"per_transaction": rule.PerTransaction,
+		"daily":           rule.Daily,
+		"monthly":         rule.Monthly,
+	}
+	if rule.UserID != nil {
+		snapshot["user_id"] = *rule.UserID
+	}
+	return snapshot
+}
@@ -0,0 +1,65 @@
+package limits
+
+import (
+	"time"
+)
+
+type Operation string
+
+const (
+	OperationDebit    Operation = "DEBIT"
+	OperationTransfer Operation = "TRANSFER"
+	OperationTopUp    Operation = "TOPUP"
+)
+
+var Operations = []Operation{OperationDebit, OperationTransfer, OperationTopUp}
+
+// DefaultTier dipakai untuk user yang belum memiliki tier.
+const DefaultTier = "DEFAULT"
+
+// LimitRule mengatur batas per transaksi, harian dan bulanan untuk satu operasi.
+// Rule dengan UserID berlaku khusus untuk user tersebut dan mengalahkan rule tier.
+// Nilai 0 berarti tidak dibatasi.
+type LimitRule struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	Tier           string    `gorm:"type:varchar(30);index:idx_limit_rule_scope" json:"tier,omitempty"`
+	UserID         *uint     `gorm:"index:idx_limit_rule_scope" json:"user_id,omitempty"`
+	Operation      Operation `gorm:"type:varchar(20);not null;index:idx_limit_rule_scope" json:"operation"`
+	PerTransaction float64   `gorm:"not null;default:0" json:"per_transaction"`
+	Daily          float64   `gorm:"not null;default:0" json:"daily"`
+	Monthly        float64   `gorm:"not null;default:0" json:"monthly"`
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// LimitUsage adalah catatan pemakaian limit yang menjadi sumber kebenaran saat
+// counter Redis tidak tersedia.
+type LimitUsage struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;index:idx_limit_usage_window" json:"user_id"`
+	Operation Operation `gorm:"type:varchar(20);not null;index:idx_limit_usage_window" json:"operation"`
+	Amount    float64   `gorm:"not null" json:"amount"`
+	Reference string    `gorm:"type:varchar(255);not null" json:"reference"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index:idx_limit_usage_window" json:"created_at"`
+}
+
+type LimitStatus struct {
+	Operation        Operation `json:"operation"`
+	PerTransaction   float64   `json:"per_transaction"`
+	Daily            float64   `json:"daily"`
+	DailyUsed        float64   `json:"daily_used"`
+	DailyRemaining   *float64  `json:"daily_remaining"`
+	Monthly          float64   `json:"monthly"`
+	MonthlyUsed      float64   `json:"monthly_used"`
+	MonthlyRemaining *float64  `json:"monthly_remaining"`
+}
+
+// TierResolver mengembalikan tier user yang dipakai untuk memilih LimitRule.
+type TierResolver func(userID uint) string
+
+// defaultRules dipakai bila belum ada rule di database untuk tier/operasi tersebut.
+var defaultRules = map[Operation]LimitRule{
+	OperationDebit:    {Operation: OperationDebit, PerTransaction: 10000000, Daily: 20000000, Monthly: 100000000},
+	OperationTransfer: {Operation: OperationTransfer, PerTransaction: 10000000, Daily: 20000000, Monthly: 100000000},
+	OperationTopUp:    {Operation: OperationTopUp, PerTransaction: 10000000, Daily: 20000000, Monthly: 100000000},
+}
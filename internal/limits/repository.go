@@ -0,0 +1,178 @@
+package limits
+
+import (
+	"context"
+	"errors"
+	"ewallet-engine/internal/database"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// incrementIfExists hanya menambah counter yang sudah ada, supaya counter yang
+// kedaluwarsa dibangun ulang dari database alih-alih mulai dari nol.
+var incrementIfExists = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return redis.call("INCRBYFLOAT", KEYS[1], ARGV[1])
+end
+return false
+`)
+
+// reserveWithinLimits menambah semua counter sekaligus hanya jika tidak ada
+// yang melewati batasnya (ARGV[i+1], 0 berarti tanpa batas). Hasil 0 berarti
+// berhasil, -i berarti counter ke-i belum ada dan perlu dibangun ulang, dan i
+// berarti counter ke-i akan melewati batas.
+var reserveWithinLimits = redis.NewScript(`
+local amount = tonumber(ARGV[1])
+for i, key in ipairs(KEYS) do
+	local current = redis.call("GET", key)
+	if not current then
+		return -i
+	end
+	local limit = tonumber(ARGV[i + 1])
+	if limit > 0 and tonumber(current) + amount > limit then
+		return i
+	end
+end
+for _, key in ipairs(KEYS) do
+	redis.call("INCRBYFLOAT", key, ARGV[1])
+end
+return 0
+`)
+
+type LimitRepository interface {
+	FindUserRule(userID uint, operation Operation) (*LimitRule, error)
+	FindTierRule(tier string, operation Operation) (*LimitRule, error)
+	ListRules() ([]LimitRule, error)
+	SaveRule(rule *LimitRule) error
+	CreateUsage(usage *LimitUsage) error
+	FindUsage(userID uint, reference string) (*LimitUsage, error)
+	DeleteUsage(id uint) (bool, error)
+	SumUsage(userID uint, operation Operation, from time.Time, to time.Time) (float64, error)
+	GetCounter(key string) (float64, bool, error)
+	InitCounter(key string, value float64, ttl time.Duration) error
+	IncrementCounter(key string, amount float64) error
+	ReserveCounters(keys []string, limits []float64, amount float64) (int, error)
+}
+
+type limitRepository struct {
+	DB    *gorm.DB
+	Redis *redis.Client
+}
+
+func NewLimitRepository(dbService database.Service) LimitRepository {
+	return &limitRepository{DB: dbService.GetDB(),
+		Redis: dbService.GetRedis(),
+	}
+}
+
+func (r *limitRepository) FindUserRule(userID uint, operation Operation) (*LimitRule, error) {
+	var rule LimitRule
+	err := r.DB.Where("user_id = ? AND operation = ?", userID, operation).First(&rule).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *limitRepository) FindTierRule(tier string, operation Operation) (*LimitRule, error) {
+	var rule LimitRule
+	err := r.DB.Where("user_id IS NULL AND tier = ? AND operation = ?", tier, operation).First(&rule).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *limitRepository) ListRules() ([]LimitRule, error) {
+	var rules []LimitRule
+	err := r.DB.Order("tier ASC, user_id ASC, operation ASC").Find(&rules).Error
+	return rules, err
+}
+
+func (r *limitRepository) SaveRule(rule *LimitRule) error {
+	query := r.DB.Where("operation = ?", rule.Operation)
+	if rule.UserID != nil {
+		query = query.Where("user_id = ?", *rule.UserID)
+	} else {
+		query = query.Where("user_id IS NULL AND tier = ?", rule.Tier)
+	}
+
+	var existing LimitRule
+	err := query.First(&existing).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if err == nil {
+		rule.ID = existing.ID
+		rule.CreatedAt = existing.CreatedAt
+	}
+
+	return r.DB.Save(rule).Error
+}
+
+func (r *limitRepository) CreateUsage(usage *LimitUsage) error {
+	return r.DB.Create(usage).Error
+}
+
+func (r *limitRepository) FindUsage(userID uint, reference string) (*LimitUsage, error) {
+	var usage LimitUsage
+	err := r.DB.Where("user_id = ? AND reference = ?", userID, reference).First(&usage).Error
+	if err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
+// DeleteUsage mengembalikan false jika baris sudah dihapus oleh pemanggil lain.
+func (r *limitRepository) DeleteUsage(id uint) (bool, error) {
+	result := r.DB.Delete(&LimitUsage{}, id)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *limitRepository) SumUsage(userID uint, operation Operation, from time.Time, to time.Time) (float64, error) {
+	var total float64
+	err := r.DB.Model(&LimitUsage{}).
+		Where("user_id = ? AND operation = ? AND created_at >= ? AND created_at < ?", userID, operation, from, to).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
+func (r *limitRepository) GetCounter(key string) (float64, bool, error) {
+	value, err := r.Redis.Get(context.Background(), key).Float64()
+	if errors.Is(err, redis.Nil) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return value, true, nil
+}
+
+// InitCounter hanya membuat counter yang belum ada, sehingga hasil rebuild dari
+// database tidak menimpa counter yang sudah dibuat dan ditambah proses lain.
+func (r *limitRepository) InitCounter(key string, value float64, ttl time.Duration) error {
+	return r.Redis.SetNX(context.Background(), key, value, ttl).Err()
+}
+
+func (r *limitRepository) IncrementCounter(key string, amount float64) error {
+	err := incrementIfExists.Run(context.Background(), r.Redis, []string{key}, amount).Err()
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	return err
+}
+
+func (r *limitRepository) ReserveCounters(keys []string, limits []float64, amount float64) (int, error) {
+	args := make([]interface{}, 0, len(limits)+1)
+	args = append(args, amount)
+	for _, limit := range limits {
+		args = append(args, limit)
+	}
+	return reserveWithinLimits.Run(context.Background(), r.Redis, keys, args...).Int()
+}
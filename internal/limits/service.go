@@ -0,0 +1,358 @@
+package limits
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type LimitService interface {
+	Check(userID uint, operation Operation, amount float64) error
+	Reserve(userID uint, operation Operation, amount float64, reference string) (bool, error)
+	Release(userID uint, operation Operation, reference string) error
+	GetLimits(userID uint) ([]LimitStatus, error)
+	ListRules() ([]LimitRule, error)
+	SaveRule(rule LimitRule) (*LimitRule, *LimitRule, error)
+}
+
+// ErrReservationMismatch dikembalikan jika reference sudah memakai limit untuk
+// operasi atau jumlah yang berbeda.
+var ErrReservationMismatch = errors.New("reference sudah dipakai untuk transaksi lain")
+
+type limitService struct {
+	repo         LimitRepository
+	tierResolver TierResolver
+}
+
+func NewLimitService(repo LimitRepository, tierResolver TierResolver) LimitService {
+	if tierResolver == nil {
+		tierResolver = func(userID uint) string { return DefaultTier }
+	}
+	return &limitService{repo: repo, tierResolver: tierResolver}
+}
+
+// Check memeriksa sisa limit tanpa memakainya. Hasilnya bisa basi saat
+// transaksi diproses; pemakaian yang mengikat selalu lewat Reserve.
+func (s *limitService) Check(userID uint, operation Operation, amount float64) error {
+	rule := s.resolveRule(userID, operation)
+
+	if rule.PerTransaction > 0 && amount > rule.PerTransaction {
+		return fmt.Errorf("jumlah melebihi limit per transaksi %.2f", rule.PerTransaction)
+	}
+
+	now := time.Now()
+	if rule.Daily > 0 {
+		used, err := s.usage(userID, operation, dailyWindow(now))
+		if err != nil {
+			return err
+		}
+		if used+amount > rule.Daily {
+			return exceeded(dailyWindow(now), rule.Daily, used)
+		}
+	}
+
+	if rule.Monthly > 0 {
+		used, err := s.usage(userID, operation, monthlyWindow(now))
+		if err != nil {
+			return err
+		}
+		if used+amount > rule.Monthly {
+			return exceeded(monthlyWindow(now), rule.Monthly, used)
+		}
+	}
+
+	return nil
+}
+
+// Reserve memakai limit untuk reference secara atomik: counter harian dan
+// bulanan hanya ditambah jika keduanya masih di bawah batas. Reserve ulang
+// dengan reference, operasi dan jumlah yang sama tidak memakai limit dua kali
+// dan mengembalikan created false; pemanggil hanya boleh memanggil Release
+// untuk reservasi yang dibuatnya sendiri.
+func (s *limitService) Reserve(userID uint, operation Operation, amount float64, reference string) (bool, error) {
+	rule := s.resolveRule(userID, operation)
+	if rule.PerTransaction > 0 && amount > rule.PerTransaction {
+		return false, fmt.Errorf("jumlah melebihi limit per transaksi %.2f", rule.PerTransaction)
+	}
+
+	if reference != "" {
+		existing, err := s.repo.FindUsage(userID, reference)
+		if err == nil {
+			if existing.Operation != operation || existing.Amount != amount {
+				return false, ErrReservationMismatch
+			}
+			return false, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, err
+		}
+	}
+
+	now := time.Now()
+	windows := []window{dailyWindow(now), monthlyWindow(now)}
+	counted, err := s.reserveCounters(userID, operation, windows, []float64{rule.Daily, rule.Monthly}, amount)
+	if err != nil {
+		return false, err
+	}
+
+	usage := LimitUsage{
+		UserID:    userID,
+		Operation: operation,
+		Amount:    amount,
+		Reference: reference,
+		CreatedAt: now,
+	}
+	if err := s.repo.CreateUsage(&usage); err != nil {
+		if counted {
+			s.adjustCounters(userID, operation, windows, -amount)
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Release mengembalikan limit yang dipakai reference, misalnya saat transaksi
+// gagal setelah Reserve. Counter yang dikurangi adalah window saat Reserve.
+// Pemakaian tanpa reference tidak bisa dikembalikan.
+func (s *limitService) Release(userID uint, operation Operation, reference string) error {
+	if reference == "" {
+		return nil
+	}
+	usage, err := s.repo.FindUsage(userID, reference)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if usage.Operation != operation {
+		return ErrReservationMismatch
+	}
+
+	deleted, err := s.repo.DeleteUsage(usage.ID)
+	if err != nil || !deleted {
+		return err
+	}
+	s.adjustCounters(userID, operation, []window{dailyWindow(usage.CreatedAt), monthlyWindow(usage.CreatedAt)}, -usage.Amount)
+	return nil
+}
+
+// reserveCounters menjalankan reservasi atomik di Redis dan membangun ulang
+// counter yang belum ada dari database. Jika Redis tidak tersedia, batas
+// diperiksa dari limit_usages dan counted bernilai false.
+func (s *limitService) reserveCounters(userID uint, operation Operation, windows []window, caps []float64, amount float64) (bool, error) {
+	keys := make([]string, len(windows))
+	for i, w := range windows {
+		keys[i] = w.key(userID, operation)
+	}
+
+	for attempt := 0; attempt <= len(windows); attempt++ {
+		code, err := s.repo.ReserveCounters(keys, caps, amount)
+		if err != nil {
+			log.Printf("ERROR: Counter limit Redis tidak tersedia, memakai database: %v", err)
+			return false, s.checkUsage(userID, operation, windows, caps, amount)
+		}
+		switch {
+		case code == 0:
+			return true, nil
+		case code > 0:
+			used, _ := s.usage(userID, operation, windows[code-1])
+			return false, exceeded(windows[code-1], caps[code-1], used)
+		default:
+			w := windows[-code-1]
+			total, err := s.repo.SumUsage(userID, operation, w.start, w.end)
+			if err != nil {
+				return false, err
+			}
+			if err := s.repo.InitCounter(keys[-code-1], total, time.Until(w.end)+time.Hour); err != nil {
+				return false, err
+			}
+		}
+	}
+	return false, errors.New("gagal memproses limit transaksi")
+}
+
+func (s *limitService) checkUsage(userID uint, operation Operation, windows []window, caps []float64, amount float64) error {
+	for i, w := range windows {
+		if caps[i] <= 0 {
+			continue
+		}
+		used, err := s.repo.SumUsage(userID, operation, w.start, w.end)
+		if err != nil {
+			return err
+		}
+		if used+amount > caps[i] {
+			return exceeded(w, caps[i], used)
+		}
+	}
+	return nil
+}
+
+func (s *limitService) adjustCounters(userID uint, operation Operation, windows []window, amount float64) {
+	for _, w := range windows {
+		if err := s.repo.IncrementCounter(w.key(userID, operation), amount); err != nil {
+			log.Printf("ERROR: Gagal memperbarui counter limit user_id %d: %v", userID, err)
+		}
+	}
+}
+
+func exceeded(w window, limit float64, used float64) error {
+	if w.name == "monthly" {
+		return fmt.Errorf("jumlah melebihi sisa limit bulanan %.2f", limit-used)
+	}
+	return fmt.Errorf("jumlah melebihi sisa limit harian %.2f", limit-used)
+}
+
+func (s *limitService) GetLimits(userID uint) ([]LimitStatus, error) {
+	now := time.Now()
+	statuses := make([]LimitStatus, 0, len(Operations))
+
+	for _, operation := range Operations {
+		rule := s.resolveRule(userID, operation)
+
+		dailyUsed, err := s.usage(userID, operation, dailyWindow(now))
+		if err != nil {
+			return nil, err
+		}
+		monthlyUsed, err := s.usage(userID, operation, monthlyWindow(now))
+		if err != nil {
+			return nil, err
+		}
+
+		statuses = append(statuses, LimitStatus{
+			Operation:        operation,
+			PerTransaction:   rule.PerTransaction,
+			Daily:            rule.Daily,
+			DailyUsed:        dailyUsed,
+			DailyRemaining:   remaining(rule.Daily, dailyUsed),
+			Monthly:          rule.Monthly,
+			MonthlyUsed:      monthlyUsed,
+			MonthlyRemaining: remaining(rule.Monthly, monthlyUsed),
+		})
+	}
+
+	return statuses, nil
+}
+
+func (s *limitService) ListRules() ([]LimitRule, error) {
+	return s.repo.ListRules()
+}
+
+// SaveRule membuat atau mengganti rule untuk scope user atau tier-nya, lalu
+// mengembalikan rule sebelumnya (nil bila belum ada) dan rule yang tersimpan.
+func (s *limitService) SaveRule(rule LimitRule) (*LimitRule, *LimitRule, error) {
+	if !isValidOperation(rule.Operation) {
+		return nil, nil, errors.New("operasi limit tidak valid")
+	}
+	if rule.UserID == nil && rule.Tier == "" {
+		return nil, nil, errors.New("rule harus memiliki tier atau user_id")
+	}
+	if rule.PerTransaction < 0 || rule.Daily < 0 || rule.Monthly < 0 {
+		return nil, nil, errors.New("nilai limit tidak boleh negatif")
+	}
+
+	var previous *LimitRule
+	var err error
+	if rule.UserID != nil {
+		previous, err = s.repo.FindUserRule(*rule.UserID, rule.Operation)
+	} else {
+		previous, err = s.repo.FindTierRule(rule.Tier, rule.Operation)
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, err
+	}
+
+	if err := s.repo.SaveRule(&rule); err != nil {
+		return nil, nil, err
+	}
+	return previous, &rule, nil
+}
+
+// resolveRule memilih rule user, lalu rule tier, lalu default bawaan.
+func (s *limitService) resolveRule(userID uint, operation Operation) LimitRule {
+	if rule, err := s.repo.FindUserRule(userID, operation); err == nil {
+		return *rule
+	}
+
+	tier := s.tierResolver(userID)
+	if rule, err := s.repo.FindTierRule(tier, operation); err == nil {
+		return *rule
+	}
+	if tier != DefaultTier {
+		if rule, err := s.repo.FindTierRule(DefaultTier, operation); err == nil {
+			return *rule
+		}
+	}
+
+	return defaultRules[operation]
+}
+
+// usage membaca counter Redis; jika counter tidak ada atau Redis bermasalah,
+// pemakaian dihitung dari tabel limit_usages lalu counter dibangun ulang.
+func (s *limitService) usage(userID uint, operation Operation, w window) (float64, error) {
+	key := w.key(userID, operation)
+
+	value, found, err := s.repo.GetCounter(key)
+	if err == nil && found {
+		return value, nil
+	}
+	if err != nil {
+		log.Printf("ERROR: Counter limit Redis tidak tersedia, memakai database: %v", err)
+	}
+
+	total, dbErr := s.repo.SumUsage(userID, operation, w.start, w.end)
+	if dbErr != nil {
+		return 0, dbErr
+	}
+
+	if err == nil {
+		if setErr := s.repo.InitCounter(key, total, time.Until(w.end)+time.Hour); setErr != nil {
+			log.Printf("ERROR: Gagal menyimpan counter limit %s: %v", key, setErr)
+		}
+	}
+
+	return total, nil
+}
+
+type window struct {
+	name  string
+	start time.Time
+	end   time.Time
+}
+
+func (w window) key(userID uint, operation Operation) string {
+	return fmt.Sprintf("limits:%d:%s:%s:%s", userID, operation, w.name, w.start.Format("20060102"))
+}
+
+func dailyWindow(now time.Time) window {
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return window{name: "daily", start: start, end: start.AddDate(0, 0, 1)}
+}
+
+func monthlyWindow(now time.Time) window {
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	return window{name: "monthly", start: start, end: start.AddDate(0, 1, 0)}
+}
+
+func remaining(limit float64, used float64) *float64 {
+	if limit <= 0 {
+		return nil
+	}
+	value := limit - used
+	if value < 0 {
+		value = 0
+	}
+	return &value
+}
+
+func isValidOperation(operation Operation) bool {
+	for _, candidate := range Operations {
+		if candidate == operation {
+			return true
+		}
+	}
+	return false
+}
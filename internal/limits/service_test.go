@@ -0,0 +1,258 @@
+package limits
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type fakeLimitRepository struct {
+	rules    []LimitRule
+	usages   []LimitUsage
+	counters map[string]float64
+	redisErr error
+}
+
+func (f *fakeLimitRepository) FindUserRule(userID uint, operation Operation) (*LimitRule, error) {
+	for _, rule := range f.rules {
+		if rule.UserID != nil && *rule.UserID == userID && rule.Operation == operation {
+			return &rule, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (f *fakeLimitRepository) FindTierRule(tier string, operation Operation) (*LimitRule, error) {
+	for _, rule := range f.rules {
+		if rule.UserID == nil && rule.Tier == tier && rule.Operation == operation {
+			return &rule, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (f *fakeLimitRepository) ListRules() ([]LimitRule, error) { return f.rules, nil }
+
+func (f *fakeLimitRepository) SaveRule(rule *LimitRule) error {
+	f.rules = append(f.rules, *rule)
+	return nil
+}
+
+func (f *fakeLimitRepository) CreateUsage(usage *LimitUsage) error {
+	usage.ID = uint(len(f.usages) + 1)
+	if usage.CreatedAt.IsZero() {
+		usage.CreatedAt = time.Now()
+	}
+	f.usages = append(f.usages, *usage)
+	return nil
+}
+
+func (f *fakeLimitRepository) FindUsage(userID uint, reference string) (*LimitUsage, error) {
+	for _, usage := range f.usages {
+		if usage.ID != 0 && usage.UserID == userID && usage.Reference == reference {
+			return &usage, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (f *fakeLimitRepository) DeleteUsage(id uint) (bool, error) {
+	for i, usage := range f.usages {
+		if usage.ID == id {
+			f.usages[i] = LimitUsage{}
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeLimitRepository) SumUsage(userID uint, operation Operation, from time.Time, to time.Time) (float64, error) {
+	total := 0.0
+	for _, usage := range f.usages {
+		if usage.UserID == userID && usage.Operation == operation && !usage.CreatedAt.Before(from) && usage.CreatedAt.Before(to) {
+			total += usage.Amount
+		}
+	}
+	return total, nil
+}
+
+func (f *fakeLimitRepository) GetCounter(key string) (float64, bool, error) {
+	if f.redisErr != nil {
+		return 0, false, f.redisErr
+	}
+	value, ok := f.counters[key]
+	return value, ok, nil
+}
+
+func (f *fakeLimitRepository) InitCounter(key string, value float64, ttl time.Duration) error {
+	if _, ok := f.counters[key]; !ok {
+		f.counters[key] = value
+	}
+	return nil
+}
+
+func (f *fakeLimitRepository) IncrementCounter(key string, amount float64) error {
+	if _, ok := f.counters[key]; ok {
+		f.counters[key] += amount
+	}
+	return nil
+}
+
+func (f *fakeLimitRepository) ReserveCounters(keys []string, limits []float64, amount float64) (int, error) {
+	if f.redisErr != nil {
+		return 0, f.redisErr
+	}
+	for i, key := range keys {
+		current, ok := f.counters[key]
+		if !ok {
+			return -(i + 1), nil
+		}
+		if limits[i] > 0 && current+amount > limits[i] {
+			return i + 1, nil
+		}
+	}
+	for _, key := range keys {
+		f.counters[key] += amount
+	}
+	return 0, nil
+}
+
+func TestCheckEnforcesDailyLimitAcrossReservations(t *testing.T) {
+	repo := &fakeLimitRepository{
+		rules:    []LimitRule{{Tier: DefaultTier, Operation: OperationDebit, PerTransaction: 100, Daily: 150}},
+		counters: map[string]float64{},
+	}
+	service := NewLimitService(repo, nil)
+
+	if err := service.Check(1, OperationDebit, 90); err != nil {
+		t.Fatalf("expected first debit to pass; got %v", err)
+	}
+	if err := service.Check(1, OperationDebit, 101); err == nil {
+		t.Error("expected per-transaction limit to reject 101")
+	}
+
+	if _, err := service.Reserve(1, OperationDebit, 100, "REF-1"); err != nil {
+		t.Fatalf("reserve usage: %v", err)
+	}
+	if err := service.Check(1, OperationDebit, 60); err == nil {
+		t.Error("expected daily limit to reject 60 after 100 used")
+	}
+	if err := service.Check(2, OperationDebit, 60); err != nil {
+		t.Errorf("expected other user to be unaffected; got %v", err)
+	}
+}
+
+func TestUsageFallsBackToDatabaseWhenRedisFails(t *testing.T) {
+	repo := &fakeLimitRepository{
+		rules:    []LimitRule{{Tier: DefaultTier, Operation: OperationTopUp, Daily: 100}},
+		counters: map[string]float64{},
+	}
+	service := NewLimitService(repo, nil)
+	_, _ = service.Reserve(1, OperationTopUp, 80, "REF-1")
+
+	repo.redisErr = errors.New("redis down")
+	if err := service.Check(1, OperationTopUp, 30); err == nil {
+		t.Error("expected database fallback to see 80 already used")
+	}
+}
+
+func TestUserRuleOverridesTierRule(t *testing.T) {
+	userID := uint(5)
+	repo := &fakeLimitRepository{
+		rules: []LimitRule{
+			{Tier: "PREMIUM", Operation: OperationDebit, PerTransaction: 100},
+			{UserID: &userID, Operation: OperationDebit, PerTransaction: 1000},
+		},
+		counters: map[string]float64{},
+	}
+	service := NewLimitService(repo, func(uint) string { return "PREMIUM" })
+
+	if err := service.Check(5, OperationDebit, 500); err != nil {
+		t.Errorf("expected user rule to allow 500; got %v", err)
+	}
+	if err := service.Check(6, OperationDebit, 500); err == nil {
+		t.Error("expected tier rule to reject 500 for other users")
+	}
+}
+
+func TestReserveRejectsOverLimitWithoutCounting(t *testing.T) {
+	repo := &fakeLimitRepository{
+		rules:    []LimitRule{{Tier: DefaultTier, Operation: OperationTransfer, Daily: 150}},
+		counters: map[string]float64{},
+	}
+	service := NewLimitService(repo, nil)
+
+	if _, err := service.Reserve(1, OperationTransfer, 100, "REF-1"); err != nil {
+		t.Fatalf("expected first reservation to pass; got %v", err)
+	}
+	if _, err := service.Reserve(1, OperationTransfer, 60, "REF-2"); err == nil {
+		t.Fatal("expected second reservation to exceed the daily limit")
+	}
+	if created, err := service.Reserve(1, OperationTransfer, 100, "REF-1"); err != nil || created {
+		t.Fatalf("expected repeated reference to reuse the reservation; got %v, %v", created, err)
+	}
+	if _, err := service.Reserve(1, OperationTransfer, 50, "REF-3"); err != nil {
+		t.Fatalf("expected rejected and repeated reservations not to use the limit; got %v", err)
+	}
+}
+
+func TestReleaseReturnsReservedLimit(t *testing.T) {
+	repo := &fakeLimitRepository{
+		rules:    []LimitRule{{Tier: DefaultTier, Operation: OperationDebit, Daily: 150}},
+		counters: map[string]float64{},
+	}
+	service := NewLimitService(repo, nil)
+
+	_, _ = service.Reserve(1, OperationDebit, 100, "REF-1")
+	if err := service.Release(1, OperationDebit, "REF-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := service.Release(1, OperationDebit, "REF-1"); err != nil {
+		t.Fatalf("expected second release to be a no-op; got %v", err)
+	}
+	if _, err := service.Reserve(1, OperationDebit, 150, "REF-2"); err != nil {
+		t.Fatalf("expected released limit to be available again; got %v", err)
+	}
+}
+
+func TestReserveRebuildsMissingCounterFromDatabase(t *testing.T) {
+	repo := &fakeLimitRepository{
+		rules:    []LimitRule{{Tier: DefaultTier, Operation: OperationDebit, Daily: 150}},
+		usages:   []LimitUsage{{ID: 1, UserID: 1, Operation: OperationDebit, Amount: 100, Reference: "REF-1", CreatedAt: time.Now()}},
+		counters: map[string]float64{},
+	}
+	service := NewLimitService(repo, nil)
+
+	if _, err := service.Reserve(1, OperationDebit, 60, "REF-2"); err == nil {
+		t.Fatal("expected rebuilt counter to include usage from the database")
+	}
+	if _, err := service.Reserve(1, OperationDebit, 50, "REF-3"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if used := repo.counters[dailyWindow(time.Now()).key(1, OperationDebit)]; used != 150 {
+		t.Fatalf("expected daily counter 150; got %.2f", used)
+	}
+}
+
+func TestReserveRejectsReferenceReusedForDifferentAmount(t *testing.T) {
+	repo := &fakeLimitRepository{
+		rules:    []LimitRule{{Tier: DefaultTier, Operation: OperationTopUp, Daily: 1000}},
+		counters: map[string]float64{},
+	}
+	service := NewLimitService(repo, nil)
+
+	if _, err := service.Reserve(1, OperationTopUp, 100, "TOP-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.Reserve(1, OperationTopUp, 900, "TOP-1"); !errors.Is(err, ErrReservationMismatch) {
+		t.Fatalf("expected ErrReservationMismatch for a different amount; got %v", err)
+	}
+	if _, err := service.Reserve(1, OperationDebit, 100, "TOP-1"); !errors.Is(err, ErrReservationMismatch) {
+		t.Fatalf("expected ErrReservationMismatch for a different operation; got %v", err)
+	}
+	if used := repo.counters[dailyWindow(time.Now()).key(1, OperationTopUp)]; used != 100 {
+		t.Fatalf("expected daily counter 100; got %.2f", used)
+	}
+}
@@ -0,0 +1,30 @@
+package loyalty
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// StartExpirer secara berkala menghanguskan poin yang melewati masa
+// berlakunya sampai ctx dibatalkan.
+func StartExpirer(ctx context.Context, service LoyaltyService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := service.ExpirePoints()
+			if err != nil {
+				log.Printf("ERROR: Gagal memproses poin kedaluwarsa: %v", err)
+				continue
+			}
+			if expired > 0 {
+				log.Printf("SUCCESS: Poin dari %d lot kedaluwarsa", expired)
+			}
+		}
+	}
+}
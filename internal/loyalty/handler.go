@@ -0,0 +1,312 @@
+package loyalty
+
+import (
+	"errors"
+	"ewallet-engine/internal/audit"
+	"ewallet-engine/internal/promotions"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type LoyaltyHandler struct {
+	service      LoyaltyService
+	auditService audit.AuditService
+}
+
+func NewLoyaltyHandler(service LoyaltyService, auditService audit.AuditService) *LoyaltyHandler {
+	return &LoyaltyHandler{service: service, auditService: auditService}
+}
+
+func (h *LoyaltyHandler) GetPointsHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	summary, err := h.service.Summary(userID, c.QueryInt("limit", defaultHistoryLimit))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": summary})
+}
+
+func (h *LoyaltyHandler) RedeemHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	var request struct {
+		Points    int64  `json:"points"`
+		Reference string `json:"reference"`
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	redemption, err := h.service.RedeemToWallet(userID, request.Points, request.Reference)
+	if err != nil {
+		return h.redeemError(c, err)
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionPointsRedeemed,
+		TargetType: "point_redemption",
+		TargetID:   redemption.Reference,
+		After:      redemptionSnapshot(redemption),
+	})
+
+	return c.JSON(fiber.Map{
+		"message": "Poin berhasil ditukar ke saldo",
+		"data":    redemption,
+	})
+}
+
+func (h *LoyaltyHandler) ListRewardsHandler(c *fiber.Ctx) error {
+	rewards, err := h.service.ListRewards(true)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": rewards})
+}
+
+func (h *LoyaltyHandler) RedeemRewardHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	var request struct {
+		Reference string `json:"reference"`
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	redemption, err := h.service.RedeemReward(userID, uint(id), request.Reference)
+	if err != nil {
+		return h.redeemError(c, err)
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionPointsRedeemed,
+		TargetType: "point_redemption",
+		TargetID:   redemption.Reference,
+		After:      redemptionSnapshot(redemption),
+	})
+
+	return c.JSON(fiber.Map{
+		"message": "Poin berhasil ditukar ke voucher",
+		"data":    redemption,
+	})
+}
+
+func (h *LoyaltyHandler) ListRulesHandler(c *fiber.Ctx) error {
+	rules, err := h.service.ListRules(c.QueryBool("active", false))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": rules})
+}
+
+func (h *LoyaltyHandler) CreateRuleHandler(c *fiber.Ctx) error {
+	actorID := c.Locals("user_id").(uint)
+
+	var request EarnRule
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	rule, err := h.service.CreateRule(actorID, request)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionEarnRuleCreated,
+		TargetType: "earn_rule",
+		TargetID:   fmt.Sprint(rule.ID),
+		After:      ruleSnapshot(rule),
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Aturan poin berhasil dibuat",
+		"data":    rule,
+	})
+}
+
+func (h *LoyaltyHandler) EndRuleHandler(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	var request struct {
+		EffectiveTo *time.Time `json:"effective_to"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+	}
+
+	at := time.Now()
+	if request.EffectiveTo != nil {
+		at = *request.EffectiveTo
+	}
+
+	before, after, err := h.service.EndRule(uint(id), at)
+	if err != nil {
+		if errors.Is(err, ErrRuleNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionEarnRuleEnded,
+		TargetType: "earn_rule",
+		TargetID:   fmt.Sprint(after.ID),
+		Before:     ruleSnapshot(before),
+		After:      ruleSnapshot(after),
+	})
+
+	return c.JSON(fiber.Map{
+		"message": "Aturan poin berhasil dihentikan",
+		"data":    after,
+	})
+}
+
+func (h *LoyaltyHandler) ListAllRewardsHandler(c *fiber.Ctx) error {
+	rewards, err := h.service.ListRewards(c.QueryBool("active", false))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": rewards})
+}
+
+func (h *LoyaltyHandler) CreateRewardHandler(c *fiber.Ctx) error {
+	actorID := c.Locals("user_id").(uint)
+
+	var request Reward
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	reward, err := h.service.CreateReward(actorID, request)
+	if err != nil {
+		if errors.Is(err, promotions.ErrCampaignNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionRewardCreated,
+		TargetType: "reward",
+		TargetID:   fmt.Sprint(reward.ID),
+		After:      rewardSnapshot(reward),
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Reward berhasil dibuat",
+		"data":    reward,
+	})
+}
+
+func (h *LoyaltyHandler) SetRewardStatusHandler(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	var request struct {
+		Active bool `json:"active"`
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	before, reward, err := h.service.SetRewardActive(uint(id), request.Active)
+	if err != nil {
+		if errors.Is(err, ErrRewardNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionRewardStatusChanged,
+		TargetType: "reward",
+		TargetID:   fmt.Sprint(reward.ID),
+		Before:     rewardSnapshot(before),
+		After:      rewardSnapshot(reward),
+	})
+
+	return c.JSON(fiber.Map{
+		"message": "Status reward berhasil diperbarui",
+		"data":    reward,
+	})
+}
+
+func (h *LoyaltyHandler) redeemError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, ErrRewardNotFound), errors.Is(err, promotions.ErrCampaignNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, ErrInsufficientPoints):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, ErrReferenceUsed):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, ErrFundingUnavailable):
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"message": err.Error()})
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+}
+
+func redemptionSnapshot(redemption *PointRedemption) audit.Snapshot {
+	return audit.Snapshot{
+		"type":         redemption.Type,
+		"points":       redemption.Points,
+		"amount":       redemption.Amount,
+		"currency":     redemption.Currency,
+		"reward_id":    redemption.RewardID,
+		"voucher_code": redemption.VoucherCode,
+	}
+}
+
+func ruleSnapshot(rule *EarnRule) audit.Snapshot {
+	return audit.Snapshot{
+		"name":             rule.Name,
+		"currency":         rule.Currency,
+		"channel":          rule.Channel,
+		"merchant_user_id": rule.MerchantUserID,
+		"min_amount":       rule.MinAmount,
+		"amount_unit":      rule.AmountUnit,
+		"points_per_unit":  rule.PointsPerUnit,
+		"max_points":       rule.MaxPoints,
+		"validity_days":    rule.ValidityDays,
+		"effective_from":   rule.EffectiveFrom,
+		"effective_to":     rule.EffectiveTo,
+	}
+}
+
+func rewardSnapshot(reward *Reward) audit.Snapshot {
+	return audit.Snapshot{
+		"name":        reward.Name,
+		"campaign_id": reward.CampaignID,
+		"points_cost": reward.PointsCost,
+		"active":      reward.Active,
+	}
+}
@@ -0,0 +1,126 @@
+package loyalty
+
+import (
+	"time"
+)
+
+// EarnRule menentukan poin untuk PURCHASE SUCCESS: PointsPerUnit poin untuk
+// setiap AmountUnit yang dibayar, dibatasi MaxPoints per transaksi (0 berarti
+// tanpa batas). Channel kosong dan MerchantUserID 0 berarti berlaku untuk
+// semua. Bila beberapa aturan cocok, dipakai yang memberi poin terbanyak.
+type EarnRule struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	Name           string     `gorm:"type:varchar(100);not null" json:"name"`
+	Currency       string     `gorm:"type:char(3);not null;default:'IDR';index:idx_earn_rule_scope" json:"currency"`
+	Channel        string     `gorm:"type:varchar(50);not null;default:''" json:"channel"`
+	MerchantUserID uint       `gorm:"not null;default:0" json:"merchant_user_id"`
+	MinAmount      float64    `gorm:"not null;default:0" json:"min_amount"`
+	AmountUnit     float64    `gorm:"not null" json:"amount_unit"`
+	PointsPerUnit  int64      `gorm:"not null" json:"points_per_unit"`
+	MaxPoints      int64      `gorm:"not null;default:0" json:"max_points"`
+	ValidityDays   int        `gorm:"not null" json:"validity_days"`
+	EffectiveFrom  time.Time  `gorm:"not null;index:idx_earn_rule_scope" json:"effective_from"`
+	EffectiveTo    *time.Time `json:"effective_to,omitempty"`
+	CreatedBy      uint       `gorm:"not null" json:"created_by"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// ActiveAt melaporkan apakah aturan berlaku pada waktu t.
+func (r EarnRule) ActiveAt(t time.Time) bool {
+	if t.Before(r.EffectiveFrom) {
+		return false
+	}
+	return r.EffectiveTo == nil || t.Before(*r.EffectiveTo)
+}
+
+// PointLot adalah poin dari satu transaksi. Poin dipakai dari lot yang paling
+// cepat kedaluwarsa (FIFO) dan sisa lot hangus pada ExpiresAt.
+type PointLot struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;index:idx_point_lot_user" json:"user_id"`
+	RuleID    uint      `gorm:"not null" json:"rule_id"`
+	Reference string    `gorm:"type:varchar(255);uniqueIndex;not null" json:"reference"`
+	Points    int64     `gorm:"not null" json:"points"`
+	Remaining int64     `gorm:"not null" json:"remaining"`
+	ExpiresAt time.Time `gorm:"not null;index:idx_point_lot_user;index" json:"expires_at"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type EntryType string
+
+const (
+	EntryEarn   EntryType = "EARN"
+	EntryRedeem EntryType = "REDEEM"
+	EntryExpire EntryType = "EXPIRE"
+	// EntryRevoke menarik poin dari transaksi yang di-reverse atau direfund.
+	EntryRevoke EntryType = "REVOKE"
+)
+
+// PointEntry adalah baris riwayat poin. Points positif untuk EARN dan negatif
+// untuk entri yang mengurangi saldo.
+type PointEntry struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	UserID      uint      `gorm:"not null;index" json:"user_id"`
+	Type        EntryType `gorm:"type:enum('EARN','REDEEM','EXPIRE','REVOKE');not null" json:"type"`
+	Points      int64     `gorm:"not null" json:"points"`
+	Reference   string    `gorm:"type:varchar(255);not null;index" json:"reference"`
+	Description string    `gorm:"type:varchar(255)" json:"description"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type RedemptionType string
+
+const (
+	RedeemWallet  RedemptionType = "WALLET"
+	RedeemVoucher RedemptionType = "VOUCHER"
+)
+
+// PointRedemption mencatat penukaran poin menjadi saldo wallet atau voucher.
+type PointRedemption struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	UserID      uint           `gorm:"not null;index" json:"user_id"`
+	Reference   string         `gorm:"type:varchar(255);uniqueIndex;not null" json:"reference"`
+	Type        RedemptionType `gorm:"type:enum('WALLET','VOUCHER');not null" json:"type"`
+	Points      int64          `gorm:"not null" json:"points"`
+	Amount      float64        `gorm:"not null;default:0" json:"amount,omitempty"`
+	Currency    string         `gorm:"type:char(3)" json:"currency,omitempty"`
+	RewardID    uint           `gorm:"not null;default:0" json:"reward_id,omitempty"`
+	VoucherCode string         `gorm:"type:varchar(40)" json:"voucher_code,omitempty"`
+	CreatedAt   time.Time      `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// Reward adalah voucher promo yang dapat ditukar dengan poin. Setiap
+// penukaran menghasilkan satu kode voucher sekali pakai untuk CampaignID.
+type Reward struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	Name       string    `gorm:"type:varchar(100);not null" json:"name"`
+	CampaignID uint      `gorm:"not null" json:"campaign_id"`
+	PointsCost int64     `gorm:"not null" json:"points_cost"`
+	Active     bool      `gorm:"not null;default:true" json:"active"`
+	CreatedBy  uint      `gorm:"not null" json:"created_by"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// Purchase adalah PURCHASE SUCCESS yang menjadi dasar perolehan poin. Amount
+// adalah jumlah yang benar-benar dibayar user.
+type Purchase struct {
+	UserID         uint
+	Reference      string
+	Currency       string
+	Amount         float64
+	MerchantUserID uint
+	Channel        string
+}
+
+// Summary adalah saldo poin user beserta riwayatnya.
+type Summary struct {
+	Balance int64 `json:"balance"`
+	// ExpiringPoints hangus pada ExpiringAt, yaitu lot terdekat yang masih tersisa.
+	ExpiringPoints int64        `json:"expiring_points"`
+	ExpiringAt     *time.Time   `json:"expiring_at,omitempty"`
+	PointValue     float64      `json:"point_value"`
+	Currency       string       `json:"currency"`
+	History        []PointEntry `json:"history"`
+}
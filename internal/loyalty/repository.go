@@ -0,0 +1,349 @@
+package loyalty
+
+import (
+	"errors"
+	"ewallet-engine/internal/balance"
+	"ewallet-engine/internal/promotions"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LoyaltyRepository interface {
+	CreateRule(rule *EarnRule) error
+	FindRule(id uint) (*EarnRule, error)
+	SaveRule(rule *EarnRule) error
+	ListRules(activeOnly bool, now time.Time) ([]EarnRule, error)
+	FindActiveRules(currency string, now time.Time) ([]EarnRule, error)
+
+	CreateReward(reward *Reward) error
+	FindReward(id uint) (*Reward, error)
+	ListRewards(activeOnly bool) ([]Reward, error)
+	SetRewardActive(id uint, active bool) error
+
+	// Earn menyimpan lot beserta entri EARN-nya; false bila reference sudah pernah mendapat poin.
+	Earn(lot *PointLot, description string) (bool, error)
+	// Revoke menghanguskan sisa poin lot reference dan mengembalikan jumlahnya.
+	Revoke(reference string, description string) (int64, error)
+	FindLot(reference string) (*PointLot, error)
+	// RevokeUpTo menarik poin lot reference sampai total REVOKE-nya mencapai
+	// target, dibatasi sisa lot, dan mengembalikan jumlah yang ditarik.
+	RevokeUpTo(reference string, target int64, description string) (int64, error)
+	FindExpiredLots(now time.Time, limit int) ([]PointLot, error)
+	// Expire menghanguskan sisa poin satu lot yang sudah kedaluwarsa.
+	Expire(lotID uint, now time.Time) (int64, error)
+
+	Balance(userID uint, now time.Time) (int64, error)
+	NextExpiring(userID uint, now time.Time) (*PointLot, error)
+	ListEntries(userID uint, limit int) ([]PointEntry, error)
+
+	FindRedemption(reference string) (*PointRedemption, error)
+	// RedeemToWallet memakai poin secara FIFO lalu mengkredit wallet user dari
+	// wallet pendapatan platform dalam satu transaksi database.
+	RedeemToWallet(redemption *PointRedemption, now time.Time) error
+	// RedeemVoucher memakai poin secara FIFO lalu membuat voucher sekali pakai.
+	RedeemVoucher(redemption *PointRedemption, campaignID uint, now time.Time) error
+}
+
+type loyaltyRepository struct {
+	DB *gorm.DB
+}
+
+func NewLoyaltyRepository(db *gorm.DB) LoyaltyRepository {
+	return &loyaltyRepository{DB: db}
+}
+
+func (r *loyaltyRepository) CreateRule(rule *EarnRule) error {
+	return r.DB.Create(rule).Error
+}
+
+func (r *loyaltyRepository) FindRule(id uint) (*EarnRule, error) {
+	var rule EarnRule
+	if err := r.DB.First(&rule, id).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *loyaltyRepository) SaveRule(rule *EarnRule) error {
+	return r.DB.Save(rule).Error
+}
+
+func (r *loyaltyRepository) ListRules(activeOnly bool, now time.Time) ([]EarnRule, error) {
+	var rules []EarnRule
+	query := r.DB.Order("id DESC")
+	if activeOnly {
+		query = query.Where("effective_from <= ? AND (effective_to IS NULL OR effective_to > ?)", now, now)
+	}
+	err := query.Find(&rules).Error
+	return rules, err
+}
+
+func (r *loyaltyRepository) FindActiveRules(currency string, now time.Time) ([]EarnRule, error) {
+	var rules []EarnRule
+	err := r.DB.Where("currency = ? AND effective_from <= ? AND (effective_to IS NULL OR effective_to > ?)", currency, now, now).
+		Find(&rules).Error
+	return rules, err
+}
+
+func (r *loyaltyRepository) CreateReward(reward *Reward) error {
+	return r.DB.Create(reward).Error
+}
+
+func (r *loyaltyRepository) FindReward(id uint) (*Reward, error) {
+	var reward Reward
+	if err := r.DB.First(&reward, id).Error; err != nil {
+		return nil, err
+	}
+	return &reward, nil
+}
+
+func (r *loyaltyRepository) ListRewards(activeOnly bool) ([]Reward, error) {
+	var rewards []Reward
+	query := r.DB.Order("points_cost ASC")
+	if activeOnly {
+		query = query.Where("active = ?", true)
+	}
+	err := query.Find(&rewards).Error
+	return rewards, err
+}
+
+func (r *loyaltyRepository) SetRewardActive(id uint, active bool) error {
+	return r.DB.Model(&Reward{}).Where("id = ?", id).Update("active", active).Error
+}
+
+func (r *loyaltyRepository) Earn(lot *PointLot, description string) (bool, error) {
+	created := false
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(lot)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		created = true
+		return tx.Create(&PointEntry{
+			UserID:      lot.UserID,
+			Type:        EntryEarn,
+			Points:      lot.Points,
+			Reference:   lot.Reference,
+			Description: description,
+		}).Error
+	})
+	return created, err
+}
+
+func (r *loyaltyRepository) Revoke(reference string, description string) (int64, error) {
+	var revoked int64
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var lot PointLot
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("reference = ?", reference).First(&lot).Error
+		if err != nil {
+			return err
+		}
+		revoked, err = zeroLot(tx, &lot, EntryRevoke, description)
+		return err
+	})
+	return revoked, err
+}
+
+func (r *loyaltyRepository) FindLot(reference string) (*PointLot, error) {
+	var lot PointLot
+	if err := r.DB.Where("reference = ?", reference).First(&lot).Error; err != nil {
+		return nil, err
+	}
+	return &lot, nil
+}
+
+func (r *loyaltyRepository) RevokeUpTo(reference string, target int64, description string) (int64, error) {
+	var revoked int64
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var lot PointLot
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("reference = ?", reference).First(&lot).Error
+		if err != nil {
+			return err
+		}
+
+		var already int64
+		err = tx.Model(&PointEntry{}).
+			Where("reference = ? AND type = ?", reference, EntryRevoke).
+			Select("COALESCE(-SUM(points), 0)").Scan(&already).Error
+		if err != nil {
+			return err
+		}
+
+		revoked = target - already
+		if revoked > lot.Remaining {
+			revoked = lot.Remaining
+		}
+		if revoked <= 0 {
+			revoked = 0
+			return nil
+		}
+		if err := tx.Model(&lot).Update("remaining", lot.Remaining-revoked).Error; err != nil {
+			return err
+		}
+		return tx.Create(&PointEntry{
+			UserID:      lot.UserID,
+			Type:        EntryRevoke,
+			Points:      -revoked,
+			Reference:   lot.Reference,
+			Description: description,
+		}).Error
+	})
+	return revoked, err
+}
+
+func (r *loyaltyRepository) FindExpiredLots(now time.Time, limit int) ([]PointLot, error) {
+	var lots []PointLot
+	err := r.DB.Where("expires_at <= ? AND remaining > 0", now).Order("expires_at ASC").Limit(limit).Find(&lots).Error
+	return lots, err
+}
+
+func (r *loyaltyRepository) Expire(lotID uint, now time.Time) (int64, error) {
+	var expired int64
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var lot PointLot
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&lot, lotID).Error
+		if err != nil {
+			return err
+		}
+		if lot.ExpiresAt.After(now) {
+			return nil
+		}
+		expired, err = zeroLot(tx, &lot, EntryExpire, "Poin kedaluwarsa")
+		return err
+	})
+	return expired, err
+}
+
+// zeroLot harus dipanggil di dalam transaksi yang sudah mengunci lot.
+func zeroLot(tx *gorm.DB, lot *PointLot, entryType EntryType, description string) (int64, error) {
+	remaining := lot.Remaining
+	if remaining <= 0 {
+		return 0, nil
+	}
+	if err := tx.Model(lot).Update("remaining", 0).Error; err != nil {
+		return 0, err
+	}
+	err := tx.Create(&PointEntry{
+		UserID:      lot.UserID,
+		Type:        entryType,
+		Points:      -remaining,
+		Reference:   lot.Reference,
+		Description: description,
+	}).Error
+	return remaining, err
+}
+
+func (r *loyaltyRepository) Balance(userID uint, now time.Time) (int64, error) {
+	var total int64
+	err := r.DB.Model(&PointLot{}).
+		Where("user_id = ? AND remaining > 0 AND expires_at > ?", userID, now).
+		Select("COALESCE(SUM(remaining), 0)").Scan(&total).Error
+	return total, err
+}
+
+func (r *loyaltyRepository) NextExpiring(userID uint, now time.Time) (*PointLot, error) {
+	var lot PointLot
+	err := r.DB.Where("user_id = ? AND remaining > 0 AND expires_at > ?", userID, now).
+		Order("expires_at ASC, id ASC").First(&lot).Error
+	if err != nil {
+		return nil, err
+	}
+	return &lot, nil
+}
+
+func (r *loyaltyRepository) ListEntries(userID uint, limit int) ([]PointEntry, error) {
+	var entries []PointEntry
+	err := r.DB.Where("user_id = ?", userID).Order("id DESC").Limit(limit).Find(&entries).Error
+	return entries, err
+}
+
+func (r *loyaltyRepository) FindRedemption(reference string) (*PointRedemption, error) {
+	var redemption PointRedemption
+	if err := r.DB.Where("reference = ?", reference).First(&redemption).Error; err != nil {
+		return nil, err
+	}
+	return &redemption, nil
+}
+
+func (r *loyaltyRepository) RedeemToWallet(redemption *PointRedemption, now time.Time) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := redeemPoints(tx, redemption, now, "Tukar poin ke saldo"); err != nil {
+			return err
+		}
+
+		wallet, err := balance.FindUserWallet(tx, redemption.UserID, redemption.Currency, true)
+		if err != nil {
+			return err
+		}
+		platform, err := balance.PlatformWallet(tx, redemption.Currency)
+		if err != nil {
+			return err
+		}
+
+		// Wallet dikunci menurut id, sama seperti transfer.
+		var locked []balance.Wallet
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", []uint{wallet.ID, platform.ID}).Order("id ASC").Find(&locked).Error
+		if err != nil {
+			return err
+		}
+
+		if _, err := balance.ApplyWalletEntry(tx, platform.ID, "DEBIT", redemption.Amount, redemption.Reference); err != nil {
+			return err
+		}
+		_, err = balance.ApplyWalletEntry(tx, wallet.ID, "CREDIT", redemption.Amount, redemption.Reference)
+		return err
+	})
+}
+
+func (r *loyaltyRepository) RedeemVoucher(redemption *PointRedemption, campaignID uint, now time.Time) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := redeemPoints(tx, redemption, now, "Tukar poin ke voucher "+redemption.VoucherCode); err != nil {
+			return err
+		}
+		voucher := promotions.Voucher{CampaignID: campaignID, Code: redemption.VoucherCode, MaxRedemptions: 1}
+		return promotions.NewPromotionRepository(tx).CreateVouchers([]promotions.Voucher{voucher})
+	})
+}
+
+// redeemPoints mengunci lot user yang masih berlaku, memakainya secara FIFO,
+// lalu mencatat entri REDEEM dan redemption-nya.
+func redeemPoints(tx *gorm.DB, redemption *PointRedemption, now time.Time, description string) error {
+	var lots []PointLot
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND remaining > 0 AND expires_at > ?", redemption.UserID, now).
+		Order("expires_at ASC, id ASC").Find(&lots).Error
+	if err != nil {
+		return err
+	}
+
+	touched, ok := consume(lots, redemption.Points)
+	if !ok {
+		return ErrInsufficientPoints
+	}
+	for _, lot := range touched {
+		if err := tx.Model(&PointLot{}).Where("id = ?", lot.ID).Update("remaining", lot.Remaining).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Create(redemption).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "Duplicate entry") {
+			return ErrReferenceUsed
+		}
+		return err
+	}
+	return tx.Create(&PointEntry{
+		UserID:      redemption.UserID,
+		Type:        EntryRedeem,
+		Points:      -redemption.Points,
+		Reference:   redemption.Reference,
+		Description: description,
+	}).Error
+}
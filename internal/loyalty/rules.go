@@ -0,0 +1,100 @@
+package loyalty
+
+import (
+	"ewallet-engine/internal/balance"
+	"time"
+)
+
+// Points menghitung poin yang diberikan rule untuk amount. Satuan yang belum
+// genap AmountUnit tidak menghasilkan poin.
+func Points(rule EarnRule, amount float64) int64 {
+	if rule.AmountUnit <= 0 || rule.PointsPerUnit <= 0 || amount < rule.MinAmount {
+		return 0
+	}
+
+	unit := balance.ToMinor(rule.AmountUnit, rule.Currency)
+	if unit <= 0 {
+		return 0
+	}
+	points := balance.ToMinor(amount, rule.Currency) / unit * rule.PointsPerUnit
+	if rule.MaxPoints > 0 && points > rule.MaxPoints {
+		points = rule.MaxPoints
+	}
+	return points
+}
+
+// RefundedPoints menghitung total poin lot yang harus ditarik setelah
+// refunded dari paid direfund. Total dihitung kumulatif supaya beberapa refund
+// parsial tidak menarik lebih atau kurang karena pembulatan; refund penuh
+// menarik seluruh poin lot.
+func RefundedPoints(points int64, refunded float64, paid float64, currency string) int64 {
+	paidMinor := balance.ToMinor(paid, currency)
+	refundedMinor := balance.ToMinor(refunded, currency)
+	if points <= 0 || refundedMinor <= 0 || paidMinor <= 0 {
+		return 0
+	}
+	if refundedMinor >= paidMinor {
+		return points
+	}
+	return points * refundedMinor / paidMinor
+}
+
+// SelectRule memilih aturan yang berlaku untuk purchase dan memberi poin
+// terbanyak. Bila sama banyak, aturan yang lebih spesifik menang.
+func SelectRule(rules []EarnRule, purchase Purchase, now time.Time) (*EarnRule, int64) {
+	var best *EarnRule
+	var bestPoints int64
+	bestSpecificity := -1
+
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.ActiveAt(now) || rule.Currency != purchase.Currency {
+			continue
+		}
+		if rule.Channel != "" && rule.Channel != purchase.Channel {
+			continue
+		}
+		if rule.MerchantUserID != 0 && rule.MerchantUserID != purchase.MerchantUserID {
+			continue
+		}
+
+		points := Points(*rule, purchase.Amount)
+		if points <= 0 {
+			continue
+		}
+		specificity := 0
+		if rule.Channel != "" {
+			specificity++
+		}
+		if rule.MerchantUserID != 0 {
+			specificity++
+		}
+		if points > bestPoints || (points == bestPoints && specificity > bestSpecificity) {
+			best, bestPoints, bestSpecificity = rule, points, specificity
+		}
+	}
+	return best, bestPoints
+}
+
+// consume mengurangi Remaining lot secara FIFO sampai points terpenuhi. lots
+// harus sudah terurut dari yang paling cepat kedaluwarsa. Lot yang berubah
+// dikembalikan; false bila poin tidak cukup.
+func consume(lots []PointLot, points int64) ([]PointLot, bool) {
+	var touched []PointLot
+	for _, lot := range lots {
+		if points == 0 {
+			break
+		}
+		used := lot.Remaining
+		if used > points {
+			used = points
+		}
+		if used <= 0 {
+			continue
+		}
+		lot.Remaining -= used
+		points -= used
+		touched = append(touched, lot)
+	}
+	return touched, points == 0
+}
@@ -0,0 +1,107 @@
+package loyalty
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRefundedPoints(t *testing.T) {
+	cases := []struct {
+		refunded float64
+		want     int64
+	}{
+		{0, 0},
+		{33333, 33},
+		{66666, 66},
+		{99999, 99},
+		{100000, 100},
+		{120000, 100},
+	}
+	for _, tc := range cases {
+		if got := RefundedPoints(100, tc.refunded, 100000, "IDR"); got != tc.want {
+			t.Errorf("RefundedPoints(100, %v, 100000) = %d, want %d", tc.refunded, got, tc.want)
+		}
+	}
+
+	if got := RefundedPoints(100, 50000, 0, "IDR"); got != 0 {
+		t.Errorf("paid 0 harus 0, got %d", got)
+	}
+}
+
+func TestPoints(t *testing.T) {
+	rule := EarnRule{Currency: "IDR", AmountUnit: 10000, PointsPerUnit: 2, MinAmount: 20000, MaxPoints: 50}
+
+	cases := []struct {
+		amount float64
+		want   int64
+	}{
+		{19999, 0},
+		{20000, 4},
+		{29999, 4},
+		{125000, 24},
+		{1000000, 50},
+	}
+	for _, tc := range cases {
+		if got := Points(rule, tc.amount); got != tc.want {
+			t.Errorf("Points(%v) = %d, want %d", tc.amount, got, tc.want)
+		}
+	}
+
+	if got := Points(EarnRule{Currency: "IDR", AmountUnit: 0.1, PointsPerUnit: 1}, 100000); got != 0 {
+		t.Errorf("AmountUnit di bawah satuan terkecil harus 0, got %d", got)
+	}
+}
+
+func TestSelectRule(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	ended := now.Add(-time.Hour)
+	rules := []EarnRule{
+		{ID: 1, Currency: "IDR", AmountUnit: 10000, PointsPerUnit: 1, EffectiveFrom: now.AddDate(0, -1, 0)},
+		{ID: 2, Currency: "IDR", MerchantUserID: 7, AmountUnit: 10000, PointsPerUnit: 1, EffectiveFrom: now.AddDate(0, -1, 0)},
+		{ID: 3, Currency: "IDR", Channel: "QRIS", AmountUnit: 10000, PointsPerUnit: 3, EffectiveFrom: now.AddDate(0, -1, 0)},
+		{ID: 4, Currency: "IDR", AmountUnit: 1000, PointsPerUnit: 1, EffectiveFrom: now.AddDate(0, -1, 0), EffectiveTo: &ended},
+		{ID: 5, Currency: "USD", AmountUnit: 1, PointsPerUnit: 10, EffectiveFrom: now.AddDate(0, -1, 0)},
+	}
+
+	cases := []struct {
+		name     string
+		purchase Purchase
+		wantID   uint
+		want     int64
+	}{
+		{"aturan umum", Purchase{Currency: "IDR", Amount: 50000}, 1, 5},
+		{"merchant lebih spesifik saat poin sama", Purchase{Currency: "IDR", Amount: 50000, MerchantUserID: 7}, 2, 5},
+		{"poin terbanyak menang", Purchase{Currency: "IDR", Amount: 50000, MerchantUserID: 7, Channel: "QRIS"}, 3, 15},
+		{"di bawah satu unit", Purchase{Currency: "IDR", Amount: 9000}, 0, 0},
+		{"currency lain", Purchase{Currency: "SGD", Amount: 50000}, 0, 0},
+	}
+	for _, tc := range cases {
+		rule, points := SelectRule(rules, tc.purchase, now)
+		var gotID uint
+		if rule != nil {
+			gotID = rule.ID
+		}
+		if gotID != tc.wantID || points != tc.want {
+			t.Errorf("%s: got rule %d dengan %d poin, want rule %d dengan %d poin", tc.name, gotID, points, tc.wantID, tc.want)
+		}
+	}
+}
+
+func TestConsume(t *testing.T) {
+	lots := []PointLot{{ID: 1, Remaining: 30}, {ID: 2, Remaining: 0}, {ID: 3, Remaining: 50}, {ID: 4, Remaining: 20}}
+
+	touched, ok := consume(lots, 60)
+	if !ok || len(touched) != 2 {
+		t.Fatalf("consume(60) = %+v, %v", touched, ok)
+	}
+	if touched[0].ID != 1 || touched[0].Remaining != 0 || touched[1].ID != 3 || touched[1].Remaining != 20 {
+		t.Fatalf("lot harus dipakai FIFO: %+v", touched)
+	}
+	if lots[0].Remaining != 30 {
+		t.Fatal("consume tidak boleh mengubah slice asal")
+	}
+
+	if _, ok := consume(lots, 101); ok {
+		t.Fatal("poin yang tidak cukup harus gagal")
+	}
+}
@@ -0,0 +1,396 @@
+package loyalty
+
+import (
+	"errors"
+	"ewallet-engine/internal/balance"
+	"ewallet-engine/internal/notifications"
+	"ewallet-engine/internal/promotions"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	NotificationPointsEarned = "POINTS_EARNED"
+
+	defaultPointValue   = 1
+	defaultMinRedeem    = 100
+	defaultHistoryLimit = 50
+	expireBatchSize     = 500
+	rewardVoucherPrefix = "PTS-"
+)
+
+var (
+	ErrRuleNotFound       = errors.New("aturan poin tidak ditemukan")
+	ErrRewardNotFound     = errors.New("reward tidak ditemukan")
+	ErrInsufficientPoints = errors.New("poin tidak mencukupi")
+	ErrReferenceUsed      = errors.New("reference sudah digunakan")
+	ErrFundingUnavailable = errors.New("penukaran poin sedang tidak tersedia")
+)
+
+type LoyaltyService interface {
+	Earn(purchase Purchase)
+	Revoke(reference string)
+	RevokeRefunded(reference string, currency string, refunded float64, paid float64)
+	ExpirePoints() (int, error)
+
+	Summary(userID uint, limit int) (*Summary, error)
+	RedeemToWallet(userID uint, points int64, reference string) (*PointRedemption, error)
+	RedeemReward(userID uint, rewardID uint, reference string) (*PointRedemption, error)
+
+	CreateRule(actorID uint, rule EarnRule) (*EarnRule, error)
+	ListRules(activeOnly bool) ([]EarnRule, error)
+	EndRule(id uint, at time.Time) (*EarnRule, *EarnRule, error)
+	CreateReward(actorID uint, reward Reward) (*Reward, error)
+	ListRewards(activeOnly bool) ([]Reward, error)
+	SetRewardActive(id uint, active bool) (*Reward, *Reward, error)
+}
+
+type loyaltyService struct {
+	repo        LoyaltyRepository
+	promotions  promotions.PromotionService
+	creditGuard balance.CreditGuard
+	notifier    notifications.Notifier
+	// pointValue adalah nilai satu poin dalam balance.DefaultCurrency saat ditukar ke saldo.
+	pointValue float64
+	minRedeem  int64
+}
+
+func NewLoyaltyService(repo LoyaltyRepository, promotionService promotions.PromotionService, creditGuard balance.CreditGuard, notifier notifications.Notifier) LoyaltyService {
+	s := &loyaltyService{repo: repo, promotions: promotionService, creditGuard: creditGuard, notifier: notifier, pointValue: defaultPointValue, minRedeem: defaultMinRedeem}
+	if value, err := strconv.ParseFloat(os.Getenv("POINTS_REDEEM_VALUE"), 64); err == nil && value > 0 {
+		s.pointValue = value
+	}
+	if points, err := strconv.ParseInt(os.Getenv("POINTS_MIN_REDEEM"), 10, 64); err == nil && points > 0 {
+		s.minRedeem = points
+	}
+	return s
+}
+
+// Earn memberi poin untuk PURCHASE SUCCESS menurut aturan yang berlaku.
+// Reference yang sama hanya mendapat poin sekali.
+func (s *loyaltyService) Earn(purchase Purchase) {
+	now := time.Now()
+	rules, err := s.repo.FindActiveRules(purchase.Currency, now)
+	if err != nil {
+		log.Printf("ERROR: Gagal memuat aturan poin untuk %s: %v", purchase.Reference, err)
+		return
+	}
+	rule, points := SelectRule(rules, purchase, now)
+	if rule == nil {
+		return
+	}
+
+	lot := &PointLot{
+		UserID:    purchase.UserID,
+		RuleID:    rule.ID,
+		Reference: purchase.Reference,
+		Points:    points,
+		Remaining: points,
+		ExpiresAt: now.AddDate(0, 0, rule.ValidityDays),
+	}
+	created, err := s.repo.Earn(lot, "Poin dari transaksi "+purchase.Reference)
+	if err != nil {
+		log.Printf("ERROR: Gagal mencatat poin transaksi %s: %v", purchase.Reference, err)
+		return
+	}
+	if !created {
+		return
+	}
+
+	s.notifier.Notify(purchase.UserID, NotificationPointsEarned, "Poin bertambah",
+		fmt.Sprintf("Anda mendapat %d poin dari transaksi %s, berlaku sampai %s.", points, purchase.Reference, lot.ExpiresAt.Format("02-01-2006")),
+		notifications.Data{"reference": purchase.Reference, "points": points})
+}
+
+// Revoke menarik sisa poin dari transaksi yang di-reverse. Poin yang sudah
+// ditukar tidak ditarik kembali.
+func (s *loyaltyService) Revoke(reference string) {
+	revoked, err := s.repo.Revoke(reference, "Transaksi "+reference+" dibatalkan")
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("ERROR: Gagal menarik poin transaksi %s: %v", reference, err)
+		}
+		return
+	}
+	if revoked > 0 {
+		log.Printf("SUCCESS: %d poin transaksi %s ditarik", revoked, reference)
+	}
+}
+
+// RevokeRefunded menarik poin transaksi sebanding dengan bagian yang sudah
+// direfund. refunded adalah total refund transaksi termasuk refund terakhir,
+// paid adalah jumlah yang dibayar. Seperti Revoke, poin yang sudah ditukar
+// tidak ditarik kembali.
+func (s *loyaltyService) RevokeRefunded(reference string, currency string, refunded float64, paid float64) {
+	lot, err := s.repo.FindLot(reference)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("ERROR: Gagal memuat poin transaksi %s: %v", reference, err)
+		}
+		return
+	}
+
+	target := RefundedPoints(lot.Points, refunded, paid, currency)
+	revoked, err := s.repo.RevokeUpTo(reference, target, "Refund transaksi "+reference)
+	if err != nil {
+		log.Printf("ERROR: Gagal menarik poin refund transaksi %s: %v", reference, err)
+		return
+	}
+	if revoked > 0 {
+		log.Printf("SUCCESS: %d poin transaksi %s ditarik karena refund", revoked, reference)
+	}
+}
+
+// ExpirePoints menghanguskan sisa poin dari lot yang sudah kedaluwarsa dan
+// mengembalikan jumlah lot yang diproses.
+func (s *loyaltyService) ExpirePoints() (int, error) {
+	now := time.Now()
+	lots, err := s.repo.FindExpiredLots(now, expireBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, lot := range lots {
+		if _, err := s.repo.Expire(lot.ID, now); err != nil {
+			log.Printf("ERROR: Gagal menghanguskan poin lot %d: %v", lot.ID, err)
+			continue
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+func (s *loyaltyService) Summary(userID uint, limit int) (*Summary, error) {
+	if limit <= 0 || limit > defaultHistoryLimit {
+		limit = defaultHistoryLimit
+	}
+
+	now := time.Now()
+	total, err := s.repo.Balance(userID, now)
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.repo.ListEntries(userID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &Summary{
+		Balance:    total,
+		PointValue: s.pointValue,
+		Currency:   balance.DefaultCurrency,
+		History:    history,
+	}
+	if lot, err := s.repo.NextExpiring(userID, now); err == nil {
+		summary.ExpiringPoints = lot.Remaining
+		summary.ExpiringAt = &lot.ExpiresAt
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// RedeemToWallet menukar poin menjadi saldo balance.DefaultCurrency senilai
+// points * POINTS_REDEEM_VALUE, dibayar dari wallet pendapatan platform dan
+// dibatasi saldo maksimum tier KYC user.
+// Reference yang sama dari user yang sama mengembalikan penukaran yang sudah ada.
+func (s *loyaltyService) RedeemToWallet(userID uint, points int64, reference string) (*PointRedemption, error) {
+	if reference == "" {
+		return nil, errors.New("reference wajib diisi")
+	}
+	if points < s.minRedeem {
+		return nil, fmt.Errorf("minimal penukaran %d poin", s.minRedeem)
+	}
+	if existing, done, err := s.existingRedemption(userID, reference); done {
+		return existing, err
+	}
+
+	redemption := &PointRedemption{
+		UserID:    userID,
+		Reference: reference,
+		Type:      RedeemWallet,
+		Points:    points,
+		Amount:    balance.RoundAmount(float64(points)*s.pointValue, balance.DefaultCurrency),
+		Currency:  balance.DefaultCurrency,
+	}
+	if err := balance.ValidateAmount(redemption.Amount, redemption.Currency); err != nil {
+		return nil, err
+	}
+	if err := s.creditGuard.CheckCredit(userID, redemption.Amount); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.RedeemToWallet(redemption, time.Now()); err != nil {
+		if errors.Is(err, balance.ErrInsufficientBalance) {
+			log.Printf("ALERT: Wallet platform %s tidak mencukupi untuk penukaran poin %s sebesar %.2f", redemption.Currency, reference, redemption.Amount)
+			return nil, ErrFundingUnavailable
+		}
+		return nil, err
+	}
+	return redemption, nil
+}
+
+// RedeemReward menukar poin dengan voucher sekali pakai dari campaign reward.
+func (s *loyaltyService) RedeemReward(userID uint, rewardID uint, reference string) (*PointRedemption, error) {
+	if reference == "" {
+		return nil, errors.New("reference wajib diisi")
+	}
+	if existing, done, err := s.existingRedemption(userID, reference); done {
+		return existing, err
+	}
+
+	reward, err := s.repo.FindReward(rewardID)
+	if err != nil || !reward.Active {
+		return nil, ErrRewardNotFound
+	}
+	campaign, err := s.promotions.GetCampaign(reward.CampaignID)
+	if err != nil {
+		return nil, err
+	}
+	if campaign.Status == promotions.CampaignEnded || !time.Now().Before(campaign.EndsAt) {
+		return nil, errors.New("reward sudah tidak tersedia")
+	}
+
+	code, err := promotions.GenerateCode(rewardVoucherPrefix)
+	if err != nil {
+		return nil, err
+	}
+	redemption := &PointRedemption{
+		UserID:      userID,
+		Reference:   reference,
+		Type:        RedeemVoucher,
+		Points:      reward.PointsCost,
+		RewardID:    reward.ID,
+		VoucherCode: code,
+	}
+	if err := s.repo.RedeemVoucher(redemption, campaign.ID, time.Now()); err != nil {
+		return nil, err
+	}
+	return redemption, nil
+}
+
+// existingRedemption mengembalikan penukaran dengan reference yang sama.
+// done bernilai true bila reference sudah dipakai, oleh user ini atau lainnya.
+func (s *loyaltyService) existingRedemption(userID uint, reference string) (*PointRedemption, bool, error) {
+	existing, err := s.repo.FindRedemption(reference)
+	if err != nil {
+		return nil, false, nil
+	}
+	if existing.UserID != userID {
+		return nil, true, ErrReferenceUsed
+	}
+	return existing, true, nil
+}
+
+func (s *loyaltyService) CreateRule(actorID uint, rule EarnRule) (*EarnRule, error) {
+	rule.ID = 0
+	rule.Name = strings.TrimSpace(rule.Name)
+	rule.Channel = strings.ToUpper(strings.TrimSpace(rule.Channel))
+	rule.EffectiveTo = nil
+	rule.CreatedBy = actorID
+
+	if rule.Name == "" {
+		return nil, errors.New("nama aturan wajib diisi")
+	}
+	currency, err := balance.NormalizeCurrency(rule.Currency)
+	if err != nil {
+		return nil, err
+	}
+	rule.Currency = currency
+	if err := balance.ValidateAmount(rule.AmountUnit, currency); err != nil {
+		return nil, fmt.Errorf("amount_unit: %w", err)
+	}
+	if rule.PointsPerUnit <= 0 {
+		return nil, errors.New("points_per_unit harus lebih dari 0")
+	}
+	if rule.MinAmount < 0 || rule.MaxPoints < 0 {
+		return nil, errors.New("min_amount dan max_points tidak boleh negatif")
+	}
+	if rule.ValidityDays <= 0 {
+		return nil, errors.New("validity_days harus lebih dari 0")
+	}
+	if rule.EffectiveFrom.IsZero() {
+		rule.EffectiveFrom = time.Now()
+	}
+
+	if err := s.repo.CreateRule(&rule); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (s *loyaltyService) ListRules(activeOnly bool) ([]EarnRule, error) {
+	return s.repo.ListRules(activeOnly, time.Now())
+}
+
+// EndRule menghentikan aturan mulai at. Poin yang sudah diberikan tetap berlaku.
+func (s *loyaltyService) EndRule(id uint, at time.Time) (*EarnRule, *EarnRule, error) {
+	existing, err := s.repo.FindRule(id)
+	if err != nil {
+		return nil, nil, ErrRuleNotFound
+	}
+	if existing.EffectiveTo != nil && !existing.EffectiveTo.After(time.Now()) {
+		return nil, nil, errors.New("aturan sudah tidak berlaku")
+	}
+
+	before := *existing
+	if at.Before(existing.EffectiveFrom) {
+		at = existing.EffectiveFrom
+	}
+	existing.EffectiveTo = &at
+
+	if err := s.repo.SaveRule(existing); err != nil {
+		return nil, nil, err
+	}
+	return &before, existing, nil
+}
+
+func (s *loyaltyService) CreateReward(actorID uint, reward Reward) (*Reward, error) {
+	reward.ID = 0
+	reward.Name = strings.TrimSpace(reward.Name)
+	reward.Active = true
+	reward.CreatedBy = actorID
+
+	if reward.Name == "" {
+		return nil, errors.New("nama reward wajib diisi")
+	}
+	if reward.PointsCost <= 0 {
+		return nil, errors.New("points_cost harus lebih dari 0")
+	}
+	campaign, err := s.promotions.GetCampaign(reward.CampaignID)
+	if err != nil {
+		return nil, err
+	}
+	if campaign.Status == promotions.CampaignEnded {
+		return nil, errors.New("campaign sudah berakhir")
+	}
+
+	if err := s.repo.CreateReward(&reward); err != nil {
+		return nil, err
+	}
+	return &reward, nil
+}
+
+func (s *loyaltyService) ListRewards(activeOnly bool) ([]Reward, error) {
+	return s.repo.ListRewards(activeOnly)
+}
+
+func (s *loyaltyService) SetRewardActive(id uint, active bool) (*Reward, *Reward, error) {
+	reward, err := s.repo.FindReward(id)
+	if err != nil {
+		return nil, nil, ErrRewardNotFound
+	}
+	before := *reward
+	if err := s.repo.SetRewardActive(id, active); err != nil {
+		return nil, nil, err
+	}
+	reward.Active = active
+	return &before, reward, nil
+}
@@ -0,0 +1,117 @@
+package merchants
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const apiKeyPrefix = "mk_"
+
+var ErrInvalidAPIKey = errors.New("API key tidak valid")
+
+// generateAPIKey membuat key berformat mk_<prefix>_<secret>. Prefix disimpan
+// apa adanya untuk pencarian, sedangkan key utuh hanya disimpan hash-nya.
+func generateAPIKey() (raw string, prefix string, hash string, err error) {
+	buf := make([]byte, 30)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", "", err
+	}
+	encoded := hex.EncodeToString(buf)
+	prefix = apiKeyPrefix + encoded[:12]
+	raw = prefix + "_" + encoded[12:]
+	return raw, prefix, hashAPIKey(raw), nil
+}
+
+func hashAPIKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// splitAPIKey mengambil prefix dari key mentah.
+func splitAPIKey(raw string) (string, error) {
+	if !strings.HasPrefix(raw, apiKeyPrefix) {
+		return "", ErrInvalidAPIKey
+	}
+	separator := strings.LastIndex(raw, "_")
+	if separator <= len(apiKeyPrefix) {
+		return "", ErrInvalidAPIKey
+	}
+	return raw[:separator], nil
+}
+
+// normalizeScopes memvalidasi scope dan mengembalikannya sebagai daftar
+// dipisah koma dalam urutan baku.
+func normalizeScopes(scopes []string) (string, error) {
+	if len(scopes) == 0 {
+		return "", errors.New("minimal satu scope wajib diisi")
+	}
+	requested := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		requested[strings.TrimSpace(scope)] = true
+	}
+
+	var granted []string
+	for _, scope := range knownScopes {
+		if requested[scope] {
+			granted = append(granted, scope)
+			delete(requested, scope)
+		}
+	}
+	for scope := range requested {
+		return "", errors.New("scope tidak dikenal: " + scope)
+	}
+	return strings.Join(granted, ","), nil
+}
+
+// APIKeyMiddleware mengautentikasi merchant lewat header Authorization
+// "Bearer mk_..." atau X-API-Key, lalu menyimpan merchant_id dan api_key
+// di Locals.
+func APIKeyMiddleware(repo MerchantRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		raw := c.Get("X-API-Key")
+		if raw == "" {
+			raw = strings.TrimPrefix(c.Get("Authorization"), "Bearer ")
+		}
+		if raw == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Missing API key"})
+		}
+
+		prefix, err := splitAPIKey(raw)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": err.Error()})
+		}
+		key, err := repo.FindAPIKey(prefix)
+		if err != nil || key.RevokedAt != nil || subtle.ConstantTimeCompare([]byte(key.KeyHash), []byte(hashAPIKey(raw))) != 1 {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": ErrInvalidAPIKey.Error()})
+		}
+
+		merchant, err := repo.FindMerchant(key.MerchantID)
+		if err != nil || merchant.Status != MerchantActive {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"message": "Merchant tidak aktif"})
+		}
+
+		_ = repo.TouchAPIKey(key.ID, time.Now())
+		c.Locals("merchant_id", merchant.ID)
+		c.Locals("api_key", *key)
+		return c.Next()
+	}
+}
+
+// RequireScope hanya meneruskan request jika API key memiliki scope tersebut.
+// Harus dipasang setelah APIKeyMiddleware.
+func RequireScope(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key, ok := c.Locals("api_key").(APIKey)
+		if !ok || !key.HasScope(scope) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"message": "API key tidak memiliki scope " + scope})
+		}
+		return c.Next()
+	}
+}
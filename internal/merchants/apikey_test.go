@@ -0,0 +1,45 @@
+package merchants
+
+import "testing"
+
+func TestGenerateAPIKey(t *testing.T) {
+	raw, prefix, hash, err := generateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := splitAPIKey(raw)
+	if err != nil || got != prefix {
+		t.Fatalf("splitAPIKey(%q) = %q, %v; want %q", raw, got, err, prefix)
+	}
+	if hashAPIKey(raw) != hash || hashAPIKey(raw+"x") == hash {
+		t.Fatalf("hash tidak konsisten untuk %q", raw)
+	}
+
+	for _, invalid := range []string{"", "sk_abc_def", "mk_", "mk_nosecret"} {
+		if _, err := splitAPIKey(invalid); err == nil {
+			t.Fatalf("splitAPIKey(%q) seharusnya gagal", invalid)
+		}
+	}
+}
+
+func TestNormalizeScopes(t *testing.T) {
+	scopes, err := normalizeScopes([]string{" balance:read", ScopeIntentsWrite, ScopeIntentsWrite})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scopes != ScopeIntentsWrite+","+ScopeBalanceRead {
+		t.Fatalf("scopes = %q", scopes)
+	}
+
+	key := APIKey{Scopes: scopes}
+	if !key.HasScope(ScopeBalanceRead) || key.HasScope(ScopeIntentsRead) {
+		t.Fatalf("HasScope salah untuk %q", scopes)
+	}
+
+	if _, err := normalizeScopes(nil); err == nil {
+		t.Fatal("scope kosong seharusnya ditolak")
+	}
+	if _, err := normalizeScopes([]string{"admin"}); err == nil {
+		t.Fatal("scope tidak dikenal seharusnya ditolak")
+	}
+}
@@ -0,0 +1,389 @@
+package merchants
+
+import (
+	"errors"
+	"ewallet-engine/internal/audit"
+	"ewallet-engine/internal/auth"
+	"ewallet-engine/internal/transactions"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type MerchantHandler struct {
+	service      MerchantService
+	auditService audit.AuditService
+}
+
+func NewMerchantHandler(service MerchantService, auditService audit.AuditService) *MerchantHandler {
+	return &MerchantHandler{service: service, auditService: auditService}
+}
+
+// --- Admin ---
+
+func (h *MerchantHandler) CreateMerchantHandler(c *fiber.Ctx) error {
+	var request MerchantRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	merchant, err := h.service.CreateMerchant(request)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionMerchantCreated,
+		TargetType: "merchant",
+		TargetID:   fmt.Sprint(merchant.ID),
+		After:      merchantSnapshot(merchant),
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Merchant berhasil dibuat",
+		"data":    merchant,
+	})
+}
+
+func (h *MerchantHandler) ListMerchantsHandler(c *fiber.Ctx) error {
+	merchants, err := h.service.ListMerchants(uint(c.QueryInt("owner_user_id", 0)))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": merchants})
+}
+
+func (h *MerchantHandler) GetMerchantHandler(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	merchant, err := h.service.GetMerchant(uint(id))
+	if err != nil {
+		return h.merchantError(c, err)
+	}
+	summary, err := h.service.Balance(merchant.ID)
+	if err != nil {
+		return h.merchantError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": fiber.Map{"merchant": merchant, "settlement_balance": summary}})
+}
+
+func (h *MerchantHandler) SetStatusHandler(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	var request struct {
+		Status MerchantStatus `json:"status"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+	if request.Status != MerchantActive && request.Status != MerchantSuspended {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Status harus ACTIVE atau SUSPENDED"})
+	}
+
+	before, err := h.service.GetMerchant(uint(id))
+	if err != nil {
+		return h.merchantError(c, err)
+	}
+	previous := before.Status
+
+	merchant, err := h.service.SetStatus(uint(id), request.Status)
+	if err != nil {
+		return h.merchantError(c, err)
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionMerchantStatusChanged,
+		TargetType: "merchant",
+		TargetID:   fmt.Sprint(merchant.ID),
+		Before:     audit.Snapshot{"status": previous},
+		After:      audit.Snapshot{"status": merchant.Status},
+	})
+
+	return c.JSON(fiber.Map{"message": "Status merchant diperbarui", "data": merchant})
+}
+
+func (h *MerchantHandler) IssueAPIKeyHandler(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	var request APIKeyRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	key, raw, err := h.service.IssueAPIKey(uint(id), request)
+	if err != nil {
+		return h.merchantError(c, err)
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionMerchantAPIKeyIssued,
+		TargetType: "merchant_api_key",
+		TargetID:   fmt.Sprint(key.ID),
+		After:      audit.Snapshot{"merchant_id": key.MerchantID, "prefix": key.Prefix, "scopes": key.Scopes},
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "API key berhasil dibuat. Simpan key ini, key tidak akan ditampilkan lagi",
+		"data":    fiber.Map{"api_key": raw, "key": key},
+	})
+}
+
+func (h *MerchantHandler) ListAPIKeysHandler(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	keys, err := h.service.ListAPIKeys(uint(id))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": keys})
+}
+
+func (h *MerchantHandler) RevokeAPIKeyHandler(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+	keyID, err := c.ParamsInt("key_id")
+	if err != nil || keyID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	if err := h.service.RevokeAPIKey(uint(id), uint(keyID)); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionMerchantAPIKeyRevoked,
+		TargetType: "merchant_api_key",
+		TargetID:   fmt.Sprint(keyID),
+		After:      audit.Snapshot{"merchant_id": id, "revoked": true},
+	})
+
+	return c.JSON(fiber.Map{"message": "API key dicabut"})
+}
+
+// --- Merchant API ---
+
+func (h *MerchantHandler) CreateIntentHandler(c *fiber.Ctx) error {
+	merchantID := c.Locals("merchant_id").(uint)
+
+	var request IntentRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	intent, created, err := h.service.CreateIntent(merchantID, request)
+	if err != nil {
+		return h.merchantError(c, err)
+	}
+	if !created {
+		return c.JSON(fiber.Map{"message": "Payment intent sudah ada", "data": intent})
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionPaymentIntentCreated,
+		TargetType: "payment_intent",
+		TargetID:   intent.IntentID,
+		After:      intentSnapshot(intent),
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Payment intent berhasil dibuat",
+		"data":    intent,
+	})
+}
+
+func (h *MerchantHandler) GetIntentHandler(c *fiber.Ctx) error {
+	merchantID := c.Locals("merchant_id").(uint)
+
+	intent, err := h.service.GetIntent(merchantID, c.Params("intent_id"))
+	if err != nil {
+		return h.merchantError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": intent})
+}
+
+func (h *MerchantHandler) CancelIntentHandler(c *fiber.Ctx) error {
+	merchantID := c.Locals("merchant_id").(uint)
+
+	var request struct {
+		Reason string `json:"reason"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+	}
+
+	intent, err := h.service.CancelIntent(merchantID, c.Params("intent_id"), request.Reason)
+	if err != nil {
+		return h.merchantError(c, err)
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionPaymentIntentCancelled,
+		TargetType: "payment_intent",
+		TargetID:   intent.IntentID,
+		Before:     audit.Snapshot{"status": IntentRequiresConfirmation},
+		After:      intentSnapshot(intent),
+	})
+
+	return c.JSON(fiber.Map{"message": "Payment intent dibatalkan", "data": intent})
+}
+
+func (h *MerchantHandler) RefundIntentHandler(c *fiber.Ctx) error {
+	merchantID := c.Locals("merchant_id").(uint)
+
+	var request RefundRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	refund, err := h.service.RefundIntent(merchantID, c.Params("intent_id"), request)
+	if err != nil {
+		return h.merchantError(c, err)
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionPaymentIntentRefunded,
+		TargetType: "payment_intent",
+		TargetID:   c.Params("intent_id"),
+		After: audit.Snapshot{
+			"refund_reference":   refund.Reference,
+			"original_reference": refund.OriginalReference,
+			"amount":             refund.Amount,
+			"currency":           refund.Currency,
+		},
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Refund berhasil diproses",
+		"data":    refund,
+	})
+}
+
+func (h *MerchantHandler) BalanceHandler(c *fiber.Ctx) error {
+	merchantID := c.Locals("merchant_id").(uint)
+
+	summary, err := h.service.Balance(merchantID)
+	if err != nil {
+		return h.merchantError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": summary})
+}
+
+// --- Customer ---
+
+func (h *MerchantHandler) ViewIntentHandler(c *fiber.Ctx) error {
+	view, err := h.service.ViewIntent(c.Params("intent_id"))
+	if err != nil {
+		return h.merchantError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": view})
+}
+
+func (h *MerchantHandler) ConfirmIntentHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	var request struct {
+		PIN string `json:"pin"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	additionalInfo := transactions.AdditionalInfo{
+		"device_id": c.Get("X-Device-ID"),
+		"ip":        c.IP(),
+	}
+
+	intent, transaction, err := h.service.ConfirmIntent(userID, c.Params("intent_id"), request.PIN, additionalInfo)
+	if err != nil {
+		return h.merchantError(c, err)
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionPaymentIntentConfirmed,
+		TargetType: "payment_intent",
+		TargetID:   intent.IntentID,
+		Before:     audit.Snapshot{"status": IntentRequiresConfirmation},
+		After:      intentSnapshot(intent),
+	})
+
+	message := "Pembayaran berhasil"
+	switch intent.Status {
+	case IntentProcessing:
+		message = "Pembayaran dibuat dan sedang direview"
+	case IntentFailed:
+		message = "Pembayaran gagal"
+	}
+
+	return c.JSON(fiber.Map{
+		"message": message,
+		"data":    fiber.Map{"payment_intent": intent, "transaction": transaction},
+	})
+}
+
+func (h *MerchantHandler) merchantError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, ErrMerchantNotFound), errors.Is(err, ErrIntentNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, ErrIntentState), errors.Is(err, ErrIntentExpired):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, auth.ErrPINLocked):
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, auth.ErrInvalidPIN), errors.Is(err, auth.ErrPINNotSet):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"message": err.Error()})
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+}
+
+func merchantSnapshot(merchant *Merchant) audit.Snapshot {
+	return audit.Snapshot{
+		"code":               merchant.Code,
+		"name":               merchant.Name,
+		"owner_user_id":      merchant.OwnerUserID,
+		"settlement_user_id": merchant.SettlementUserID,
+		"currency":           merchant.Currency,
+		"status":             merchant.Status,
+	}
+}
+
+func intentSnapshot(intent *PaymentIntent) audit.Snapshot {
+	return audit.Snapshot{
+		"merchant_id":           intent.MerchantID,
+		"merchant_reference":    intent.MerchantReference,
+		"amount":                intent.Amount,
+		"currency":              intent.Currency,
+		"status":                intent.Status,
+		"customer_user_id":      intent.CustomerUserID,
+		"transaction_reference": intent.TransactionReference,
+	}
+}
@@ -0,0 +1,144 @@
+package merchants
+
+import (
+	"ewallet-engine/internal/transactions"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type MerchantStatus string
+
+const (
+	MerchantActive    MerchantStatus = "ACTIVE"
+	MerchantSuspended MerchantStatus = "SUSPENDED"
+)
+
+// Scope API key merchant.
+const (
+	ScopeIntentsRead     = "payment_intents:read"
+	ScopeIntentsWrite    = "payment_intents:write"
+	ScopeBalanceRead     = "balance:read"
+	ScopeRefundsWrite    = "refunds:write"
+	ScopeSettlementsRead = "settlements:read"
+	ScopeDisputesRead    = "disputes:read"
+	ScopeDisputesWrite   = "disputes:write"
+)
+
+var knownScopes = []string{ScopeIntentsRead, ScopeIntentsWrite, ScopeBalanceRead, ScopeRefundsWrite, ScopeSettlementsRead, ScopeDisputesRead, ScopeDisputesWrite}
+
+// Merchant adalah penerima pembayaran. Dana masuk ke wallet milik
+// SettlementUserID, user sistem ber-role MERCHANT yang dibuat bersama
+// merchant, sehingga terpisah dari wallet pribadi OwnerUserID.
+type Merchant struct {
+	ID               uint           `gorm:"primaryKey" json:"id"`
+	Code             string         `gorm:"type:varchar(32);uniqueIndex;not null" json:"code"`
+	Name             string         `gorm:"type:varchar(100);not null" json:"name"`
+	OwnerUserID      uint           `gorm:"not null;index" json:"owner_user_id"`
+	SettlementUserID uint           `gorm:"uniqueIndex" json:"settlement_user_id"`
+	Currency         string         `gorm:"type:char(3);not null;default:'IDR'" json:"currency"`
+	CategoryCode     string         `gorm:"type:char(4)" json:"category_code,omitempty"`
+	City             string         `gorm:"type:varchar(50)" json:"city,omitempty"`
+	Status           MerchantStatus `gorm:"type:enum('ACTIVE','SUSPENDED');default:'ACTIVE'" json:"status"`
+	CreatedAt        time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// APIKey menyimpan SHA-256 dari key merchant; key mentah hanya ditampilkan
+// sekali saat dibuat. Prefix dipakai untuk mencari key tanpa memindai tabel.
+type APIKey struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	MerchantID uint       `gorm:"not null;index" json:"merchant_id"`
+	Name       string     `gorm:"type:varchar(100)" json:"name,omitempty"`
+	Prefix     string     `gorm:"type:varchar(32);uniqueIndex;not null" json:"prefix"`
+	KeyHash    string     `gorm:"type:char(64);not null" json:"-"`
+	Scopes     string     `gorm:"type:varchar(255);not null" json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// HasScope melaporkan apakah key memiliki scope tertentu.
+func (k APIKey) HasScope(scope string) bool {
+	for _, granted := range strings.Split(k.Scopes, ",") {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type IntentStatus string
+
+const (
+	IntentRequiresConfirmation IntentStatus = "REQUIRES_CONFIRMATION"
+	IntentProcessing           IntentStatus = "PROCESSING"
+	IntentSucceeded            IntentStatus = "SUCCEEDED"
+	IntentFailed               IntentStatus = "FAILED"
+	IntentCancelled            IntentStatus = "CANCELLED"
+	IntentExpired              IntentStatus = "EXPIRED"
+)
+
+// PaymentIntent adalah tagihan yang dibuat merchant dan dikonfirmasi customer
+// dari wallet-nya. MerchantReference unik per merchant sehingga pembuatan
+// intent dengan reference yang sama mengembalikan intent yang sudah ada.
+type PaymentIntent struct {
+	ID                   uint                        `gorm:"primaryKey" json:"-"`
+	IntentID             string                      `gorm:"type:varchar(40);uniqueIndex;not null" json:"id"`
+	MerchantID           uint                        `gorm:"not null;uniqueIndex:idx_intent_merchant_reference" json:"merchant_id"`
+	MerchantReference    string                      `gorm:"type:varchar(100);not null;uniqueIndex:idx_intent_merchant_reference" json:"merchant_reference"`
+	Amount               float64                     `gorm:"not null" json:"amount"`
+	Currency             string                      `gorm:"type:char(3);not null;default:'IDR'" json:"currency"`
+	Description          string                      `gorm:"type:varchar(255)" json:"description,omitempty"`
+	Metadata             transactions.AdditionalInfo `gorm:"type:json" json:"metadata,omitempty"`
+	Status               IntentStatus                `gorm:"type:enum('REQUIRES_CONFIRMATION','PROCESSING','SUCCEEDED','FAILED','CANCELLED','EXPIRED');default:'REQUIRES_CONFIRMATION';index" json:"status"`
+	CustomerUserID       uint                        `gorm:"index" json:"customer_user_id,omitempty"`
+	TransactionReference string                      `gorm:"type:varchar(100)" json:"transaction_reference,omitempty"`
+	Attempts             int                         `gorm:"not null;default:0" json:"attempts"`
+	CancellationReason   string                      `gorm:"type:varchar(255)" json:"cancellation_reason,omitempty"`
+	ExpiresAt            time.Time                   `gorm:"not null" json:"expires_at"`
+	ConfirmedAt          *time.Time                  `json:"confirmed_at,omitempty"`
+	CreatedAt            time.Time                   `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt            time.Time                   `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// AttemptReference adalah reference transaksi untuk percobaan konfirmasi saat
+// ini. Attempts bertambah setiap konfirmasi mengklaim intent, sehingga
+// konfirmasi ulang setelah pembayaran gagal memakai reference yang baru.
+func (i PaymentIntent) AttemptReference() string {
+	return fmt.Sprintf("PI-%s-%d", i.IntentID, i.Attempts)
+}
+
+type MerchantRequest struct {
+	Name         string `json:"name"`
+	OwnerUserID  uint   `json:"owner_user_id"`
+	Currency     string `json:"currency"`
+	CategoryCode string `json:"category_code"`
+	City         string `json:"city"`
+}
+
+type APIKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+type IntentRequest struct {
+	Amount            float64                     `json:"amount"`
+	Currency          string                      `json:"currency"`
+	Description       string                      `json:"description"`
+	MerchantReference string                      `json:"merchant_reference"`
+	ExpiresInMinutes  int                         `json:"expires_in_minutes"`
+	Metadata          transactions.AdditionalInfo `json:"metadata"`
+}
+
+type RefundRequest struct {
+	Amount    float64 `json:"amount"`
+	Reference string  `json:"reference"`
+	Reason    string  `json:"reason"`
+}
+
+// IntentView adalah tampilan intent untuk customer sebelum konfirmasi.
+type IntentView struct {
+	PaymentIntent
+	MerchantName string `json:"merchant_name"`
+}
@@ -0,0 +1,162 @@
+package merchants
+
+import (
+	"ewallet-engine/internal/auth"
+	"ewallet-engine/internal/balance"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type MerchantRepository interface {
+	CreateMerchant(merchant *Merchant, settlementUser *auth.User) error
+	FindMerchant(id uint) (*Merchant, error)
+	FindMerchantBySettlementUser(userID uint) (*Merchant, error)
+	ListMerchants(ownerUserID uint) ([]Merchant, error)
+	UpdateMerchantStatus(id uint, status MerchantStatus) error
+	FindUser(id uint) (*auth.User, error)
+	FindSettlementWallet(merchant *Merchant) (*balance.Wallet, error)
+	CreateAPIKey(key *APIKey) error
+	FindAPIKey(prefix string) (*APIKey, error)
+	ListAPIKeys(merchantID uint) ([]APIKey, error)
+	RevokeAPIKey(merchantID uint, id uint, at time.Time) (bool, error)
+	TouchAPIKey(id uint, at time.Time) error
+	CreateIntent(intent *PaymentIntent) error
+	FindIntent(intentID string) (*PaymentIntent, error)
+	FindIntentByMerchantReference(merchantID uint, reference string) (*PaymentIntent, error)
+	TransitionIntent(id uint, from []IntentStatus, updates map[string]interface{}) (bool, error)
+}
+
+type merchantRepository struct {
+	DB *gorm.DB
+}
+
+func NewMerchantRepository(db *gorm.DB) MerchantRepository {
+	return &merchantRepository{DB: db}
+}
+
+// CreateMerchant membuat merchant, user settlement dan wallet settlement-nya
+// dalam satu transaksi. Nomor HP user settlement diturunkan dari id merchant
+// karena kolomnya wajib unik.
+func (r *merchantRepository) CreateMerchant(merchant *Merchant, settlementUser *auth.User) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(merchant).Error; err != nil {
+			return err
+		}
+
+		settlementUser.PhoneNumber = fmt.Sprintf("M%011d", merchant.ID)
+		if err := tx.Create(settlementUser).Error; err != nil {
+			return err
+		}
+		if _, err := balance.OpenWallet(tx, settlementUser.ID, merchant.Currency); err != nil {
+			return err
+		}
+
+		merchant.SettlementUserID = settlementUser.ID
+		return tx.Model(merchant).Update("settlement_user_id", settlementUser.ID).Error
+	})
+}
+
+func (r *merchantRepository) FindMerchant(id uint) (*Merchant, error) {
+	var merchant Merchant
+	if err := r.DB.First(&merchant, id).Error; err != nil {
+		return nil, err
+	}
+	return &merchant, nil
+}
+
+func (r *merchantRepository) FindMerchantBySettlementUser(userID uint) (*Merchant, error) {
+	var merchant Merchant
+	if err := r.DB.Where("settlement_user_id = ?", userID).First(&merchant).Error; err != nil {
+		return nil, err
+	}
+	return &merchant, nil
+}
+
+// ListMerchants mengembalikan semua merchant, atau hanya milik ownerUserID bila diisi.
+func (r *merchantRepository) ListMerchants(ownerUserID uint) ([]Merchant, error) {
+	var merchants []Merchant
+	query := r.DB.Order("id DESC")
+	if ownerUserID != 0 {
+		query = query.Where("owner_user_id = ?", ownerUserID)
+	}
+	err := query.Find(&merchants).Error
+	return merchants, err
+}
+
+func (r *merchantRepository) UpdateMerchantStatus(id uint, status MerchantStatus) error {
+	return r.DB.Model(&Merchant{}).Where("id = ?", id).Update("status", status).Error
+}
+
+func (r *merchantRepository) FindUser(id uint) (*auth.User, error) {
+	var user auth.User
+	if err := r.DB.First(&user, id).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *merchantRepository) FindSettlementWallet(merchant *Merchant) (*balance.Wallet, error) {
+	return balance.FindUserWallet(r.DB, merchant.SettlementUserID, merchant.Currency, true)
+}
+
+func (r *merchantRepository) CreateAPIKey(key *APIKey) error {
+	return r.DB.Create(key).Error
+}
+
+func (r *merchantRepository) FindAPIKey(prefix string) (*APIKey, error) {
+	var key APIKey
+	if err := r.DB.Where("prefix = ?", prefix).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *merchantRepository) ListAPIKeys(merchantID uint) ([]APIKey, error) {
+	var keys []APIKey
+	err := r.DB.Where("merchant_id = ?", merchantID).Order("id DESC").Find(&keys).Error
+	return keys, err
+}
+
+func (r *merchantRepository) RevokeAPIKey(merchantID uint, id uint, at time.Time) (bool, error) {
+	result := r.DB.Model(&APIKey{}).
+		Where("id = ? AND merchant_id = ? AND revoked_at IS NULL", id, merchantID).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *merchantRepository) TouchAPIKey(id uint, at time.Time) error {
+	return r.DB.Model(&APIKey{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
+
+func (r *merchantRepository) CreateIntent(intent *PaymentIntent) error {
+	return r.DB.Create(intent).Error
+}
+
+func (r *merchantRepository) FindIntent(intentID string) (*PaymentIntent, error) {
+	var intent PaymentIntent
+	if err := r.DB.Where("intent_id = ?", intentID).First(&intent).Error; err != nil {
+		return nil, err
+	}
+	return &intent, nil
+}
+
+func (r *merchantRepository) FindIntentByMerchantReference(merchantID uint, reference string) (*PaymentIntent, error) {
+	var intent PaymentIntent
+	if err := r.DB.Where("merchant_id = ? AND merchant_reference = ?", merchantID, reference).First(&intent).Error; err != nil {
+		return nil, err
+	}
+	return &intent, nil
+}
+
+func (r *merchantRepository) TransitionIntent(id uint, from []IntentStatus, updates map[string]interface{}) (bool, error) {
+	result := r.DB.Model(&PaymentIntent{}).Where("id = ? AND status IN ?", id, from).Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
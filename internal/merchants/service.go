@@ -0,0 +1,513 @@
+package merchants
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"ewallet-engine/internal/auth"
+	"ewallet-engine/internal/balance"
+	"ewallet-engine/internal/transactions"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrMerchantNotFound = errors.New("merchant tidak ditemukan")
+	ErrIntentNotFound   = errors.New("payment intent tidak ditemukan")
+	ErrIntentState      = errors.New("status payment intent tidak mengizinkan aksi ini")
+	ErrIntentExpired    = errors.New("payment intent sudah kedaluwarsa")
+)
+
+type MerchantService interface {
+	CreateMerchant(request MerchantRequest) (*Merchant, error)
+	ListMerchants(ownerUserID uint) ([]Merchant, error)
+	GetMerchant(id uint) (*Merchant, error)
+	GetMerchantBySettlementUser(userID uint) (*Merchant, error)
+	SetStatus(id uint, status MerchantStatus) (*Merchant, error)
+	IssueAPIKey(merchantID uint, request APIKeyRequest) (*APIKey, string, error)
+	ListAPIKeys(merchantID uint) ([]APIKey, error)
+	RevokeAPIKey(merchantID uint, keyID uint) error
+	Balance(merchantID uint) (*balance.BalanceSummary, error)
+	CreateIntent(merchantID uint, request IntentRequest) (*PaymentIntent, bool, error)
+	GetIntent(merchantID uint, intentID string) (*PaymentIntent, error)
+	CancelIntent(merchantID uint, intentID string, reason string) (*PaymentIntent, error)
+	RefundIntent(merchantID uint, intentID string, request RefundRequest) (*transactions.Transaction, error)
+	ViewIntent(intentID string) (*IntentView, error)
+	ConfirmIntent(customerID uint, intentID string, pin string, additionalInfo transactions.AdditionalInfo) (*PaymentIntent, *transactions.Transaction, error)
+}
+
+type merchantService struct {
+	repo         MerchantRepository
+	transactions transactions.TransactionService
+	pins         auth.PINVerifier
+	intentTTL    time.Duration
+	maxIntentTTL time.Duration
+}
+
+func NewMerchantService(repo MerchantRepository, transactionService transactions.TransactionService, pins auth.PINVerifier) MerchantService {
+	s := &merchantService{
+		repo:         repo,
+		transactions: transactionService,
+		pins:         pins,
+		intentTTL:    30 * time.Minute,
+		maxIntentTTL: 24 * time.Hour,
+	}
+	if minutes, err := strconv.Atoi(os.Getenv("PAYMENT_INTENT_TTL_MINUTES")); err == nil && minutes > 0 {
+		s.intentTTL = time.Duration(minutes) * time.Minute
+	}
+	return s
+}
+
+func (s *merchantService) CreateMerchant(request MerchantRequest) (*Merchant, error) {
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		return nil, errors.New("nama merchant wajib diisi")
+	}
+	currency, err := balance.NormalizeCurrency(request.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if request.CategoryCode != "" && len(request.CategoryCode) != 4 {
+		return nil, errors.New("category_code harus 4 digit MCC")
+	}
+
+	owner, err := s.repo.FindUser(request.OwnerUserID)
+	if err != nil || owner.Role == auth.RoleMerchant {
+		return nil, errors.New("pemilik merchant tidak ditemukan")
+	}
+
+	code, err := randomHex(4)
+	if err != nil {
+		return nil, err
+	}
+	code = "MRC" + strings.ToUpper(code)
+
+	// Password acak yang tidak pernah dibagikan membuat user settlement tidak bisa login.
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	password, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	merchant := &Merchant{
+		Code:         code,
+		Name:         name,
+		OwnerUserID:  owner.ID,
+		Currency:     currency,
+		CategoryCode: request.CategoryCode,
+		City:         strings.TrimSpace(request.City),
+		Status:       MerchantActive,
+	}
+	address := merchant.City
+	if address == "" {
+		address = "-"
+	}
+	settlementUser := &auth.User{
+		Username: "merchant_" + strings.ToLower(code),
+		FullName: name,
+		Password: string(password),
+		Email:    strings.ToLower(code) + "@merchant.invalid",
+		Address:  address,
+		DOB:      time.Now(),
+		Role:     auth.RoleMerchant,
+		KYCTier:  auth.KYCTierVerified,
+		Status:   auth.StatusActive,
+	}
+
+	if err := s.repo.CreateMerchant(merchant, settlementUser); err != nil {
+		return nil, err
+	}
+	log.Printf("SUCCESS: Merchant %s dibuat dengan wallet settlement user_id %d", merchant.Code, merchant.SettlementUserID)
+	return merchant, nil
+}
+
+func (s *merchantService) ListMerchants(ownerUserID uint) ([]Merchant, error) {
+	return s.repo.ListMerchants(ownerUserID)
+}
+
+func (s *merchantService) GetMerchant(id uint) (*Merchant, error) {
+	merchant, err := s.repo.FindMerchant(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMerchantNotFound
+		}
+		return nil, err
+	}
+	return merchant, nil
+}
+
+// GetMerchantBySettlementUser mencari merchant penerima sebuah pembayaran
+// dari CounterpartyUserID transaksinya.
+func (s *merchantService) GetMerchantBySettlementUser(userID uint) (*Merchant, error) {
+	merchant, err := s.repo.FindMerchantBySettlementUser(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMerchantNotFound
+		}
+		return nil, err
+	}
+	return merchant, nil
+}
+
+func (s *merchantService) SetStatus(id uint, status MerchantStatus) (*Merchant, error) {
+	merchant, err := s.GetMerchant(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateMerchantStatus(id, status); err != nil {
+		return nil, err
+	}
+	merchant.Status = status
+	return merchant, nil
+}
+
+// IssueAPIKey membuat API key baru. Key mentah hanya dikembalikan sekali.
+func (s *merchantService) IssueAPIKey(merchantID uint, request APIKeyRequest) (*APIKey, string, error) {
+	if _, err := s.GetMerchant(merchantID); err != nil {
+		return nil, "", err
+	}
+	scopes, err := normalizeScopes(request.Scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	raw, prefix, hash, err := generateAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+	key := &APIKey{
+		MerchantID: merchantID,
+		Name:       strings.TrimSpace(request.Name),
+		Prefix:     prefix,
+		KeyHash:    hash,
+		Scopes:     scopes,
+	}
+	if err := s.repo.CreateAPIKey(key); err != nil {
+		return nil, "", err
+	}
+	return key, raw, nil
+}
+
+func (s *merchantService) ListAPIKeys(merchantID uint) ([]APIKey, error) {
+	return s.repo.ListAPIKeys(merchantID)
+}
+
+func (s *merchantService) RevokeAPIKey(merchantID uint, keyID uint) error {
+	revoked, err := s.repo.RevokeAPIKey(merchantID, keyID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return errors.New("API key tidak ditemukan atau sudah dicabut")
+	}
+	return nil
+}
+
+func (s *merchantService) Balance(merchantID uint) (*balance.BalanceSummary, error) {
+	merchant, err := s.GetMerchant(merchantID)
+	if err != nil {
+		return nil, err
+	}
+	wallet, err := s.repo.FindSettlementWallet(merchant)
+	if err != nil {
+		return nil, err
+	}
+	return &balance.BalanceSummary{
+		Currency:         wallet.Currency,
+		Balance:          wallet.Balance,
+		AvailableBalance: wallet.Available(),
+		HeldBalance:      wallet.HeldBalance,
+	}, nil
+}
+
+// CreateIntent membuat payment intent. Bool kedua bernilai false bila intent
+// dengan merchant_reference yang sama sudah ada dan dikembalikan apa adanya.
+func (s *merchantService) CreateIntent(merchantID uint, request IntentRequest) (*PaymentIntent, bool, error) {
+	merchant, err := s.GetMerchant(merchantID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	reference := strings.TrimSpace(request.MerchantReference)
+	if reference == "" {
+		return nil, false, errors.New("merchant_reference wajib diisi")
+	}
+	if existing, err := s.repo.FindIntentByMerchantReference(merchant.ID, reference); err == nil {
+		if existing.Amount != request.Amount {
+			return nil, false, errors.New("merchant_reference sudah dipakai untuk nominal yang berbeda")
+		}
+		s.refresh(existing)
+		return existing, false, nil
+	}
+
+	currency := merchant.Currency
+	if request.Currency != "" && !strings.EqualFold(request.Currency, currency) {
+		return nil, false, fmt.Errorf("merchant hanya menerima %s", currency)
+	}
+	if err := balance.ValidateAmount(request.Amount, currency); err != nil {
+		return nil, false, err
+	}
+
+	ttl := s.intentTTL
+	if request.ExpiresInMinutes > 0 {
+		ttl = time.Duration(request.ExpiresInMinutes) * time.Minute
+	}
+	if ttl > s.maxIntentTTL {
+		return nil, false, fmt.Errorf("masa berlaku maksimal %d menit", int(s.maxIntentTTL.Minutes()))
+	}
+
+	suffix, err := randomHex(12)
+	if err != nil {
+		return nil, false, err
+	}
+	intent := &PaymentIntent{
+		IntentID:          "pi_" + suffix,
+		MerchantID:        merchant.ID,
+		MerchantReference: reference,
+		Amount:            request.Amount,
+		Currency:          currency,
+		Description:       strings.TrimSpace(request.Description),
+		Metadata:          request.Metadata,
+		Status:            IntentRequiresConfirmation,
+		ExpiresAt:         time.Now().Add(ttl),
+	}
+	if err := s.repo.CreateIntent(intent); err != nil {
+		return nil, false, err
+	}
+	return intent, true, nil
+}
+
+func (s *merchantService) GetIntent(merchantID uint, intentID string) (*PaymentIntent, error) {
+	intent, err := s.findIntent(intentID)
+	if err != nil {
+		return nil, err
+	}
+	if intent.MerchantID != merchantID {
+		return nil, ErrIntentNotFound
+	}
+	s.refresh(intent)
+	return intent, nil
+}
+
+func (s *merchantService) CancelIntent(merchantID uint, intentID string, reason string) (*PaymentIntent, error) {
+	intent, err := s.GetIntent(merchantID, intentID)
+	if err != nil {
+		return nil, err
+	}
+	if intent.Status != IntentRequiresConfirmation {
+		return nil, ErrIntentState
+	}
+
+	reason = strings.TrimSpace(reason)
+	ok, err := s.repo.TransitionIntent(intent.ID, []IntentStatus{IntentRequiresConfirmation}, map[string]interface{}{
+		"status":              IntentCancelled,
+		"cancellation_reason": reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrIntentState
+	}
+	intent.Status = IntentCancelled
+	intent.CancellationReason = reason
+	return intent, nil
+}
+
+// RefundIntent mengembalikan sebagian atau seluruh pembayaran intent yang
+// SUCCEEDED dari wallet settlement merchant ke customer. Reference dari
+// merchant diberi awalan intent supaya tidak bertabrakan antarmerchant.
+func (s *merchantService) RefundIntent(merchantID uint, intentID string, request RefundRequest) (*transactions.Transaction, error) {
+	intent, err := s.GetIntent(merchantID, intentID)
+	if err != nil {
+		return nil, err
+	}
+	if intent.Status != IntentSucceeded {
+		return nil, ErrIntentState
+	}
+	reference := strings.TrimSpace(request.Reference)
+	if reference == "" {
+		return nil, errors.New("reference wajib diisi")
+	}
+
+	merchant, err := s.GetMerchant(merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	description := "Refund dari " + merchant.Name
+	if reason := strings.TrimSpace(request.Reason); reason != "" {
+		description += ": " + reason
+	}
+	return s.transactions.RefundMerchantPayment(merchant.SettlementUserID, intent.TransactionReference, request.Amount, "PIR-"+intent.IntentID+"-"+reference, description)
+}
+
+// ViewIntent menampilkan intent kepada customer yang akan membayar.
+func (s *merchantService) ViewIntent(intentID string) (*IntentView, error) {
+	intent, err := s.findIntent(intentID)
+	if err != nil {
+		return nil, err
+	}
+	s.refresh(intent)
+
+	merchant, err := s.GetMerchant(intent.MerchantID)
+	if err != nil {
+		return nil, err
+	}
+	return &IntentView{PaymentIntent: *intent, MerchantName: merchant.Name}, nil
+}
+
+// ConfirmIntent membayar intent dari wallet customer ke wallet settlement
+// merchant. Intent diklaim ke PROCESSING lebih dulu supaya konfirmasi ganda
+// tidak membayar dua kali; bila pembayaran gagal sebelum dana bergerak,
+// intent dikembalikan ke REQUIRES_CONFIRMATION agar bisa dicoba lagi dengan
+// reference percobaan berikutnya.
+func (s *merchantService) ConfirmIntent(customerID uint, intentID string, pin string, additionalInfo transactions.AdditionalInfo) (*PaymentIntent, *transactions.Transaction, error) {
+	intent, err := s.findIntent(intentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.refresh(intent)
+	if intent.Status == IntentExpired {
+		return nil, nil, ErrIntentExpired
+	}
+	if intent.Status != IntentRequiresConfirmation {
+		return nil, nil, ErrIntentState
+	}
+
+	merchant, err := s.GetMerchant(intent.MerchantID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if merchant.Status != MerchantActive {
+		return nil, nil, errors.New("merchant sedang tidak menerima pembayaran")
+	}
+	if customerID == merchant.SettlementUserID || customerID == merchant.OwnerUserID {
+		return nil, nil, errors.New("pemilik merchant tidak dapat membayar intent miliknya sendiri")
+	}
+
+	if err := s.pins.VerifyPIN(customerID, pin); err != nil {
+		return nil, nil, err
+	}
+
+	ok, err := s.repo.TransitionIntent(intent.ID, []IntentStatus{IntentRequiresConfirmation}, map[string]interface{}{
+		"status":           IntentProcessing,
+		"customer_user_id": customerID,
+		"attempts":         gorm.Expr("attempts + 1"),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		return nil, nil, ErrIntentState
+	}
+	// Muat ulang untuk nomor percobaan yang baru saja diklaim.
+	intent, err = s.findIntent(intentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if additionalInfo == nil {
+		additionalInfo = make(transactions.AdditionalInfo)
+	}
+	additionalInfo["channel"] = "MERCHANT"
+	additionalInfo["merchant_id"] = merchant.ID
+	additionalInfo["merchant_code"] = merchant.Code
+	additionalInfo["payment_intent_id"] = intent.IntentID
+	additionalInfo["merchant_reference"] = intent.MerchantReference
+
+	description := "Pembayaran ke " + merchant.Name
+	if intent.Description != "" {
+		description += ": " + intent.Description
+	}
+
+	transaction, err := s.transactions.PayMerchant(customerID, merchant.SettlementUserID, intent.Amount, intent.Currency, intent.AttemptReference(), description, additionalInfo)
+	if err != nil {
+		if _, resetErr := s.repo.TransitionIntent(intent.ID, []IntentStatus{IntentProcessing}, map[string]interface{}{
+			"status":           IntentRequiresConfirmation,
+			"customer_user_id": 0,
+		}); resetErr != nil {
+			log.Printf("ERROR: Gagal mengembalikan status payment intent %s: %v", intent.IntentID, resetErr)
+		}
+		return nil, nil, err
+	}
+
+	s.applyTransaction(intent, transaction)
+	return intent, transaction, nil
+}
+
+func (s *merchantService) findIntent(intentID string) (*PaymentIntent, error) {
+	intent, err := s.repo.FindIntent(intentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrIntentNotFound
+		}
+		return nil, err
+	}
+	return intent, nil
+}
+
+// refresh menyelaraskan status intent secara lazy: intent yang lewat masa
+// berlakunya menjadi EXPIRED, dan intent PROCESSING mengikuti status
+// transaksinya (misalnya setelah review fraud selesai).
+func (s *merchantService) refresh(intent *PaymentIntent) {
+	switch intent.Status {
+	case IntentRequiresConfirmation:
+		if time.Now().Before(intent.ExpiresAt) {
+			return
+		}
+		ok, err := s.repo.TransitionIntent(intent.ID, []IntentStatus{IntentRequiresConfirmation}, map[string]interface{}{"status": IntentExpired})
+		if err == nil && ok {
+			intent.Status = IntentExpired
+		}
+	case IntentProcessing:
+		if intent.TransactionReference == "" {
+			return
+		}
+		transaction, err := s.transactions.GetTransactionByReference(intent.TransactionReference)
+		if err != nil {
+			return
+		}
+		s.applyTransaction(intent, transaction)
+	}
+}
+
+func (s *merchantService) applyTransaction(intent *PaymentIntent, transaction *transactions.Transaction) {
+	updates := map[string]interface{}{"transaction_reference": transaction.Reference}
+	status := IntentProcessing
+	switch transaction.TransactionStatus {
+	case transactions.StatusSuccess:
+		status = IntentSucceeded
+		now := time.Now()
+		updates["confirmed_at"] = &now
+		intent.ConfirmedAt = &now
+	case transactions.StatusFailed:
+		status = IntentFailed
+	}
+	updates["status"] = status
+
+	if _, err := s.repo.TransitionIntent(intent.ID, []IntentStatus{IntentProcessing}, updates); err != nil {
+		log.Printf("ERROR: Gagal memperbarui payment intent %s: %v", intent.IntentID, err)
+		return
+	}
+	intent.Status = status
+	intent.TransactionReference = transaction.Reference
+}
+
+func randomHex(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
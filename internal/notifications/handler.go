@@ -0,0 +1,54 @@
+package notifications
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+type NotificationHandler struct {
+	service NotificationService
+}
+
+func NewNotificationHandler(service NotificationService) *NotificationHandler {
+	return &NotificationHandler{service: service}
+}
+
+func (h *NotificationHandler) ListHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	notifications, unread, err := h.service.List(userID, c.QueryBool("unread", false))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": notifications, "unread": unread})
+}
+
+func (h *NotificationHandler) MarkReadHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	updated, err := h.service.MarkRead(userID, uint(id))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+	if !updated {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": "Notifikasi tidak ditemukan atau sudah dibaca"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Notifikasi ditandai sudah dibaca"})
+}
+
+func (h *NotificationHandler) MarkAllReadHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	count, err := h.service.MarkAllRead(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "Semua notifikasi ditandai sudah dibaca", "data": fiber.Map{"updated": count}})
+}
@@ -0,0 +1,45 @@
+package notifications
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Data adalah payload tambahan notifikasi, misalnya id objek yang dirujuk.
+type Data map[string]interface{}
+
+func (d Data) Value() (driver.Value, error) {
+	return json.Marshal(d)
+}
+
+func (d *Data) Scan(value interface{}) error {
+	if value == nil {
+		*d = make(Data)
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("failed to unmarshal JSON")
+	}
+	return json.Unmarshal(bytes, d)
+}
+
+// Notification adalah pesan in-app untuk user.
+type Notification struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index:idx_notification_user" json:"user_id"`
+	Type      string     `gorm:"type:varchar(50);not null" json:"type"`
+	Title     string     `gorm:"type:varchar(150);not null" json:"title"`
+	Body      string     `gorm:"type:varchar(500);not null" json:"body"`
+	Data      Data       `gorm:"type:json" json:"data,omitempty"`
+	ReadAt    *time.Time `gorm:"index:idx_notification_user" json:"read_at,omitempty"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// Notifier dipakai service lain untuk mengirim notifikasi. Kegagalan
+// pengiriman tidak boleh menggagalkan operasi utamanya.
+type Notifier interface {
+	Notify(userID uint, notificationType string, title string, body string, data Data)
+}
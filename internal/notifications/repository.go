@@ -0,0 +1,56 @@
+package notifications
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type NotificationRepository interface {
+	Create(notification *Notification) error
+	List(userID uint, unreadOnly bool, limit int) ([]Notification, error)
+	CountUnread(userID uint) (int64, error)
+	MarkRead(userID uint, id uint, at time.Time) (bool, error)
+	MarkAllRead(userID uint, at time.Time) (int64, error)
+}
+
+type notificationRepository struct {
+	DB *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{DB: db}
+}
+
+func (r *notificationRepository) Create(notification *Notification) error {
+	return r.DB.Create(notification).Error
+}
+
+func (r *notificationRepository) List(userID uint, unreadOnly bool, limit int) ([]Notification, error) {
+	var notifications []Notification
+	query := r.DB.Where("user_id = ?", userID).Order("id DESC").Limit(limit)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	err := query.Find(&notifications).Error
+	return notifications, err
+}
+
+func (r *notificationRepository) CountUnread(userID uint) (int64, error) {
+	var count int64
+	err := r.DB.Model(&Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *notificationRepository) MarkRead(userID uint, id uint, at time.Time) (bool, error) {
+	result := r.DB.Model(&Notification{}).Where("id = ? AND user_id = ? AND read_at IS NULL", id, userID).Update("read_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *notificationRepository) MarkAllRead(userID uint, at time.Time) (int64, error) {
+	result := r.DB.Model(&Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Update("read_at", at)
+	return result.RowsAffected, result.Error
+}
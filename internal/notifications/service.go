@@ -0,0 +1,55 @@
+package notifications
+
+import (
+	"log"
+	"time"
+)
+
+type NotificationService interface {
+	Notifier
+	List(userID uint, unreadOnly bool) ([]Notification, int64, error)
+	MarkRead(userID uint, id uint) (bool, error)
+	MarkAllRead(userID uint) (int64, error)
+}
+
+type notificationService struct {
+	repo NotificationRepository
+}
+
+func NewNotificationService(repo NotificationRepository) NotificationService {
+	return &notificationService{repo: repo}
+}
+
+// Notify menyimpan notifikasi in-app. Error hanya dicatat di log.
+func (s *notificationService) Notify(userID uint, notificationType string, title string, body string, data Data) {
+	notification := &Notification{
+		UserID: userID,
+		Type:   notificationType,
+		Title:  title,
+		Body:   body,
+		Data:   data,
+	}
+	if err := s.repo.Create(notification); err != nil {
+		log.Printf("ERROR: Gagal menyimpan notifikasi %s untuk user_id %d: %v", notificationType, userID, err)
+	}
+}
+
+func (s *notificationService) List(userID uint, unreadOnly bool) ([]Notification, int64, error) {
+	notifications, err := s.repo.List(userID, unreadOnly, 100)
+	if err != nil {
+		return nil, 0, err
+	}
+	unread, err := s.repo.CountUnread(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return notifications, unread, nil
+}
+
+func (s *notificationService) MarkRead(userID uint, id uint) (bool, error) {
+	return s.repo.MarkRead(userID, id, time.Now())
+}
+
+func (s *notificationService) MarkAllRead(userID uint) (int64, error) {
+	return s.repo.MarkAllRead(userID, time.Now())
+}
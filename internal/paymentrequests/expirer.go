@@ -0,0 +1,38 @@
+package paymentrequests
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// StartExpirer secara berkala menyelesaikan permintaan dana PROCESSING dan
+// menandai permintaan yang lewat masa berlakunya sebagai EXPIRED sampai ctx
+// dibatalkan.
+func StartExpirer(ctx context.Context, service PaymentRequestService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			settled, err := service.SettleProcessing(now)
+			if err != nil {
+				log.Printf("ERROR: Gagal menyelesaikan permintaan dana PROCESSING: %v", err)
+			} else if settled > 0 {
+				log.Printf("SUCCESS: %d permintaan dana PROCESSING diselesaikan", settled)
+			}
+
+			expired, err := service.ExpireDue(now)
+			if err != nil {
+				log.Printf("ERROR: Gagal memproses permintaan dana kedaluwarsa: %v", err)
+				continue
+			}
+			if expired > 0 {
+				log.Printf("SUCCESS: %d permintaan dana kedaluwarsa", expired)
+			}
+		}
+	}
+}
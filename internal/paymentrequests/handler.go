@@ -0,0 +1,215 @@
+package paymentrequests
+
+import (
+	"errors"
+	"ewallet-engine/internal/audit"
+	"ewallet-engine/internal/auth"
+	"ewallet-engine/internal/transactions"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PaymentRequestHandler struct {
+	service      PaymentRequestService
+	auditService audit.AuditService
+}
+
+func NewPaymentRequestHandler(service PaymentRequestService, auditService audit.AuditService) *PaymentRequestHandler {
+	return &PaymentRequestHandler{service: service, auditService: auditService}
+}
+
+func (h *PaymentRequestHandler) CreateHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	var request CreateRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	paymentRequest, err := h.service.Create(userID, request)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionPaymentRequestCreated,
+		TargetType: "payment_request",
+		TargetID:   fmt.Sprint(paymentRequest.ID),
+		After:      requestSnapshot(paymentRequest),
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Permintaan dana berhasil dikirim",
+		"data":    paymentRequest,
+	})
+}
+
+func (h *PaymentRequestHandler) IncomingHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	requests, err := h.service.ListIncoming(userID, RequestStatus(c.Query("status")))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": requests})
+}
+
+func (h *PaymentRequestHandler) OutgoingHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	requests, err := h.service.ListOutgoing(userID, RequestStatus(c.Query("status")))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": requests})
+}
+
+func (h *PaymentRequestHandler) GetHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	paymentRequest, err := h.service.Get(userID, uint(id))
+	if err != nil {
+		return h.requestError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": paymentRequest})
+}
+
+func (h *PaymentRequestHandler) AcceptHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	var request struct {
+		PIN string `json:"pin"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	additionalInfo := transactions.AdditionalInfo{
+		"device_id": c.Get("X-Device-ID"),
+		"ip":        c.IP(),
+	}
+
+	paymentRequest, transaction, err := h.service.Accept(userID, uint(id), request.PIN, additionalInfo)
+	if err != nil {
+		return h.requestError(c, err)
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionPaymentRequestAccepted,
+		TargetType: "payment_request",
+		TargetID:   fmt.Sprint(paymentRequest.ID),
+		Before:     audit.Snapshot{"status": StatusPending},
+		After:      requestSnapshot(paymentRequest),
+	})
+
+	message := "Permintaan dana berhasil dibayar"
+	if transaction.TransactionStatus == transactions.StatusPending {
+		message = "Pembayaran dibuat dan sedang direview"
+	}
+
+	return c.JSON(fiber.Map{
+		"message": message,
+		"data":    fiber.Map{"payment_request": paymentRequest, "transaction": transaction},
+	})
+}
+
+func (h *PaymentRequestHandler) DeclineHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	var request struct {
+		Reason string `json:"reason"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+	}
+
+	paymentRequest, err := h.service.Decline(userID, uint(id), request.Reason)
+	if err != nil {
+		return h.requestError(c, err)
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionPaymentRequestDeclined,
+		TargetType: "payment_request",
+		TargetID:   fmt.Sprint(paymentRequest.ID),
+		Before:     audit.Snapshot{"status": StatusPending},
+		After:      requestSnapshot(paymentRequest),
+	})
+
+	return c.JSON(fiber.Map{"message": "Permintaan dana ditolak", "data": paymentRequest})
+}
+
+func (h *PaymentRequestHandler) CancelHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	paymentRequest, err := h.service.Cancel(userID, uint(id))
+	if err != nil {
+		return h.requestError(c, err)
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionPaymentRequestCancelled,
+		TargetType: "payment_request",
+		TargetID:   fmt.Sprint(paymentRequest.ID),
+		Before:     audit.Snapshot{"status": StatusPending},
+		After:      requestSnapshot(paymentRequest),
+	})
+
+	return c.JSON(fiber.Map{"message": "Permintaan dana dibatalkan", "data": paymentRequest})
+}
+
+func (h *PaymentRequestHandler) requestError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, ErrRequestNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, ErrRequestClosed), errors.Is(err, ErrRequestExpired):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, auth.ErrPINLocked):
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, auth.ErrInvalidPIN), errors.Is(err, auth.ErrPINNotSet):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"message": err.Error()})
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+}
+
+func requestSnapshot(request *PaymentRequest) audit.Snapshot {
+	return audit.Snapshot{
+		"status":                request.Status,
+		"requester_id":          request.RequesterID,
+		"payer_id":              request.PayerID,
+		"amount":                request.Amount,
+		"currency":              request.Currency,
+		"transaction_reference": request.TransactionReference,
+	}
+}
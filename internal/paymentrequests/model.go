@@ -0,0 +1,61 @@
+package paymentrequests
+
+import (
+	"fmt"
+	"time"
+)
+
+type RequestStatus string
+
+const (
+	StatusPending RequestStatus = "PENDING"
+	// StatusProcessing: payer sudah menerima permintaan tetapi TRANSFER-nya
+	// belum SUCCESS, misalnya masih direview tim fraud.
+	StatusProcessing RequestStatus = "PROCESSING"
+	StatusPaid       RequestStatus = "PAID"
+	StatusDeclined   RequestStatus = "DECLINED"
+	StatusCancelled  RequestStatus = "CANCELLED"
+	StatusExpired    RequestStatus = "EXPIRED"
+)
+
+// PaymentRequest adalah permintaan dana dari RequesterID kepada PayerID.
+// TransactionReference menunjuk TRANSFER dari payer ke requester untuk
+// percobaan terakhir; Attempts menghitung percobaan pembayaran.
+type PaymentRequest struct {
+	ID                   uint          `gorm:"primaryKey" json:"id"`
+	RequesterID          uint          `gorm:"not null;index" json:"requester_id"`
+	PayerID              uint          `gorm:"not null;index:idx_payment_request_payer" json:"payer_id"`
+	Amount               float64       `gorm:"not null" json:"amount"`
+	Currency             string        `gorm:"type:char(3);not null;default:'IDR'" json:"currency"`
+	Note                 string        `gorm:"type:varchar(255)" json:"note,omitempty"`
+	Status               RequestStatus `gorm:"type:enum('PENDING','PROCESSING','PAID','DECLINED','CANCELLED','EXPIRED');default:'PENDING';index:idx_payment_request_payer" json:"status"`
+	TransactionReference string        `gorm:"type:varchar(100)" json:"transaction_reference,omitempty"`
+	Attempts             int           `gorm:"not null;default:0" json:"attempts"`
+	DeclineReason        string        `gorm:"type:varchar(255)" json:"decline_reason,omitempty"`
+	ExpiresAt            time.Time     `gorm:"not null;index" json:"expires_at"`
+	RespondedAt          *time.Time    `json:"responded_at,omitempty"`
+	CreatedAt            time.Time     `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt            time.Time     `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// transferReference adalah reference TRANSFER untuk percobaan ke-attempt.
+// Percobaan pertama memakai PRQ-<id>; percobaan ulang setelah TRANSFER gagal
+// mendapat reference baru karena reference lama sudah tercatat FAILED.
+func transferReference(id uint, attempt int) string {
+	if attempt <= 1 {
+		return fmt.Sprintf("PRQ-%d", id)
+	}
+	return fmt.Sprintf("PRQ-%d-%d", id, attempt)
+}
+
+// CreateRequest adalah input pembuatan permintaan dana. Payer dicari dari
+// PayerUserID, PayerPhone atau PayerUsername, mana yang diisi lebih dulu.
+type CreateRequest struct {
+	PayerUserID    uint    `json:"payer_user_id"`
+	PayerPhone     string  `json:"payer_phone"`
+	PayerUsername  string  `json:"payer_username"`
+	Amount         float64 `json:"amount"`
+	Currency       string  `json:"currency"`
+	Note           string  `json:"note"`
+	ExpiresInHours int     `json:"expires_in_hours"`
+}
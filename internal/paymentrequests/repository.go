@@ -0,0 +1,84 @@
+package paymentrequests
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PaymentRequestRepository interface {
+	Create(request *PaymentRequest) error
+	FindByID(id uint) (*PaymentRequest, error)
+	ListByPayer(payerID uint, status RequestStatus, limit int) ([]PaymentRequest, error)
+	ListByRequester(requesterID uint, status RequestStatus, limit int) ([]PaymentRequest, error)
+	CountPending(requesterID uint, payerID uint) (int64, error)
+	FindExpired(now time.Time, limit int) ([]PaymentRequest, error)
+	FindProcessing(limit int) ([]PaymentRequest, error)
+	TransitionStatus(id uint, from []RequestStatus, updates map[string]interface{}) (bool, error)
+}
+
+type paymentRequestRepository struct {
+	DB *gorm.DB
+}
+
+func NewPaymentRequestRepository(db *gorm.DB) PaymentRequestRepository {
+	return &paymentRequestRepository{DB: db}
+}
+
+func (r *paymentRequestRepository) Create(request *PaymentRequest) error {
+	return r.DB.Create(request).Error
+}
+
+func (r *paymentRequestRepository) FindByID(id uint) (*PaymentRequest, error) {
+	var request PaymentRequest
+	if err := r.DB.First(&request, id).Error; err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (r *paymentRequestRepository) ListByPayer(payerID uint, status RequestStatus, limit int) ([]PaymentRequest, error) {
+	return r.list("payer_id", payerID, status, limit)
+}
+
+func (r *paymentRequestRepository) ListByRequester(requesterID uint, status RequestStatus, limit int) ([]PaymentRequest, error) {
+	return r.list("requester_id", requesterID, status, limit)
+}
+
+func (r *paymentRequestRepository) list(column string, userID uint, status RequestStatus, limit int) ([]PaymentRequest, error) {
+	var requests []PaymentRequest
+	query := r.DB.Where(column+" = ?", userID).Order("id DESC").Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&requests).Error
+	return requests, err
+}
+
+func (r *paymentRequestRepository) CountPending(requesterID uint, payerID uint) (int64, error) {
+	var count int64
+	err := r.DB.Model(&PaymentRequest{}).
+		Where("requester_id = ? AND payer_id = ? AND status = ?", requesterID, payerID, StatusPending).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *paymentRequestRepository) FindExpired(now time.Time, limit int) ([]PaymentRequest, error) {
+	var requests []PaymentRequest
+	err := r.DB.Where("status = ? AND expires_at <= ?", StatusPending, now).Order("id ASC").Limit(limit).Find(&requests).Error
+	return requests, err
+}
+
+func (r *paymentRequestRepository) FindProcessing(limit int) ([]PaymentRequest, error) {
+	var requests []PaymentRequest
+	err := r.DB.Where("status = ?", StatusProcessing).Order("id ASC").Limit(limit).Find(&requests).Error
+	return requests, err
+}
+
+func (r *paymentRequestRepository) TransitionStatus(id uint, from []RequestStatus, updates map[string]interface{}) (bool, error) {
+	result := r.DB.Model(&PaymentRequest{}).Where("id = ? AND status IN ?", id, from).Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -0,0 +1,396 @@
+package paymentrequests
+
+import (
+	"errors"
+	"ewallet-engine/internal/auth"
+	"ewallet-engine/internal/balance"
+	"ewallet-engine/internal/notifications"
+	"ewallet-engine/internal/transactions"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// staleProcessing adalah batas waktu permintaan PROCESSING tanpa TRANSFER
+// tercatat sebelum dianggap terhenti dan dibuka kembali.
+const staleProcessing = 10 * time.Minute
+
+// maxPendingPerPayer membatasi permintaan PENDING dari satu requester ke
+// payer yang sama supaya fitur ini tidak dipakai untuk spam.
+const maxPendingPerPayer = 5
+
+// Jenis notifikasi permintaan dana.
+const (
+	NotificationReceived  = "PAYMENT_REQUEST_RECEIVED"
+	NotificationPaid      = "PAYMENT_REQUEST_PAID"
+	NotificationDeclined  = "PAYMENT_REQUEST_DECLINED"
+	NotificationCancelled = "PAYMENT_REQUEST_CANCELLED"
+	NotificationExpired   = "PAYMENT_REQUEST_EXPIRED"
+)
+
+var (
+	ErrRequestNotFound = errors.New("permintaan dana tidak ditemukan")
+	ErrRequestClosed   = errors.New("permintaan dana sudah tidak menunggu pembayaran")
+	ErrRequestExpired  = errors.New("permintaan dana sudah kedaluwarsa")
+	ErrTransferFailed  = errors.New("pembayaran permintaan dana gagal, silakan coba lagi")
+)
+
+// PaidListener dipanggil setelah permintaan dana dibayar, misalnya untuk
+// menandai utang split bill yang terkait sebagai lunas.
+type PaidListener func(request PaymentRequest, transaction *transactions.Transaction)
+
+type PaymentRequestService interface {
+	SetPaidListener(listener PaidListener)
+	Create(requesterID uint, request CreateRequest) (*PaymentRequest, error)
+	ListIncoming(payerID uint, status RequestStatus) ([]PaymentRequest, error)
+	ListOutgoing(requesterID uint, status RequestStatus) ([]PaymentRequest, error)
+	Get(userID uint, id uint) (*PaymentRequest, error)
+	Accept(payerID uint, id uint, pin string, additionalInfo transactions.AdditionalInfo) (*PaymentRequest, *transactions.Transaction, error)
+	Decline(payerID uint, id uint, reason string) (*PaymentRequest, error)
+	Cancel(requesterID uint, id uint) (*PaymentRequest, error)
+	ExpireDue(now time.Time) (int, error)
+	SettleProcessing(now time.Time) (int, error)
+}
+
+type paymentRequestService struct {
+	repo         PaymentRequestRepository
+	transactions transactions.TransactionService
+	pins         auth.PINVerifier
+	notifier     notifications.Notifier
+	onPaid       PaidListener
+	defaultTTL   time.Duration
+	maxTTL       time.Duration
+}
+
+func NewPaymentRequestService(repo PaymentRequestRepository, transactionService transactions.TransactionService, pins auth.PINVerifier, notifier notifications.Notifier) PaymentRequestService {
+	s := &paymentRequestService{
+		repo:         repo,
+		transactions: transactionService,
+		pins:         pins,
+		notifier:     notifier,
+		defaultTTL:   72 * time.Hour,
+		maxTTL:       30 * 24 * time.Hour,
+	}
+	if hours, err := strconv.Atoi(os.Getenv("PAYMENT_REQUEST_TTL_HOURS")); err == nil && hours > 0 {
+		s.defaultTTL = time.Duration(hours) * time.Hour
+	}
+	return s
+}
+
+func (s *paymentRequestService) SetPaidListener(listener PaidListener) {
+	s.onPaid = listener
+}
+
+func (s *paymentRequestService) Create(requesterID uint, request CreateRequest) (*PaymentRequest, error) {
+	currency, err := balance.NormalizeCurrency(request.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if err := balance.ValidateAmount(request.Amount, currency); err != nil {
+		return nil, err
+	}
+
+	payer, err := s.transactions.FindRecipient(request.PayerUserID, request.PayerPhone, request.PayerUsername)
+	if err != nil {
+		return nil, errors.New("pengguna yang diminta tidak ditemukan")
+	}
+	if payer.ID == requesterID {
+		return nil, errors.New("tidak dapat meminta dana dari diri sendiri")
+	}
+	if payer.Status != auth.StatusActive {
+		return nil, errors.New("akun pengguna yang diminta tidak aktif")
+	}
+
+	pending, err := s.repo.CountPending(requesterID, payer.ID)
+	if err != nil {
+		return nil, err
+	}
+	if pending >= maxPendingPerPayer {
+		return nil, fmt.Errorf("masih ada %d permintaan yang belum dibalas oleh pengguna ini", pending)
+	}
+
+	ttl := s.defaultTTL
+	if request.ExpiresInHours > 0 {
+		ttl = time.Duration(request.ExpiresInHours) * time.Hour
+	}
+	if ttl > s.maxTTL {
+		return nil, fmt.Errorf("masa berlaku maksimal %d jam", int(s.maxTTL.Hours()))
+	}
+
+	paymentRequest := &PaymentRequest{
+		RequesterID: requesterID,
+		PayerID:     payer.ID,
+		Amount:      request.Amount,
+		Currency:    currency,
+		Note:        strings.TrimSpace(request.Note),
+		Status:      StatusPending,
+		ExpiresAt:   time.Now().Add(ttl),
+	}
+	if err := s.repo.Create(paymentRequest); err != nil {
+		return nil, err
+	}
+
+	s.notifier.Notify(payer.ID, NotificationReceived, "Permintaan dana baru",
+		fmt.Sprintf("Ada permintaan dana sebesar %s %.2f menunggu persetujuan Anda", currency, request.Amount),
+		notifications.Data{"payment_request_id": paymentRequest.ID})
+	return paymentRequest, nil
+}
+
+func (s *paymentRequestService) ListIncoming(payerID uint, status RequestStatus) ([]PaymentRequest, error) {
+	return s.repo.ListByPayer(payerID, RequestStatus(strings.ToUpper(string(status))), 100)
+}
+
+func (s *paymentRequestService) ListOutgoing(requesterID uint, status RequestStatus) ([]PaymentRequest, error) {
+	return s.repo.ListByRequester(requesterID, RequestStatus(strings.ToUpper(string(status))), 100)
+}
+
+// Get hanya mengembalikan permintaan milik requester atau payer-nya.
+func (s *paymentRequestService) Get(userID uint, id uint) (*PaymentRequest, error) {
+	request, err := s.repo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRequestNotFound
+		}
+		return nil, err
+	}
+	if request.RequesterID != userID && request.PayerID != userID {
+		return nil, ErrRequestNotFound
+	}
+	return request, nil
+}
+
+// Accept membayar permintaan dengan TRANSFER dari payer ke requester setelah
+// PIN diverifikasi. Selama transfer berjalan permintaan berstatus PROCESSING
+// sehingga tidak bisa dibatalkan atau kedaluwarsa, dan baru menjadi PAID
+// setelah TRANSFER SUCCESS. TRANSFER yang masih PENDING diselesaikan oleh
+// SettleProcessing; TRANSFER yang gagal membuka kembali permintaan dan
+// percobaan berikutnya memakai reference baru.
+func (s *paymentRequestService) Accept(payerID uint, id uint, pin string, additionalInfo transactions.AdditionalInfo) (*PaymentRequest, *transactions.Transaction, error) {
+	request, err := s.pendingFor(id, func(r *PaymentRequest) bool { return r.PayerID == payerID })
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.pins.VerifyPIN(payerID, pin); err != nil {
+		return nil, nil, err
+	}
+
+	if additionalInfo == nil {
+		additionalInfo = make(transactions.AdditionalInfo)
+	}
+	additionalInfo["payment_request_id"] = request.ID
+
+	description := "Pembayaran permintaan dana"
+	if request.Note != "" {
+		description += ": " + request.Note
+	}
+
+	attempt := request.Attempts + 1
+	reference := transferReference(request.ID, attempt)
+	if err := s.close(request, StatusProcessing, map[string]interface{}{"attempts": attempt, "transaction_reference": reference}); err != nil {
+		return nil, nil, err
+	}
+	request.Attempts = attempt
+	request.TransactionReference = reference
+
+	transaction, err := s.transactions.Transfer(payerID, request.RequesterID, request.Amount, request.Currency, reference, description, additionalInfo)
+	if err != nil {
+		s.reopen(request)
+		return nil, nil, err
+	}
+	if err := s.settle(request, transaction); err != nil {
+		return nil, nil, err
+	}
+	return request, transaction, nil
+}
+
+func (s *paymentRequestService) Decline(payerID uint, id uint, reason string) (*PaymentRequest, error) {
+	request, err := s.pendingFor(id, func(r *PaymentRequest) bool { return r.PayerID == payerID })
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	reason = strings.TrimSpace(reason)
+	if err := s.close(request, StatusDeclined, map[string]interface{}{"decline_reason": reason, "responded_at": &now}); err != nil {
+		return nil, err
+	}
+	request.DeclineReason = reason
+	request.RespondedAt = &now
+
+	s.notifier.Notify(request.RequesterID, NotificationDeclined, "Permintaan dana ditolak",
+		fmt.Sprintf("Permintaan dana sebesar %s %.2f ditolak", request.Currency, request.Amount),
+		notifications.Data{"payment_request_id": request.ID})
+	return request, nil
+}
+
+func (s *paymentRequestService) Cancel(requesterID uint, id uint) (*PaymentRequest, error) {
+	request, err := s.pendingFor(id, func(r *PaymentRequest) bool { return r.RequesterID == requesterID })
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.close(request, StatusCancelled, nil); err != nil {
+		return nil, err
+	}
+
+	s.notifier.Notify(request.PayerID, NotificationCancelled, "Permintaan dana dibatalkan",
+		fmt.Sprintf("Permintaan dana sebesar %s %.2f dibatalkan oleh pengirimnya", request.Currency, request.Amount),
+		notifications.Data{"payment_request_id": request.ID})
+	return request, nil
+}
+
+// ExpireDue menandai permintaan PENDING yang sudah lewat masa berlakunya
+// sebagai EXPIRED dan memberi tahu kedua pihak.
+func (s *paymentRequestService) ExpireDue(now time.Time) (int, error) {
+	expired, err := s.repo.FindExpired(now, 500)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := range expired {
+		if err := s.close(&expired[i], StatusExpired, nil); err != nil {
+			continue
+		}
+		s.notifyExpired(&expired[i])
+		count++
+	}
+	return count, nil
+}
+
+// SettleProcessing menyelesaikan permintaan PROCESSING sesuai status
+// TRANSFER-nya dan mengembalikan jumlah permintaan yang selesai. Permintaan
+// yang TRANSFER-nya tidak pernah tercatat dibuka kembali setelah staleProcessing.
+func (s *paymentRequestService) SettleProcessing(now time.Time) (int, error) {
+	processing, err := s.repo.FindProcessing(500)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := range processing {
+		request := &processing[i]
+		transaction, err := s.transactions.GetTransactionByReference(request.TransactionReference)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if now.Sub(request.UpdatedAt) >= staleProcessing {
+				s.reopen(request)
+				count++
+			}
+			continue
+		}
+		if err != nil {
+			log.Printf("ERROR: Gagal mengambil transaksi %s untuk permintaan dana %d: %v", request.TransactionReference, request.ID, err)
+			continue
+		}
+		if transaction.TransactionStatus == transactions.StatusPending {
+			continue
+		}
+		s.settle(request, transaction)
+		count++
+	}
+	return count, nil
+}
+
+// settle menerapkan status TRANSFER ke permintaan PROCESSING. Hanya TRANSFER
+// SUCCESS yang membuat permintaan PAID; PENDING dibiarkan menunggu.
+func (s *paymentRequestService) settle(request *PaymentRequest, transaction *transactions.Transaction) error {
+	switch transaction.TransactionStatus {
+	case transactions.StatusSuccess:
+		s.markPaid(request, transaction)
+	case transactions.StatusPending:
+	default:
+		s.reopen(request)
+		return ErrTransferFailed
+	}
+	return nil
+}
+
+func (s *paymentRequestService) markPaid(request *PaymentRequest, transaction *transactions.Transaction) {
+	now := time.Now()
+	ok, err := s.repo.TransitionStatus(request.ID, []RequestStatus{StatusProcessing}, map[string]interface{}{
+		"status":                StatusPaid,
+		"transaction_reference": transaction.Reference,
+		"responded_at":          &now,
+	})
+	if err != nil || !ok {
+		log.Printf("ERROR: Permintaan dana %d dibayar dengan %s tetapi status gagal diperbarui: %v", request.ID, transaction.Reference, err)
+		return
+	}
+	request.Status = StatusPaid
+	request.TransactionReference = transaction.Reference
+	request.RespondedAt = &now
+
+	if s.onPaid != nil {
+		s.onPaid(*request, transaction)
+	}
+
+	s.notifier.Notify(request.RequesterID, NotificationPaid, "Permintaan dana dibayar",
+		fmt.Sprintf("Permintaan dana sebesar %s %.2f telah dibayar", request.Currency, request.Amount),
+		notifications.Data{"payment_request_id": request.ID, "reference": transaction.Reference})
+}
+
+// reopen mengembalikan permintaan PROCESSING ke PENDING setelah TRANSFER gagal.
+// Permintaan yang sudah lewat masa berlakunya akan diproses ExpireDue.
+func (s *paymentRequestService) reopen(request *PaymentRequest) {
+	ok, err := s.repo.TransitionStatus(request.ID, []RequestStatus{StatusProcessing}, map[string]interface{}{"status": StatusPending})
+	if err != nil || !ok {
+		log.Printf("ERROR: Gagal membuka kembali permintaan dana %d: %v", request.ID, err)
+		return
+	}
+	request.Status = StatusPending
+}
+
+// pendingFor mengambil permintaan PENDING yang boleh diakses user (owns).
+// Permintaan yang sudah lewat masa berlakunya langsung ditandai EXPIRED.
+func (s *paymentRequestService) pendingFor(id uint, owns func(*PaymentRequest) bool) (*PaymentRequest, error) {
+	request, err := s.repo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRequestNotFound
+		}
+		return nil, err
+	}
+	if !owns(request) {
+		return nil, ErrRequestNotFound
+	}
+	if request.Status != StatusPending {
+		return nil, ErrRequestClosed
+	}
+	if !time.Now().Before(request.ExpiresAt) {
+		if err := s.close(request, StatusExpired, nil); err == nil {
+			s.notifyExpired(request)
+		}
+		return nil, ErrRequestExpired
+	}
+	return request, nil
+}
+
+func (s *paymentRequestService) close(request *PaymentRequest, status RequestStatus, extra map[string]interface{}) error {
+	updates := map[string]interface{}{"status": status}
+	for key, value := range extra {
+		updates[key] = value
+	}
+
+	ok, err := s.repo.TransitionStatus(request.ID, []RequestStatus{StatusPending}, updates)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrRequestClosed
+	}
+	request.Status = status
+	return nil
+}
+
+func (s *paymentRequestService) notifyExpired(request *PaymentRequest) {
+	body := fmt.Sprintf("Permintaan dana sebesar %s %.2f sudah kedaluwarsa", request.Currency, request.Amount)
+	data := notifications.Data{"payment_request_id": request.ID}
+	s.notifier.Notify(request.RequesterID, NotificationExpired, "Permintaan dana kedaluwarsa", body, data)
+	s.notifier.Notify(request.PayerID, NotificationExpired, "Permintaan dana kedaluwarsa", body, data)
+}
@@ -0,0 +1,244 @@
+package paymentrequests
+
+import (
+	"errors"
+	"ewallet-engine/internal/notifications"
+	"ewallet-engine/internal/transactions"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type fakePaymentRequestRepository struct {
+	PaymentRequestRepository
+	requests map[uint]*PaymentRequest
+}
+
+func (r *fakePaymentRequestRepository) FindByID(id uint) (*PaymentRequest, error) {
+	request, ok := r.requests[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *request
+	return &copied, nil
+}
+
+func (r *fakePaymentRequestRepository) FindProcessing(limit int) ([]PaymentRequest, error) {
+	var requests []PaymentRequest
+	for _, request := range r.requests {
+		if request.Status == StatusProcessing {
+			requests = append(requests, *request)
+		}
+	}
+	return requests, nil
+}
+
+func (r *fakePaymentRequestRepository) TransitionStatus(id uint, from []RequestStatus, updates map[string]interface{}) (bool, error) {
+	request, ok := r.requests[id]
+	if !ok {
+		return false, nil
+	}
+	for _, status := range from {
+		if request.Status != status {
+			continue
+		}
+		request.Status = updates["status"].(RequestStatus)
+		if attempts, ok := updates["attempts"].(int); ok {
+			request.Attempts = attempts
+		}
+		if reference, ok := updates["transaction_reference"].(string); ok {
+			request.TransactionReference = reference
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// fakeTransactionService memindahkan saldo per TRANSFER dan menyimpannya per
+// reference. Transfer ulang dengan reference yang sama mengembalikan transaksi
+// yang sudah ada, seperti transactionService. TRANSFER yang ditinjau fraud
+// (review) tetap PENDING dengan dana payer tertahan sampai settleTransfer.
+type fakeTransactionService struct {
+	transactions.TransactionService
+	transfers map[string]*transactions.Transaction
+	balances  map[uint]float64
+	limit     float64
+	review    bool
+}
+
+func (s *fakeTransactionService) Transfer(userID uint, recipientUserID uint, amount float64, currency string, reference string, description string, additionalInfo transactions.AdditionalInfo) (*transactions.Transaction, error) {
+	if existing, ok := s.transfers[reference]; ok {
+		return existing, nil
+	}
+	if amount > s.limit {
+		return nil, errors.New("jumlah transaksi melebihi limit")
+	}
+	transaction := &transactions.Transaction{UserID: userID, CounterpartyUserID: recipientUserID, Amount: amount, Reference: reference}
+	s.transfers[reference] = transaction
+	if s.balances[userID] < amount {
+		transaction.TransactionStatus = transactions.StatusFailed
+		return nil, transactions.ErrInsufficientFunds
+	}
+
+	s.balances[userID] -= amount
+	transaction.TransactionStatus = transactions.StatusPending
+	if !s.review {
+		s.settleTransfer(reference, transactions.StatusSuccess)
+	}
+	return transaction, nil
+}
+
+func (s *fakeTransactionService) settleTransfer(reference string, status transactions.TransactionStatus) {
+	transaction := s.transfers[reference]
+	if status == transactions.StatusSuccess {
+		s.balances[transaction.CounterpartyUserID] += transaction.Amount
+	} else {
+		s.balances[transaction.UserID] += transaction.Amount
+	}
+	transaction.TransactionStatus = status
+}
+
+func (s *fakeTransactionService) GetTransactionByReference(reference string) (*transactions.Transaction, error) {
+	transaction, ok := s.transfers[reference]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return transaction, nil
+}
+
+type acceptPIN struct{}
+
+func (acceptPIN) VerifyPIN(userID uint, pin string) error { return nil }
+
+type discardNotifier struct{}
+
+func (discardNotifier) Notify(userID uint, notificationType string, title string, body string, data notifications.Data) {
+}
+
+// Permintaan 1: user 3 meminta 50.000 dari user 7.
+func newFakePaymentRequestRepository() *fakePaymentRequestRepository {
+	return &fakePaymentRequestRepository{requests: map[uint]*PaymentRequest{
+		1: {ID: 1, RequesterID: 3, PayerID: 7, Amount: 50000, Currency: "IDR", Status: StatusPending, ExpiresAt: time.Now().Add(time.Hour)},
+	}}
+}
+
+func TestAcceptMovesFundsAndMarksPaid(t *testing.T) {
+	repo := newFakePaymentRequestRepository()
+	transfers := &fakeTransactionService{transfers: make(map[string]*transactions.Transaction), balances: map[uint]float64{7: 80000}, limit: 1000000}
+	service := &paymentRequestService{repo: repo, transactions: transfers, pins: acceptPIN{}, notifier: discardNotifier{}}
+
+	request, transaction, err := service.Accept(7, 1, "123456", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if request.Status != StatusPaid || repo.requests[1].Status != StatusPaid || repo.requests[1].TransactionReference != transaction.Reference {
+		t.Fatalf("expected PAID with the transfer reference; got %s, %q", repo.requests[1].Status, repo.requests[1].TransactionReference)
+	}
+	if transfers.balances[7] != 30000 || transfers.balances[3] != 50000 {
+		t.Fatalf("expected 50000 moved from payer to requester; got %.2f/%.2f", transfers.balances[7], transfers.balances[3])
+	}
+
+	if _, _, err := service.Accept(7, 1, "123456", nil); err == nil || transfers.balances[7] != 30000 {
+		t.Fatalf("expected a PAID request not to be paid twice; got %v, payer %.2f", err, transfers.balances[7])
+	}
+}
+
+func TestAcceptReviewedTransferFollowsSettlement(t *testing.T) {
+	cases := []struct {
+		name             string
+		settled          transactions.TransactionStatus
+		wantStatus       RequestStatus
+		wantPayer        float64
+		wantRequester    float64
+		wantPaidListener int
+	}{
+		{"cleared", transactions.StatusSuccess, StatusPaid, 30000, 50000, 1},
+		{"rejected", transactions.StatusFailed, StatusPending, 80000, 0, 0},
+	}
+
+	for _, tc := range cases {
+		repo := newFakePaymentRequestRepository()
+		transfers := &fakeTransactionService{transfers: make(map[string]*transactions.Transaction), balances: map[uint]float64{7: 80000}, limit: 1000000, review: true}
+		service := &paymentRequestService{repo: repo, transactions: transfers, pins: acceptPIN{}, notifier: discardNotifier{}}
+		paid := 0
+		service.SetPaidListener(func(request PaymentRequest, transaction *transactions.Transaction) { paid++ })
+
+		request, _, err := service.Accept(7, 1, "123456", nil)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if request.Status != StatusProcessing || transfers.balances[7] != 30000 || transfers.balances[3] != 0 {
+			t.Fatalf("%s: expected PROCESSING with the payer's funds held; got %s, %.2f/%.2f", tc.name, request.Status, transfers.balances[7], transfers.balances[3])
+		}
+		if _, err := service.Cancel(3, 1); !errors.Is(err, ErrRequestClosed) {
+			t.Fatalf("%s: expected a PROCESSING request to be closed for cancel; got %v", tc.name, err)
+		}
+		if settled, _ := service.SettleProcessing(time.Now()); settled != 0 || repo.requests[1].Status != StatusProcessing {
+			t.Fatalf("%s: expected request to keep waiting while the transfer is PENDING", tc.name)
+		}
+
+		transfers.settleTransfer("PRQ-1", tc.settled)
+		if settled, _ := service.SettleProcessing(time.Now()); settled != 1 || repo.requests[1].Status != tc.wantStatus {
+			t.Errorf("%s: status = %s, want %s", tc.name, repo.requests[1].Status, tc.wantStatus)
+		}
+		if transfers.balances[7] != tc.wantPayer || transfers.balances[3] != tc.wantRequester || paid != tc.wantPaidListener {
+			t.Errorf("%s: payer/requester/paid = %.2f/%.2f/%d, want %.2f/%.2f/%d",
+				tc.name, transfers.balances[7], transfers.balances[3], paid, tc.wantPayer, tc.wantRequester, tc.wantPaidListener)
+		}
+	}
+}
+
+func TestAcceptFailedTransferReopensWithNewReference(t *testing.T) {
+	repo := newFakePaymentRequestRepository()
+	transfers := &fakeTransactionService{transfers: make(map[string]*transactions.Transaction), balances: map[uint]float64{7: 20000}, limit: 1000000}
+	service := &paymentRequestService{repo: repo, transactions: transfers, pins: acceptPIN{}, notifier: discardNotifier{}}
+
+	if _, _, err := service.Accept(7, 1, "123456", nil); !errors.Is(err, transactions.ErrInsufficientFunds) {
+		t.Fatalf("expected ErrInsufficientFunds; got %v", err)
+	}
+	if repo.requests[1].Status != StatusPending || transfers.balances[7] != 20000 || transfers.balances[3] != 0 {
+		t.Fatalf("expected request reopened without moving funds; got %s, %.2f/%.2f", repo.requests[1].Status, transfers.balances[7], transfers.balances[3])
+	}
+
+	// Reference pertama sudah tercatat FAILED, jadi percobaan ulang harus
+	// memakai reference baru dan tidak menandai PAID dari transaksi gagal.
+	transfers.balances[7] = 60000
+	request, transaction, err := service.Accept(7, 1, "123456", nil)
+	if err != nil {
+		t.Fatalf("unexpected error on retry: %v", err)
+	}
+	if transaction.Reference != "PRQ-1-2" || request.Status != StatusPaid {
+		t.Fatalf("expected PAID with reference PRQ-1-2; got %s %s", request.Status, transaction.Reference)
+	}
+	if transfers.balances[7] != 10000 || transfers.balances[3] != 50000 {
+		t.Fatalf("expected 50000 moved once; got %.2f/%.2f", transfers.balances[7], transfers.balances[3])
+	}
+}
+
+func TestAcceptAboveTransferLimitKeepsRequestOpen(t *testing.T) {
+	repo := newFakePaymentRequestRepository()
+	transfers := &fakeTransactionService{transfers: make(map[string]*transactions.Transaction), balances: map[uint]float64{7: 80000}, limit: 40000}
+	service := &paymentRequestService{repo: repo, transactions: transfers, pins: acceptPIN{}, notifier: discardNotifier{}}
+
+	if _, _, err := service.Accept(7, 1, "123456", nil); err == nil {
+		t.Fatalf("expected the transfer limit to reject the payment")
+	}
+	if repo.requests[1].Status != StatusPending || transfers.balances[7] != 80000 || transfers.balances[3] != 0 {
+		t.Fatalf("expected request reopened without moving funds; got %s, %.2f/%.2f", repo.requests[1].Status, transfers.balances[7], transfers.balances[3])
+	}
+}
+
+func TestAcceptRejectsExistingFailedTransfer(t *testing.T) {
+	repo := newFakePaymentRequestRepository()
+	transfers := &fakeTransactionService{transfers: make(map[string]*transactions.Transaction), balances: map[uint]float64{7: 80000}, limit: 1000000}
+	service := &paymentRequestService{repo: repo, transactions: transfers, pins: acceptPIN{}, notifier: discardNotifier{}}
+	transfers.transfers["PRQ-1"] = &transactions.Transaction{UserID: 7, CounterpartyUserID: 3, Amount: 50000, Reference: "PRQ-1", TransactionStatus: transactions.StatusFailed}
+
+	if _, _, err := service.Accept(7, 1, "123456", nil); !errors.Is(err, ErrTransferFailed) {
+		t.Fatalf("expected ErrTransferFailed; got %v", err)
+	}
+	if repo.requests[1].Status != StatusPending || transfers.balances[7] != 80000 || transfers.balances[3] != 0 {
+		t.Fatalf("expected request reopened without moving funds; got %s, %.2f/%.2f", repo.requests[1].Status, transfers.balances[7], transfers.balances[3])
+	}
+}
@@ -0,0 +1,211 @@
+package payouts
+
+import (
+	"context"
+	"errors"
+	"ewallet-engine/internal/balance"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// State adalah tahap payout yang dipahami Machine. Setiap domain memetakan
+// status miliknya sendiri ke State.
+type State string
+
+const (
+	// StatePending: record sudah dibuat, hold mungkin belum terpasang dan
+	// belum ada yang dikirim ke pihak eksternal.
+	StatePending State = "PENDING"
+	// StateProcessing: dana sudah di-hold dan pihak eksternal menerima permintaan.
+	StateProcessing State = "PROCESSING"
+	// StateUnknown: error atau timeout saat mengirim; hasil dipastikan lewat rekonsiliasi.
+	StateUnknown   State = "UNKNOWN"
+	StateSucceeded State = "SUCCEEDED"
+	StateFailed    State = "FAILED"
+)
+
+type Result string
+
+const (
+	ResultSuccess Result = "SUCCESS"
+	ResultFailed  Result = "FAILED"
+	ResultPending Result = "PENDING"
+)
+
+// Outcome adalah hasil dari bank atau biller yang sudah diseragamkan.
+type Outcome struct {
+	Result        Result
+	ExternalID    string
+	FailureReason string
+	// SerialNumber adalah nomor seri atau token dari biller; kosong untuk bank.
+	SerialNumber string
+}
+
+// Payout membungkus satu record domain (withdrawal, pembayaran tagihan, batch
+// settlement) supaya alurnya bisa dijalankan Machine.
+type Payout interface {
+	Reference() string
+	State() State
+	// Transition memindahkan record dari State() saat ini ke to hanya bila
+	// belum diubah proses lain, lalu memperbarui salinan di memori.
+	Transition(to State, outcome Outcome) (bool, error)
+	CaptureHold() error
+	ReleaseHold() error
+	// Succeeded dipanggil setelah hold di-capture. Failed dipanggil setelah
+	// payout ditandai gagal; held false berarti tidak ada hold yang dilepas.
+	Succeeded()
+	Failed(held bool)
+	// MarkChecked mencatat satu kali cek status dan mengembalikan jumlah cek sejauh ini.
+	MarkChecked(at time.Time) (int, error)
+	// SentAt adalah acuan masa tenggang saat pihak eksternal belum mengenal payout.
+	SentAt() time.Time
+}
+
+// Machine menjalankan alur payout yang dananya di-hold lebih dulu. Hold hanya
+// di-capture saat pihak eksternal mengonfirmasi SUCCESS dan hanya dilepas saat
+// mengonfirmasi FAILED; error dan timeout menjadi UNKNOWN sampai rekonsiliasi
+// mendapat status pasti.
+type Machine struct {
+	// Label dipakai di log, misalnya "Penarikan".
+	Label         string
+	Timeout       time.Duration
+	NotFoundGrace time.Duration
+	MaxChecks     int
+	// NotFound adalah error pihak eksternal untuk payout yang tidak pernah
+	// diterimanya; setelah NotFoundGrace payout dianggap gagal dengan
+	// NotFoundReason.
+	NotFound       error
+	NotFoundReason string
+}
+
+// Send memindahkan payout yang sudah di-hold ke PROCESSING lalu mengirimnya.
+func (m Machine) Send(p Payout, send func(ctx context.Context) (*Outcome, error)) error {
+	ok, err := p.Transition(StateProcessing, Outcome{})
+	if err != nil || !ok {
+		return fmt.Errorf("gagal memproses %s %s", strings.ToLower(m.Label), p.Reference())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.Timeout)
+	defer cancel()
+	outcome, err := send(ctx)
+	if err != nil {
+		// Pihak eksternal mungkin sudah memproses payout; dana tetap di-hold
+		// sampai rekonsiliasi mendapat status pasti.
+		log.Printf("ERROR: %s %s tidak pasti: %v", m.Label, p.Reference(), err)
+		m.markUnknown(p, err.Error())
+		return nil
+	}
+
+	m.Apply(p, *outcome)
+	return nil
+}
+
+// Reconcile menanyakan status payout PROCESSING/UNKNOWN ke pihak eksternal.
+// Payout yang sudah final atau belum dikirim dibiarkan apa adanya.
+func (m Machine) Reconcile(p Payout, check func(ctx context.Context) (*Outcome, error)) error {
+	if state := p.State(); state != StateProcessing && state != StateUnknown {
+		return nil
+	}
+
+	attempts, err := p.MarkChecked(time.Now())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.Timeout)
+	defer cancel()
+	outcome, err := check(ctx)
+	switch {
+	case m.NotFound != nil && errors.Is(err, m.NotFound):
+		// Pihak eksternal tidak pernah menerima payout. Setelah masa tenggang,
+		// aman untuk menganggapnya gagal dan mengembalikan dana.
+		if time.Since(p.SentAt()) < m.NotFoundGrace {
+			return nil
+		}
+		m.Apply(p, Outcome{Result: ResultFailed, FailureReason: m.NotFoundReason})
+	case err != nil:
+		log.Printf("ERROR: Gagal cek status %s %s: %v", m.Label, p.Reference(), err)
+	default:
+		m.Apply(p, *outcome)
+	}
+
+	if state := p.State(); state != StateSucceeded && state != StateFailed && attempts >= m.MaxChecks {
+		log.Printf("ALERT: %s %s masih %s setelah %d kali cek status, perlu penanganan manual", m.Label, p.Reference(), state, attempts)
+	}
+	return nil
+}
+
+// ReconcileAll menjalankan reconcile untuk setiap reference dan mengembalikan
+// jumlah payout yang menjadi final.
+func (m Machine) ReconcileAll(references []string, reconcile func(reference string) (bool, error)) int {
+	resolved := 0
+	for _, reference := range references {
+		final, err := reconcile(reference)
+		if err != nil {
+			log.Printf("ERROR: Gagal rekonsiliasi %s %s: %v", m.Label, reference, err)
+			continue
+		}
+		if final {
+			resolved++
+		}
+	}
+	return resolved
+}
+
+// Apply menerapkan hasil dari pihak eksternal. PENDING tidak mengubah apa pun
+// selain memastikan status PROCESSING.
+func (m Machine) Apply(p Payout, outcome Outcome) {
+	switch outcome.Result {
+	case ResultSuccess:
+		m.succeed(p, outcome)
+	case ResultFailed:
+		m.Fail(p, outcome.FailureReason)
+	default:
+		if p.State() == StateUnknown {
+			if _, err := p.Transition(StateProcessing, outcome); err != nil {
+				log.Printf("ERROR: Gagal menandai %s %s PROCESSING: %v", m.Label, p.Reference(), err)
+			}
+		}
+	}
+}
+
+// Fail menandai payout gagal. Hold hanya dilepas bila payout sudah melewati
+// PENDING; dari PENDING berarti hold belum pernah dipasang oleh payout ini.
+func (m Machine) Fail(p Payout, reason string) {
+	held := p.State() != StatePending
+	ok, err := p.Transition(StateFailed, Outcome{Result: ResultFailed, FailureReason: reason})
+	if err != nil || !ok {
+		log.Printf("ERROR: Gagal menandai %s %s gagal: %v", m.Label, p.Reference(), err)
+		return
+	}
+
+	if held {
+		if err := p.ReleaseHold(); err != nil && !errors.Is(err, balance.ErrHoldNotFound) {
+			log.Printf("ALERT: Hold %s %s gagal dilepas: %v", m.Label, p.Reference(), err)
+		}
+	}
+	p.Failed(held)
+}
+
+func (m Machine) succeed(p Payout, outcome Outcome) {
+	ok, err := p.Transition(StateSucceeded, outcome)
+	if err != nil || !ok {
+		log.Printf("ERROR: Gagal menandai %s %s berhasil: %v", m.Label, p.Reference(), err)
+		return
+	}
+
+	if err := p.CaptureHold(); err != nil {
+		log.Printf("ALERT: %s %s berhasil di pihak eksternal tetapi hold gagal di-capture: %v", m.Label, p.Reference(), err)
+		return
+	}
+	p.Succeeded()
+}
+
+func (m Machine) markUnknown(p Payout, reason string) {
+	ok, err := p.Transition(StateUnknown, Outcome{FailureReason: reason})
+	if err != nil || !ok {
+		log.Printf("ERROR: Gagal menandai %s %s UNKNOWN: %v", m.Label, p.Reference(), err)
+	}
+}
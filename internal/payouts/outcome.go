@@ -0,0 +1,25 @@
+package payouts
+
+import (
+	"ewallet-engine/internal/bank"
+	"ewallet-engine/internal/billers"
+)
+
+// TransferOutcome menyeragamkan hasil transfer bank.
+func TransferOutcome(result *bank.TransferResult) *Outcome {
+	return &Outcome{
+		Result:        Result(result.Status),
+		ExternalID:    result.ExternalID,
+		FailureReason: result.FailureReason,
+	}
+}
+
+// PaymentOutcome menyeragamkan hasil pembayaran dari biller.
+func PaymentOutcome(result *billers.PaymentResult) *Outcome {
+	return &Outcome{
+		Result:        Result(result.Status),
+		ExternalID:    result.ExternalID,
+		FailureReason: result.FailureReason,
+		SerialNumber:  result.SerialNumber,
+	}
+}
@@ -0,0 +1,100 @@
+package promotions
+
+import (
+	"crypto/rand"
+	"errors"
+	"ewallet-engine/internal/balance"
+	"math/big"
+	"strings"
+)
+
+// eligibleTypes adalah jenis transaksi yang boleh menjadi target campaign.
+// DISCOUNT tidak berlaku untuk TOPUP karena tidak ada pembayaran yang dikurangi.
+var eligibleTypes = map[Kind][]string{
+	KindDiscount: {"PURCHASE", "TRANSFER"},
+	KindCashback: {"TOPUP", "PURCHASE", "TRANSFER"},
+}
+
+// codeAlphabet tidak memuat karakter yang mudah tertukar seperti 0/O dan 1/I.
+const codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+const (
+	minCodeLength     = 4
+	maxCodeLength     = 40
+	randomCodeLength  = 8
+	maxVouchersPerRun = 1000
+)
+
+// Benefit menghitung benefit campaign untuk amount: 0 bila di bawah
+// MinSpend, lalu dibatasi MaxBenefit dan dibulatkan ke satuan terkecil
+// currency. Benefit tidak pernah melebihi amount.
+func Benefit(campaign Campaign, amount float64) float64 {
+	if amount <= 0 || amount < campaign.MinSpend {
+		return 0
+	}
+
+	benefit := campaign.Value
+	if campaign.ValueType == ValuePercent {
+		benefit = amount * campaign.Value / 100
+	}
+	if campaign.MaxBenefit > 0 && benefit > campaign.MaxBenefit {
+		benefit = campaign.MaxBenefit
+	}
+	if benefit > amount {
+		benefit = amount
+	}
+	return balance.RoundAmount(benefit, campaign.Currency)
+}
+
+// normalizeEligibleTypes memvalidasi jenis transaksi untuk kind dan
+// mengembalikannya sebagai daftar dipisah koma dalam urutan baku.
+func normalizeEligibleTypes(kind Kind, types []string) (string, error) {
+	if len(types) == 0 {
+		return "", errors.New("minimal satu eligible_types wajib diisi")
+	}
+	requested := make(map[string]bool, len(types))
+	for _, txType := range types {
+		requested[strings.ToUpper(strings.TrimSpace(txType))] = true
+	}
+
+	var allowed []string
+	for _, txType := range eligibleTypes[kind] {
+		if requested[txType] {
+			allowed = append(allowed, txType)
+			delete(requested, txType)
+		}
+	}
+	for txType := range requested {
+		return "", errors.New("jenis transaksi tidak didukung untuk " + string(kind) + ": " + txType)
+	}
+	return strings.Join(allowed, ","), nil
+}
+
+// NormalizeCode menyeragamkan kode voucher menjadi huruf besar tanpa spasi.
+func NormalizeCode(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) < minCodeLength || len(code) > maxCodeLength {
+		return "", errors.New("kode voucher harus 4-40 karakter")
+	}
+	for _, r := range code {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '-' {
+			return "", errors.New("kode voucher hanya boleh berisi huruf, angka dan tanda hubung")
+		}
+	}
+	return code, nil
+}
+
+// GenerateCode membuat kode voucher acak berawalan prefix.
+func GenerateCode(prefix string) (string, error) {
+	var builder strings.Builder
+	builder.WriteString(prefix)
+	limit := big.NewInt(int64(len(codeAlphabet)))
+	for i := 0; i < randomCodeLength; i++ {
+		n, err := rand.Int(rand.Reader, limit)
+		if err != nil {
+			return "", err
+		}
+		builder.WriteByte(codeAlphabet[n.Int64()])
+	}
+	return builder.String(), nil
+}
@@ -0,0 +1,84 @@
+package promotions
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBenefit(t *testing.T) {
+	percent := Campaign{ValueType: ValuePercent, Value: 10, MaxBenefit: 25000, MinSpend: 50000, Currency: "IDR"}
+	fixed := Campaign{ValueType: ValueFixed, Value: 15000, Currency: "IDR"}
+
+	cases := []struct {
+		name     string
+		campaign Campaign
+		amount   float64
+		want     float64
+	}{
+		{"di bawah min spend", percent, 49999, 0},
+		{"persen", percent, 120000, 12000},
+		{"persen dibatasi max benefit", percent, 400000, 25000},
+		{"persen dibulatkan ke rupiah", percent, 50005, 5001},
+		{"nominal tetap", fixed, 100000, 15000},
+		{"tidak melebihi amount", fixed, 10000, 10000},
+	}
+	for _, tc := range cases {
+		if got := Benefit(tc.campaign, tc.amount); got != tc.want {
+			t.Errorf("%s: Benefit(%v) = %v, want %v", tc.name, tc.amount, got, tc.want)
+		}
+	}
+}
+
+func TestNormalizeEligibleTypes(t *testing.T) {
+	got, err := normalizeEligibleTypes(KindCashback, []string{"transfer", " PURCHASE ", "TOPUP"})
+	if err != nil || got != "TOPUP,PURCHASE,TRANSFER" {
+		t.Fatalf("got %q, err = %v", got, err)
+	}
+	if _, err := normalizeEligibleTypes(KindDiscount, []string{"TOPUP"}); err == nil {
+		t.Fatal("DISCOUNT untuk TOPUP harus ditolak")
+	}
+	if _, err := normalizeEligibleTypes(KindCashback, nil); err == nil {
+		t.Fatal("eligible_types kosong harus ditolak")
+	}
+
+	campaign := Campaign{EligibleTypes: got}
+	if !campaign.Eligible("PURCHASE") || campaign.Eligible("REFUND") {
+		t.Fatalf("Eligible tidak sesuai untuk %q", got)
+	}
+}
+
+func TestNormalizeCode(t *testing.T) {
+	for code, want := range map[string]string{
+		" hemat-50 ": "HEMAT-50",
+		"ABC":        "",
+		"PROMO 10":   "",
+		"DISKON!":    "",
+	} {
+		got, err := NormalizeCode(code)
+		if (err == nil) != (want != "") || got != want {
+			t.Errorf("NormalizeCode(%q) = %q, %v; want %q", code, got, err, want)
+		}
+	}
+
+	code, err := GenerateCode("RAMADAN-")
+	if err != nil || !strings.HasPrefix(code, "RAMADAN-") || len(code) != len("RAMADAN-")+randomCodeLength {
+		t.Fatalf("GenerateCode = %q, err = %v", code, err)
+	}
+	if _, err := NormalizeCode(code); err != nil {
+		t.Fatalf("kode acak harus valid: %v", err)
+	}
+}
+
+func TestCampaignRunningAt(t *testing.T) {
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	campaign := Campaign{Status: CampaignActive, StartsAt: start, EndsAt: start.AddDate(0, 1, 0)}
+
+	if campaign.RunningAt(start.Add(-time.Second)) || !campaign.RunningAt(start) || campaign.RunningAt(campaign.EndsAt) {
+		t.Fatal("RunningAt harus berlaku untuk [StartsAt, EndsAt)")
+	}
+	campaign.Status = CampaignPaused
+	if campaign.RunningAt(start.Add(time.Hour)) {
+		t.Fatal("campaign PAUSED tidak boleh berjalan")
+	}
+}
@@ -0,0 +1,232 @@
+package promotions
+
+import (
+	"errors"
+	"ewallet-engine/internal/audit"
+	"ewallet-engine/internal/balance"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PromotionHandler struct {
+	service      PromotionService
+	auditService audit.AuditService
+}
+
+func NewPromotionHandler(service PromotionService, auditService audit.AuditService) *PromotionHandler {
+	return &PromotionHandler{service: service, auditService: auditService}
+}
+
+func (h *PromotionHandler) QuoteHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	var request struct {
+		Code            string  `json:"code"`
+		TransactionType string  `json:"transaction_type"`
+		Currency        string  `json:"currency"`
+		Amount          float64 `json:"amount"`
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	currency, err := balance.NormalizeCurrency(request.Currency)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	quote, err := h.service.Preview(Claim{
+		UserID:          userID,
+		Code:            request.Code,
+		TransactionType: strings.ToUpper(request.TransactionType),
+		Currency:        currency,
+		Amount:          request.Amount,
+	})
+	if err != nil {
+		return h.promotionError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": quote})
+}
+
+func (h *PromotionHandler) CreateCampaignHandler(c *fiber.Ctx) error {
+	actorID := c.Locals("user_id").(uint)
+
+	var request CampaignRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	campaign, err := h.service.CreateCampaign(actorID, request)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionCampaignCreated,
+		TargetType: "campaign",
+		TargetID:   fmt.Sprint(campaign.ID),
+		After:      campaignSnapshot(campaign),
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Campaign berhasil dibuat",
+		"data":    campaign,
+	})
+}
+
+func (h *PromotionHandler) ListCampaignsHandler(c *fiber.Ctx) error {
+	campaigns, err := h.service.ListCampaigns(CampaignStatus(c.Query("status")))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": campaigns})
+}
+
+func (h *PromotionHandler) GetCampaignHandler(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	campaign, err := h.service.GetCampaign(uint(id))
+	if err != nil {
+		return h.promotionError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": campaign})
+}
+
+func (h *PromotionHandler) SetCampaignStatusHandler(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	var request struct {
+		Status CampaignStatus `json:"status"`
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	before, err := h.service.GetCampaign(uint(id))
+	if err != nil {
+		return h.promotionError(c, err)
+	}
+	beforeSnapshot := campaignSnapshot(before)
+
+	campaign, err := h.service.SetCampaignStatus(uint(id), request.Status)
+	if err != nil {
+		return h.promotionError(c, err)
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionCampaignStatusChanged,
+		TargetType: "campaign",
+		TargetID:   fmt.Sprint(campaign.ID),
+		Before:     beforeSnapshot,
+		After:      campaignSnapshot(campaign),
+	})
+
+	return c.JSON(fiber.Map{
+		"message": "Status campaign berhasil diperbarui",
+		"data":    campaign,
+	})
+}
+
+func (h *PromotionHandler) CreateVouchersHandler(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	var request VoucherRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	vouchers, err := h.service.CreateVouchers(uint(id), request)
+	if err != nil {
+		return h.promotionError(c, err)
+	}
+
+	codes := make([]string, 0, len(vouchers))
+	for _, voucher := range vouchers {
+		codes = append(codes, voucher.Code)
+	}
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionVouchersCreated,
+		TargetType: "campaign",
+		TargetID:   fmt.Sprint(id),
+		After: audit.Snapshot{
+			"count":           len(vouchers),
+			"codes":           codes,
+			"max_redemptions": request.MaxRedemptions,
+		},
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Voucher berhasil dibuat",
+		"data":    vouchers,
+	})
+}
+
+func (h *PromotionHandler) ListVouchersHandler(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	vouchers, err := h.service.ListVouchers(uint(id))
+	if err != nil {
+		return h.promotionError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": vouchers})
+}
+
+func (h *PromotionHandler) ListRedemptionsHandler(c *fiber.Ctx) error {
+	redemptions, err := h.service.ListRedemptions(uint(c.QueryInt("campaign_id", 0)), uint(c.QueryInt("user_id", 0)))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": redemptions})
+}
+
+func (h *PromotionHandler) promotionError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, ErrCampaignNotFound), errors.Is(err, ErrVoucherNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, ErrQuotaExhausted), errors.Is(err, ErrUserQuotaExhausted),
+		errors.Is(err, ErrVoucherExhausted), errors.Is(err, ErrBudgetExhausted):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"message": err.Error()})
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+}
+
+func campaignSnapshot(campaign *Campaign) audit.Snapshot {
+	return audit.Snapshot{
+		"name":              campaign.Name,
+		"kind":              campaign.Kind,
+		"value_type":        campaign.ValueType,
+		"value":             campaign.Value,
+		"max_benefit":       campaign.MaxBenefit,
+		"budget":            campaign.Budget,
+		"eligible_types":    campaign.EligibleTypes,
+		"funding_wallet_id": campaign.FundingWalletID,
+		"starts_at":         campaign.StartsAt,
+		"ends_at":           campaign.EndsAt,
+		"status":            campaign.Status,
+	}
+}
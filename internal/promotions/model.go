@@ -0,0 +1,176 @@
+package promotions
+
+import (
+	"strings"
+	"time"
+)
+
+type Kind string
+
+const (
+	// KindDiscount mengurangi jumlah yang dibayar user saat transaksi dibuat.
+	KindDiscount Kind = "DISCOUNT"
+	// KindCashback mengkredit wallet user setelah transaksi SUCCESS.
+	KindCashback Kind = "CASHBACK"
+)
+
+type ValueType string
+
+const (
+	ValuePercent ValueType = "PERCENT"
+	ValueFixed   ValueType = "FIXED"
+)
+
+type CampaignStatus string
+
+const (
+	CampaignActive CampaignStatus = "ACTIVE"
+	CampaignPaused CampaignStatus = "PAUSED"
+	CampaignEnded  CampaignStatus = "ENDED"
+)
+
+// Campaign adalah program promo yang didanai dari FundingWalletID. Value
+// dinyatakan dalam persen untuk PERCENT (10 berarti 10%) dan MaxBenefit
+// membatasi benefit per transaksi; 0 berarti tanpa batas. Quota 0 berarti
+// tanpa batas. BudgetUsed mencakup benefit yang sudah dicadangkan maupun
+// yang sudah dibayarkan, sehingga tidak pernah melebihi Budget.
+type Campaign struct {
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	Name            string         `gorm:"type:varchar(100);not null" json:"name"`
+	Description     string         `gorm:"type:varchar(500)" json:"description,omitempty"`
+	Kind            Kind           `gorm:"type:enum('DISCOUNT','CASHBACK');not null" json:"kind"`
+	ValueType       ValueType      `gorm:"type:enum('PERCENT','FIXED');not null" json:"value_type"`
+	Value           float64        `gorm:"not null" json:"value"`
+	MaxBenefit      float64        `gorm:"not null;default:0" json:"max_benefit"`
+	MinSpend        float64        `gorm:"not null;default:0" json:"min_spend"`
+	PerUserQuota    int            `gorm:"not null;default:0" json:"per_user_quota"`
+	GlobalQuota     int            `gorm:"not null;default:0" json:"global_quota"`
+	Redemptions     int            `gorm:"not null;default:0" json:"redemptions"`
+	Budget          float64        `gorm:"not null" json:"budget"`
+	BudgetUsed      float64        `gorm:"not null;default:0" json:"budget_used"`
+	EligibleTypes   string         `gorm:"type:varchar(100);not null" json:"eligible_types"`
+	Currency        string         `gorm:"type:char(3);not null;default:'IDR'" json:"currency"`
+	FundingWalletID uint           `gorm:"not null" json:"funding_wallet_id"`
+	StartsAt        time.Time      `gorm:"not null" json:"starts_at"`
+	EndsAt          time.Time      `gorm:"not null" json:"ends_at"`
+	Status          CampaignStatus `gorm:"type:enum('ACTIVE','PAUSED','ENDED');default:'ACTIVE';index" json:"status"`
+	CreatedBy       uint           `gorm:"not null" json:"created_by"`
+	CreatedAt       time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// Eligible melaporkan apakah jenis transaksi termasuk EligibleTypes.
+func (c Campaign) Eligible(txType string) bool {
+	for _, eligible := range strings.Split(c.EligibleTypes, ",") {
+		if eligible == txType {
+			return true
+		}
+	}
+	return false
+}
+
+// RunningAt melaporkan apakah campaign aktif dan berada di masa berlakunya.
+func (c Campaign) RunningAt(t time.Time) bool {
+	return c.Status == CampaignActive && !t.Before(c.StartsAt) && t.Before(c.EndsAt)
+}
+
+// Voucher adalah kode yang ditukarkan user untuk sebuah campaign.
+// MaxRedemptions 0 berarti hanya dibatasi quota campaign.
+type Voucher struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	CampaignID     uint      `gorm:"not null;index" json:"campaign_id"`
+	Code           string    `gorm:"type:varchar(40);uniqueIndex;not null" json:"code"`
+	MaxRedemptions int       `gorm:"not null;default:0" json:"max_redemptions"`
+	Redemptions    int       `gorm:"not null;default:0" json:"redemptions"`
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type RedemptionStatus string
+
+const (
+	// RedemptionReserved: benefit di-hold dari wallet pendanaan, menunggu transaksi final.
+	RedemptionReserved RedemptionStatus = "RESERVED"
+	// RedemptionCompleted: benefit sudah dibayarkan dari wallet pendanaan.
+	RedemptionCompleted RedemptionStatus = "COMPLETED"
+	// RedemptionCancelled: transaksi gagal; hold, quota dan budget dikembalikan.
+	RedemptionCancelled RedemptionStatus = "CANCELLED"
+)
+
+// Redemption adalah pemakaian voucher pada satu transaksi. BeneficiaryUserID
+// adalah penerima dana dari wallet pendanaan: user untuk CASHBACK, penerima
+// transaksi untuk DISCOUNT, atau 0 bila dana diskon keluar bersama
+// pembayaran ke pihak luar.
+type Redemption struct {
+	ID                   uint             `gorm:"primaryKey" json:"id"`
+	CampaignID           uint             `gorm:"not null;index" json:"campaign_id"`
+	VoucherID            uint             `gorm:"not null" json:"voucher_id"`
+	Code                 string           `gorm:"type:varchar(40);not null" json:"code"`
+	UserID               uint             `gorm:"not null;index" json:"user_id"`
+	TransactionReference string           `gorm:"type:varchar(255);uniqueIndex;not null" json:"transaction_reference"`
+	TransactionType      string           `gorm:"type:varchar(20);not null" json:"transaction_type"`
+	Kind                 Kind             `gorm:"type:enum('DISCOUNT','CASHBACK');not null" json:"kind"`
+	Amount               float64          `gorm:"not null" json:"amount"`
+	Benefit              float64          `gorm:"not null" json:"benefit"`
+	Currency             string           `gorm:"type:char(3);not null;default:'IDR'" json:"currency"`
+	BeneficiaryUserID    uint             `gorm:"not null;default:0" json:"beneficiary_user_id,omitempty"`
+	Status               RedemptionStatus `gorm:"type:enum('RESERVED','COMPLETED','CANCELLED');default:'RESERVED';index" json:"status"`
+	CompletedAt          *time.Time       `json:"completed_at,omitempty"`
+	CreatedAt            time.Time        `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt            time.Time        `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// HoldReference adalah reference hold wallet pendanaan dan mutasi benefitnya.
+func (r Redemption) HoldReference() string {
+	return HoldReferencePrefix + r.TransactionReference
+}
+
+// HoldReferencePrefix membedakan mutasi dana promo dari mutasi transaksinya.
+const HoldReferencePrefix = "PROMO-"
+
+// Claim adalah permintaan memakai voucher saat transaksi dibuat.
+type Claim struct {
+	UserID             uint
+	Code               string
+	TransactionType    string
+	Currency           string
+	Amount             float64
+	Reference          string
+	CounterpartyUserID uint
+}
+
+// Quote adalah hasil pengecekan voucher tanpa mencadangkan benefit.
+type Quote struct {
+	Code       string  `json:"code"`
+	CampaignID uint    `json:"campaign_id"`
+	Kind       Kind    `json:"kind"`
+	Amount     float64 `json:"amount"`
+	Benefit    float64 `json:"benefit"`
+	// Payable adalah jumlah yang dibayar user sebelum fee.
+	Payable float64 `json:"payable"`
+}
+
+type CampaignRequest struct {
+	Name            string    `json:"name"`
+	Description     string    `json:"description"`
+	Kind            Kind      `json:"kind"`
+	ValueType       ValueType `json:"value_type"`
+	Value           float64   `json:"value"`
+	MaxBenefit      float64   `json:"max_benefit"`
+	MinSpend        float64   `json:"min_spend"`
+	PerUserQuota    int       `json:"per_user_quota"`
+	GlobalQuota     int       `json:"global_quota"`
+	Budget          float64   `json:"budget"`
+	EligibleTypes   []string  `json:"eligible_types"`
+	FundingWalletID uint      `json:"funding_wallet_id"`
+	StartsAt        time.Time `json:"starts_at"`
+	EndsAt          time.Time `json:"ends_at"`
+}
+
+// VoucherRequest membuat voucher dengan kode yang disebutkan di Codes, atau
+// Count kode acak berawalan Prefix bila Codes kosong.
+type VoucherRequest struct {
+	Codes          []string `json:"codes"`
+	Prefix         string   `json:"prefix"`
+	Count          int      `json:"count"`
+	MaxRedemptions int      `json:"max_redemptions"`
+}
@@ -0,0 +1,293 @@
+package promotions
+
+import (
+	"errors"
+	"ewallet-engine/internal/balance"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// budgetTolerance menyerap selisih pembulatan float saat membandingkan budget.
+const budgetTolerance = 0.005
+
+type PromotionRepository interface {
+	CreateCampaign(campaign *Campaign) error
+	FindCampaign(id uint) (*Campaign, error)
+	ListCampaigns(status CampaignStatus) ([]Campaign, error)
+	UpdateCampaignStatus(id uint, status CampaignStatus) error
+	FindWallet(id uint) (*balance.Wallet, error)
+
+	CreateVouchers(vouchers []Voucher) error
+	FindVoucher(code string) (*Voucher, error)
+	ListVouchers(campaignID uint) ([]Voucher, error)
+
+	FindRedemption(reference string) (*Redemption, error)
+	ListRedemptions(campaignID uint, userID uint, limit int) ([]Redemption, error)
+	CountUserRedemptions(campaignID uint, userID uint) (int64, error)
+
+	// Reserve mencadangkan quota, budget dan dana benefit dari wallet
+	// pendanaan lalu menyimpan redemption, semuanya dalam satu transaksi database.
+	Reserve(redemption *Redemption, expiresAt time.Time) error
+	// Complete meng-capture hold wallet pendanaan dan mengkredit penerima benefit.
+	Complete(redemption *Redemption) error
+	// Cancel melepas hold dan mengembalikan quota serta budget.
+	Cancel(redemption *Redemption) error
+}
+
+type promotionRepository struct {
+	DB *gorm.DB
+}
+
+func NewPromotionRepository(db *gorm.DB) PromotionRepository {
+	return &promotionRepository{DB: db}
+}
+
+func (r *promotionRepository) CreateCampaign(campaign *Campaign) error {
+	return r.DB.Create(campaign).Error
+}
+
+func (r *promotionRepository) FindCampaign(id uint) (*Campaign, error) {
+	var campaign Campaign
+	if err := r.DB.First(&campaign, id).Error; err != nil {
+		return nil, err
+	}
+	return &campaign, nil
+}
+
+func (r *promotionRepository) ListCampaigns(status CampaignStatus) ([]Campaign, error) {
+	var campaigns []Campaign
+	query := r.DB.Order("id DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&campaigns).Error
+	return campaigns, err
+}
+
+func (r *promotionRepository) UpdateCampaignStatus(id uint, status CampaignStatus) error {
+	return r.DB.Model(&Campaign{}).Where("id = ?", id).Update("status", status).Error
+}
+
+func (r *promotionRepository) FindWallet(id uint) (*balance.Wallet, error) {
+	var wallet balance.Wallet
+	if err := r.DB.First(&wallet, id).Error; err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+func (r *promotionRepository) CreateVouchers(vouchers []Voucher) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(&vouchers).Error
+	})
+}
+
+func (r *promotionRepository) FindVoucher(code string) (*Voucher, error) {
+	var voucher Voucher
+	if err := r.DB.Where("code = ?", code).First(&voucher).Error; err != nil {
+		return nil, err
+	}
+	return &voucher, nil
+}
+
+func (r *promotionRepository) ListVouchers(campaignID uint) ([]Voucher, error) {
+	var vouchers []Voucher
+	err := r.DB.Where("campaign_id = ?", campaignID).Order("id ASC").Find(&vouchers).Error
+	return vouchers, err
+}
+
+func (r *promotionRepository) FindRedemption(reference string) (*Redemption, error) {
+	var redemption Redemption
+	if err := r.DB.Where("transaction_reference = ?", reference).First(&redemption).Error; err != nil {
+		return nil, err
+	}
+	return &redemption, nil
+}
+
+func (r *promotionRepository) ListRedemptions(campaignID uint, userID uint, limit int) ([]Redemption, error) {
+	var redemptions []Redemption
+	query := r.DB.Order("id DESC").Limit(limit)
+	if campaignID != 0 {
+		query = query.Where("campaign_id = ?", campaignID)
+	}
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	err := query.Find(&redemptions).Error
+	return redemptions, err
+}
+
+func (r *promotionRepository) CountUserRedemptions(campaignID uint, userID uint) (int64, error) {
+	var count int64
+	err := r.DB.Model(&Redemption{}).
+		Where("campaign_id = ? AND user_id = ? AND status IN ?", campaignID, userID, []RedemptionStatus{RedemptionReserved, RedemptionCompleted}).
+		Count(&count).Error
+	return count, err
+}
+
+// Reserve mengunci baris campaign sehingga pengecekan quota per user, quota
+// global dan budget konsisten walaupun voucher dipakai bersamaan.
+func (r *promotionRepository) Reserve(redemption *Redemption, expiresAt time.Time) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var campaign Campaign
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&campaign, redemption.CampaignID).Error
+		if err != nil {
+			return err
+		}
+		if !campaign.RunningAt(time.Now()) {
+			return ErrCampaignNotRunning
+		}
+		if campaign.GlobalQuota > 0 && campaign.Redemptions >= campaign.GlobalQuota {
+			return ErrQuotaExhausted
+		}
+		if campaign.BudgetUsed+redemption.Benefit > campaign.Budget+budgetTolerance {
+			return ErrBudgetExhausted
+		}
+
+		if campaign.PerUserQuota > 0 {
+			var used int64
+			err := tx.Model(&Redemption{}).
+				Where("campaign_id = ? AND user_id = ? AND status IN ?", campaign.ID, redemption.UserID, []RedemptionStatus{RedemptionReserved, RedemptionCompleted}).
+				Count(&used).Error
+			if err != nil {
+				return err
+			}
+			if used >= int64(campaign.PerUserQuota) {
+				return ErrUserQuotaExhausted
+			}
+		}
+
+		claimed := tx.Model(&Voucher{}).
+			Where("id = ? AND (max_redemptions = 0 OR redemptions < max_redemptions)", redemption.VoucherID).
+			Update("redemptions", gorm.Expr("redemptions + 1"))
+		if claimed.Error != nil {
+			return claimed.Error
+		}
+		if claimed.RowsAffected != 1 {
+			return ErrVoucherExhausted
+		}
+
+		err = tx.Model(&campaign).Updates(map[string]interface{}{
+			"redemptions": gorm.Expr("redemptions + 1"),
+			"budget_used": gorm.Expr("budget_used + ?", redemption.Benefit),
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		if _, err := balance.PlaceHold(tx, campaign.FundingWalletID, redemption.Benefit, redemption.HoldReference(), expiresAt); err != nil {
+			if errors.Is(err, balance.ErrInsufficientBalance) {
+				return ErrFundingInsufficient
+			}
+			return err
+		}
+		return tx.Create(redemption).Error
+	})
+}
+
+func (r *promotionRepository) Complete(redemption *Redemption) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		claimed := tx.Model(&Redemption{}).
+			Where("id = ? AND status = ?", redemption.ID, RedemptionReserved).
+			Updates(map[string]interface{}{"status": RedemptionCompleted, "completed_at": &now})
+		if claimed.Error != nil {
+			return claimed.Error
+		}
+		if claimed.RowsAffected != 1 {
+			return ErrRedemptionClosed
+		}
+
+		var beneficiary *balance.Wallet
+		if redemption.BeneficiaryUserID != 0 {
+			var err error
+			beneficiary, err = balance.FindUserWallet(tx, redemption.BeneficiaryUserID, redemption.Currency, true)
+			if err != nil {
+				return err
+			}
+
+			// Wallet pendanaan dan penerima dikunci menurut id, sama seperti transfer.
+			var hold balance.Hold
+			if err := tx.Where("reference = ?", redemption.HoldReference()).First(&hold).Error; err != nil {
+				return err
+			}
+			var locked []balance.Wallet
+			err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id IN ?", []uint{hold.WalletID, beneficiary.ID}).Order("id ASC").Find(&locked).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		owner, err := fundingOwner(tx, redemption.CampaignID)
+		if err != nil {
+			return err
+		}
+		if _, err := balance.CaptureHold(tx, owner, redemption.HoldReference(), redemption.Benefit, 0); err != nil {
+			return err
+		}
+		if beneficiary != nil {
+			if _, err := balance.ApplyWalletEntry(tx, beneficiary.ID, "CREDIT", redemption.Benefit, redemption.HoldReference()); err != nil {
+				return err
+			}
+		}
+
+		redemption.Status = RedemptionCompleted
+		redemption.CompletedAt = &now
+		return nil
+	})
+}
+
+func (r *promotionRepository) Cancel(redemption *Redemption) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		claimed := tx.Model(&Redemption{}).
+			Where("id = ? AND status = ?", redemption.ID, RedemptionReserved).
+			Update("status", RedemptionCancelled)
+		if claimed.Error != nil {
+			return claimed.Error
+		}
+		if claimed.RowsAffected != 1 {
+			return ErrRedemptionClosed
+		}
+
+		err := tx.Model(&Campaign{}).Where("id = ?", redemption.CampaignID).Updates(map[string]interface{}{
+			"redemptions": gorm.Expr("redemptions - 1"),
+			"budget_used": gorm.Expr("budget_used - ?", redemption.Benefit),
+		}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&Voucher{}).Where("id = ?", redemption.VoucherID).
+			Update("redemptions", gorm.Expr("redemptions - 1")).Error
+		if err != nil {
+			return err
+		}
+
+		owner, err := fundingOwner(tx, redemption.CampaignID)
+		if err != nil {
+			return err
+		}
+		err = balance.ReleaseHold(tx, owner, redemption.HoldReference(), balance.HoldReleased)
+		if err != nil && !errors.Is(err, balance.ErrHoldNotFound) && !errors.Is(err, balance.ErrHoldNotActive) {
+			return err
+		}
+		redemption.Status = RedemptionCancelled
+		return nil
+	})
+}
+
+// fundingOwner mengembalikan pemilik wallet pendanaan campaign, yaitu pemilik
+// hold benefit redemption-nya.
+func fundingOwner(tx *gorm.DB, campaignID uint) (uint, error) {
+	var campaign Campaign
+	if err := tx.Select("id", "funding_wallet_id").First(&campaign, campaignID).Error; err != nil {
+		return 0, err
+	}
+	var wallet balance.Wallet
+	if err := tx.Select("id", "user_id").First(&wallet, campaign.FundingWalletID).Error; err != nil {
+		return 0, err
+	}
+	return wallet.UserID, nil
+}
@@ -0,0 +1,432 @@
+package promotions
+
+import (
+	"errors"
+	"ewallet-engine/internal/balance"
+	"ewallet-engine/internal/notifications"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const NotificationCashback = "PROMO_CASHBACK"
+
+var (
+	ErrCampaignNotFound    = errors.New("campaign tidak ditemukan")
+	ErrVoucherNotFound     = errors.New("kode voucher tidak ditemukan")
+	ErrCampaignNotRunning  = errors.New("promo tidak sedang berlaku")
+	ErrNotEligible         = errors.New("voucher tidak berlaku untuk transaksi ini")
+	ErrMinSpend            = errors.New("jumlah transaksi belum memenuhi minimum promo")
+	ErrQuotaExhausted      = errors.New("kuota promo sudah habis")
+	ErrUserQuotaExhausted  = errors.New("batas pemakaian promo untuk akun Anda sudah tercapai")
+	ErrVoucherExhausted    = errors.New("kode voucher sudah habis dipakai")
+	ErrBudgetExhausted     = errors.New("budget promo sudah habis")
+	ErrFundingInsufficient = errors.New("dana promo tidak mencukupi")
+	ErrRedemptionClosed    = errors.New("pemakaian voucher sudah diselesaikan")
+	ErrFundingNotAllowed   = errors.New("wallet bukan wallet pendanaan promo")
+)
+
+type PromotionService interface {
+	CreateCampaign(actorID uint, request CampaignRequest) (*Campaign, error)
+	ListCampaigns(status CampaignStatus) ([]Campaign, error)
+	GetCampaign(id uint) (*Campaign, error)
+	SetCampaignStatus(id uint, status CampaignStatus) (*Campaign, error)
+	CreateVouchers(campaignID uint, request VoucherRequest) ([]Voucher, error)
+	ListVouchers(campaignID uint) ([]Voucher, error)
+	ListRedemptions(campaignID uint, userID uint) ([]Redemption, error)
+
+	Preview(claim Claim) (*Quote, error)
+	Redeem(claim Claim) (*Redemption, error)
+	Settle(reference string, success bool)
+}
+
+type promotionService struct {
+	repo        PromotionRepository
+	creditGuard balance.CreditGuard
+	notifier    notifications.Notifier
+	holdTTL     time.Duration
+	// fundingWallets adalah wallet selain wallet platform yang boleh mendanai
+	// campaign (PROMO_FUNDING_WALLET_IDS).
+	fundingWallets map[uint]bool
+}
+
+func NewPromotionService(repo PromotionRepository, creditGuard balance.CreditGuard, notifier notifications.Notifier) PromotionService {
+	s := &promotionService{repo: repo, creditGuard: creditGuard, notifier: notifier, holdTTL: 30 * 24 * time.Hour, fundingWallets: make(map[uint]bool)}
+	if days, err := strconv.Atoi(os.Getenv("PROMO_HOLD_TTL_DAYS")); err == nil && days > 0 {
+		s.holdTTL = time.Duration(days) * 24 * time.Hour
+	}
+	for _, id := range strings.Split(os.Getenv("PROMO_FUNDING_WALLET_IDS"), ",") {
+		if v, err := strconv.ParseUint(strings.TrimSpace(id), 10, 64); err == nil && v > 0 {
+			s.fundingWallets[uint(v)] = true
+		}
+	}
+	return s
+}
+
+// CreateCampaign memvalidasi aturan campaign dan wallet pendanaannya. Wallet
+// pendanaan harus wallet platform atau terdaftar di PROMO_FUNDING_WALLET_IDS;
+// saldonya baru diperiksa saat voucher dipakai.
+func (s *promotionService) CreateCampaign(actorID uint, request CampaignRequest) (*Campaign, error) {
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		return nil, errors.New("nama campaign wajib diisi")
+	}
+	if request.Kind != KindDiscount && request.Kind != KindCashback {
+		return nil, errors.New("kind harus DISCOUNT atau CASHBACK")
+	}
+	switch request.ValueType {
+	case ValuePercent:
+		if request.Value <= 0 || request.Value > 100 {
+			return nil, errors.New("value persen harus lebih dari 0 dan maksimal 100")
+		}
+	case ValueFixed:
+		if request.Value <= 0 {
+			return nil, errors.New("value harus lebih dari 0")
+		}
+	default:
+		return nil, errors.New("value_type harus PERCENT atau FIXED")
+	}
+	if request.MaxBenefit < 0 || request.MinSpend < 0 || request.PerUserQuota < 0 || request.GlobalQuota < 0 {
+		return nil, errors.New("max_benefit, min_spend dan quota tidak boleh negatif")
+	}
+	if request.EndsAt.IsZero() || !request.EndsAt.After(request.StartsAt) {
+		return nil, errors.New("ends_at harus setelah starts_at")
+	}
+
+	eligible, err := normalizeEligibleTypes(request.Kind, request.EligibleTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	wallet, err := s.repo.FindWallet(request.FundingWalletID)
+	if err != nil {
+		return nil, errors.New("wallet pendanaan tidak ditemukan")
+	}
+	if wallet.UserID != balance.PlatformRevenueUserID && !s.fundingWallets[wallet.ID] {
+		return nil, ErrFundingNotAllowed
+	}
+	if err := balance.ValidateAmount(request.Budget, wallet.Currency); err != nil {
+		return nil, fmt.Errorf("budget: %w", err)
+	}
+
+	startsAt := request.StartsAt
+	if startsAt.IsZero() {
+		startsAt = time.Now()
+	}
+
+	campaign := &Campaign{
+		Name:            name,
+		Description:     strings.TrimSpace(request.Description),
+		Kind:            request.Kind,
+		ValueType:       request.ValueType,
+		Value:           request.Value,
+		MaxBenefit:      request.MaxBenefit,
+		MinSpend:        request.MinSpend,
+		PerUserQuota:    request.PerUserQuota,
+		GlobalQuota:     request.GlobalQuota,
+		Budget:          request.Budget,
+		EligibleTypes:   eligible,
+		Currency:        wallet.Currency,
+		FundingWalletID: wallet.ID,
+		StartsAt:        startsAt,
+		EndsAt:          request.EndsAt,
+		Status:          CampaignActive,
+		CreatedBy:       actorID,
+	}
+	if err := s.repo.CreateCampaign(campaign); err != nil {
+		return nil, err
+	}
+	return campaign, nil
+}
+
+func (s *promotionService) ListCampaigns(status CampaignStatus) ([]Campaign, error) {
+	return s.repo.ListCampaigns(CampaignStatus(strings.ToUpper(string(status))))
+}
+
+func (s *promotionService) GetCampaign(id uint) (*Campaign, error) {
+	campaign, err := s.repo.FindCampaign(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCampaignNotFound
+		}
+		return nil, err
+	}
+	return campaign, nil
+}
+
+// SetCampaignStatus menjeda, melanjutkan atau mengakhiri campaign. Campaign
+// yang sudah ENDED tidak dapat diaktifkan lagi; redemption yang masih
+// RESERVED tetap diselesaikan mengikuti transaksinya.
+func (s *promotionService) SetCampaignStatus(id uint, status CampaignStatus) (*Campaign, error) {
+	status = CampaignStatus(strings.ToUpper(string(status)))
+	if status != CampaignActive && status != CampaignPaused && status != CampaignEnded {
+		return nil, errors.New("status harus ACTIVE, PAUSED atau ENDED")
+	}
+	campaign, err := s.GetCampaign(id)
+	if err != nil {
+		return nil, err
+	}
+	if campaign.Status == CampaignEnded && status != CampaignEnded {
+		return nil, errors.New("campaign yang sudah berakhir tidak dapat diaktifkan kembali")
+	}
+	if err := s.repo.UpdateCampaignStatus(id, status); err != nil {
+		return nil, err
+	}
+	campaign.Status = status
+	return campaign, nil
+}
+
+func (s *promotionService) CreateVouchers(campaignID uint, request VoucherRequest) ([]Voucher, error) {
+	campaign, err := s.GetCampaign(campaignID)
+	if err != nil {
+		return nil, err
+	}
+	if campaign.Status == CampaignEnded {
+		return nil, errors.New("campaign sudah berakhir")
+	}
+	if request.MaxRedemptions < 0 {
+		return nil, errors.New("max_redemptions tidak boleh negatif")
+	}
+
+	var codes []string
+	if len(request.Codes) > 0 {
+		seen := make(map[string]bool, len(request.Codes))
+		for _, raw := range request.Codes {
+			code, err := NormalizeCode(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", raw, err)
+			}
+			if seen[code] {
+				return nil, fmt.Errorf("kode voucher %s duplikat", code)
+			}
+			seen[code] = true
+			codes = append(codes, code)
+		}
+	} else {
+		if request.Count <= 0 || request.Count > maxVouchersPerRun {
+			return nil, fmt.Errorf("count harus 1-%d", maxVouchersPerRun)
+		}
+		prefix := strings.ToUpper(strings.TrimSpace(request.Prefix))
+		if len(prefix)+randomCodeLength > maxCodeLength {
+			return nil, errors.New("prefix terlalu panjang")
+		}
+		for len(codes) < request.Count {
+			code, err := GenerateCode(prefix)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := NormalizeCode(code); err != nil {
+				return nil, fmt.Errorf("prefix: %w", err)
+			}
+			codes = append(codes, code)
+		}
+	}
+	if len(codes) > maxVouchersPerRun {
+		return nil, fmt.Errorf("maksimal %d voucher per permintaan", maxVouchersPerRun)
+	}
+
+	vouchers := make([]Voucher, 0, len(codes))
+	for _, code := range codes {
+		vouchers = append(vouchers, Voucher{CampaignID: campaign.ID, Code: code, MaxRedemptions: request.MaxRedemptions})
+	}
+	if err := s.repo.CreateVouchers(vouchers); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "Duplicate entry") {
+			return nil, errors.New("kode voucher sudah digunakan")
+		}
+		return nil, err
+	}
+	return vouchers, nil
+}
+
+func (s *promotionService) ListVouchers(campaignID uint) ([]Voucher, error) {
+	if _, err := s.GetCampaign(campaignID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListVouchers(campaignID)
+}
+
+func (s *promotionService) ListRedemptions(campaignID uint, userID uint) ([]Redemption, error) {
+	return s.repo.ListRedemptions(campaignID, userID, 100)
+}
+
+// Preview memeriksa voucher untuk transaksi tanpa mencadangkan apa pun,
+// sehingga hasilnya bisa berubah saat transaksi benar-benar dibuat.
+func (s *promotionService) Preview(claim Claim) (*Quote, error) {
+	campaign, voucher, benefit, err := s.evaluate(claim)
+	if err != nil {
+		return nil, err
+	}
+
+	if campaign.PerUserQuota > 0 {
+		used, err := s.repo.CountUserRedemptions(campaign.ID, claim.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if used >= int64(campaign.PerUserQuota) {
+			return nil, ErrUserQuotaExhausted
+		}
+	}
+	if campaign.GlobalQuota > 0 && campaign.Redemptions >= campaign.GlobalQuota {
+		return nil, ErrQuotaExhausted
+	}
+	if voucher.MaxRedemptions > 0 && voucher.Redemptions >= voucher.MaxRedemptions {
+		return nil, ErrVoucherExhausted
+	}
+	if campaign.BudgetUsed+benefit > campaign.Budget+budgetTolerance {
+		return nil, ErrBudgetExhausted
+	}
+
+	return quoteOf(campaign, voucher, claim.Amount, benefit), nil
+}
+
+// Redeem mencadangkan benefit voucher untuk transaksi claim.Reference. Dana
+// benefit di-hold dari wallet pendanaan sampai transaksi final lewat Settle.
+func (s *promotionService) Redeem(claim Claim) (*Redemption, error) {
+	if claim.Reference == "" {
+		return nil, errors.New("reference wajib diisi")
+	}
+	campaign, voucher, benefit, err := s.evaluate(claim)
+	if err != nil {
+		return nil, err
+	}
+
+	redemption := &Redemption{
+		CampaignID:           campaign.ID,
+		VoucherID:            voucher.ID,
+		Code:                 voucher.Code,
+		UserID:               claim.UserID,
+		TransactionReference: claim.Reference,
+		TransactionType:      claim.TransactionType,
+		Kind:                 campaign.Kind,
+		Amount:               claim.Amount,
+		Benefit:              benefit,
+		Currency:             campaign.Currency,
+		Status:               RedemptionReserved,
+	}
+	// Diskon menggantikan sebagian pembayaran user, jadi penerima transaksi
+	// tetap menerima jumlah penuh. Tanpa penerima internal, dana diskon
+	// keluar bersama pembayaran ke pihak luar.
+	if campaign.Kind == KindCashback {
+		redemption.BeneficiaryUserID = claim.UserID
+	} else {
+		redemption.BeneficiaryUserID = claim.CounterpartyUserID
+	}
+
+	if err := s.repo.Reserve(redemption, time.Now().Add(s.holdTTL)); err != nil {
+		if errors.Is(err, ErrFundingInsufficient) {
+			log.Printf("ALERT: Wallet pendanaan campaign %d tidak mencukupi untuk benefit %.2f", campaign.ID, benefit)
+		}
+		return nil, err
+	}
+	return redemption, nil
+}
+
+// Settle menyelesaikan redemption milik transaksi reference: membayarkan
+// benefit bila transaksi SUCCESS, atau membatalkannya bila gagal. Benefit
+// yang sudah dibayarkan tidak ditarik kembali saat transaksi kemudian
+// di-refund atau di-reverse.
+func (s *promotionService) Settle(reference string, success bool) {
+	redemption, err := s.repo.FindRedemption(reference)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("ERROR: Gagal mencari redemption promo %s: %v", reference, err)
+		}
+		return
+	}
+	if redemption.Status != RedemptionReserved {
+		return
+	}
+
+	if !success {
+		if err := s.repo.Cancel(redemption); err != nil && !errors.Is(err, ErrRedemptionClosed) {
+			log.Printf("ERROR: Gagal membatalkan redemption promo %s: %v", reference, err)
+		}
+		return
+	}
+
+	// Cashback yang membuat saldo user melewati batas tier KYC-nya dibatalkan
+	// supaya budget campaign kembali.
+	if redemption.Kind == KindCashback && redemption.Currency == balance.DefaultCurrency {
+		if err := s.creditGuard.CheckCredit(redemption.BeneficiaryUserID, redemption.Benefit); err != nil {
+			log.Printf("ALERT: Cashback promo %s dibatalkan: %v", reference, err)
+			if err := s.repo.Cancel(redemption); err != nil && !errors.Is(err, ErrRedemptionClosed) {
+				log.Printf("ERROR: Gagal membatalkan redemption promo %s: %v", reference, err)
+			}
+			return
+		}
+	}
+
+	if err := s.repo.Complete(redemption); err != nil {
+		if !errors.Is(err, ErrRedemptionClosed) {
+			log.Printf("ALERT: Benefit promo %s sebesar %.2f gagal dibayarkan: %v", reference, redemption.Benefit, err)
+		}
+		return
+	}
+	if redemption.Kind == KindCashback {
+		s.notifier.Notify(redemption.UserID, NotificationCashback, "Cashback diterima",
+			fmt.Sprintf("Cashback %s %s dari voucher %s sudah masuk ke saldo Anda.",
+				strconv.FormatFloat(redemption.Benefit, 'f', balance.MinorUnits(redemption.Currency), 64), redemption.Currency, redemption.Code),
+			notifications.Data{"reference": reference, "campaign_id": redemption.CampaignID})
+	}
+	log.Printf("SUCCESS: Benefit promo %s sebesar %.2f %s dibayarkan", reference, redemption.Benefit, redemption.Currency)
+}
+
+// evaluate memeriksa aturan campaign yang tidak bergantung pada pemakaian
+// lain; quota dan budget diperiksa ulang secara atomik oleh Reserve.
+func (s *promotionService) evaluate(claim Claim) (*Campaign, *Voucher, float64, error) {
+	code, err := NormalizeCode(claim.Code)
+	if err != nil {
+		return nil, nil, 0, ErrVoucherNotFound
+	}
+	voucher, err := s.repo.FindVoucher(code)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, 0, ErrVoucherNotFound
+		}
+		return nil, nil, 0, err
+	}
+	campaign, err := s.GetCampaign(voucher.CampaignID)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	if !campaign.RunningAt(time.Now()) {
+		return nil, nil, 0, ErrCampaignNotRunning
+	}
+	if !campaign.Eligible(claim.TransactionType) || campaign.Currency != claim.Currency {
+		return nil, nil, 0, ErrNotEligible
+	}
+	if claim.Amount < campaign.MinSpend {
+		return nil, nil, 0, ErrMinSpend
+	}
+
+	benefit := Benefit(*campaign, claim.Amount)
+	if benefit <= 0 {
+		return nil, nil, 0, ErrNotEligible
+	}
+	// Diskon tidak boleh membuat pembayaran user menjadi nol.
+	if campaign.Kind == KindDiscount && benefit >= claim.Amount {
+		return nil, nil, 0, ErrNotEligible
+	}
+	return campaign, voucher, benefit, nil
+}
+
+func quoteOf(campaign *Campaign, voucher *Voucher, amount float64, benefit float64) *Quote {
+	quote := &Quote{
+		Code:       voucher.Code,
+		CampaignID: campaign.ID,
+		Kind:       campaign.Kind,
+		Amount:     amount,
+		Benefit:    benefit,
+		Payable:    amount,
+	}
+	if campaign.Kind == KindDiscount {
+		quote.Payable = balance.RoundAmount(amount-benefit, campaign.Currency)
+	}
+	return quote
+}
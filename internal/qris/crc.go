@@ -0,0 +1,25 @@
+package qris
+
+import "fmt"
+
+// crc16 menghitung CRC-16/CCITT-FALSE (polinomial 0x1021, nilai awal 0xFFFF)
+// seperti yang diwajibkan EMVCo untuk tag 63.
+func crc16(data string) uint16 {
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(data); i++ {
+		crc ^= uint16(data[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// checksum mengembalikan nilai tag 63 dalam 4 digit heksadesimal huruf besar.
+func checksum(data string) string {
+	return fmt.Sprintf("%04X", crc16(data))
+}
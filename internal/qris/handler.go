@@ -0,0 +1,101 @@
+package qris
+
+import (
+	"ewallet-engine/internal/audit"
+	"ewallet-engine/internal/transactions"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type QRISHandler struct {
+	service      QRISService
+	auditService audit.AuditService
+}
+
+func NewQRISHandler(service QRISService, auditService audit.AuditService) *QRISHandler {
+	return &QRISHandler{service: service, auditService: auditService}
+}
+
+// GenerateHandler membuat QR merchant. QR dinamis dibuat bila amount diisi.
+// Secara default mengembalikan PNG; ?format=json mengembalikan payload teks.
+func (h *QRISHandler) GenerateHandler(c *fiber.Ctx) error {
+	var request Payload
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	raw, code, err := h.service.Generate(request)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionQRISGenerated,
+		TargetType: "merchant",
+		TargetID:   request.Merchant.Identifier(),
+		After:      audit.Snapshot{"name": request.Merchant.Name, "amount": request.Amount, "reference_label": request.ReferenceLabel},
+	})
+
+	if c.Query("format") == "json" {
+		return c.JSON(fiber.Map{"data": fiber.Map{"payload": raw, "version": code.Version}})
+	}
+
+	scale := c.QueryInt("scale", 8)
+	if scale < 1 || scale > 20 {
+		scale = 8
+	}
+	image, err := code.PNG(scale)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+	c.Set(fiber.HeaderContentType, "image/png")
+	return c.Send(image)
+}
+
+func (h *QRISHandler) ParseHandler(c *fiber.Ctx) error {
+	var request struct {
+		Payload string `json:"payload"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	payload, err := h.service.Parse(request.Payload)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": payload})
+}
+
+func (h *QRISHandler) PayHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	var request PayRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	additionalInfo := transactions.AdditionalInfo{
+		"device_id": c.Get("X-Device-ID"),
+		"ip":        c.IP(),
+	}
+
+	transaction, payload, err := h.service.Pay(userID, request, additionalInfo)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	if _, held := transaction.AdditionalInfo["fraud_case_id"]; held {
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+			"message": "Pembayaran QRIS dibuat dan sedang direview",
+			"data":    fiber.Map{"transaction": transaction, "merchant": payload.Merchant},
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Pembayaran QRIS berhasil dibuat",
+		"data":    fiber.Map{"transaction": transaction, "merchant": payload.Merchant},
+	})
+}
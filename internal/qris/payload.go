@@ -0,0 +1,323 @@
+package qris
+
+import (
+	"errors"
+	"ewallet-engine/internal/balance"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Nilai tag 01 (Point of Initiation Method).
+const (
+	InitiationStatic  = "11"
+	InitiationDynamic = "12"
+)
+
+// Nilai tag 55 (Tip or Convenience Indicator).
+const (
+	TipPrompt        = "01"
+	TipFixedFee      = "02"
+	TipPercentageFee = "03"
+)
+
+const (
+	qrisGUID          = "ID.CO.QRIS.WWW"
+	defaultAcquirerID = "ID.CO.EWALLET.WWW"
+)
+
+// numericCurrency memetakan kode ISO 4217 numerik pada tag 53 ke kode huruf.
+var numericCurrency = map[string]string{
+	"360": "IDR",
+	"392": "JPY",
+	"840": "USD",
+	"702": "SGD",
+	"458": "MYR",
+	"978": "EUR",
+	"036": "AUD",
+}
+
+// Merchant adalah data merchant pada QR. MerchantPAN, MerchantID dan
+// AcquirerID masuk ke Merchant Account Information (tag 26), NMID ke
+// template nasional QRIS (tag 51).
+type Merchant struct {
+	Name         string `json:"name"`
+	City         string `json:"city"`
+	PostalCode   string `json:"postal_code,omitempty"`
+	CategoryCode string `json:"category_code"`
+	AcquirerID   string `json:"acquirer_id,omitempty"`
+	MerchantPAN  string `json:"merchant_pan,omitempty"`
+	MerchantID   string `json:"merchant_id,omitempty"`
+	NMID         string `json:"nmid,omitempty"`
+	Criteria     string `json:"criteria,omitempty"`
+}
+
+// Payload adalah isi QR MPM EMVCo/QRIS. Amount hanya terisi pada QR dinamis;
+// QR statis meminta pembayar memasukkan nominal sendiri.
+type Payload struct {
+	Initiation         string   `json:"initiation"`
+	Merchant           Merchant `json:"merchant"`
+	Currency           string   `json:"currency"`
+	Amount             float64  `json:"amount,omitempty"`
+	TipIndicator       string   `json:"tip_indicator,omitempty"`
+	ConvenienceFee     float64  `json:"convenience_fee,omitempty"`
+	ConveniencePercent float64  `json:"convenience_percent,omitempty"`
+	CountryCode        string   `json:"country_code"`
+	BillNumber         string   `json:"bill_number,omitempty"`
+	ReferenceLabel     string   `json:"reference_label,omitempty"`
+	TerminalLabel      string   `json:"terminal_label,omitempty"`
+}
+
+// Dynamic melaporkan apakah QR berlaku untuk satu transaksi dengan nominal tetap.
+func (p *Payload) Dynamic() bool {
+	return p.Initiation == InitiationDynamic
+}
+
+// Build menyusun payload menjadi string QR lengkap dengan CRC pada tag 63.
+func Build(payload Payload) (string, error) {
+	merchant := payload.Merchant
+	if strings.TrimSpace(merchant.Name) == "" || strings.TrimSpace(merchant.City) == "" {
+		return "", errors.New("nama dan kota merchant wajib diisi")
+	}
+	if len(merchant.CategoryCode) != 4 {
+		return "", errors.New("kode kategori merchant (MCC) harus 4 digit")
+	}
+	if merchant.MerchantPAN == "" && merchant.MerchantID == "" && merchant.NMID == "" {
+		return "", errors.New("merchant_pan, merchant_id atau nmid wajib diisi")
+	}
+
+	currency, err := balance.NormalizeCurrency(payload.Currency)
+	if err != nil {
+		return "", err
+	}
+	currencyCode := ""
+	for numeric, alpha := range numericCurrency {
+		if alpha == currency {
+			currencyCode = numeric
+		}
+	}
+
+	initiation := InitiationStatic
+	amount := ""
+	if payload.Amount > 0 {
+		if err := balance.ValidateAmount(payload.Amount, currency); err != nil {
+			return "", err
+		}
+		initiation = InitiationDynamic
+		amount = strconv.FormatFloat(balance.RoundAmount(payload.Amount, currency), 'f', -1, 64)
+	}
+
+	acquirerID := merchant.AcquirerID
+	if acquirerID == "" {
+		acquirerID = defaultAcquirerID
+	}
+	accountInfo, err := EncodeTLV([]Field{
+		{"00", acquirerID},
+		{"01", merchant.MerchantPAN},
+		{"02", merchant.MerchantID},
+		{"03", merchant.Criteria},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var national string
+	if merchant.NMID != "" {
+		national, err = EncodeTLV([]Field{{"00", qrisGUID}, {"02", merchant.NMID}, {"03", merchant.Criteria}})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	additional, err := EncodeTLV([]Field{
+		{"01", payload.BillNumber},
+		{"05", payload.ReferenceLabel},
+		{"07", payload.TerminalLabel},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	fields := []Field{
+		{"00", "01"},
+		{"01", initiation},
+		{"26", accountInfo},
+		{"51", national},
+		{"52", merchant.CategoryCode},
+		{"53", currencyCode},
+		{"54", amount},
+	}
+	switch payload.TipIndicator {
+	case "":
+	case TipPrompt:
+		fields = append(fields, Field{"55", TipPrompt})
+	case TipFixedFee:
+		fields = append(fields, Field{"55", TipFixedFee}, Field{"56", strconv.FormatFloat(payload.ConvenienceFee, 'f', -1, 64)})
+	case TipPercentageFee:
+		fields = append(fields, Field{"55", TipPercentageFee}, Field{"57", strconv.FormatFloat(payload.ConveniencePercent, 'f', -1, 64)})
+	default:
+		return "", fmt.Errorf("tip_indicator tidak dikenal: %s", payload.TipIndicator)
+	}
+	fields = append(fields,
+		Field{"58", "ID"},
+		Field{"59", truncate(merchant.Name, 25)},
+		Field{"60", truncate(merchant.City, 15)},
+		Field{"61", merchant.PostalCode},
+		Field{"62", additional},
+	)
+
+	body, err := EncodeTLV(fields)
+	if err != nil {
+		return "", err
+	}
+	body += "6304"
+	return body + checksum(body), nil
+}
+
+// Parse memvalidasi CRC lalu membaca payload QR. Tag yang tidak dikenal
+// diabaikan supaya QR dari penerbit lain tetap bisa dibaca.
+func Parse(data string) (*Payload, error) {
+	data = strings.TrimSpace(data)
+	if len(data) < 8 || data[len(data)-8:len(data)-4] != "6304" {
+		return nil, errors.New("payload QR tidak memiliki CRC")
+	}
+	if !strings.EqualFold(checksum(data[:len(data)-4]), data[len(data)-4:]) {
+		return nil, errors.New("CRC payload QR tidak valid")
+	}
+
+	fields, err := DecodeTLV(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 || fields[0].Tag != "00" || fields[0].Value != "01" {
+		return nil, errors.New("payload format indicator QR tidak valid")
+	}
+
+	payload := &Payload{
+		Initiation:   lookup(fields, "01"),
+		TipIndicator: lookup(fields, "55"),
+		CountryCode:  lookup(fields, "58"),
+		Merchant: Merchant{
+			CategoryCode: lookup(fields, "52"),
+			Name:         lookup(fields, "59"),
+			City:         lookup(fields, "60"),
+			PostalCode:   lookup(fields, "61"),
+		},
+	}
+	if payload.Initiation != InitiationStatic && payload.Initiation != InitiationDynamic {
+		return nil, errors.New("point of initiation QR tidak valid")
+	}
+	if payload.Merchant.Name == "" {
+		return nil, errors.New("QR tidak memuat nama merchant")
+	}
+
+	currency, ok := numericCurrency[lookup(fields, "53")]
+	if !ok {
+		return nil, fmt.Errorf("mata uang QR tidak didukung: %s", lookup(fields, "53"))
+	}
+	payload.Currency = currency
+
+	if raw := lookup(fields, "54"); raw != "" {
+		amount, err := strconv.ParseFloat(raw, 64)
+		if err != nil || amount <= 0 {
+			return nil, errors.New("nominal pada QR tidak valid")
+		}
+		payload.Amount = amount
+	}
+	if payload.Dynamic() && payload.Amount == 0 {
+		return nil, errors.New("QR dinamis tidak memuat nominal")
+	}
+
+	switch payload.TipIndicator {
+	case "", TipPrompt:
+	case TipFixedFee:
+		payload.ConvenienceFee, err = strconv.ParseFloat(lookup(fields, "56"), 64)
+	case TipPercentageFee:
+		payload.ConveniencePercent, err = strconv.ParseFloat(lookup(fields, "57"), 64)
+	default:
+		err = fmt.Errorf("tip indicator tidak dikenal: %s", payload.TipIndicator)
+	}
+	if err != nil {
+		return nil, errors.New("biaya layanan pada QR tidak valid")
+	}
+
+	// Merchant Account Information bisa berada di tag 26 sampai 45; yang
+	// pertama dipakai sebagai identitas merchant.
+	for _, field := range fields {
+		tag, _ := strconv.Atoi(field.Tag)
+		if tag < 26 || tag > 45 || payload.Merchant.AcquirerID != "" {
+			continue
+		}
+		sub, err := DecodeTLV(field.Value)
+		if err != nil {
+			return nil, fmt.Errorf("merchant account information tag %s tidak valid", field.Tag)
+		}
+		payload.Merchant.AcquirerID = lookup(sub, "00")
+		payload.Merchant.MerchantPAN = lookup(sub, "01")
+		payload.Merchant.MerchantID = lookup(sub, "02")
+		payload.Merchant.Criteria = lookup(sub, "03")
+	}
+	if national := lookup(fields, "51"); national != "" {
+		sub, err := DecodeTLV(national)
+		if err != nil {
+			return nil, errors.New("template QRIS nasional tidak valid")
+		}
+		payload.Merchant.NMID = lookup(sub, "02")
+		if payload.Merchant.Criteria == "" {
+			payload.Merchant.Criteria = lookup(sub, "03")
+		}
+	}
+	if payload.Merchant.MerchantPAN == "" && payload.Merchant.MerchantID == "" && payload.Merchant.NMID == "" {
+		return nil, errors.New("QR tidak memuat identitas merchant")
+	}
+
+	if additional := lookup(fields, "62"); additional != "" {
+		sub, err := DecodeTLV(additional)
+		if err != nil {
+			return nil, errors.New("additional data QR tidak valid")
+		}
+		payload.BillNumber = lookup(sub, "01")
+		payload.ReferenceLabel = lookup(sub, "05")
+		payload.TerminalLabel = lookup(sub, "07")
+	}
+	return payload, nil
+}
+
+// Identifier adalah identitas merchant yang paling spesifik pada QR.
+func (m Merchant) Identifier() string {
+	switch {
+	case m.NMID != "":
+		return m.NMID
+	case m.MerchantID != "":
+		return m.MerchantID
+	default:
+		return m.MerchantPAN
+	}
+}
+
+// Charge mengembalikan total yang dibayar untuk nominal amount, termasuk tip
+// yang dimasukkan pembayar atau biaya layanan yang ditetapkan merchant.
+func (p *Payload) Charge(amount float64, tip float64) (float64, float64, error) {
+	var extra float64
+	switch p.TipIndicator {
+	case TipPrompt:
+		if tip < 0 {
+			return 0, 0, errors.New("tip tidak boleh negatif")
+		}
+		extra = tip
+	case TipFixedFee:
+		extra = p.ConvenienceFee
+	case TipPercentageFee:
+		extra = amount * p.ConveniencePercent / 100
+	}
+	extra = balance.RoundAmount(extra, p.Currency)
+	return balance.RoundAmount(amount+extra, p.Currency), extra, nil
+}
+
+func truncate(value string, max int) string {
+	value = strings.TrimSpace(value)
+	if len(value) > max {
+		return value[:max]
+	}
+	return value
+}
@@ -0,0 +1,497 @@
+package qris
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// Encoder QR Code (ISO/IEC 18004) minimal untuk payload QRIS: mode byte,
+// error correction level M, versi 1 sampai 40. Level M adalah level yang
+// direkomendasikan untuk QRIS cetak.
+
+// eccCodewordsPerBlockM dan errorCorrectionBlocksM adalah tabel level M per
+// versi; indeks 0 tidak dipakai.
+var eccCodewordsPerBlockM = [41]int{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28}
+var errorCorrectionBlocksM = [41]int{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49}
+
+// formatBitsM adalah 2 bit level M pada format information.
+const formatBitsM = 0
+
+// QRCode adalah matriks modul hasil encode; true berarti modul gelap.
+type QRCode struct {
+	Version  int
+	Size     int
+	Mask     int
+	modules  [][]bool
+	function [][]bool
+}
+
+// Dark melaporkan apakah modul pada kolom x dan baris y berwarna gelap.
+func (q *QRCode) Dark(x, y int) bool {
+	return q.modules[y][x]
+}
+
+// EncodeQR membuat QR Code berisi data dalam mode byte dengan versi terkecil
+// yang cukup dan mask dengan penalti terendah.
+func EncodeQR(data []byte) (*QRCode, error) {
+	version := 0
+	for v := 1; v <= 40; v++ {
+		if 4+charCountBits(v)+len(data)*8 <= dataCodewords(v)*8 {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, errors.New("data terlalu panjang untuk QR Code")
+	}
+
+	q := newQRCode(version)
+	q.drawFunctionPatterns()
+	q.drawCodewords(addErrorCorrection(dataCodewordsFor(data, version), version))
+
+	bestMask, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormatBits(mask)
+		if penalty := q.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			bestMask, bestPenalty = mask, penalty
+		}
+		q.applyMask(mask)
+	}
+	q.Mask = bestMask
+	q.applyMask(bestMask)
+	q.drawFormatBits(bestMask)
+	return q, nil
+}
+
+// PNG merender QR Code dengan scale piksel per modul dan quiet zone 4 modul.
+func (q *QRCode) PNG(scale int) ([]byte, error) {
+	if scale < 1 {
+		scale = 1
+	}
+	const border = 4
+	dimension := (q.Size + 2*border) * scale
+	img := image.NewPaletted(image.Rect(0, 0, dimension, dimension), color.Palette{color.White, color.Black})
+	for y := 0; y < q.Size; y++ {
+		for x := 0; x < q.Size; x++ {
+			if !q.modules[y][x] {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetColorIndex((x+border)*scale+dx, (y+border)*scale+dy, 1)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// dataCodewordsFor menyusun segmen mode byte, terminator dan byte pengisi
+// sampai kapasitas data versi tersebut penuh.
+func dataCodewordsFor(data []byte, version int) []byte {
+	bits := &bitBuffer{}
+	bits.append(0x4, 4)
+	bits.append(len(data), charCountBits(version))
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+
+	capacity := dataCodewords(version) * 8
+	terminator := capacity - len(*bits)
+	if terminator > 4 {
+		terminator = 4
+	}
+	bits.append(0, terminator)
+	bits.append(0, (8-len(*bits)%8)%8)
+	for pad := 0xEC; len(*bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+
+	codewords := make([]byte, len(*bits)/8)
+	for i, bit := range *bits {
+		if bit {
+			codewords[i>>3] |= 1 << (7 - uint(i&7))
+		}
+	}
+	return codewords
+}
+
+type bitBuffer []bool
+
+func (b *bitBuffer) append(value int, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*b = append(*b, (value>>uint(i))&1 == 1)
+	}
+}
+
+func charCountBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+// rawDataModules adalah jumlah modul yang tersedia untuk data dan ECC setelah
+// dikurangi seluruh function pattern.
+func rawDataModules(version int) int {
+	result := (16*version+128)*version + 64
+	if version >= 2 {
+		alignments := version/7 + 2
+		result -= (25*alignments-10)*alignments - 55
+		if version >= 7 {
+			result -= 36
+		}
+	}
+	return result
+}
+
+func dataCodewords(version int) int {
+	return rawDataModules(version)/8 - eccCodewordsPerBlockM[version]*errorCorrectionBlocksM[version]
+}
+
+// alignmentPositions mengembalikan koordinat tengah alignment pattern.
+func alignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	count := version/7 + 2
+	step := (version*8 + count*3 + 5) / (count*4 - 4) * 2
+	positions := make([]int, count)
+	positions[0] = 6
+	for i, pos := count-1, version*4+17-7; i >= 1; i, pos = i-1, pos-step {
+		positions[i] = pos
+	}
+	return positions
+}
+
+func newQRCode(version int) *QRCode {
+	size := version*4 + 17
+	q := &QRCode{Version: version, Size: size, modules: make([][]bool, size), function: make([][]bool, size)}
+	for i := range q.modules {
+		q.modules[i] = make([]bool, size)
+		q.function[i] = make([]bool, size)
+	}
+	return q
+}
+
+func (q *QRCode) setFunction(x, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.function[y][x] = true
+}
+
+func (q *QRCode) drawFunctionPatterns() {
+	for i := 0; i < q.Size; i++ {
+		q.setFunction(6, i, i%2 == 0)
+		q.setFunction(i, 6, i%2 == 0)
+	}
+
+	q.drawFinder(3, 3)
+	q.drawFinder(q.Size-4, 3)
+	q.drawFinder(3, q.Size-4)
+
+	positions := alignmentPositions(q.Version)
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			// Alignment pattern tidak boleh menimpa finder pattern di tiga sudut.
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			q.drawAlignment(x, y)
+		}
+	}
+
+	q.drawFormatBits(0)
+	q.drawVersion()
+}
+
+func (q *QRCode) drawFinder(cx, cy int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			x, y := cx+dx, cy+dy
+			if x < 0 || x >= q.Size || y < 0 || y >= q.Size {
+				continue
+			}
+			distance := max(abs(dx), abs(dy))
+			q.setFunction(x, y, distance != 2 && distance != 4)
+		}
+	}
+}
+
+func (q *QRCode) drawAlignment(cx, cy int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			q.setFunction(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// drawFormatBits menulis level ECC dan mask (BCH 15,5) di dua lokasi
+// beserta dark module.
+func (q *QRCode) drawFormatBits(mask int) {
+	data := formatBitsM<<3 | mask
+	remainder := data
+	for i := 0; i < 10; i++ {
+		remainder = remainder<<1 ^ (remainder>>9)*0x537
+	}
+	bits := (data<<10 | remainder) ^ 0x5412
+
+	for i := 0; i <= 5; i++ {
+		q.setFunction(8, i, bitAt(bits, i))
+	}
+	q.setFunction(8, 7, bitAt(bits, 6))
+	q.setFunction(8, 8, bitAt(bits, 7))
+	q.setFunction(7, 8, bitAt(bits, 8))
+	for i := 9; i < 15; i++ {
+		q.setFunction(14-i, 8, bitAt(bits, i))
+	}
+
+	for i := 0; i < 8; i++ {
+		q.setFunction(q.Size-1-i, 8, bitAt(bits, i))
+	}
+	for i := 8; i < 15; i++ {
+		q.setFunction(8, q.Size-15+i, bitAt(bits, i))
+	}
+	q.setFunction(8, q.Size-8, true)
+}
+
+// drawVersion menulis version information (BCH 18,6) untuk versi 7 ke atas.
+func (q *QRCode) drawVersion() {
+	if q.Version < 7 {
+		return
+	}
+	remainder := q.Version
+	for i := 0; i < 12; i++ {
+		remainder = remainder<<1 ^ (remainder>>11)*0x1F25
+	}
+	bits := q.Version<<12 | remainder
+
+	for i := 0; i < 18; i++ {
+		dark := bitAt(bits, i)
+		a, b := q.Size-11+i%3, i/3
+		q.setFunction(a, b, dark)
+		q.setFunction(b, a, dark)
+	}
+}
+
+// drawCodewords menempatkan bit data secara zig-zag dua kolom dari kanan bawah.
+func (q *QRCode) drawCodewords(data []byte) {
+	i := 0
+	for right := q.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vertical := 0; vertical < q.Size; vertical++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vertical
+				if (right+1)&2 == 0 {
+					y = q.Size - 1 - vertical
+				}
+				if !q.function[y][x] && i < len(data)*8 {
+					q.modules[y][x] = bitAt(int(data[i>>3]), 7-i&7)
+					i++
+				}
+			}
+		}
+	}
+}
+
+func (q *QRCode) applyMask(mask int) {
+	for y := 0; y < q.Size; y++ {
+		for x := 0; x < q.Size; x++ {
+			if q.function[y][x] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			q.modules[y][x] = q.modules[y][x] != invert
+		}
+	}
+}
+
+// penalty menghitung skor penalti mask sesuai empat aturan ISO/IEC 18004.
+func (q *QRCode) penalty() int {
+	score := 0
+	finderLike := func(line []bool, i int) bool {
+		pattern := []bool{true, false, true, true, true, false, true}
+		for k, dark := range pattern {
+			if line[i+k] != dark {
+				return false
+			}
+		}
+		light := func(from, to int) bool {
+			for k := from; k < to; k++ {
+				if k >= 0 && k < len(line) && line[k] {
+					return false
+				}
+			}
+			return true
+		}
+		return light(i-4, i) || light(i+7, i+11)
+	}
+
+	lines := make([][]bool, 0, 2*q.Size)
+	for y := 0; y < q.Size; y++ {
+		lines = append(lines, q.modules[y])
+	}
+	for x := 0; x < q.Size; x++ {
+		column := make([]bool, q.Size)
+		for y := 0; y < q.Size; y++ {
+			column[y] = q.modules[y][x]
+		}
+		lines = append(lines, column)
+	}
+
+	for _, line := range lines {
+		run := 1
+		for i := 1; i <= len(line); i++ {
+			if i < len(line) && line[i] == line[i-1] {
+				run++
+				continue
+			}
+			if run >= 5 {
+				score += run - 2
+			}
+			run = 1
+		}
+		for i := 0; i+7 <= len(line); i++ {
+			if finderLike(line, i) {
+				score += 40
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < q.Size; y++ {
+		for x := 0; x < q.Size; x++ {
+			if q.modules[y][x] {
+				dark++
+			}
+			if x+1 < q.Size && y+1 < q.Size {
+				c := q.modules[y][x]
+				if c == q.modules[y][x+1] && c == q.modules[y+1][x] && c == q.modules[y+1][x+1] {
+					score += 3
+				}
+			}
+		}
+	}
+	total := q.Size * q.Size
+	deviation := abs(dark*20-total*10) / total
+	score += deviation * 10
+	return score
+}
+
+// addErrorCorrection membagi data ke blok, menambahkan ECC Reed-Solomon per
+// blok lalu menyelang-nyelingkan hasilnya.
+func addErrorCorrection(data []byte, version int) []byte {
+	blocks := errorCorrectionBlocksM[version]
+	eccLength := eccCodewordsPerBlockM[version]
+	rawCodewords := rawDataModules(version) / 8
+	shortBlocks := blocks - rawCodewords%blocks
+	shortBlockLength := rawCodewords / blocks
+
+	divisor := reedSolomonDivisor(eccLength)
+	all := make([][]byte, blocks)
+	offset := 0
+	for i := 0; i < blocks; i++ {
+		length := shortBlockLength - eccLength
+		if i >= shortBlocks {
+			length++
+		}
+		block := append([]byte{}, data[offset:offset+length]...)
+		offset += length
+		ecc := reedSolomonRemainder(block, divisor)
+		if i < shortBlocks {
+			block = append(block, 0)
+		}
+		all[i] = append(block, ecc...)
+	}
+
+	result := make([]byte, 0, rawCodewords)
+	for i := 0; i < len(all[0]); i++ {
+		for j, block := range all {
+			// Byte pengganjal pada blok pendek tidak ikut ditulis.
+			if i != shortBlockLength-eccLength || j >= shortBlocks {
+				result = append(result, block[i])
+			}
+		}
+	}
+	return result
+}
+
+func reedSolomonDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+func reedSolomonRemainder(data []byte, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, coefficient := range divisor {
+			result[i] ^= gfMultiply(coefficient, factor)
+		}
+	}
+	return result
+}
+
+// gfMultiply mengalikan dua elemen GF(2^8) dengan polinomial 0x11D.
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		z ^= int(y>>uint(i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+func bitAt(value int, i int) bool {
+	return (value>>uint(i))&1 != 0
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
@@ -0,0 +1,158 @@
+package qris
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCRC16(t *testing.T) {
+	if got := crc16("123456789"); got != 0x29B1 {
+		t.Fatalf("crc16 = %04X, want 29B1", got)
+	}
+}
+
+func TestBuildParseRoundTrip(t *testing.T) {
+	merchant := Merchant{
+		Name:         "Warung Kopi Senja",
+		City:         "Jakarta",
+		PostalCode:   "12190",
+		CategoryCode: "5814",
+		MerchantPAN:  "936000140000123456",
+		MerchantID:   "MRC001",
+		NMID:         "ID1020000000001",
+		Criteria:     "UMI",
+	}
+
+	static, err := Build(Payload{Merchant: merchant, Currency: "IDR"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := Parse(static)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Dynamic() || parsed.Amount != 0 || parsed.Merchant != (Merchant{
+		Name: "Warung Kopi Senja", City: "Jakarta", PostalCode: "12190", CategoryCode: "5814",
+		AcquirerID: defaultAcquirerID, MerchantPAN: "936000140000123456", MerchantID: "MRC001", NMID: "ID1020000000001", Criteria: "UMI",
+	}) {
+		t.Fatalf("static payload = %+v", parsed)
+	}
+
+	dynamic, err := Build(Payload{Merchant: merchant, Currency: "IDR", Amount: 25000, BillNumber: "INV-7", ReferenceLabel: "ORD-99", TipIndicator: TipFixedFee, ConvenienceFee: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err = Parse(dynamic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.Dynamic() || parsed.Amount != 25000 || parsed.BillNumber != "INV-7" || parsed.ReferenceLabel != "ORD-99" || parsed.Currency != "IDR" {
+		t.Fatalf("dynamic payload = %+v", parsed)
+	}
+	if total, fee, _ := parsed.Charge(parsed.Amount, 0); total != 26000 || fee != 1000 {
+		t.Fatalf("Charge = %v, %v", total, fee)
+	}
+
+	tampered := []byte(dynamic)
+	tampered[len(tampered)-10] ^= 1
+	if _, err := Parse(string(tampered)); err == nil {
+		t.Fatal("expected CRC error for tampered payload")
+	}
+}
+
+func TestReedSolomon(t *testing.T) {
+	// Contoh versi 1-M "HELLO WORLD" dari spesifikasi.
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	if got := reedSolomonRemainder(data, reedSolomonDivisor(10)); !bytes.Equal(got, want) {
+		t.Fatalf("ecc = %v, want %v", got, want)
+	}
+}
+
+func TestDataCapacity(t *testing.T) {
+	for version, want := range map[int]int{1: 16, 2: 28, 5: 86, 7: 124, 10: 216, 40: 2334} {
+		if got := dataCodewords(version); got != want {
+			t.Errorf("dataCodewords(%d) = %d, want %d", version, got, want)
+		}
+	}
+}
+
+func TestEncodeQRLayout(t *testing.T) {
+	payload, err := Build(Payload{
+		Merchant: Merchant{Name: "Toko Maju", City: "Bandung", CategoryCode: "5411", NMID: "ID1020000000002"},
+		Currency: "IDR",
+		Amount:   150000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := EncodeQR([]byte(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code.Size != code.Version*4+17 {
+		t.Fatalf("size %d tidak cocok dengan versi %d", code.Size, code.Version)
+	}
+
+	// Format information di sekitar finder kiri atas harus memuat level M
+	// dan mask yang dipilih.
+	bits := 0
+	for i := 0; i <= 5; i++ {
+		if code.Dark(8, i) {
+			bits |= 1 << i
+		}
+	}
+	for i, pos := range [][2]int{{8, 7}, {8, 8}, {7, 8}} {
+		if code.Dark(pos[0], pos[1]) {
+			bits |= 1 << (6 + i)
+		}
+	}
+	for i := 9; i < 15; i++ {
+		if code.Dark(14-i, 8) {
+			bits |= 1 << i
+		}
+	}
+	format := (bits ^ 0x5412) >> 10
+	if format>>3 != formatBitsM || format&7 != code.Mask {
+		t.Fatalf("format bits %05b, mask %d", format, code.Mask)
+	}
+
+	// Codeword yang dibaca ulang dari matriks harus sama dengan yang ditulis.
+	code.applyMask(code.Mask)
+	var read []byte
+	var current byte
+	count := 0
+	for right := code.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vertical := 0; vertical < code.Size; vertical++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vertical
+				if (right+1)&2 == 0 {
+					y = code.Size - 1 - vertical
+				}
+				if code.function[y][x] {
+					continue
+				}
+				current <<= 1
+				if code.modules[y][x] {
+					current |= 1
+				}
+				if count++; count%8 == 0 {
+					read = append(read, current)
+				}
+			}
+		}
+	}
+	if want := addErrorCorrection(dataCodewordsFor([]byte(payload), code.Version), code.Version); !bytes.Equal(read, want) {
+		t.Fatalf("codeword terbaca berbeda dari yang ditulis")
+	}
+
+	png, err := code.PNG(4)
+	if err != nil || !bytes.HasPrefix(png, []byte("\x89PNG")) {
+		t.Fatalf("PNG tidak valid: %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package qris
+
+import (
+	"errors"
+	"ewallet-engine/internal/balance"
+	"ewallet-engine/internal/transactions"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PayRequest adalah input pembayaran dari QR yang dipindai. Amount wajib
+// untuk QR statis dan harus kosong atau sama dengan nominal QR dinamis.
+type PayRequest struct {
+	Payload     string  `json:"payload"`
+	Amount      float64 `json:"amount"`
+	Tip         float64 `json:"tip"`
+	Reference   string  `json:"reference"`
+	Description string  `json:"description"`
+}
+
+type QRISService interface {
+	Generate(payload Payload) (string, *QRCode, error)
+	Parse(raw string) (*Payload, error)
+	Pay(userID uint, request PayRequest, additionalInfo transactions.AdditionalInfo) (*transactions.Transaction, *Payload, error)
+}
+
+type qrisService struct {
+	transactions transactions.TransactionService
+}
+
+func NewQRISService(transactionService transactions.TransactionService) QRISService {
+	return &qrisService{transactions: transactionService}
+}
+
+func (s *qrisService) Generate(payload Payload) (string, *QRCode, error) {
+	raw, err := Build(payload)
+	if err != nil {
+		return "", nil, err
+	}
+	code, err := EncodeQR([]byte(raw))
+	if err != nil {
+		return "", nil, err
+	}
+	return raw, code, nil
+}
+
+func (s *qrisService) Parse(raw string) (*Payload, error) {
+	return Parse(raw)
+}
+
+// Pay membuat PURCHASE PENDING ke merchant pada QR; penyelesaiannya tetap
+// mengikuti alur PURCHASE biasa. QR dinamis dengan reference label hanya bisa
+// dibayar sekali karena reference transaksinya diturunkan dari label itu.
+func (s *qrisService) Pay(userID uint, request PayRequest, additionalInfo transactions.AdditionalInfo) (*transactions.Transaction, *Payload, error) {
+	payload, err := Parse(request.Payload)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	amount := request.Amount
+	if payload.Dynamic() {
+		if amount > 0 && balance.ToMinor(amount, payload.Currency) != balance.ToMinor(payload.Amount, payload.Currency) {
+			return nil, nil, errors.New("nominal tidak sama dengan nominal pada QR")
+		}
+		amount = payload.Amount
+	} else if amount <= 0 {
+		return nil, nil, errors.New("nominal wajib diisi untuk QR statis")
+	}
+
+	total, extra, err := payload.Charge(amount, request.Tip)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reference := strings.TrimSpace(request.Reference)
+	if payload.Dynamic() && payload.ReferenceLabel != "" {
+		reference = fmt.Sprintf("QRIS-%s-%s", payload.Merchant.Identifier(), payload.ReferenceLabel)
+	}
+	if reference == "" {
+		reference = fmt.Sprintf("QRIS-%d-%d", userID, time.Now().UnixNano())
+	}
+
+	description := strings.TrimSpace(request.Description)
+	if description == "" {
+		description = "Pembayaran QRIS ke " + payload.Merchant.Name
+	}
+
+	if additionalInfo == nil {
+		additionalInfo = make(transactions.AdditionalInfo)
+	}
+	qrType := "STATIC"
+	if payload.Dynamic() {
+		qrType = "DYNAMIC"
+	}
+	additionalInfo["channel"] = "QRIS"
+	additionalInfo["counterparty_name"] = payload.Merchant.Name
+	additionalInfo["qr_type"] = qrType
+	additionalInfo["merchant"] = map[string]interface{}{
+		"name":          payload.Merchant.Name,
+		"city":          payload.Merchant.City,
+		"postal_code":   payload.Merchant.PostalCode,
+		"category_code": payload.Merchant.CategoryCode,
+		"acquirer_id":   payload.Merchant.AcquirerID,
+		"merchant_pan":  payload.Merchant.MerchantPAN,
+		"merchant_id":   payload.Merchant.MerchantID,
+		"nmid":          payload.Merchant.NMID,
+		"criteria":      payload.Merchant.Criteria,
+	}
+	if payload.BillNumber != "" {
+		additionalInfo["bill_number"] = payload.BillNumber
+	}
+	if payload.ReferenceLabel != "" {
+		additionalInfo["reference_label"] = payload.ReferenceLabel
+	}
+	if payload.TerminalLabel != "" {
+		additionalInfo["terminal_label"] = payload.TerminalLabel
+	}
+	if extra > 0 {
+		additionalInfo["base_amount"] = amount
+		if payload.TipIndicator == TipPrompt {
+			additionalInfo["tip"] = extra
+		} else {
+			additionalInfo["convenience_fee"] = extra
+		}
+	}
+
+	transaction, err := s.transactions.InitiateTransaction(userID, total, payload.Currency, transactions.TransactionPurchase, reference, description, additionalInfo)
+	if err != nil {
+		return nil, nil, err
+	}
+	return transaction, payload, nil
+}
@@ -0,0 +1,69 @@
+package qris
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Field adalah satu elemen TLV EMVCo: tag 2 digit, panjang 2 digit, lalu nilai.
+type Field struct {
+	Tag   string
+	Value string
+}
+
+// EncodeTLV menyusun fields menjadi string TLV sesuai urutan yang diberikan.
+func EncodeTLV(fields []Field) (string, error) {
+	var out []byte
+	for _, field := range fields {
+		if len(field.Tag) != 2 {
+			return "", fmt.Errorf("tag %q harus 2 digit", field.Tag)
+		}
+		if len(field.Value) == 0 {
+			continue
+		}
+		if len(field.Value) > 99 {
+			return "", fmt.Errorf("nilai tag %s lebih dari 99 karakter", field.Tag)
+		}
+		out = append(out, field.Tag...)
+		out = append(out, fmt.Sprintf("%02d", len(field.Value))...)
+		out = append(out, field.Value...)
+	}
+	return string(out), nil
+}
+
+// DecodeTLV memecah string TLV menjadi fields. Panjang dihitung dalam byte
+// seperti pada spesifikasi EMVCo.
+func DecodeTLV(data string) ([]Field, error) {
+	var fields []Field
+	for offset := 0; offset < len(data); {
+		if offset+4 > len(data) {
+			return nil, errors.New("payload QR terpotong")
+		}
+		tag := data[offset : offset+2]
+		if _, err := strconv.Atoi(tag); err != nil {
+			return nil, fmt.Errorf("tag %q tidak valid", tag)
+		}
+		length, err := strconv.Atoi(data[offset+2 : offset+4])
+		if err != nil {
+			return nil, fmt.Errorf("panjang tag %s tidak valid", tag)
+		}
+		offset += 4
+		if offset+length > len(data) {
+			return nil, fmt.Errorf("nilai tag %s melebihi panjang payload", tag)
+		}
+		fields = append(fields, Field{Tag: tag, Value: data[offset : offset+length]})
+		offset += length
+	}
+	return fields, nil
+}
+
+// lookup mengembalikan nilai tag pertama yang cocok.
+func lookup(fields []Field, tag string) string {
+	for _, field := range fields {
+		if field.Tag == tag {
+			return field.Value
+		}
+	}
+	return ""
+}
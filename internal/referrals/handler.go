@@ -0,0 +1,112 @@
+package referrals
+
+import (
+	"errors"
+	"ewallet-engine/internal/audit"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ReferralHandler struct {
+	service      ReferralService
+	auditService audit.AuditService
+}
+
+func NewReferralHandler(service ReferralService, auditService audit.AuditService) *ReferralHandler {
+	return &ReferralHandler{service: service, auditService: auditService}
+}
+
+func (h *ReferralHandler) GetReferralsHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	summary, err := h.service.Summary(userID, c.QueryInt("limit", defaultListLimit))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": summary})
+}
+
+func (h *ReferralHandler) ListHandler(c *fiber.Ctx) error {
+	referrals, err := h.service.ListReferrals(Status(c.Query("status")), uint(c.QueryInt("referrer_user_id", 0)), c.QueryInt("limit", defaultListLimit))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": referrals})
+}
+
+func (h *ReferralHandler) ApproveHandler(c *fiber.Ctx) error {
+	return h.decide(c, true)
+}
+
+func (h *ReferralHandler) RejectHandler(c *fiber.Ctx) error {
+	return h.decide(c, false)
+}
+
+func (h *ReferralHandler) decide(c *fiber.Ctx, approve bool) error {
+	reviewerID := c.Locals("user_id").(uint)
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	var request struct {
+		Note string `json:"note"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+	}
+
+	var before, after *Referral
+	action, message := audit.ActionReferralApproved, "Referral disetujui dan reward dibayarkan"
+	if approve {
+		before, after, err = h.service.Approve(uint(id), reviewerID, request.Note)
+	} else {
+		action, message = audit.ActionReferralRejected, "Referral ditolak"
+		before, after, err = h.service.Reject(uint(id), reviewerID, request.Note)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrReferralNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+		case errors.Is(err, ErrAlreadyProcessed):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"message": err.Error()})
+		case errors.Is(err, ErrFundingUnavailable):
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"message": err.Error()})
+		default:
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+		}
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     action,
+		TargetType: "referral",
+		TargetID:   fmt.Sprint(after.ID),
+		Before:     referralSnapshot(before),
+		After:      referralSnapshot(after),
+	})
+
+	return c.JSON(fiber.Map{
+		"message": message,
+		"data":    after,
+	})
+}
+
+func referralSnapshot(referral *Referral) audit.Snapshot {
+	return audit.Snapshot{
+		"referrer_user_id":     referral.ReferrerUserID,
+		"referee_user_id":      referral.RefereeUserID,
+		"status":               referral.Status,
+		"reason":               referral.Reason,
+		"qualifying_reference": referral.QualifyingReference,
+		"referrer_reward":      referral.ReferrerReward,
+		"referee_reward":       referral.RefereeReward,
+		"currency":             referral.Currency,
+	}
+}
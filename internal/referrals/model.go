@@ -0,0 +1,107 @@
+package referrals
+
+import (
+	"time"
+)
+
+// Code adalah kode referral milik satu user. Kode dibuat saat registrasi
+// atau pertama kali dibutuhkan untuk user lama.
+type Code struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;uniqueIndex" json:"user_id"`
+	Code      string    `gorm:"type:varchar(16);not null;uniqueIndex" json:"code"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (Code) TableName() string {
+	return "referral_codes"
+}
+
+type Status string
+
+const (
+	// StatusPending menunggu referee menyelesaikan KYC dan transaksi pertama.
+	StatusPending Status = "PENDING"
+	// StatusReview sudah memenuhi syarat tetapi ada sinyal self-referral yang
+	// perlu diputuskan operator.
+	StatusReview   Status = "REVIEW"
+	StatusRewarded Status = "REWARDED"
+	StatusRejected Status = "REJECTED"
+	// StatusExpired tidak memenuhi syarat dalam masa kualifikasi.
+	StatusExpired Status = "EXPIRED"
+)
+
+// Referral menghubungkan referee dengan referrer yang kodenya dipakai saat
+// registrasi. Satu user hanya bisa menjadi referee sekali.
+type Referral struct {
+	ID             uint   `gorm:"primaryKey" json:"id"`
+	ReferrerUserID uint   `gorm:"not null;index:idx_referral_referrer" json:"referrer_user_id"`
+	RefereeUserID  uint   `gorm:"not null;uniqueIndex" json:"referee_user_id"`
+	Code           string `gorm:"type:varchar(16);not null" json:"code"`
+	Status         Status `gorm:"type:varchar(10);not null;default:'PENDING';index;index:idx_referral_referrer" json:"status"`
+	SignupDeviceID string `gorm:"type:varchar(100);index" json:"signup_device_id"`
+	SignupIP       string `gorm:"type:varchar(45)" json:"signup_ip"`
+	// Reason adalah sinyal self-referral atau catatan operator; hanya untuk internal.
+	Reason string `gorm:"type:varchar(255)" json:"reason,omitempty"`
+	// QualifyingReference adalah transaksi pertama referee yang memenuhi syarat.
+	QualifyingReference string     `gorm:"type:varchar(255)" json:"qualifying_reference,omitempty"`
+	ReferrerReward      float64    `gorm:"not null;default:0" json:"referrer_reward"`
+	RefereeReward       float64    `gorm:"not null;default:0" json:"referee_reward"`
+	Currency            string     `gorm:"type:char(3);not null;default:'IDR'" json:"currency"`
+	ReviewerID          *uint      `json:"reviewer_id,omitempty"`
+	QualifiedAt         *time.Time `json:"qualified_at,omitempty"`
+	RewardedAt          *time.Time `gorm:"index:idx_referral_referrer" json:"rewarded_at,omitempty"`
+	CreatedAt           time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt           time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// View adalah referral seperti yang dilihat referrer. Status REVIEW
+// ditampilkan sebagai PENDING dan alasannya tidak ikut supaya sinyal fraud
+// tidak bocor.
+type View struct {
+	ID         uint       `json:"id"`
+	Status     Status     `json:"status"`
+	Reward     float64    `json:"reward"`
+	Currency   string     `json:"currency"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RewardedAt *time.Time `json:"rewarded_at,omitempty"`
+}
+
+func (r Referral) View() View {
+	status := r.Status
+	if status == StatusReview {
+		status = StatusPending
+	}
+	return View{
+		ID:         r.ID,
+		Status:     status,
+		Reward:     r.ReferrerReward,
+		Currency:   r.Currency,
+		CreatedAt:  r.CreatedAt,
+		RewardedAt: r.RewardedAt,
+	}
+}
+
+// Summary adalah ringkasan program referral untuk satu user.
+type Summary struct {
+	Code           string  `json:"code"`
+	ReferrerReward float64 `json:"referrer_reward"`
+	RefereeReward  float64 `json:"referee_reward"`
+	Currency       string  `json:"currency"`
+	Pending        int64   `json:"pending"`
+	Rewarded       int64   `json:"rewarded"`
+	Earned         float64 `json:"earned"`
+	Referrals      []View  `json:"referrals"`
+}
+
+// Signals adalah indikasi bahwa referrer dan referee adalah orang yang sama.
+type Signals struct {
+	SamePhone    bool
+	SameIDNumber bool
+	// SharedDevice berarti device referee pernah dipakai referrer.
+	SharedDevice bool
+	// DeviceReused berarti device signup sudah dipakai referee lain dari referrer yang sama.
+	DeviceReused  bool
+	SharedIP      bool
+	MissingDevice bool
+}
@@ -0,0 +1,31 @@
+package referrals
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// StartQualifier secara berkala memberi reward untuk referral yang sudah
+// memenuhi syarat dan mengakhiri yang melewati masa kualifikasi sampai ctx
+// dibatalkan.
+func StartQualifier(ctx context.Context, service ReferralService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			processed, err := service.ProcessPending()
+			if err != nil {
+				log.Printf("ERROR: Gagal memproses referral: %v", err)
+				continue
+			}
+			if processed > 0 {
+				log.Printf("SUCCESS: %d referral diproses", processed)
+			}
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package referrals
+
+import (
+	"ewallet-engine/internal/auth"
+	"ewallet-engine/internal/balance"
+	"ewallet-engine/internal/fraud"
+	"ewallet-engine/internal/kyc"
+	"ewallet-engine/internal/merchants"
+	"ewallet-engine/internal/transactions"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReferralRepository interface {
+	FindCodeByUser(userID uint) (*Code, error)
+	FindCode(code string) (*Code, error)
+	CreateCode(code *Code) error
+	FindUser(userID uint) (*auth.User, error)
+
+	CreateReferral(referral *Referral) error
+	FindReferral(id uint) (*Referral, error)
+	ListReferrals(status Status, referrerID uint, limit int) ([]Referral, error)
+	FindPending(limit int) ([]Referral, error)
+	// UpdateStatus mengubah referral yang masih berstatus from; false bila
+	// statusnya sudah diubah proses lain.
+	UpdateStatus(id uint, from Status, updates map[string]interface{}) (bool, error)
+	// CountRewarded menghitung referral yang memberi reward ke referrer sejak since.
+	CountRewarded(referrerID uint, since time.Time) (int64, error)
+	Stats(referrerID uint) (pending int64, rewarded int64, earned float64, err error)
+
+	// DevicesOf dan IPsOf mengumpulkan device/IP yang pernah dipakai user,
+	// dari event fraud dan dari signup-nya sendiri sebagai referee.
+	DevicesOf(userID uint) ([]string, error)
+	IPsOf(userID uint) ([]string, error)
+	DeviceReused(referrerID uint, deviceID string, excludeID uint) (bool, error)
+	ApprovedIDNumber(userID uint) (string, error)
+	// FirstQualifyingTransaction mencari PURCHASE atau TRANSFER SUCCESS pertama
+	// user sejak since. Transaksi tanpa penerima, ke referrer atau ke merchant
+	// milik referrer, serta transaksi yang sudah atau sedang di-refund tidak
+	// dihitung.
+	FirstQualifyingTransaction(userID uint, referrerID uint, since time.Time, currency string, minAmount float64) (*transactions.Transaction, error)
+
+	// Reward menandai referral REWARDED dan mengkredit kedua pihak dari wallet
+	// platform dalam satu transaksi database. Mengembalikan ErrAlreadyProcessed
+	// bila status referral bukan lagi from.
+	Reward(referral *Referral, from Status, updates map[string]interface{}) error
+}
+
+type referralRepository struct {
+	DB *gorm.DB
+}
+
+func NewReferralRepository(db *gorm.DB) ReferralRepository {
+	return &referralRepository{DB: db}
+}
+
+func (r *referralRepository) FindCodeByUser(userID uint) (*Code, error) {
+	var code Code
+	if err := r.DB.Where("user_id = ?", userID).First(&code).Error; err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+func (r *referralRepository) FindCode(value string) (*Code, error) {
+	var code Code
+	if err := r.DB.Where("code = ?", value).First(&code).Error; err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+func (r *referralRepository) CreateCode(code *Code) error {
+	return r.DB.Create(code).Error
+}
+
+func (r *referralRepository) FindUser(userID uint) (*auth.User, error) {
+	var user auth.User
+	if err := r.DB.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *referralRepository) CreateReferral(referral *Referral) error {
+	return r.DB.Create(referral).Error
+}
+
+func (r *referralRepository) FindReferral(id uint) (*Referral, error) {
+	var referral Referral
+	if err := r.DB.First(&referral, id).Error; err != nil {
+		return nil, err
+	}
+	return &referral, nil
+}
+
+func (r *referralRepository) ListReferrals(status Status, referrerID uint, limit int) ([]Referral, error) {
+	var referrals []Referral
+	query := r.DB.Order("id DESC").Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if referrerID != 0 {
+		query = query.Where("referrer_user_id = ?", referrerID)
+	}
+	err := query.Find(&referrals).Error
+	return referrals, err
+}
+
+func (r *referralRepository) FindPending(limit int) ([]Referral, error) {
+	var referrals []Referral
+	err := r.DB.Where("status = ?", StatusPending).Order("id ASC").Limit(limit).Find(&referrals).Error
+	return referrals, err
+}
+
+func (r *referralRepository) UpdateStatus(id uint, from Status, updates map[string]interface{}) (bool, error) {
+	result := r.DB.Model(&Referral{}).Where("id = ? AND status = ?", id, from).Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *referralRepository) CountRewarded(referrerID uint, since time.Time) (int64, error) {
+	var count int64
+	err := r.DB.Model(&Referral{}).
+		Where("referrer_user_id = ? AND status = ? AND referrer_reward > 0 AND rewarded_at >= ?", referrerID, StatusRewarded, since).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *referralRepository) Stats(referrerID uint) (int64, int64, float64, error) {
+	var rows []struct {
+		Status Status
+		Count  int64
+		Earned float64
+	}
+	err := r.DB.Model(&Referral{}).
+		Select("status, COUNT(*) AS count, COALESCE(SUM(referrer_reward), 0) AS earned").
+		Where("referrer_user_id = ?", referrerID).
+		Group("status").Scan(&rows).Error
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	var pending, rewarded int64
+	var earned float64
+	for _, row := range rows {
+		switch row.Status {
+		case StatusPending, StatusReview:
+			pending += row.Count
+		case StatusRewarded:
+			rewarded += row.Count
+			earned += row.Earned
+		}
+	}
+	return pending, rewarded, earned, nil
+}
+
+func (r *referralRepository) DevicesOf(userID uint) ([]string, error) {
+	return r.signupTrail(userID, "device_id", "signup_device_id")
+}
+
+func (r *referralRepository) IPsOf(userID uint) ([]string, error) {
+	return r.signupTrail(userID, "ip", "signup_ip")
+}
+
+func (r *referralRepository) signupTrail(userID uint, eventColumn string, signupColumn string) ([]string, error) {
+	var values []string
+	err := r.DB.Model(&fraud.FraudEvent{}).
+		Where("user_id = ? AND "+eventColumn+" <> ''", userID).
+		Distinct().Pluck(eventColumn, &values).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var signup []string
+	err = r.DB.Model(&Referral{}).
+		Where("referee_user_id = ? AND "+signupColumn+" <> ''", userID).
+		Pluck(signupColumn, &signup).Error
+	return append(values, signup...), err
+}
+
+func (r *referralRepository) DeviceReused(referrerID uint, deviceID string, excludeID uint) (bool, error) {
+	var count int64
+	err := r.DB.Model(&Referral{}).
+		Where("referrer_user_id = ? AND signup_device_id = ? AND id <> ?", referrerID, deviceID, excludeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *referralRepository) ApprovedIDNumber(userID uint) (string, error) {
+	var submission kyc.KYCSubmission
+	err := r.DB.Select("id", "id_number").
+		Where("user_id = ? AND status = ?", userID, kyc.StatusApproved).
+		Order("id DESC").First(&submission).Error
+	if err != nil {
+		return "", err
+	}
+	return submission.IDNumber, nil
+}
+
+func (r *referralRepository) FirstQualifyingTransaction(userID uint, referrerID uint, since time.Time, currency string, minAmount float64) (*transactions.Transaction, error) {
+	referrerMerchants := r.DB.Model(&merchants.Merchant{}).Select("settlement_user_id").Where("owner_user_id = ? AND settlement_user_id IS NOT NULL", referrerID)
+
+	var transaction transactions.Transaction
+	err := r.DB.
+		Where("user_id = ? AND transaction_status = ? AND currency = ? AND created_at >= ? AND amount >= ? AND refunded_amount = 0",
+			userID, transactions.StatusSuccess, currency, since, minAmount).
+		Where("transaction_type IN ?", []transactions.TransactionType{transactions.TransactionPurchase, transactions.TransactionTransfer}).
+		Where("counterparty_user_id <> 0 AND counterparty_user_id <> ?", referrerID).
+		Where("counterparty_user_id NOT IN (?)", referrerMerchants).
+		Where("NOT EXISTS (SELECT 1 FROM transactions refunds WHERE refunds.original_reference = transactions.reference AND refunds.transaction_type = ? AND refunds.transaction_status IN ?)",
+			transactions.TransactionRefund, []transactions.TransactionStatus{transactions.StatusPending, transactions.StatusSuccess}).
+		Order("id ASC").First(&transaction).Error
+	if err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+func (r *referralRepository) Reward(referral *Referral, from Status, updates map[string]interface{}) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&Referral{}).Where("id = ? AND status = ?", referral.ID, from).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrAlreadyProcessed
+		}
+
+		total := balance.RoundAmount(referral.ReferrerReward+referral.RefereeReward, referral.Currency)
+		if total <= 0 {
+			return nil
+		}
+
+		platform, err := balance.PlatformWallet(tx, referral.Currency)
+		if err != nil {
+			return err
+		}
+		walletIDs := []uint{platform.ID}
+		credits := map[uint]float64{}
+		for userID, amount := range map[uint]float64{referral.ReferrerUserID: referral.ReferrerReward, referral.RefereeUserID: referral.RefereeReward} {
+			if amount <= 0 {
+				continue
+			}
+			wallet, err := balance.FindUserWallet(tx, userID, referral.Currency, true)
+			if err != nil {
+				return err
+			}
+			walletIDs = append(walletIDs, wallet.ID)
+			credits[wallet.ID] = amount
+		}
+
+		// Wallet dikunci menurut id, sama seperti transfer.
+		var locked []balance.Wallet
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", walletIDs).Order("id ASC").Find(&locked).Error
+		if err != nil {
+			return err
+		}
+
+		reference := rewardReference(referral.ID)
+		if _, err := balance.ApplyWalletEntry(tx, platform.ID, "DEBIT", total, reference); err != nil {
+			return err
+		}
+		for _, wallet := range locked {
+			amount, ok := credits[wallet.ID]
+			if !ok {
+				continue
+			}
+			if _, err := balance.ApplyWalletEntry(tx, wallet.ID, "CREDIT", amount, reference); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,101 @@
+package referrals
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// codeAlphabet tanpa 0/O dan 1/I supaya kode mudah diketik ulang.
+const codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+const codeLength = 8
+
+func generateCode() (string, error) {
+	var b strings.Builder
+	max := big.NewInt(int64(len(codeAlphabet)))
+	for i := 0; i < codeLength; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(codeAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+// NormalizeCode menyeragamkan kode yang diketik user.
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// NormalizePhone mengubah nomor ke format lokal berawalan 0 supaya
+// 0812..., 62812... dan +62 812-... dianggap nomor yang sama.
+func NormalizePhone(phone string) string {
+	var digits strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	normalized := digits.String()
+	if strings.HasPrefix(normalized, "62") {
+		normalized = "0" + normalized[2:]
+	} else if normalized != "" && !strings.HasPrefix(normalized, "0") {
+		normalized = "0" + normalized
+	}
+	return normalized
+}
+
+// Assess memutuskan nasib referral dari sinyal self-referral. Kecocokan
+// nomor HP, NIK atau device dianggap orang yang sama dan ditolak; IP yang
+// sama atau device yang tidak diketahui hanya cukup untuk review manual.
+// Status kosong berarti referral boleh diberi reward.
+func Assess(signals Signals) (Status, string) {
+	var reject, review []string
+	if signals.SamePhone {
+		reject = append(reject, "nomor HP sama dengan referrer")
+	}
+	if signals.SameIDNumber {
+		reject = append(reject, "NIK sama dengan referrer")
+	}
+	if signals.SharedDevice {
+		reject = append(reject, "device pernah dipakai referrer")
+	}
+	if signals.DeviceReused {
+		reject = append(reject, "device dipakai referee lain dari referrer yang sama")
+	}
+	if signals.SharedIP {
+		review = append(review, "IP signup pernah dipakai referrer")
+	}
+	if signals.MissingDevice {
+		review = append(review, "device signup tidak diketahui")
+	}
+
+	switch {
+	case len(reject) > 0:
+		return StatusRejected, strings.Join(append(reject, review...), "; ")
+	case len(review) > 0:
+		return StatusReview, strings.Join(review, "; ")
+	default:
+		return "", ""
+	}
+}
+
+// ReferrerReward mengembalikan reward referrer setelah batas total dan
+// bulanan diterapkan. Batas 0 berarti tanpa batas. Referee tetap mendapat
+// reward walaupun referrer sudah mencapai batas.
+func ReferrerReward(reward float64, rewardedTotal, rewardedThisMonth, maxTotal, maxMonthly int64) float64 {
+	if maxTotal > 0 && rewardedTotal >= maxTotal {
+		return 0
+	}
+	if maxMonthly > 0 && rewardedThisMonth >= maxMonthly {
+		return 0
+	}
+	return reward
+}
+
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
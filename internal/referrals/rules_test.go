@@ -0,0 +1,90 @@
+package referrals
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	cases := map[string]string{
+		"081234567890":       "081234567890",
+		"6281234567890":      "081234567890",
+		"+62 812-3456-7890":  "081234567890",
+		"81234567890":        "081234567890",
+		"":                   "",
+		"(021) 555 1234":     "0215551234",
+		"+62 (812) 3456 789": "08123456789",
+	}
+	for in, want := range cases {
+		if got := NormalizePhone(in); got != want {
+			t.Errorf("NormalizePhone(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestAssess(t *testing.T) {
+	cases := []struct {
+		name    string
+		signals Signals
+		want    Status
+	}{
+		{"bersih", Signals{}, ""},
+		{"nomor HP sama", Signals{SamePhone: true}, StatusRejected},
+		{"NIK sama", Signals{SameIDNumber: true}, StatusRejected},
+		{"device referrer", Signals{SharedDevice: true}, StatusRejected},
+		{"device dipakai ulang", Signals{DeviceReused: true, SharedIP: true}, StatusRejected},
+		{"IP sama", Signals{SharedIP: true}, StatusReview},
+		{"tanpa device", Signals{MissingDevice: true}, StatusReview},
+	}
+	for _, tc := range cases {
+		status, reason := Assess(tc.signals)
+		if status != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, status, tc.want)
+		}
+		if (status == "") != (reason == "") {
+			t.Errorf("%s: alasan %q tidak sesuai status %q", tc.name, reason, status)
+		}
+	}
+
+	_, reason := Assess(Signals{SamePhone: true, SharedIP: true})
+	if !strings.Contains(reason, "nomor HP") || !strings.Contains(reason, "IP") {
+		t.Errorf("alasan harus memuat semua sinyal, got %q", reason)
+	}
+}
+
+func TestReferrerReward(t *testing.T) {
+	cases := []struct {
+		name            string
+		total, monthly  int64
+		maxTotal, maxMo int64
+		want            float64
+	}{
+		{"di bawah batas", 3, 1, 20, 5, 25000},
+		{"batas total", 20, 0, 20, 5, 0},
+		{"batas bulanan", 6, 5, 20, 5, 0},
+		{"tanpa batas", 100, 100, 0, 0, 25000},
+	}
+	for _, tc := range cases {
+		if got := ReferrerReward(25000, tc.total, tc.monthly, tc.maxTotal, tc.maxMo); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestGenerateCode(t *testing.T) {
+	code, err := generateCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(code) != codeLength {
+		t.Fatalf("panjang kode %d, want %d", len(code), codeLength)
+	}
+	for _, r := range code {
+		if !strings.ContainsRune(codeAlphabet, r) {
+			t.Fatalf("karakter %q di luar alfabet", r)
+		}
+	}
+	if NormalizeCode(" "+strings.ToLower(code)+" ") != code {
+		t.Fatal("NormalizeCode harus mengembalikan kode asli")
+	}
+}
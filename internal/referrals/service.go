@@ -0,0 +1,502 @@
+package referrals
+
+import (
+	"errors"
+	"ewallet-engine/internal/auth"
+	"ewallet-engine/internal/balance"
+	"ewallet-engine/internal/notifications"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	NotificationReferralReward = "REFERRAL_REWARD"
+
+	defaultReferrerReward  = 25000
+	defaultRefereeReward   = 10000
+	defaultMinTransaction  = 10000
+	defaultQualifyDays     = 30
+	defaultMaxPerReferrer  = 20
+	defaultMaxPerMonth     = 5
+	defaultListLimit       = 50
+	qualifyBatchSize       = 200
+	codeGenerationAttempts = 5
+)
+
+var (
+	ErrInvalidCode        = errors.New("kode referral tidak valid")
+	ErrReferralNotFound   = errors.New("referral tidak ditemukan")
+	ErrAlreadyProcessed   = errors.New("referral sudah diproses")
+	ErrFundingUnavailable = errors.New("reward referral sedang tidak tersedia")
+)
+
+// ReferralService juga memenuhi auth.ReferralRegistrar.
+type ReferralService interface {
+	CheckCode(code string) error
+	Register(user auth.User, signup auth.Signup) error
+	CodeFor(userID uint) (*Code, error)
+	Summary(userID uint, limit int) (*Summary, error)
+
+	// ProcessPending memeriksa referral PENDING yang sudah memenuhi syarat atau
+	// melewati masa kualifikasi dan mengembalikan jumlah yang diproses.
+	ProcessPending() (int, error)
+
+	ListReferrals(status Status, referrerID uint, limit int) ([]Referral, error)
+	Approve(id uint, reviewerID uint, note string) (*Referral, *Referral, error)
+	Reject(id uint, reviewerID uint, note string) (*Referral, *Referral, error)
+}
+
+type referralService struct {
+	repo        ReferralRepository
+	creditGuard balance.CreditGuard
+	notifier    notifications.Notifier
+
+	referrerReward float64
+	refereeReward  float64
+	minTransaction float64
+	qualifyWindow  time.Duration
+	maxPerReferrer int64
+	maxPerMonth    int64
+}
+
+func NewReferralService(repo ReferralRepository, creditGuard balance.CreditGuard, notifier notifications.Notifier) ReferralService {
+	s := &referralService{
+		repo:           repo,
+		creditGuard:    creditGuard,
+		notifier:       notifier,
+		referrerReward: defaultReferrerReward,
+		refereeReward:  defaultRefereeReward,
+		minTransaction: defaultMinTransaction,
+		qualifyWindow:  defaultQualifyDays * 24 * time.Hour,
+		maxPerReferrer: defaultMaxPerReferrer,
+		maxPerMonth:    defaultMaxPerMonth,
+	}
+	if amount, err := strconv.ParseFloat(os.Getenv("REFERRAL_REFERRER_REWARD"), 64); err == nil && amount >= 0 {
+		s.referrerReward = amount
+	}
+	if amount, err := strconv.ParseFloat(os.Getenv("REFERRAL_REFEREE_REWARD"), 64); err == nil && amount >= 0 {
+		s.refereeReward = amount
+	}
+	if amount, err := strconv.ParseFloat(os.Getenv("REFERRAL_MIN_TRANSACTION"), 64); err == nil && amount > 0 {
+		s.minTransaction = amount
+	}
+	if days, err := strconv.Atoi(os.Getenv("REFERRAL_QUALIFY_DAYS")); err == nil && days > 0 {
+		s.qualifyWindow = time.Duration(days) * 24 * time.Hour
+	}
+	// Batas 0 berarti tanpa batas, jadi nilai 0 dari env tetap dipakai.
+	if max, err := strconv.ParseInt(os.Getenv("REFERRAL_MAX_REWARDS_PER_REFERRER"), 10, 64); err == nil && max >= 0 {
+		s.maxPerReferrer = max
+	}
+	if max, err := strconv.ParseInt(os.Getenv("REFERRAL_MAX_REWARDS_PER_MONTH"), 10, 64); err == nil && max >= 0 {
+		s.maxPerMonth = max
+	}
+	return s
+}
+
+// CheckCode dipanggil sebelum user dibuat supaya kode yang salah menolak registrasi.
+func (s *referralService) CheckCode(code string) error {
+	if _, err := s.repo.FindCode(NormalizeCode(code)); err != nil {
+		return ErrInvalidCode
+	}
+	return nil
+}
+
+// Register membuat kode referral user baru dan, bila mendaftar dengan kode
+// orang lain, mencatat referral-nya. Self-referral yang sudah terlihat saat
+// signup langsung ditolak; sisanya diperiksa lagi saat kualifikasi.
+func (s *referralService) Register(user auth.User, signup auth.Signup) error {
+	if _, err := s.CodeFor(user.ID); err != nil {
+		return err
+	}
+	if signup.ReferralCode == "" {
+		return nil
+	}
+
+	code, err := s.repo.FindCode(NormalizeCode(signup.ReferralCode))
+	if err != nil {
+		return ErrInvalidCode
+	}
+	if code.UserID == user.ID {
+		return nil
+	}
+
+	referral := &Referral{
+		ReferrerUserID: code.UserID,
+		RefereeUserID:  user.ID,
+		Code:           code.Code,
+		Status:         StatusPending,
+		SignupDeviceID: strings.TrimSpace(signup.DeviceID),
+		SignupIP:       signup.IP,
+		Currency:       balance.DefaultCurrency,
+	}
+
+	signals, err := s.signals(referral, &user)
+	if err != nil {
+		return err
+	}
+	// Saat signup referee belum punya riwayat, jadi hanya penolakan yang
+	// diputuskan di sini; sinyal review dinilai ulang saat kualifikasi.
+	if status, reason := Assess(signals); status == StatusRejected {
+		referral.Status = StatusRejected
+		referral.Reason = reason
+		log.Printf("ALERT: Referral user_id %d oleh user_id %d ditolak saat signup: %s", user.ID, code.UserID, reason)
+	}
+	return s.repo.CreateReferral(referral)
+}
+
+// CodeFor mengembalikan kode referral user dan membuatnya bila belum ada.
+func (s *referralService) CodeFor(userID uint) (*Code, error) {
+	if code, err := s.repo.FindCodeByUser(userID); err == nil {
+		return code, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < codeGenerationAttempts; attempt++ {
+		value, err := generateCode()
+		if err != nil {
+			return nil, err
+		}
+		code := &Code{UserID: userID, Code: value}
+		lastErr = s.repo.CreateCode(code)
+		if lastErr == nil {
+			return code, nil
+		}
+		if !isDuplicate(lastErr) {
+			return nil, lastErr
+		}
+		// Duplikat bisa berasal dari user_id yang dibuat request lain bersamaan.
+		if existing, err := s.repo.FindCodeByUser(userID); err == nil {
+			return existing, nil
+		}
+	}
+	return nil, fmt.Errorf("gagal membuat kode referral: %w", lastErr)
+}
+
+func (s *referralService) Summary(userID uint, limit int) (*Summary, error) {
+	if limit <= 0 || limit > defaultListLimit {
+		limit = defaultListLimit
+	}
+
+	code, err := s.CodeFor(userID)
+	if err != nil {
+		return nil, err
+	}
+	pending, rewarded, earned, err := s.repo.Stats(userID)
+	if err != nil {
+		return nil, err
+	}
+	referrals, err := s.repo.ListReferrals("", userID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &Summary{
+		Code:           code.Code,
+		ReferrerReward: s.referrerReward,
+		RefereeReward:  s.refereeReward,
+		Currency:       balance.DefaultCurrency,
+		Pending:        pending,
+		Rewarded:       rewarded,
+		Earned:         earned,
+		Referrals:      make([]View, 0, len(referrals)),
+	}
+	for _, referral := range referrals {
+		summary.Referrals = append(summary.Referrals, referral.View())
+	}
+	return summary, nil
+}
+
+func (s *referralService) ProcessPending() (int, error) {
+	referrals, err := s.repo.FindPending(qualifyBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	processed := 0
+	for i := range referrals {
+		done, err := s.qualify(&referrals[i], now)
+		if err != nil {
+			log.Printf("ERROR: Gagal memproses referral %d: %v", referrals[i].ID, err)
+			continue
+		}
+		if done {
+			processed++
+		}
+	}
+	return processed, nil
+}
+
+// qualify memberi reward bila referee sudah VERIFIED dan punya transaksi
+// pertama yang memenuhi syarat. done bernilai false bila referral masih
+// menunggu.
+func (s *referralService) qualify(referral *Referral, now time.Time) (bool, error) {
+	if now.After(referral.CreatedAt.Add(s.qualifyWindow)) {
+		_, err := s.repo.UpdateStatus(referral.ID, StatusPending, map[string]interface{}{"status": StatusExpired})
+		return err == nil, err
+	}
+
+	referee, err := s.repo.FindUser(referral.RefereeUserID)
+	if err != nil {
+		return false, err
+	}
+	if referee.KYCTier != auth.KYCTierVerified {
+		return false, nil
+	}
+	transaction, err := s.repo.FirstQualifyingTransaction(referral.RefereeUserID, referral.ReferrerUserID, referral.CreatedAt, referral.Currency, s.minTransaction)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	referral.QualifyingReference = transaction.Reference
+	referral.QualifiedAt = &now
+	updates := map[string]interface{}{
+		"qualifying_reference": referral.QualifyingReference,
+		"qualified_at":         now,
+	}
+
+	signals, err := s.signals(referral, referee)
+	if err != nil {
+		return false, err
+	}
+	status, reason := Assess(signals)
+	if status == "" {
+		if err := s.checkCredit(referral); err != nil {
+			status, reason = StatusReview, err.Error()
+		}
+	}
+	if status != "" {
+		updates["status"] = status
+		updates["reason"] = truncate(reason)
+		if _, err := s.repo.UpdateStatus(referral.ID, StatusPending, updates); err != nil {
+			return false, err
+		}
+		log.Printf("ALERT: Referral %d masuk %s: %s", referral.ID, status, reason)
+		return true, nil
+	}
+
+	if err := s.reward(referral, StatusPending, nil, updates); err != nil {
+		if errors.Is(err, ErrAlreadyProcessed) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// signals mengumpulkan sinyal self-referral antara referee dan referrer.
+func (s *referralService) signals(referral *Referral, referee *auth.User) (Signals, error) {
+	referrer, err := s.repo.FindUser(referral.ReferrerUserID)
+	if err != nil {
+		return Signals{}, err
+	}
+
+	signals := Signals{MissingDevice: referral.SignupDeviceID == ""}
+	phone := NormalizePhone(referee.PhoneNumber)
+	signals.SamePhone = phone != "" && phone == NormalizePhone(referrer.PhoneNumber)
+
+	refereeID, err := s.repo.ApprovedIDNumber(referee.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return Signals{}, err
+	}
+	if refereeID != "" {
+		referrerID, err := s.repo.ApprovedIDNumber(referrer.ID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return Signals{}, err
+		}
+		signals.SameIDNumber = refereeID == referrerID
+	}
+
+	referrerDevices, err := s.repo.DevicesOf(referrer.ID)
+	if err != nil {
+		return Signals{}, err
+	}
+	refereeDevices := []string{referral.SignupDeviceID}
+	if referral.ID != 0 {
+		if refereeDevices, err = s.repo.DevicesOf(referee.ID); err != nil {
+			return Signals{}, err
+		}
+	}
+	signals.SharedDevice = overlaps(refereeDevices, referrerDevices)
+
+	if referral.SignupDeviceID != "" {
+		if signals.DeviceReused, err = s.repo.DeviceReused(referrer.ID, referral.SignupDeviceID, referral.ID); err != nil {
+			return Signals{}, err
+		}
+	}
+	if referral.SignupIP != "" {
+		referrerIPs, err := s.repo.IPsOf(referrer.ID)
+		if err != nil {
+			return Signals{}, err
+		}
+		signals.SharedIP = overlaps([]string{referral.SignupIP}, referrerIPs)
+	}
+	return signals, nil
+}
+
+// checkCredit memastikan reward tidak membuat saldo melewati batas tier.
+func (s *referralService) checkCredit(referral *Referral) error {
+	if s.refereeReward > 0 {
+		if err := s.creditGuard.CheckCredit(referral.RefereeUserID, s.refereeReward); err != nil {
+			return fmt.Errorf("reward referee: %w", err)
+		}
+	}
+	if s.referrerReward > 0 {
+		if err := s.creditGuard.CheckCredit(referral.ReferrerUserID, s.referrerReward); err != nil {
+			return fmt.Errorf("reward referrer: %w", err)
+		}
+	}
+	return nil
+}
+
+// reward membayar kedua pihak dari wallet pendapatan platform. Reward
+// referrer menjadi 0 bila batas total atau bulanannya sudah tercapai.
+func (s *referralService) reward(referral *Referral, from Status, reviewerID *uint, updates map[string]interface{}) error {
+	now := time.Now()
+	total, err := s.repo.CountRewarded(referral.ReferrerUserID, time.Time{})
+	if err != nil {
+		return err
+	}
+	monthly, err := s.repo.CountRewarded(referral.ReferrerUserID, startOfMonth(now))
+	if err != nil {
+		return err
+	}
+
+	referral.ReferrerReward = ReferrerReward(s.referrerReward, total, monthly, s.maxPerReferrer, s.maxPerMonth)
+	referral.RefereeReward = s.refereeReward
+	referral.Status = StatusRewarded
+	referral.RewardedAt = &now
+	referral.ReviewerID = reviewerID
+
+	updates["status"] = StatusRewarded
+	updates["referrer_reward"] = referral.ReferrerReward
+	updates["referee_reward"] = referral.RefereeReward
+	updates["rewarded_at"] = now
+	if reviewerID != nil {
+		updates["reviewer_id"] = *reviewerID
+	}
+
+	if err := s.repo.Reward(referral, from, updates); err != nil {
+		if errors.Is(err, balance.ErrInsufficientBalance) {
+			log.Printf("ALERT: Wallet platform %s tidak mencukupi untuk reward referral %d", referral.Currency, referral.ID)
+			return ErrFundingUnavailable
+		}
+		return err
+	}
+
+	if referral.RefereeReward > 0 {
+		s.notifier.Notify(referral.RefereeUserID, NotificationReferralReward, "Reward referral diterima",
+			fmt.Sprintf("Anda mendapat reward referral %s %.2f.", referral.Currency, referral.RefereeReward),
+			notifications.Data{"referral_id": referral.ID, "amount": referral.RefereeReward})
+	}
+	if referral.ReferrerReward > 0 {
+		s.notifier.Notify(referral.ReferrerUserID, NotificationReferralReward, "Reward referral diterima",
+			fmt.Sprintf("Teman yang Anda ajak sudah bertransaksi. Anda mendapat reward %s %.2f.", referral.Currency, referral.ReferrerReward),
+			notifications.Data{"referral_id": referral.ID, "amount": referral.ReferrerReward})
+	}
+	return nil
+}
+
+func (s *referralService) ListReferrals(status Status, referrerID uint, limit int) ([]Referral, error) {
+	if limit <= 0 || limit > defaultListLimit {
+		limit = defaultListLimit
+	}
+	return s.repo.ListReferrals(status, referrerID, limit)
+}
+
+// Approve memberi reward untuk referral REVIEW setelah diperiksa operator.
+func (s *referralService) Approve(id uint, reviewerID uint, note string) (*Referral, *Referral, error) {
+	referral, err := s.repo.FindReferral(id)
+	if err != nil {
+		return nil, nil, ErrReferralNotFound
+	}
+	if referral.Status != StatusReview {
+		return nil, nil, ErrAlreadyProcessed
+	}
+	before := *referral
+
+	if err := s.checkCredit(referral); err != nil {
+		return nil, nil, err
+	}
+	updates := map[string]interface{}{}
+	if note = strings.TrimSpace(note); note != "" {
+		referral.Reason = truncate(referral.Reason + "; disetujui: " + note)
+		updates["reason"] = referral.Reason
+	}
+	if err := s.reward(referral, StatusReview, &reviewerID, updates); err != nil {
+		return nil, nil, err
+	}
+	return &before, referral, nil
+}
+
+// Reject menolak referral PENDING atau REVIEW tanpa reward.
+func (s *referralService) Reject(id uint, reviewerID uint, note string) (*Referral, *Referral, error) {
+	if note = strings.TrimSpace(note); note == "" {
+		return nil, nil, errors.New("catatan penolakan wajib diisi")
+	}
+	referral, err := s.repo.FindReferral(id)
+	if err != nil {
+		return nil, nil, ErrReferralNotFound
+	}
+	if referral.Status != StatusPending && referral.Status != StatusReview {
+		return nil, nil, ErrAlreadyProcessed
+	}
+	before := *referral
+
+	referral.Status = StatusRejected
+	referral.Reason = truncate(strings.TrimPrefix(referral.Reason+"; ditolak: "+note, "; "))
+	referral.ReviewerID = &reviewerID
+	ok, err := s.repo.UpdateStatus(referral.ID, before.Status, map[string]interface{}{
+		"status":      referral.Status,
+		"reason":      referral.Reason,
+		"reviewer_id": reviewerID,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		return nil, nil, ErrAlreadyProcessed
+	}
+	return &before, referral, nil
+}
+
+func overlaps(a []string, b []string) bool {
+	seen := make(map[string]bool, len(b))
+	for _, value := range b {
+		if value != "" {
+			seen[value] = true
+		}
+	}
+	for _, value := range a {
+		if seen[value] {
+			return true
+		}
+	}
+	return false
+}
+
+func truncate(reason string) string {
+	if len(reason) > 255 {
+		return reason[:255]
+	}
+	return reason
+}
+
+func isDuplicate(err error) bool {
+	return errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "Duplicate entry")
+}
+
+func rewardReference(referralID uint) string {
+	return fmt.Sprintf("REFERRAL-%d", referralID)
+}
@@ -0,0 +1,131 @@
+package schedules
+
+import (
+	"errors"
+	"ewallet-engine/internal/audit"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ScheduleHandler struct {
+	service      ScheduleService
+	auditService audit.AuditService
+}
+
+func NewScheduleHandler(service ScheduleService, auditService audit.AuditService) *ScheduleHandler {
+	return &ScheduleHandler{service: service, auditService: auditService}
+}
+
+func (h *ScheduleHandler) CreateHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	var request ScheduleRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	schedule, err := h.service.Create(userID, request)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionScheduleCreated,
+		TargetType: "schedule",
+		TargetID:   fmt.Sprint(schedule.ID),
+		After:      scheduleSnapshot(schedule),
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Jadwal transfer berhasil dibuat",
+		"data":    schedule,
+	})
+}
+
+func (h *ScheduleHandler) ListHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	schedules, err := h.service.List(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": schedules})
+}
+
+func (h *ScheduleHandler) GetHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	schedule, runs, err := h.service.Get(userID, uint(id))
+	if err != nil {
+		return h.scheduleError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": fiber.Map{"schedule": schedule, "runs": runs}})
+}
+
+func (h *ScheduleHandler) PauseHandler(c *fiber.Ctx) error {
+	return h.changeStatus(c, h.service.Pause, "Jadwal transfer dijeda")
+}
+
+func (h *ScheduleHandler) ResumeHandler(c *fiber.Ctx) error {
+	return h.changeStatus(c, h.service.Resume, "Jadwal transfer diaktifkan kembali")
+}
+
+func (h *ScheduleHandler) CancelHandler(c *fiber.Ctx) error {
+	return h.changeStatus(c, h.service.Cancel, "Jadwal transfer dibatalkan")
+}
+
+func (h *ScheduleHandler) changeStatus(c *fiber.Ctx, action func(userID uint, id uint) (*Schedule, error), message string) error {
+	userID := c.Locals("user_id").(uint)
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	schedule, err := action(userID, uint(id))
+	if err != nil {
+		return h.scheduleError(c, err)
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionScheduleStatusChanged,
+		TargetType: "schedule",
+		TargetID:   fmt.Sprint(schedule.ID),
+		After:      scheduleSnapshot(schedule),
+	})
+
+	return c.JSON(fiber.Map{"message": message, "data": schedule})
+}
+
+func (h *ScheduleHandler) scheduleError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, ErrScheduleNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, ErrScheduleState):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"message": err.Error()})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+}
+
+func scheduleSnapshot(schedule *Schedule) audit.Snapshot {
+	return audit.Snapshot{
+		"status":            schedule.Status,
+		"recipient_user_id": schedule.RecipientUserID,
+		"amount":            schedule.Amount,
+		"currency":          schedule.Currency,
+		"frequency":         schedule.Frequency,
+		"cron_expr":         schedule.CronExpr,
+		"next_run_at":       schedule.NextRunAt,
+	}
+}
@@ -0,0 +1,51 @@
+package schedules
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Locker memastikan hanya satu instance yang menjalankan scheduler pada satu waktu.
+type Locker interface {
+	// TryLock mengembalikan ok=false jika lock sedang dipegang instance lain.
+	TryLock(ctx context.Context, key string, ttl time.Duration) (unlock func(), ok bool, err error)
+}
+
+// releaseLock hanya menghapus lock jika token-nya masih milik pemanggil,
+// sehingga lock yang sudah kedaluwarsa dan diambil instance lain tidak ikut terhapus.
+var releaseLock = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+type RedisLocker struct {
+	client *redis.Client
+}
+
+func NewRedisLocker(client *redis.Client) *RedisLocker {
+	return &RedisLocker{client: client}
+}
+
+func (l *RedisLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, false, err
+	}
+	token := hex.EncodeToString(buf)
+
+	ok, err := l.client.SetNX(ctx, key, token, ttl).Result()
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	unlock := func() {
+		_ = releaseLock.Run(context.Background(), l.client, []string{key}, token).Err()
+	}
+	return unlock, true, nil
+}
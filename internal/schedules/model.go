@@ -0,0 +1,79 @@
+package schedules
+
+import "time"
+
+type ScheduleStatus string
+
+const (
+	StatusActive    ScheduleStatus = "ACTIVE"
+	StatusPaused    ScheduleStatus = "PAUSED"
+	StatusCompleted ScheduleStatus = "COMPLETED"
+	StatusCancelled ScheduleStatus = "CANCELLED"
+)
+
+// Schedule adalah instruksi transfer berulang milik user. NextRunAt adalah
+// waktu occurrence yang sedang ditunggu, sedangkan DueAt adalah kapan worker
+// boleh mengeksekusinya (lebih lambat dari NextRunAt saat sedang retry).
+type Schedule struct {
+	ID                  uint           `gorm:"primaryKey" json:"id"`
+	UserID              uint           `gorm:"not null;index" json:"user_id"`
+	RecipientUserID     uint           `gorm:"not null" json:"recipient_user_id"`
+	Amount              float64        `gorm:"not null" json:"amount"`
+	Currency            string         `gorm:"type:char(3);not null;default:'IDR'" json:"currency"`
+	Description         string         `gorm:"type:varchar(255)" json:"description,omitempty"`
+	Frequency           Frequency      `gorm:"type:varchar(10);not null" json:"frequency"`
+	CronExpr            string         `gorm:"type:varchar(100)" json:"cron_expr,omitempty"`
+	StartAt             time.Time      `gorm:"not null" json:"start_at"`
+	EndAt               *time.Time     `json:"end_at,omitempty"`
+	Status              ScheduleStatus `gorm:"type:enum('ACTIVE','PAUSED','COMPLETED','CANCELLED');default:'ACTIVE';index:idx_schedule_due" json:"status"`
+	NextRunAt           *time.Time     `json:"next_run_at,omitempty"`
+	DueAt               *time.Time     `gorm:"index:idx_schedule_due" json:"-"`
+	Attempt             int            `gorm:"not null;default:0" json:"attempt"`
+	RunCount            int            `gorm:"not null;default:0" json:"run_count"`
+	ConsecutiveFailures int            `gorm:"not null;default:0" json:"consecutive_failures"`
+	LastRunAt           *time.Time     `json:"last_run_at,omitempty"`
+	CreatedAt           time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt           time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// Recurrence mengembalikan aturan pengulangan jadwal.
+func (s Schedule) Recurrence() Recurrence {
+	return Recurrence{Frequency: s.Frequency, CronExpr: s.CronExpr, Anchor: s.StartAt}
+}
+
+type RunStatus string
+
+const (
+	RunSuccess RunStatus = "SUCCESS"
+	// RunPending: transfer dibuat tetapi ditahan untuk review fraud.
+	RunPending RunStatus = "PENDING"
+	// RunRetrying: saldo kurang, occurrence yang sama akan dicoba lagi.
+	RunRetrying RunStatus = "RETRYING"
+	RunFailed   RunStatus = "FAILED"
+)
+
+// Run adalah satu percobaan eksekusi sebuah jadwal.
+type Run struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	ScheduleID   uint      `gorm:"not null;index" json:"schedule_id"`
+	ScheduledFor time.Time `gorm:"not null" json:"scheduled_for"`
+	Attempt      int       `gorm:"not null" json:"attempt"`
+	Reference    string    `gorm:"type:varchar(100);not null;index" json:"reference"`
+	Status       RunStatus `gorm:"type:enum('SUCCESS','PENDING','RETRYING','FAILED');not null" json:"status"`
+	Error        string    `gorm:"type:varchar(255)" json:"error,omitempty"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// ScheduleRequest adalah input pembuatan jadwal.
+type ScheduleRequest struct {
+	RecipientUserID   uint       `json:"recipient_user_id"`
+	RecipientPhone    string     `json:"recipient_phone"`
+	RecipientUsername string     `json:"recipient_username"`
+	Amount            float64    `json:"amount"`
+	Currency          string     `json:"currency"`
+	Description       string     `json:"description"`
+	Frequency         Frequency  `json:"frequency"`
+	CronExpr          string     `json:"cron_expr"`
+	StartAt           time.Time  `json:"start_at"`
+	EndAt             *time.Time `json:"end_at"`
+}
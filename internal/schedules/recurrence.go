@@ -0,0 +1,259 @@
+package schedules
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	FrequencyDaily   Frequency = "DAILY"
+	FrequencyWeekly  Frequency = "WEEKLY"
+	FrequencyMonthly Frequency = "MONTHLY"
+	// FrequencyCron memakai ekspresi cron 5 kolom: menit jam tanggal bulan hari.
+	FrequencyCron Frequency = "CRON"
+)
+
+// minInterval adalah jarak minimal antar eksekusi yang diizinkan.
+const minInterval = time.Hour
+
+// cronSearchLimit membatasi pencarian eksekusi berikutnya untuk ekspresi yang
+// tidak pernah cocok, misalnya tanggal 31 Februari.
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+var ErrNoNextRun = errors.New("jadwal tidak memiliki eksekusi berikutnya")
+
+// Recurrence menentukan kapan sebuah jadwal berjalan. Untuk DAILY, WEEKLY dan
+// MONTHLY, jam dan tanggal eksekusi mengikuti Anchor (waktu mulai jadwal);
+// jadwal bulanan pada tanggal 29-31 jatuh di hari terakhir bulan yang lebih pendek.
+type Recurrence struct {
+	Frequency Frequency
+	CronExpr  string
+	Anchor    time.Time
+}
+
+// First mengembalikan eksekusi pertama pada atau setelah Anchor.
+func (r Recurrence) First() (time.Time, error) {
+	if r.Frequency == FrequencyCron {
+		return r.Next(r.Anchor.Add(-time.Minute))
+	}
+	if err := r.Validate(); err != nil {
+		return time.Time{}, err
+	}
+	return r.Anchor, nil
+}
+
+// Next mengembalikan eksekusi pertama yang lebih besar dari after.
+func (r Recurrence) Next(after time.Time) (time.Time, error) {
+	switch r.Frequency {
+	case FrequencyDaily:
+		return r.nextByStep(after, func(k int) time.Time { return r.Anchor.AddDate(0, 0, k) }, 24*time.Hour), nil
+	case FrequencyWeekly:
+		return r.nextByStep(after, func(k int) time.Time { return r.Anchor.AddDate(0, 0, 7*k) }, 7*24*time.Hour), nil
+	case FrequencyMonthly:
+		return r.nextByStep(after, r.monthly, 28*24*time.Hour), nil
+	case FrequencyCron:
+		spec, err := ParseCron(r.CronExpr)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if after.Before(r.Anchor) {
+			after = r.Anchor.Add(-time.Minute)
+		}
+		return spec.Next(after)
+	}
+	return time.Time{}, fmt.Errorf("frekuensi %q tidak didukung", r.Frequency)
+}
+
+// Validate memeriksa frekuensi dan ekspresi cron, termasuk jarak minimal
+// antar eksekusi.
+func (r Recurrence) Validate() error {
+	switch r.Frequency {
+	case FrequencyDaily, FrequencyWeekly, FrequencyMonthly:
+		return nil
+	case FrequencyCron:
+		spec, err := ParseCron(r.CronExpr)
+		if err != nil {
+			return err
+		}
+		previous, err := spec.Next(r.Anchor.Add(-time.Minute))
+		if err != nil {
+			return err
+		}
+		for i := 0; i < 24; i++ {
+			next, err := spec.Next(previous)
+			if err != nil {
+				return nil
+			}
+			if next.Sub(previous) < minInterval {
+				return fmt.Errorf("jadwal cron tidak boleh berjalan lebih sering dari setiap %s", minInterval)
+			}
+			previous = next
+		}
+		return nil
+	}
+	return fmt.Errorf("frekuensi %q tidak didukung", r.Frequency)
+}
+
+// nextByStep mencari occurrence(k) pertama yang lebih besar dari after.
+// approx dipakai untuk melompati occurrence yang sudah lewat.
+func (r Recurrence) nextByStep(after time.Time, occurrence func(k int) time.Time, approx time.Duration) time.Time {
+	k := 0
+	if after.After(r.Anchor) {
+		k = int(after.Sub(r.Anchor)/approx) - 1
+		if k < 0 {
+			k = 0
+		}
+	}
+	for {
+		candidate := occurrence(k)
+		if candidate.After(after) {
+			return candidate
+		}
+		k++
+	}
+}
+
+func (r Recurrence) monthly(k int) time.Time {
+	anchor := r.Anchor
+	firstOfMonth := time.Date(anchor.Year(), anchor.Month()+time.Month(k), 1, anchor.Hour(), anchor.Minute(), anchor.Second(), 0, anchor.Location())
+	day := anchor.Day()
+	if last := firstOfMonth.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return firstOfMonth.AddDate(0, 0, day-1)
+}
+
+// CronSpec adalah ekspresi cron 5 kolom yang sudah di-parse. Setiap kolom
+// mendukung *, angka, rentang a-b, daftar a,b dan langkah */n atau a-b/n.
+type CronSpec struct {
+	minutes  [60]bool
+	hours    [24]bool
+	days     [32]bool
+	months   [13]bool
+	weekdays [7]bool
+	// anyDay/anyWeekday menandai kolom bernilai *. Sesuai cron standar, jika
+	// keduanya dibatasi maka tanggal cukup cocok dengan salah satunya.
+	anyDay     bool
+	anyWeekday bool
+}
+
+func ParseCron(expr string) (*CronSpec, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, errors.New("ekspresi cron harus terdiri dari 5 kolom: menit jam tanggal bulan hari")
+	}
+
+	spec := &CronSpec{anyDay: fields[2] == "*", anyWeekday: fields[4] == "*"}
+	if err := parseCronField(fields[0], 0, 59, spec.minutes[:]); err != nil {
+		return nil, fmt.Errorf("kolom menit: %w", err)
+	}
+	if err := parseCronField(fields[1], 0, 23, spec.hours[:]); err != nil {
+		return nil, fmt.Errorf("kolom jam: %w", err)
+	}
+	if err := parseCronField(fields[2], 1, 31, spec.days[:]); err != nil {
+		return nil, fmt.Errorf("kolom tanggal: %w", err)
+	}
+	if err := parseCronField(fields[3], 1, 12, spec.months[:]); err != nil {
+		return nil, fmt.Errorf("kolom bulan: %w", err)
+	}
+
+	var weekdays [8]bool
+	if err := parseCronField(fields[4], 0, 7, weekdays[:]); err != nil {
+		return nil, fmt.Errorf("kolom hari: %w", err)
+	}
+	copy(spec.weekdays[:], weekdays[:7])
+	if weekdays[7] {
+		spec.weekdays[0] = true
+	}
+	return spec, nil
+}
+
+func parseCronField(field string, min int, max int, set []bool) error {
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("langkah %q tidak valid", part)
+			}
+			step = n
+			part = part[:i]
+		}
+
+		low, high := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			a, errA := strconv.Atoi(bounds[0])
+			b, errB := strconv.Atoi(bounds[1])
+			if errA != nil || errB != nil || a > b {
+				return fmt.Errorf("rentang %q tidak valid", part)
+			}
+			low, high = a, b
+		default:
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return fmt.Errorf("nilai %q tidak valid", part)
+			}
+			low, high = n, n
+			if step > 1 {
+				high = max
+			}
+		}
+
+		if low < min || high > max {
+			return fmt.Errorf("nilai harus di antara %d dan %d", min, max)
+		}
+		for v := low; v <= high; v += step {
+			set[v] = true
+		}
+	}
+	return nil
+}
+
+// Next mengembalikan waktu pertama yang cocok setelah after, dengan resolusi menit.
+func (c *CronSpec) Next(after time.Time) (time.Time, error) {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(cronSearchLimit)
+
+	for t.Before(limit) {
+		if !c.months[t.Month()] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.hours[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.minutes[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t, nil
+	}
+	return time.Time{}, ErrNoNextRun
+}
+
+func (c *CronSpec) dayMatches(t time.Time) bool {
+	dayOK := c.days[t.Day()]
+	weekdayOK := c.weekdays[t.Weekday()]
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekdayOK
+	case c.anyWeekday:
+		return dayOK
+	}
+	return dayOK || weekdayOK
+}
@@ -0,0 +1,93 @@
+package schedules
+
+import (
+	"testing"
+	"time"
+)
+
+var jakarta = time.FixedZone("WIB", 7*3600)
+
+func at(year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, jakarta)
+}
+
+func TestMonthlyClampsToLastDay(t *testing.T) {
+	r := Recurrence{Frequency: FrequencyMonthly, Anchor: at(2025, time.January, 31, 9, 0)}
+
+	want := []time.Time{
+		at(2025, time.February, 28, 9, 0),
+		at(2025, time.March, 31, 9, 0),
+		at(2025, time.April, 30, 9, 0),
+	}
+	current := r.Anchor
+	for _, expected := range want {
+		next, err := r.Next(current)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !next.Equal(expected) {
+			t.Fatalf("Next(%s) = %s, want %s", current, next, expected)
+		}
+		current = next
+	}
+}
+
+func TestDailyAndWeeklySkipPastOccurrences(t *testing.T) {
+	daily := Recurrence{Frequency: FrequencyDaily, Anchor: at(2025, time.March, 1, 7, 30)}
+	next, _ := daily.Next(at(2025, time.March, 10, 8, 0))
+	if !next.Equal(at(2025, time.March, 11, 7, 30)) {
+		t.Fatalf("daily next = %s", next)
+	}
+
+	weekly := Recurrence{Frequency: FrequencyWeekly, Anchor: at(2025, time.March, 3, 7, 30)}
+	next, _ = weekly.Next(at(2025, time.March, 3, 7, 30))
+	if !next.Equal(at(2025, time.March, 10, 7, 30)) {
+		t.Fatalf("weekly next = %s", next)
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	cases := []struct {
+		expr  string
+		after time.Time
+		want  time.Time
+	}{
+		{"0 9 * * 1-5", at(2025, time.March, 7, 10, 0), at(2025, time.March, 10, 9, 0)},
+		{"30 8 1,15 * *", at(2025, time.March, 2, 0, 0), at(2025, time.March, 15, 8, 30)},
+		{"0 */6 * * *", at(2025, time.March, 2, 6, 0), at(2025, time.March, 2, 12, 0)},
+		{"0 0 * * 7", at(2025, time.March, 3, 0, 0), at(2025, time.March, 9, 0, 0)},
+		{"0 12 29 2 *", at(2025, time.March, 1, 0, 0), at(2028, time.February, 29, 12, 0)},
+	}
+
+	for _, tc := range cases {
+		spec, err := ParseCron(tc.expr)
+		if err != nil {
+			t.Fatalf("%q: %v", tc.expr, err)
+		}
+		next, err := spec.Next(tc.after)
+		if err != nil {
+			t.Fatalf("%q: %v", tc.expr, err)
+		}
+		if !next.Equal(tc.want) {
+			t.Fatalf("%q: Next = %s, want %s", tc.expr, next, tc.want)
+		}
+	}
+}
+
+func TestCronValidation(t *testing.T) {
+	for _, expr := range []string{"* * * *", "60 * * * *", "0 9 * * 8", "0 5-1 * * *", "0 */0 * * *"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Fatalf("%q harus ditolak", expr)
+		}
+	}
+
+	tooOften := Recurrence{Frequency: FrequencyCron, CronExpr: "*/15 * * * *", Anchor: at(2025, time.March, 1, 0, 0)}
+	if err := tooOften.Validate(); err == nil {
+		t.Fatal("cron setiap 15 menit harus ditolak")
+	}
+
+	never := Recurrence{Frequency: FrequencyCron, CronExpr: "0 0 31 2 *", Anchor: at(2025, time.March, 1, 0, 0)}
+	if err := never.Validate(); err == nil {
+		t.Fatal("cron yang tidak pernah cocok harus ditolak")
+	}
+}
@@ -0,0 +1,96 @@
+package schedules
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ScheduleRepository interface {
+	Create(schedule *Schedule) error
+	FindForUser(userID uint, id uint) (*Schedule, error)
+	ListByUser(userID uint) ([]Schedule, error)
+	FindDue(now time.Time, limit int) ([]Schedule, error)
+	Claim(id uint, now time.Time, leaseUntil time.Time) (bool, error)
+	Update(id uint, updates map[string]interface{}) error
+	TransitionStatus(id uint, from []ScheduleStatus, updates map[string]interface{}) (bool, error)
+	PauseByUser(userID uint) (int64, error)
+	CreateRun(run *Run) error
+	ListRuns(scheduleID uint, limit int) ([]Run, error)
+}
+
+type scheduleRepository struct {
+	DB *gorm.DB
+}
+
+func NewScheduleRepository(db *gorm.DB) ScheduleRepository {
+	return &scheduleRepository{DB: db}
+}
+
+func (r *scheduleRepository) Create(schedule *Schedule) error {
+	return r.DB.Create(schedule).Error
+}
+
+func (r *scheduleRepository) FindForUser(userID uint, id uint) (*Schedule, error) {
+	var schedule Schedule
+	if err := r.DB.Where("id = ? AND user_id = ?", id, userID).First(&schedule).Error; err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (r *scheduleRepository) ListByUser(userID uint) ([]Schedule, error) {
+	var schedules []Schedule
+	err := r.DB.Where("user_id = ?", userID).Order("id DESC").Find(&schedules).Error
+	return schedules, err
+}
+
+func (r *scheduleRepository) FindDue(now time.Time, limit int) ([]Schedule, error) {
+	var schedules []Schedule
+	err := r.DB.Where("status = ? AND due_at <= ?", StatusActive, now).Order("due_at ASC").Limit(limit).Find(&schedules).Error
+	return schedules, err
+}
+
+// Claim menggeser due_at ke leaseUntil supaya jadwal yang sama tidak diambil
+// worker lain selama sedang dieksekusi, walaupun lock Redis sempat lepas.
+func (r *scheduleRepository) Claim(id uint, now time.Time, leaseUntil time.Time) (bool, error) {
+	result := r.DB.Model(&Schedule{}).
+		Where("id = ? AND status = ? AND due_at <= ?", id, StatusActive, now).
+		Update("due_at", leaseUntil)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *scheduleRepository) Update(id uint, updates map[string]interface{}) error {
+	return r.DB.Model(&Schedule{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *scheduleRepository) TransitionStatus(id uint, from []ScheduleStatus, updates map[string]interface{}) (bool, error) {
+	result := r.DB.Model(&Schedule{}).Where("id = ? AND status IN ?", id, from).Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// PauseByUser menjeda semua jadwal ACTIVE milik user dan mengembalikan
+// jumlah jadwal yang dijeda.
+func (r *scheduleRepository) PauseByUser(userID uint) (int64, error) {
+	result := r.DB.Model(&Schedule{}).Where("user_id = ? AND status = ?", userID, StatusActive).Updates(map[string]interface{}{
+		"status": StatusPaused,
+		"due_at": nil,
+	})
+	return result.RowsAffected, result.Error
+}
+
+func (r *scheduleRepository) CreateRun(run *Run) error {
+	return r.DB.Create(run).Error
+}
+
+func (r *scheduleRepository) ListRuns(scheduleID uint, limit int) ([]Run, error) {
+	var runs []Run
+	err := r.DB.Where("schedule_id = ?", scheduleID).Order("id DESC").Limit(limit).Find(&runs).Error
+	return runs, err
+}
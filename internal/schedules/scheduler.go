@@ -0,0 +1,43 @@
+package schedules
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const schedulerLockKey = "scheduler:transfers:lock"
+
+// StartScheduler mengeksekusi jadwal yang jatuh tempo setiap interval sampai
+// ctx dibatalkan. Lock Redis memastikan hanya satu instance yang bekerja per
+// putaran; klaim per jadwal di database menjadi pengaman kedua.
+func StartScheduler(ctx context.Context, service ScheduleService, locker Locker, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			unlock, ok, err := locker.TryLock(ctx, schedulerLockKey, executionLease)
+			if err != nil {
+				log.Printf("ERROR: Gagal mengambil lock scheduler: %v", err)
+				continue
+			}
+			if !ok {
+				continue
+			}
+
+			executed, err := service.RunDue(time.Now())
+			unlock()
+			if err != nil {
+				log.Printf("ERROR: Gagal menjalankan jadwal transfer: %v", err)
+				continue
+			}
+			if executed > 0 {
+				log.Printf("SUCCESS: %d jadwal transfer dieksekusi", executed)
+			}
+		}
+	}
+}
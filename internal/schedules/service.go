@@ -0,0 +1,391 @@
+package schedules
+
+import (
+	"errors"
+	"ewallet-engine/internal/auth"
+	"ewallet-engine/internal/balance"
+	"ewallet-engine/internal/transactions"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// executionLease adalah lama sebuah jadwal dianggap sedang dieksekusi.
+const executionLease = 5 * time.Minute
+
+var (
+	ErrScheduleNotFound = errors.New("jadwal tidak ditemukan")
+	ErrScheduleState    = errors.New("status jadwal tidak mengizinkan aksi ini")
+	ErrSenderInactive   = errors.New("akun pengirim tidak aktif")
+)
+
+type ScheduleService interface {
+	Create(userID uint, request ScheduleRequest) (*Schedule, error)
+	List(userID uint) ([]Schedule, error)
+	Get(userID uint, id uint) (*Schedule, []Run, error)
+	Pause(userID uint, id uint) (*Schedule, error)
+	Resume(userID uint, id uint) (*Schedule, error)
+	Cancel(userID uint, id uint) (*Schedule, error)
+	RunDue(now time.Time) (int, error)
+}
+
+type scheduleService struct {
+	repo         ScheduleRepository
+	transactions transactions.TransactionService
+	users        auth.UserRepository
+	maxRetries   int
+	retryDelay   time.Duration
+	maxFailures  int
+}
+
+func NewScheduleService(repo ScheduleRepository, transactionService transactions.TransactionService, users auth.UserRepository) ScheduleService {
+	s := &scheduleService{
+		repo:         repo,
+		transactions: transactionService,
+		users:        users,
+		maxRetries:   3,
+		retryDelay:   time.Hour,
+		maxFailures:  3,
+	}
+	if v, err := strconv.Atoi(os.Getenv("SCHEDULE_MAX_RETRIES")); err == nil && v >= 0 {
+		s.maxRetries = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("SCHEDULE_RETRY_DELAY_MINUTES")); err == nil && v > 0 {
+		s.retryDelay = time.Duration(v) * time.Minute
+	}
+	if v, err := strconv.Atoi(os.Getenv("SCHEDULE_MAX_CONSECUTIVE_FAILURES")); err == nil && v > 0 {
+		s.maxFailures = v
+	}
+	return s
+}
+
+func (s *scheduleService) Create(userID uint, request ScheduleRequest) (*Schedule, error) {
+	currency, err := balance.NormalizeCurrency(request.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if err := balance.ValidateAmount(request.Amount, currency); err != nil {
+		return nil, err
+	}
+
+	recipient, err := s.transactions.FindRecipient(request.RecipientUserID, request.RecipientPhone, request.RecipientUsername)
+	if err != nil {
+		return nil, err
+	}
+	if recipient.ID == userID {
+		return nil, errors.New("tidak dapat membuat jadwal transfer ke diri sendiri")
+	}
+
+	now := time.Now()
+	startAt := request.StartAt
+	if startAt.IsZero() {
+		startAt = now.Truncate(time.Minute).Add(time.Minute)
+	}
+	if startAt.Before(now.Add(-time.Minute)) {
+		return nil, errors.New("start_at tidak boleh di masa lalu")
+	}
+	if request.EndAt != nil && !request.EndAt.After(startAt) {
+		return nil, errors.New("end_at harus setelah start_at")
+	}
+
+	schedule := &Schedule{
+		UserID:          userID,
+		RecipientUserID: recipient.ID,
+		Amount:          request.Amount,
+		Currency:        currency,
+		Description:     strings.TrimSpace(request.Description),
+		Frequency:       Frequency(strings.ToUpper(string(request.Frequency))),
+		CronExpr:        strings.TrimSpace(request.CronExpr),
+		StartAt:         startAt,
+		EndAt:           request.EndAt,
+		Status:          StatusActive,
+	}
+	if schedule.Frequency != FrequencyCron {
+		schedule.CronExpr = ""
+	}
+
+	recurrence := schedule.Recurrence()
+	if err := recurrence.Validate(); err != nil {
+		return nil, err
+	}
+	first, err := recurrence.First()
+	if err != nil {
+		return nil, err
+	}
+	if schedule.EndAt != nil && first.After(*schedule.EndAt) {
+		return nil, errors.New("jadwal tidak memiliki eksekusi sebelum end_at")
+	}
+	schedule.NextRunAt = &first
+	schedule.DueAt = &first
+
+	if err := s.repo.Create(schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func (s *scheduleService) List(userID uint) ([]Schedule, error) {
+	return s.repo.ListByUser(userID)
+}
+
+func (s *scheduleService) Get(userID uint, id uint) (*Schedule, []Run, error) {
+	schedule, err := s.find(userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	runs, err := s.repo.ListRuns(schedule.ID, 50)
+	if err != nil {
+		return nil, nil, err
+	}
+	return schedule, runs, nil
+}
+
+func (s *scheduleService) Pause(userID uint, id uint) (*Schedule, error) {
+	return s.transition(userID, id, []ScheduleStatus{StatusActive}, map[string]interface{}{"status": StatusPaused})
+}
+
+// Resume mengaktifkan kembali jadwal. Occurrence yang terlewat selama jadwal
+// dijeda tidak dieksekusi susulan.
+func (s *scheduleService) Resume(userID uint, id uint) (*Schedule, error) {
+	schedule, err := s.find(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.Status != StatusPaused {
+		return nil, ErrScheduleState
+	}
+
+	now := time.Now()
+	next := schedule.NextRunAt
+	if next == nil || next.Before(now) {
+		upcoming, err := schedule.Recurrence().Next(now)
+		if err != nil || (schedule.EndAt != nil && upcoming.After(*schedule.EndAt)) {
+			return s.transition(userID, id, []ScheduleStatus{StatusPaused}, map[string]interface{}{
+				"status":      StatusCompleted,
+				"next_run_at": nil,
+				"due_at":      nil,
+			})
+		}
+		next = &upcoming
+	}
+
+	return s.transition(userID, id, []ScheduleStatus{StatusPaused}, map[string]interface{}{
+		"status":               StatusActive,
+		"next_run_at":          next,
+		"due_at":               next,
+		"attempt":              0,
+		"consecutive_failures": 0,
+	})
+}
+
+func (s *scheduleService) Cancel(userID uint, id uint) (*Schedule, error) {
+	return s.transition(userID, id, []ScheduleStatus{StatusActive, StatusPaused}, map[string]interface{}{
+		"status": StatusCancelled,
+		"due_at": nil,
+	})
+}
+
+// RunDue mengeksekusi semua jadwal yang sudah jatuh tempo dan mengembalikan
+// jumlah jadwal yang dieksekusi.
+func (s *scheduleService) RunDue(now time.Time) (int, error) {
+	due, err := s.repo.FindDue(now, 100)
+	if err != nil {
+		return 0, err
+	}
+
+	executed := 0
+	for i := range due {
+		claimed, err := s.repo.Claim(due[i].ID, now, now.Add(executionLease))
+		if err != nil {
+			log.Printf("ERROR: Gagal mengklaim jadwal %d: %v", due[i].ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		s.execute(&due[i], now)
+		executed++
+	}
+	return executed, nil
+}
+
+// execute menjalankan occurrence NextRunAt sebagai TRANSFER biasa. Reference
+// diturunkan dari id jadwal dan waktu occurrence, jadi retry dan eksekusi ulang
+// setelah crash tidak menghasilkan transfer ganda.
+func (s *scheduleService) execute(schedule *Schedule, now time.Time) {
+	scheduledFor := *schedule.NextRunAt
+	reference := fmt.Sprintf("SCH-%d-%s", schedule.ID, scheduledFor.Format("200601021504"))
+	attempt := schedule.Attempt + 1
+
+	if err := s.checkSender(schedule.UserID); err != nil {
+		// Error selain ErrSenderInactive dibiarkan; jadwal dicoba lagi setelah
+		// lease klaim habis.
+		if errors.Is(err, ErrSenderInactive) {
+			s.recordRun(&Run{ScheduleID: schedule.ID, ScheduledFor: scheduledFor, Attempt: attempt, Reference: reference, Status: RunFailed, Error: err.Error()})
+			s.pauseSender(schedule, now)
+		} else {
+			log.Printf("ERROR: Gagal memeriksa status akun pemilik jadwal %d: %v", schedule.ID, err)
+		}
+		return
+	}
+
+	transaction, err := s.transactions.Transfer(schedule.UserID, schedule.RecipientUserID, schedule.Amount, schedule.Currency, reference, schedule.Description, transactions.AdditionalInfo{
+		"schedule_id": schedule.ID,
+		"channel":     "SCHEDULED",
+	})
+
+	run := &Run{ScheduleID: schedule.ID, ScheduledFor: scheduledFor, Attempt: attempt, Reference: reference}
+
+	switch {
+	case err == nil:
+		run.Status = RunSuccess
+		if transaction.TransactionStatus == transactions.StatusPending {
+			run.Status = RunPending
+		} else if transaction.TransactionStatus != transactions.StatusSuccess {
+			run.Status = RunFailed
+			run.Error = fmt.Sprintf("transaksi berstatus %s", transaction.TransactionStatus)
+		}
+	case errors.Is(err, transactions.ErrInsufficientFunds) && attempt <= s.maxRetries:
+		retryAt := now.Add(s.retryDelay * time.Duration(1<<(attempt-1)))
+		next, nextErr := schedule.Recurrence().Next(scheduledFor)
+		if nextErr != nil || retryAt.Before(next) {
+			run.Status = RunRetrying
+			run.Error = err.Error()
+			s.recordRun(run)
+			if err := s.repo.Update(schedule.ID, map[string]interface{}{"attempt": attempt, "due_at": retryAt, "last_run_at": now}); err != nil {
+				log.Printf("ERROR: Gagal menjadwalkan ulang jadwal %d: %v", schedule.ID, err)
+			}
+			return
+		}
+		run.Status = RunFailed
+		run.Error = err.Error()
+	default:
+		run.Status = RunFailed
+		run.Error = err.Error()
+	}
+
+	s.recordRun(run)
+	s.advance(schedule, scheduledFor, now, run.Status != RunFailed)
+}
+
+// checkSender memastikan pemilik jadwal masih boleh bertransaksi. Jadwal
+// berjalan tanpa request HTTP sehingga ActiveAccountMiddleware tidak ikut
+// memeriksanya.
+func (s *scheduleService) checkSender(userID uint) error {
+	user, err := s.users.FindByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSenderInactive
+		}
+		return err
+	}
+	if user.Status != auth.StatusActive {
+		return ErrSenderInactive
+	}
+	return nil
+}
+
+// pauseSender menjeda semua jadwal milik pengirim yang tidak aktif. Occurrence
+// yang sedang diklaim tetap menjadi NextRunAt sehingga Resume menghitung ulang
+// jadwal berikutnya dari waktu resume.
+func (s *scheduleService) pauseSender(schedule *Schedule, now time.Time) {
+	paused, err := s.repo.PauseByUser(schedule.UserID)
+	if err != nil {
+		log.Printf("ERROR: Gagal menjeda jadwal milik user_id %d: %v", schedule.UserID, err)
+	} else {
+		log.Printf("ALERT: %d jadwal milik user_id %d dijeda karena akun tidak aktif", paused, schedule.UserID)
+	}
+	if err := s.repo.Update(schedule.ID, map[string]interface{}{"attempt": 0, "last_run_at": now}); err != nil {
+		log.Printf("ERROR: Gagal memperbarui jadwal %d: %v", schedule.ID, err)
+	}
+}
+
+// advance memindahkan jadwal ke occurrence berikutnya yang belum lewat.
+func (s *scheduleService) advance(schedule *Schedule, scheduledFor time.Time, now time.Time, succeeded bool) {
+	updates := map[string]interface{}{
+		"attempt":     0,
+		"run_count":   gorm.Expr("run_count + 1"),
+		"last_run_at": now,
+	}
+
+	failures := 0
+	if !succeeded {
+		failures = schedule.ConsecutiveFailures + 1
+	}
+	updates["consecutive_failures"] = failures
+
+	recurrence := schedule.Recurrence()
+	next, err := recurrence.Next(scheduledFor)
+	if err == nil && !next.After(now) {
+		next, err = recurrence.Next(now)
+	}
+
+	switch {
+	case err != nil || (schedule.EndAt != nil && next.After(*schedule.EndAt)):
+		updates["status"] = StatusCompleted
+		updates["next_run_at"] = nil
+		updates["due_at"] = nil
+	case failures >= s.maxFailures:
+		updates["status"] = StatusPaused
+		updates["next_run_at"] = next
+		updates["due_at"] = nil
+		log.Printf("ALERT: Jadwal %d milik user_id %d dijeda setelah %d kali gagal berturut-turut", schedule.ID, schedule.UserID, failures)
+	default:
+		updates["next_run_at"] = next
+		updates["due_at"] = next
+	}
+
+	// Status hanya diubah jika jadwal masih ACTIVE, supaya pause/cancel yang
+	// terjadi selama eksekusi tidak tertimpa.
+	ok, err := s.repo.TransitionStatus(schedule.ID, []ScheduleStatus{StatusActive}, updates)
+	if err == nil && !ok {
+		delete(updates, "status")
+		delete(updates, "due_at")
+		err = s.repo.Update(schedule.ID, updates)
+	}
+	if err != nil {
+		log.Printf("ERROR: Gagal memperbarui jadwal %d: %v", schedule.ID, err)
+	}
+}
+
+func (s *scheduleService) recordRun(run *Run) {
+	if err := s.repo.CreateRun(run); err != nil {
+		log.Printf("ERROR: Gagal mencatat eksekusi jadwal %d: %v", run.ScheduleID, err)
+		return
+	}
+	if run.Status == RunSuccess {
+		log.Printf("SUCCESS: Jadwal %d dieksekusi dengan reference %s", run.ScheduleID, run.Reference)
+	}
+}
+
+func (s *scheduleService) find(userID uint, id uint) (*Schedule, error) {
+	schedule, err := s.repo.FindForUser(userID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrScheduleNotFound
+		}
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func (s *scheduleService) transition(userID uint, id uint, from []ScheduleStatus, updates map[string]interface{}) (*Schedule, error) {
+	schedule, err := s.find(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := s.repo.TransitionStatus(schedule.ID, from, updates)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrScheduleState
+	}
+	return s.find(userID, id)
+}
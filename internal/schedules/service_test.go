@@ -0,0 +1,208 @@
+package schedules
+
+import (
+	"errors"
+	"ewallet-engine/internal/auth"
+	"ewallet-engine/internal/transactions"
+	"testing"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+type fakeScheduleRepository struct {
+	ScheduleRepository
+	schedules map[uint]*Schedule
+	runs      []Run
+}
+
+func (r *fakeScheduleRepository) Update(id uint, updates map[string]interface{}) error {
+	schedule := r.schedules[id]
+	for key, value := range updates {
+		switch key {
+		case "status":
+			schedule.Status = value.(ScheduleStatus)
+		case "attempt":
+			schedule.Attempt = value.(int)
+		case "consecutive_failures":
+			schedule.ConsecutiveFailures = value.(int)
+		case "run_count":
+			if _, ok := value.(clause.Expr); ok {
+				schedule.RunCount++
+			}
+		case "next_run_at", "due_at":
+			var at *time.Time
+			if next, ok := value.(time.Time); ok {
+				at = &next
+			}
+			if key == "next_run_at" {
+				schedule.NextRunAt = at
+			} else {
+				schedule.DueAt = at
+			}
+		}
+	}
+	return nil
+}
+
+func (r *fakeScheduleRepository) TransitionStatus(id uint, from []ScheduleStatus, updates map[string]interface{}) (bool, error) {
+	for _, status := range from {
+		if r.schedules[id].Status == status {
+			return true, r.Update(id, updates)
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeScheduleRepository) PauseByUser(userID uint) (int64, error) {
+	var paused int64
+	for _, schedule := range r.schedules {
+		if schedule.UserID == userID && schedule.Status == StatusActive {
+			schedule.Status = StatusPaused
+			schedule.DueAt = nil
+			paused++
+		}
+	}
+	return paused, nil
+}
+
+func (r *fakeScheduleRepository) CreateRun(run *Run) error {
+	r.runs = append(r.runs, *run)
+	return nil
+}
+
+// fakeTransactionService memindahkan saldo antar user dan menolak transfer di
+// atas limit per transaksi.
+type fakeTransactionService struct {
+	transactions.TransactionService
+	balances map[uint]float64
+	limit    float64
+}
+
+func (s *fakeTransactionService) Transfer(userID uint, recipientUserID uint, amount float64, currency string, reference string, description string, additionalInfo transactions.AdditionalInfo) (*transactions.Transaction, error) {
+	if amount > s.limit {
+		return nil, errors.New("jumlah transaksi melebihi limit")
+	}
+	if s.balances[userID] < amount {
+		return nil, transactions.ErrInsufficientFunds
+	}
+	s.balances[userID] -= amount
+	s.balances[recipientUserID] += amount
+	return &transactions.Transaction{Reference: reference, TransactionStatus: transactions.StatusSuccess}, nil
+}
+
+type fakeUserRepository struct {
+	auth.UserRepository
+	statuses map[uint]string
+}
+
+func (r *fakeUserRepository) FindByID(id uint) (*auth.User, error) {
+	return &auth.User{ID: id, Status: r.statuses[id]}, nil
+}
+
+var firstRun = time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+
+// Dua jadwal harian milik user 7 dan satu milik user 8.
+func newFakeScheduleRepository() *fakeScheduleRepository {
+	next := firstRun
+	schedule := func(id uint, userID uint, recipientUserID uint, amount float64) *Schedule {
+		return &Schedule{ID: id, UserID: userID, RecipientUserID: recipientUserID, Amount: amount, Currency: "IDR", Frequency: FrequencyDaily, StartAt: next, Status: StatusActive, NextRunAt: &next, DueAt: &next}
+	}
+	return &fakeScheduleRepository{schedules: map[uint]*Schedule{
+		1: schedule(1, 7, 9, 50000),
+		2: schedule(2, 7, 11, 25000),
+		3: schedule(3, 8, 9, 10000),
+	}}
+}
+
+func (r *fakeScheduleRepository) claim(id uint) *Schedule {
+	copied := *r.schedules[id]
+	return &copied
+}
+
+func TestExecuteTransfersAndAdvances(t *testing.T) {
+	repo := newFakeScheduleRepository()
+	transfers := &fakeTransactionService{balances: map[uint]float64{7: 80000}, limit: 1000000}
+	service := &scheduleService{repo: repo, transactions: transfers, users: &fakeUserRepository{statuses: map[uint]string{7: auth.StatusActive}}, maxRetries: 3, retryDelay: time.Hour, maxFailures: 3}
+
+	service.execute(repo.claim(1), firstRun.Add(30*time.Second))
+
+	if transfers.balances[7] != 30000 || transfers.balances[9] != 50000 {
+		t.Fatalf("expected 50000 moved from 7 to 9; got %.2f/%.2f", transfers.balances[7], transfers.balances[9])
+	}
+	if len(repo.runs) != 1 || repo.runs[0].Status != RunSuccess || repo.runs[0].Reference != "SCH-1-202610010900" {
+		t.Fatalf("expected a SUCCESS run SCH-1-202610010900; got %+v", repo.runs)
+	}
+	schedule := repo.schedules[1]
+	if schedule.Status != StatusActive || schedule.RunCount != 1 || !schedule.DueAt.Equal(firstRun.AddDate(0, 0, 1)) {
+		t.Fatalf("expected schedule ACTIVE and due the next day; got %s, %d runs, due %v", schedule.Status, schedule.RunCount, schedule.DueAt)
+	}
+}
+
+func TestExecuteRetriesInsufficientFundsWithoutMovingBalance(t *testing.T) {
+	repo := newFakeScheduleRepository()
+	transfers := &fakeTransactionService{balances: map[uint]float64{7: 20000}, limit: 1000000}
+	service := &scheduleService{repo: repo, transactions: transfers, users: &fakeUserRepository{statuses: map[uint]string{7: auth.StatusActive}}, maxRetries: 3, retryDelay: time.Hour, maxFailures: 3}
+	now := firstRun.Add(30 * time.Second)
+
+	service.execute(repo.claim(1), now)
+
+	schedule := repo.schedules[1]
+	if transfers.balances[7] != 20000 || transfers.balances[9] != 0 {
+		t.Fatalf("expected balances untouched; got %.2f/%.2f", transfers.balances[7], transfers.balances[9])
+	}
+	if repo.runs[0].Status != RunRetrying || schedule.Attempt != 1 || !schedule.DueAt.Equal(now.Add(time.Hour)) {
+		t.Fatalf("expected a RETRYING run due in an hour; got %s, attempt %d, due %v", repo.runs[0].Status, schedule.Attempt, schedule.DueAt)
+	}
+
+	// Setelah top up, percobaan ulang membayar occurrence yang sama sekali saja.
+	transfers.balances[7] = 60000
+	service.execute(repo.claim(1), now.Add(time.Hour))
+	if transfers.balances[7] != 10000 || transfers.balances[9] != 50000 {
+		t.Fatalf("expected the retry to move 50000 once; got %.2f/%.2f", transfers.balances[7], transfers.balances[9])
+	}
+	if repo.runs[1].Status != RunSuccess || repo.runs[1].Reference != repo.runs[0].Reference || schedule.Attempt != 0 {
+		t.Fatalf("expected the retry to succeed on the same reference; got %+v, attempt %d", repo.runs[1], schedule.Attempt)
+	}
+}
+
+func TestExecutePausesAfterConsecutiveLimitFailures(t *testing.T) {
+	repo := newFakeScheduleRepository()
+	transfers := &fakeTransactionService{balances: map[uint]float64{7: 500000}, limit: 40000}
+	service := &scheduleService{repo: repo, transactions: transfers, users: &fakeUserRepository{statuses: map[uint]string{7: auth.StatusActive}}, maxRetries: 3, retryDelay: time.Hour, maxFailures: 3}
+
+	for day := 0; day < 3; day++ {
+		service.execute(repo.claim(1), firstRun.AddDate(0, 0, day).Add(30*time.Second))
+	}
+
+	schedule := repo.schedules[1]
+	if schedule.Status != StatusPaused || schedule.ConsecutiveFailures != 3 || schedule.DueAt != nil {
+		t.Fatalf("expected PAUSED after 3 failures; got %s, %d failures, due %v", schedule.Status, schedule.ConsecutiveFailures, schedule.DueAt)
+	}
+	if transfers.balances[7] != 500000 {
+		t.Fatalf("expected no transfer above the limit; balance %.2f", transfers.balances[7])
+	}
+}
+
+func TestExecuteInactiveSenderPausesOnlyTheirSchedules(t *testing.T) {
+	repo := newFakeScheduleRepository()
+	transfers := &fakeTransactionService{balances: map[uint]float64{7: 80000, 8: 80000}, limit: 1000000}
+	service := &scheduleService{repo: repo, transactions: transfers, users: &fakeUserRepository{statuses: map[uint]string{7: auth.StatusBlocked, 8: auth.StatusActive}}, maxRetries: 3, retryDelay: time.Hour, maxFailures: 3}
+
+	service.execute(repo.claim(1), firstRun.Add(30*time.Second))
+
+	if transfers.balances[7] != 80000 || transfers.balances[9] != 0 {
+		t.Fatalf("expected no transfer from a blocked sender; got %.2f/%.2f", transfers.balances[7], transfers.balances[9])
+	}
+	if len(repo.runs) != 1 || repo.runs[0].Status != RunFailed || repo.runs[0].Error != ErrSenderInactive.Error() {
+		t.Fatalf("expected a FAILED run for the inactive sender; got %+v", repo.runs)
+	}
+	for _, id := range []uint{1, 2} {
+		if repo.schedules[id].Status != StatusPaused || repo.schedules[id].DueAt != nil {
+			t.Errorf("expected schedule %d paused; got %s", id, repo.schedules[id].Status)
+		}
+	}
+	if repo.schedules[3].Status != StatusActive {
+		t.Errorf("expected another user's schedule to stay ACTIVE; got %s", repo.schedules[3].Status)
+	}
+}
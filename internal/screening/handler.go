@@ -0,0 +1,96 @@
+package screening
+
+import (
+	"ewallet-engine/internal/audit"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ScreeningHandler struct {
+	service      ScreeningService
+	auditService audit.AuditService
+}
+
+func NewScreeningHandler(service ScreeningService, auditService audit.AuditService) *ScreeningHandler {
+	return &ScreeningHandler{service: service, auditService: auditService}
+}
+
+func (h *ScreeningHandler) ListHitsHandler(c *fiber.Ctx) error {
+	status := HitStatus(c.Query("status", string(HitPending)))
+
+	hits, err := h.service.ListHits(status)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": hits})
+}
+
+func (h *ScreeningHandler) CheckNameHandler(c *fiber.Ctx) error {
+	var request struct {
+		Name string `json:"name"`
+	}
+	if err := c.BodyParser(&request); err != nil || request.Name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Nama wajib diisi"})
+	}
+
+	return c.JSON(fiber.Map{"data": h.service.Match(request.Name)})
+}
+
+func (h *ScreeningHandler) ClearHitHandler(c *fiber.Ctx) error {
+	return h.review(c, h.service.ClearHit, audit.ActionScreeningHitCleared, "Hit dinyatakan bukan kecocokan")
+}
+
+func (h *ScreeningHandler) ConfirmHitHandler(c *fiber.Ctx) error {
+	return h.review(c, h.service.ConfirmHit, audit.ActionScreeningHitConfirmed, "Hit dikonfirmasi, akun tetap diblokir")
+}
+
+func (h *ScreeningHandler) ReloadListsHandler(c *fiber.Ctx) error {
+	size, err := h.service.ReloadLists()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Daftar screening berhasil dimuat ulang",
+		"entries": size,
+	})
+}
+
+func (h *ScreeningHandler) review(c *fiber.Ctx, decision func(id uint, reviewerID uint, note string) (*ScreeningHit, error), action string, successMessage string) error {
+	reviewerID := c.Locals("user_id").(uint)
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	var request struct {
+		Note string `json:"note"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+	}
+
+	hit, err := decision(uint(id), reviewerID, request.Note)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     action,
+		TargetType: "screening_hit",
+		TargetID:   fmt.Sprint(hit.ID),
+		Before:     audit.Snapshot{"status": HitPending},
+		After:      audit.Snapshot{"status": hit.Status, "user_id": hit.UserID, "note": hit.ReviewNote},
+	})
+
+	return c.JSON(fiber.Map{
+		"message": successMessage,
+		"data":    hit,
+	})
+}
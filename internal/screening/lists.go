@@ -0,0 +1,184 @@
+package screening
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	ListTypeSanction = "SANCTION"
+	ListTypePEP      = "PEP"
+)
+
+// ListEntry adalah satu orang/entitas pada daftar sanksi atau PEP.
+type ListEntry struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+	Type    string   `json:"type"`
+	Source  string   `json:"source"`
+
+	// tokens berisi hasil Normalize untuk Name dan setiap alias.
+	tokens [][]string
+}
+
+// LoadLists memuat semua file .csv dan .xml di dir.
+func LoadLists(dir string) ([]ListEntry, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []ListEntry
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		path := filepath.Join(dir, file.Name())
+		var loaded []ListEntry
+		switch strings.ToLower(filepath.Ext(file.Name())) {
+		case ".csv":
+			loaded, err = loadCSV(path)
+		case ".xml":
+			loaded, err = loadXML(path)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("gagal memuat %s: %w", file.Name(), err)
+		}
+		entries = append(entries, loaded...)
+	}
+
+	for i := range entries {
+		entries[i].tokens = append(entries[i].tokens, Normalize(entries[i].Name))
+		for _, alias := range entries[i].Aliases {
+			entries[i].tokens = append(entries[i].tokens, Normalize(alias))
+		}
+	}
+
+	return entries, nil
+}
+
+// loadCSV membaca CSV dengan header id,name,aliases,type,source; alias
+// dipisahkan titik koma.
+func loadCSV(path string) ([]ListEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, errors.New("kolom name wajib ada")
+	}
+
+	field := func(record []string, column string) string {
+		if i, ok := columns[column]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	source := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	var entries []ListEntry
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		entry := ListEntry{
+			ID:     field(record, "id"),
+			Name:   field(record, "name"),
+			Type:   strings.ToUpper(field(record, "type")),
+			Source: field(record, "source"),
+		}
+		if entry.Name == "" {
+			continue
+		}
+		if entry.Type == "" {
+			entry.Type = ListTypeSanction
+		}
+		if entry.Source == "" {
+			entry.Source = source
+		}
+		for _, alias := range strings.Split(field(record, "aliases"), ";") {
+			if alias = strings.TrimSpace(alias); alias != "" {
+				entry.Aliases = append(entry.Aliases, alias)
+			}
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// unConsolidatedList adalah subset format XML UN Security Council Consolidated List.
+type unConsolidatedList struct {
+	Individuals []unSubject `xml:"INDIVIDUALS>INDIVIDUAL"`
+	Entities    []unSubject `xml:"ENTITIES>ENTITY"`
+}
+
+type unSubject struct {
+	DataID            string    `xml:"DATAID"`
+	FirstName         string    `xml:"FIRST_NAME"`
+	SecondName        string    `xml:"SECOND_NAME"`
+	ThirdName         string    `xml:"THIRD_NAME"`
+	FourthName        string    `xml:"FOURTH_NAME"`
+	IndividualAliases []unAlias `xml:"INDIVIDUAL_ALIAS"`
+	EntityAliases     []unAlias `xml:"ENTITY_ALIAS"`
+}
+
+type unAlias struct {
+	Name string `xml:"ALIAS_NAME"`
+}
+
+func loadXML(path string) ([]ListEntry, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var list unConsolidatedList
+	if err := xml.Unmarshal(raw, &list); err != nil {
+		return nil, err
+	}
+
+	source := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	var entries []ListEntry
+	for _, subject := range append(list.Individuals, list.Entities...) {
+		name := strings.Join(strings.Fields(strings.Join([]string{subject.FirstName, subject.SecondName, subject.ThirdName, subject.FourthName}, " ")), " ")
+		if name == "" {
+			continue
+		}
+
+		entry := ListEntry{ID: subject.DataID, Name: name, Type: ListTypeSanction, Source: source}
+		for _, alias := range append(subject.IndividualAliases, subject.EntityAliases...) {
+			if alias.Name = strings.TrimSpace(alias.Name); alias.Name != "" {
+				entry.Aliases = append(entry.Aliases, alias.Name)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
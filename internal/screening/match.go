@@ -0,0 +1,110 @@
+package screening
+
+import (
+	"sort"
+	"strings"
+)
+
+// fuzzyTokenThreshold adalah kemiripan minimum agar dua token dianggap sama,
+// misalnya salah ketik "suharto" dan "soeharto".
+const fuzzyTokenThreshold = 0.8
+
+// Similarity menghitung kemiripan dua nama (0..1) dengan token-set ratio:
+// token yang sama di kedua nama dibandingkan terpisah dari sisanya sehingga
+// urutan nama dan token tambahan tidak terlalu menurunkan skor.
+func Similarity(a []string, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	common, restA, restB := splitTokens(a, b)
+
+	// Satu token yang sama (misalnya "muhammad") belum cukup untuk menyatakan
+	// nama multi-token cocok.
+	if len(common) < 2 && (len(a) > 1 || len(b) > 1) {
+		return ratio(joinSorted(a), joinSorted(b))
+	}
+
+	base := joinSorted(common)
+	withA := strings.TrimSpace(base + " " + joinSorted(restA))
+	withB := strings.TrimSpace(base + " " + joinSorted(restB))
+
+	best := ratio(withA, withB)
+	if base != "" {
+		best = max(best, ratio(base, withA), ratio(base, withB))
+	}
+	return best
+}
+
+// splitTokens memisahkan token yang sama (termasuk yang mirip) dari sisanya.
+func splitTokens(a []string, b []string) ([]string, []string, []string) {
+	used := make([]bool, len(b))
+	var common, restA []string
+
+	for _, tokenA := range a {
+		matched := false
+		for j, tokenB := range b {
+			if used[j] {
+				continue
+			}
+			if tokenA == tokenB || ratio(tokenA, tokenB) >= fuzzyTokenThreshold {
+				used[j] = true
+				matched = true
+				common = append(common, tokenA)
+				break
+			}
+		}
+		if !matched {
+			restA = append(restA, tokenA)
+		}
+	}
+
+	var restB []string
+	for j, tokenB := range b {
+		if !used[j] {
+			restB = append(restB, tokenB)
+		}
+	}
+
+	return common, restA, restB
+}
+
+func joinSorted(tokens []string) string {
+	sorted := append([]string(nil), tokens...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, " ")
+}
+
+// ratio adalah 1 - jarak Levenshtein dibagi panjang string terpanjang.
+func ratio(a string, b string) float64 {
+	if a == b {
+		return 1
+	}
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a []rune, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,70 @@
+package screening
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := map[string][]string{
+		"Dr. José  Müller":    {"jose", "muller"},
+		"H. Mochammad Yousef": {"muhammad", "yusuf"},
+		"Владимир Петров":     {"vladimir", "petrov"},
+		"O'Brien-Smith, Jr":   {"o", "brien", "smith", "jr"},
+		"  ":                  nil,
+	}
+
+	for input, expected := range cases {
+		if got := Normalize(input); !reflect.DeepEqual(got, expected) {
+			t.Errorf("Normalize(%q) = %v; expected %v", input, got, expected)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	score := func(a, b string) float64 { return Similarity(Normalize(a), Normalize(b)) }
+
+	if got := score("Mohammed Example Sanctioned", "Sanctioned, Muhammad Example"); got < 0.99 {
+		t.Errorf("expected reordered spelling variant to match; got %.2f", got)
+	}
+	if got := score("Budhi Santosa Contoh", "Budi Santoso Contoh"); got < DefaultMatchThreshold {
+		t.Errorf("expected typo variant to match; got %.2f", got)
+	}
+	if got := score("Muhammad Rizki", "Muhammad Example Sanctioned"); got >= DefaultMatchThreshold {
+		t.Errorf("expected single shared given name not to match; got %.2f", got)
+	}
+}
+
+func TestWatchlistMatchesSampleLists(t *testing.T) {
+	watchlist, err := NewWatchlist("../../config/watchlists")
+	if err != nil {
+		t.Fatalf("load sample lists: %v", err)
+	}
+
+	matches := watchlist.Match("Muhamad Ekzampel", DefaultMatchThreshold)
+	if len(matches) == 0 || matches[0].Entry.ID != "900001" {
+		t.Fatalf("expected alias to match UN entry 900001; got %+v", matches)
+	}
+	if matches[0].MatchedName != "Muhamad Ekzampel" {
+		t.Errorf("expected matched alias to be reported; got %q", matches[0].MatchedName)
+	}
+
+	if matches := watchlist.Match("Andi Pratama", DefaultMatchThreshold); len(matches) != 0 {
+		t.Errorf("expected no match for unrelated name; got %+v", matches)
+	}
+}
+
+func TestScreeningFailsClosedWhenListsMissing(t *testing.T) {
+	watchlist, err := NewWatchlist("testdata/does-not-exist")
+	if err == nil {
+		t.Fatal("expected missing list directory to fail loading")
+	}
+	service := NewScreeningService(nil, watchlist, 0)
+
+	if err := service.ScreenUser(1, "Andi Pratama"); err != ErrListsUnavailable {
+		t.Errorf("expected ScreenUser to fail closed; got %v", err)
+	}
+	if hit, err := service.ScreenCounterparty(1, "Andi Pratama", "TRF-1"); err != ErrListsUnavailable || hit {
+		t.Errorf("expected ScreenCounterparty to fail closed; got %v, %v", hit, err)
+	}
+}
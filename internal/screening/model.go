@@ -0,0 +1,46 @@
+package screening
+
+import (
+	"time"
+)
+
+const (
+	SubjectRegistration = "REGISTRATION"
+	SubjectCounterparty = "COUNTERPARTY"
+)
+
+type HitStatus string
+
+const (
+	HitPending   HitStatus = "PENDING"
+	HitCleared   HitStatus = "CLEARED"
+	HitConfirmed HitStatus = "CONFIRMED"
+)
+
+// ScreeningHit adalah kecocokan nama yang menunggu review manual. Selama masih
+// ada hit PENDING atau CONFIRMED, akun user diblokir.
+type ScreeningHit struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	UserID       uint       `gorm:"not null;index" json:"user_id"`
+	Subject      string     `gorm:"type:varchar(20);not null" json:"subject"`
+	Reference    string     `gorm:"type:varchar(255)" json:"reference,omitempty"`
+	ScreenedName string     `gorm:"type:varchar(255);not null" json:"screened_name"`
+	ListEntryID  string     `gorm:"type:varchar(100)" json:"list_entry_id"`
+	ListSource   string     `gorm:"type:varchar(100)" json:"list_source"`
+	ListType     string     `gorm:"type:varchar(20)" json:"list_type"`
+	MatchedName  string     `gorm:"type:varchar(255);not null" json:"matched_name"`
+	Score        float64    `gorm:"not null" json:"score"`
+	Status       HitStatus  `gorm:"type:enum('PENDING','CLEARED','CONFIRMED');default:'PENDING';index" json:"status"`
+	ReviewerID   *uint      `json:"reviewer_id,omitempty"`
+	ReviewNote   string     `gorm:"type:varchar(255)" json:"review_note,omitempty"`
+	ReviewedAt   *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// Match adalah hasil pencocokan satu nama terhadap satu ListEntry.
+type Match struct {
+	Entry       ListEntry `json:"entry"`
+	MatchedName string    `json:"matched_name"`
+	Score       float64   `json:"score"`
+}
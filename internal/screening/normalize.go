@@ -0,0 +1,72 @@
+package screening
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// cyrillicToLatin mengikuti transliterasi ICAO yang dipakai di paspor.
+var cyrillicToLatin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "i", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "ie", 'ы': "y", 'ь': "", 'э': "e", 'ю': "iu",
+	'я': "ia",
+}
+
+// nameVariants menyatukan ejaan umum dari nama yang sama.
+var nameVariants = map[string]string{
+	"mohammed": "muhammad", "mohammad": "muhammad", "mohamed": "muhammad", "mohamad": "muhammad",
+	"muhammed": "muhammad", "muhamad": "muhammad", "mochammad": "muhammad", "mochamad": "muhammad",
+	"moch": "muhammad", "moh": "muhammad", "muh": "muhammad", "md": "muhammad",
+	"abd": "abdul", "abdel": "abdul", "abdal": "abdul",
+	"yousef": "yusuf", "youssef": "yusuf", "yousuf": "yusuf", "yusup": "yusuf",
+	"usama": "osama", "ussama": "osama",
+	"achmad": "ahmad", "ahmed": "ahmad", "akhmad": "ahmad",
+}
+
+// honorifics tidak membedakan orang sehingga dibuang sebelum dibandingkan.
+var honorifics = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "dr": true, "prof": true, "h": true, "hj": true,
+	"haji": true, "hajjah": true, "ir": true, "drs": true, "sh": true, "se": true, "sheikh": true,
+}
+
+var stripMarks = transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+// Normalize mengubah nama menjadi token huruf kecil tanpa diakritik,
+// mentransliterasi huruf Sirilik, membuang gelar dan menyatukan varian ejaan.
+func Normalize(name string) []string {
+	stripped, _, err := transform.String(stripMarks, name)
+	if err != nil {
+		stripped = name
+	}
+
+	var builder strings.Builder
+	for _, r := range strings.ToLower(stripped) {
+		if latin, ok := cyrillicToLatin[r]; ok {
+			builder.WriteString(latin)
+			continue
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			builder.WriteRune(r)
+			continue
+		}
+		builder.WriteRune(' ')
+	}
+
+	var tokens []string
+	for _, token := range strings.Fields(builder.String()) {
+		if honorifics[token] {
+			continue
+		}
+		if canonical, ok := nameVariants[token]; ok {
+			token = canonical
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
@@ -0,0 +1,80 @@
+package screening
+
+import (
+	"ewallet-engine/internal/auth"
+
+	"gorm.io/gorm"
+)
+
+type ScreeningRepository interface {
+	CreateHits(hits []ScreeningHit) error
+	FindHitByID(id uint) (*ScreeningHit, error)
+	ListHits(status HitStatus) ([]ScreeningHit, error)
+	CountUnresolvedHits(userID uint) (int64, error)
+	TransitionHit(id uint, from HitStatus, updates map[string]interface{}) (bool, error)
+	UpdateUserStatus(userID uint, status string) error
+	ActivateUser(userID uint) (bool, error)
+}
+
+type screeningRepository struct {
+	DB *gorm.DB
+}
+
+func NewScreeningRepository(db *gorm.DB) ScreeningRepository {
+	return &screeningRepository{DB: db}
+}
+
+func (r *screeningRepository) CreateHits(hits []ScreeningHit) error {
+	return r.DB.Create(&hits).Error
+}
+
+func (r *screeningRepository) FindHitByID(id uint) (*ScreeningHit, error) {
+	var hit ScreeningHit
+	err := r.DB.First(&hit, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &hit, nil
+}
+
+func (r *screeningRepository) ListHits(status HitStatus) ([]ScreeningHit, error) {
+	var hits []ScreeningHit
+	query := r.DB.Order("created_at ASC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&hits).Error
+	return hits, err
+}
+
+func (r *screeningRepository) CountUnresolvedHits(userID uint) (int64, error) {
+	var count int64
+	err := r.DB.Model(&ScreeningHit{}).
+		Where("user_id = ? AND status IN ?", userID, []HitStatus{HitPending, HitConfirmed}).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *screeningRepository) TransitionHit(id uint, from HitStatus, updates map[string]interface{}) (bool, error) {
+	result := r.DB.Model(&ScreeningHit{}).Where("id = ? AND status = ?", id, from).Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ActivateUser mengaktifkan akun yang diblokir atau masih menunggu screening;
+// status lain tidak diubah.
+func (r *screeningRepository) ActivateUser(userID uint) (bool, error) {
+	result := r.DB.Model(&auth.User{}).
+		Where("id = ? AND status IN ?", userID, []string{auth.StatusBlocked, auth.StatusPendingScreening}).
+		Update("status", auth.StatusActive)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *screeningRepository) UpdateUserStatus(userID uint, status string) error {
+	return r.DB.Model(&auth.User{}).Where("id = ?", userID).Update("status", status).Error
+}
@@ -0,0 +1,161 @@
+package screening
+
+import (
+	"errors"
+	"ewallet-engine/internal/auth"
+	"fmt"
+	"log"
+	"time"
+)
+
+const DefaultMatchThreshold = 0.85
+
+// ErrListsUnavailable dikembalikan selama daftar screening belum berhasil
+// dimuat; screening gagal tertutup sehingga registrasi dan transaksi ditahan.
+var ErrListsUnavailable = errors.New("daftar screening belum tersedia")
+
+type ScreeningService interface {
+	ScreenUser(userID uint, name string) error
+	ScreenCounterparty(userID uint, name string, reference string) (bool, error)
+	Match(name string) []Match
+	ListHits(status HitStatus) ([]ScreeningHit, error)
+	ClearHit(id uint, reviewerID uint, note string) (*ScreeningHit, error)
+	ConfirmHit(id uint, reviewerID uint, note string) (*ScreeningHit, error)
+	ReloadLists() (int, error)
+}
+
+type screeningService struct {
+	repo      ScreeningRepository
+	watchlist *Watchlist
+	threshold float64
+}
+
+func NewScreeningService(repo ScreeningRepository, watchlist *Watchlist, threshold float64) ScreeningService {
+	if threshold <= 0 || threshold > 1 {
+		threshold = DefaultMatchThreshold
+	}
+	return &screeningService{repo: repo, watchlist: watchlist, threshold: threshold}
+}
+
+// ScreenUser memenuhi auth.NameScreener dan dipanggil saat registrasi.
+func (s *screeningService) ScreenUser(userID uint, name string) error {
+	_, err := s.screen(userID, SubjectRegistration, name, "")
+	return err
+}
+
+// ScreenCounterparty memeriksa nama penerima dana. Nilai true berarti ada hit
+// sehingga transaksi harus dihentikan.
+func (s *screeningService) ScreenCounterparty(userID uint, name string, reference string) (bool, error) {
+	return s.screen(userID, SubjectCounterparty, name, reference)
+}
+
+func (s *screeningService) Match(name string) []Match {
+	return s.watchlist.Match(name, s.threshold)
+}
+
+func (s *screeningService) ListHits(status HitStatus) ([]ScreeningHit, error) {
+	return s.repo.ListHits(status)
+}
+
+// ClearHit menandai hit sebagai false positive dan membuka blokir akun jika
+// tidak ada hit lain yang belum selesai. Hanya akun BLOCKED atau
+// PENDING_SCREENING yang diaktifkan.
+func (s *screeningService) ClearHit(id uint, reviewerID uint, note string) (*ScreeningHit, error) {
+	hit, err := s.review(id, reviewerID, note, HitCleared)
+	if err != nil {
+		return nil, err
+	}
+
+	unresolved, err := s.repo.CountUnresolvedHits(hit.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if unresolved == 0 {
+		activated, err := s.repo.ActivateUser(hit.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if activated {
+			log.Printf("SUCCESS: Blokir screening user_id %d dibuka", hit.UserID)
+		}
+	}
+
+	return hit, nil
+}
+
+// ConfirmHit menyatakan kecocokan benar; akun tetap diblokir.
+func (s *screeningService) ConfirmHit(id uint, reviewerID uint, note string) (*ScreeningHit, error) {
+	if note == "" {
+		return nil, errors.New("catatan konfirmasi wajib diisi")
+	}
+	return s.review(id, reviewerID, note, HitConfirmed)
+}
+
+func (s *screeningService) ReloadLists() (int, error) {
+	if err := s.watchlist.Reload(); err != nil {
+		return 0, err
+	}
+	return s.watchlist.Size(), nil
+}
+
+func (s *screeningService) screen(userID uint, subject string, name string, reference string) (bool, error) {
+	if s.watchlist == nil || !s.watchlist.Loaded() {
+		return false, ErrListsUnavailable
+	}
+
+	matches := s.watchlist.Match(name, s.threshold)
+	if len(matches) == 0 {
+		return false, nil
+	}
+
+	hits := make([]ScreeningHit, 0, len(matches))
+	for _, match := range matches {
+		hits = append(hits, ScreeningHit{
+			UserID:       userID,
+			Subject:      subject,
+			Reference:    reference,
+			ScreenedName: name,
+			ListEntryID:  match.Entry.ID,
+			ListSource:   match.Entry.Source,
+			ListType:     match.Entry.Type,
+			MatchedName:  match.MatchedName,
+			Score:        match.Score,
+			Status:       HitPending,
+		})
+	}
+
+	if err := s.repo.CreateHits(hits); err != nil {
+		return true, err
+	}
+	if err := s.repo.UpdateUserStatus(userID, auth.StatusBlocked); err != nil {
+		return true, err
+	}
+
+	log.Printf("ALERT: Screening %s user_id %d cocok dengan %d entri daftar, akun diblokir", subject, userID, len(hits))
+	return true, nil
+}
+
+func (s *screeningService) review(id uint, reviewerID uint, note string, status HitStatus) (*ScreeningHit, error) {
+	hit, err := s.repo.FindHitByID(id)
+	if err != nil {
+		return nil, errors.New("hit screening tidak ditemukan")
+	}
+	if hit.Status != HitPending {
+		return nil, fmt.Errorf("hit sudah berstatus %s", hit.Status)
+	}
+
+	ok, err := s.repo.TransitionHit(id, HitPending, map[string]interface{}{
+		"status":      status,
+		"reviewer_id": reviewerID,
+		"review_note": note,
+		"reviewed_at": time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("hit sudah direview oleh operator lain")
+	}
+
+	return s.repo.FindHitByID(id)
+}
@@ -0,0 +1,85 @@
+package screening
+
+import (
+	"sort"
+	"sync"
+)
+
+// maxMatches membatasi jumlah hit yang dibuat untuk satu nama.
+const maxMatches = 5
+
+// Watchlist menyimpan daftar yang sudah dinormalisasi di memori dan dibagi
+// oleh semua ScreeningService.
+type Watchlist struct {
+	dir string
+
+	mu      sync.RWMutex
+	entries []ListEntry
+	loaded  bool
+}
+
+func NewWatchlist(dir string) (*Watchlist, error) {
+	watchlist := &Watchlist{dir: dir}
+	return watchlist, watchlist.Reload()
+}
+
+func (w *Watchlist) Reload() error {
+	entries, err := LoadLists(w.dir)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.entries = entries
+	w.loaded = true
+	return nil
+}
+
+// Loaded bernilai false sampai daftar berhasil dimuat sekali.
+func (w *Watchlist) Loaded() bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.loaded
+}
+
+func (w *Watchlist) Size() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return len(w.entries)
+}
+
+// Match mengembalikan entri dengan skor >= threshold, skor tertinggi dulu.
+func (w *Watchlist) Match(name string, threshold float64) []Match {
+	tokens := Normalize(name)
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	var matches []Match
+	for _, entry := range w.entries {
+		best := Match{Entry: entry}
+		for i, candidate := range entry.tokens {
+			score := Similarity(tokens, candidate)
+			if score > best.Score {
+				best.Score = score
+				best.MatchedName = entry.Name
+				if i > 0 {
+					best.MatchedName = entry.Aliases[i-1]
+				}
+			}
+		}
+		if best.Score >= threshold {
+			matches = append(matches, best)
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if len(matches) > maxMatches {
+		matches = matches[:maxMatches]
+	}
+	return matches
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"ewallet-engine/internal/approvals"
+	"ewallet-engine/internal/balance"
+	"ewallet-engine/internal/billpayments"
+	"ewallet-engine/internal/disputes"
+	"ewallet-engine/internal/loyalty"
+	"ewallet-engine/internal/paymentrequests"
+	"ewallet-engine/internal/referrals"
+	"ewallet-engine/internal/schedules"
+	"ewallet-engine/internal/settlements"
+	"ewallet-engine/internal/transactions"
+	"ewallet-engine/internal/withdrawals"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultChainVerifyInterval = time.Hour
+	defaultHoldExpiryInterval  = 5 * time.Minute
+	defaultWithdrawalReconcile = time.Minute
+	defaultSchedulerInterval   = 30 * time.Second
+	defaultRequestExpiry       = 5 * time.Minute
+	defaultSettlementInterval  = 5 * time.Minute
+	defaultDisputeEscalation   = 15 * time.Minute
+	defaultBillPaymentCheck    = time.Minute
+	defaultPointsExpiry        = time.Hour
+	defaultReferralQualify     = 10 * time.Minute
+	defaultApprovalRecovery    = 5 * time.Minute
+)
+
+// StartBackgroundJobs menjalankan pekerjaan periodik sampai ctx dibatalkan.
+func (s *FiberServer) StartBackgroundJobs(ctx context.Context) {
+	chainVerifyInterval := defaultChainVerifyInterval
+	if minutes, err := strconv.Atoi(os.Getenv("WALLET_CHAIN_VERIFY_INTERVAL_MINUTES")); err == nil && minutes > 0 {
+		chainVerifyInterval = time.Duration(minutes) * time.Minute
+	}
+
+	go balance.StartChainVerifier(ctx, s.newBalanceService(), chainVerifyInterval)
+
+	holdExpiryInterval := defaultHoldExpiryInterval
+	if minutes, err := strconv.Atoi(os.Getenv("WALLET_HOLD_EXPIRY_INTERVAL_MINUTES")); err == nil && minutes > 0 {
+		holdExpiryInterval = time.Duration(minutes) * time.Minute
+	}
+
+	transactionService := s.newTransactionService()
+	go balance.StartHoldExpirer(ctx, s.newBalanceService(), holdExpiryInterval, func(hold balance.Hold) {
+		// Hold yang kedaluwarsa berarti PURCHASE-nya tidak pernah diselesaikan.
+		transaction, err := transactionService.GetTransactionByReference(hold.Reference)
+		if err != nil || transaction.TransactionStatus != transactions.StatusPending {
+			return
+		}
+		if err := transactionService.UpdateTransaction(hold.Reference, transactions.StatusFailed); err != nil {
+			log.Printf("ERROR: Gagal menggagalkan transaksi %s yang hold-nya kedaluwarsa: %v", hold.Reference, err)
+		}
+	})
+
+	reconcileInterval := defaultWithdrawalReconcile
+	if seconds, err := strconv.Atoi(os.Getenv("WITHDRAWAL_RECONCILE_INTERVAL_SECONDS")); err == nil && seconds > 0 {
+		reconcileInterval = time.Duration(seconds) * time.Second
+	}
+
+	go withdrawals.StartReconciler(ctx, s.newWithdrawalService(), reconcileInterval)
+
+	schedulerInterval := defaultSchedulerInterval
+	if seconds, err := strconv.Atoi(os.Getenv("SCHEDULER_INTERVAL_SECONDS")); err == nil && seconds > 0 {
+		schedulerInterval = time.Duration(seconds) * time.Second
+	}
+
+	go schedules.StartScheduler(ctx, s.newScheduleService(), schedules.NewRedisLocker(s.db.GetRedis()), schedulerInterval)
+
+	requestExpiryInterval := defaultRequestExpiry
+	if minutes, err := strconv.Atoi(os.Getenv("PAYMENT_REQUEST_EXPIRY_INTERVAL_MINUTES")); err == nil && minutes > 0 {
+		requestExpiryInterval = time.Duration(minutes) * time.Minute
+	}
+
+	go paymentrequests.StartExpirer(ctx, s.newPaymentRequestService(), requestExpiryInterval)
+
+	settlementInterval := defaultSettlementInterval
+	if minutes, err := strconv.Atoi(os.Getenv("SETTLEMENT_RUN_INTERVAL_MINUTES")); err == nil && minutes > 0 {
+		settlementInterval = time.Duration(minutes) * time.Minute
+	}
+
+	go settlements.StartRunner(ctx, s.newSettlementService(), schedules.NewRedisLocker(s.db.GetRedis()), settlementInterval)
+
+	disputeInterval := defaultDisputeEscalation
+	if minutes, err := strconv.Atoi(os.Getenv("DISPUTE_ESCALATION_INTERVAL_MINUTES")); err == nil && minutes > 0 {
+		disputeInterval = time.Duration(minutes) * time.Minute
+	}
+
+	go disputes.StartEscalator(ctx, s.newDisputeService(), disputeInterval)
+
+	billPaymentInterval := defaultBillPaymentCheck
+	if seconds, err := strconv.Atoi(os.Getenv("BILL_PAYMENT_RECONCILE_INTERVAL_SECONDS")); err == nil && seconds > 0 {
+		billPaymentInterval = time.Duration(seconds) * time.Second
+	}
+
+	go billpayments.StartReconciler(ctx, s.newBillPaymentService(), billPaymentInterval)
+
+	pointsExpiryInterval := defaultPointsExpiry
+	if minutes, err := strconv.Atoi(os.Getenv("POINTS_EXPIRY_INTERVAL_MINUTES")); err == nil && minutes > 0 {
+		pointsExpiryInterval = time.Duration(minutes) * time.Minute
+	}
+
+	go loyalty.StartExpirer(ctx, s.newLoyaltyService(), pointsExpiryInterval)
+
+	referralInterval := defaultReferralQualify
+	if minutes, err := strconv.Atoi(os.Getenv("REFERRAL_QUALIFY_INTERVAL_MINUTES")); err == nil && minutes > 0 {
+		referralInterval = time.Duration(minutes) * time.Minute
+	}
+
+	go referrals.StartQualifier(ctx, s.newReferralService(), referralInterval)
+
+	approvalInterval := defaultApprovalRecovery
+	if minutes, err := strconv.Atoi(os.Getenv("APPROVAL_RECOVERY_INTERVAL_MINUTES")); err == nil && minutes > 0 {
+		approvalInterval = time.Duration(minutes) * time.Minute
+	}
+
+	go approvals.StartRecoverer(ctx, s.newApprovalService(), approvalInterval)
+
+	// Batch yang terputus karena restart dilanjutkan; baris yang sudah dibayar tidak diulang.
+	if resumed := s.newDisbursementService().ResumeProcessing(); resumed > 0 {
+		log.Printf("SUCCESS: %d batch disbursement dilanjutkan", resumed)
+	}
+}
@@ -1,13 +1,36 @@
 package server
 
 import (
+	"errors"
+	"ewallet-engine/internal/approvals"
+	"ewallet-engine/internal/audit"
 	"ewallet-engine/internal/auth"
 	"ewallet-engine/internal/balance"
-	"ewallet-engine/internal/database"
+	"ewallet-engine/internal/billpayments"
+	"ewallet-engine/internal/disbursements"
+	"ewallet-engine/internal/disputes"
+	"ewallet-engine/internal/fees"
+	"ewallet-engine/internal/fraud"
+	"ewallet-engine/internal/fx"
+	"ewallet-engine/internal/kyc"
+	"ewallet-engine/internal/limits"
+	"ewallet-engine/internal/loyalty"
+	"ewallet-engine/internal/merchants"
+	"ewallet-engine/internal/notifications"
+	"ewallet-engine/internal/paymentrequests"
+	"ewallet-engine/internal/promotions"
+	"ewallet-engine/internal/qris"
+	"ewallet-engine/internal/referrals"
+	"ewallet-engine/internal/schedules"
+	"ewallet-engine/internal/screening"
+	"ewallet-engine/internal/settlements"
+	"ewallet-engine/internal/splitbills"
 	"ewallet-engine/internal/transactions"
+	"ewallet-engine/internal/withdrawals"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 )
 
 func (s *FiberServer) RegisterFiberRoutes() {
@@ -19,14 +42,13 @@ func (s *FiberServer) RegisterFiberRoutes() {
 		AllowCredentials: false, // credentials require explicit origins
 		MaxAge:           300,
 	}))
+	s.App.Use(requestid.New())
 
 	s.App.Get("/", s.HelloWorldHandler)
 
 	s.App.Get("/health", s.healthHandler)
 
-	userRepo := auth.NewUserRepository(s.db)
-	authService := auth.NewAuthService(userRepo)
-	authHandler := auth.NewAuthHandler(authService)
+	authHandler := auth.NewAuthHandler(s.newAuthService(), s.newAuditService())
 
 	// Routing
 	api := s.App.Group("/user/v1")
@@ -34,6 +56,7 @@ func (s *FiberServer) RegisterFiberRoutes() {
 	api.Post("/login", authHandler.Login)
 	api.Post("/logout", auth.JWTMiddleware() ,authHandler.Logout)
 	api.Post("/refresh", authHandler.RefreshToken)
+	api.Post("/pin", auth.JWTMiddleware(), authHandler.SetPIN)
 
 }
 
@@ -46,14 +69,14 @@ func (s *FiberServer) BalanceFiberRoutes() {
 		MaxAge:           300,
 	}))
 
-	db := database.New().GetDB()
-	balanceRepo := balance.NewBalanceRepository(db)
-	balanceService := balance.NewBalanceService(balanceRepo)
-	balanceHandler := balance.NewBalanceHandler(balanceService)
+	balanceService := s.newBalanceService()
+	balanceHandler := balance.NewBalanceHandler(balanceService, s.newAuditService(), s.newFraudService())
 
 	api := s.App.Group("/user/v1")
 	api.Get("/balance", auth.JWTMiddleware(), balanceHandler.GetBalanceHandler)
-	api.Post("/topup", auth.JWTMiddleware(), balanceHandler.TopUpBalanceHandler)
+	api.Get("/wallets", auth.JWTMiddleware(), balanceHandler.ListWalletsHandler)
+	api.Post("/wallets", auth.JWTMiddleware(), auth.ActiveAccountMiddleware(auth.NewUserRepository(s.db)), balanceHandler.OpenWalletHandler)
+	api.Post("/topup", auth.JWTMiddleware(), auth.ActiveAccountMiddleware(auth.NewUserRepository(s.db)), balanceHandler.TopUpBalanceHandler)
 }
 
 func (s *FiberServer) TransactionFiberRoutes() {
@@ -65,15 +88,568 @@ func (s *FiberServer) TransactionFiberRoutes() {
 		MaxAge:           300,
 	}))
 
-	db := database.New().GetDB()
-	transactionRepo := transactions.NewTransactionRepository(db)
-	transactionService := transactions.NewTransactionService(transactionRepo)
-	transactionHandler := transactions.NewTransactionHandler(transactionService)
+	transactionService := s.newTransactionService()
+	transactionHandler := transactions.NewTransactionHandler(transactionService, s.newAuditService())
 
 	api := s.App.Group("/user/v1")
-	api.Post("/transaction", auth.JWTMiddleware(), transactionHandler.CreateTransactionHandler)
+	api.Post("/transaction", auth.JWTMiddleware(), auth.ActiveAccountMiddleware(auth.NewUserRepository(s.db)), transactionHandler.CreateTransactionHandler)
+	api.Post("/transfer", auth.JWTMiddleware(), auth.ActiveAccountMiddleware(auth.NewUserRepository(s.db)), transactionHandler.TransferHandler)
 	api.Put("/transaction/status", auth.JWTMiddleware(), transactionHandler.UpdateTransactionHandler)
 	api.Get("/transaction/:reference", auth.JWTMiddleware(), transactionHandler.GetTransactionHandler)
+	api.Get("/transaction/:reference/refunds", auth.JWTMiddleware(), transactionHandler.GetRefundsHandler)
+}
+
+func (s *FiberServer) ApprovalFiberRoutes() {
+	s.App.Use(cors.New(cors.Config{
+		AllowOrigins:     "*",
+		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS,PATCH",
+		AllowHeaders:     "Accept,Authorization,Content-Type",
+		AllowCredentials: false, // credentials require explicit origins
+		MaxAge:           300,
+	}))
+
+	approvalService := s.newApprovalService()
+	approvalHandler := approvals.NewApprovalHandler(approvalService, s.newAuditService())
+
+	api := s.App.Group("/admin/v1/approvals", auth.JWTMiddleware())
+	api.Post("/", auth.RequireRole(auth.RoleOperator, auth.RoleApprover, auth.RoleAdmin), approvalHandler.SubmitHandler)
+	api.Get("/", auth.RequireRole(auth.RoleOperator, auth.RoleApprover, auth.RoleAdmin), approvalHandler.ListHandler)
+	api.Get("/:id", auth.RequireRole(auth.RoleOperator, auth.RoleApprover, auth.RoleAdmin), approvalHandler.GetHandler)
+	api.Post("/:id/approve", auth.RequireRole(auth.RoleApprover), approvalHandler.ApproveHandler)
+	api.Post("/:id/reject", auth.RequireRole(auth.RoleApprover), approvalHandler.RejectHandler)
+}
+
+func (s *FiberServer) AuditFiberRoutes() {
+	s.App.Use(cors.New(cors.Config{
+		AllowOrigins:     "*",
+		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS,PATCH",
+		AllowHeaders:     "Accept,Authorization,Content-Type",
+		AllowCredentials: false, // credentials require explicit origins
+		MaxAge:           300,
+	}))
+
+	auditHandler := audit.NewAuditHandler(s.newAuditService())
+
+	api := s.App.Group("/admin/v1/audit-logs", auth.JWTMiddleware(), auth.RequireRole(auth.RoleAdmin))
+	api.Get("/", auditHandler.QueryHandler)
+	api.Get("/verify", auditHandler.VerifyHandler)
+}
+
+func (s *FiberServer) WalletAdminFiberRoutes() {
+	s.App.Use(cors.New(cors.Config{
+		AllowOrigins:     "*",
+		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS,PATCH",
+		AllowHeaders:     "Accept,Authorization,Content-Type",
+		AllowCredentials: false, // credentials require explicit origins
+		MaxAge:           300,
+	}))
+
+	balanceHandler := balance.NewBalanceHandler(s.newBalanceService(), s.newAuditService(), s.newFraudService())
+
+	api := s.App.Group("/admin/v1/wallets", auth.JWTMiddleware(), auth.RequireRole(auth.RoleAdmin))
+	api.Get("/:id/verify", balanceHandler.VerifyWalletHandler)
+}
+
+func (s *FiberServer) LimitFiberRoutes() {
+	s.App.Use(cors.New(cors.Config{
+		AllowOrigins:     "*",
+		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS,PATCH",
+		AllowHeaders:     "Accept,Authorization,Content-Type",
+		AllowCredentials: false, // credentials require explicit origins
+		MaxAge:           300,
+	}))
+
+	limitHandler := limits.NewLimitHandler(s.newLimitService(), s.newAuditService())
+
+	api := s.App.Group("/user/v1")
+	api.Get("/limits", auth.JWTMiddleware(), limitHandler.GetLimitsHandler)
+
+	admin := s.App.Group("/admin/v1/limits", auth.JWTMiddleware(), auth.RequireRole(auth.RoleAdmin))
+	admin.Get("/rules", limitHandler.ListRulesHandler)
+	admin.Put("/rules", limitHandler.SaveRuleHandler)
+}
+
+func (s *FiberServer) KYCFiberRoutes() {
+	s.App.Use(cors.New(cors.Config{
+		AllowOrigins:     "*",
+		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS,PATCH",
+		AllowHeaders:     "Accept,Authorization,Content-Type",
+		AllowCredentials: false, // credentials require explicit origins
+		MaxAge:           300,
+	}))
+
+	kycHandler := kyc.NewKYCHandler(s.newKYCService(), s.newAuditService())
+
+	api := s.App.Group("/user/v1")
+	api.Post("/kyc", auth.JWTMiddleware(), kycHandler.SubmitHandler)
+	api.Get("/kyc", auth.JWTMiddleware(), kycHandler.StatusHandler)
+
+	admin := s.App.Group("/admin/v1/kyc", auth.JWTMiddleware(), auth.RequireRole(auth.RoleOperator, auth.RoleAdmin))
+	admin.Get("/", kycHandler.ListHandler)
+	admin.Get("/:id/documents/:kind", kycHandler.DocumentHandler)
+	admin.Post("/:id/approve", kycHandler.ApproveHandler)
+	admin.Post("/:id/reject", kycHandler.RejectHandler)
+}
+
+func (s *FiberServer) FraudFiberRoutes() {
+	s.App.Use(cors.New(cors.Config{
+		AllowOrigins:     "*",
+		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS,PATCH",
+		AllowHeaders:     "Accept,Authorization,Content-Type",
+		AllowCredentials: false, // credentials require explicit origins
+		MaxAge:           300,
+	}))
+
+	transactionService := s.newTransactionService()
+	fraudService := s.newFraudService()
+	balanceService := s.newBalanceService()
+	fraudService.SetResolvers(func(fraudCase fraud.FraudCase) error {
+		// Top up langsung yang ditahan REVIEW dibukukan setelah case dinyatakan aman.
+		if _, err := balanceService.ResolveReview(fraudCase.ID, true); !errors.Is(err, balance.ErrAdjustmentNotFound) {
+			return err
+		}
+		// TRANSFER dan pembayaran merchant tidak punya pihak lain yang menyelesaikannya,
+		// jadi diselesaikan setelah case dinyatakan aman.
+		transaction, err := transactionService.GetTransactionByReference(fraudCase.Reference)
+		if err != nil || transaction.CounterpartyUserID == 0 || transaction.TransactionStatus != transactions.StatusPending {
+			return nil
+		}
+		return transactionService.UpdateTransaction(fraudCase.Reference, transactions.StatusSuccess)
+	}, func(fraudCase fraud.FraudCase) error {
+		// Top up langsung dan transaksi PENDING yang terbukti fraud digagalkan.
+		if _, err := balanceService.ResolveReview(fraudCase.ID, false); !errors.Is(err, balance.ErrAdjustmentNotFound) {
+			return err
+		}
+		transaction, err := transactionService.GetTransactionByReference(fraudCase.Reference)
+		if err != nil || transaction.TransactionStatus != transactions.StatusPending {
+			return nil
+		}
+		return transactionService.UpdateTransaction(fraudCase.Reference, transactions.StatusFailed)
+	})
+	fraudHandler := fraud.NewFraudHandler(fraudService, s.newAuditService())
+
+	admin := s.App.Group("/admin/v1/fraud", auth.JWTMiddleware(), auth.RequireRole(auth.RoleOperator, auth.RoleAdmin))
+	admin.Get("/cases", fraudHandler.ListCasesHandler)
+	admin.Get("/cases/:id", fraudHandler.GetCaseHandler)
+	admin.Post("/cases/:id/clear", fraudHandler.ClearCaseHandler)
+	admin.Post("/cases/:id/confirm", fraudHandler.ConfirmCaseHandler)
+	admin.Get("/rules", fraudHandler.RulesHandler)
+	admin.Post("/rules/reload", auth.RequireRole(auth.RoleAdmin), fraudHandler.ReloadRulesHandler)
+}
+
+func (s *FiberServer) ScreeningFiberRoutes() {
+	s.App.Use(cors.New(cors.Config{
+		AllowOrigins:     "*",
+		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS,PATCH",
+		AllowHeaders:     "Accept,Authorization,Content-Type",
+		AllowCredentials: false, // credentials require explicit origins
+		MaxAge:           300,
+	}))
+
+	screeningHandler := screening.NewScreeningHandler(s.newScreeningService(), s.newAuditService())
+
+	admin := s.App.Group("/admin/v1/screening", auth.JWTMiddleware(), auth.RequireRole(auth.RoleOperator, auth.RoleAdmin))
+	admin.Get("/hits", screeningHandler.ListHitsHandler)
+	admin.Post("/hits/:id/clear", screeningHandler.ClearHitHandler)
+	admin.Post("/hits/:id/confirm", screeningHandler.ConfirmHitHandler)
+	admin.Post("/check", screeningHandler.CheckNameHandler)
+	admin.Post("/lists/reload", auth.RequireRole(auth.RoleAdmin), screeningHandler.ReloadListsHandler)
+}
+
+func (s *FiberServer) FXFiberRoutes() {
+	s.App.Use(cors.New(cors.Config{
+		AllowOrigins:     "*",
+		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS,PATCH",
+		AllowHeaders:     "Accept,Authorization,Content-Type",
+		AllowCredentials: false, // credentials require explicit origins
+		MaxAge:           300,
+	}))
+
+	fxHandler := fx.NewFXHandler(s.newFXService(), s.newAuditService())
+
+	api := s.App.Group("/user/v1/fx", auth.JWTMiddleware())
+	api.Post("/quote", fxHandler.QuoteHandler)
+	api.Post("/execute", auth.ActiveAccountMiddleware(auth.NewUserRepository(s.db)), fxHandler.ExecuteHandler)
+
+	admin := s.App.Group("/admin/v1/fx", auth.JWTMiddleware(), auth.RequireRole(auth.RoleOperator, auth.RoleAdmin))
+	admin.Get("/ledger", fxHandler.LedgerHandler)
+}
+
+func (s *FiberServer) FeeFiberRoutes() {
+	s.App.Use(cors.New(cors.Config{
+		AllowOrigins:     "*",
+		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS,PATCH",
+		AllowHeaders:     "Accept,Authorization,Content-Type",
+		AllowCredentials: false, // credentials require explicit origins
+		MaxAge:           300,
+	}))
+
+	feeHandler := fees.NewFeeHandler(s.newFeeService(), s.newAuditService())
+
+	api := s.App.Group("/user/v1/fees", auth.JWTMiddleware())
+	api.Post("/quote", feeHandler.QuoteHandler)
+
+	admin := s.App.Group("/admin/v1/fees", auth.JWTMiddleware(), auth.RequireRole(auth.RoleOperator, auth.RoleAdmin))
+	admin.Get("/schedules", feeHandler.ListSchedulesHandler)
+	admin.Post("/schedules", auth.RequireRole(auth.RoleAdmin), feeHandler.CreateScheduleHandler)
+	admin.Put("/schedules/:id", auth.RequireRole(auth.RoleAdmin), feeHandler.UpdateScheduleHandler)
+	admin.Post("/schedules/:id/end", auth.RequireRole(auth.RoleAdmin), feeHandler.EndScheduleHandler)
+}
+
+func (s *FiberServer) WithdrawalFiberRoutes() {
+	s.App.Use(cors.New(cors.Config{
+		AllowOrigins:     "*",
+		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS,PATCH",
+		AllowHeaders:     "Accept,Authorization,Content-Type",
+		AllowCredentials: false, // credentials require explicit origins
+		MaxAge:           300,
+	}))
+
+	withdrawalHandler := withdrawals.NewWithdrawalHandler(s.newWithdrawalService(), s.newAuditService())
+
+	api := s.App.Group("/user/v1", auth.JWTMiddleware())
+	api.Get("/banks", withdrawalHandler.ListBanksHandler)
+	api.Post("/beneficiaries/inquiry", withdrawalHandler.InquiryHandler)
+	api.Get("/beneficiaries", withdrawalHandler.ListBeneficiariesHandler)
+	api.Post("/beneficiaries", auth.ActiveAccountMiddleware(auth.NewUserRepository(s.db)), withdrawalHandler.AddBeneficiaryHandler)
+	api.Delete("/beneficiaries/:id", withdrawalHandler.RemoveBeneficiaryHandler)
+	api.Post("/withdrawals", auth.ActiveAccountMiddleware(auth.NewUserRepository(s.db)), withdrawalHandler.CreateWithdrawalHandler)
+	api.Get("/withdrawals/:reference", withdrawalHandler.GetWithdrawalHandler)
+
+	admin := s.App.Group("/admin/v1/withdrawals", auth.JWTMiddleware(), auth.RequireRole(auth.RoleOperator, auth.RoleAdmin))
+	admin.Get("/", withdrawalHandler.ListWithdrawalsHandler)
+	admin.Post("/:reference/reconcile", withdrawalHandler.ReconcileHandler)
+}
+
+func (s *FiberServer) DisbursementFiberRoutes() {
+	s.App.Use(cors.New(cors.Config{
+		AllowOrigins:     "*",
+		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS,PATCH",
+		AllowHeaders:     "Accept,Authorization,Content-Type",
+		AllowCredentials: false, // credentials require explicit origins
+		MaxAge:           300,
+	}))
+
+	disbursementHandler := disbursements.NewDisbursementHandler(s.newDisbursementService(), s.newAuditService())
+
+	admin := s.App.Group("/admin/v1/disbursements", auth.JWTMiddleware(), auth.RequireRole(auth.RoleOperator, auth.RoleAdmin))
+	admin.Get("/", disbursementHandler.ListBatchesHandler)
+	admin.Post("/", disbursementHandler.CreateBatchHandler)
+	admin.Get("/:batch_id", disbursementHandler.GetBatchHandler)
+	admin.Get("/:batch_id/items", disbursementHandler.ListItemsHandler)
+	admin.Get("/:batch_id/result", disbursementHandler.ResultFileHandler)
+	admin.Post("/:batch_id/execute", auth.RequireRole(auth.RoleAdmin), disbursementHandler.ExecuteBatchHandler)
+	admin.Post("/:batch_id/cancel", disbursementHandler.CancelBatchHandler)
+}
+
+func (s *FiberServer) ScheduleFiberRoutes() {
+	s.App.Use(cors.New(cors.Config{
+		AllowOrigins:     "*",
+		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS,PATCH",
+		AllowHeaders:     "Accept,Authorization,Content-Type",
+		AllowCredentials: false, // credentials require explicit origins
+		MaxAge:           300,
+	}))
+
+	scheduleHandler := schedules.NewScheduleHandler(s.newScheduleService(), s.newAuditService())
+
+	api := s.App.Group("/user/v1/schedules", auth.JWTMiddleware())
+	api.Get("/", scheduleHandler.ListHandler)
+	api.Post("/", auth.ActiveAccountMiddleware(auth.NewUserRepository(s.db)), scheduleHandler.CreateHandler)
+	api.Get("/:id", scheduleHandler.GetHandler)
+	api.Post("/:id/pause", scheduleHandler.PauseHandler)
+	api.Post("/:id/resume", auth.ActiveAccountMiddleware(auth.NewUserRepository(s.db)), scheduleHandler.ResumeHandler)
+	api.Delete("/:id", scheduleHandler.CancelHandler)
+}
+
+func (s *FiberServer) NotificationFiberRoutes() {
+	s.App.Use(cors.New(cors.Config{
+		AllowOrigins:     "*",
+		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS,PATCH",
+		AllowHeaders:     "Accept,Authorization,Content-Type",
+		AllowCredentials: false, // credentials require explicit origins
+		MaxAge:           300,
+	}))
+
+	notificationHandler := notifications.NewNotificationHandler(s.newNotificationService())
+
+	api := s.App.Group("/user/v1/notifications", auth.JWTMiddleware())
+	api.Get("/", notificationHandler.ListHandler)
+	api.Post("/read-all", notificationHandler.MarkAllReadHandler)
+	api.Post("/:id/read", notificationHandler.MarkReadHandler)
+}
+
+func (s *FiberServer) PaymentRequestFiberRoutes() {
+	s.App.Use(cors.New(cors.Config{
+		AllowOrigins:     "*",
+		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS,PATCH",
+		AllowHeaders:     "Accept,Authorization,Content-Type",
+		AllowCredentials: false, // credentials require explicit origins
+		MaxAge:           300,
+	}))
+
+	paymentRequestHandler := paymentrequests.NewPaymentRequestHandler(s.newPaymentRequestService(), s.newAuditService())
+
+	api := s.App.Group("/user/v1/payment-requests", auth.JWTMiddleware())
+	api.Post("/", auth.ActiveAccountMiddleware(auth.NewUserRepository(s.db)), paymentRequestHandler.CreateHandler)
+	api.Get("/incoming", paymentRequestHandler.IncomingHandler)
+	api.Get("/outgoing", paymentRequestHandler.OutgoingHandler)
+	api.Get("/:id", paymentRequestHandler.GetHandler)
+	api.Post("/:id/accept", auth.ActiveAccountMiddleware(auth.NewUserRepository(s.db)), paymentRequestHandler.AcceptHandler)
+	api.Post("/:id/decline", paymentRequestHandler.DeclineHandler)
+	api.Post("/:id/cancel", paymentRequestHandler.CancelHandler)
+}
+
+func (s *FiberServer) SplitBillFiberRoutes() {
+	s.App.Use(cors.New(cors.Config{
+		AllowOrigins:     "*",
+		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS,PATCH",
+		AllowHeaders:     "Accept,Authorization,Content-Type",
+		AllowCredentials: false, // credentials require explicit origins
+		MaxAge:           300,
+	}))
+
+	splitBillHandler := splitbills.NewSplitBillHandler(s.newSplitBillService(), s.newAuditService())
+
+	api := s.App.Group("/user/v1/split-groups", auth.JWTMiddleware())
+	api.Get("/", splitBillHandler.ListGroupsHandler)
+	api.Post("/", splitBillHandler.CreateGroupHandler)
+	api.Get("/:id", splitBillHandler.GetGroupHandler)
+	api.Post("/:id/members", splitBillHandler.AddMemberHandler)
+	api.Get("/:id/expenses", splitBillHandler.ListExpensesHandler)
+	api.Post("/:id/expenses", auth.ActiveAccountMiddleware(auth.NewUserRepository(s.db)), splitBillHandler.AddExpenseHandler)
+	api.Get("/:id/summary", splitBillHandler.SummaryHandler)
+	api.Get("/:id/settlements", splitBillHandler.ListSettlementsHandler)
+	api.Post("/:id/settlements", auth.ActiveAccountMiddleware(auth.NewUserRepository(s.db)), splitBillHandler.SettleHandler)
+}
+
+func (s *FiberServer) QRISFiberRoutes() {
+	s.App.Use(cors.New(cors.Config{
+		AllowOrigins:     "*",
+		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS,PATCH",
+		AllowHeaders:     "Accept,Authorization,Content-Type",
+		AllowCredentials: false, // credentials require explicit origins
+		MaxAge:           300,
+	}))
+
+	qrisHandler := qris.NewQRISHandler(s.newQRISService(), s.newAuditService())
+
+	api := s.App.Group("/user/v1/qr", auth.JWTMiddleware())
+	api.Post("/parse", qrisHandler.ParseHandler)
+	api.Post("/pay", auth.ActiveAccountMiddleware(auth.NewUserRepository(s.db)), qrisHandler.PayHandler)
+
+	admin := s.App.Group("/admin/v1/qris", auth.JWTMiddleware(), auth.RequireRole(auth.RoleOperator, auth.RoleAdmin))
+	admin.Post("/generate", qrisHandler.GenerateHandler)
+}
+
+func (s *FiberServer) MerchantFiberRoutes() {
+	s.App.Use(cors.New(cors.Config{
+		AllowOrigins:     "*",
+		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS,PATCH",
+		AllowHeaders:     "Accept,Authorization,Content-Type,X-API-Key",
+		AllowCredentials: false, // credentials require explicit origins
+		MaxAge:           300,
+	}))
+
+	merchantHandler := merchants.NewMerchantHandler(s.newMerchantService(), s.newAuditService())
+
+	admin := s.App.Group("/admin/v1/merchants", auth.JWTMiddleware(), auth.RequireRole(auth.RoleOperator, auth.RoleAdmin))
+	admin.Get("/", merchantHandler.ListMerchantsHandler)
+	admin.Post("/", merchantHandler.CreateMerchantHandler)
+	admin.Get("/:id", merchantHandler.GetMerchantHandler)
+	admin.Post("/:id/status", merchantHandler.SetStatusHandler)
+	admin.Get("/:id/api-keys", merchantHandler.ListAPIKeysHandler)
+	admin.Post("/:id/api-keys", auth.RequireRole(auth.RoleAdmin), merchantHandler.IssueAPIKeyHandler)
+	admin.Delete("/:id/api-keys/:key_id", merchantHandler.RevokeAPIKeyHandler)
+
+	api := s.App.Group("/merchant/v1", merchants.APIKeyMiddleware(merchants.NewMerchantRepository(s.db.GetDB())))
+	api.Post("/payment-intents", merchants.RequireScope(merchants.ScopeIntentsWrite), merchantHandler.CreateIntentHandler)
+	api.Get("/payment-intents/:intent_id", merchants.RequireScope(merchants.ScopeIntentsRead), merchantHandler.GetIntentHandler)
+	api.Post("/payment-intents/:intent_id/cancel", merchants.RequireScope(merchants.ScopeIntentsWrite), merchantHandler.CancelIntentHandler)
+	api.Post("/payment-intents/:intent_id/refunds", merchants.RequireScope(merchants.ScopeRefundsWrite), merchantHandler.RefundIntentHandler)
+	api.Get("/balance", merchants.RequireScope(merchants.ScopeBalanceRead), merchantHandler.BalanceHandler)
+
+	user := s.App.Group("/user/v1/payment-intents", auth.JWTMiddleware())
+	user.Get("/:intent_id", merchantHandler.ViewIntentHandler)
+	user.Post("/:intent_id/confirm", auth.ActiveAccountMiddleware(auth.NewUserRepository(s.db)), merchantHandler.ConfirmIntentHandler)
+}
+
+func (s *FiberServer) SettlementFiberRoutes() {
+	s.App.Use(cors.New(cors.Config{
+		AllowOrigins:     "*",
+		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS,PATCH",
+		AllowHeaders:     "Accept,Authorization,Content-Type,X-API-Key",
+		AllowCredentials: false, // credentials require explicit origins
+		MaxAge:           300,
+	}))
+
+	settlementHandler := settlements.NewSettlementHandler(s.newSettlementService(), s.newAuditService())
+
+	admin := s.App.Group("/admin/v1/settlements", auth.JWTMiddleware(), auth.RequireRole(auth.RoleOperator, auth.RoleAdmin))
+	admin.Get("/", settlementHandler.ListHandler)
+	admin.Post("/run", auth.RequireRole(auth.RoleAdmin), settlementHandler.RunHandler)
+	admin.Get("/accounts/:merchant_id", settlementHandler.GetAccountHandler)
+	admin.Put("/accounts/:merchant_id", auth.RequireRole(auth.RoleAdmin), settlementHandler.SetAccountHandler)
+	admin.Get("/:reference", settlementHandler.GetHandler)
+	admin.Get("/:reference/items", settlementHandler.ItemsHandler)
+	admin.Get("/:reference/report", settlementHandler.ReportHandler)
+	admin.Post("/:reference/retry", auth.RequireRole(auth.RoleAdmin), settlementHandler.RetryHandler)
+	admin.Post("/:reference/reconcile", settlementHandler.ReconcileHandler)
+
+	api := s.App.Group("/merchant/v1/settlements", merchants.APIKeyMiddleware(merchants.NewMerchantRepository(s.db.GetDB())), merchants.RequireScope(merchants.ScopeSettlementsRead))
+	api.Get("/", settlementHandler.MerchantListHandler)
+	api.Get("/:reference", settlementHandler.GetHandler)
+	api.Get("/:reference/items", settlementHandler.ItemsHandler)
+	api.Get("/:reference/report", settlementHandler.ReportHandler)
+}
+
+func (s *FiberServer) DisputeFiberRoutes() {
+	s.App.Use(cors.New(cors.Config{
+		AllowOrigins:     "*",
+		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS,PATCH",
+		AllowHeaders:     "Accept,Authorization,Content-Type,X-API-Key",
+		AllowCredentials: false, // credentials require explicit origins
+		MaxAge:           300,
+	}))
+
+	disputeHandler := disputes.NewDisputeHandler(s.newDisputeService(), s.newAuditService())
+
+	api := s.App.Group("/user/v1/disputes", auth.JWTMiddleware())
+	api.Get("/", disputeHandler.ListHandler)
+	api.Post("/", auth.ActiveAccountMiddleware(auth.NewUserRepository(s.db)), disputeHandler.OpenHandler)
+	api.Get("/:id", disputeHandler.GetHandler)
+	api.Post("/:id/evidence", disputeHandler.AddEvidenceHandler)
+	api.Get("/:id/evidence/:evidence_id/file", disputeHandler.EvidenceFileHandler)
+	api.Post("/:id/withdraw", disputeHandler.WithdrawHandler)
+
+	merchant := s.App.Group("/merchant/v1/disputes", merchants.APIKeyMiddleware(merchants.NewMerchantRepository(s.db.GetDB())))
+	merchant.Get("/", merchants.RequireScope(merchants.ScopeDisputesRead), disputeHandler.ListHandler)
+	merchant.Get("/:id", merchants.RequireScope(merchants.ScopeDisputesRead), disputeHandler.GetHandler)
+	merchant.Get("/:id/evidence/:evidence_id/file", merchants.RequireScope(merchants.ScopeDisputesRead), disputeHandler.EvidenceFileHandler)
+	merchant.Post("/:id/evidence", merchants.RequireScope(merchants.ScopeDisputesWrite), disputeHandler.AddEvidenceHandler)
+	merchant.Post("/:id/respond", merchants.RequireScope(merchants.ScopeDisputesWrite), disputeHandler.RespondHandler)
+
+	admin := s.App.Group("/admin/v1/disputes", auth.JWTMiddleware(), auth.RequireRole(auth.RoleOperator, auth.RoleAdmin))
+	admin.Get("/", disputeHandler.AdminListHandler)
+	admin.Get("/:id", disputeHandler.AdminGetHandler)
+	admin.Post("/:id/evidence", disputeHandler.AdminAddEvidenceHandler)
+	admin.Get("/:id/evidence/:evidence_id/file", disputeHandler.AdminEvidenceFileHandler)
+	admin.Post("/:id/decide", disputeHandler.DecideHandler)
+}
+
+func (s *FiberServer) BillPaymentFiberRoutes() {
+	s.App.Use(cors.New(cors.Config{
+		AllowOrigins:     "*",
+		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS,PATCH",
+		AllowHeaders:     "Accept,Authorization,Content-Type",
+		AllowCredentials: false, // credentials require explicit origins
+		MaxAge:           300,
+	}))
+
+	billPaymentHandler := billpayments.NewBillPaymentHandler(s.newBillPaymentService(), s.newAuditService())
+
+	api := s.App.Group("/user/v1/bills", auth.JWTMiddleware())
+	api.Get("/billers", billPaymentHandler.ListBillersHandler)
+	api.Post("/inquiry", billPaymentHandler.InquiryHandler)
+	api.Get("/payments", billPaymentHandler.ListMyPaymentsHandler)
+	api.Post("/payments", auth.ActiveAccountMiddleware(auth.NewUserRepository(s.db)), billPaymentHandler.PayHandler)
+	api.Get("/payments/:reference", billPaymentHandler.GetPaymentHandler)
+
+	admin := s.App.Group("/admin/v1/bill-payments", auth.JWTMiddleware(), auth.RequireRole(auth.RoleOperator, auth.RoleAdmin))
+	admin.Get("/", billPaymentHandler.ListPaymentsHandler)
+	admin.Post("/:reference/reconcile", billPaymentHandler.ReconcileHandler)
+}
+
+func (s *FiberServer) PromotionFiberRoutes() {
+	s.App.Use(cors.New(cors.Config{
+		AllowOrigins:     "*",
+		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS,PATCH",
+		AllowHeaders:     "Accept,Authorization,Content-Type",
+		AllowCredentials: false, // credentials require explicit origins
+		MaxAge:           300,
+	}))
+
+	promotionHandler := promotions.NewPromotionHandler(s.newPromotionService(), s.newAuditService())
+
+	api := s.App.Group("/user/v1/promotions", auth.JWTMiddleware())
+	api.Post("/quote", promotionHandler.QuoteHandler)
+
+	admin := s.App.Group("/admin/v1/promotions", auth.JWTMiddleware(), auth.RequireRole(auth.RoleOperator, auth.RoleAdmin))
+	admin.Get("/campaigns", promotionHandler.ListCampaignsHandler)
+	admin.Post("/campaigns", auth.RequireRole(auth.RoleAdmin), promotionHandler.CreateCampaignHandler)
+	admin.Get("/campaigns/:id", promotionHandler.GetCampaignHandler)
+	admin.Post("/campaigns/:id/status", promotionHandler.SetCampaignStatusHandler)
+	admin.Get("/campaigns/:id/vouchers", promotionHandler.ListVouchersHandler)
+	admin.Post("/campaigns/:id/vouchers", promotionHandler.CreateVouchersHandler)
+	admin.Get("/redemptions", promotionHandler.ListRedemptionsHandler)
+}
+
+func (s *FiberServer) LoyaltyFiberRoutes() {
+	s.App.Use(cors.New(cors.Config{
+		AllowOrigins:     "*",
+		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS,PATCH",
+		AllowHeaders:     "Accept,Authorization,Content-Type",
+		AllowCredentials: false, // credentials require explicit origins
+		MaxAge:           300,
+	}))
+
+	loyaltyHandler := loyalty.NewLoyaltyHandler(s.newLoyaltyService(), s.newAuditService())
+
+	api := s.App.Group("/user/v1/points", auth.JWTMiddleware())
+	api.Get("/", loyaltyHandler.GetPointsHandler)
+	api.Post("/redeem", auth.ActiveAccountMiddleware(auth.NewUserRepository(s.db)), loyaltyHandler.RedeemHandler)
+	api.Get("/rewards", loyaltyHandler.ListRewardsHandler)
+	api.Post("/rewards/:id/redeem", auth.ActiveAccountMiddleware(auth.NewUserRepository(s.db)), loyaltyHandler.RedeemRewardHandler)
+
+	admin := s.App.Group("/admin/v1/loyalty", auth.JWTMiddleware(), auth.RequireRole(auth.RoleOperator, auth.RoleAdmin))
+	admin.Get("/rules", loyaltyHandler.ListRulesHandler)
+	admin.Post("/rules", loyaltyHandler.CreateRuleHandler)
+	admin.Post("/rules/:id/end", loyaltyHandler.EndRuleHandler)
+	admin.Get("/rewards", loyaltyHandler.ListAllRewardsHandler)
+	admin.Post("/rewards", loyaltyHandler.CreateRewardHandler)
+	admin.Post("/rewards/:id/status", loyaltyHandler.SetRewardStatusHandler)
+}
+
+func (s *FiberServer) ReferralFiberRoutes() {
+	s.App.Use(cors.New(cors.Config{
+		AllowOrigins:     "*",
+		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS,PATCH",
+		AllowHeaders:     "Accept,Authorization,Content-Type",
+		AllowCredentials: false, // credentials require explicit origins
+		MaxAge:           300,
+	}))
+
+	referralHandler := referrals.NewReferralHandler(s.newReferralService(), s.newAuditService())
+
+	api := s.App.Group("/user/v1/referrals", auth.JWTMiddleware())
+	api.Get("/", referralHandler.GetReferralsHandler)
+
+	admin := s.App.Group("/admin/v1/referrals", auth.JWTMiddleware(), auth.RequireRole(auth.RoleOperator, auth.RoleAdmin))
+	admin.Get("/", referralHandler.ListHandler)
+	admin.Post("/:id/approve", referralHandler.ApproveHandler)
+	admin.Post("/:id/reject", referralHandler.RejectHandler)
+}
+
+// balanceExecutor membukukan dengan referensi approval sehingga eksekusi ulang
+// setelah crash tidak membukukan dua kali.
+func balanceExecutor(balanceService balance.BalanceService, walletTxType string) approvals.Executor {
+	return func(approval approvals.ApprovalRequest) error {
+		userID, err := approval.Payload.Uint("user_id")
+		if err != nil {
+			return err
+		}
+		amount, err := approval.Payload.Float("amount")
+		if err != nil {
+			return err
+		}
+		// Payload lama tanpa currency berarti DefaultCurrency.
+		currency, _ := approval.Payload.String("currency")
+		err = balanceService.ProcessBalanceTransaction(userID, currency, amount, walletTxType, approval.Reference())
+		if errors.Is(err, balance.ErrReferenceUsed) {
+			return nil
+		}
+		return err
+	}
 }
 
 func (s *FiberServer) HelloWorldHandler(c *fiber.Ctx) error {
@@ -3,13 +3,28 @@ package server
 import (
 	"github.com/gofiber/fiber/v2"
 
+	"ewallet-engine/internal/bank"
+	"ewallet-engine/internal/billers"
 	"ewallet-engine/internal/database"
+	"ewallet-engine/internal/fraud"
+	"ewallet-engine/internal/fx"
+	"ewallet-engine/internal/screening"
 )
 
 type FiberServer struct {
 	*fiber.App
 
 	db database.Service
+
+	// fraudEngine dibagi semua service agar rule hanya dimuat sekali.
+	fraudEngine *fraud.Engine
+	watchlist   *screening.Watchlist
+	fxProvider  fx.RateProvider
+
+	// bankConnector menyimpan state transfer sehingga harus satu instance.
+	bankConnector bank.BankConnector
+	// billerProvider sama seperti bankConnector, stub menyimpan state pembayaran.
+	billerProvider billers.BillerProvider
 }
 
 func New() *FiberServer {
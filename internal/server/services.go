@@ -0,0 +1,261 @@
+package server
+
+import (
+	"errors"
+	"ewallet-engine/internal/approvals"
+	"ewallet-engine/internal/audit"
+	"ewallet-engine/internal/auth"
+	"ewallet-engine/internal/balance"
+	"ewallet-engine/internal/bank"
+	"ewallet-engine/internal/billers"
+	"ewallet-engine/internal/billpayments"
+	"ewallet-engine/internal/disbursements"
+	"ewallet-engine/internal/disputes"
+	"ewallet-engine/internal/fees"
+	"ewallet-engine/internal/fraud"
+	"ewallet-engine/internal/fx"
+	"ewallet-engine/internal/kyc"
+	"ewallet-engine/internal/limits"
+	"ewallet-engine/internal/loyalty"
+	"ewallet-engine/internal/merchants"
+	"ewallet-engine/internal/notifications"
+	"ewallet-engine/internal/paymentrequests"
+	"ewallet-engine/internal/promotions"
+	"ewallet-engine/internal/qris"
+	"ewallet-engine/internal/referrals"
+	"ewallet-engine/internal/schedules"
+	"ewallet-engine/internal/screening"
+	"ewallet-engine/internal/settlements"
+	"ewallet-engine/internal/splitbills"
+	"ewallet-engine/internal/transactions"
+	"ewallet-engine/internal/withdrawals"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Factory service bersama supaya setiap grup route merakit dependensi yang sama.
+
+func (s *FiberServer) newAuditService() audit.AuditService {
+	return audit.NewAuditService(audit.NewAuditRepository(s.db.GetDB()))
+}
+
+func (s *FiberServer) newAuthService() auth.AuthService {
+	return auth.NewAuthService(auth.NewUserRepository(s.db), s.newScreeningService(), s.newReferralService())
+}
+
+func (s *FiberServer) newKYCService() kyc.KYCService {
+	storageDir := os.Getenv("KYC_STORAGE_DIR")
+	if storageDir == "" {
+		storageDir = "storage/kyc"
+	}
+	return kyc.NewKYCService(kyc.NewKYCRepository(s.db.GetDB()), kyc.NewLocalBlobStore(storageDir))
+}
+
+func (s *FiberServer) newLimitService() limits.LimitService {
+	return limits.NewLimitService(limits.NewLimitRepository(s.db), s.newKYCService().TierOf)
+}
+
+func (s *FiberServer) newBalanceService() balance.BalanceService {
+	return balance.NewBalanceService(balance.NewBalanceRepository(s.db.GetDB()), s.newLimitService(), s.newKYCService(), s.newFeeService())
+}
+
+func (s *FiberServer) newFeeService() fees.FeeService {
+	return fees.NewFeeService(fees.NewFeeRepository(s.db.GetDB()), s.newKYCService().TierOf)
+}
+
+func (s *FiberServer) newFraudService() fraud.FraudService {
+	if s.fraudEngine == nil {
+		rulesFile := os.Getenv("FRAUD_RULES_FILE")
+		if rulesFile == "" {
+			rulesFile = "config/fraud_rules.json"
+		}
+		s.fraudEngine = fraud.NewEngine(rulesFile)
+	}
+	return fraud.NewFraudService(fraud.NewFraudRepository(s.db.GetDB()), s.fraudEngine)
+}
+
+func (s *FiberServer) newScreeningService() screening.ScreeningService {
+	if s.watchlist == nil {
+		listsDir := os.Getenv("SCREENING_LISTS_DIR")
+		if listsDir == "" {
+			listsDir = "config/watchlists"
+		}
+
+		// Watchlist yang gagal dimuat tetap dipasang supaya bisa dimuat ulang;
+		// sampai itu berhasil, setiap screening ditolak.
+		watchlist, err := screening.NewWatchlist(listsDir)
+		if err != nil {
+			log.Printf("ERROR: Gagal memuat daftar screening dari %s, screening ditolak sampai daftar dimuat ulang: %v", listsDir, err)
+		}
+		s.watchlist = watchlist
+	}
+
+	threshold, _ := strconv.ParseFloat(os.Getenv("SCREENING_MATCH_THRESHOLD"), 64)
+	return screening.NewScreeningService(screening.NewScreeningRepository(s.db.GetDB()), s.watchlist, threshold)
+}
+
+func (s *FiberServer) newTransactionService() transactions.TransactionService {
+	return transactions.NewTransactionService(transactions.NewTransactionRepository(s.db.GetDB()), s.newLimitService(), s.newKYCService(), s.newFraudService(), s.newScreeningService(), s.newFeeService(), s.newPromotionService(), s.newLoyaltyService())
+}
+
+func (s *FiberServer) newFXService() fx.FXService {
+	if s.fxProvider == nil {
+		s.fxProvider = newRateProvider()
+	}
+	return fx.NewFXService(fx.NewFXRepository(s.db.GetDB()), s.fxProvider, s.newKYCService())
+}
+
+// newRateProvider memilih sumber kurs dari FX_RATE_SOURCE: "http" memakai
+// FX_RATES_URL, selain itu file FX_RATES_FILE.
+func newRateProvider() fx.RateProvider {
+	if os.Getenv("FX_RATE_SOURCE") == "http" {
+		cacheTTL := time.Minute
+		if seconds, err := strconv.Atoi(os.Getenv("FX_RATES_CACHE_SECONDS")); err == nil && seconds > 0 {
+			cacheTTL = time.Duration(seconds) * time.Second
+		}
+		return fx.NewHTTPRateProvider(os.Getenv("FX_RATES_URL"), cacheTTL)
+	}
+
+	ratesFile := os.Getenv("FX_RATES_FILE")
+	if ratesFile == "" {
+		ratesFile = "config/fx_rates.json"
+	}
+
+	return fx.NewStaticFileProvider(ratesFile)
+}
+
+func (s *FiberServer) newWithdrawalService() withdrawals.WithdrawalService {
+	return withdrawals.NewWithdrawalService(withdrawals.NewWithdrawalRepository(s.db.GetDB()), s.newBankConnector(), s.newLimitService(), s.newScreeningService(), s.newFeeService())
+}
+
+func (s *FiberServer) newDisbursementService() disbursements.DisbursementService {
+	return disbursements.NewDisbursementService(disbursements.NewDisbursementRepository(s.db.GetDB()), s.newApprovalService(), s.newKYCService())
+}
+
+// newApprovalService mendaftarkan executor untuk setiap jenis operasi. Service
+// disbursement di dalamnya memakai instance approval yang sama supaya batch di
+// bawah limit langsung dieksekusi saat diajukan.
+func (s *FiberServer) newApprovalService() approvals.ApprovalService {
+	balanceService := s.newBalanceService()
+	transactionService := s.newTransactionService()
+
+	approvalService := approvals.NewApprovalService(approvals.NewApprovalRepository(s.db.GetDB()))
+	approvalService.RegisterExecutor(approvals.OperationManualCredit, balanceExecutor(balanceService, "CREDIT"))
+	approvalService.RegisterExecutor(approvals.OperationManualDebit, balanceExecutor(balanceService, "DEBIT"))
+	approvalService.RegisterExecutor(approvals.OperationPayout, balanceExecutor(balanceService, "DEBIT"))
+	approvalService.RegisterExecutor(approvals.OperationReversal, func(approval approvals.ApprovalRequest) error {
+		reference, err := approval.Payload.String("reference")
+		if err != nil {
+			return err
+		}
+		err = transactionService.ReverseTransaction(reference)
+		if errors.Is(err, transactions.ErrAlreadyReversed) {
+			return nil
+		}
+		return err
+	})
+
+	disbursementService := disbursements.NewDisbursementService(disbursements.NewDisbursementRepository(s.db.GetDB()), approvalService, s.newKYCService())
+	approvalService.RegisterExecutor(approvals.OperationDisbursement, func(approval approvals.ApprovalRequest) error {
+		batchID, err := approval.Payload.String("batch_id")
+		if err != nil {
+			return err
+		}
+		_, err = disbursementService.ExecuteApproved(approval.Approver(), batchID)
+		return err
+	})
+	// Batch yang approval-nya ditolak, kedaluwarsa atau gagal bisa diajukan ulang.
+	approvalService.RegisterCloser(approvals.OperationDisbursement, func(approval approvals.ApprovalRequest) {
+		batchID, err := approval.Payload.String("batch_id")
+		if err != nil {
+			return
+		}
+		if _, err := disbursementService.Reopen(batchID); err != nil && !errors.Is(err, disbursements.ErrBatchNotPending) {
+			log.Printf("ERROR: Gagal membuka kembali batch %s: %v", batchID, err)
+		}
+	})
+	return approvalService
+}
+
+func (s *FiberServer) newScheduleService() schedules.ScheduleService {
+	return schedules.NewScheduleService(schedules.NewScheduleRepository(s.db.GetDB()), s.newTransactionService(), auth.NewUserRepository(s.db))
+}
+
+func (s *FiberServer) newNotificationService() notifications.NotificationService {
+	return notifications.NewNotificationService(notifications.NewNotificationRepository(s.db.GetDB()))
+}
+
+// newPaymentRequestService memasang listener split bill supaya permintaan
+// dana yang berasal dari share grup ikut tercatat lunas saat dibayar.
+func (s *FiberServer) newPaymentRequestService() paymentrequests.PaymentRequestService {
+	service := paymentrequests.NewPaymentRequestService(paymentrequests.NewPaymentRequestRepository(s.db.GetDB()), s.newTransactionService(), s.newAuthService(), s.newNotificationService())
+	splitBills := splitbills.NewSplitBillService(splitbills.NewSplitBillRepository(s.db.GetDB()), s.newTransactionService(), service, s.newAuthService())
+	service.SetPaidListener(splitBills.OnPaymentRequestPaid)
+	return service
+}
+
+func (s *FiberServer) newSplitBillService() splitbills.SplitBillService {
+	return splitbills.NewSplitBillService(splitbills.NewSplitBillRepository(s.db.GetDB()), s.newTransactionService(), s.newPaymentRequestService(), s.newAuthService())
+}
+
+func (s *FiberServer) newQRISService() qris.QRISService {
+	return qris.NewQRISService(s.newTransactionService())
+}
+
+func (s *FiberServer) newMerchantService() merchants.MerchantService {
+	return merchants.NewMerchantService(merchants.NewMerchantRepository(s.db.GetDB()), s.newTransactionService(), s.newAuthService())
+}
+
+func (s *FiberServer) newSettlementService() settlements.SettlementService {
+	return settlements.NewSettlementService(settlements.NewSettlementRepository(s.db.GetDB()), s.newMerchantService(), s.newBankConnector(), s.newFeeService(), s.newNotificationService())
+}
+
+func (s *FiberServer) newDisputeService() disputes.DisputeService {
+	storageDir := os.Getenv("DISPUTE_STORAGE_DIR")
+	if storageDir == "" {
+		storageDir = "storage/disputes"
+	}
+	return disputes.NewDisputeService(disputes.NewDisputeRepository(s.db.GetDB()), s.newTransactionService(), s.newMerchantService(), kyc.NewLocalBlobStore(storageDir), s.newNotificationService())
+}
+
+// newBankConnector mengembalikan simulator bank sampai connector produksi tersedia.
+func (s *FiberServer) newBankConnector() bank.BankConnector {
+	if s.bankConnector == nil {
+		latency := 200 * time.Millisecond
+		if ms, err := strconv.Atoi(os.Getenv("BANK_SIMULATOR_LATENCY_MS")); err == nil && ms >= 0 {
+			latency = time.Duration(ms) * time.Millisecond
+		}
+		s.bankConnector = bank.NewSimulator(latency)
+	}
+	return s.bankConnector
+}
+
+func (s *FiberServer) newBillPaymentService() billpayments.BillPaymentService {
+	return billpayments.NewBillPaymentService(billpayments.NewBillPaymentRepository(s.db.GetDB()), s.newBillerProvider(), s.newAuthService(), s.newLimitService(), s.newFeeService(), s.newNotificationService())
+}
+
+// newBillerProvider mengembalikan stub biller sampai aggregator produksi tersedia.
+func (s *FiberServer) newBillerProvider() billers.BillerProvider {
+	if s.billerProvider == nil {
+		latency := 200 * time.Millisecond
+		if ms, err := strconv.Atoi(os.Getenv("BILLER_STUB_LATENCY_MS")); err == nil && ms >= 0 {
+			latency = time.Duration(ms) * time.Millisecond
+		}
+		s.billerProvider = billers.NewStub(latency)
+	}
+	return s.billerProvider
+}
+
+func (s *FiberServer) newPromotionService() promotions.PromotionService {
+	return promotions.NewPromotionService(promotions.NewPromotionRepository(s.db.GetDB()), s.newKYCService(), s.newNotificationService())
+}
+
+func (s *FiberServer) newLoyaltyService() loyalty.LoyaltyService {
+	return loyalty.NewLoyaltyService(loyalty.NewLoyaltyRepository(s.db.GetDB()), s.newPromotionService(), s.newKYCService(), s.newNotificationService())
+}
+
+func (s *FiberServer) newReferralService() referrals.ReferralService {
+	return referrals.NewReferralService(referrals.NewReferralRepository(s.db.GetDB()), s.newKYCService(), s.newNotificationService())
+}
@@ -0,0 +1,94 @@
+package settlements
+
+import (
+	"bytes"
+	"encoding/csv"
+	"ewallet-engine/internal/balance"
+	"ewallet-engine/internal/transactions"
+	"strconv"
+	"time"
+)
+
+// cutoffBefore mengembalikan cut-off harian terakhir (pukul hour di loc) yang
+// tidak melewati now.
+func cutoffBefore(now time.Time, hour int, loc *time.Location) time.Time {
+	local := now.In(loc)
+	cutoff := time.Date(local.Year(), local.Month(), local.Day(), hour, 0, 0, 0, loc)
+	if cutoff.After(local) {
+		cutoff = cutoff.AddDate(0, 0, -1)
+	}
+	return cutoff
+}
+
+// buildItems mengubah transaksi merchant menjadi line item dan mengisi total
+// batch. feeFor menghitung MDR untuk satu pembayaran; refund tidak dikenai
+// biaya dan MDR pembayaran yang di-refund tidak dikembalikan.
+func buildItems(batch *SettlementBatch, rows []transactions.Transaction, feeFor func(transactions.Transaction) (float64, error)) ([]SettlementItem, error) {
+	currency := batch.Currency
+	items := make([]SettlementItem, 0, len(rows))
+	var gross, refunds, fees float64
+
+	for _, row := range rows {
+		item := SettlementItem{
+			TransactionReference: row.Reference,
+			CustomerUserID:       row.UserID,
+			Amount:               row.Amount,
+			OccurredAt:           row.CreatedAt,
+		}
+		if row.TransactionType == transactions.TransactionRefund {
+			item.ItemType = ItemRefund
+			item.OriginalReference = row.OriginalReference
+			item.NetAmount = -row.Amount
+			refunds += row.Amount
+			batch.RefundCount++
+		} else {
+			fee, err := feeFor(row)
+			if err != nil {
+				return nil, err
+			}
+			item.ItemType = ItemPayment
+			item.Fee = fee
+			item.NetAmount = balance.RoundAmount(row.Amount-fee, currency)
+			gross += row.Amount
+			fees += fee
+			batch.PaymentCount++
+		}
+		items = append(items, item)
+	}
+
+	batch.GrossAmount = balance.RoundAmount(gross, currency)
+	batch.RefundAmount = balance.RoundAmount(refunds, currency)
+	batch.FeeAmount = balance.RoundAmount(fees, currency)
+	batch.NetAmount = balance.RoundAmount(gross-refunds-fees, currency)
+	return items, nil
+}
+
+// writeReport menghasilkan laporan settlement per line item, diakhiri baris
+// TOTAL yang jumlahnya sama dengan FeeAmount dan NetAmount batch.
+func writeReport(batch *SettlementBatch, items []SettlementItem) ([]byte, error) {
+	digits := balance.MinorUnits(batch.Currency)
+	format := func(amount float64) string {
+		return strconv.FormatFloat(amount, 'f', digits, 64)
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	_ = writer.Write([]string{"settlement_reference", "item_type", "transaction_reference", "original_reference", "customer_user_id", "occurred_at", "amount", "fee", "net_amount", "currency"})
+	for _, item := range items {
+		_ = writer.Write([]string{
+			batch.Reference,
+			string(item.ItemType),
+			item.TransactionReference,
+			item.OriginalReference,
+			strconv.FormatUint(uint64(item.CustomerUserID), 10),
+			item.OccurredAt.Format(time.RFC3339),
+			format(item.Amount),
+			format(item.Fee),
+			format(item.NetAmount),
+			batch.Currency,
+		})
+	}
+	_ = writer.Write([]string{batch.Reference, "TOTAL", "", "", "", batch.PeriodEnd.Format(time.RFC3339), format(batch.GrossAmount - batch.RefundAmount), format(batch.FeeAmount), format(batch.NetAmount), batch.Currency})
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
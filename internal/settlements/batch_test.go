@@ -0,0 +1,78 @@
+package settlements
+
+import (
+	"encoding/csv"
+	"ewallet-engine/internal/transactions"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCutoffBefore(t *testing.T) {
+	cases := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{time.Date(2026, 3, 10, 8, 30, 0, 0, wib), time.Date(2026, 3, 10, 6, 0, 0, 0, wib)},
+		{time.Date(2026, 3, 10, 5, 59, 0, 0, wib), time.Date(2026, 3, 9, 6, 0, 0, 0, wib)},
+		{time.Date(2026, 3, 10, 6, 0, 0, 0, wib), time.Date(2026, 3, 10, 6, 0, 0, 0, wib)},
+		// 23:30 UTC sudah tanggal berikutnya di WIB.
+		{time.Date(2026, 3, 10, 23, 30, 0, 0, time.UTC), time.Date(2026, 3, 11, 6, 0, 0, 0, wib)},
+	}
+	for _, tc := range cases {
+		if got := cutoffBefore(tc.now, 6, wib); !got.Equal(tc.want) {
+			t.Errorf("cutoffBefore(%v) = %v, want %v", tc.now, got, tc.want)
+		}
+	}
+}
+
+func TestBuildItemsAndReport(t *testing.T) {
+	occurred := time.Date(2026, 3, 9, 10, 0, 0, 0, wib)
+	rows := []transactions.Transaction{
+		{UserID: 7, Reference: "PI-1", Amount: 100000, TransactionType: transactions.TransactionPurchase, CreatedAt: occurred},
+		{UserID: 8, Reference: "PI-2", Amount: 50000, TransactionType: transactions.TransactionPurchase, CreatedAt: occurred},
+		{UserID: 7, Reference: "PIR-1", OriginalReference: "PI-1", Amount: 25000, TransactionType: transactions.TransactionRefund, CreatedAt: occurred},
+	}
+	batch := &SettlementBatch{Reference: "STL-X", Currency: "IDR", PeriodEnd: occurred}
+
+	// MDR 0,7% dibulatkan ke rupiah.
+	items, err := buildItems(batch, rows, func(row transactions.Transaction) (float64, error) {
+		return float64(int(row.Amount*0.007 + 0.5)), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batch.PaymentCount != 2 || batch.RefundCount != 1 {
+		t.Fatalf("counts = %d/%d", batch.PaymentCount, batch.RefundCount)
+	}
+	if batch.GrossAmount != 150000 || batch.RefundAmount != 25000 || batch.FeeAmount != 1050 || batch.NetAmount != 123950 {
+		t.Fatalf("totals = %+v", batch)
+	}
+
+	var net float64
+	for _, item := range items {
+		net += item.NetAmount
+	}
+	if net != batch.NetAmount {
+		t.Fatalf("jumlah net item %v != net batch %v", net, batch.NetAmount)
+	}
+	if items[2].ItemType != ItemRefund || items[2].NetAmount != -25000 || items[2].Fee != 0 {
+		t.Fatalf("refund item = %+v", items[2])
+	}
+
+	data, err := writeReport(batch, items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 5 {
+		t.Fatalf("jumlah baris = %d", len(records))
+	}
+	total := records[4]
+	if total[1] != "TOTAL" || total[6] != "125000" || total[7] != "1050" || total[8] != "123950" {
+		t.Fatalf("baris total = %v", total)
+	}
+}
@@ -0,0 +1,260 @@
+package settlements
+
+import (
+	"errors"
+	"ewallet-engine/internal/audit"
+	"ewallet-engine/internal/bank"
+	"ewallet-engine/internal/merchants"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SettlementHandler struct {
+	service      SettlementService
+	auditService audit.AuditService
+}
+
+func NewSettlementHandler(service SettlementService, auditService audit.AuditService) *SettlementHandler {
+	return &SettlementHandler{service: service, auditService: auditService}
+}
+
+// --- Admin ---
+
+func (h *SettlementHandler) SetAccountHandler(c *fiber.Ctx) error {
+	merchantID, err := c.ParamsInt("merchant_id")
+	if err != nil || merchantID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	var request AccountRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	account, err := h.service.SetAccount(uint(merchantID), request)
+	if err != nil {
+		return h.settlementError(c, err)
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionSettlementAccountSet,
+		TargetType: "merchant",
+		TargetID:   fmt.Sprint(account.MerchantID),
+		After: audit.Snapshot{
+			"bank_code":      account.BankCode,
+			"account_number": account.AccountNumber,
+			"account_name":   account.AccountName,
+		},
+	})
+
+	return c.JSON(fiber.Map{"message": "Rekening settlement disimpan", "data": account})
+}
+
+func (h *SettlementHandler) GetAccountHandler(c *fiber.Ctx) error {
+	merchantID, err := c.ParamsInt("merchant_id")
+	if err != nil || merchantID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	account, err := h.service.GetAccount(uint(merchantID))
+	if err != nil {
+		return h.settlementError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": account})
+}
+
+// RunHandler menjalankan settlement secara manual. Tanpa merchant_id semua
+// merchant ACTIVE diproses; tanpa cutoff dipakai cut-off terakhir.
+func (h *SettlementHandler) RunHandler(c *fiber.Ctx) error {
+	var request RunRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+		}
+	}
+
+	cutoff := h.service.LastCutoff(time.Now())
+	if request.Cutoff != "" {
+		parsed, err := time.Parse(time.RFC3339, request.Cutoff)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "cutoff harus berformat RFC3339"})
+		}
+		cutoff = parsed
+	}
+
+	if request.MerchantID == 0 {
+		created, err := h.service.Run(cutoff)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+		}
+
+		_ = h.auditService.Record(audit.Entry{
+			Meta:       audit.FromContext(c),
+			Action:     audit.ActionSettlementRun,
+			TargetType: "settlement",
+			TargetID:   cutoff.Format(time.RFC3339),
+			After:      audit.Snapshot{"batches_created": created},
+		})
+
+		return c.JSON(fiber.Map{
+			"message": fmt.Sprintf("%d batch settlement dibuat", created),
+			"data":    fiber.Map{"cutoff": cutoff, "batches_created": created},
+		})
+	}
+
+	batch, err := h.service.SettleMerchant(request.MerchantID, cutoff)
+	if err != nil {
+		return h.settlementError(c, err)
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionSettlementRun,
+		TargetType: "settlement",
+		TargetID:   batch.Reference,
+		After:      batchSnapshot(batch),
+	})
+
+	return c.JSON(fiber.Map{"message": "Batch settlement diproses", "data": batch})
+}
+
+func (h *SettlementHandler) ListHandler(c *fiber.Ctx) error {
+	batches, err := h.service.ListBatches(uint(c.QueryInt("merchant_id", 0)), BatchStatus(c.Query("status")))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": batches})
+}
+
+func (h *SettlementHandler) GetHandler(c *fiber.Ctx) error {
+	batch, err := h.service.GetBatch(merchantScope(c), c.Params("reference"))
+	if err != nil {
+		return h.settlementError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": batch})
+}
+
+func (h *SettlementHandler) ItemsHandler(c *fiber.Ctx) error {
+	items, err := h.service.ListItems(merchantScope(c), c.Params("reference"))
+	if err != nil {
+		return h.settlementError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": items})
+}
+
+func (h *SettlementHandler) ReportHandler(c *fiber.Ctx) error {
+	reference := c.Params("reference")
+
+	data, err := h.service.Report(merchantScope(c), reference)
+	if err != nil {
+		return h.settlementError(c, err)
+	}
+
+	c.Set(fiber.HeaderContentType, "text/csv")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+reference+`.csv"`)
+	return c.Send(data)
+}
+
+func (h *SettlementHandler) RetryHandler(c *fiber.Ctx) error {
+	reference := c.Params("reference")
+
+	before, err := h.service.GetBatch(0, reference)
+	if err != nil {
+		return h.settlementError(c, err)
+	}
+
+	after, err := h.service.Retry(reference)
+	if err != nil {
+		return h.settlementError(c, err)
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionSettlementRetried,
+		TargetType: "settlement",
+		TargetID:   reference,
+		Before:     batchSnapshot(before),
+		After:      batchSnapshot(after),
+	})
+
+	return c.JSON(fiber.Map{"message": "Pencairan settlement dicoba ulang", "data": after})
+}
+
+func (h *SettlementHandler) ReconcileHandler(c *fiber.Ctx) error {
+	reference := c.Params("reference")
+
+	before, err := h.service.GetBatch(0, reference)
+	if err != nil {
+		return h.settlementError(c, err)
+	}
+
+	after, err := h.service.Reconcile(reference)
+	if err != nil {
+		return h.settlementError(c, err)
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionSettlementReconciled,
+		TargetType: "settlement",
+		TargetID:   reference,
+		Before:     batchSnapshot(before),
+		After:      batchSnapshot(after),
+	})
+
+	return c.JSON(fiber.Map{"message": "Rekonsiliasi settlement selesai", "data": after})
+}
+
+// --- Merchant API ---
+
+func (h *SettlementHandler) MerchantListHandler(c *fiber.Ctx) error {
+	merchantID := c.Locals("merchant_id").(uint)
+
+	batches, err := h.service.ListBatches(merchantID, BatchStatus(c.Query("status")))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": batches})
+}
+
+// merchantScope membatasi pencarian batch ke merchant pemilik API key; untuk
+// route admin Locals merchant_id tidak diisi sehingga semua batch terlihat.
+func merchantScope(c *fiber.Ctx) uint {
+	merchantID, _ := c.Locals("merchant_id").(uint)
+	return merchantID
+}
+
+func (h *SettlementHandler) settlementError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, ErrBatchNotFound), errors.Is(err, ErrAccountNotFound), errors.Is(err, merchants.ErrMerchantNotFound), errors.Is(err, bank.ErrAccountNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, ErrBatchNotRetrying):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, ErrNothingToSettle), errors.Is(err, ErrNonPositiveNet):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, bank.ErrConnectorTimeout):
+		return c.Status(fiber.StatusGatewayTimeout).JSON(fiber.Map{"message": err.Error()})
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+}
+
+func batchSnapshot(batch *SettlementBatch) audit.Snapshot {
+	return audit.Snapshot{
+		"merchant_id":      batch.MerchantID,
+		"status":           batch.Status,
+		"net_amount":       batch.NetAmount,
+		"fee_amount":       batch.FeeAmount,
+		"currency":         batch.Currency,
+		"attempts":         batch.Attempts,
+		"payout_reference": batch.PayoutReference,
+	}
+}
@@ -0,0 +1,106 @@
+package settlements
+
+import "time"
+
+// SettlementAccount adalah rekening bank tujuan pencairan merchant.
+// AccountName selalu berasal dari name inquiry bank.
+type SettlementAccount struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	MerchantID    uint      `gorm:"not null;uniqueIndex" json:"merchant_id"`
+	BankCode      string    `gorm:"type:varchar(20);not null" json:"bank_code"`
+	AccountNumber string    `gorm:"type:varchar(30);not null" json:"account_number"`
+	AccountName   string    `gorm:"type:varchar(150);not null" json:"account_name"`
+	VerifiedAt    time.Time `gorm:"not null" json:"verified_at"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+type BatchStatus string
+
+const (
+	// BatchPending: batch dan line item sudah dibuat, dana belum di-hold.
+	BatchPending BatchStatus = "PENDING"
+	// BatchProcessing: dana di-hold dan transfer sudah dikirim ke bank.
+	BatchProcessing BatchStatus = "PROCESSING"
+	// BatchUnknown: connector error atau timeout; hasil dipastikan oleh rekonsiliasi.
+	BatchUnknown BatchStatus = "UNKNOWN"
+	BatchPaid    BatchStatus = "PAID"
+	// BatchFailed: pencairan gagal dan hold dilepas; batch dapat dicoba ulang.
+	BatchFailed BatchStatus = "FAILED"
+)
+
+// SettlementBatch merangkum transaksi merchant sampai satu cut-off. Setiap
+// transaksi hanya masuk ke satu batch; transaksi yang baru berhasil setelah
+// cut-off ikut batch berikutnya.
+//
+// NetAmount = GrossAmount - RefundAmount - FeeAmount dan itulah yang dikirim
+// ke rekening merchant. Wallet settlement didebit NetAmount ditambah FeeAmount
+// sebagai baris biaya; refund sudah didebit saat refund diproses.
+type SettlementBatch struct {
+	ID              uint        `gorm:"primaryKey" json:"id"`
+	Reference       string      `gorm:"type:varchar(100);uniqueIndex;not null" json:"reference"`
+	MerchantID      uint        `gorm:"not null;uniqueIndex:idx_settlement_period" json:"merchant_id"`
+	Currency        string      `gorm:"type:char(3);not null;default:'IDR'" json:"currency"`
+	PeriodStart     *time.Time  `json:"period_start,omitempty"`
+	PeriodEnd       time.Time   `gorm:"not null;uniqueIndex:idx_settlement_period" json:"period_end"`
+	PaymentCount    int         `gorm:"not null;default:0" json:"payment_count"`
+	RefundCount     int         `gorm:"not null;default:0" json:"refund_count"`
+	GrossAmount     float64     `gorm:"not null;default:0" json:"gross_amount"`
+	RefundAmount    float64     `gorm:"not null;default:0" json:"refund_amount"`
+	FeeAmount       float64     `gorm:"not null;default:0" json:"fee_amount"`
+	NetAmount       float64     `gorm:"not null;default:0" json:"net_amount"`
+	Status          BatchStatus `gorm:"type:enum('PENDING','PROCESSING','UNKNOWN','PAID','FAILED');default:'PENDING';index" json:"status"`
+	Attempts        int         `gorm:"not null;default:0" json:"attempts"`
+	PayoutReference string      `gorm:"type:varchar(120)" json:"payout_reference,omitempty"`
+	BankCode        string      `gorm:"type:varchar(20)" json:"bank_code,omitempty"`
+	AccountNumber   string      `gorm:"type:varchar(30)" json:"account_number,omitempty"`
+	AccountName     string      `gorm:"type:varchar(150)" json:"account_name,omitempty"`
+	Connector       string      `gorm:"type:varchar(30)" json:"connector,omitempty"`
+	ExternalID      string      `gorm:"type:varchar(100)" json:"external_id,omitempty"`
+	FailureReason   string      `gorm:"type:varchar(255)" json:"failure_reason,omitempty"`
+	SentAt          *time.Time  `json:"sent_at,omitempty"`
+	CheckAttempts   int         `gorm:"not null;default:0" json:"check_attempts"`
+	LastCheckedAt   *time.Time  `json:"last_checked_at,omitempty"`
+	PaidAt          *time.Time  `json:"paid_at,omitempty"`
+	CreatedAt       time.Time   `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time   `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// IsFinal melaporkan apakah pencairan batch sudah selesai.
+func (b SettlementBatch) IsFinal() bool {
+	return b.Status == BatchPaid || b.Status == BatchFailed
+}
+
+type ItemType string
+
+const (
+	ItemPayment ItemType = "PAYMENT"
+	ItemRefund  ItemType = "REFUND"
+)
+
+// SettlementItem adalah satu transaksi dalam batch. NetAmount bernilai
+// negatif untuk refund.
+type SettlementItem struct {
+	ID                   uint      `gorm:"primaryKey" json:"id"`
+	BatchID              uint      `gorm:"not null;index" json:"batch_id"`
+	ItemType             ItemType  `gorm:"type:enum('PAYMENT','REFUND');not null" json:"item_type"`
+	TransactionReference string    `gorm:"type:varchar(255);uniqueIndex;not null" json:"transaction_reference"`
+	OriginalReference    string    `gorm:"type:varchar(255)" json:"original_reference,omitempty"`
+	CustomerUserID       uint      `gorm:"not null" json:"customer_user_id"`
+	Amount               float64   `gorm:"not null" json:"amount"`
+	Fee                  float64   `gorm:"not null;default:0" json:"fee"`
+	NetAmount            float64   `gorm:"not null" json:"net_amount"`
+	OccurredAt           time.Time `gorm:"not null" json:"occurred_at"`
+	CreatedAt            time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type AccountRequest struct {
+	BankCode      string `json:"bank_code"`
+	AccountNumber string `json:"account_number"`
+	ExpectedName  string `json:"expected_name"`
+}
+
+type RunRequest struct {
+	MerchantID uint   `json:"merchant_id"`
+	Cutoff     string `json:"cutoff"`
+}
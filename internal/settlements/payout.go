@@ -0,0 +1,114 @@
+package settlements
+
+import (
+	"ewallet-engine/internal/balance"
+	"ewallet-engine/internal/merchants"
+	"ewallet-engine/internal/notifications"
+	"ewallet-engine/internal/payouts"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+)
+
+var payoutStates = map[BatchStatus]payouts.State{
+	BatchPending:    payouts.StatePending,
+	BatchProcessing: payouts.StateProcessing,
+	BatchUnknown:    payouts.StateUnknown,
+	BatchPaid:       payouts.StateSucceeded,
+	BatchFailed:     payouts.StateFailed,
+}
+
+// batchPayout menjalankan pencairan SettlementBatch lewat payouts.Machine.
+// Hold memakai PayoutReference sehingga setiap percobaan punya hold sendiri.
+type batchPayout struct {
+	service  *settlementService
+	merchant *merchants.Merchant
+	batch    *SettlementBatch
+}
+
+func (p *batchPayout) Reference() string {
+	return p.batch.Reference
+}
+
+func (p *batchPayout) State() payouts.State {
+	return payoutStates[p.batch.Status]
+}
+
+func (p *batchPayout) Transition(to payouts.State, outcome payouts.Outcome) (bool, error) {
+	next := *p.batch
+	now := time.Now()
+	switch to {
+	case payouts.StateProcessing:
+		next.Status = BatchProcessing
+		if p.batch.Status == BatchPending {
+			next.SentAt = &now
+		}
+		if outcome.ExternalID != "" {
+			next.ExternalID = outcome.ExternalID
+		}
+	case payouts.StateUnknown:
+		next.Status = BatchUnknown
+		next.FailureReason = outcome.FailureReason
+	case payouts.StateSucceeded:
+		next.Status = BatchPaid
+		next.ExternalID = outcome.ExternalID
+		next.FailureReason = ""
+		next.PaidAt = &now
+	case payouts.StateFailed:
+		next.Status = BatchFailed
+		next.FailureReason = outcome.FailureReason
+	}
+
+	ok, err := p.service.repo.TransitionStatus(p.batch.ID, p.batch.Status, map[string]interface{}{
+		"status":         next.Status,
+		"external_id":    next.ExternalID,
+		"failure_reason": next.FailureReason,
+		"sent_at":        next.SentAt,
+		"paid_at":        next.PaidAt,
+	})
+	if err == nil && ok {
+		*p.batch = next
+	}
+	return ok, err
+}
+
+func (p *batchPayout) CaptureHold() error {
+	return p.service.repo.CaptureHold(p.merchant.SettlementUserID, p.batch.PayoutReference, p.batch.NetAmount, p.batch.FeeAmount)
+}
+
+func (p *batchPayout) ReleaseHold() error {
+	return p.service.repo.ReleaseHold(p.merchant.SettlementUserID, p.batch.PayoutReference)
+}
+
+func (p *batchPayout) Succeeded() {
+	batch, merchant := p.batch, p.merchant
+	log.Printf("SUCCESS: Settlement %s sebesar %.2f %s ke %s %s selesai", batch.Reference, batch.NetAmount, batch.Currency, batch.BankCode, batch.AccountNumber)
+
+	p.service.notifier.Notify(merchant.OwnerUserID, NotificationPaid, "Dana settlement telah dicairkan",
+		fmt.Sprintf("Settlement %s untuk %s sebesar %s %s telah dikirim ke rekening %s %s.",
+			batch.Reference, merchant.Name, strconv.FormatFloat(batch.NetAmount, 'f', balance.MinorUnits(batch.Currency), 64), batch.Currency, batch.BankCode, batch.AccountNumber),
+		notifications.Data{"settlement_reference": batch.Reference, "merchant_id": merchant.ID})
+}
+
+func (p *batchPayout) Failed(held bool) {
+	log.Printf("ALERT: Pencairan settlement %s gagal: %s", p.batch.Reference, p.batch.FailureReason)
+}
+
+func (p *batchPayout) MarkChecked(at time.Time) (int, error) {
+	if err := p.service.repo.MarkChecked(p.batch.ID, at); err != nil {
+		return 0, err
+	}
+	p.batch.CheckAttempts++
+	p.batch.LastCheckedAt = &at
+	return p.batch.CheckAttempts, nil
+}
+
+// SentAt kosong berarti transfer tidak pernah dikirim, sehingga tidak ada masa
+// tenggang yang perlu ditunggu.
+func (p *batchPayout) SentAt() time.Time {
+	if p.batch.SentAt == nil {
+		return time.Time{}
+	}
+	return *p.batch.SentAt
+}
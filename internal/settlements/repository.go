@@ -0,0 +1,164 @@
+package settlements
+
+import (
+	"ewallet-engine/internal/balance"
+	"ewallet-engine/internal/transactions"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SettlementRepository interface {
+	SaveAccount(account *SettlementAccount) error
+	FindAccount(merchantID uint) (*SettlementAccount, error)
+
+	// UnsettledTransactions mengembalikan PURCHASE berhasil dan REFUND berhasil
+	// milik wallet settlement yang dibuat sebelum cutoff dan belum masuk batch mana pun.
+	UnsettledTransactions(settlementUserID uint, currency string, cutoff time.Time) ([]transactions.Transaction, error)
+	CreateBatch(batch *SettlementBatch, items []SettlementItem) error
+	FindBatch(reference string) (*SettlementBatch, error)
+	FindBatchByPeriod(merchantID uint, periodEnd time.Time) (*SettlementBatch, error)
+	LastBatch(merchantID uint) (*SettlementBatch, error)
+	ListBatches(merchantID uint, status BatchStatus, limit int) ([]SettlementBatch, error)
+	ListItems(batchID uint) ([]SettlementItem, error)
+	FindUnresolved(checkedBefore time.Time, limit int) ([]SettlementBatch, error)
+	TransitionStatus(id uint, from BatchStatus, updates map[string]interface{}) (bool, error)
+	MarkChecked(id uint, at time.Time) error
+
+	PlaceHold(userID uint, currency string, amount float64, reference string, expiresAt time.Time) error
+	CaptureHold(userID uint, reference string, amount float64, fee float64) error
+	ReleaseHold(userID uint, reference string) error
+}
+
+type settlementRepository struct {
+	DB *gorm.DB
+}
+
+func NewSettlementRepository(db *gorm.DB) SettlementRepository {
+	return &settlementRepository{DB: db}
+}
+
+func (r *settlementRepository) SaveAccount(account *SettlementAccount) error {
+	return r.DB.Save(account).Error
+}
+
+func (r *settlementRepository) FindAccount(merchantID uint) (*SettlementAccount, error) {
+	var account SettlementAccount
+	if err := r.DB.Where("merchant_id = ?", merchantID).First(&account).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *settlementRepository) UnsettledTransactions(settlementUserID uint, currency string, cutoff time.Time) ([]transactions.Transaction, error) {
+	var rows []transactions.Transaction
+	err := r.DB.
+		Where("counterparty_user_id = ? AND currency = ? AND created_at < ?", settlementUserID, currency, cutoff).
+		Where("(transaction_type = ? AND transaction_status IN ?) OR (transaction_type = ? AND transaction_status = ?)",
+			transactions.TransactionPurchase,
+			[]transactions.TransactionStatus{transactions.StatusSuccess, transactions.StatusPartiallyRefunded, transactions.StatusRefunded},
+			transactions.TransactionRefund, transactions.StatusSuccess).
+		Where("reference NOT IN (?)", r.DB.Model(&SettlementItem{}).Select("transaction_reference")).
+		Order("id ASC").Find(&rows).Error
+	return rows, err
+}
+
+// CreateBatch menyimpan batch beserta line item-nya. Unique index pada
+// transaction_reference menggagalkan seluruh batch jika ada transaksi yang
+// sudah diklaim batch lain secara bersamaan.
+func (r *settlementRepository) CreateBatch(batch *SettlementBatch, items []SettlementItem) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(batch).Error; err != nil {
+			return err
+		}
+		for i := range items {
+			items[i].BatchID = batch.ID
+		}
+		return tx.CreateInBatches(items, 200).Error
+	})
+}
+
+func (r *settlementRepository) FindBatch(reference string) (*SettlementBatch, error) {
+	var batch SettlementBatch
+	if err := r.DB.Where("reference = ?", reference).First(&batch).Error; err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+func (r *settlementRepository) FindBatchByPeriod(merchantID uint, periodEnd time.Time) (*SettlementBatch, error) {
+	var batch SettlementBatch
+	if err := r.DB.Where("merchant_id = ? AND period_end = ?", merchantID, periodEnd).First(&batch).Error; err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+func (r *settlementRepository) LastBatch(merchantID uint) (*SettlementBatch, error) {
+	var batch SettlementBatch
+	if err := r.DB.Where("merchant_id = ?", merchantID).Order("period_end DESC").First(&batch).Error; err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+func (r *settlementRepository) ListBatches(merchantID uint, status BatchStatus, limit int) ([]SettlementBatch, error) {
+	var batches []SettlementBatch
+	query := r.DB.Model(&SettlementBatch{})
+	if merchantID != 0 {
+		query = query.Where("merchant_id = ?", merchantID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("period_end DESC, id DESC").Limit(limit).Find(&batches).Error
+	return batches, err
+}
+
+func (r *settlementRepository) ListItems(batchID uint) ([]SettlementItem, error) {
+	var items []SettlementItem
+	err := r.DB.Where("batch_id = ?", batchID).Order("occurred_at ASC, id ASC").Find(&items).Error
+	return items, err
+}
+
+func (r *settlementRepository) FindUnresolved(checkedBefore time.Time, limit int) ([]SettlementBatch, error) {
+	var batches []SettlementBatch
+	err := r.DB.
+		Where("status IN ?", []BatchStatus{BatchProcessing, BatchUnknown}).
+		Where("last_checked_at IS NULL OR last_checked_at < ?", checkedBefore).
+		Order("id ASC").Limit(limit).Find(&batches).Error
+	return batches, err
+}
+
+func (r *settlementRepository) TransitionStatus(id uint, from BatchStatus, updates map[string]interface{}) (bool, error) {
+	result := r.DB.Model(&SettlementBatch{}).Where("id = ? AND status = ?", id, from).Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *settlementRepository) MarkChecked(id uint, at time.Time) error {
+	return r.DB.Model(&SettlementBatch{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"last_checked_at": at,
+		"check_attempts":  gorm.Expr("check_attempts + 1"),
+	}).Error
+}
+
+func (r *settlementRepository) PlaceHold(userID uint, currency string, amount float64, reference string, expiresAt time.Time) error {
+	wallet, err := balance.FindUserWallet(r.DB, userID, currency, false)
+	if err != nil {
+		return err
+	}
+	_, err = balance.PlaceHold(r.DB, wallet.ID, amount, reference, expiresAt)
+	return err
+}
+
+func (r *settlementRepository) CaptureHold(userID uint, reference string, amount float64, fee float64) error {
+	_, err := balance.CaptureHold(r.DB, userID, reference, amount, fee)
+	return err
+}
+
+func (r *settlementRepository) ReleaseHold(userID uint, reference string) error {
+	return balance.ReleaseHold(r.DB, userID, reference, balance.HoldReleased)
+}
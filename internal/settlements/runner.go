@@ -0,0 +1,55 @@
+package settlements
+
+import (
+	"context"
+	"ewallet-engine/internal/schedules"
+	"log"
+	"time"
+)
+
+const (
+	runnerLockKey   = "scheduler:settlements:lock"
+	runnerLockLease = 10 * time.Minute
+)
+
+// StartRunner setiap interval membuat batch untuk cut-off terakhir dan
+// merekonsiliasi pencairan yang belum final, sampai ctx dibatalkan. Batch per
+// merchant per cut-off bersifat unik, jadi putaran berikutnya setelah batch
+// dibuat tidak mengulang apa pun.
+func StartRunner(ctx context.Context, service SettlementService, locker schedules.Locker, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			unlock, ok, err := locker.TryLock(ctx, runnerLockKey, runnerLockLease)
+			if err != nil {
+				log.Printf("ERROR: Gagal mengambil lock settlement: %v", err)
+				continue
+			}
+			if !ok {
+				continue
+			}
+
+			created, err := service.Run(service.LastCutoff(time.Now()))
+			if err != nil {
+				log.Printf("ERROR: Gagal menjalankan settlement: %v", err)
+			} else if created > 0 {
+				log.Printf("SUCCESS: %d batch settlement dibuat", created)
+			}
+
+			resolved, err := service.ReconcilePending()
+			unlock()
+			if err != nil {
+				log.Printf("ERROR: Gagal rekonsiliasi settlement: %v", err)
+				continue
+			}
+			if resolved > 0 {
+				log.Printf("SUCCESS: %d settlement selesai direkonsiliasi", resolved)
+			}
+		}
+	}
+}
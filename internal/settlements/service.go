@@ -0,0 +1,433 @@
+package settlements
+
+import (
+	"context"
+	"errors"
+	"ewallet-engine/internal/balance"
+	"ewallet-engine/internal/bank"
+	"ewallet-engine/internal/merchants"
+	"ewallet-engine/internal/notifications"
+	"ewallet-engine/internal/payouts"
+	"ewallet-engine/internal/screening"
+	"ewallet-engine/internal/transactions"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FeeTransactionType adalah transaction_type jadwal biaya untuk MDR
+// (merchant discount rate) yang dipotong saat settlement.
+const FeeTransactionType = "MDR"
+
+// NotificationPaid dikirim ke pemilik merchant saat dana settlement masuk ke rekening.
+const NotificationPaid = "SETTLEMENT_PAID"
+
+// nameMatchThreshold mengikuti pencocokan nama rekening pada penarikan.
+const nameMatchThreshold = 0.8
+
+var (
+	ErrBatchNotFound    = errors.New("batch settlement tidak ditemukan")
+	ErrAccountNotFound  = errors.New("rekening settlement merchant belum diatur")
+	ErrNothingToSettle  = errors.New("tidak ada transaksi yang perlu di-settle")
+	ErrNonPositiveNet   = errors.New("nilai bersih settlement tidak positif, transaksi dibawa ke periode berikutnya")
+	ErrBatchNotRetrying = errors.New("hanya batch FAILED yang dapat dicoba ulang")
+	ErrNameMismatch     = errors.New("nama pemilik rekening tidak sesuai dengan data bank")
+)
+
+// wib adalah zona waktu cut-off settlement.
+var wib = time.FixedZone("WIB", 7*3600)
+
+type SettlementService interface {
+	SetAccount(merchantID uint, request AccountRequest) (*SettlementAccount, error)
+	GetAccount(merchantID uint) (*SettlementAccount, error)
+
+	LastCutoff(now time.Time) time.Time
+	Run(cutoff time.Time) (int, error)
+	SettleMerchant(merchantID uint, cutoff time.Time) (*SettlementBatch, error)
+	Retry(reference string) (*SettlementBatch, error)
+	Reconcile(reference string) (*SettlementBatch, error)
+	ReconcilePending() (int, error)
+
+	ListBatches(merchantID uint, status BatchStatus) ([]SettlementBatch, error)
+	GetBatch(merchantID uint, reference string) (*SettlementBatch, error)
+	ListItems(merchantID uint, reference string) ([]SettlementItem, error)
+	Report(merchantID uint, reference string) ([]byte, error)
+}
+
+type settlementService struct {
+	repo       SettlementRepository
+	merchants  merchants.MerchantService
+	connector  bank.BankConnector
+	fees       balance.FeeCalculator
+	notifier   notifications.Notifier
+	cutoffHour int
+	machine    payouts.Machine
+	holdTTL    time.Duration
+	recheck    time.Duration
+}
+
+func NewSettlementService(repo SettlementRepository, merchantService merchants.MerchantService, connector bank.BankConnector, fees balance.FeeCalculator, notifier notifications.Notifier) SettlementService {
+	s := &settlementService{
+		repo:      repo,
+		merchants: merchantService,
+		connector: connector,
+		fees:      fees,
+		notifier:  notifier,
+		machine: payouts.Machine{
+			Label:          "Settlement",
+			Timeout:        15 * time.Second,
+			NotFoundGrace:  10 * time.Minute,
+			MaxChecks:      30,
+			NotFound:       bank.ErrTransferNotFound,
+			NotFoundReason: "transfer tidak diterima bank",
+		},
+		holdTTL: 30 * 24 * time.Hour,
+		recheck: time.Minute,
+	}
+	if v, err := strconv.Atoi(os.Getenv("SETTLEMENT_CUTOFF_HOUR")); err == nil && v >= 0 && v < 24 {
+		s.cutoffHour = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("BANK_TRANSFER_TIMEOUT_SECONDS")); err == nil && v > 0 {
+		s.machine.Timeout = time.Duration(v) * time.Second
+	}
+	if v, err := strconv.Atoi(os.Getenv("WITHDRAWAL_NOT_FOUND_GRACE_MINUTES")); err == nil && v > 0 {
+		s.machine.NotFoundGrace = time.Duration(v) * time.Minute
+	}
+	if v, err := strconv.Atoi(os.Getenv("WITHDRAWAL_RECHECK_SECONDS")); err == nil && v > 0 {
+		s.recheck = time.Duration(v) * time.Second
+	}
+	return s
+}
+
+// SetAccount memverifikasi rekening lewat name inquiry lalu menyimpannya
+// sebagai tujuan pencairan merchant.
+func (s *settlementService) SetAccount(merchantID uint, request AccountRequest) (*SettlementAccount, error) {
+	if _, err := s.merchants.GetMerchant(merchantID); err != nil {
+		return nil, err
+	}
+
+	bankCode := strings.ToUpper(strings.TrimSpace(request.BankCode))
+	accountNumber := strings.TrimSpace(request.AccountNumber)
+	if accountNumber == "" {
+		return nil, errors.New("nomor rekening wajib diisi")
+	}
+	if !bank.IsSupported(bankCode) {
+		return nil, bank.ErrUnsupportedBank
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.machine.Timeout)
+	defer cancel()
+	result, err := s.connector.Inquiry(ctx, bankCode, accountNumber)
+	if err != nil {
+		return nil, err
+	}
+	if request.ExpectedName != "" && screening.Similarity(screening.Normalize(request.ExpectedName), screening.Normalize(result.AccountName)) < nameMatchThreshold {
+		return nil, ErrNameMismatch
+	}
+
+	account := &SettlementAccount{MerchantID: merchantID}
+	if existing, err := s.repo.FindAccount(merchantID); err == nil {
+		account = existing
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	account.BankCode = result.BankCode
+	account.AccountNumber = result.AccountNumber
+	account.AccountName = result.AccountName
+	account.VerifiedAt = time.Now()
+
+	if err := s.repo.SaveAccount(account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+func (s *settlementService) GetAccount(merchantID uint) (*SettlementAccount, error) {
+	account, err := s.repo.FindAccount(merchantID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAccountNotFound
+		}
+		return nil, err
+	}
+	return account, nil
+}
+
+func (s *settlementService) LastCutoff(now time.Time) time.Time {
+	return cutoffBefore(now, s.cutoffHour, wib)
+}
+
+// Run membuat dan mencairkan batch untuk semua merchant ACTIVE sampai cutoff.
+// Merchant yang sudah punya batch untuk cutoff yang sama dilewati, sehingga
+// Run aman dipanggil berulang kali. Merchant SUSPENDED tidak di-settle.
+func (s *settlementService) Run(cutoff time.Time) (int, error) {
+	list, err := s.merchants.ListMerchants(0)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for _, merchant := range list {
+		if merchant.Status != merchants.MerchantActive {
+			continue
+		}
+		_, isNew, err := s.settle(&merchant, cutoff)
+		if err != nil {
+			if !errors.Is(err, ErrNothingToSettle) && !errors.Is(err, ErrNonPositiveNet) {
+				log.Printf("ERROR: Gagal membuat settlement merchant %s: %v", merchant.Code, err)
+			}
+			continue
+		}
+		if isNew {
+			created++
+		}
+	}
+	return created, nil
+}
+
+func (s *settlementService) SettleMerchant(merchantID uint, cutoff time.Time) (*SettlementBatch, error) {
+	merchant, err := s.merchants.GetMerchant(merchantID)
+	if err != nil {
+		return nil, err
+	}
+	batch, _, err := s.settle(merchant, cutoff)
+	return batch, err
+}
+
+// settle membuat batch merchant untuk cutoff lalu langsung mencairkannya.
+// Bool kedua bernilai false bila batch untuk cutoff tersebut sudah ada.
+func (s *settlementService) settle(merchant *merchants.Merchant, cutoff time.Time) (*SettlementBatch, bool, error) {
+	if cutoff.After(time.Now()) {
+		return nil, false, errors.New("cut-off tidak boleh di masa depan")
+	}
+	if existing, err := s.repo.FindBatchByPeriod(merchant.ID, cutoff); err == nil {
+		return existing, false, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+
+	rows, err := s.repo.UnsettledTransactions(merchant.SettlementUserID, merchant.Currency, cutoff)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(rows) == 0 {
+		return nil, false, ErrNothingToSettle
+	}
+
+	batch := &SettlementBatch{
+		Reference:  fmt.Sprintf("STL-%s-%s", merchant.Code, cutoff.In(wib).Format("200601021504")),
+		MerchantID: merchant.ID,
+		Currency:   merchant.Currency,
+		PeriodEnd:  cutoff,
+		Status:     BatchPending,
+	}
+	if last, err := s.repo.LastBatch(merchant.ID); err == nil {
+		batch.PeriodStart = &last.PeriodEnd
+	}
+
+	items, err := buildItems(batch, rows, func(row transactions.Transaction) (float64, error) {
+		channel, _ := row.AdditionalInfo["channel"].(string)
+		return s.fees.CalculateFee(merchant.SettlementUserID, FeeTransactionType, channel, row.Currency, row.Amount)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if batch.NetAmount <= 0 {
+		log.Printf("ALERT: Settlement merchant %s bernilai bersih %.2f, %d transaksi dibawa ke periode berikutnya", merchant.Code, batch.NetAmount, len(items))
+		return nil, false, ErrNonPositiveNet
+	}
+
+	if err := s.repo.CreateBatch(batch, items); err != nil {
+		return nil, false, err
+	}
+	log.Printf("SUCCESS: Batch settlement %s dibuat: %d pembayaran, %d refund, bersih %.2f %s", batch.Reference, batch.PaymentCount, batch.RefundCount, batch.NetAmount, batch.Currency)
+
+	s.payout(merchant, batch)
+	return batch, true, nil
+}
+
+// Retry mencairkan ulang batch FAILED, misalnya setelah rekening merchant
+// diperbaiki. Setiap percobaan memakai reference bank baru.
+func (s *settlementService) Retry(reference string) (*SettlementBatch, error) {
+	batch, err := s.GetBatch(0, reference)
+	if err != nil {
+		return nil, err
+	}
+	if batch.Status != BatchFailed {
+		return nil, ErrBatchNotRetrying
+	}
+	merchant, err := s.merchants.GetMerchant(batch.MerchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := s.repo.TransitionStatus(batch.ID, BatchFailed, map[string]interface{}{"status": BatchPending})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrBatchNotRetrying
+	}
+	batch.Status = BatchPending
+
+	s.payout(merchant, batch)
+	return batch, nil
+}
+
+// payout menahan NetAmount+FeeAmount di wallet settlement lalu mengirim
+// NetAmount ke rekening merchant. Seperti penarikan, hold hanya di-capture
+// saat bank mengonfirmasi SUCCESS dan hanya dilepas saat bank mengonfirmasi
+// FAILED.
+func (s *settlementService) payout(merchant *merchants.Merchant, batch *SettlementBatch) {
+	payout := &batchPayout{service: s, merchant: merchant, batch: batch}
+	account, err := s.GetAccount(merchant.ID)
+	if err != nil {
+		s.machine.Fail(payout, err.Error())
+		return
+	}
+
+	attempts := batch.Attempts + 1
+	payoutReference := fmt.Sprintf("%s-%d", batch.Reference, attempts)
+	ok, err := s.repo.TransitionStatus(batch.ID, BatchPending, map[string]interface{}{
+		"attempts":         attempts,
+		"payout_reference": payoutReference,
+		"bank_code":        account.BankCode,
+		"account_number":   account.AccountNumber,
+		"account_name":     account.AccountName,
+		"connector":        s.connector.Name(),
+		"external_id":      "",
+		"failure_reason":   "",
+	})
+	if err != nil || !ok {
+		log.Printf("ERROR: Gagal menyiapkan pencairan settlement %s: %v", batch.Reference, err)
+		return
+	}
+	batch.Attempts = attempts
+	batch.PayoutReference = payoutReference
+	batch.BankCode = account.BankCode
+	batch.AccountNumber = account.AccountNumber
+	batch.AccountName = account.AccountName
+	batch.Connector = s.connector.Name()
+	batch.ExternalID = ""
+	batch.FailureReason = ""
+
+	amount := balance.RoundAmount(batch.NetAmount+batch.FeeAmount, batch.Currency)
+	if err := s.repo.PlaceHold(merchant.SettlementUserID, batch.Currency, amount, payoutReference, time.Now().Add(s.holdTTL)); err != nil {
+		reason := err.Error()
+		if errors.Is(err, balance.ErrInsufficientBalance) {
+			reason = "saldo settlement merchant tidak mencukupi"
+		}
+		s.machine.Fail(payout, reason)
+		return
+	}
+
+	err = s.machine.Send(payout, func(ctx context.Context) (*payouts.Outcome, error) {
+		result, err := s.connector.Transfer(ctx, bank.TransferRequest{
+			Reference:     payoutReference,
+			BankCode:      account.BankCode,
+			AccountNumber: account.AccountNumber,
+			AccountName:   account.AccountName,
+			Amount:        batch.NetAmount,
+			Currency:      batch.Currency,
+			Remark:        "Settlement " + batch.Reference,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return payouts.TransferOutcome(result), nil
+	})
+	if err != nil {
+		log.Printf("ERROR: Gagal memproses pencairan settlement %s: %v", batch.Reference, err)
+	}
+}
+
+// Reconcile menanyakan status transfer ke bank untuk batch yang belum final.
+func (s *settlementService) Reconcile(reference string) (*SettlementBatch, error) {
+	batch, err := s.GetBatch(0, reference)
+	if err != nil {
+		return nil, err
+	}
+	if batch.Status != BatchProcessing && batch.Status != BatchUnknown {
+		return batch, nil
+	}
+	merchant, err := s.merchants.GetMerchant(batch.MerchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.machine.Reconcile(&batchPayout{service: s, merchant: merchant, batch: batch}, func(ctx context.Context) (*payouts.Outcome, error) {
+		result, err := s.connector.Status(ctx, batch.PayoutReference)
+		if err != nil {
+			return nil, err
+		}
+		return payouts.TransferOutcome(result), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+func (s *settlementService) ReconcilePending() (int, error) {
+	pending, err := s.repo.FindUnresolved(time.Now().Add(-s.recheck), 100)
+	if err != nil {
+		return 0, err
+	}
+
+	references := make([]string, 0, len(pending))
+	for _, item := range pending {
+		references = append(references, item.Reference)
+	}
+	return s.machine.ReconcileAll(references, func(reference string) (bool, error) {
+		batch, err := s.Reconcile(reference)
+		if err != nil {
+			return false, err
+		}
+		return batch.IsFinal(), nil
+	}), nil
+}
+func (s *settlementService) ListBatches(merchantID uint, status BatchStatus) ([]SettlementBatch, error) {
+	return s.repo.ListBatches(merchantID, BatchStatus(strings.ToUpper(string(status))), 100)
+}
+
+// GetBatch mencari batch berdasarkan reference. merchantID selain 0 membatasi
+// hasil ke batch milik merchant tersebut.
+func (s *settlementService) GetBatch(merchantID uint, reference string) (*SettlementBatch, error) {
+	batch, err := s.repo.FindBatch(reference)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBatchNotFound
+		}
+		return nil, err
+	}
+	if merchantID != 0 && batch.MerchantID != merchantID {
+		return nil, ErrBatchNotFound
+	}
+	return batch, nil
+}
+
+func (s *settlementService) ListItems(merchantID uint, reference string) ([]SettlementItem, error) {
+	batch, err := s.GetBatch(merchantID, reference)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListItems(batch.ID)
+}
+
+// Report menghasilkan laporan CSV batch untuk diunduh merchant atau tim finance.
+func (s *settlementService) Report(merchantID uint, reference string) ([]byte, error) {
+	batch, err := s.GetBatch(merchantID, reference)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.repo.ListItems(batch.ID)
+	if err != nil {
+		return nil, err
+	}
+	return writeReport(batch, items)
+}
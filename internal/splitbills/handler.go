@@ -0,0 +1,257 @@
+package splitbills
+
+import (
+	"errors"
+	"ewallet-engine/internal/audit"
+	"ewallet-engine/internal/auth"
+	"ewallet-engine/internal/transactions"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SplitBillHandler struct {
+	service      SplitBillService
+	auditService audit.AuditService
+}
+
+func NewSplitBillHandler(service SplitBillService, auditService audit.AuditService) *SplitBillHandler {
+	return &SplitBillHandler{service: service, auditService: auditService}
+}
+
+func (h *SplitBillHandler) CreateGroupHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	var request GroupRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	group, err := h.service.CreateGroup(userID, request)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionSplitGroupCreated,
+		TargetType: "split_group",
+		TargetID:   fmt.Sprint(group.ID),
+		After:      audit.Snapshot{"name": group.Name, "currency": group.Currency, "members": len(group.Members)},
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Grup split bill berhasil dibuat",
+		"data":    group,
+	})
+}
+
+func (h *SplitBillHandler) ListGroupsHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	groups, err := h.service.ListGroups(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": groups})
+}
+
+func (h *SplitBillHandler) GetGroupHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	group, err := h.service.GetGroup(userID, uint(id))
+	if err != nil {
+		return h.splitError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": group})
+}
+
+func (h *SplitBillHandler) AddMemberHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	var request MemberRef
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	member, err := h.service.AddMember(userID, uint(id), request)
+	if err != nil {
+		return h.splitError(c, err)
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionSplitMemberAdded,
+		TargetType: "split_group",
+		TargetID:   fmt.Sprint(member.GroupID),
+		After:      audit.Snapshot{"user_id": member.UserID},
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Anggota berhasil ditambahkan",
+		"data":    member,
+	})
+}
+
+func (h *SplitBillHandler) AddExpenseHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	var request ExpenseRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	expense, err := h.service.AddExpense(userID, uint(id), request)
+	if err != nil {
+		return h.splitError(c, err)
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionSplitExpenseAdded,
+		TargetType: "split_expense",
+		TargetID:   fmt.Sprint(expense.ID),
+		After: audit.Snapshot{
+			"group_id":   expense.GroupID,
+			"amount":     expense.Amount,
+			"currency":   expense.Currency,
+			"split_type": expense.SplitType,
+			"shares":     len(expense.Shares),
+		},
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Tagihan berhasil dibagi",
+		"data":    expense,
+	})
+}
+
+func (h *SplitBillHandler) ListExpensesHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	expenses, err := h.service.ListExpenses(userID, uint(id))
+	if err != nil {
+		return h.splitError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": expenses})
+}
+
+func (h *SplitBillHandler) SummaryHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	summary, err := h.service.Summary(userID, uint(id))
+	if err != nil {
+		return h.splitError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": summary})
+}
+
+func (h *SplitBillHandler) SettleHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	var request struct {
+		ToUserID  uint    `json:"to_user_id"`
+		Amount    float64 `json:"amount"`
+		PIN       string  `json:"pin"`
+		Reference string  `json:"reference"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	additionalInfo := transactions.AdditionalInfo{
+		"device_id": c.Get("X-Device-ID"),
+		"ip":        c.IP(),
+	}
+
+	settlement, transaction, err := h.service.Settle(userID, uint(id), request.ToUserID, request.Amount, request.PIN, request.Reference, additionalInfo)
+	if errors.Is(err, ErrTransferPending) {
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+			"message": err.Error(),
+			"data":    fiber.Map{"transaction": transaction},
+		})
+	}
+	if err != nil {
+		return h.splitError(c, err)
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionSplitSettled,
+		TargetType: "split_group",
+		TargetID:   fmt.Sprint(settlement.GroupID),
+		After: audit.Snapshot{
+			"to_user_id": settlement.ToUserID,
+			"amount":     settlement.Amount,
+			"reference":  settlement.TransactionReference,
+		},
+	})
+
+	return c.JSON(fiber.Map{
+		"message": "Pelunasan berhasil dicatat",
+		"data":    fiber.Map{"settlement": settlement, "transaction": transaction},
+	})
+}
+
+func (h *SplitBillHandler) ListSettlementsHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	settlements, err := h.service.ListSettlements(userID, uint(id))
+	if err != nil {
+		return h.splitError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": settlements})
+}
+
+func (h *SplitBillHandler) splitError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, ErrGroupNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, auth.ErrPINLocked):
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, auth.ErrInvalidPIN), errors.Is(err, auth.ErrPINNotSet):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"message": err.Error()})
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+}
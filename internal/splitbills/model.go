@@ -0,0 +1,125 @@
+package splitbills
+
+import "time"
+
+type SplitType string
+
+const (
+	SplitEqual  SplitType = "EQUAL"
+	SplitShares SplitType = "SHARES"
+	SplitExact  SplitType = "EXACT"
+)
+
+type ShareStatus string
+
+const (
+	ShareOpen    ShareStatus = "OPEN"
+	SharePartial ShareStatus = "PARTIAL"
+	ShareSettled ShareStatus = "SETTLED"
+)
+
+// SplitGroup adalah kelompok user yang berbagi tagihan dalam satu mata uang.
+type SplitGroup struct {
+	ID        uint          `gorm:"primaryKey" json:"id"`
+	Name      string        `gorm:"type:varchar(100);not null" json:"name"`
+	Currency  string        `gorm:"type:char(3);not null;default:'IDR'" json:"currency"`
+	CreatedBy uint          `gorm:"not null" json:"created_by"`
+	Members   []SplitMember `gorm:"foreignKey:GroupID" json:"members,omitempty"`
+	CreatedAt time.Time     `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time     `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+type SplitMember struct {
+	ID       uint      `gorm:"primaryKey" json:"id"`
+	GroupID  uint      `gorm:"not null;uniqueIndex:idx_split_group_member" json:"group_id"`
+	UserID   uint      `gorm:"not null;uniqueIndex:idx_split_group_member;index" json:"user_id"`
+	JoinedAt time.Time `gorm:"autoCreateTime" json:"joined_at"`
+}
+
+// SplitExpense adalah tagihan yang sudah dibayar PayerID dan dibagi ke anggota.
+// Bagian payer sendiri tidak menjadi SplitShare karena tidak ada utang.
+type SplitExpense struct {
+	ID          uint         `gorm:"primaryKey" json:"id"`
+	GroupID     uint         `gorm:"not null;index" json:"group_id"`
+	PayerID     uint         `gorm:"not null" json:"payer_id"`
+	Description string       `gorm:"type:varchar(255)" json:"description"`
+	Amount      float64      `gorm:"not null" json:"amount"`
+	Currency    string       `gorm:"type:char(3);not null;default:'IDR'" json:"currency"`
+	SplitType   SplitType    `gorm:"type:enum('EQUAL','SHARES','EXACT');not null" json:"split_type"`
+	Shares      []SplitShare `gorm:"foreignKey:ExpenseID" json:"shares,omitempty"`
+	CreatedAt   time.Time    `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// SplitShare adalah utang DebtorID kepada CreditorID dari satu expense. Utang
+// ditagihkan lewat PaymentRequestID dan bisa dilunasi sebagian.
+type SplitShare struct {
+	ID               uint        `gorm:"primaryKey" json:"id"`
+	ExpenseID        uint        `gorm:"not null;index" json:"expense_id"`
+	GroupID          uint        `gorm:"not null;index:idx_split_share_pair" json:"group_id"`
+	DebtorID         uint        `gorm:"not null;index:idx_split_share_pair" json:"debtor_id"`
+	CreditorID       uint        `gorm:"not null;index:idx_split_share_pair" json:"creditor_id"`
+	Amount           float64     `gorm:"not null" json:"amount"`
+	SettledAmount    float64     `gorm:"not null;default:0" json:"settled_amount"`
+	Status           ShareStatus `gorm:"type:enum('OPEN','PARTIAL','SETTLED');default:'OPEN'" json:"status"`
+	PaymentRequestID *uint       `gorm:"index" json:"payment_request_id,omitempty"`
+	CreatedAt        time.Time   `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time   `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// SplitSettlement mencatat pembayaran utang antar anggota grup, baik lewat
+// permintaan dana maupun transfer langsung dari endpoint settle.
+type SplitSettlement struct {
+	ID                   uint      `gorm:"primaryKey" json:"id"`
+	GroupID              uint      `gorm:"not null;index" json:"group_id"`
+	FromUserID           uint      `gorm:"not null" json:"from_user_id"`
+	ToUserID             uint      `gorm:"not null" json:"to_user_id"`
+	Amount               float64   `gorm:"not null" json:"amount"`
+	TransactionReference string    `gorm:"type:varchar(100);uniqueIndex" json:"transaction_reference"`
+	PaymentRequestID     *uint     `json:"payment_request_id,omitempty"`
+	CreatedAt            time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// MemberRef menunjuk seorang user lewat id, nomor HP atau username.
+type MemberRef struct {
+	UserID   uint   `json:"user_id"`
+	Phone    string `json:"phone_number"`
+	Username string `json:"username"`
+}
+
+type GroupRequest struct {
+	Name     string      `json:"name"`
+	Currency string      `json:"currency"`
+	Members  []MemberRef `json:"members"`
+}
+
+// Participant adalah bagian satu anggota pada expense. Weight dipakai untuk
+// SHARES dan Amount untuk EXACT; EQUAL cukup UserID.
+type Participant struct {
+	UserID uint    `json:"user_id"`
+	Weight float64 `json:"weight"`
+	Amount float64 `json:"amount"`
+}
+
+type ExpenseRequest struct {
+	Description  string        `json:"description"`
+	Amount       float64       `json:"amount"`
+	SplitType    SplitType     `json:"split_type"`
+	Participants []Participant `json:"participants"`
+}
+
+// Debt adalah sisa utang From kepada To.
+type Debt struct {
+	From   uint    `json:"from_user_id"`
+	To     uint    `json:"to_user_id"`
+	Amount float64 `json:"amount"`
+}
+
+// Summary merangkum posisi grup: saldo bersih tiap anggota (positif berarti
+// berpiutang), utang per pasangan, dan rencana pelunasan yang disederhanakan.
+type Summary struct {
+	GroupID    uint             `json:"group_id"`
+	Currency   string           `json:"currency"`
+	Balances   map[uint]float64 `json:"balances"`
+	Debts      []Debt           `json:"debts"`
+	Simplified []Debt           `json:"simplified"`
+}
@@ -0,0 +1,165 @@
+package splitbills
+
+import (
+	"ewallet-engine/internal/balance"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SplitBillRepository interface {
+	CreateGroup(group *SplitGroup) error
+	FindGroup(id uint) (*SplitGroup, error)
+	ListGroups(userID uint) ([]SplitGroup, error)
+	IsMember(groupID uint, userID uint) (bool, error)
+	AddMember(member *SplitMember) error
+	CreateExpense(expense *SplitExpense) error
+	ListExpenses(groupID uint, limit int) ([]SplitExpense, error)
+	ListOpenShares(groupID uint) ([]SplitShare, error)
+	FindShareByPaymentRequest(paymentRequestID uint) (*SplitShare, error)
+	SetPaymentRequest(shareID uint, paymentRequestID *uint) error
+	ApplySettlement(settlement *SplitSettlement, preferShareID uint) ([]SplitShare, float64, error)
+	ListSettlements(groupID uint, limit int) ([]SplitSettlement, error)
+}
+
+type splitBillRepository struct {
+	DB *gorm.DB
+}
+
+func NewSplitBillRepository(db *gorm.DB) SplitBillRepository {
+	return &splitBillRepository{DB: db}
+}
+
+func (r *splitBillRepository) CreateGroup(group *SplitGroup) error {
+	return r.DB.Create(group).Error
+}
+
+func (r *splitBillRepository) FindGroup(id uint) (*SplitGroup, error) {
+	var group SplitGroup
+	if err := r.DB.Preload("Members").First(&group, id).Error; err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (r *splitBillRepository) ListGroups(userID uint) ([]SplitGroup, error) {
+	var groups []SplitGroup
+	err := r.DB.Preload("Members").
+		Where("id IN (?)", r.DB.Model(&SplitMember{}).Select("group_id").Where("user_id = ?", userID)).
+		Order("id DESC").Find(&groups).Error
+	return groups, err
+}
+
+func (r *splitBillRepository) IsMember(groupID uint, userID uint) (bool, error) {
+	var count int64
+	err := r.DB.Model(&SplitMember{}).Where("group_id = ? AND user_id = ?", groupID, userID).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *splitBillRepository) AddMember(member *SplitMember) error {
+	return r.DB.Create(member).Error
+}
+
+// CreateExpense menyimpan expense beserta seluruh share-nya dalam satu transaksi.
+func (r *splitBillRepository) CreateExpense(expense *SplitExpense) error {
+	return r.DB.Create(expense).Error
+}
+
+func (r *splitBillRepository) ListExpenses(groupID uint, limit int) ([]SplitExpense, error) {
+	var expenses []SplitExpense
+	err := r.DB.Preload("Shares").Where("group_id = ?", groupID).Order("id DESC").Limit(limit).Find(&expenses).Error
+	return expenses, err
+}
+
+func (r *splitBillRepository) ListOpenShares(groupID uint) ([]SplitShare, error) {
+	var shares []SplitShare
+	err := r.DB.Where("group_id = ? AND status <> ?", groupID, ShareSettled).Order("id ASC").Find(&shares).Error
+	return shares, err
+}
+
+func (r *splitBillRepository) FindShareByPaymentRequest(paymentRequestID uint) (*SplitShare, error) {
+	var share SplitShare
+	if err := r.DB.Where("payment_request_id = ?", paymentRequestID).First(&share).Error; err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+func (r *splitBillRepository) SetPaymentRequest(shareID uint, paymentRequestID *uint) error {
+	return r.DB.Model(&SplitShare{}).Where("id = ?", shareID).Update("payment_request_id", paymentRequestID).Error
+}
+
+// ApplySettlement mencatat settlement dan mengalokasikan nominalnya ke share
+// FromUserID kepada ToUserID yang belum lunas, dimulai dari preferShareID lalu
+// share tertua. Share dikunci FOR UPDATE supaya dua pelunasan bersamaan tidak
+// menghitung sisa utang yang sama. Reference yang sudah tercatat tidak
+// dialokasikan ulang. Mengembalikan share yang berubah dan sisa nominal yang
+// tidak teralokasi karena utangnya sudah habis.
+func (r *splitBillRepository) ApplySettlement(settlement *SplitSettlement, preferShareID uint) ([]SplitShare, float64, error) {
+	var touched []SplitShare
+	var leftover float64
+
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Model(&SplitSettlement{}).Where("transaction_reference = ?", settlement.TransactionReference).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return nil
+		}
+
+		var shares []SplitShare
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("group_id = ? AND debtor_id = ? AND creditor_id = ? AND status <> ?", settlement.GroupID, settlement.FromUserID, settlement.ToUserID, ShareSettled).
+			Order(clause.Expr{SQL: "id = ? DESC, id ASC", Vars: []interface{}{preferShareID}}).
+			Find(&shares).Error
+		if err != nil {
+			return err
+		}
+
+		group := SplitGroup{}
+		if err := tx.Select("currency").First(&group, settlement.GroupID).Error; err != nil {
+			return err
+		}
+
+		remaining := balance.ToMinor(settlement.Amount, group.Currency)
+		for i := range shares {
+			if remaining == 0 {
+				break
+			}
+			share := &shares[i]
+			outstanding := balance.ToMinor(share.Outstanding(), group.Currency)
+			applied := outstanding
+			if remaining < applied {
+				applied = remaining
+			}
+			remaining -= applied
+
+			share.SettledAmount = balance.FromMinor(balance.ToMinor(share.SettledAmount, group.Currency)+applied, group.Currency)
+			share.Status = SharePartial
+			if applied == outstanding {
+				share.Status = ShareSettled
+			}
+			if err := tx.Model(&SplitShare{}).Where("id = ?", share.ID).Updates(map[string]interface{}{
+				"settled_amount": share.SettledAmount,
+				"status":         share.Status,
+			}).Error; err != nil {
+				return err
+			}
+			touched = append(touched, *share)
+		}
+
+		leftover = balance.FromMinor(remaining, group.Currency)
+		return tx.Create(settlement).Error
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return touched, leftover, nil
+}
+
+func (r *splitBillRepository) ListSettlements(groupID uint, limit int) ([]SplitSettlement, error) {
+	var settlements []SplitSettlement
+	err := r.DB.Where("group_id = ?", groupID).Order("id DESC").Limit(limit).Find(&settlements).Error
+	return settlements, err
+}
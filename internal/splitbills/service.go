@@ -0,0 +1,417 @@
+package splitbills
+
+import (
+	"errors"
+	"ewallet-engine/internal/auth"
+	"ewallet-engine/internal/balance"
+	"ewallet-engine/internal/paymentrequests"
+	"ewallet-engine/internal/transactions"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const maxGroupMembers = 50
+
+var (
+	ErrGroupNotFound = errors.New("grup tidak ditemukan")
+	ErrNotMember     = errors.New("user bukan anggota grup")
+	// ErrTransferPending dikembalikan bersama transaksinya supaya pelunasan
+	// bisa dikirim ulang dengan reference yang sama setelah transfer berhasil.
+	ErrTransferPending = errors.New("transfer sedang direview, pelunasan dicatat setelah transfer berhasil")
+	ErrTransferFailed  = errors.New("transfer pelunasan gagal")
+)
+
+type SplitBillService interface {
+	CreateGroup(userID uint, request GroupRequest) (*SplitGroup, error)
+	ListGroups(userID uint) ([]SplitGroup, error)
+	GetGroup(userID uint, groupID uint) (*SplitGroup, error)
+	AddMember(userID uint, groupID uint, ref MemberRef) (*SplitMember, error)
+	AddExpense(userID uint, groupID uint, request ExpenseRequest) (*SplitExpense, error)
+	ListExpenses(userID uint, groupID uint) ([]SplitExpense, error)
+	Summary(userID uint, groupID uint) (*Summary, error)
+	Settle(userID uint, groupID uint, toUserID uint, amount float64, pin string, reference string, additionalInfo transactions.AdditionalInfo) (*SplitSettlement, *transactions.Transaction, error)
+	ListSettlements(userID uint, groupID uint) ([]SplitSettlement, error)
+	OnPaymentRequestPaid(request paymentrequests.PaymentRequest, transaction *transactions.Transaction)
+}
+
+type splitBillService struct {
+	repo            SplitBillRepository
+	transactions    transactions.TransactionService
+	paymentRequests paymentrequests.PaymentRequestService
+	pins            auth.PINVerifier
+}
+
+func NewSplitBillService(repo SplitBillRepository, transactionService transactions.TransactionService, paymentRequestService paymentrequests.PaymentRequestService, pins auth.PINVerifier) SplitBillService {
+	return &splitBillService{repo: repo, transactions: transactionService, paymentRequests: paymentRequestService, pins: pins}
+}
+
+func (s *splitBillService) CreateGroup(userID uint, request GroupRequest) (*SplitGroup, error) {
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		return nil, errors.New("nama grup wajib diisi")
+	}
+	currency, err := balance.NormalizeCurrency(request.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if len(request.Members)+1 > maxGroupMembers {
+		return nil, fmt.Errorf("anggota grup maksimal %d orang", maxGroupMembers)
+	}
+
+	group := &SplitGroup{Name: name, Currency: currency, CreatedBy: userID, Members: []SplitMember{{UserID: userID}}}
+	seen := map[uint]bool{userID: true}
+	for _, ref := range request.Members {
+		user, err := s.transactions.FindRecipient(ref.UserID, ref.Phone, ref.Username)
+		if err != nil {
+			return nil, fmt.Errorf("anggota %s tidak ditemukan", describeRef(ref))
+		}
+		if seen[user.ID] {
+			continue
+		}
+		seen[user.ID] = true
+		group.Members = append(group.Members, SplitMember{UserID: user.ID})
+	}
+
+	if err := s.repo.CreateGroup(group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+func (s *splitBillService) ListGroups(userID uint) ([]SplitGroup, error) {
+	return s.repo.ListGroups(userID)
+}
+
+// GetGroup hanya mengembalikan grup bila userID adalah anggotanya.
+func (s *splitBillService) GetGroup(userID uint, groupID uint) (*SplitGroup, error) {
+	group, err := s.repo.FindGroup(groupID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGroupNotFound
+		}
+		return nil, err
+	}
+	if !hasMember(group, userID) {
+		return nil, ErrGroupNotFound
+	}
+	return group, nil
+}
+
+func (s *splitBillService) AddMember(userID uint, groupID uint, ref MemberRef) (*SplitMember, error) {
+	group, err := s.GetGroup(userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if len(group.Members) >= maxGroupMembers {
+		return nil, fmt.Errorf("anggota grup maksimal %d orang", maxGroupMembers)
+	}
+
+	user, err := s.transactions.FindRecipient(ref.UserID, ref.Phone, ref.Username)
+	if err != nil {
+		return nil, fmt.Errorf("anggota %s tidak ditemukan", describeRef(ref))
+	}
+	if hasMember(group, user.ID) {
+		return nil, errors.New("user sudah menjadi anggota grup")
+	}
+
+	member := &SplitMember{GroupID: group.ID, UserID: user.ID}
+	if err := s.repo.AddMember(member); err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// AddExpense mencatat tagihan yang dibayar userID lalu menagih bagian setiap
+// anggota lain lewat permintaan dana. Kegagalan membuat permintaan dana tidak
+// membatalkan expense; utangnya tetap tercatat dan bisa dilunasi lewat Settle.
+func (s *splitBillService) AddExpense(userID uint, groupID uint, request ExpenseRequest) (*SplitExpense, error) {
+	group, err := s.GetGroup(userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if err := balance.ValidateAmount(request.Amount, group.Currency); err != nil {
+		return nil, err
+	}
+
+	splitType := SplitType(strings.ToUpper(string(request.SplitType)))
+	if splitType == "" {
+		splitType = SplitEqual
+	}
+
+	participants := request.Participants
+	if len(participants) == 0 && splitType == SplitEqual {
+		for _, member := range group.Members {
+			participants = append(participants, Participant{UserID: member.UserID})
+		}
+	}
+	for _, participant := range participants {
+		if !hasMember(group, participant.UserID) {
+			return nil, fmt.Errorf("user_id %d bukan anggota grup", participant.UserID)
+		}
+	}
+
+	amounts, err := Split(request.Amount, group.Currency, splitType, participants)
+	if err != nil {
+		return nil, err
+	}
+
+	expense := &SplitExpense{
+		GroupID:     group.ID,
+		PayerID:     userID,
+		Description: strings.TrimSpace(request.Description),
+		Amount:      request.Amount,
+		Currency:    group.Currency,
+		SplitType:   splitType,
+	}
+	for i, participant := range participants {
+		if participant.UserID == userID || amounts[i] <= 0 {
+			continue
+		}
+		expense.Shares = append(expense.Shares, SplitShare{
+			GroupID:    group.ID,
+			DebtorID:   participant.UserID,
+			CreditorID: userID,
+			Amount:     amounts[i],
+			Status:     ShareOpen,
+		})
+	}
+
+	if err := s.repo.CreateExpense(expense); err != nil {
+		return nil, err
+	}
+
+	for i := range expense.Shares {
+		s.requestPayment(group, expense.Description, &expense.Shares[i])
+	}
+	return expense, nil
+}
+
+func (s *splitBillService) ListExpenses(userID uint, groupID uint) ([]SplitExpense, error) {
+	if _, err := s.GetGroup(userID, groupID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListExpenses(groupID, 100)
+}
+
+func (s *splitBillService) Summary(userID uint, groupID uint) (*Summary, error) {
+	group, err := s.GetGroup(userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	shares, err := s.repo.ListOpenShares(group.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &Summary{GroupID: group.ID, Currency: group.Currency, Balances: make(map[uint]float64), Debts: []Debt{}}
+	for _, member := range group.Members {
+		summary.Balances[member.UserID] = 0
+	}
+
+	type pair struct{ from, to uint }
+	pairs := make(map[pair]float64)
+	for _, share := range shares {
+		outstanding := share.Outstanding()
+		summary.Balances[share.CreditorID] += outstanding
+		summary.Balances[share.DebtorID] -= outstanding
+		pairs[pair{share.DebtorID, share.CreditorID}] += outstanding
+	}
+	for userID, amount := range summary.Balances {
+		summary.Balances[userID] = balance.RoundAmount(amount, group.Currency)
+	}
+	for key, amount := range pairs {
+		summary.Debts = append(summary.Debts, Debt{From: key.from, To: key.to, Amount: balance.RoundAmount(amount, group.Currency)})
+	}
+	sort.Slice(summary.Debts, func(a, b int) bool {
+		if summary.Debts[a].From != summary.Debts[b].From {
+			return summary.Debts[a].From < summary.Debts[b].From
+		}
+		return summary.Debts[a].To < summary.Debts[b].To
+	})
+
+	summary.Simplified = Simplify(summary.Balances, group.Currency)
+	return summary, nil
+}
+
+// Settle melunasi sebagian atau seluruh utang userID kepada toUserID lewat
+// TRANSFER. Nominal tidak boleh melebihi sisa utang ke anggota tersebut.
+// Pelunasan hanya dicatat setelah TRANSFER SUCCESS.
+func (s *splitBillService) Settle(userID uint, groupID uint, toUserID uint, amount float64, pin string, reference string, additionalInfo transactions.AdditionalInfo) (*SplitSettlement, *transactions.Transaction, error) {
+	group, err := s.GetGroup(userID, groupID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if toUserID == userID || !hasMember(group, toUserID) {
+		return nil, nil, ErrNotMember
+	}
+	if err := balance.ValidateAmount(amount, group.Currency); err != nil {
+		return nil, nil, err
+	}
+
+	shares, err := s.repo.ListOpenShares(group.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var owed float64
+	for _, share := range shares {
+		if share.DebtorID == userID && share.CreditorID == toUserID {
+			owed += share.Outstanding()
+		}
+	}
+	if balance.ToMinor(amount, group.Currency) > balance.ToMinor(owed, group.Currency) {
+		return nil, nil, fmt.Errorf("nominal melebihi sisa utang %s %.2f", group.Currency, owed)
+	}
+
+	if err := s.pins.VerifyPIN(userID, pin); err != nil {
+		return nil, nil, err
+	}
+
+	if reference == "" {
+		reference = fmt.Sprintf("SPL-%d-%d", group.ID, time.Now().UnixNano())
+	}
+	if additionalInfo == nil {
+		additionalInfo = make(transactions.AdditionalInfo)
+	}
+	additionalInfo["split_group_id"] = group.ID
+
+	transaction, err := s.transactions.Transfer(userID, toUserID, amount, group.Currency, reference, "Pelunasan split bill: "+group.Name, additionalInfo)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch transaction.TransactionStatus {
+	case transactions.StatusSuccess:
+	case transactions.StatusPending:
+		return nil, transaction, ErrTransferPending
+	default:
+		return nil, nil, ErrTransferFailed
+	}
+
+	settlement := &SplitSettlement{
+		GroupID:              group.ID,
+		FromUserID:           userID,
+		ToUserID:             toUserID,
+		Amount:               transaction.Amount,
+		TransactionReference: transaction.Reference,
+	}
+	touched, _, err := s.repo.ApplySettlement(settlement, 0)
+	if err != nil {
+		log.Printf("ERROR: Transfer %s berhasil tetapi settlement grup %d gagal dicatat: %v", transaction.Reference, group.ID, err)
+		return nil, nil, err
+	}
+	s.reissue(group, touched, 0)
+	return settlement, transaction, nil
+}
+
+func (s *splitBillService) ListSettlements(userID uint, groupID uint) ([]SplitSettlement, error) {
+	if _, err := s.GetGroup(userID, groupID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListSettlements(groupID, 100)
+}
+
+// OnPaymentRequestPaid dipasang sebagai PaidListener: permintaan dana yang
+// berasal dari share split bill dicatat sebagai settlement.
+func (s *splitBillService) OnPaymentRequestPaid(request paymentrequests.PaymentRequest, transaction *transactions.Transaction) {
+	if transaction.TransactionStatus != transactions.StatusSuccess {
+		log.Printf("ERROR: Permintaan dana %d dilaporkan dibayar dengan transaksi %s berstatus %s", request.ID, transaction.Reference, transaction.TransactionStatus)
+		return
+	}
+	share, err := s.repo.FindShareByPaymentRequest(request.ID)
+	if err != nil {
+		return
+	}
+
+	settlement := &SplitSettlement{
+		GroupID:              share.GroupID,
+		FromUserID:           request.PayerID,
+		ToUserID:             request.RequesterID,
+		Amount:               request.Amount,
+		TransactionReference: transaction.Reference,
+		PaymentRequestID:     &request.ID,
+	}
+	touched, leftover, err := s.repo.ApplySettlement(settlement, share.ID)
+	if err != nil {
+		log.Printf("ERROR: Permintaan dana %d dibayar tetapi settlement split bill gagal dicatat: %v", request.ID, err)
+		return
+	}
+	if leftover > 0 {
+		log.Printf("ALERT: Pembayaran %s melebihi sisa utang grup %d sebesar %.2f", transaction.Reference, share.GroupID, leftover)
+	}
+
+	group, err := s.repo.FindGroup(share.GroupID)
+	if err != nil {
+		return
+	}
+	s.reissue(group, touched, request.ID)
+}
+
+// requestPayment menagih sisa utang share lewat permintaan dana atas nama kreditur.
+func (s *splitBillService) requestPayment(group *SplitGroup, description string, share *SplitShare) {
+	note := "Split bill " + group.Name
+	if description != "" {
+		note += ": " + description
+	}
+	if len(note) > 255 {
+		note = note[:255]
+	}
+
+	request, err := s.paymentRequests.Create(share.CreditorID, paymentrequests.CreateRequest{
+		PayerUserID: share.DebtorID,
+		Amount:      balance.RoundAmount(share.Outstanding(), group.Currency),
+		Currency:    group.Currency,
+		Note:        note,
+	})
+	if err != nil {
+		log.Printf("ERROR: Gagal membuat permintaan dana untuk share %d: %v", share.ID, err)
+		return
+	}
+	if err := s.repo.SetPaymentRequest(share.ID, &request.ID); err != nil {
+		log.Printf("ERROR: Gagal menautkan permintaan dana %d ke share %d: %v", request.ID, share.ID, err)
+		return
+	}
+	share.PaymentRequestID = &request.ID
+}
+
+// reissue menyesuaikan permintaan dana share yang nominalnya berubah karena
+// pelunasan: permintaan lama dibatalkan dan, bila masih ada sisa, diganti
+// permintaan baru sebesar sisa utang. Permintaan yang sudah tidak PENDING
+// (misalnya sedang dibayar) dibiarkan supaya tidak terjadi tagihan ganda.
+func (s *splitBillService) reissue(group *SplitGroup, shares []SplitShare, paidRequestID uint) {
+	for i := range shares {
+		share := &shares[i]
+		if share.PaymentRequestID == nil || *share.PaymentRequestID == paidRequestID {
+			continue
+		}
+		if _, err := s.paymentRequests.Cancel(share.CreditorID, *share.PaymentRequestID); err != nil {
+			continue
+		}
+		if share.Status != ShareSettled {
+			s.requestPayment(group, "sisa tagihan", share)
+		}
+	}
+}
+
+func hasMember(group *SplitGroup, userID uint) bool {
+	for _, member := range group.Members {
+		if member.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func describeRef(ref MemberRef) string {
+	switch {
+	case ref.UserID != 0:
+		return fmt.Sprint(ref.UserID)
+	case ref.Phone != "":
+		return ref.Phone
+	default:
+		return ref.Username
+	}
+}
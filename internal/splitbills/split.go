@@ -0,0 +1,152 @@
+package splitbills
+
+import (
+	"errors"
+	"ewallet-engine/internal/balance"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Split membagi amount ke participants dan mengembalikan bagian tiap user
+// dalam urutan participants. Pembagian dihitung dalam satuan terkecil mata
+// uang; sisa pembulatan diberikan satu per satu ke participant dengan sisa
+// pecahan terbesar sehingga jumlah bagian selalu sama persis dengan amount.
+func Split(amount float64, currency string, splitType SplitType, participants []Participant) ([]float64, error) {
+	if len(participants) == 0 {
+		return nil, errors.New("minimal satu anggota ikut dalam pembagian")
+	}
+	seen := make(map[uint]bool, len(participants))
+	for _, participant := range participants {
+		if participant.UserID == 0 {
+			return nil, errors.New("user_id participant wajib diisi")
+		}
+		if seen[participant.UserID] {
+			return nil, fmt.Errorf("user_id %d muncul lebih dari sekali", participant.UserID)
+		}
+		seen[participant.UserID] = true
+	}
+
+	total := balance.ToMinor(amount, currency)
+	weights := make([]float64, len(participants))
+
+	switch splitType {
+	case SplitEqual:
+		for i := range weights {
+			weights[i] = 1
+		}
+	case SplitShares:
+		for i, participant := range participants {
+			if participant.Weight <= 0 {
+				return nil, fmt.Errorf("weight user_id %d harus lebih dari 0", participant.UserID)
+			}
+			weights[i] = participant.Weight
+		}
+	case SplitExact:
+		amounts := make([]float64, len(participants))
+		var sum int64
+		for i, participant := range participants {
+			if participant.Amount < 0 {
+				return nil, fmt.Errorf("nominal user_id %d tidak boleh negatif", participant.UserID)
+			}
+			minor := balance.ToMinor(participant.Amount, currency)
+			sum += minor
+			amounts[i] = balance.FromMinor(minor, currency)
+		}
+		if sum != total {
+			return nil, fmt.Errorf("jumlah pembagian %.2f tidak sama dengan total %.2f", balance.FromMinor(sum, currency), balance.FromMinor(total, currency))
+		}
+		return amounts, nil
+	default:
+		return nil, fmt.Errorf("split_type tidak dikenal: %s", splitType)
+	}
+
+	var weightSum float64
+	for _, weight := range weights {
+		weightSum += weight
+	}
+
+	minors := make([]int64, len(participants))
+	remainders := make([]float64, len(participants))
+	var allocated int64
+	for i, weight := range weights {
+		exact := float64(total) * weight / weightSum
+		minors[i] = int64(math.Floor(exact))
+		remainders[i] = exact - float64(minors[i])
+		allocated += minors[i]
+	}
+
+	order := make([]int, len(participants))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return remainders[order[a]] > remainders[order[b]] })
+	for i := 0; allocated < total; i++ {
+		minors[order[i%len(order)]]++
+		allocated++
+	}
+
+	amounts := make([]float64, len(participants))
+	for i, minor := range minors {
+		amounts[i] = balance.FromMinor(minor, currency)
+	}
+	return amounts, nil
+}
+
+// Simplify mengubah saldo bersih anggota (positif berarti berpiutang) menjadi
+// daftar transfer seminimal mungkin: debitur terbesar membayar kreditur
+// terbesar berulang-ulang sampai semua saldo nol. Urutan dibuat deterministik
+// lewat user_id supaya hasil ringkasan stabil.
+func Simplify(balances map[uint]float64, currency string) []Debt {
+	type position struct {
+		userID uint
+		minor  int64
+	}
+
+	var creditors, debtors []position
+	for userID, amount := range balances {
+		minor := balance.ToMinor(amount, currency)
+		switch {
+		case minor > 0:
+			creditors = append(creditors, position{userID, minor})
+		case minor < 0:
+			debtors = append(debtors, position{userID, -minor})
+		}
+	}
+
+	byAmount := func(list []position) func(a, b int) bool {
+		return func(a, b int) bool {
+			if list[a].minor != list[b].minor {
+				return list[a].minor > list[b].minor
+			}
+			return list[a].userID < list[b].userID
+		}
+	}
+
+	debts := []Debt{}
+	for len(creditors) > 0 && len(debtors) > 0 {
+		sort.Slice(creditors, byAmount(creditors))
+		sort.Slice(debtors, byAmount(debtors))
+
+		amount := creditors[0].minor
+		if debtors[0].minor < amount {
+			amount = debtors[0].minor
+		}
+		debts = append(debts, Debt{From: debtors[0].userID, To: creditors[0].userID, Amount: balance.FromMinor(amount, currency)})
+
+		creditors[0].minor -= amount
+		debtors[0].minor -= amount
+		if creditors[0].minor == 0 {
+			creditors = creditors[1:]
+		}
+		if debtors[0].minor == 0 {
+			debtors = debtors[1:]
+		}
+	}
+	return debts
+}
+
+// Outstanding adalah sisa utang yang belum dilunasi.
+func (s SplitShare) Outstanding() float64 {
+	return s.Amount - s.SettledAmount
+}
@@ -0,0 +1,91 @@
+package splitbills
+
+import "testing"
+
+func sum(amounts []float64) float64 {
+	var total float64
+	for _, amount := range amounts {
+		total += amount
+	}
+	return total
+}
+
+func TestSplitEqualDistributesRemainder(t *testing.T) {
+	participants := []Participant{{UserID: 1}, {UserID: 2}, {UserID: 3}}
+
+	amounts, err := Split(100000, "IDR", SplitEqual, participants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum(amounts) != 100000 {
+		t.Fatalf("sum = %v, want 100000", sum(amounts))
+	}
+	for _, amount := range amounts {
+		if amount != 33333 && amount != 33334 {
+			t.Fatalf("unexpected share %v", amount)
+		}
+	}
+
+	usd, err := Split(10, "USD", SplitEqual, participants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usd[0] != 3.34 || usd[1] != 3.33 || usd[2] != 3.33 {
+		t.Fatalf("USD shares = %v", usd)
+	}
+}
+
+func TestSplitShares(t *testing.T) {
+	participants := []Participant{{UserID: 1, Weight: 2}, {UserID: 2, Weight: 1}, {UserID: 3, Weight: 1}}
+
+	amounts, err := Split(90000, "IDR", SplitShares, participants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amounts[0] != 45000 || amounts[1] != 22500 || amounts[2] != 22500 {
+		t.Fatalf("shares = %v", amounts)
+	}
+
+	if _, err := Split(90000, "IDR", SplitShares, []Participant{{UserID: 1, Weight: 0}}); err == nil {
+		t.Fatal("expected error for zero weight")
+	}
+}
+
+func TestSplitExactMustMatchTotal(t *testing.T) {
+	participants := []Participant{{UserID: 1, Amount: 60000}, {UserID: 2, Amount: 30000}}
+
+	if _, err := Split(100000, "IDR", SplitExact, participants); err == nil {
+		t.Fatal("expected error when exact amounts do not add up")
+	}
+	amounts, err := Split(90000, "IDR", SplitExact, participants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amounts[0] != 60000 || amounts[1] != 30000 {
+		t.Fatalf("exact = %v", amounts)
+	}
+}
+
+func TestSplitRejectsDuplicateParticipant(t *testing.T) {
+	if _, err := Split(1000, "IDR", SplitEqual, []Participant{{UserID: 1}, {UserID: 1}}); err == nil {
+		t.Fatal("expected error for duplicate participant")
+	}
+}
+
+func TestSimplifyMinimisesTransfers(t *testing.T) {
+	// Anggota 1 berpiutang, anggota 2 dan 3 berutang: cukup dua transfer
+	// langsung ke anggota 1 walaupun utang aslinya berantai.
+	balances := map[uint]float64{1: 70000, 2: -20000, 3: -50000}
+
+	debts := Simplify(balances, "IDR")
+	if len(debts) != 2 {
+		t.Fatalf("debts = %+v, want 2 transfers", debts)
+	}
+	if debts[0] != (Debt{From: 3, To: 1, Amount: 50000}) || debts[1] != (Debt{From: 2, To: 1, Amount: 20000}) {
+		t.Fatalf("debts = %+v", debts)
+	}
+
+	if got := Simplify(map[uint]float64{1: 0, 2: 0}, "IDR"); len(got) != 0 {
+		t.Fatalf("settled group should need no transfers, got %+v", got)
+	}
+}
@@ -1,15 +1,19 @@
 package transactions
 
 import (
+	"ewallet-engine/internal/audit"
+	"ewallet-engine/internal/auth"
+
 	"github.com/gofiber/fiber/v2"
 )
 
 type TransactionHandler struct {
-	service TransactionService
+	service      TransactionService
+	auditService audit.AuditService
 }
 
-func NewTransactionHandler(service TransactionService) *TransactionHandler {
-	return &TransactionHandler{service: service}
+func NewTransactionHandler(service TransactionService, auditService audit.AuditService) *TransactionHandler {
+	return &TransactionHandler{service: service, auditService: auditService}
 }
 
 func (h *TransactionHandler) CreateTransactionHandler(c *fiber.Ctx) error {
@@ -21,35 +25,147 @@ func (h *TransactionHandler) CreateTransactionHandler(c *fiber.Ctx) error {
 		Reference       string        `json:"reference"`
 		Description     string        `json:"description"`
 		AdditionalInfo  AdditionalInfo `json:"additional_info"`
+		OriginalReference string       `json:"original_reference"`
+		Currency          string       `json:"currency"`
+		PromoCode         string       `json:"promo_code"`
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	if request.AdditionalInfo == nil {
+		request.AdditionalInfo = make(AdditionalInfo)
+	}
+	request.AdditionalInfo["device_id"] = c.Get("X-Device-ID")
+	request.AdditionalInfo["ip"] = c.IP()
+	if request.PromoCode != "" {
+		request.AdditionalInfo["promo_code"] = request.PromoCode
+	}
+
+	var transaction *Transaction
+	var err error
+	if request.TransactionType == TransactionRefund {
+		transaction, err = h.service.InitiateRefund(userID, request.OriginalReference, request.Amount, request.Reference, request.Description)
+	} else {
+		transaction, err = h.service.InitiateTransaction(userID, request.Amount, request.Currency, request.TransactionType, request.Reference, request.Description, request.AdditionalInfo)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	if _, held := transaction.AdditionalInfo["fraud_case_id"]; held {
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+			"message": "Transaksi dibuat dan sedang direview",
+			"data":    transaction,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Transaksi berhasil dibuat",
+		"data":    transaction,
+	})
+}
+
+func (h *TransactionHandler) TransferHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	var request struct {
+		RecipientUserID   uint           `json:"recipient_user_id"`
+		RecipientPhone    string         `json:"recipient_phone"`
+		RecipientUsername string         `json:"recipient_username"`
+		Amount            float64        `json:"amount"`
+		Currency          string         `json:"currency"`
+		Reference         string         `json:"reference"`
+		Description       string         `json:"description"`
+		AdditionalInfo    AdditionalInfo `json:"additional_info"`
+		PromoCode         string         `json:"promo_code"`
 	}
 
 	if err := c.BodyParser(&request); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
 	}
 
-	err := h.service.InitiateTransaction(userID, request.Amount, request.TransactionType, request.Reference, request.Description, request.AdditionalInfo)
+	recipient, err := h.service.FindRecipient(request.RecipientUserID, request.RecipientPhone, request.RecipientUsername)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	if request.AdditionalInfo == nil {
+		request.AdditionalInfo = make(AdditionalInfo)
+	}
+	request.AdditionalInfo["device_id"] = c.Get("X-Device-ID")
+	request.AdditionalInfo["ip"] = c.IP()
+	if request.PromoCode != "" {
+		request.AdditionalInfo["promo_code"] = request.PromoCode
+	}
+
+	transaction, err := h.service.Transfer(userID, recipient.ID, request.Amount, request.Currency, request.Reference, request.Description, request.AdditionalInfo)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
 	}
 
-	return c.JSON(fiber.Map{"message": "Transaksi berhasil dibuat"})
+	if transaction.TransactionStatus == StatusPending {
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+			"message": "Transfer dibuat dan sedang direview",
+			"data":    transaction,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Transfer berhasil",
+		"data":    transaction,
+	})
 }
 
+// UpdateTransactionHandler mengubah status transaksi milik user. Operator dan
+// admin boleh mengubah transaksi siapa pun; REFUND hanya boleh diputuskan oleh
+// mereka supaya customer tidak menyetujui refund yang diajukannya sendiri.
 func (h *TransactionHandler) UpdateTransactionHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+	staff := role == auth.RoleOperator || role == auth.RoleAdmin
+
 	var request struct {
-		Reference string          `json:"reference"`
-		Status    TransactionStatus `json:"status"`
+		Reference     string            `json:"reference"`
+		Status        TransactionStatus `json:"status"`
+		CaptureAmount float64           `json:"capture_amount"`
 	}
 
 	if err := c.BodyParser(&request); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
 	}
 
-	err := h.service.UpdateTransaction(request.Reference, request.Status)
+	before, err := h.service.GetTransactionByReference(request.Reference)
+	if err != nil || (!staff && before.UserID != userID) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": "Transaksi tidak ditemukan"})
+	}
+	if before.TransactionType == TransactionRefund && !staff {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"message": "Refund hanya dapat diproses oleh operator"})
+	}
+
+	if request.Status == StatusSuccess && request.CaptureAmount > 0 {
+		err = h.service.CaptureTransaction(request.Reference, request.CaptureAmount)
+	} else {
+		err = h.service.UpdateTransaction(request.Reference, request.Status)
+	}
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
 	}
 
+	entry := audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionTransactionStatusChanged,
+		TargetType: "transaction",
+		TargetID:   request.Reference,
+		Before:     audit.Snapshot{"transaction_status": before.TransactionStatus},
+		After:      audit.Snapshot{"transaction_status": request.Status},
+	}
+	if request.CaptureAmount > 0 {
+		entry.After["capture_amount"] = request.CaptureAmount
+	}
+	_ = h.auditService.Record(entry)
+
 	return c.JSON(fiber.Map{"message": "Status transaksi berhasil diperbarui"})
 }
 
@@ -63,3 +179,28 @@ func (h *TransactionHandler) GetTransactionHandler(c *fiber.Ctx) error {
 
 	return c.JSON(transaction)
 }
+
+func (h *TransactionHandler) GetRefundsHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+	reference := c.Params("reference")
+
+	transaction, err := h.service.GetTransactionByReference(reference)
+	if err != nil || transaction.UserID != userID {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": "Transaksi tidak ditemukan"})
+	}
+
+	refunds, err := h.service.GetRefunds(reference)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": fiber.Map{
+			"reference":       transaction.Reference,
+			"amount":          transaction.Amount,
+			"refunded_amount": transaction.RefundedAmount,
+			"status":          transaction.TransactionStatus,
+			"refunds":         refunds,
+		},
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"ewallet-engine/internal/balance"
 )
 
 type TransactionType string
@@ -13,6 +14,8 @@ const (
 	TransactionTopUp    TransactionType = "TOPUP"
 	TransactionPurchase TransactionType = "PURCHASE"
 	TransactionRefund   TransactionType = "REFUND"
+	// TransactionTransfer memindahkan saldo ke wallet user lain (P2P).
+	TransactionTransfer TransactionType = "TRANSFER"
 )
 
 type TransactionStatus string
@@ -22,6 +25,9 @@ const (
 	StatusSuccess  TransactionStatus = "SUCCESS"
 	StatusFailed   TransactionStatus = "FAILED"
 	StatusReversed TransactionStatus = "REVERSED"
+	// Status PURCHASE yang sudah dikembalikan sebagian atau seluruhnya lewat REFUND.
+	StatusPartiallyRefunded TransactionStatus = "PARTIALLY_REFUNDED"
+	StatusRefunded          TransactionStatus = "REFUNDED"
 )
 
 type AdditionalInfo map[string]interface{}
@@ -46,11 +52,29 @@ type Transaction struct {
 	ID                uint              `gorm:"primaryKey" json:"id"`
 	UserID            uint              `gorm:"not null" json:"user_id"`
 	Amount            float64           `gorm:"not null;default:0" json:"amount"`
-	TransactionType   TransactionType   `gorm:"type:enum('TOPUP','PURCHASE','REFUND');not null" json:"transaction_type"`
-	TransactionStatus TransactionStatus `gorm:"type:enum('PENDING','SUCCESS','FAILED','REVERSED');default:'PENDING'" json:"transaction_status"`
+	Currency          string            `gorm:"type:char(3);not null;default:'IDR'" json:"currency"`
+	Fee               float64           `gorm:"not null;default:0" json:"fee"`
+	// Discount adalah potongan voucher promo yang ditanggung wallet pendanaan campaign.
+	Discount          float64           `gorm:"not null;default:0" json:"discount"`
+	TransactionType   TransactionType   `gorm:"type:enum('TOPUP','PURCHASE','REFUND','TRANSFER');not null" json:"transaction_type"`
+	TransactionStatus TransactionStatus `gorm:"type:enum('PENDING','SUCCESS','FAILED','REVERSED','PARTIALLY_REFUNDED','REFUNDED');default:'PENDING'" json:"transaction_status"`
 	Reference         string            `gorm:"type:varchar(255);not null" json:"reference"`
+	// OriginalReference menunjuk PURCHASE yang dikembalikan oleh transaksi REFUND.
+	OriginalReference string  `gorm:"type:varchar(255);index" json:"original_reference,omitempty"`
+	RefundedAmount    float64 `gorm:"not null;default:0" json:"refunded_amount"`
+	// CounterpartyUserID adalah penerima dana untuk TRANSFER dan PURCHASE ke
+	// merchant; pada REFUND merchant, pemilik wallet yang didebit.
+	CounterpartyUserID uint `gorm:"index" json:"counterparty_user_id,omitempty"`
 	Description       string            `gorm:"type:varchar(255);not null" json:"description"`
 	AdditionalInfo    AdditionalInfo    `gorm:"type:json" json:"additional_info,omitempty"`
 	CreatedAt         time.Time         `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt         time.Time         `gorm:"autoUpdateTime" json:"updated_at"`
 }
+
+// Charged adalah jumlah yang dibayar user sebelum fee, yaitu Amount dikurangi diskon promo.
+func (t Transaction) Charged() float64 {
+	if t.Discount == 0 {
+		return t.Amount
+	}
+	return balance.RoundAmount(t.Amount-t.Discount, t.Currency)
+}
@@ -2,17 +2,33 @@ package transactions
 
 import (
 	"errors"
+	"ewallet-engine/internal/auth"
 	"ewallet-engine/internal/balance"
 	"log"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type TransactionRepository interface {
 	CreateTransaction(tx *Transaction) error
-	UpdateTransactionStatus(reference string, status TransactionStatus) error
+	FailTransaction(reference string) error
 	GetTransactionByReference(reference string) (*Transaction, error)
-	AdjustBalance(userID uint, txType TransactionType, amount float64, reference string) error
+	AdjustBalance(userID uint, currency string, txType TransactionType, amount float64, fee float64, reference string) error
+	ReverseBalance(userID uint, currency string, txType TransactionType, amount float64, fee float64, reference string) error
+	PlaceHold(userID uint, currency string, amount float64, reference string, expiresAt time.Time) error
+	CaptureHold(userID uint, reference string, amount float64, fee float64) error
+	ReleaseHold(userID uint, reference string) error
+	FindHold(reference string) (*balance.Hold, error)
+	SumPendingRefunds(originalReference string) (float64, error)
+	FindRefunds(originalReference string) ([]Transaction, error)
+	SettleRefund(refund *Transaction, refundable float64) error
+	UpdateTransactionFee(reference string, fee float64) error
+	FindUser(id uint) (*auth.User, error)
+	FindUserByContact(phoneNumber string, username string) (*auth.User, error)
+	SettleTransfer(transaction *Transaction, fee float64) error
+	ReverseTransfer(transaction *Transaction) error
 }
 
 type transactionRepository struct {
@@ -27,8 +43,10 @@ func (r *transactionRepository) CreateTransaction(tx *Transaction) error {
 	return r.DB.Create(tx).Error
 }
 
-func (r *transactionRepository) UpdateTransactionStatus(reference string, status TransactionStatus) error {
-	return r.DB.Model(&Transaction{}).Where("reference = ?", reference).Update("transaction_status", status).Error
+// FailTransaction mengubah transaksi PENDING menjadi FAILED. Status akhir
+// tidak bisa diubah lagi.
+func (r *transactionRepository) FailTransaction(reference string) error {
+	return claimSettlement(r.DB, reference, StatusFailed)
 }
 
 func (r *transactionRepository) GetTransactionByReference(reference string) (*Transaction, error) {
@@ -40,47 +58,309 @@ func (r *transactionRepository) GetTransactionByReference(reference string) (*Tr
 	return &tx, nil
 }
 
-func (r *transactionRepository) AdjustBalance(userID uint, txType TransactionType, amount float64, reference string) error {
-	var wallet balance.Wallet
+// AdjustBalance membukukan transaksi PENDING tanpa hold dan menandainya
+// SUCCESS dalam satu transaksi database.
+func (r *transactionRepository) AdjustBalance(userID uint, currency string, txType TransactionType, amount float64, fee float64, reference string) error {
+	var walletTxType string
+	if txType == TransactionTopUp || txType == TransactionRefund {
+		walletTxType = "CREDIT"
+	} else if txType == TransactionPurchase {
+		walletTxType = "DEBIT"
+	}
+
+	return r.applyWalletChange(userID, currency, walletTxType, amount, fee, false, reference)
+}
 
-	err := r.DB.Where("user_id = ?", userID).First(&wallet).Error
+// ReverseBalance membalik efek AdjustBalance untuk transaksi yang sudah SUCCESS
+// dan menandainya REVERSED dalam transaksi database yang sama.
+func (r *transactionRepository) ReverseBalance(userID uint, currency string, txType TransactionType, amount float64, fee float64, reference string) error {
+	var walletTxType string
+	if txType == TransactionTopUp || txType == TransactionRefund {
+		walletTxType = "DEBIT"
+	} else if txType == TransactionPurchase {
+		walletTxType = "CREDIT"
+	}
+
+	return r.applyWalletChange(userID, currency, walletTxType, amount, fee, true, reference)
+}
+
+// applyWalletChange menerapkan mutasi dan baris fee-nya dalam satu transaksi
+// database setelah mengklaim status transaksi reference: PENDING menjadi
+// SUCCESS, atau SUCCESS menjadi REVERSED jika reverse. Reversal mengembalikan
+// fee ke user alih-alih memotongnya.
+func (r *transactionRepository) applyWalletChange(userID uint, currency string, walletTxType string, amount float64, fee float64, reverse bool, reference string) error {
+	wallet, err := balance.FindUserWallet(r.DB, userID, currency, false)
 	if err != nil {
 		log.Printf("ERROR: Wallet tidak ditemukan untuk user_id %d, error: %v", userID, err)
 		return err
 	}
 
-	var walletTxType string
-	if txType == TransactionTopUp || txType == TransactionRefund {
-		wallet.Balance += amount
-		walletTxType = "CREDIT"
-	} else if txType == TransactionPurchase {
-		if wallet.Balance < amount {
-			log.Printf("ERROR: Saldo tidak mencukupi untuk user_id %d", userID)
-			return errors.New("saldo tidak mencukupi")
+	err = r.DB.Transaction(func(tx *gorm.DB) error {
+		if reverse {
+			if err := claimReversal(tx, reference); err != nil {
+				return err
+			}
+		} else if err := claimSettlement(tx, reference, StatusSuccess); err != nil {
+			return err
 		}
-		wallet.Balance -= amount
-		walletTxType = "DEBIT"
+		if _, err := balance.ApplyWalletEntry(tx, wallet.ID, walletTxType, amount, reference); err != nil {
+			return err
+		}
+		if reverse {
+			return balance.ReverseFee(tx, wallet, fee, reference)
+		}
+		return balance.PostFee(tx, wallet, fee, reference)
+	})
+	if err != nil {
+		log.Printf("ERROR: Gagal memperbarui saldo user_id %d, error: %v", userID, err)
+		return err
 	}
 
-	err = r.DB.Save(&wallet).Error
+	log.Printf("SUCCESS: Saldo user_id %d berhasil diperbarui, transaksi disimpan.", userID)
+	return nil
+}
+
+func (r *transactionRepository) PlaceHold(userID uint, currency string, amount float64, reference string, expiresAt time.Time) error {
+	wallet, err := balance.FindUserWallet(r.DB, userID, currency, false)
 	if err != nil {
-		log.Printf("ERROR: Gagal menyimpan saldo user_id %d, error: %v", userID, err)
+		if errors.Is(err, balance.ErrWalletNotFound) {
+			return balance.ErrInsufficientBalance
+		}
 		return err
 	}
 
-	walletTransaction := balance.WalletTransaction{
-		WalletID:             wallet.ID,
-		Amount:               amount,
-		WalletTransactionType: walletTxType,
-		Reference:            reference,
+	_, err = balance.PlaceHold(r.DB, wallet.ID, amount, reference, expiresAt)
+	if err != nil {
+		return err
 	}
 
-	err = r.DB.Create(&walletTransaction).Error
+	log.Printf("SUCCESS: Hold %.2f untuk transaksi %s dibuat pada wallet user_id %d", amount, reference, userID)
+	return nil
+}
+
+// CaptureHold meng-capture hold PURCHASE dan menandai transaksinya SUCCESS
+// dalam satu transaksi database.
+func (r *transactionRepository) CaptureHold(userID uint, reference string, amount float64, fee float64) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := claimSettlement(tx, reference, StatusSuccess); err != nil {
+			return err
+		}
+		_, err := balance.CaptureHold(tx, userID, reference, amount, fee)
+		return err
+	})
+}
+
+func (r *transactionRepository) UpdateTransactionFee(reference string, fee float64) error {
+	return r.DB.Model(&Transaction{}).Where("reference = ?", reference).Update("fee", fee).Error
+}
+
+func (r *transactionRepository) ReleaseHold(userID uint, reference string) error {
+	return balance.ReleaseHold(r.DB, userID, reference, balance.HoldReleased)
+}
+
+func (r *transactionRepository) FindHold(reference string) (*balance.Hold, error) {
+	var hold balance.Hold
+	err := r.DB.Where("reference = ?", reference).First(&hold).Error
 	if err != nil {
-		log.Printf("ERROR: Gagal menyimpan transaksi wallet untuk user_id %d, error: %v", userID, err)
-		return errors.New("gagal menyimpan transaksi saldo")
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, balance.ErrHoldNotFound
+		}
+		return nil, err
 	}
+	return &hold, nil
+}
 
-	log.Printf("SUCCESS: Saldo user_id %d berhasil diperbarui, transaksi disimpan.", userID)
+func (r *transactionRepository) SumPendingRefunds(originalReference string) (float64, error) {
+	var total float64
+	err := r.DB.Model(&Transaction{}).
+		Where("original_reference = ? AND transaction_type = ? AND transaction_status = ?", originalReference, TransactionRefund, StatusPending).
+		Select("COALESCE(SUM(amount), 0)").Scan(&total).Error
+	return total, err
+}
+
+func (r *transactionRepository) FindRefunds(originalReference string) ([]Transaction, error) {
+	var refunds []Transaction
+	err := r.DB.Where("original_reference = ? AND transaction_type = ?", originalReference, TransactionRefund).Order("id ASC").Find(&refunds).Error
+	return refunds, err
+}
+
+// SettleRefund menandai REFUND sebagai SUCCESS, menambah refunded_amount pada
+// PURCHASE asal dan mengkredit wallet user dalam satu transaksi database. Update bersyarat menjamin total refund
+// tidak pernah melebihi refundable walaupun ada refund yang diproses bersamaan.
+func (r *transactionRepository) SettleRefund(refund *Transaction, refundable float64) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		claimed := tx.Model(&Transaction{}).
+			Where("reference = ? AND transaction_status = ?", refund.Reference, StatusPending).
+			Update("transaction_status", StatusSuccess)
+		if claimed.Error != nil {
+			return claimed.Error
+		}
+		if claimed.RowsAffected != 1 {
+			return ErrRefundAlreadyProcessed
+		}
+
+		// MySQL mengevaluasi SET dari kiri ke kanan (GORM mengurutkan kolom map),
+		// jadi CASE di bawah sudah melihat refunded_amount yang baru.
+		result := tx.Model(&Transaction{}).
+			Where("reference = ? AND transaction_status IN ? AND refunded_amount + ? <= ?",
+				refund.OriginalReference, []TransactionStatus{StatusSuccess, StatusPartiallyRefunded}, refund.Amount, refundable+refundTolerance).
+			Updates(map[string]interface{}{
+				"refunded_amount": gorm.Expr("refunded_amount + ?", refund.Amount),
+				"transaction_status": gorm.Expr("CASE WHEN refunded_amount >= ? THEN ? ELSE ? END",
+					refundable-refundTolerance, StatusRefunded, StatusPartiallyRefunded),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return ErrRefundExceedsCaptured
+		}
+
+		// Refund pembayaran merchant didanai dari wallet settlement merchant.
+		if refund.CounterpartyUserID != 0 {
+			merchantWallet, err := balance.FindUserWallet(tx, refund.CounterpartyUserID, refund.Currency, false)
+			if err != nil {
+				return err
+			}
+			if _, err := balance.ApplyWalletEntry(tx, merchantWallet.ID, "DEBIT", refund.Amount, refund.Reference); err != nil {
+				return err
+			}
+		}
+
+		wallet, err := balance.FindUserWallet(tx, refund.UserID, refund.Currency, false)
+		if err != nil {
+			return err
+		}
+
+		_, err = balance.ApplyWalletEntry(tx, wallet.ID, "CREDIT", refund.Amount, refund.Reference)
+		return err
+	})
+}
+
+func (r *transactionRepository) FindUser(id uint) (*auth.User, error) {
+	var user auth.User
+	if err := r.DB.First(&user, id).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindUserByContact mencari user berdasarkan nomor HP atau username, mana yang diisi.
+func (r *transactionRepository) FindUserByContact(phoneNumber string, username string) (*auth.User, error) {
+	var user auth.User
+	query := r.DB
+	if phoneNumber != "" {
+		query = query.Where("phone_number = ?", phoneNumber)
+	} else {
+		query = query.Where("username = ?", username)
+	}
+	if err := query.First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SettleTransfer menandai transaksi SUCCESS, meng-capture hold pengirim
+// (beserta fee) dan mengkredit penerima dalam satu transaksi database. Kedua
+// wallet dikunci berurutan menurut id supaya transfer dua arah yang bersamaan
+// tidak deadlock.
+func (r *transactionRepository) SettleTransfer(transaction *Transaction, fee float64) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := claimSettlement(tx, transaction.Reference, StatusSuccess); err != nil {
+			return err
+		}
+
+		sender, recipient, err := lockTransferWallets(tx, transaction)
+		if err != nil {
+			return err
+		}
+
+		// Bagian diskon promo dikredit ke penerima dari wallet pendanaan campaign.
+		charged := transaction.Charged()
+		if _, err := balance.CaptureHold(tx, transaction.UserID, transaction.Reference, charged, fee); err != nil {
+			return err
+		}
+		if _, err := balance.ApplyWalletEntry(tx, recipient.ID, "CREDIT", charged, transaction.Reference); err != nil {
+			return err
+		}
+
+		log.Printf("SUCCESS: Transfer %s sebesar %.2f dari wallet %d ke wallet %d", transaction.Reference, charged, sender.ID, recipient.ID)
+		return nil
+	})
+}
+
+// ReverseTransfer mengembalikan dana TRANSFER ke pengirim, termasuk fee-nya,
+// dan menandainya REVERSED. Hanya jumlah yang dibayar pengirim yang
+// dikembalikan; diskon promo tidak ditarik dari penerima. Gagal jika saldo
+// penerima sudah tidak mencukupi.
+func (r *transactionRepository) ReverseTransfer(transaction *Transaction) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := claimReversal(tx, transaction.Reference); err != nil {
+			return err
+		}
+
+		sender, recipient, err := lockTransferWallets(tx, transaction)
+		if err != nil {
+			return err
+		}
+
+		charged := transaction.Charged()
+		if _, err := balance.ApplyWalletEntry(tx, recipient.ID, "DEBIT", charged, transaction.Reference); err != nil {
+			return err
+		}
+		if _, err := balance.ApplyWalletEntry(tx, sender.ID, "CREDIT", charged, transaction.Reference); err != nil {
+			return err
+		}
+		return balance.ReverseFee(tx, sender, transaction.Fee, transaction.Reference)
+	})
+}
+
+// claimReversal mengubah status SUCCESS menjadi REVERSED secara bersyarat, jadi
+// dua reversal yang berjalan bersamaan tidak bisa sama-sama mengembalikan saldo.
+func claimReversal(tx *gorm.DB, reference string) error {
+	result := tx.Model(&Transaction{}).
+		Where("reference = ? AND transaction_status = ?", reference, StatusSuccess).
+		Update("transaction_status", StatusReversed)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return ErrAlreadyReversed
+	}
+	return nil
+}
+
+// claimSettlement mengubah status PENDING menjadi status akhir secara
+// bersyarat, jadi transaksi yang sudah SUCCESS, FAILED atau REVERSED tidak
+// bisa diselesaikan ulang.
+func claimSettlement(tx *gorm.DB, reference string, status TransactionStatus) error {
+	result := tx.Model(&Transaction{}).
+		Where("reference = ? AND transaction_status = ?", reference, StatusPending).
+		Update("transaction_status", status)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return ErrAlreadySettled
+	}
 	return nil
 }
+
+func lockTransferWallets(tx *gorm.DB, transaction *Transaction) (*balance.Wallet, *balance.Wallet, error) {
+	sender, err := balance.FindUserWallet(tx, transaction.UserID, transaction.Currency, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	recipient, err := balance.FindUserWallet(tx, transaction.CounterpartyUserID, transaction.Currency, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var locked []balance.Wallet
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", []uint{sender.ID, recipient.ID}).Order("id ASC").Find(&locked).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	return sender, recipient, nil
+}
@@ -2,30 +2,223 @@ package transactions
 
 import (
 	"errors"
+	"ewallet-engine/internal/auth"
+	"ewallet-engine/internal/balance"
+	"ewallet-engine/internal/fraud"
+	"ewallet-engine/internal/limits"
+	"ewallet-engine/internal/loyalty"
+	"ewallet-engine/internal/promotions"
+	"ewallet-engine/internal/screening"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultHoldTTL = 72 * time.Hour
+	// refundTolerance menyerap selisih pembulatan float saat membandingkan total refund.
+	refundTolerance = 0.005
+)
+
+var (
+	ErrRefundExceedsCaptured  = errors.New("total refund melebihi jumlah yang dibayar pada transaksi asal")
+	ErrRefundAlreadyProcessed = errors.New("refund sudah diproses")
+	ErrInsufficientFunds      = errors.New("saldo tidak mencukupi")
+	ErrRecipientNotFound      = errors.New("penerima tidak ditemukan")
+	ErrReferenceUsed          = errors.New("reference sudah digunakan")
+	ErrAlreadyReversed        = errors.New("transaksi sudah tidak berstatus SUCCESS")
+	ErrAlreadySettled         = errors.New("transaksi sudah diselesaikan")
+	ErrInvalidStatus          = errors.New("status transaksi hanya dapat diubah menjadi SUCCESS atau FAILED")
 )
 
 type TransactionService interface {
-	InitiateTransaction(userID uint, amount float64, txType TransactionType, reference string, description string, additionalInfo AdditionalInfo) error
+	InitiateTransaction(userID uint, amount float64, currency string, txType TransactionType, reference string, description string, additionalInfo AdditionalInfo) (*Transaction, error)
 	UpdateTransaction(reference string, status TransactionStatus) error
+	CaptureTransaction(reference string, amount float64) error
+	InitiateRefund(userID uint, originalReference string, amount float64, reference string, description string) (*Transaction, error)
+	RefundMerchantPayment(merchantUserID uint, originalReference string, amount float64, reference string, description string) (*Transaction, error)
+	Transfer(userID uint, recipientUserID uint, amount float64, currency string, reference string, description string, additionalInfo AdditionalInfo) (*Transaction, error)
+	PayMerchant(userID uint, merchantUserID uint, amount float64, currency string, reference string, description string, additionalInfo AdditionalInfo) (*Transaction, error)
+	FindRecipient(recipientUserID uint, phoneNumber string, username string) (*auth.User, error)
+	GetRefunds(originalReference string) ([]Transaction, error)
 	GetTransactionByReference(reference string) (*Transaction, error)
+	ReverseTransaction(reference string) error
 }
 
 type transactionService struct {
-	txRepo TransactionRepository
+	txRepo      TransactionRepository
+	limiter     limits.LimitService
+	creditGuard balance.CreditGuard
+	fraud       fraud.FraudService
+	screening   screening.ScreeningService
+	fees        balance.FeeCalculator
+	promotions  promotions.PromotionService
+	loyalty     loyalty.LoyaltyService
+	holdTTL     time.Duration
+}
+
+func NewTransactionService(repo TransactionRepository, limiter limits.LimitService, creditGuard balance.CreditGuard, fraudService fraud.FraudService, screeningService screening.ScreeningService, fees balance.FeeCalculator, promotionService promotions.PromotionService, loyaltyService loyalty.LoyaltyService) TransactionService {
+	holdTTL := defaultHoldTTL
+	if hours, err := strconv.Atoi(os.Getenv("WALLET_HOLD_TTL_HOURS")); err == nil && hours > 0 {
+		holdTTL = time.Duration(hours) * time.Hour
+	}
+
+	return &transactionService{txRepo: repo, limiter: limiter, creditGuard: creditGuard, fraud: fraudService, screening: screeningService, fees: fees, promotions: promotionService, loyalty: loyaltyService, holdTTL: holdTTL}
+}
+
+// channelOf mengambil channel pembayaran dari AdditionalInfo untuk memilih jadwal fee.
+func channelOf(additionalInfo AdditionalInfo) string {
+	channel, _ := additionalInfo["channel"].(string)
+	return channel
+}
+
+// promoCodeOf mengambil kode voucher promo yang dipakai transaksi.
+func promoCodeOf(additionalInfo AdditionalInfo) string {
+	code, _ := additionalInfo["promo_code"].(string)
+	return code
+}
+
+// redeemPromo mencadangkan benefit voucher untuk transaksi. Diskon langsung
+// mengurangi jumlah yang di-hold dari user, sedangkan cashback baru dikredit
+// setelah transaksi SUCCESS.
+func (s *transactionService) redeemPromo(transaction *Transaction) error {
+	code := promoCodeOf(transaction.AdditionalInfo)
+	if code == "" {
+		return nil
+	}
+
+	redemption, err := s.promotions.Redeem(promotions.Claim{
+		UserID:             transaction.UserID,
+		Code:               code,
+		TransactionType:    string(transaction.TransactionType),
+		Currency:           transaction.Currency,
+		Amount:             transaction.Amount,
+		Reference:          transaction.Reference,
+		CounterpartyUserID: transaction.CounterpartyUserID,
+	})
+	if err != nil {
+		return err
+	}
+	transaction.AdditionalInfo["promo_code"] = redemption.Code
+	if redemption.Kind == promotions.KindDiscount {
+		transaction.Discount = redemption.Benefit
+	}
+	return nil
+}
+
+// releasePromo membatalkan redemption transaksi yang gagal dibuat.
+func (s *transactionService) releasePromo(transaction *Transaction) {
+	if promoCodeOf(transaction.AdditionalInfo) != "" {
+		s.promotions.Settle(transaction.Reference, false)
+	}
+}
+
+// feeFor menghitung biaya TOPUP, PURCHASE dan TRANSFER; REFUND tidak dikenai biaya.
+func (s *transactionService) feeFor(userID uint, txType TransactionType, additionalInfo AdditionalInfo, currency string, amount float64) (float64, error) {
+	if txType != TransactionTopUp && txType != TransactionPurchase && txType != TransactionTransfer {
+		return 0, nil
+	}
+
+	fee, err := s.fees.CalculateFee(userID, string(txType), channelOf(additionalInfo), currency, amount)
+	if err != nil {
+		return 0, err
+	}
+	if txType == TransactionTopUp && fee >= amount {
+		return 0, errors.New("jumlah top up harus lebih besar dari biaya")
+	}
+	return fee, nil
 }
 
-func NewTransactionService(repo TransactionRepository) TransactionService {
-	return &transactionService{txRepo: repo}
+// limitOperation memetakan jenis transaksi ke operasi limit; REFUND tidak dibatasi.
+// Limit dinyatakan dalam balance.DefaultCurrency sehingga transaksi mata uang
+// lain tidak dihitung.
+func limitOperation(txType TransactionType, currency string) (limits.Operation, bool) {
+	if currency != balance.DefaultCurrency {
+		return "", false
+	}
+
+	switch txType {
+	case TransactionTopUp:
+		return limits.OperationTopUp, true
+	case TransactionPurchase:
+		return limits.OperationDebit, true
+	case TransactionTransfer:
+		return limits.OperationTransfer, true
+	}
+	return "", false
 }
 
-func (s *transactionService) InitiateTransaction(userID uint, amount float64, txType TransactionType, reference string, description string, additionalInfo AdditionalInfo) error {
-	if amount <= 0 {
-		return errors.New("jumlah transaksi tidak valid")
+func (s *transactionService) InitiateTransaction(userID uint, amount float64, currency string, txType TransactionType, reference string, description string, additionalInfo AdditionalInfo) (*Transaction, error) {
+	currency, err := balance.NormalizeCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+	if err := balance.ValidateAmount(amount, currency); err != nil {
+		return nil, err
+	}
+
+	if operation, ok := limitOperation(txType, currency); ok {
+		if err := s.limiter.Check(userID, operation, amount); err != nil {
+			return nil, err
+		}
+	}
+
+	if txType == TransactionRefund {
+		return nil, errors.New("refund harus merujuk transaksi asal lewat original_reference")
+	}
+	if txType == TransactionTransfer {
+		return nil, errors.New("transfer harus menyebutkan penerima lewat endpoint transfer")
+	}
+
+	if additionalInfo == nil {
+		additionalInfo = make(AdditionalInfo)
+	}
+
+	if counterparty, _ := additionalInfo["counterparty_name"].(string); counterparty != "" {
+		hit, err := s.screening.ScreenCounterparty(userID, counterparty, reference)
+		if err != nil {
+			return nil, err
+		}
+		if hit {
+			return nil, errors.New("transaksi tidak dapat diproses, silakan hubungi customer service")
+		}
+	}
+
+	if txType == TransactionPurchase || txType == TransactionTopUp {
+		deviceID, _ := additionalInfo["device_id"].(string)
+		ip, _ := additionalInfo["ip"].(string)
+
+		evaluation, err := s.fraud.Screen(fraud.Event{
+			UserID:    userID,
+			Operation: string(txType),
+			Amount:    amount,
+			Reference: reference,
+			DeviceID:  deviceID,
+			IP:        ip,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if evaluation.Decision == fraud.DecisionBlock {
+			return nil, errors.New("transaksi ditolak oleh sistem deteksi fraud")
+		}
+		if evaluation.Decision == fraud.DecisionReview {
+			additionalInfo["fraud_case_id"] = evaluation.CaseID
+		}
+	}
+
+	fee, err := s.feeFor(userID, txType, additionalInfo, currency, amount)
+	if err != nil {
+		return nil, err
 	}
 
 	transaction := Transaction{
 		UserID:            userID,
 		Amount:            amount,
+		Currency:          currency,
+		Fee:               fee,
 		TransactionType:   txType,
 		TransactionStatus: StatusPending,
 		Reference:         reference,
@@ -33,7 +226,311 @@ func (s *transactionService) InitiateTransaction(userID uint, amount float64, tx
 		AdditionalInfo:    additionalInfo,
 	}
 
-	return s.txRepo.CreateTransaction(&transaction)
+	if err := s.redeemPromo(&transaction); err != nil {
+		return nil, err
+	}
+
+	// PURCHASE mencadangkan saldo beserta biayanya sejak dibuat supaya tidak
+	// gagal karena saldo kurang saat merchant menyelesaikannya.
+	if txType == TransactionPurchase {
+		holdAmount := balance.RoundAmount(transaction.Charged()+fee, currency)
+		if err := s.txRepo.PlaceHold(userID, currency, holdAmount, reference, time.Now().Add(s.holdTTL)); err != nil {
+			s.releasePromo(&transaction)
+			return nil, err
+		}
+	}
+
+	if err := s.txRepo.CreateTransaction(&transaction); err != nil {
+		if txType == TransactionPurchase {
+			if releaseErr := s.txRepo.ReleaseHold(userID, reference); releaseErr != nil {
+				log.Printf("ERROR: Gagal melepas hold transaksi %s: %v", reference, releaseErr)
+			}
+		}
+		s.releasePromo(&transaction)
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// InitiateRefund membuat REFUND PENDING untuk PURCHASE milik user. Beberapa
+// refund parsial diperbolehkan selama totalnya, termasuk refund yang masih
+// PENDING, tidak melebihi jumlah yang di-capture.
+func (s *transactionService) InitiateRefund(userID uint, originalReference string, amount float64, reference string, description string) (*Transaction, error) {
+	original, err := s.txRepo.GetTransactionByReference(originalReference)
+	if err != nil || original.UserID != userID {
+		return nil, errors.New("transaksi asal tidak ditemukan")
+	}
+	if original.CounterpartyUserID != 0 {
+		return nil, errors.New("refund pembayaran merchant harus diajukan oleh merchant")
+	}
+	if err := s.checkRefundable(original, amount); err != nil {
+		return nil, err
+	}
+
+	refund := Transaction{
+		UserID:            userID,
+		Amount:            amount,
+		Currency:          original.Currency,
+		TransactionType:   TransactionRefund,
+		TransactionStatus: StatusPending,
+		Reference:         reference,
+		OriginalReference: originalReference,
+		Description:       description,
+		AdditionalInfo:    AdditionalInfo{},
+	}
+
+	if err := s.txRepo.CreateTransaction(&refund); err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// RefundMerchantPayment membuat REFUND atas PURCHASE ke merchant dan langsung
+// menyelesaikannya: wallet settlement merchant didebit dan customer dikredit
+// dalam batas saldo tier KYC customer. Refund dengan reference yang sama
+// dikembalikan apa adanya.
+func (s *transactionService) RefundMerchantPayment(merchantUserID uint, originalReference string, amount float64, reference string, description string) (*Transaction, error) {
+	if reference == "" {
+		return nil, errors.New("reference wajib diisi")
+	}
+	original, err := s.txRepo.GetTransactionByReference(originalReference)
+	if err != nil || original.CounterpartyUserID == 0 || original.CounterpartyUserID != merchantUserID {
+		return nil, errors.New("transaksi asal tidak ditemukan")
+	}
+
+	if existing, err := s.txRepo.GetTransactionByReference(reference); err == nil {
+		if existing.TransactionType != TransactionRefund || existing.OriginalReference != originalReference || existing.Amount != amount {
+			return nil, ErrReferenceUsed
+		}
+		return existing, nil
+	}
+
+	if err := s.checkRefundable(original, amount); err != nil {
+		return nil, err
+	}
+	if original.Currency == balance.DefaultCurrency {
+		if err := s.creditGuard.CheckCredit(original.UserID, amount); err != nil {
+			return nil, err
+		}
+	}
+
+	refund := Transaction{
+		UserID:             original.UserID,
+		Amount:             amount,
+		Currency:           original.Currency,
+		TransactionType:    TransactionRefund,
+		TransactionStatus:  StatusPending,
+		Reference:          reference,
+		OriginalReference:  originalReference,
+		CounterpartyUserID: merchantUserID,
+		Description:        description,
+		AdditionalInfo:     AdditionalInfo{},
+	}
+	if err := s.txRepo.CreateTransaction(&refund); err != nil {
+		return nil, err
+	}
+
+	if err := s.settleRefund(&refund); err != nil {
+		if updateErr := s.txRepo.FailTransaction(reference); updateErr != nil {
+			log.Printf("ERROR: Gagal menandai refund %s gagal: %v", reference, updateErr)
+		}
+		return nil, err
+	}
+	refund.TransactionStatus = StatusSuccess
+	return &refund, nil
+}
+
+// checkRefundable memastikan original adalah PURCHASE yang berhasil dan sisa
+// yang dapat dikembalikan, termasuk refund yang masih PENDING, cukup untuk amount.
+func (s *transactionService) checkRefundable(original *Transaction, amount float64) error {
+	if err := balance.ValidateAmount(amount, original.Currency); err != nil {
+		return err
+	}
+	if original.TransactionType != TransactionPurchase {
+		return errors.New("refund hanya dapat dilakukan untuk transaksi PURCHASE")
+	}
+	if original.TransactionStatus != StatusSuccess && original.TransactionStatus != StatusPartiallyRefunded {
+		return errors.New("transaksi asal belum berhasil atau sudah dikembalikan seluruhnya")
+	}
+
+	refundable, err := s.settledAmount(original)
+	if err != nil {
+		return err
+	}
+
+	pending, err := s.txRepo.SumPendingRefunds(original.Reference)
+	if err != nil {
+		return err
+	}
+	if original.RefundedAmount+pending+amount > refundable+refundTolerance {
+		return ErrRefundExceedsCaptured
+	}
+	return nil
+}
+
+func (s *transactionService) GetRefunds(originalReference string) ([]Transaction, error) {
+	return s.txRepo.FindRefunds(originalReference)
+}
+
+// FindRecipient mencari penerima transfer berdasarkan user id, nomor HP atau
+// username, dengan urutan prioritas tersebut.
+func (s *transactionService) FindRecipient(recipientUserID uint, phoneNumber string, username string) (*auth.User, error) {
+	var recipient *auth.User
+	var err error
+	switch {
+	case recipientUserID != 0:
+		recipient, err = s.txRepo.FindUser(recipientUserID)
+	case phoneNumber != "" || username != "":
+		recipient, err = s.txRepo.FindUserByContact(phoneNumber, username)
+	default:
+		return nil, errors.New("penerima wajib diisi")
+	}
+	if err != nil || recipient.ID == balance.PlatformRevenueUserID || recipient.Role == auth.RoleMerchant {
+		return nil, ErrRecipientNotFound
+	}
+	return recipient, nil
+}
+
+// Transfer memindahkan saldo ke user lain. Dana pengirim di-hold lebih dulu;
+// transfer yang ditandai review oleh fraud tetap PENDING sampai case diputuskan,
+// selain itu langsung diselesaikan. Reference yang sama dari pengirim yang sama
+// mengembalikan transaksi yang sudah ada.
+func (s *transactionService) Transfer(userID uint, recipientUserID uint, amount float64, currency string, reference string, description string, additionalInfo AdditionalInfo) (*Transaction, error) {
+	return s.transfer(TransactionTransfer, userID, recipientUserID, amount, currency, reference, description, additionalInfo)
+}
+
+// PayMerchant membuat PURCHASE dengan merchant sebagai counterparty. Alurnya
+// sama dengan Transfer, tetapi dana dikredit ke wallet settlement merchant
+// dan limit serta fee mengikuti PURCHASE.
+func (s *transactionService) PayMerchant(userID uint, merchantUserID uint, amount float64, currency string, reference string, description string, additionalInfo AdditionalInfo) (*Transaction, error) {
+	return s.transfer(TransactionPurchase, userID, merchantUserID, amount, currency, reference, description, additionalInfo)
+}
+
+func (s *transactionService) transfer(txType TransactionType, userID uint, recipientUserID uint, amount float64, currency string, reference string, description string, additionalInfo AdditionalInfo) (*Transaction, error) {
+	currency, err := balance.NormalizeCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+	if err := balance.ValidateAmount(amount, currency); err != nil {
+		return nil, err
+	}
+	if reference == "" {
+		return nil, errors.New("reference wajib diisi")
+	}
+	if recipientUserID == userID {
+		return nil, errors.New("tidak dapat transfer ke diri sendiri")
+	}
+
+	if existing, err := s.txRepo.GetTransactionByReference(reference); err == nil {
+		if existing.UserID != userID || existing.TransactionType != txType || existing.CounterpartyUserID != recipientUserID {
+			return nil, ErrReferenceUsed
+		}
+		return existing, nil
+	}
+
+	recipient, err := s.txRepo.FindUser(recipientUserID)
+	if err != nil || recipient.ID == balance.PlatformRevenueUserID || (recipient.Role == auth.RoleMerchant) != (txType == TransactionPurchase) {
+		return nil, ErrRecipientNotFound
+	}
+	if recipient.Status != auth.StatusActive {
+		return nil, errors.New("akun penerima tidak aktif")
+	}
+
+	if operation, ok := limitOperation(txType, currency); ok {
+		if err := s.limiter.Check(userID, operation, amount); err != nil {
+			return nil, err
+		}
+	}
+
+	counterparty := recipient.FullName
+	if counterparty == "" {
+		counterparty = recipient.Username
+	}
+	hit, err := s.screening.ScreenCounterparty(userID, counterparty, reference)
+	if err != nil {
+		return nil, err
+	}
+	if hit {
+		return nil, errors.New("transaksi tidak dapat diproses, silakan hubungi customer service")
+	}
+
+	if additionalInfo == nil {
+		additionalInfo = make(AdditionalInfo)
+	}
+	additionalInfo["counterparty_name"] = counterparty
+
+	deviceID, _ := additionalInfo["device_id"].(string)
+	ip, _ := additionalInfo["ip"].(string)
+	evaluation, err := s.fraud.Screen(fraud.Event{
+		UserID:    userID,
+		Operation: string(txType),
+		Amount:    amount,
+		Reference: reference,
+		DeviceID:  deviceID,
+		IP:        ip,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if evaluation.Decision == fraud.DecisionBlock {
+		return nil, errors.New("transaksi ditolak oleh sistem deteksi fraud")
+	}
+	if evaluation.Decision == fraud.DecisionReview {
+		additionalInfo["fraud_case_id"] = evaluation.CaseID
+	}
+
+	fee, err := s.feeFor(userID, txType, additionalInfo, currency, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction := Transaction{
+		UserID:             userID,
+		Amount:             amount,
+		Currency:           currency,
+		Fee:                fee,
+		TransactionType:    txType,
+		TransactionStatus:  StatusPending,
+		Reference:          reference,
+		CounterpartyUserID: recipient.ID,
+		Description:        description,
+		AdditionalInfo:     additionalInfo,
+	}
+
+	if err := s.redeemPromo(&transaction); err != nil {
+		return nil, err
+	}
+
+	holdAmount := balance.RoundAmount(transaction.Charged()+fee, currency)
+	if err := s.txRepo.PlaceHold(userID, currency, holdAmount, reference, time.Now().Add(s.holdTTL)); err != nil {
+		s.releasePromo(&transaction)
+		if errors.Is(err, balance.ErrInsufficientBalance) {
+			return nil, ErrInsufficientFunds
+		}
+		return nil, err
+	}
+
+	if err := s.txRepo.CreateTransaction(&transaction); err != nil {
+		if releaseErr := s.txRepo.ReleaseHold(userID, reference); releaseErr != nil {
+			log.Printf("ERROR: Gagal melepas hold transaksi %s: %v", reference, releaseErr)
+		}
+		s.releasePromo(&transaction)
+		return nil, err
+	}
+
+	if evaluation.Decision == fraud.DecisionReview {
+		return &transaction, nil
+	}
+
+	if err := s.settleTransaction(&transaction, StatusSuccess, amount); err != nil {
+		if failErr := s.settleTransaction(&transaction, StatusFailed, amount); failErr != nil {
+			log.Printf("ERROR: Gagal menggagalkan transfer %s: %v", reference, failErr)
+		}
+		transaction.TransactionStatus = StatusFailed
+		return nil, err
+	}
+	transaction.TransactionStatus = StatusSuccess
+	return &transaction, nil
 }
 
 func (s *transactionService) UpdateTransaction(reference string, status TransactionStatus) error {
@@ -42,21 +539,316 @@ func (s *transactionService) UpdateTransaction(reference string, status Transact
 		return errors.New("transaksi tidak ditemukan")
 	}
 
-	err = s.txRepo.UpdateTransactionStatus(reference, status)
+	return s.settleTransaction(transaction, status, transaction.Amount)
+}
+
+// CaptureTransaction menyelesaikan PURCHASE dengan jumlah yang bisa lebih kecil
+// dari jumlah yang diotorisasi; sisa hold dikembalikan ke saldo tersedia.
+func (s *transactionService) CaptureTransaction(reference string, amount float64) error {
+	transaction, err := s.txRepo.GetTransactionByReference(reference)
 	if err != nil {
+		return errors.New("transaksi tidak ditemukan")
+	}
+
+	if transaction.TransactionType != TransactionPurchase {
+		return errors.New("capture hanya berlaku untuk transaksi PURCHASE")
+	}
+	if transaction.CounterpartyUserID != 0 {
+		return errors.New("pembayaran merchant tidak dapat di-capture sebagian")
+	}
+	if transaction.Discount > 0 && amount != transaction.Amount {
+		return errors.New("transaksi dengan diskon promo tidak dapat di-capture sebagian")
+	}
+	if err := balance.ValidateAmount(amount, transaction.Currency); err != nil {
 		return err
 	}
+	if amount > transaction.Amount {
+		return errors.New("jumlah capture tidak boleh melebihi jumlah transaksi")
+	}
+
+	return s.settleTransaction(transaction, StatusSuccess, amount)
+}
+
+// settleTransaction menyelesaikan transaksi PENDING menjadi SUCCESS atau
+// FAILED dan menggerakkan saldonya. Status diklaim bersyarat di transaksi
+// database yang sama dengan pembukuan saldo, jadi SUCCESS, FAILED dan
+// REVERSED bersifat final. amount adalah jumlah yang benar-benar dibukukan
+// saat SUCCESS; limit-nya dipakai lebih dulu dan dikembalikan jika
+// penyelesaian gagal.
+func (s *transactionService) settleTransaction(transaction *Transaction, status TransactionStatus, amount float64) (err error) {
+	reference := transaction.Reference
+
+	if status != StatusSuccess && status != StatusFailed {
+		return ErrInvalidStatus
+	}
+	if transaction.TransactionStatus != StatusPending {
+		return ErrAlreadySettled
+	}
 
 	if status == StatusSuccess {
-		err = s.txRepo.AdjustBalance(transaction.UserID, transaction.TransactionType, transaction.Amount, transaction.Reference)
+		held, err := s.fraud.IsHeld(reference)
 		if err != nil {
+			return err
+		}
+		if held {
+			return errors.New("transaksi sedang direview tim fraud")
+		}
+	}
+
+	operation, limited := limitOperation(transaction.TransactionType, transaction.Currency)
+	if status == StatusSuccess && limited {
+		created, err := s.limiter.Reserve(transaction.UserID, operation, amount, reference)
+		if err != nil {
+			return err
+		}
+		// Reservasi milik percobaan lain tidak boleh dikembalikan di sini.
+		defer func() {
+			if err == nil || !created {
+				return
+			}
+			if releaseErr := s.limiter.Release(transaction.UserID, operation, reference); releaseErr != nil {
+				log.Printf("ERROR: Gagal mengembalikan limit transaksi %s: %v", reference, releaseErr)
+			}
+		}()
+	}
+
+	if status == StatusSuccess && transaction.TransactionType == TransactionTopUp && transaction.Currency == balance.DefaultCurrency {
+		if err := s.creditGuard.CheckCredit(transaction.UserID, amount); err != nil {
+			return err
+		}
+	}
+	if status == StatusSuccess && transaction.TransactionType == TransactionTransfer && transaction.Currency == balance.DefaultCurrency {
+		if err := s.creditGuard.CheckCredit(transaction.CounterpartyUserID, amount); err != nil {
+			return err
+		}
+	}
+
+	// Capture parsial dikenai biaya sesuai jumlah yang benar-benar dibayar.
+	fee := transaction.Fee
+	if status == StatusSuccess && amount != transaction.Amount {
+		recalculated, err := s.feeFor(transaction.UserID, transaction.TransactionType, transaction.AdditionalInfo, transaction.Currency, amount)
+		if err != nil {
+			return err
+		}
+		fee = recalculated
+	}
+
+	// Diskon hanya berlaku untuk capture penuh, jadi user membayar Charged().
+	charged := amount
+	if transaction.Discount > 0 {
+		charged = transaction.Charged()
+	}
+
+	booked := false
+	if status == StatusSuccess && transaction.TransactionType == TransactionRefund && transaction.OriginalReference != "" {
+		if err := s.settleRefund(transaction); err != nil {
+			return err
+		}
+		booked = true
+	}
+
+	if status == StatusSuccess && transaction.TransactionType == TransactionPurchase && transaction.CounterpartyUserID == 0 {
+		if err := s.txRepo.CaptureHold(transaction.UserID, reference, charged, fee); err != nil {
+			if errors.Is(err, ErrAlreadySettled) {
+				return err
+			}
+			if errors.Is(err, balance.ErrHoldNotActive) {
+				return errors.New("otorisasi transaksi sudah kedaluwarsa atau sudah diselesaikan")
+			}
+			// PURCHASE yang dibuat sebelum ada hold tetap didebit langsung.
+			if !errors.Is(err, balance.ErrHoldNotFound) {
+				return err
+			}
+		} else {
+			booked = true
+		}
+	}
+
+	// TRANSFER dan PURCHASE ke merchant mengkredit counterparty bersamaan dengan capture hold.
+	if status == StatusSuccess && transaction.CounterpartyUserID != 0 {
+		if err := s.txRepo.SettleTransfer(transaction, fee); err != nil {
+			if errors.Is(err, ErrAlreadySettled) {
+				return err
+			}
+			if errors.Is(err, balance.ErrHoldNotActive) {
+				return errors.New("otorisasi transaksi sudah kedaluwarsa atau sudah diselesaikan")
+			}
+			return err
+		}
+		booked = true
+	}
+
+	if status == StatusFailed {
+		if err = s.txRepo.FailTransaction(reference); err != nil {
+			return err
+		}
+	}
+
+	if status == StatusSuccess && !booked {
+		err = s.txRepo.AdjustBalance(transaction.UserID, transaction.Currency, transaction.TransactionType, charged, fee, transaction.Reference)
+		if err != nil {
+			if errors.Is(err, ErrAlreadySettled) {
+				return err
+			}
 			return errors.New("gagal memperbarui saldo user")
 		}
 	}
+	transaction.TransactionStatus = status
+
+	if status == StatusFailed && (transaction.TransactionType == TransactionPurchase || transaction.TransactionType == TransactionTransfer) {
+		err := s.txRepo.ReleaseHold(transaction.UserID, reference)
+		if err != nil && !errors.Is(err, balance.ErrHoldNotFound) && !errors.Is(err, balance.ErrHoldNotActive) {
+			log.Printf("ERROR: Gagal melepas hold transaksi %s: %v", reference, err)
+		}
+	}
+
+	// Benefit promo dibatalkan bersama transaksinya, atau dibayarkan setelah
+	// saldo transaksi SUCCESS dibukukan.
+	promo := promoCodeOf(transaction.AdditionalInfo) != ""
+	if status == StatusFailed && promo {
+		s.promotions.Settle(reference, false)
+	}
+
+	if status == StatusSuccess {
+		if fee != transaction.Fee {
+			if err := s.txRepo.UpdateTransactionFee(reference, fee); err != nil {
+				log.Printf("ERROR: Gagal memperbarui fee transaksi %s: %v", reference, err)
+			}
+		}
+
+		if promo {
+			s.promotions.Settle(reference, true)
+		}
+
+		if transaction.TransactionType == TransactionPurchase {
+			s.loyalty.Earn(loyalty.Purchase{
+				UserID:         transaction.UserID,
+				Reference:      reference,
+				Currency:       transaction.Currency,
+				Amount:         charged,
+				MerchantUserID: transaction.CounterpartyUserID,
+				Channel:        strings.ToUpper(channelOf(transaction.AdditionalInfo)),
+			})
+		}
+	}
+
+	return nil
+}
+
+func (s *transactionService) settleRefund(refund *Transaction) error {
+	if refund.TransactionStatus != StatusPending {
+		return ErrRefundAlreadyProcessed
+	}
+
+	original, err := s.txRepo.GetTransactionByReference(refund.OriginalReference)
+	if err != nil {
+		return errors.New("transaksi asal tidak ditemukan")
+	}
+
+	refundable, err := s.settledAmount(original)
+	if err != nil {
+		return err
+	}
+
+	if err := s.txRepo.SettleRefund(refund, refundable); err != nil {
+		if errors.Is(err, ErrRefundExceedsCaptured) || errors.Is(err, ErrRefundAlreadyProcessed) {
+			return err
+		}
+		if errors.Is(err, balance.ErrInsufficientBalance) {
+			return errors.New("saldo merchant tidak mencukupi untuk refund")
+		}
+		return errors.New("gagal memperbarui saldo user")
+	}
 
+	// Poin PURCHASE ditarik sebanding dengan total yang sudah direfund.
+	if original.TransactionType == TransactionPurchase {
+		refunded := original.RefundedAmount + refund.Amount
+		if updated, err := s.txRepo.GetTransactionByReference(original.Reference); err == nil {
+			refunded = updated.RefundedAmount
+		}
+		s.loyalty.RevokeRefunded(original.Reference, original.Currency, refunded, refundable)
+	}
 	return nil
 }
 
+// settledAmount mengembalikan jumlah yang benar-benar dibukukan untuk transaksi
+// SUCCESS, yaitu jumlah capture untuk PURCHASE yang di-capture parsial.
+func (s *transactionService) settledAmount(transaction *Transaction) (float64, error) {
+	if transaction.TransactionType != TransactionPurchase {
+		return transaction.Amount, nil
+	}
+
+	hold, err := s.txRepo.FindHold(transaction.Reference)
+	if err != nil {
+		if errors.Is(err, balance.ErrHoldNotFound) {
+			return transaction.Amount, nil
+		}
+		return 0, err
+	}
+	if hold.Status != balance.HoldCaptured {
+		return transaction.Amount, nil
+	}
+	return hold.CapturedAmount, nil
+}
+
 func (s *transactionService) GetTransactionByReference(reference string) (*Transaction, error) {
 	return s.txRepo.GetTransactionByReference(reference)
 }
+
+// ReverseTransaction mengembalikan saldo dari transaksi SUCCESS dan menandainya
+// REVERSED dalam satu transaksi database. Hanya jumlah yang dibayar user yang
+// dikembalikan; benefit promo yang sudah dibayarkan tidak ditarik kembali,
+// sedangkan sisa poin loyalty-nya ditarik.
+func (s *transactionService) ReverseTransaction(reference string) error {
+	transaction, err := s.txRepo.GetTransactionByReference(reference)
+	if err != nil {
+		return errors.New("transaksi tidak ditemukan")
+	}
+
+	// Reversal yang terputus sebelum poin ditarik diselesaikan saat diulang.
+	if transaction.TransactionStatus == StatusReversed {
+		s.markReversed(transaction)
+		return ErrAlreadyReversed
+	}
+	if transaction.TransactionStatus != StatusSuccess {
+		return errors.New("hanya transaksi SUCCESS yang dapat di-reverse")
+	}
+
+	if transaction.CounterpartyUserID != 0 {
+		if err := s.txRepo.ReverseTransfer(transaction); err != nil {
+			if errors.Is(err, ErrAlreadyReversed) {
+				return err
+			}
+			if errors.Is(err, balance.ErrInsufficientBalance) {
+				return errors.New("saldo penerima tidak mencukupi untuk reversal")
+			}
+			return errors.New("gagal mengembalikan saldo user")
+		}
+		s.markReversed(transaction)
+		return nil
+	}
+
+	amount, err := s.settledAmount(transaction)
+	if err != nil {
+		return err
+	}
+
+	err = s.txRepo.ReverseBalance(transaction.UserID, transaction.Currency, transaction.TransactionType, amount, transaction.Fee, transaction.Reference)
+	if err != nil {
+		if errors.Is(err, ErrAlreadyReversed) {
+			return err
+		}
+		return errors.New("gagal mengembalikan saldo user")
+	}
+
+	s.markReversed(transaction)
+	return nil
+}
+
+// markReversed menjalankan efek samping reversal setelah status REVERSED
+// tersimpan bersama pengembalian saldo.
+func (s *transactionService) markReversed(transaction *Transaction) {
+	if transaction.TransactionType == TransactionPurchase {
+		s.loyalty.Revoke(transaction.Reference)
+	}
+}
@@ -0,0 +1,222 @@
+package transactions
+
+import (
+	"errors"
+	"ewallet-engine/internal/balance"
+	"ewallet-engine/internal/loyalty"
+	"testing"
+)
+
+type fakeTransactionRepository struct {
+	TransactionRepository
+	transactions map[string]*Transaction
+	holds        map[string]*balance.Hold
+}
+
+func newFakeTransactionRepository() *fakeTransactionRepository {
+	return &fakeTransactionRepository{
+		transactions: make(map[string]*Transaction),
+		holds:        make(map[string]*balance.Hold),
+	}
+}
+
+func (r *fakeTransactionRepository) CreateTransaction(tx *Transaction) error {
+	r.transactions[tx.Reference] = tx
+	return nil
+}
+
+func (r *fakeTransactionRepository) GetTransactionByReference(reference string) (*Transaction, error) {
+	tx, ok := r.transactions[reference]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return tx, nil
+}
+
+func (r *fakeTransactionRepository) FindHold(reference string) (*balance.Hold, error) {
+	hold, ok := r.holds[reference]
+	if !ok {
+		return nil, balance.ErrHoldNotFound
+	}
+	return hold, nil
+}
+
+func (r *fakeTransactionRepository) SumPendingRefunds(originalReference string) (float64, error) {
+	total := 0.0
+	for _, tx := range r.transactions {
+		if tx.OriginalReference == originalReference && tx.TransactionStatus == StatusPending {
+			total += tx.Amount
+		}
+	}
+	return total, nil
+}
+
+func (r *fakeTransactionRepository) SettleRefund(refund *Transaction, refundable float64) error {
+	r.transactions[refund.OriginalReference].RefundedAmount += refund.Amount
+	refund.TransactionStatus = StatusSuccess
+	return nil
+}
+
+type revokeCall struct {
+	reference string
+	refunded  float64
+	paid      float64
+}
+
+type fakeLoyaltyService struct {
+	loyalty.LoyaltyService
+	revoked []revokeCall
+}
+
+func (l *fakeLoyaltyService) RevokeRefunded(reference string, currency string, refunded float64, paid float64) {
+	l.revoked = append(l.revoked, revokeCall{reference: reference, refunded: refunded, paid: paid})
+}
+
+func TestInitiateRefundCapsAtCapturedAmount(t *testing.T) {
+	repo := newFakeTransactionRepository()
+	repo.transactions["PUR-1"] = &Transaction{
+		UserID:            7,
+		Amount:            100000,
+		TransactionType:   TransactionPurchase,
+		TransactionStatus: StatusPartiallyRefunded,
+		Reference:         "PUR-1",
+		RefundedAmount:    20000,
+	}
+	repo.holds["PUR-1"] = &balance.Hold{Reference: "PUR-1", Amount: 100000, CapturedAmount: 80000, Status: balance.HoldCaptured}
+
+	service := &transactionService{txRepo: repo}
+
+	if _, err := service.InitiateRefund(7, "PUR-1", 30000, "REF-1", "refund sebagian"); err != nil {
+		t.Fatalf("expected first partial refund to be accepted: %v", err)
+	}
+
+	// 20.000 sudah direfund dan 30.000 masih PENDING dari 80.000 yang di-capture.
+	if _, err := service.InitiateRefund(7, "PUR-1", 30001, "REF-2", "refund sisa"); !errors.Is(err, ErrRefundExceedsCaptured) {
+		t.Fatalf("expected ErrRefundExceedsCaptured; got %v", err)
+	}
+
+	if _, err := service.InitiateRefund(7, "PUR-1", 30000, "REF-3", "refund sisa"); err != nil {
+		t.Fatalf("expected refund of the remaining amount to be accepted: %v", err)
+	}
+}
+
+func TestInitiateRefundRejectsOtherUsersPurchase(t *testing.T) {
+	repo := newFakeTransactionRepository()
+	repo.transactions["PUR-1"] = &Transaction{
+		UserID:            7,
+		Amount:            50000,
+		TransactionType:   TransactionPurchase,
+		TransactionStatus: StatusSuccess,
+		Reference:         "PUR-1",
+	}
+
+	service := &transactionService{txRepo: repo}
+
+	if _, err := service.InitiateRefund(8, "PUR-1", 10000, "REF-1", "refund"); err == nil {
+		t.Fatal("expected refund against another user's purchase to fail")
+	}
+}
+
+func TestSettleRefundRevokesPointsByRefundedShare(t *testing.T) {
+	repo := newFakeTransactionRepository()
+	repo.transactions["PUR-1"] = &Transaction{
+		UserID:            7,
+		Amount:            100000,
+		Currency:          "IDR",
+		TransactionType:   TransactionPurchase,
+		TransactionStatus: StatusSuccess,
+		Reference:         "PUR-1",
+	}
+	repo.holds["PUR-1"] = &balance.Hold{Reference: "PUR-1", Amount: 100000, CapturedAmount: 80000, Status: balance.HoldCaptured}
+	points := &fakeLoyaltyService{}
+	service := &transactionService{txRepo: repo, loyalty: points}
+
+	for _, reference := range []string{"REF-1", "REF-2"} {
+		refund := &Transaction{UserID: 7, Amount: 20000, TransactionType: TransactionRefund, TransactionStatus: StatusPending, Reference: reference, OriginalReference: "PUR-1"}
+		if err := service.settleRefund(refund); err != nil {
+			t.Fatalf("unexpected error settling %s: %v", reference, err)
+		}
+	}
+
+	// Refund kedua melaporkan total kumulatif terhadap jumlah yang di-capture.
+	want := []revokeCall{{"PUR-1", 20000, 80000}, {"PUR-1", 40000, 80000}}
+	if len(points.revoked) != len(want) {
+		t.Fatalf("expected %d revoke calls; got %v", len(want), points.revoked)
+	}
+	for i := range want {
+		if points.revoked[i] != want[i] {
+			t.Errorf("revoke %d = %+v, want %+v", i, points.revoked[i], want[i])
+		}
+	}
+}
+
+func TestUpdateTransactionRejectsFinalStatus(t *testing.T) {
+	cases := []struct {
+		name   string
+		from   TransactionStatus
+		status TransactionStatus
+	}{
+		{"success again", StatusSuccess, StatusSuccess},
+		{"reversed to success", StatusReversed, StatusSuccess},
+		{"failed to success", StatusFailed, StatusSuccess},
+		{"success to failed", StatusSuccess, StatusFailed},
+	}
+	for _, tc := range cases {
+		repo := newFakeTransactionRepository()
+		repo.transactions["TOP-1"] = &Transaction{UserID: 7, Amount: 50000, Currency: "IDR", TransactionType: TransactionTopUp, TransactionStatus: tc.from, Reference: "TOP-1"}
+
+		// Limiter dan repository saldo sengaja kosong: transaksi final tidak
+		// boleh memakai atau mengembalikan limit, apalagi menggerakkan saldo.
+		service := &transactionService{txRepo: repo}
+		if err := service.UpdateTransaction("TOP-1", tc.status); !errors.Is(err, ErrAlreadySettled) {
+			t.Errorf("%s: expected ErrAlreadySettled; got %v", tc.name, err)
+		}
+	}
+
+	repo := newFakeTransactionRepository()
+	repo.transactions["TOP-2"] = &Transaction{UserID: 7, Amount: 50000, TransactionType: TransactionTopUp, TransactionStatus: StatusPending, Reference: "TOP-2"}
+	service := &transactionService{txRepo: repo}
+	if err := service.UpdateTransaction("TOP-2", StatusReversed); !errors.Is(err, ErrInvalidStatus) {
+		t.Errorf("expected ErrInvalidStatus for PENDING to REVERSED; got %v", err)
+	}
+}
+
+type fakeCreditGuard struct {
+	maxCredit float64
+}
+
+func (g fakeCreditGuard) CheckCredit(userID uint, amount float64) error {
+	if amount > g.maxCredit {
+		return errors.New("saldo melebihi batas maksimum")
+	}
+	return nil
+}
+
+func TestRefundMerchantPaymentChecksCustomerCap(t *testing.T) {
+	repo := newFakeTransactionRepository()
+	repo.transactions["PUR-1"] = &Transaction{
+		UserID:             7,
+		Amount:             100000,
+		Currency:           "IDR",
+		TransactionType:    TransactionPurchase,
+		TransactionStatus:  StatusSuccess,
+		Reference:          "PUR-1",
+		CounterpartyUserID: 9,
+	}
+	repo.holds["PUR-1"] = &balance.Hold{Reference: "PUR-1", Amount: 100000, CapturedAmount: 100000, Status: balance.HoldCaptured}
+
+	service := &transactionService{txRepo: repo, creditGuard: fakeCreditGuard{maxCredit: 40000}, loyalty: &fakeLoyaltyService{}}
+
+	if _, err := service.RefundMerchantPayment(9, "PUR-1", 50000, "PIR-1", "refund"); err == nil {
+		t.Fatal("expected refund above the customer's KYC cap to be rejected")
+	}
+	if _, ok := repo.transactions["PIR-1"]; ok {
+		t.Fatal("rejected refund must not create a REFUND transaction")
+	}
+	if refund, err := service.RefundMerchantPayment(9, "PUR-1", 40000, "PIR-2", "refund"); err != nil || refund.TransactionStatus != StatusSuccess {
+		t.Fatalf("expected refund within the cap to settle; got %+v, %v", refund, err)
+	}
+	if repo.transactions["PUR-1"].RefundedAmount != 40000 {
+		t.Fatalf("expected 40000 refunded; got %.2f", repo.transactions["PUR-1"].RefundedAmount)
+	}
+}
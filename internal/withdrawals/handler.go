@@ -0,0 +1,233 @@
+package withdrawals
+
+import (
+	"errors"
+	"ewallet-engine/internal/audit"
+	"ewallet-engine/internal/bank"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type WithdrawalHandler struct {
+	service      WithdrawalService
+	auditService audit.AuditService
+}
+
+func NewWithdrawalHandler(service WithdrawalService, auditService audit.AuditService) *WithdrawalHandler {
+	return &WithdrawalHandler{service: service, auditService: auditService}
+}
+
+func (h *WithdrawalHandler) ListBanksHandler(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"data": h.service.ListBanks()})
+}
+
+func (h *WithdrawalHandler) InquiryHandler(c *fiber.Ctx) error {
+	var request struct {
+		BankCode      string `json:"bank_code"`
+		AccountNumber string `json:"account_number"`
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	result, err := h.service.Inquiry(request.BankCode, request.AccountNumber)
+	if err != nil {
+		return h.bankError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": result})
+}
+
+func (h *WithdrawalHandler) AddBeneficiaryHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	var request struct {
+		BankCode      string `json:"bank_code"`
+		AccountNumber string `json:"account_number"`
+		AccountName   string `json:"account_name"`
+		Alias         string `json:"alias"`
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	beneficiary, err := h.service.AddBeneficiary(userID, request.BankCode, request.AccountNumber, request.AccountName, request.Alias)
+	if err != nil {
+		if errors.Is(err, ErrBeneficiaryExists) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"message": err.Error()})
+		}
+		return h.bankError(c, err)
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionBeneficiaryAdded,
+		TargetType: "beneficiary",
+		TargetID:   fmt.Sprint(beneficiary.ID),
+		After: audit.Snapshot{
+			"bank_code":      beneficiary.BankCode,
+			"account_number": beneficiary.AccountNumber,
+			"account_name":   beneficiary.AccountName,
+		},
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Rekening tujuan berhasil disimpan",
+		"data":    beneficiary,
+	})
+}
+
+func (h *WithdrawalHandler) ListBeneficiariesHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	beneficiaries, err := h.service.ListBeneficiaries(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": beneficiaries})
+}
+
+func (h *WithdrawalHandler) RemoveBeneficiaryHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID tidak valid"})
+	}
+
+	if err := h.service.RemoveBeneficiary(userID, uint(id)); err != nil {
+		if errors.Is(err, ErrBeneficiaryNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionBeneficiaryRemoved,
+		TargetType: "beneficiary",
+		TargetID:   fmt.Sprint(id),
+	})
+
+	return c.JSON(fiber.Map{"message": "Rekening tujuan berhasil dihapus"})
+}
+
+func (h *WithdrawalHandler) CreateWithdrawalHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	var request struct {
+		BeneficiaryID uint    `json:"beneficiary_id"`
+		Amount        float64 `json:"amount"`
+		Reference     string  `json:"reference"`
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
+	}
+
+	withdrawal, err := h.service.RequestWithdrawal(userID, request.BeneficiaryID, request.Amount, request.Reference)
+	if err != nil {
+		if errors.Is(err, ErrBeneficiaryNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionWithdrawalRequested,
+		TargetType: "withdrawal",
+		TargetID:   withdrawal.Reference,
+		After:      withdrawalSnapshot(withdrawal),
+	})
+
+	status := fiber.StatusAccepted
+	if withdrawal.IsFinal() {
+		status = fiber.StatusOK
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"message": "Penarikan diproses",
+		"data":    withdrawal,
+	})
+}
+
+func (h *WithdrawalHandler) GetWithdrawalHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	withdrawal, err := h.service.GetWithdrawal(userID, c.Params("reference"))
+	if err != nil {
+		return h.withdrawalError(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": withdrawal})
+}
+
+func (h *WithdrawalHandler) ListWithdrawalsHandler(c *fiber.Ctx) error {
+	withdrawals, err := h.service.ListWithdrawals(0, WithdrawalStatus(c.Query("status")))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": withdrawals})
+}
+
+func (h *WithdrawalHandler) ReconcileHandler(c *fiber.Ctx) error {
+	reference := c.Params("reference")
+
+	before, err := h.service.GetWithdrawal(0, reference)
+	if err != nil {
+		return h.withdrawalError(c, err)
+	}
+
+	after, err := h.service.Reconcile(reference)
+	if err != nil {
+		return h.withdrawalError(c, err)
+	}
+
+	_ = h.auditService.Record(audit.Entry{
+		Meta:       audit.FromContext(c),
+		Action:     audit.ActionWithdrawalReconciled,
+		TargetType: "withdrawal",
+		TargetID:   reference,
+		Before:     withdrawalSnapshot(before),
+		After:      withdrawalSnapshot(after),
+	})
+
+	return c.JSON(fiber.Map{
+		"message": "Rekonsiliasi penarikan selesai",
+		"data":    after,
+	})
+}
+
+func (h *WithdrawalHandler) bankError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, bank.ErrAccountNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+	case errors.Is(err, bank.ErrConnectorTimeout):
+		return c.Status(fiber.StatusGatewayTimeout).JSON(fiber.Map{"message": err.Error()})
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+}
+
+func (h *WithdrawalHandler) withdrawalError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, ErrWithdrawalNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": err.Error()})
+}
+
+func withdrawalSnapshot(withdrawal *Withdrawal) audit.Snapshot {
+	return audit.Snapshot{
+		"status":         withdrawal.Status,
+		"amount":         withdrawal.Amount,
+		"fee":            withdrawal.Fee,
+		"bank_code":      withdrawal.BankCode,
+		"account_number": withdrawal.AccountNumber,
+		"external_id":    withdrawal.ExternalID,
+	}
+}
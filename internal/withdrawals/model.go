@@ -0,0 +1,69 @@
+package withdrawals
+
+import "time"
+
+// Beneficiary adalah rekening bank tujuan yang disimpan user. AccountName
+// selalu berasal dari name inquiry bank, bukan dari input user.
+type Beneficiary struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	UserID        uint      `gorm:"not null;uniqueIndex:idx_beneficiary_account" json:"user_id"`
+	BankCode      string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_beneficiary_account" json:"bank_code"`
+	AccountNumber string    `gorm:"type:varchar(30);not null;uniqueIndex:idx_beneficiary_account" json:"account_number"`
+	AccountName   string    `gorm:"type:varchar(150);not null" json:"account_name"`
+	Alias         string    `gorm:"type:varchar(50)" json:"alias,omitempty"`
+	VerifiedAt    time.Time `gorm:"not null" json:"verified_at"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+type WithdrawalStatus string
+
+const (
+	// StatusPending: dana sudah di-hold, belum dikirim ke bank.
+	StatusPending WithdrawalStatus = "PENDING"
+	// StatusProcessing: bank menerima transfer tetapi belum final.
+	StatusProcessing WithdrawalStatus = "PROCESSING"
+	// StatusUnknown: connector error atau timeout; hasil dipastikan oleh rekonsiliasi.
+	StatusUnknown WithdrawalStatus = "UNKNOWN"
+	StatusSuccess WithdrawalStatus = "SUCCESS"
+	StatusFailed  WithdrawalStatus = "FAILED"
+)
+
+// Withdrawal adalah penarikan saldo ke rekening bank. Detail rekening disalin
+// dari Beneficiary supaya riwayat tetap utuh walaupun beneficiary dihapus.
+type Withdrawal struct {
+	ID            uint             `gorm:"primaryKey" json:"id"`
+	Reference     string           `gorm:"type:varchar(100);uniqueIndex;not null" json:"reference"`
+	UserID        uint             `gorm:"not null;index" json:"user_id"`
+	BeneficiaryID uint             `gorm:"not null" json:"beneficiary_id"`
+	BankCode      string           `gorm:"type:varchar(20);not null" json:"bank_code"`
+	AccountNumber string           `gorm:"type:varchar(30);not null" json:"account_number"`
+	AccountName   string           `gorm:"type:varchar(150);not null" json:"account_name"`
+	Amount        float64          `gorm:"not null" json:"amount"`
+	Fee           float64          `gorm:"not null;default:0" json:"fee"`
+	Currency      string           `gorm:"type:char(3);not null;default:'IDR'" json:"currency"`
+	Status        WithdrawalStatus `gorm:"type:enum('PENDING','PROCESSING','UNKNOWN','SUCCESS','FAILED');default:'PENDING';index" json:"status"`
+	Connector     string           `gorm:"type:varchar(30);not null" json:"connector"`
+	ExternalID    string           `gorm:"type:varchar(100)" json:"external_id,omitempty"`
+	FailureReason string           `gorm:"type:varchar(255)" json:"failure_reason,omitempty"`
+	CheckAttempts int              `gorm:"not null;default:0" json:"check_attempts"`
+	LastCheckedAt *time.Time       `json:"last_checked_at,omitempty"`
+	CompletedAt   *time.Time       `json:"completed_at,omitempty"`
+	CreatedAt     time.Time        `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time        `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// IsFinal melaporkan apakah withdrawal sudah selesai dan hold-nya sudah diputuskan.
+func (w Withdrawal) IsFinal() bool {
+	return w.Status == StatusSuccess || w.Status == StatusFailed
+}
+
+// HoldReferencePrefix membedakan hold penarikan dari hold fitur lain. Reference
+// withdrawal dipilih user sehingga tidak boleh dipakai langsung sebagai
+// reference hold yang unik secara global.
+const HoldReferencePrefix = "WD-"
+
+// HoldReference adalah reference hold saldo untuk withdrawal ini.
+func (w Withdrawal) HoldReference() string {
+	return HoldReferencePrefix + w.Reference
+}
@@ -0,0 +1,95 @@
+package withdrawals
+
+import (
+	"ewallet-engine/internal/payouts"
+	"log"
+	"time"
+)
+
+var payoutStates = map[WithdrawalStatus]payouts.State{
+	StatusPending:    payouts.StatePending,
+	StatusProcessing: payouts.StateProcessing,
+	StatusUnknown:    payouts.StateUnknown,
+	StatusSuccess:    payouts.StateSucceeded,
+	StatusFailed:     payouts.StateFailed,
+}
+
+// withdrawalPayout menjalankan Withdrawal lewat payouts.Machine.
+type withdrawalPayout struct {
+	service    *withdrawalService
+	withdrawal *Withdrawal
+}
+
+func (p *withdrawalPayout) Reference() string {
+	return p.withdrawal.Reference
+}
+
+func (p *withdrawalPayout) State() payouts.State {
+	return payoutStates[p.withdrawal.Status]
+}
+
+func (p *withdrawalPayout) Transition(to payouts.State, outcome payouts.Outcome) (bool, error) {
+	next := *p.withdrawal
+	now := time.Now()
+	switch to {
+	case payouts.StateProcessing:
+		next.Status = StatusProcessing
+		if outcome.ExternalID != "" {
+			next.ExternalID = outcome.ExternalID
+		}
+	case payouts.StateUnknown:
+		next.Status = StatusUnknown
+		next.FailureReason = outcome.FailureReason
+	case payouts.StateSucceeded:
+		next.Status = StatusSuccess
+		next.ExternalID = outcome.ExternalID
+		next.FailureReason = ""
+		next.CompletedAt = &now
+	case payouts.StateFailed:
+		next.Status = StatusFailed
+		next.FailureReason = outcome.FailureReason
+		next.CompletedAt = &now
+	}
+
+	ok, err := p.service.repo.TransitionStatus(p.withdrawal.ID, p.withdrawal.Status, map[string]interface{}{
+		"status":         next.Status,
+		"external_id":    next.ExternalID,
+		"failure_reason": next.FailureReason,
+		"completed_at":   next.CompletedAt,
+	})
+	if err == nil && ok {
+		*p.withdrawal = next
+	}
+	return ok, err
+}
+
+func (p *withdrawalPayout) CaptureHold() error {
+	w := p.withdrawal
+	return p.service.repo.CaptureHold(w.UserID, w.HoldReference(), w.Amount, w.Fee)
+}
+
+func (p *withdrawalPayout) ReleaseHold() error {
+	return p.service.repo.ReleaseHold(p.withdrawal.UserID, p.withdrawal.HoldReference())
+}
+
+func (p *withdrawalPayout) Succeeded() {
+	w := p.withdrawal
+	log.Printf("SUCCESS: Penarikan %s sebesar %.2f ke %s %s selesai", w.Reference, w.Amount, w.BankCode, w.AccountNumber)
+}
+
+func (p *withdrawalPayout) Failed(held bool) {
+	p.service.releaseLimit(p.withdrawal)
+}
+
+func (p *withdrawalPayout) MarkChecked(at time.Time) (int, error) {
+	if err := p.service.repo.MarkChecked(p.withdrawal.ID, at); err != nil {
+		return 0, err
+	}
+	p.withdrawal.CheckAttempts++
+	p.withdrawal.LastCheckedAt = &at
+	return p.withdrawal.CheckAttempts, nil
+}
+
+func (p *withdrawalPayout) SentAt() time.Time {
+	return p.withdrawal.CreatedAt
+}
@@ -0,0 +1,30 @@
+package withdrawals
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// StartReconciler secara berkala memastikan hasil withdrawal PROCESSING/UNKNOWN
+// ke bank sampai ctx dibatalkan.
+func StartReconciler(ctx context.Context, service WithdrawalService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			resolved, err := service.ReconcilePending()
+			if err != nil {
+				log.Printf("ERROR: Gagal rekonsiliasi penarikan: %v", err)
+				continue
+			}
+			if resolved > 0 {
+				log.Printf("SUCCESS: %d penarikan selesai direkonsiliasi", resolved)
+			}
+		}
+	}
+}
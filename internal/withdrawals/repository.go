@@ -0,0 +1,138 @@
+package withdrawals
+
+import (
+	"ewallet-engine/internal/balance"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WithdrawalRepository interface {
+	CreateBeneficiary(beneficiary *Beneficiary) error
+	FindBeneficiary(userID uint, id uint) (*Beneficiary, error)
+	FindBeneficiaryByAccount(userID uint, bankCode string, accountNumber string) (*Beneficiary, error)
+	ListBeneficiaries(userID uint) ([]Beneficiary, error)
+	DeleteBeneficiary(userID uint, id uint) (bool, error)
+
+	CreateWithdrawal(withdrawal *Withdrawal) error
+	FindByReference(reference string) (*Withdrawal, error)
+	ListWithdrawals(userID uint, status WithdrawalStatus, limit int) ([]Withdrawal, error)
+	FindUnresolved(checkedBefore time.Time, limit int) ([]Withdrawal, error)
+	TransitionStatus(id uint, from WithdrawalStatus, updates map[string]interface{}) (bool, error)
+	MarkChecked(id uint, at time.Time) error
+
+	PlaceHold(userID uint, currency string, amount float64, reference string, expiresAt time.Time) error
+	CaptureHold(userID uint, reference string, amount float64, fee float64) error
+	ReleaseHold(userID uint, reference string) error
+}
+
+type withdrawalRepository struct {
+	DB *gorm.DB
+}
+
+func NewWithdrawalRepository(db *gorm.DB) WithdrawalRepository {
+	return &withdrawalRepository{DB: db}
+}
+
+func (r *withdrawalRepository) CreateBeneficiary(beneficiary *Beneficiary) error {
+	return r.DB.Create(beneficiary).Error
+}
+
+func (r *withdrawalRepository) FindBeneficiary(userID uint, id uint) (*Beneficiary, error) {
+	var beneficiary Beneficiary
+	err := r.DB.Where("id = ? AND user_id = ?", id, userID).First(&beneficiary).Error
+	if err != nil {
+		return nil, err
+	}
+	return &beneficiary, nil
+}
+
+func (r *withdrawalRepository) FindBeneficiaryByAccount(userID uint, bankCode string, accountNumber string) (*Beneficiary, error) {
+	var beneficiary Beneficiary
+	err := r.DB.Where("user_id = ? AND bank_code = ? AND account_number = ?", userID, bankCode, accountNumber).First(&beneficiary).Error
+	if err != nil {
+		return nil, err
+	}
+	return &beneficiary, nil
+}
+
+func (r *withdrawalRepository) ListBeneficiaries(userID uint) ([]Beneficiary, error) {
+	var beneficiaries []Beneficiary
+	err := r.DB.Where("user_id = ?", userID).Order("id ASC").Find(&beneficiaries).Error
+	return beneficiaries, err
+}
+
+func (r *withdrawalRepository) DeleteBeneficiary(userID uint, id uint) (bool, error) {
+	result := r.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&Beneficiary{})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *withdrawalRepository) CreateWithdrawal(withdrawal *Withdrawal) error {
+	return r.DB.Create(withdrawal).Error
+}
+
+func (r *withdrawalRepository) FindByReference(reference string) (*Withdrawal, error) {
+	var withdrawal Withdrawal
+	err := r.DB.Where("reference = ?", reference).First(&withdrawal).Error
+	if err != nil {
+		return nil, err
+	}
+	return &withdrawal, nil
+}
+
+func (r *withdrawalRepository) ListWithdrawals(userID uint, status WithdrawalStatus, limit int) ([]Withdrawal, error) {
+	var withdrawals []Withdrawal
+	query := r.DB.Order("id DESC").Limit(limit)
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&withdrawals).Error
+	return withdrawals, err
+}
+
+// FindUnresolved mengembalikan withdrawal PROCESSING/UNKNOWN yang belum dicek
+// sejak checkedBefore, termasuk yang belum pernah dicek.
+func (r *withdrawalRepository) FindUnresolved(checkedBefore time.Time, limit int) ([]Withdrawal, error) {
+	var withdrawals []Withdrawal
+	err := r.DB.
+		Where("status IN ?", []WithdrawalStatus{StatusProcessing, StatusUnknown}).
+		Where("last_checked_at IS NULL OR last_checked_at < ?", checkedBefore).
+		Order("id ASC").Limit(limit).Find(&withdrawals).Error
+	return withdrawals, err
+}
+
+func (r *withdrawalRepository) TransitionStatus(id uint, from WithdrawalStatus, updates map[string]interface{}) (bool, error) {
+	result := r.DB.Model(&Withdrawal{}).Where("id = ? AND status = ?", id, from).Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *withdrawalRepository) MarkChecked(id uint, at time.Time) error {
+	return r.DB.Model(&Withdrawal{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"last_checked_at": at,
+		"check_attempts":  gorm.Expr("check_attempts + 1"),
+	}).Error
+}
+
+func (r *withdrawalRepository) PlaceHold(userID uint, currency string, amount float64, reference string, expiresAt time.Time) error {
+	wallet, err := balance.FindUserWallet(r.DB, userID, currency, false)
+	if err != nil {
+		return err
+	}
+	_, err = balance.PlaceHold(r.DB, wallet.ID, amount, reference, expiresAt)
+	return err
+}
+
+func (r *withdrawalRepository) CaptureHold(userID uint, reference string, amount float64, fee float64) error {
+	_, err := balance.CaptureHold(r.DB, userID, reference, amount, fee)
+	return err
+}
+
+func (r *withdrawalRepository) ReleaseHold(userID uint, reference string) error {
+	return balance.ReleaseHold(r.DB, userID, reference, balance.HoldReleased)
+}
@@ -0,0 +1,326 @@
+package withdrawals
+
+import (
+	"context"
+	"errors"
+	"ewallet-engine/internal/balance"
+	"ewallet-engine/internal/bank"
+	"ewallet-engine/internal/limits"
+	"ewallet-engine/internal/payouts"
+	"ewallet-engine/internal/screening"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FeeTransactionType adalah transaction_type jadwal biaya untuk penarikan ke bank.
+const FeeTransactionType = "WITHDRAWAL"
+
+// nameMatchThreshold adalah kemiripan minimal antara nama yang diketik user
+// dan nama hasil inquiry bank.
+const nameMatchThreshold = 0.8
+
+var (
+	ErrBeneficiaryNotFound = errors.New("rekening tujuan tidak ditemukan")
+	ErrBeneficiaryExists   = errors.New("rekening tujuan sudah terdaftar")
+	ErrNameMismatch        = errors.New("nama pemilik rekening tidak sesuai dengan data bank")
+	ErrWithdrawalNotFound  = errors.New("penarikan tidak ditemukan")
+)
+
+type WithdrawalService interface {
+	ListBanks() []bank.Bank
+	Inquiry(bankCode string, accountNumber string) (*bank.InquiryResult, error)
+	AddBeneficiary(userID uint, bankCode string, accountNumber string, expectedName string, alias string) (*Beneficiary, error)
+	ListBeneficiaries(userID uint) ([]Beneficiary, error)
+	RemoveBeneficiary(userID uint, id uint) error
+
+	RequestWithdrawal(userID uint, beneficiaryID uint, amount float64, reference string) (*Withdrawal, error)
+	GetWithdrawal(userID uint, reference string) (*Withdrawal, error)
+	ListWithdrawals(userID uint, status WithdrawalStatus) ([]Withdrawal, error)
+	Reconcile(reference string) (*Withdrawal, error)
+	ReconcilePending() (int, error)
+}
+
+type withdrawalService struct {
+	repo      WithdrawalRepository
+	connector bank.BankConnector
+	limiter   limits.LimitService
+	screening screening.ScreeningService
+	fees      balance.FeeCalculator
+	machine   payouts.Machine
+	holdTTL   time.Duration
+	recheck   time.Duration
+}
+
+func NewWithdrawalService(repo WithdrawalRepository, connector bank.BankConnector, limiter limits.LimitService, screeningService screening.ScreeningService, fees balance.FeeCalculator) WithdrawalService {
+	s := &withdrawalService{
+		repo:      repo,
+		connector: connector,
+		limiter:   limiter,
+		screening: screeningService,
+		fees:      fees,
+		machine: payouts.Machine{
+			Label:          "Penarikan",
+			Timeout:        15 * time.Second,
+			NotFoundGrace:  10 * time.Minute,
+			MaxChecks:      30,
+			NotFound:       bank.ErrTransferNotFound,
+			NotFoundReason: "transfer tidak diterima bank",
+		},
+		holdTTL: 30 * 24 * time.Hour,
+		recheck: time.Minute,
+	}
+	if v, err := strconv.Atoi(os.Getenv("BANK_TRANSFER_TIMEOUT_SECONDS")); err == nil && v > 0 {
+		s.machine.Timeout = time.Duration(v) * time.Second
+	}
+	if v, err := strconv.Atoi(os.Getenv("WITHDRAWAL_HOLD_TTL_DAYS")); err == nil && v > 0 {
+		s.holdTTL = time.Duration(v) * 24 * time.Hour
+	}
+	if v, err := strconv.Atoi(os.Getenv("WITHDRAWAL_NOT_FOUND_GRACE_MINUTES")); err == nil && v > 0 {
+		s.machine.NotFoundGrace = time.Duration(v) * time.Minute
+	}
+	if v, err := strconv.Atoi(os.Getenv("WITHDRAWAL_RECHECK_SECONDS")); err == nil && v > 0 {
+		s.recheck = time.Duration(v) * time.Second
+	}
+	if v, err := strconv.Atoi(os.Getenv("WITHDRAWAL_MAX_STATUS_CHECKS")); err == nil && v > 0 {
+		s.machine.MaxChecks = v
+	}
+	return s
+}
+
+func (s *withdrawalService) ListBanks() []bank.Bank {
+	return bank.SupportedBanks()
+}
+
+func (s *withdrawalService) Inquiry(bankCode string, accountNumber string) (*bank.InquiryResult, error) {
+	bankCode = strings.ToUpper(strings.TrimSpace(bankCode))
+	accountNumber = strings.TrimSpace(accountNumber)
+	if accountNumber == "" {
+		return nil, errors.New("nomor rekening wajib diisi")
+	}
+	if !bank.IsSupported(bankCode) {
+		return nil, bank.ErrUnsupportedBank
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.machine.Timeout)
+	defer cancel()
+	return s.connector.Inquiry(ctx, bankCode, accountNumber)
+}
+
+// AddBeneficiary menyimpan rekening tujuan setelah name inquiry berhasil. Jika
+// user mengisi expectedName, nama tersebut harus mirip dengan nama dari bank.
+func (s *withdrawalService) AddBeneficiary(userID uint, bankCode string, accountNumber string, expectedName string, alias string) (*Beneficiary, error) {
+	result, err := s.Inquiry(bankCode, accountNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	if expectedName != "" && screening.Similarity(screening.Normalize(expectedName), screening.Normalize(result.AccountName)) < nameMatchThreshold {
+		return nil, ErrNameMismatch
+	}
+
+	if _, err := s.repo.FindBeneficiaryByAccount(userID, result.BankCode, result.AccountNumber); err == nil {
+		return nil, ErrBeneficiaryExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	beneficiary := &Beneficiary{
+		UserID:        userID,
+		BankCode:      result.BankCode,
+		AccountNumber: result.AccountNumber,
+		AccountName:   result.AccountName,
+		Alias:         strings.TrimSpace(alias),
+		VerifiedAt:    time.Now(),
+	}
+	if err := s.repo.CreateBeneficiary(beneficiary); err != nil {
+		return nil, err
+	}
+	return beneficiary, nil
+}
+
+func (s *withdrawalService) ListBeneficiaries(userID uint) ([]Beneficiary, error) {
+	return s.repo.ListBeneficiaries(userID)
+}
+
+func (s *withdrawalService) RemoveBeneficiary(userID uint, id uint) error {
+	deleted, err := s.repo.DeleteBeneficiary(userID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrBeneficiaryNotFound
+	}
+	return nil
+}
+
+// RequestWithdrawal menahan amount+fee di wallet IDR lalu mengirim transfer ke
+// bank. Hold hanya di-capture saat bank mengonfirmasi SUCCESS dan hanya
+// dilepas saat bank mengonfirmasi FAILED; hasil lain menunggu rekonsiliasi.
+func (s *withdrawalService) RequestWithdrawal(userID uint, beneficiaryID uint, amount float64, reference string) (*Withdrawal, error) {
+	if reference == "" {
+		return nil, errors.New("reference wajib diisi")
+	}
+	currency := balance.DefaultCurrency
+	if err := balance.ValidateAmount(amount, currency); err != nil {
+		return nil, err
+	}
+
+	if existing, err := s.repo.FindByReference(reference); err == nil {
+		if existing.UserID != userID {
+			return nil, errors.New("reference sudah digunakan")
+		}
+		return existing, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	beneficiary, err := s.repo.FindBeneficiary(userID, beneficiaryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBeneficiaryNotFound
+		}
+		return nil, err
+	}
+
+	hit, err := s.screening.ScreenCounterparty(userID, beneficiary.AccountName, reference)
+	if err != nil {
+		return nil, err
+	}
+	if hit {
+		return nil, errors.New("penarikan tidak dapat diproses, silakan hubungi customer service")
+	}
+
+	fee, err := s.fees.CalculateFee(userID, FeeTransactionType, beneficiary.BankCode, currency, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	withdrawal := &Withdrawal{
+		Reference:     reference,
+		UserID:        userID,
+		BeneficiaryID: beneficiary.ID,
+		BankCode:      beneficiary.BankCode,
+		AccountNumber: beneficiary.AccountNumber,
+		AccountName:   beneficiary.AccountName,
+		Amount:        amount,
+		Fee:           fee,
+		Currency:      currency,
+		Status:        StatusPending,
+		Connector:     s.connector.Name(),
+	}
+
+	// Limit dipakai sebelum penarikan disimpan dan dikembalikan oleh
+	// withdrawalPayout.Failed bila penarikan gagal.
+	created, err := s.limiter.Reserve(userID, limits.OperationTransfer, amount, withdrawal.HoldReference())
+	if err != nil {
+		return nil, err
+	}
+	// Reservasi yang sudah ada milik permintaan lain dengan reference yang sama.
+	if !created {
+		return nil, errors.New("reference sudah digunakan")
+	}
+	if err := s.repo.CreateWithdrawal(withdrawal); err != nil {
+		s.releaseLimit(withdrawal)
+		return nil, err
+	}
+
+	payout := &withdrawalPayout{service: s, withdrawal: withdrawal}
+	if err := s.repo.PlaceHold(userID, currency, amount+fee, withdrawal.HoldReference(), time.Now().Add(s.holdTTL)); err != nil {
+		s.machine.Fail(payout, err.Error())
+		if errors.Is(err, balance.ErrInsufficientBalance) {
+			return nil, errors.New("saldo tidak mencukupi")
+		}
+		return nil, err
+	}
+
+	err = s.machine.Send(payout, func(ctx context.Context) (*payouts.Outcome, error) {
+		result, err := s.connector.Transfer(ctx, bank.TransferRequest{
+			Reference:     reference,
+			BankCode:      withdrawal.BankCode,
+			AccountNumber: withdrawal.AccountNumber,
+			AccountName:   withdrawal.AccountName,
+			Amount:        amount,
+			Currency:      currency,
+			Remark:        "Penarikan " + reference,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return payouts.TransferOutcome(result), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return withdrawal, nil
+}
+
+func (s *withdrawalService) releaseLimit(withdrawal *Withdrawal) {
+	if err := s.limiter.Release(withdrawal.UserID, limits.OperationTransfer, withdrawal.HoldReference()); err != nil {
+		log.Printf("ERROR: Gagal mengembalikan limit penarikan %s: %v", withdrawal.Reference, err)
+	}
+}
+
+func (s *withdrawalService) GetWithdrawal(userID uint, reference string) (*Withdrawal, error) {
+	withdrawal, err := s.repo.FindByReference(reference)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWithdrawalNotFound
+		}
+		return nil, err
+	}
+	if userID != 0 && withdrawal.UserID != userID {
+		return nil, ErrWithdrawalNotFound
+	}
+	return withdrawal, nil
+}
+
+func (s *withdrawalService) ListWithdrawals(userID uint, status WithdrawalStatus) ([]Withdrawal, error) {
+	return s.repo.ListWithdrawals(userID, WithdrawalStatus(strings.ToUpper(string(status))), 100)
+}
+
+// Reconcile menanyakan status transfer ke bank untuk withdrawal yang belum
+// final. Withdrawal yang sudah final dikembalikan apa adanya.
+func (s *withdrawalService) Reconcile(reference string) (*Withdrawal, error) {
+	withdrawal, err := s.GetWithdrawal(0, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.machine.Reconcile(&withdrawalPayout{service: s, withdrawal: withdrawal}, func(ctx context.Context) (*payouts.Outcome, error) {
+		result, err := s.connector.Status(ctx, reference)
+		if err != nil {
+			return nil, err
+		}
+		return payouts.TransferOutcome(result), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return withdrawal, nil
+}
+
+// ReconcilePending memproses withdrawal PROCESSING/UNKNOWN yang sudah lewat
+// interval cek ulang dan mengembalikan jumlah yang menjadi final.
+func (s *withdrawalService) ReconcilePending() (int, error) {
+	pending, err := s.repo.FindUnresolved(time.Now().Add(-s.recheck), 100)
+	if err != nil {
+		return 0, err
+	}
+
+	references := make([]string, 0, len(pending))
+	for _, item := range pending {
+		references = append(references, item.Reference)
+	}
+	return s.machine.ReconcileAll(references, func(reference string) (bool, error) {
+		withdrawal, err := s.Reconcile(reference)
+		if err != nil {
+			return false, err
+		}
+		return withdrawal.IsFinal(), nil
+	}), nil
+}
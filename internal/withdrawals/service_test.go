@@ -0,0 +1,311 @@
+package withdrawals
+
+import (
+	"context"
+	"errors"
+	"ewallet-engine/internal/balance"
+	"ewallet-engine/internal/bank"
+	"ewallet-engine/internal/limits"
+	"ewallet-engine/internal/payouts"
+	"ewallet-engine/internal/screening"
+	"fmt"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// fakeWithdrawalRepository menyimpan satu wallet milik user penarik; hold
+// mengurangi saldo tersedia dan capture mendebit jumlah ditambah fee.
+type fakeWithdrawalRepository struct {
+	WithdrawalRepository
+	withdrawals map[string]*Withdrawal
+	wallet      balance.Wallet
+	holds       map[string]*balance.Hold
+}
+
+func newFakeWithdrawalRepository(walletBalance float64) *fakeWithdrawalRepository {
+	return &fakeWithdrawalRepository{
+		withdrawals: make(map[string]*Withdrawal),
+		wallet:      balance.Wallet{ID: 1, UserID: 7, Currency: "IDR", Balance: walletBalance},
+		holds:       make(map[string]*balance.Hold),
+	}
+}
+
+func (r *fakeWithdrawalRepository) FindBeneficiary(userID uint, id uint) (*Beneficiary, error) {
+	return &Beneficiary{ID: id, UserID: userID, BankCode: "BCA", AccountNumber: "1234567890", AccountName: "BUDI SANTOSO"}, nil
+}
+
+func (r *fakeWithdrawalRepository) CreateWithdrawal(withdrawal *Withdrawal) error {
+	withdrawal.ID = uint(len(r.withdrawals) + 1)
+	withdrawal.CreatedAt = time.Now()
+	stored := *withdrawal
+	r.withdrawals[withdrawal.Reference] = &stored
+	return nil
+}
+
+func (r *fakeWithdrawalRepository) FindByReference(reference string) (*Withdrawal, error) {
+	withdrawal, ok := r.withdrawals[reference]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *withdrawal
+	return &copied, nil
+}
+
+func (r *fakeWithdrawalRepository) TransitionStatus(id uint, from WithdrawalStatus, updates map[string]interface{}) (bool, error) {
+	for _, withdrawal := range r.withdrawals {
+		if withdrawal.ID != id {
+			continue
+		}
+		if withdrawal.Status != from {
+			return false, nil
+		}
+		withdrawal.Status = updates["status"].(WithdrawalStatus)
+		return true, nil
+	}
+	return false, nil
+}
+
+func (r *fakeWithdrawalRepository) MarkChecked(id uint, at time.Time) error {
+	return nil
+}
+
+func (r *fakeWithdrawalRepository) PlaceHold(userID uint, currency string, amount float64, reference string, expiresAt time.Time) error {
+	if _, ok := r.holds[reference]; ok {
+		return balance.ErrHoldReferenceUsed
+	}
+	if r.wallet.Available() < amount {
+		return balance.ErrInsufficientBalance
+	}
+	r.wallet.HeldBalance += amount
+	r.holds[reference] = &balance.Hold{Reference: reference, Amount: amount, Status: balance.HoldActive}
+	return nil
+}
+
+func (r *fakeWithdrawalRepository) CaptureHold(userID uint, reference string, amount float64, fee float64) error {
+	hold, ok := r.holds[reference]
+	if !ok || hold.Status != balance.HoldActive {
+		return balance.ErrHoldNotFound
+	}
+	r.wallet.HeldBalance -= hold.Amount
+	r.wallet.Balance -= amount + fee
+	hold.Status = balance.HoldCaptured
+	return nil
+}
+
+func (r *fakeWithdrawalRepository) ReleaseHold(userID uint, reference string) error {
+	hold, ok := r.holds[reference]
+	if !ok || hold.Status != balance.HoldActive {
+		return balance.ErrHoldNotFound
+	}
+	r.wallet.HeldBalance -= hold.Amount
+	hold.Status = balance.HoldReleased
+	return nil
+}
+
+func (r *fakeWithdrawalRepository) holdStatus(reference string) balance.HoldStatus {
+	if hold, ok := r.holds[reference]; ok {
+		return hold.Status
+	}
+	return ""
+}
+
+type fakeConnector struct {
+	bank.BankConnector
+	transfer    *bank.TransferResult
+	transferErr error
+	status      *bank.TransferResult
+	statusErr   error
+}
+
+func (c *fakeConnector) Name() string { return "fake" }
+
+func (c *fakeConnector) Transfer(ctx context.Context, request bank.TransferRequest) (*bank.TransferResult, error) {
+	return c.transfer, c.transferErr
+}
+
+func (c *fakeConnector) Status(ctx context.Context, reference string) (*bank.TransferResult, error) {
+	return c.status, c.statusErr
+}
+
+// fakeLimiter mencatat pemakaian limit harian per reference.
+type fakeLimiter struct {
+	limits.LimitService
+	daily float64
+	used  map[string]float64
+}
+
+func (l *fakeLimiter) Reserve(userID uint, operation limits.Operation, amount float64, reference string) (bool, error) {
+	if _, ok := l.used[reference]; ok {
+		return false, nil
+	}
+	if l.usage()+amount > l.daily {
+		return false, fmt.Errorf("jumlah melebihi sisa limit harian %.2f", l.daily-l.usage())
+	}
+	l.used[reference] = amount
+	return true, nil
+}
+
+func (l *fakeLimiter) Release(userID uint, operation limits.Operation, reference string) error {
+	delete(l.used, reference)
+	return nil
+}
+
+func (l *fakeLimiter) usage() float64 {
+	total := 0.0
+	for _, amount := range l.used {
+		total += amount
+	}
+	return total
+}
+
+type fakeScreening struct {
+	screening.ScreeningService
+}
+
+func (fakeScreening) ScreenCounterparty(userID uint, name string, reference string) (bool, error) {
+	return false, nil
+}
+
+type flatFee float64
+
+func (f flatFee) CalculateFee(userID uint, txType string, channel string, currency string, amount float64) (float64, error) {
+	return float64(f), nil
+}
+
+var testMachine = payouts.Machine{
+	Label:          "Penarikan",
+	Timeout:        time.Second,
+	NotFoundGrace:  10 * time.Minute,
+	MaxChecks:      30,
+	NotFound:       bank.ErrTransferNotFound,
+	NotFoundReason: "transfer tidak diterima bank",
+}
+
+func TestRequestWithdrawalSettlesBalanceAndLimit(t *testing.T) {
+	cases := []struct {
+		name        string
+		result      *bank.TransferResult
+		wantStatus  WithdrawalStatus
+		wantBalance float64
+		wantUsage   float64
+	}{
+		{"bank success", &bank.TransferResult{Status: bank.TransferSuccess, ExternalID: "BANK-1"}, StatusSuccess, 97500, 100000},
+		{"bank failure", &bank.TransferResult{Status: bank.TransferFailed, FailureReason: "rekening ditutup"}, StatusFailed, 200000, 0},
+	}
+
+	for _, tc := range cases {
+		repo := newFakeWithdrawalRepository(200000)
+		limiter := &fakeLimiter{daily: 150000, used: make(map[string]float64)}
+		service := &withdrawalService{repo: repo, connector: &fakeConnector{transfer: tc.result}, limiter: limiter, screening: fakeScreening{}, fees: flatFee(2500), machine: testMachine, holdTTL: time.Hour, recheck: time.Minute}
+
+		withdrawal, err := service.RequestWithdrawal(7, 1, 100000, "REF-1")
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if withdrawal.Status != tc.wantStatus || withdrawal.Fee != 2500 {
+			t.Errorf("%s: status = %s, fee %.2f; want %s, 2500.00", tc.name, withdrawal.Status, withdrawal.Fee, tc.wantStatus)
+		}
+		if repo.wallet.Balance != tc.wantBalance || repo.wallet.HeldBalance != 0 {
+			t.Errorf("%s: balance/held = %.2f/%.2f, want %.2f/0.00", tc.name, repo.wallet.Balance, repo.wallet.HeldBalance, tc.wantBalance)
+		}
+		if limiter.usage() != tc.wantUsage {
+			t.Errorf("%s: daily usage = %.2f, want %.2f", tc.name, limiter.usage(), tc.wantUsage)
+		}
+	}
+}
+
+func TestRequestWithdrawalRejectsAboveDailyLimit(t *testing.T) {
+	repo := newFakeWithdrawalRepository(500000)
+	limiter := &fakeLimiter{daily: 150000, used: make(map[string]float64)}
+	service := &withdrawalService{repo: repo, connector: &fakeConnector{transfer: &bank.TransferResult{Status: bank.TransferSuccess, ExternalID: "BANK-1"}}, limiter: limiter, screening: fakeScreening{}, fees: flatFee(2500), machine: testMachine, holdTTL: time.Hour, recheck: time.Minute}
+
+	if _, err := service.RequestWithdrawal(7, 1, 100000, "REF-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := service.RequestWithdrawal(7, 1, 60000, "REF-2"); err == nil {
+		t.Fatalf("expected the daily limit to reject the second withdrawal")
+	}
+	if _, ok := repo.withdrawals["REF-2"]; ok {
+		t.Fatalf("expected no withdrawal stored above the limit")
+	}
+	if repo.wallet.Balance != 397500 || limiter.usage() != 100000 {
+		t.Fatalf("expected only the first withdrawal booked; balance %.2f, usage %.2f", repo.wallet.Balance, limiter.usage())
+	}
+}
+
+func TestRequestWithdrawalUnknownKeepsFundsHeldUntilReconciled(t *testing.T) {
+	repo := newFakeWithdrawalRepository(200000)
+	connector := &fakeConnector{transferErr: context.DeadlineExceeded}
+	service := &withdrawalService{repo: repo, connector: connector, limiter: &fakeLimiter{daily: 1000000, used: make(map[string]float64)}, screening: fakeScreening{}, fees: flatFee(2500), machine: testMachine, holdTTL: time.Hour, recheck: time.Minute}
+
+	withdrawal, err := service.RequestWithdrawal(7, 1, 100000, "REF-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if withdrawal.Status != StatusUnknown || repo.wallet.Available() != 97500 || repo.wallet.Balance != 200000 {
+		t.Fatalf("expected UNKNOWN with 102500 held; got %s, available %.2f", withdrawal.Status, repo.wallet.Available())
+	}
+
+	connector.status = &bank.TransferResult{Status: bank.TransferPending, ExternalID: "BANK-1"}
+	if withdrawal, _ = service.Reconcile("REF-1"); withdrawal.Status != StatusProcessing || repo.wallet.HeldBalance != 102500 {
+		t.Fatalf("expected PENDING from the bank to move UNKNOWN to PROCESSING with funds held; got %s", withdrawal.Status)
+	}
+
+	connector.status = &bank.TransferResult{Status: bank.TransferSuccess, ExternalID: "BANK-1"}
+	if withdrawal, _ = service.Reconcile("REF-1"); withdrawal.Status != StatusSuccess {
+		t.Fatalf("expected SUCCESS after reconcile; got %s", withdrawal.Status)
+	}
+	if repo.wallet.Balance != 97500 || repo.wallet.HeldBalance != 0 {
+		t.Fatalf("expected amount and fee debited after reconcile; balance/held %.2f/%.2f", repo.wallet.Balance, repo.wallet.HeldBalance)
+	}
+}
+
+func TestReconcileNotFoundWaitsForGrace(t *testing.T) {
+	repo := newFakeWithdrawalRepository(200000)
+	limiter := &fakeLimiter{daily: 1000000, used: make(map[string]float64)}
+	connector := &fakeConnector{transferErr: errors.New("connection reset"), statusErr: bank.ErrTransferNotFound}
+	service := &withdrawalService{repo: repo, connector: connector, limiter: limiter, screening: fakeScreening{}, fees: flatFee(2500), machine: testMachine, holdTTL: time.Hour, recheck: time.Minute}
+
+	if _, err := service.RequestWithdrawal(7, 1, 100000, "REF-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	withdrawal, err := service.Reconcile("REF-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if withdrawal.Status != StatusUnknown || repo.wallet.HeldBalance != 102500 {
+		t.Fatalf("expected UNKNOWN with funds held inside the grace period; got %s, held %.2f", withdrawal.Status, repo.wallet.HeldBalance)
+	}
+
+	repo.withdrawals["REF-1"].CreatedAt = time.Now().Add(-time.Hour)
+	withdrawal, err = service.Reconcile("REF-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if withdrawal.Status != StatusFailed || repo.wallet.Balance != 200000 || repo.wallet.HeldBalance != 0 || limiter.usage() != 0 {
+		t.Fatalf("expected FAILED with funds and limit returned after the grace period; got %s, %.2f/%.2f, usage %.2f",
+			withdrawal.Status, repo.wallet.Balance, repo.wallet.HeldBalance, limiter.usage())
+	}
+}
+
+func TestRequestWithdrawalHoldFailureDoesNotReleaseExistingHold(t *testing.T) {
+	repo := newFakeWithdrawalRepository(50000)
+	// Hold lain dengan reference yang sama sudah ada sebelum penarikan ini.
+	repo.wallet.HeldBalance = 30000
+	repo.holds["WD-REF-1"] = &balance.Hold{Reference: "WD-REF-1", Amount: 30000, Status: balance.HoldActive}
+	limiter := &fakeLimiter{daily: 1000000, used: make(map[string]float64)}
+	service := &withdrawalService{repo: repo, connector: &fakeConnector{}, limiter: limiter, screening: fakeScreening{}, fees: flatFee(2500), machine: testMachine, holdTTL: time.Hour, recheck: time.Minute}
+
+	if _, err := service.RequestWithdrawal(7, 1, 100000, "REF-1"); err == nil {
+		t.Fatalf("expected the hold to be rejected")
+	}
+	if repo.withdrawals["REF-1"].Status != StatusFailed || limiter.usage() != 0 {
+		t.Fatalf("expected FAILED with the limit returned; got %s, usage %.2f", repo.withdrawals["REF-1"].Status, limiter.usage())
+	}
+	if repo.holdStatus("WD-REF-1") != balance.HoldActive || repo.wallet.HeldBalance != 30000 || repo.wallet.Balance != 50000 {
+		t.Fatalf("expected a hold this withdrawal never placed to stay active; got %s, held %.2f", repo.holdStatus("WD-REF-1"), repo.wallet.HeldBalance)
+	}
+}